@@ -0,0 +1,211 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/echovault/echovault/internal/cli"
+	"github.com/echovault/echovault/internal/snapshot"
+	"io"
+	"log"
+	"net"
+	"os"
+	"path"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+func main() {
+	host := flag.String("h", "127.0.0.1", "Server hostname.")
+	port := flag.Uint("p", 7480, "Server port.")
+	password := flag.String("a", "", "Password used to authenticate the connection.")
+	user := flag.String("user", "", "Username used to authenticate the connection. Requires -a.")
+	useTLS := flag.Bool("tls", false, "Connect to the server over TLS.")
+	caCert := flag.String("cacert", "", "Path to the certificate authority used to verify the server certificate.")
+	cert := flag.String("cert", "", "Path to the client certificate used for mTLS.")
+	key := flag.String("key", "", "Path to the private key of the client certificate used for mTLS.")
+	insecure := flag.Bool("insecure", false, "Skip the verification of the server certificate.")
+	cluster := flag.Bool("c", false, "Follow the cluster leader when a follower rejects a command.")
+	raw := flag.Bool("raw", false, "Print replies without type labels, quotes or numbering.")
+	pipe := flag.Bool("pipe", false, `Read commands from stdin and send them to the server for bulk loading.
+The input is either RESP encoded commands or one command per line.`)
+	historyFile := flag.String("history-file", defaultHistoryFile(), `File that keeps the interactive command history.
+Defaults to ECHOVAULT_CLI_HISTFILE, or ~/.echovaultcli_history. When empty, the history is not saved.`)
+	snapshotFile := flag.String("snapshot", "", `Print the keys, types, sizes and TTLs of a snapshot instead of connecting to a server.
+The path is either a snapshot state file or a data directory, in which case the latest snapshot is read.`)
+	jsonOutput := flag.Bool("json", false, "Print the snapshot as JSON. Requires --snapshot.")
+	flag.Usage = func() {
+		_, _ = fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [options] [command [arg...]]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *snapshotFile != "" {
+		if err := printSnapshot(os.Stdout, *snapshotFile, *jsonOutput); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	var options []func(client *cli.Client)
+	if *password != "" {
+		options = append(options, cli.WithAuth(*user, *password))
+	}
+	if *cluster {
+		options = append(options, cli.WithRedirects(os.Stdout))
+	}
+	if *useTLS {
+		tlsConfig, err := loadTLSConfig(*host, *caCert, *cert, *key, *insecure)
+		if err != nil {
+			log.Fatal(err)
+		}
+		options = append(options, cli.WithTLS(tlsConfig))
+	}
+
+	client, err := cli.NewClient(net.JoinHostPort(*host, strconv.Itoa(int(*port))), options...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer func() {
+		_ = client.Close()
+	}()
+
+	if *pipe {
+		res, err := cli.Pipe(client, os.Stdin, os.Stderr)
+		fmt.Printf("All data transferred. errors: %d, replies: %d\n", res.Errors, res.Replies)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if res.Errors > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	history, err := cli.NewHistory(*historyFile)
+	if err != nil {
+		log.Printf("could not load history: %+v", err)
+		history, _ = cli.NewHistory("")
+	}
+
+	repl := &cli.REPL{
+		Client:  client,
+		History: history,
+		Raw:     *raw || !isTerminal(os.Stdout),
+		Prompt:  isTerminal(os.Stdin),
+	}
+
+	// Run the command in the arguments instead of starting the prompt.
+	if flag.NArg() > 0 {
+		if err = repl.Exec(flag.Args(), os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if err = repl.Run(os.Stdin, os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// printSnapshot writes the keys of the snapshot to w, one key per line, or as a JSON object.
+func printSnapshot(w io.Writer, file string, asJSON bool) error {
+	dump, err := snapshot.Inspect(file, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if asJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(dump)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(tw, "KEY\tTYPE\tSIZE\tLENGTH\tTTL\n")
+	for _, key := range dump.Keys {
+		ttl := strconv.FormatInt(key.TTL, 10)
+		if key.Expired {
+			ttl = "expired"
+		}
+		_, _ = fmt.Fprintf(tw, "%q\t%s\t%d\t%d\t%s\n", key.Key, key.Type, key.Size, key.Length, ttl)
+	}
+	if err = tw.Flush(); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%d keys in %s, taken at %s\n", len(dump.Keys), dump.File,
+		time.UnixMilli(dump.LatestSnapshotMilliseconds).Format(time.RFC3339))
+	return err
+}
+
+// defaultHistoryFile returns the history file from the ECHOVAULT_CLI_HISTFILE environment variable,
+// or ~/.echovaultcli_history if it's not set.
+func defaultHistoryFile() string {
+	if file, ok := os.LookupEnv("ECHOVAULT_CLI_HISTFILE"); ok {
+		return file
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return path.Join(home, ".echovaultcli_history")
+}
+
+func loadTLSConfig(host, caCert, cert, key string, insecure bool) (*tls.Config, error) {
+	config := &tls.Config{
+		ServerName:         host,
+		InsecureSkipVerify: insecure,
+	}
+
+	if caCert != "" {
+		b, err := os.ReadFile(caCert)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(b) {
+			return nil, fmt.Errorf("could not load certificate authority %s", caCert)
+		}
+		config.RootCAs = pool
+	}
+
+	if cert != "" || key != "" {
+		if cert == "" || key == "" {
+			return nil, errors.New("both cert and key must be provided for mTLS")
+		}
+		pair, err := tls.LoadX509KeyPair(cert, key)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{pair}
+	}
+
+	return config, nil
+}
+
+// isTerminal reports whether the file is a terminal rather than a pipe or a regular file.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
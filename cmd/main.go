@@ -23,6 +23,7 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 )
 
 func main() {
@@ -58,5 +59,10 @@ func main() {
 
 	<-cancelCh
 
-	server.ShutDown()
+	// Give the commands in progress a bounded time to complete.
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err = server.ShutDown(shutdownCtx); err != nil {
+		log.Println(err)
+	}
 }
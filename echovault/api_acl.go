@@ -80,6 +80,12 @@ type ACLLoadOptions struct {
 //
 // ExcludeChannels - []string - the list of PubSub channels the user cannot access ("Subscribe" and "Publish").
 // This field accepts glob pattern strings.
+//
+// RateLimit - int - the maximum number of commands per second the user can execute across all their connections.
+// The limit is left unchanged when 0.
+//
+// MaxConnections - int - the maximum number of connections that can be authenticated as the user at the same time.
+// The limit is left unchanged when 0.
 type User struct {
 	Username      string
 	Enabled       bool
@@ -107,6 +113,9 @@ type User struct {
 
 	IncludeChannels []string
 	ExcludeChannels []string
+
+	RateLimit      int
+	MaxConnections int
 }
 
 // ACLCat returns either the list of all categories or the list of commands within a specified category.
@@ -236,6 +245,14 @@ func (server *EchoVault) ACLSetUser(user User) (string, error) {
 		cmd = append(cmd, fmt.Sprintf("-&%s", channel))
 	}
 
+	if user.RateLimit > 0 {
+		cmd = append(cmd, fmt.Sprintf("ratelimit:%d", user.RateLimit))
+	}
+
+	if user.MaxConnections > 0 {
+		cmd = append(cmd, fmt.Sprintf("maxconns:%d", user.MaxConnections))
+	}
+
 	b, err := server.handleCommand(server.context, internal.EncodeCommand(cmd), nil, false, true)
 	if err != nil {
 		return "", err
@@ -259,10 +276,13 @@ func (server *EchoVault) ACLSetUser(user User) (string, error) {
 // "nokeys" if the user is not allowed to access any keys (and NoKeys is true),
 // "nopass" if the user has no passwords (and NoPass is true).
 //
+// "passwords" - string slice of the user's passwords. Plaintext passwords are returned as "><password>"
+// and SHA256 passwords as "#<hash>".
+//
 // "categories" - string slice af ACL command categories associated with the user.
-// If the user is allowed to access all categories, it will contain "+@*".
+// If the user is allowed to access all categories, it will contain "+@all".
 // For each category the user is allowed to access, the slice will contain "+@<category>".
-// If the user is not allowed to access any categories, it will contain "-@*".
+// If the user is not allowed to access any categories, it will contain "-@all".
 // For each category the user is not allowed to access, the slice will contain "-@<category>".
 //
 // "commands" - string slice af commands associated with the user.
@@ -310,7 +330,7 @@ func (server *EchoVault) ACLGetUser(username string) (map[string][]string, error
 		result[key] = make([]string, len(value))
 
 		for j := 0; j < len(value); j++ {
-			result[key][i] = value[i].String()
+			result[key][j] = value[j].String()
 		}
 	}
 
@@ -15,11 +15,19 @@
 package echovault
 
 import (
+	"bufio"
+	"bytes"
+	"context"
 	"fmt"
 	"github.com/echovault/echovault/internal"
+	"github.com/echovault/echovault/internal/trace"
 	"github.com/echovault/echovault/types"
+	"github.com/tidwall/resp"
+	"io"
 	"slices"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // CommandListOptions modifies the result from the CommandList command.
@@ -35,6 +43,39 @@ type CommandListOptions struct {
 	MODULE  string
 }
 
+// LatencyHistogram is the latency distribution of a command.
+//
+// Calls is the number of times the command has been executed.
+//
+// Buckets maps the upper bound of each non-empty latency bucket in microseconds to the cumulative number of calls
+// that completed within that bound. Bucket bounds are powers of 2.
+type LatencyHistogram struct {
+	Calls   int
+	Buckets map[int]int
+}
+
+// SlowLogEntry is a command recorded in the slow log.
+//
+// ID is a unique, increasing ID of the entry.
+//
+// Time is the time the command started executing.
+//
+// Duration is the time spent executing the command, with microsecond precision.
+//
+// Args are the command and its arguments. Commands with more than 32 arguments and arguments longer than 128 bytes
+// are truncated.
+//
+// ClientAddr and ClientName are the address and name of the client that sent the command.
+// They're empty for commands executed through the embedded API.
+type SlowLogEntry struct {
+	ID         int
+	Time       time.Time
+	Duration   time.Duration
+	Args       []string
+	ClientAddr string
+	ClientName string
+}
+
 // CommandOptions provides the specification of the command to be added to the EchoVault instance.
 //
 // Command is the keyword used to trigger this command (e.g. LPUSH, ZADD, ACL ...).
@@ -161,6 +202,385 @@ func (server *EchoVault) RewriteAOF() (string, error) {
 	return internal.ParseStringResponse(b)
 }
 
+// LatencyHistogram returns the latency distribution of the provided commands.
+//
+// Parameters:
+//
+// `commands` - ...string - The commands to return the latency distribution for. Sub-commands are specified
+// as "command|subcommand". If no commands are provided, the distribution of all executed commands is returned.
+//
+// Returns: A map of command name to its LatencyHistogram. Commands that have not been executed are omitted.
+func (server *EchoVault) LatencyHistogram(commands ...string) (map[string]LatencyHistogram, error) {
+	cmd := append([]string{"LATENCY", "HISTOGRAM"}, commands...)
+	b, err := server.handleCommand(server.context, internal.EncodeCommand(cmd), nil, false, true)
+	if err != nil {
+		return nil, err
+	}
+
+	r := resp.NewReader(bytes.NewReader(b))
+	v, _, err := r.ReadValue()
+	if err != nil {
+		return nil, err
+	}
+
+	arr := v.Array()
+	result := make(map[string]LatencyHistogram, len(arr)/2)
+
+	for i := 0; i < len(arr); i += 2 {
+		details := arr[i+1].Array()
+		histogram := LatencyHistogram{
+			Calls:   details[1].Integer(),
+			Buckets: make(map[int]int),
+		}
+		buckets := details[3].Array()
+		for j := 0; j < len(buckets); j += 2 {
+			histogram.Buckets[buckets[j].Integer()] = buckets[j+1].Integer()
+		}
+		result[arr[i].String()] = histogram
+	}
+
+	return result, nil
+}
+
+// SlowLogGet returns the most recent entries of the slow log, newest first.
+//
+// Parameters:
+//
+// `count` - int - The maximum number of entries to return. -1 returns all the entries.
+//
+// Errors:
+//
+// "count should be greater than or equal to -1" - If count is less than -1.
+func (server *EchoVault) SlowLogGet(count int) ([]SlowLogEntry, error) {
+	b, err := server.handleCommand(server.context,
+		internal.EncodeCommand([]string{"SLOWLOG", "GET", strconv.Itoa(count)}), nil, false, true)
+	if err != nil {
+		return nil, err
+	}
+
+	r := resp.NewReader(bytes.NewReader(b))
+	v, _, err := r.ReadValue()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]SlowLogEntry, 0, len(v.Array()))
+	for _, item := range v.Array() {
+		fields := item.Array()
+		entry := SlowLogEntry{
+			ID:         fields[0].Integer(),
+			Time:       time.Unix(int64(fields[1].Integer()), 0),
+			Duration:   time.Duration(fields[2].Integer()) * time.Microsecond,
+			Args:       make([]string, 0, len(fields[3].Array())),
+			ClientAddr: fields[4].String(),
+			ClientName: fields[5].String(),
+		}
+		for _, arg := range fields[3].Array() {
+			entry.Args = append(entry.Args, arg.String())
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// SlowLogLen returns the number of entries in the slow log.
+func (server *EchoVault) SlowLogLen() (int, error) {
+	b, err := server.handleCommand(server.context, internal.EncodeCommand([]string{"SLOWLOG", "LEN"}), nil, false, true)
+	if err != nil {
+		return 0, err
+	}
+	return internal.ParseIntegerResponse(b)
+}
+
+// SlowLogReset removes all the entries from the slow log.
+//
+// Returns: "OK" if the slow log was reset.
+func (server *EchoVault) SlowLogReset() (string, error) {
+	b, err := server.handleCommand(server.context, internal.EncodeCommand([]string{"SLOWLOG", "RESET"}), nil, false, true)
+	if err != nil {
+		return "", err
+	}
+	return internal.ParseStringResponse(b)
+}
+
+// WriteLatencyMetrics writes the latency of each executed command to w as Prometheus summaries
+// in the text exposition format. The output can be served from a metrics endpoint for scraping.
+func (server *EchoVault) WriteLatencyMetrics(w io.Writer) error {
+	return server.latencyRegistry.WritePrometheus(w)
+}
+
+// ConfigGet returns the values of the configuration parameters that match the provided glob patterns.
+// Only the parameters that can be updated at runtime are returned.
+//
+// Parameters:
+//
+// `patterns` - ...string - The glob patterns to match the parameter names against.
+//
+// Returns: A map of parameter name to its current value.
+func (server *EchoVault) ConfigGet(patterns ...string) (map[string]string, error) {
+	b, err := server.handleCommand(server.context, internal.EncodeCommand(append([]string{"CONFIG", "GET"}, patterns...)), nil, false, true)
+	if err != nil {
+		return nil, err
+	}
+
+	arr, err := internal.ParseStringArrayResponse(b)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]string, len(arr)/2)
+	for i := 0; i < len(arr); i += 2 {
+		result[arr[i]] = arr[i+1]
+	}
+
+	return result, nil
+}
+
+// ConfigSet updates a configuration parameter at runtime.
+// The supported parameters are "snapshot-threshold", "snapshot-interval" and "data-dir".
+// The snapshot and AOF engines pick up the new values without restarting.
+//
+// Parameters:
+//
+// `parameter` - string - The name of the parameter to update.
+//
+// `value` - string - The new value. snapshot-interval accepts a duration string such as "5m30s".
+//
+// Returns: "OK" if the parameter was updated.
+//
+// Errors:
+//
+// "unsupported config parameter <parameter>" - If the parameter cannot be updated at runtime.
+func (server *EchoVault) ConfigSet(parameter string, value string) (string, error) {
+	b, err := server.handleCommand(server.context, internal.EncodeCommand([]string{"CONFIG", "SET", parameter, value}), nil, false, true)
+	if err != nil {
+		return "", err
+	}
+	return internal.ParseStringResponse(b)
+}
+
+// ConfigResetStat resets the command statistics returned in the "commandstats" section of Info,
+// the counters returned in the "stats" section of Info, and the latency histograms returned by LatencyHistogram.
+//
+// Returns: "OK" if the statistics were reset.
+func (server *EchoVault) ConfigResetStat() (string, error) {
+	b, err := server.handleCommand(server.context, internal.EncodeCommand([]string{"CONFIG", "RESETSTAT"}), nil, false, true)
+	if err != nil {
+		return "", err
+	}
+	return internal.ParseStringResponse(b)
+}
+
+// MemoryStats returns the memory usage of the EchoVault instance.
+//
+// Returns: A map with the following fields:
+//
+// "total.allocated" - The number of bytes currently allocated on the heap.
+//
+// "keys.count" - The number of keys currently in the store.
+//
+// "keys.peak" - The highest number of keys in the store since the last keyspace compaction.
+//
+// "defrag.runs" - The number of keyspace compactions carried out.
+//
+// "defrag.released.keys" - The total number of deleted key entries released by keyspace compactions.
+//
+// "defrag.last.run" - Unix epoch in milliseconds of the last keyspace compaction. 0 if there was none.
+func (server *EchoVault) MemoryStats() (map[string]int, error) {
+	b, err := server.handleCommand(server.context, internal.EncodeCommand([]string{"MEMORY", "STATS"}), nil, false, true)
+	if err != nil {
+		return nil, err
+	}
+
+	r := resp.NewReader(bytes.NewReader(b))
+	v, _, err := r.ReadValue()
+	if err != nil {
+		return nil, err
+	}
+
+	arr := v.Array()
+	result := make(map[string]int, len(arr)/2)
+	for i := 0; i < len(arr); i += 2 {
+		result[arr[i].String()] = arr[i+1].Integer()
+	}
+
+	return result, nil
+}
+
+// Info returns information and statistics about the EchoVault instance.
+//
+// Parameters:
+//
+// `sections` - ...string - The sections to return. If no sections are provided, the default sections are returned.
+// The default sections are "server", "clients", "memory", "persistence", "stats", "replication", "selftest"
+// and "keyspace".
+// The "commandstats" section is only returned when it's requested, or when "all" is requested.
+//
+// Returns: A map of each returned section name (in lower case) to the fields in that section.
+// The "server" section has the following fields:
+//
+// "echovault_version" - The version of the server.
+//
+// "server_mode" - "standalone" or "cluster".
+//
+// "server_id" - The configured server ID.
+//
+// "process_id" - The process ID of the server.
+//
+// "tcp_port" - The port of the TCP listener.
+//
+// "uptime_in_seconds" and "uptime_in_days" - The time since the server was created.
+//
+// The "clients" section has the following fields:
+//
+// "connected_clients" - The number of clients connected to the TCP listeners.
+//
+// The "memory" section has the following fields:
+//
+// "used_memory" - The number of bytes allocated on the heap.
+//
+// "used_memory_sys" - The number of bytes obtained from the OS.
+//
+// "maxmemory" - The configured memory limit. 0 if there's no limit.
+//
+// "maxmemory_policy" - The eviction policy.
+//
+// The "persistence" section has the following fields:
+//
+// "rdb_last_save_time" - Unix epoch in seconds of the latest snapshot. 0 if there was none.
+//
+// "rdb_last_save_status" - "err" if the most recent snapshot attempt failed, otherwise "ok".
+//
+// "rdb_integrity_check_status" - "err" if the latest snapshot failed the checksum validation on startup, otherwise "ok".
+//
+// "aof_last_bgrewrite_status" - "err" if the most recent AOF rewrite failed, otherwise "ok".
+//
+// "aof_integrity_check_status" - "err" if the last command in the AOF log was incomplete on startup, otherwise "ok".
+//
+// "aof_queue_depth" - The number of write commands waiting to be written to the AOF log.
+//
+// "aof_rejected_writes" - The number of write commands rejected because the AOF queue was full.
+//
+// "write_stall_protection" - 1 while write commands are rejected because persistence has stalled, otherwise 0.
+//
+// The "stats" section has the following fields, which are set back to 0 by ConfigResetStat:
+//
+// "total_connections_received" - The number of client connections accepted.
+//
+// "total_commands_processed" - The number of commands executed.
+//
+// "instantaneous_ops_per_sec" - The number of commands executed per second, averaged over the last 1.6 seconds.
+//
+// "keyspace_hits" and "keyspace_misses" - The number of keys read by read commands that did and did not exist.
+//
+// "expired_keys" - The number of keys deleted because their expiry time had passed.
+//
+// "evicted_keys" - The number of keys deleted to bring the memory usage below the max memory limit.
+//
+// "total_accept_errors" - The number of failed attempts to accept a client connection on the TCP or unix socket listener.
+//
+// The "replication" section has the following fields:
+//
+// "role" - "master" in standalone mode or if this node is the raft leader, otherwise "slave".
+//
+// "connected_slaves" - The number of raft followers if this node is the leader, otherwise 0.
+//
+// "master_replid" - The replication ID. In cluster mode, it changes when a new leader is elected.
+//
+// "master_repl_offset" - The number of bytes of write commands processed in standalone mode,
+// or the raft applied index in cluster mode.
+//
+// "raft_term" - The current raft term. 0 in standalone mode.
+//
+// The "selftest" section has the "selftest_last_run" field with the unix time of the last DebugSelfTest, or 0 if
+// none has run. Once a self-test has run, the section also has the "selftest_fsync_avg_usec",
+// "selftest_fsync_max_usec", "selftest_memory_bandwidth_bytes_per_sec" and "selftest_lock_ops_per_sec" fields
+// with the measurements returned by DebugSelfTest.
+//
+// The "keyspace" section has a "db0" field with the value "keys=<keys>,expires=<expires>", where expires is the
+// number of keys with an expiry time. The field is omitted when the keyspace is empty.
+//
+// The "commandstats" section has a field for each command that has been executed since the last reset,
+// named "cmdstat_<command>". Sub-commands are named "cmdstat_<command>|<subcommand>".
+// The value is "calls=<calls>,usec=<usec>,usec_per_call=<usec_per_call>,failed_calls=<failed_calls>", where usec
+// is the total time spent executing the command in microseconds and failed_calls is the number of calls that
+// returned an error.
+func (server *EchoVault) Info(sections ...string) (map[string]map[string]string, error) {
+	b, err := server.handleCommand(server.context, internal.EncodeCommand(append([]string{"INFO"}, sections...)), nil, false, true)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := internal.ParseStringResponse(b)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]map[string]string)
+	var section map[string]string
+	for _, line := range strings.Split(res, "\r\n") {
+		if strings.HasPrefix(line, "# ") {
+			section = make(map[string]string)
+			result[strings.ToLower(strings.TrimPrefix(line, "# "))] = section
+			continue
+		}
+		if field, value, ok := strings.Cut(line, ":"); ok && section != nil {
+			section[field] = value
+		}
+	}
+
+	return result, nil
+}
+
+// DebugSelfTest measures the fsync latency of the data directory, the memory bandwidth and the lock throughput
+// of the host. The results are also reported in the "selftest" section of Info.
+//
+// Returns: A map of the measurements:
+//
+// "fsync.avg.usec" - The average latency of writing a 4KB block to a file in the data directory and syncing it.
+//
+// "fsync.max.usec" - The highest latency of writing and syncing a block.
+//
+// "memory.bandwidth.bytes.per.sec" - The number of bytes copied per second between two 64MB buffers.
+//
+// "lock.ops.per.sec" - The number of mutex lock/unlock pairs per second with one goroutine per CPU.
+//
+// Errors:
+//
+// "self-test already in progress" - If another self-test is running.
+func (server *EchoVault) DebugSelfTest() (map[string]int, error) {
+	b, err := server.handleCommand(server.context, internal.EncodeCommand([]string{"DEBUG", "SELFTEST"}), nil, false, true)
+	if err != nil {
+		return nil, err
+	}
+
+	r := resp.NewReader(bytes.NewReader(b))
+	v, _, err := r.ReadValue()
+	if err != nil {
+		return nil, err
+	}
+
+	arr := v.Array()
+	result := make(map[string]int, len(arr)/2)
+	for i := 0; i < len(arr); i += 2 {
+		result[arr[i].String()] = arr[i+1].Integer()
+	}
+
+	return result, nil
+}
+
+// DebugChangeReplicationID replaces the replication ID of this node with a new random one.
+//
+// Returns: "OK" if the replication ID was changed.
+func (server *EchoVault) DebugChangeReplicationID() (string, error) {
+	b, err := server.handleCommand(server.context, internal.EncodeCommand([]string{"DEBUG", "CHANGE-REPL-ID"}), nil, false, true)
+	if err != nil {
+		return "", err
+	}
+	return internal.ParseStringResponse(b)
+}
+
 // AddCommand adds a new command to EchoVault. The added command can be executed using the ExecuteCommand method.
 //
 // Parameters:
@@ -217,6 +637,9 @@ func (server *EchoVault) AddCommand(command CommandOptions) error {
 					CreateKeyAndLock: params.CreateKeyAndLock,
 					GetValue:         params.GetValue,
 					SetValue:         params.SetValue,
+					GetMetadata:      server.GetConnectionMetadata,
+					SetMetadata:      server.SetConnectionMetadata,
+					Replay:           params.Replay,
 				})
 			}),
 		})
@@ -288,6 +711,9 @@ func (server *EchoVault) AddCommand(command CommandOptions) error {
 					CreateKeyAndLock: params.CreateKeyAndLock,
 					GetValue:         params.GetValue,
 					SetValue:         params.SetValue,
+					GetMetadata:      server.GetConnectionMetadata,
+					SetMetadata:      server.SetConnectionMetadata,
+					Replay:           params.Replay,
 				})
 			}),
 		}
@@ -298,31 +724,165 @@ func (server *EchoVault) AddCommand(command CommandOptions) error {
 	return nil
 }
 
-// ExecuteCommand executes the command passed to it. If 1 string is passed, EchoVault will try to
-// execute the command. If 2 strings are passed, EchoVault will attempt to execute the subcommand of the command.
-// If more than 2 strings are provided, all additional strings will be ignored.
+// ReplyType is the RESP type of the reply returned by ExecuteCommand.
+type ReplyType string
+
+const (
+	ReplyString  ReplyType = "string"  // A simple or bulk string reply.
+	ReplyInteger ReplyType = "integer" // An integer reply.
+	ReplyArray   ReplyType = "array"   // An array reply.
+	ReplyNil     ReplyType = "nil"     // A null bulk string or null array reply.
+)
+
+// Reply is the parsed reply of a command executed with ExecuteCommand.
+//
+// Type - ReplyType - The RESP type of the reply.
+//
+// String - string - The string value of a string reply. For integer replies, this is the integer formatted as a string.
+//
+// Integer - int - The integer value of an integer reply. For string replies, this is the string parsed as an integer,
+// or 0 if the string is not an integer.
+//
+// Array - []Reply - The elements of an array reply.
+//
+// Raw - []byte - The raw RESP reply returned by the command handler.
+type Reply struct {
+	Type    ReplyType
+	String  string
+	Integer int
+	Array   []Reply
+	Raw     []byte
+}
+
+// Strings returns the string values of the elements of an array reply. Nil elements are returned as empty strings.
+func (reply Reply) Strings() []string {
+	strs := make([]string, len(reply.Array))
+	for i, element := range reply.Array {
+		strs[i] = element.String
+	}
+	return strs
+}
+
+// parseReply converts a RESP value into a Reply.
+func parseReply(v resp.Value) Reply {
+	if v.IsNull() {
+		return Reply{Type: ReplyNil}
+	}
+	switch v.Type() {
+	case resp.Integer:
+		return Reply{Type: ReplyInteger, String: v.String(), Integer: v.Integer()}
+	case resp.Array:
+		reply := Reply{Type: ReplyArray, Array: make([]Reply, len(v.Array()))}
+		for i, element := range v.Array() {
+			reply.Array[i] = parseReply(element)
+		}
+		return reply
+	default:
+		return Reply{Type: ReplyString, String: v.String(), Integer: v.Integer()}
+	}
+}
+
+// ExecuteCommand executes the command passed to it without a client connection. The first string is the command
+// and the following strings are its arguments. If the command has subcommands, the second string is the subcommand.
+//
+// The command runs in the server's context with its own connection ID, like a command sent by a client, so that it's
+// cancelled when the server shuts down. The deadline and cancellation of the context passed to ExecuteCommand are
+// carried over. If the context does not have a deadline and a command timeout is configured with WithCommandTimeout,
+// the command timeout is applied.
+//
+// If the context carries a traceparent set with ContextWithTraceparent, it is recorded with the command in the
+// trace file, and command handlers can read it from their context with TraceparentFromContext.
 //
-// This method returns the raw RESP response from the command handler. You will have to parse the RESP response if
-// you want to use the return value from the handler.
+// This method parses the RESP response from the command handler into a Reply.
 //
 // This method does not work with handlers that manipulate the client connection directly (i.e SUBSCRIBE, PSUBSCRIBE).
 // If you'd like to (p)subscribe or (p)unsubscribe, use the (P)SUBSCRIBE and (P)UNSUBSCRIBE methods instead.
 //
 // Parameters:
 //
-// `command` - ...string.
+// `ctx` - context.Context - The context of the command.
+//
+// `args` - ...string - The command and its arguments.
 //
-// Returns: []byte - Raw RESP response returned by the command handler.
+// Returns: Reply - The parsed response returned by the command handler.
 //
 // Errors:
 //
-// All errors from the command handler are forwarded to the caller. Other errors returned include:
+// All errors from the command handler are forwarded to the caller, including RESP error replies. Other errors returned include:
 //
 // "command <command> not supported" - If the command does not exist.
 //
 // "command <command> <subcommand> not supported" - If the command exists but the subcommand does not exist for that command.
-func (server *EchoVault) ExecuteCommand(command ...string) ([]byte, error) {
-	return server.handleCommand(server.context, internal.EncodeCommand(command), nil, false, true)
+func (server *EchoVault) ExecuteCommand(ctx context.Context, args ...string) (Reply, error) {
+	ctx, cancel := server.executeContext(ctx)
+	defer cancel()
+
+	b, err := server.handleCommand(ctx, internal.EncodeCommand(args), nil, false, true)
+	if err != nil {
+		return Reply{}, err
+	}
+
+	v, _, err := resp.NewReader(bytes.NewReader(b)).ReadValue()
+	if err != nil {
+		return Reply{}, err
+	}
+	if v.Type() == resp.Error {
+		return Reply{}, v.Error()
+	}
+
+	reply := parseReply(v)
+	reply.Raw = b
+	return reply, nil
+}
+
+// executeContext derives the context of a command run with ExecuteCommand from the server's context.
+// The context carries the server ID and a new connection ID like the context of a client connection, as well as
+// the caller's traceparent, and it's cancelled when the caller's context is done or its deadline passes.
+// The returned cancel function must always be called.
+func (server *EchoVault) executeContext(caller context.Context) (context.Context, context.CancelFunc) {
+	ctx := context.WithValue(server.context, internal.ContextServerID("ServerID"), server.config.ServerID)
+	ctx = context.WithValue(ctx, internal.ContextConnID("ConnectionID"),
+		fmt.Sprintf("%s-%d", ctx.Value(internal.ContextServerID("ServerID")), server.connId.Add(1)))
+	if traceparent := trace.Traceparent(caller); traceparent != "" {
+		// The traceparent has already been validated when it was added to the caller's context.
+		ctx, _ = trace.WithTraceparent(ctx, traceparent)
+	}
+
+	ctx, cancelCause := context.WithCancelCause(ctx)
+	cancel := func() { cancelCause(context.Canceled) }
+	if deadline, ok := caller.Deadline(); ok {
+		var cancelDeadline context.CancelFunc
+		ctx, cancelDeadline = context.WithDeadline(ctx, deadline)
+		cancel = func() {
+			cancelDeadline()
+			cancelCause(context.Canceled)
+		}
+	}
+
+	stop := context.AfterFunc(caller, func() {
+		cancelCause(context.Cause(caller))
+	})
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
+
+// ContextWithTraceparent returns a copy of the context that carries the W3C traceparent of the caller's span,
+// e.g. "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01". Pass the context to ExecuteCommand so that
+// the datastore operations of the command can be correlated with the caller's trace.
+//
+// Errors:
+//
+// "traceparent must be of the form version-traceid-parentid-flags" - If the traceparent is malformed.
+func ContextWithTraceparent(ctx context.Context, traceparent string) (context.Context, error) {
+	return trace.WithTraceparent(ctx, traceparent)
+}
+
+// TraceparentFromContext returns the traceparent carried by the context, or an empty string if there is none.
+// Custom command handlers can use it to propagate the caller's trace to their own operations.
+func TraceparentFromContext(ctx context.Context) string {
+	return trace.Traceparent(ctx)
 }
 
 // RemoveCommand removes the specified command or subcommand from EchoVault.
@@ -360,3 +920,116 @@ func (server *EchoVault) RemoveCommand(command ...string) {
 		}
 	}
 }
+
+// ExportOptions modifies the behaviour of Export.
+//
+// Match - string - Only export the keys that match the glob pattern. All the keys are exported when empty.
+type ExportOptions struct {
+	Match string
+}
+
+// ExportRecord is a line written by Export. Each line is the JSON encoding of an ExportRecord.
+//
+// Key - string - The key.
+//
+// Type - string - The type of the value as reported by the TYPE command.
+//
+// TTL - int64 - The remaining time to live of the key in milliseconds, or -1 if the key has no expiry.
+//
+// Value - interface{} - Strings are exported as strings, hashes as objects and lists as arrays. Sets are exported as
+// arrays of members in lexicographic order, sorted sets as arrays of {"member", "score"} objects ordered by score,
+// and streams as arrays of {"id", "fields"} entries.
+type ExportRecord struct {
+	Key   string      `json:"key"`
+	Type  string      `json:"type"`
+	TTL   int64       `json:"ttl"`
+	Value interface{} `json:"value"`
+}
+
+// Export writes the keys in the keyspace to w as lines of JSON, in lexicographic order of the keys.
+// Each line decodes into an ExportRecord. This is meant for backup tooling and for browsing the keyspace.
+//
+// Parameters:
+//
+// `w` - io.Writer - The writer that the lines are written to.
+//
+// `options` - ExportOptions.
+//
+// Returns: The number of keys exported.
+func (server *EchoVault) Export(w io.Writer, options ExportOptions) (int, error) {
+	cmd := []string{"EXPORT"}
+	if options.Match != "" {
+		cmd = append(cmd, "MATCH", options.Match)
+	}
+
+	b, err := server.handleCommand(server.context, internal.EncodeCommand(cmd), nil, false, true)
+	if err != nil {
+		return 0, err
+	}
+
+	lines, err := internal.ParseStringArrayResponse(b)
+	if err != nil {
+		return 0, err
+	}
+	for i, line := range lines {
+		if _, err = fmt.Fprintln(w, line); err != nil {
+			return i, err
+		}
+	}
+	return len(lines), nil
+}
+
+// ImportOptions modifies the behaviour of Import.
+//
+// Match - string - Only import the keys that match the glob pattern. All the keys are imported when empty.
+//
+// Replace - bool - Overwrite the keys that already exist. Without Replace, the import fails if any of the keys exists.
+type ImportOptions struct {
+	Match   string
+	Replace bool
+}
+
+// Import restores the keys from lines of JSON written by Export. Together with the Match option of Export, this
+// snapshots and restores the subset of the keyspace that matches a pattern, e.g. the keys of a single tenant.
+// Keys with a TTL are imported with the remaining TTL recorded in the line.
+//
+// Parameters:
+//
+// `r` - io.Reader - The reader that the lines are read from. Empty lines are skipped.
+//
+// `options` - ImportOptions.
+//
+// Returns: The number of keys imported.
+//
+// Errors:
+//
+// "key <key> already exists" - when Replace is false and one of the keys to import already exists.
+func (server *EchoVault) Import(r io.Reader, options ImportOptions) (int, error) {
+	cmd := []string{"IMPORT"}
+	if options.Replace {
+		cmd = append(cmd, "REPLACE")
+	}
+	if options.Match != "" {
+		cmd = append(cmd, "MATCH", options.Match)
+	}
+
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadString('\n')
+		if line = strings.TrimSpace(line); line != "" {
+			cmd = append(cmd, line)
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	b, err := server.handleCommand(server.context, internal.EncodeCommand(cmd), nil, false, true)
+	if err != nil {
+		return 0, err
+	}
+	return internal.ParseIntegerResponse(b)
+}
@@ -13,3 +13,248 @@
 // limitations under the License.
 
 package echovault
+
+import (
+	"bytes"
+	"context"
+	"github.com/echovault/echovault/internal"
+	"github.com/tidwall/resp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HelloResponse describes the server to client libraries.
+//
+// Server and Version are the name and version of the server.
+//
+// Proto is the protocol version used by the connection.
+//
+// Mode is "standalone" or "cluster".
+//
+// Role is "master", or "replica" if this node is a raft follower.
+//
+// Modules is the list of modules loaded on the server.
+type HelloResponse struct {
+	Server  string
+	Version string
+	Proto   int
+	Mode    string
+	Role    string
+	Modules []string
+}
+
+// SetConnectionMetadata attaches arbitrary metadata, such as a tenant ID or trace ID, to the TCP connection
+// that the context belongs to. The metadata is removed when the connection is closed.
+// Use this from custom command handlers with the context passed to the handler.
+//
+// Parameters:
+//
+// `ctx` - context.Context - The context of the connection.
+//
+// `key` - string - The metadata key.
+//
+// `value` - interface{} - The metadata value. If the value is nil, the key is removed.
+//
+// Errors:
+//
+// "client not found" - If the context does not belong to a connected TCP client.
+func (server *EchoVault) SetConnectionMetadata(ctx context.Context, key string, value interface{}) error {
+	return server.clientRegistry.SetMetadata(ctx, key, value)
+}
+
+// GetConnectionMetadata returns the metadata attached to the TCP connection that the context belongs to.
+//
+// Parameters:
+//
+// `ctx` - context.Context - The context of the connection.
+//
+// `key` - string - The metadata key.
+//
+// Returns: The value and true if the key is set, otherwise nil and false.
+func (server *EchoVault) GetConnectionMetadata(ctx context.Context, key string) (interface{}, bool) {
+	return server.clientRegistry.GetMetadata(ctx, key)
+}
+
+// ClientUnpause resumes processing of commands from all the TCP clients that were paused.
+//
+// Returns: "OK" once the clients have been unpaused.
+func (server *EchoVault) ClientUnpause() (string, error) {
+	b, err := server.handleCommand(server.context, internal.EncodeCommand([]string{"CLIENT", "UNPAUSE"}), nil, false, true)
+	if err != nil {
+		return "", err
+	}
+	return internal.ParseStringResponse(b)
+}
+
+// ClientInfo describes a connected TCP or unix socket client.
+//
+// ID is the connection ID, Addr is the remote address and Name is the name set with CLIENT SETNAME.
+//
+// Age is the time since the client connected and Idle is the time since its last command, both in whole seconds.
+//
+// Proto is the RESP protocol version, User is the ACL user the client is authenticated as, and LastCommand is the
+// name of the last command the client sent, as "command" or "command|subcommand".
+type ClientInfo struct {
+	ID          string
+	Addr        string
+	Name        string
+	Age         time.Duration
+	Idle        time.Duration
+	Proto       int
+	User        string
+	LastCommand string
+}
+
+// ClientList returns the connected TCP and unix socket clients, ordered by the time they connected.
+//
+// Parameters:
+//
+// `ids` - ...string - Only return the clients with these connection IDs. All the clients are returned if empty.
+func (server *EchoVault) ClientList(ids ...string) ([]ClientInfo, error) {
+	cmd := []string{"CLIENT", "LIST"}
+	if len(ids) > 0 {
+		cmd = append(append(cmd, "ID"), ids...)
+	}
+	b, err := server.handleCommand(server.context, internal.EncodeCommand(cmd), nil, false, true)
+	if err != nil {
+		return nil, err
+	}
+	res, err := internal.ParseStringResponse(b)
+	if err != nil {
+		return nil, err
+	}
+
+	var clients []ClientInfo
+	for _, line := range strings.Split(strings.TrimSpace(res), "\n") {
+		if line == "" {
+			continue
+		}
+		var client ClientInfo
+		for _, field := range strings.Split(line, " ") {
+			name, value, _ := strings.Cut(field, "=")
+			switch name {
+			case "id":
+				client.ID = value
+			case "addr":
+				client.Addr = value
+			case "name":
+				client.Name = value
+			case "age":
+				age, _ := strconv.Atoi(value)
+				client.Age = time.Duration(age) * time.Second
+			case "idle":
+				idle, _ := strconv.Atoi(value)
+				client.Idle = time.Duration(idle) * time.Second
+			case "resp":
+				client.Proto, _ = strconv.Atoi(value)
+			case "user":
+				client.User = value
+			case "cmd":
+				client.LastCommand = value
+			}
+		}
+		clients = append(clients, client)
+	}
+	return clients, nil
+}
+
+// ClientKillOptions selects the clients closed by ClientKill. A client is closed if it matches all the non-empty fields.
+//
+// ID - string - The connection ID of the client.
+//
+// Addr - string - The remote address of the client, as reported by ClientList.
+//
+// User - string - The ACL user the client is authenticated as.
+type ClientKillOptions struct {
+	ID   string
+	Addr string
+	User string
+}
+
+// ClientKill closes the connections of the clients that match the options.
+//
+// Returns: The number of clients that were closed.
+func (server *EchoVault) ClientKill(options ClientKillOptions) (int, error) {
+	cmd := []string{"CLIENT", "KILL"}
+	if options.ID != "" {
+		cmd = append(cmd, "ID", options.ID)
+	}
+	if options.Addr != "" {
+		cmd = append(cmd, "ADDR", options.Addr)
+	}
+	if options.User != "" {
+		cmd = append(cmd, "USER", options.User)
+	}
+	if len(cmd) == 2 {
+		// Without filters, every client is closed.
+		cmd = append(cmd, "SKIPME", "yes")
+	}
+	b, err := server.handleCommand(server.context, internal.EncodeCommand(cmd), nil, false, true)
+	if err != nil {
+		return 0, err
+	}
+	return internal.ParseIntegerResponse(b)
+}
+
+// ClientPause suspends processing of commands from all the TCP and unix socket clients for the timeout.
+// Commands executed through the embedded API are not suspended.
+//
+// Parameters:
+//
+// `timeout` - time.Duration - How long to suspend the clients for. Use ClientUnpause to resume them earlier.
+//
+// `writeOnly` - bool - When true, only write commands are suspended.
+//
+// Returns: "OK" once the clients have been paused.
+func (server *EchoVault) ClientPause(timeout time.Duration, writeOnly bool) (string, error) {
+	mode := "ALL"
+	if writeOnly {
+		mode = "WRITE"
+	}
+	b, err := server.handleCommand(server.context,
+		internal.EncodeCommand([]string{"CLIENT", "PAUSE", strconv.FormatInt(timeout.Milliseconds(), 10), mode}),
+		nil, false, true)
+	if err != nil {
+		return "", err
+	}
+	return internal.ParseStringResponse(b)
+}
+
+// Hello returns the server name, version, mode, role and loaded modules.
+//
+// Returns: A HelloResponse.
+func (server *EchoVault) Hello() (HelloResponse, error) {
+	b, err := server.handleCommand(server.context, internal.EncodeCommand([]string{"HELLO"}), nil, false, true)
+	if err != nil {
+		return HelloResponse{}, err
+	}
+
+	v, _, err := resp.NewReader(bytes.NewReader(b)).ReadValue()
+	if err != nil {
+		return HelloResponse{}, err
+	}
+	var res HelloResponse
+	fields := v.Array()
+	for i := 0; i+1 < len(fields); i += 2 {
+		value := fields[i+1]
+		switch fields[i].String() {
+		case "server":
+			res.Server = value.String()
+		case "version":
+			res.Version = value.String()
+		case "proto":
+			res.Proto = value.Integer()
+		case "mode":
+			res.Mode = value.String()
+		case "role":
+			res.Role = value.String()
+		case "modules":
+			res.Modules = make([]string, len(value.Array()))
+			for j, module := range value.Array() {
+				res.Modules[j] = module.String()
+			}
+		}
+	}
+	return res, nil
+}
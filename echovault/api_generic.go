@@ -15,8 +15,15 @@
 package echovault
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/echovault/echovault/internal"
+	"github.com/tidwall/resp"
 	"strconv"
+	"time"
 )
 
 // SetOptions modifies the behaviour for the Set command
@@ -38,14 +45,41 @@ import (
 //
 // PXAT - Expire at the exat time in unix milliseconds (positive integer).
 // PXAT has the least priority.
+//
+// KEEPTTL - Keep the expiry time of the key. Otherwise, setting the key removes its expiry time.
+// KEEPTTL is ignored if any of the expiry options is set.
 type SetOptions struct {
-	NX   bool
-	XX   bool
-	GET  bool
-	EX   int
-	PX   int
-	EXAT int
-	PXAT int
+	NX      bool
+	XX      bool
+	GET     bool
+	EX      int
+	PX      int
+	EXAT    int
+	PXAT    int
+	KEEPTTL bool
+}
+
+// GetExOptions modifies the expiry time of the key retrieved by GetEx.
+//
+// EX - Expire the key after the specified number of seconds (positive integer).
+// EX has the highest priority
+//
+// PX - Expire the key after the specified number of milliseconds (positive integer).
+// PX has the second-highest priority.
+//
+// EXAT - Expire at the exact time in unix seconds (positive integer).
+// EXAT has the third-highest priority.
+//
+// PXAT - Expire at the exat time in unix milliseconds (positive integer).
+// PXAT has the fourth-highest priority.
+//
+// PERSIST - Remove the expiry time of the key. PERSIST has the least priority.
+type GetExOptions struct {
+	EX      int
+	PX      int
+	EXAT    int
+	PXAT    int
+	PERSIST bool
 }
 
 // ExpireOptions modifies the behaviour of the Expire, PExpire, ExpireAt, PExpireAt.
@@ -57,6 +91,8 @@ type SetOptions struct {
 // GT - Only set the expiry time if the new expiry time is greater than the current one.
 //
 // LT - Only set the expiry time if the new expiry time is less than the current one.
+//
+// XX can be combined with GT or LT. Any other combination returns an error.
 type ExpireOptions struct {
 	NX bool
 	XX bool
@@ -67,6 +103,23 @@ type PExpireOptions ExpireOptions
 type ExpireAtOptions ExpireOptions
 type PExpireAtOptions ExpireOptions
 
+func expireFlags(options ExpireOptions) []string {
+	var flags []string
+	if options.NX {
+		flags = append(flags, "NX")
+	}
+	if options.XX {
+		flags = append(flags, "XX")
+	}
+	if options.GT {
+		flags = append(flags, "GT")
+	}
+	if options.LT {
+		flags = append(flags, "LT")
+	}
+	return flags
+}
+
 // Set creates or modifies the value at the given key.
 //
 // Parameters:
@@ -78,12 +131,8 @@ type PExpireAtOptions ExpireOptions
 // `options` - SetOptions.
 //
 // Returns: "OK" if the set is successful, If the "Get" flag in SetOptions is set to true, the previous value is returned.
-//
-// Errors:
-//
-// "key <key> does not exist"" - when the XX flag is set to true and the key does not exist.
-//
-// "key <key> does already exists" - when the NX flag is set to true and the key already exists.
+// If the key is not set because of the NX or XX flag, an empty string is returned, or the previous value if
+// the "Get" flag is set.
 func (server *EchoVault) Set(key, value string, options SetOptions) (string, error) {
 	cmd := []string{"SET", key, value}
 
@@ -103,6 +152,8 @@ func (server *EchoVault) Set(key, value string, options SetOptions) (string, err
 		cmd = append(cmd, []string{"EXAT", strconv.Itoa(options.EXAT)}...)
 	case options.PXAT != 0:
 		cmd = append(cmd, []string{"PXAT", strconv.Itoa(options.PXAT)}...)
+	case options.KEEPTTL:
+		cmd = append(cmd, "KEEPTTL")
 	}
 
 	if options.GET {
@@ -117,6 +168,46 @@ func (server *EchoVault) Set(key, value string, options SetOptions) (string, err
 	return internal.ParseStringResponse(b)
 }
 
+// SetEx sets the value at the given key and expires the key after the specified number of seconds.
+//
+// Parameters:
+//
+// `key` - string - the key to create or update.
+//
+// `value` - string - the value to place at the key.
+//
+// `seconds` - int - the number of seconds after which the key expires. Must be positive.
+//
+// Returns: "OK" if the set is successful.
+func (server *EchoVault) SetEx(key, value string, seconds int) (string, error) {
+	b, err := server.handleCommand(server.context,
+		internal.EncodeCommand([]string{"SETEX", key, strconv.Itoa(seconds), value}), nil, false, true)
+	if err != nil {
+		return "", err
+	}
+	return internal.ParseStringResponse(b)
+}
+
+// PSetEx sets the value at the given key and expires the key after the specified number of milliseconds.
+//
+// Parameters:
+//
+// `key` - string - the key to create or update.
+//
+// `value` - string - the value to place at the key.
+//
+// `milliseconds` - int - the number of milliseconds after which the key expires. Must be positive.
+//
+// Returns: "OK" if the set is successful.
+func (server *EchoVault) PSetEx(key, value string, milliseconds int) (string, error) {
+	b, err := server.handleCommand(server.context,
+		internal.EncodeCommand([]string{"PSETEX", key, strconv.Itoa(milliseconds), value}), nil, false, true)
+	if err != nil {
+		return "", err
+	}
+	return internal.ParseStringResponse(b)
+}
+
 // MSet set multiple values at multiple keys with one command. Existing keys are overwritten and non-existent
 // keys are created.
 //
@@ -128,7 +219,7 @@ func (server *EchoVault) Set(key, value string, options SetOptions) (string, err
 //
 // Errors:
 //
-// "key <key> does already exists" - when the NX flag is set to true and the key already exists.
+// "wrong number of arguments" - when kvPairs is empty.
 func (server *EchoVault) MSet(kvPairs map[string]string) (string, error) {
 	cmd := []string{"MSET"}
 
@@ -144,6 +235,28 @@ func (server *EchoVault) MSet(kvPairs map[string]string) (string, error) {
 	return internal.ParseStringResponse(b)
 }
 
+// MSetNX sets multiple values at multiple keys with one command, only if none of the keys exist.
+//
+// Parameters:
+//
+// `kvPairs` - map[string]string - a map representing all the keys and values to be set.
+//
+// Returns: true if all the keys were set, false if none of them were set because at least one key already exists.
+func (server *EchoVault) MSetNX(kvPairs map[string]string) (bool, error) {
+	cmd := []string{"MSETNX"}
+
+	for k, v := range kvPairs {
+		cmd = append(cmd, []string{k, v}...)
+	}
+
+	b, err := server.handleCommand(server.context, internal.EncodeCommand(cmd), nil, false, true)
+	if err != nil {
+		return false, err
+	}
+
+	return internal.ParseBooleanResponse(b)
+}
+
 // Get retrieves the value at the provided key.
 //
 // Parameters:
@@ -152,6 +265,10 @@ func (server *EchoVault) MSet(kvPairs map[string]string) (string, error) {
 //
 // Returns: A string representing the value at the specified key. If the value does not exist, an empty
 // string is returned.
+//
+// Errors:
+//
+// "value at key <key> is not a string" - when the value at the key is not a string or a number.
 func (server *EchoVault) Get(key string) (string, error) {
 	b, err := server.handleCommand(server.context, internal.EncodeCommand([]string{"GET", key}), nil, false, true)
 	if err != nil {
@@ -160,6 +277,55 @@ func (server *EchoVault) Get(key string) (string, error) {
 	return internal.ParseStringResponse(b)
 }
 
+// GetEx retrieves the value at the provided key and optionally updates the key's expiry time.
+//
+// Parameters:
+//
+// `key` - string - the key whose value should be retrieved.
+//
+// `options` - GetExOptions.
+//
+// Returns: A string representing the value at the specified key. If the value does not exist, an empty
+// string is returned.
+func (server *EchoVault) GetEx(key string, options GetExOptions) (string, error) {
+	cmd := []string{"GETEX", key}
+
+	switch {
+	case options.EX != 0:
+		cmd = append(cmd, []string{"EX", strconv.Itoa(options.EX)}...)
+	case options.PX != 0:
+		cmd = append(cmd, []string{"PX", strconv.Itoa(options.PX)}...)
+	case options.EXAT != 0:
+		cmd = append(cmd, []string{"EXAT", strconv.Itoa(options.EXAT)}...)
+	case options.PXAT != 0:
+		cmd = append(cmd, []string{"PXAT", strconv.Itoa(options.PXAT)}...)
+	case options.PERSIST:
+		cmd = append(cmd, "PERSIST")
+	}
+
+	b, err := server.handleCommand(server.context, internal.EncodeCommand(cmd), nil, false, true)
+	if err != nil {
+		return "", err
+	}
+	return internal.ParseStringResponse(b)
+}
+
+// GetDel retrieves the value at the provided key and deletes the key.
+//
+// Parameters:
+//
+// `key` - string - the key whose value should be retrieved.
+//
+// Returns: A string representing the value at the specified key. If the value does not exist, an empty
+// string is returned.
+func (server *EchoVault) GetDel(key string) (string, error) {
+	b, err := server.handleCommand(server.context, internal.EncodeCommand([]string{"GETDEL", key}), nil, false, true)
+	if err != nil {
+		return "", err
+	}
+	return internal.ParseStringResponse(b)
+}
+
 // MGet get multiple values from the list of provided keys. The index of each value corresponds to the index of its key
 // in the parameter slice. Values that do not exist will be an empty string.
 //
@@ -176,6 +342,118 @@ func (server *EchoVault) MGet(keys ...string) ([]string, error) {
 	return internal.ParseStringArrayResponse(b)
 }
 
+// getScalar sends GET for the key and returns the value as a string. Returns false if the key does not exist.
+func (server *EchoVault) getScalar(key string) (string, bool, error) {
+	b, err := server.handleCommand(server.context, internal.EncodeCommand([]string{"GET", key}), nil, false, true)
+	if err != nil {
+		return "", false, err
+	}
+
+	isNil, err := internal.ParseNilResponse(b)
+	if err != nil || isNil {
+		return "", false, err
+	}
+
+	value, err := internal.ParseStringResponse(b)
+	if err != nil {
+		return "", false, err
+	}
+
+	return value, true, nil
+}
+
+// GetInt retrieves the integer value at the provided key.
+//
+// Parameters:
+//
+// `key` - string - the key whose value should be retrieved.
+//
+// Returns: The integer at the key, and true if the key exists. If the key does not exist, 0 and false are returned.
+//
+// Errors:
+//
+// "value at key <key> is not an integer" - when the value at the key is not an integer.
+func (server *EchoVault) GetInt(key string) (int, bool, error) {
+	value, ok, err := server.getScalar(key)
+	if err != nil || !ok {
+		return 0, ok, err
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, true, fmt.Errorf("value at key %s is not an integer", key)
+	}
+	return i, true, nil
+}
+
+// GetFloat retrieves the numeric value at the provided key as a float64.
+//
+// Parameters:
+//
+// `key` - string - the key whose value should be retrieved.
+//
+// Returns: The float at the key, and true if the key exists. If the key does not exist, 0 and false are returned.
+//
+// Errors:
+//
+// "value at key <key> is not a float" - when the value at the key is not numeric.
+func (server *EchoVault) GetFloat(key string) (float64, bool, error) {
+	value, ok, err := server.getScalar(key)
+	if err != nil || !ok {
+		return 0, ok, err
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, true, fmt.Errorf("value at key %s is not a float", key)
+	}
+	return f, true, nil
+}
+
+// GetBytes retrieves the value at the provided key as a byte slice.
+// Numeric values are returned in the same format as Get.
+//
+// Parameters:
+//
+// `key` - string - the key whose value should be retrieved.
+//
+// Returns: The bytes at the key, and true if the key exists. If the key does not exist, nil and false are returned.
+//
+// Errors:
+//
+// "value at key <key> is not a string" - when the value at the key is not a string or a number.
+func (server *EchoVault) GetBytes(key string) ([]byte, bool, error) {
+	value, ok, err := server.getScalar(key)
+	if err != nil || !ok {
+		return nil, ok, err
+	}
+	return []byte(value), true, nil
+}
+
+// GetJSON decodes the JSON string at the provided key into v.
+//
+// Parameters:
+//
+// `key` - string - the key whose value should be retrieved.
+//
+// `v` - interface{} - a pointer to the value that the JSON is decoded into.
+//
+// Returns: true if the key exists. If the key does not exist, v is not modified and false is returned.
+//
+// Errors:
+//
+// "value at key <key> is not a string" - when the value at the key is not a string or a number.
+//
+// "value at key <key> is not valid JSON" - when the value at the key cannot be decoded into v.
+func (server *EchoVault) GetJSON(key string, v interface{}) (bool, error) {
+	b, ok, err := server.GetBytes(key)
+	if err != nil || !ok {
+		return ok, err
+	}
+	if err = json.Unmarshal(b, v); err != nil {
+		return true, fmt.Errorf("value at key %s is not valid JSON: %+v", key, err)
+	}
+	return true, nil
+}
+
 // Del removes the given keys from the store.
 //
 // Parameters:
@@ -191,6 +469,384 @@ func (server *EchoVault) Del(keys ...string) (int, error) {
 	return internal.ParseIntegerResponse(b)
 }
 
+// DeleteMany removes the given keys from the store like Del, but sends them in batches of at most 1024 keys
+// so that deleting a large number of keys does not log or replicate a single oversized command.
+// Each key is deleted atomically on its own, so the keys deleted before an error remain deleted.
+//
+// Parameters:
+//
+// `keys` - []string - the keys to delete from the store.
+//
+// Returns: The number of keys that were deleted. If an error occurs, the number of keys deleted before the error
+// is returned with it.
+func (server *EchoVault) DeleteMany(keys []string) (int, error) {
+	deleted := 0
+	for start := 0; start < len(keys); start += 1024 {
+		count, err := server.Del(keys[start:min(start+1024, len(keys))]...)
+		if err != nil {
+			return deleted, err
+		}
+		deleted += count
+	}
+	return deleted, nil
+}
+
+// Exists returns the number of the given keys that exist in the store.
+//
+// Parameters:
+//
+// `keys` - []string - the keys to check. A key that is provided multiple times is counted multiple times.
+//
+// Returns: The number of keys that exist.
+func (server *EchoVault) Exists(keys ...string) (int, error) {
+	b, err := server.handleCommand(server.context, internal.EncodeCommand(append([]string{"EXISTS"}, keys...)), nil, false, true)
+	if err != nil {
+		return 0, err
+	}
+	return internal.ParseIntegerResponse(b)
+}
+
+// Type returns the type of the value stored at the key.
+//
+// Parameters:
+//
+// `key` - string.
+//
+// Returns: One of "string", "list", "set", "zset", "hash" or "stream". "none" is returned if the key does not exist.
+func (server *EchoVault) Type(key string) (string, error) {
+	b, err := server.handleCommand(server.context, internal.EncodeCommand([]string{"TYPE", key}), nil, false, true)
+	if err != nil {
+		return "", err
+	}
+	return internal.ParseStringResponse(b)
+}
+
+// Types returns the types of the values stored at the keys, in the order of the keys.
+//
+// Parameters:
+//
+// `keys` - ...string - the keys to check.
+//
+// Returns: A []string where each entry is one of "string", "list", "set", "zset", "hash", "stream",
+// or "none" if the key does not exist.
+func (server *EchoVault) Types(keys ...string) ([]string, error) {
+	if len(keys) == 1 {
+		t, err := server.Type(keys[0])
+		if err != nil {
+			return nil, err
+		}
+		return []string{t}, nil
+	}
+	b, err := server.handleCommand(server.context, internal.EncodeCommand(append([]string{"TYPE"}, keys...)), nil, false, true)
+	if err != nil {
+		return nil, err
+	}
+	return internal.ParseStringArrayResponse(b)
+}
+
+// Keys returns all the keys that match the glob-style pattern.
+// This iterates the entire keyspace, so Scan should be preferred to iterate large keyspaces.
+//
+// Parameters:
+//
+// `pattern` - string - The glob-style pattern. "*" matches all the keys.
+//
+// Returns: A string slice of the matching keys.
+func (server *EchoVault) Keys(pattern string) ([]string, error) {
+	b, err := server.handleCommand(server.context, internal.EncodeCommand([]string{"KEYS", pattern}), nil, false, true)
+	if err != nil {
+		return nil, err
+	}
+	return internal.ParseStringArrayResponse(b)
+}
+
+// ScanOptions modifies the behaviour of Scan.
+//
+// Match - Only return the keys that match the glob-style pattern.
+//
+// Count - The number of keys to visit in each call. Defaults to 10.
+//
+// Type - Only return the keys that hold values of the type (e.g. "string", "list", "set", "zset", "hash" or "stream").
+type ScanOptions struct {
+	Match string
+	Count uint
+	Type  string
+}
+
+// scanCommand appends the options of the SCAN family of commands to the command.
+func scanCommand(cmd []string, cursor uint64, match string, count uint) []string {
+	cmd = append(cmd, strconv.FormatUint(cursor, 10))
+	if match != "" {
+		cmd = append(cmd, "MATCH", match)
+	}
+	if count > 0 {
+		cmd = append(cmd, "COUNT", strconv.FormatUint(uint64(count), 10))
+	}
+	return cmd
+}
+
+// parseScanResponse parses the reply of the SCAN family of commands into the next cursor and the elements.
+func parseScanResponse(b []byte) (uint64, []string, error) {
+	v, _, err := resp.NewReader(bytes.NewReader(b)).ReadValue()
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(v.Array()) != 2 {
+		return 0, nil, errors.New("invalid scan response")
+	}
+	cursor, err := strconv.ParseUint(v.Array()[0].String(), 10, 64)
+	if err != nil {
+		return 0, nil, err
+	}
+	elems := make([]string, len(v.Array()[1].Array()))
+	for i, elem := range v.Array()[1].Array() {
+		elems[i] = elem.String()
+	}
+	return cursor, elems, nil
+}
+
+// Scan incrementally iterates the keyspace. Start the iteration with a cursor of 0 and pass the returned cursor
+// to the next call. The iteration is complete when the returned cursor is 0.
+// Keys that exist for the entire iteration are returned exactly once.
+//
+// Parameters:
+//
+// `cursor` - uint64 - The cursor returned by the previous call, or 0 to start the iteration.
+//
+// `options` - ScanOptions.
+//
+// Returns: The cursor to pass to the next call and a batch of keys.
+func (server *EchoVault) Scan(cursor uint64, options ScanOptions) (uint64, []string, error) {
+	cmd := scanCommand([]string{"SCAN"}, cursor, options.Match, options.Count)
+	if options.Type != "" {
+		cmd = append(cmd, "TYPE", options.Type)
+	}
+	b, err := server.handleCommand(server.context, internal.EncodeCommand(cmd), nil, false, true)
+	if err != nil {
+		return 0, nil, err
+	}
+	return parseScanResponse(b)
+}
+
+// Rename renames the key to newKey, keeping its value and expiry time. If newKey already exists, it is overwritten.
+//
+// Parameters:
+//
+// `key` - string - the key to rename.
+//
+// `newKey` - string - the new name of the key.
+//
+// Returns: "OK" if the key is renamed.
+//
+// Errors:
+//
+// "no such key" - when the key does not exist.
+func (server *EchoVault) Rename(key, newKey string) (string, error) {
+	b, err := server.handleCommand(server.context, internal.EncodeCommand([]string{"RENAME", key, newKey}), nil, false, true)
+	if err != nil {
+		return "", err
+	}
+	return internal.ParseStringResponse(b)
+}
+
+// RenameNX renames the key to newKey only if newKey does not exist.
+//
+// Parameters:
+//
+// `key` - string - the key to rename.
+//
+// `newKey` - string - the new name of the key.
+//
+// Returns: true if the key is renamed, false if newKey already exists.
+//
+// Errors:
+//
+// "no such key" - when the key does not exist.
+func (server *EchoVault) RenameNX(key, newKey string) (bool, error) {
+	b, err := server.handleCommand(server.context, internal.EncodeCommand([]string{"RENAMENX", key, newKey}), nil, false, true)
+	if err != nil {
+		return false, err
+	}
+	return internal.ParseBooleanResponse(b)
+}
+
+// CopyOptions modifies the behaviour of Copy.
+//
+// Replace - Overwrite the destination key if it already exists.
+type CopyOptions struct {
+	Replace bool
+}
+
+// Copy copies the value and expiry time of the source key to the destination key.
+//
+// Parameters:
+//
+// `source` - string - the key to copy.
+//
+// `destination` - string - the key to copy the value to.
+//
+// `options` - CopyOptions.
+//
+// Returns: true if the value is copied. false if the source does not exist, or if the destination
+// already exists and Replace is not set.
+//
+// Errors:
+//
+// "source and destination objects are the same" - when the source and destination are the same key.
+func (server *EchoVault) Copy(source, destination string, options CopyOptions) (bool, error) {
+	cmd := []string{"COPY", source, destination}
+	if options.Replace {
+		cmd = append(cmd, "REPLACE")
+	}
+	b, err := server.handleCommand(server.context, internal.EncodeCommand(cmd), nil, false, true)
+	if err != nil {
+		return false, err
+	}
+	return internal.ParseBooleanResponse(b)
+}
+
+// SortOptions modifies the behaviour of Sort, SortRO and SortStore.
+//
+// By - Sort by the values of the keys that the pattern gives for each element, replacing the first "*" in the
+// pattern with the element. A pattern ending in "->field" uses the field of the hash at the key.
+// If the pattern has no "*", the elements are not sorted.
+//
+// Offset - The number of elements to skip.
+//
+// Count - The maximum number of elements to return. The LIMIT clause is only sent when Count is not 0.
+//
+// Get - Return the values of the keys that the patterns give for each element instead of the element.
+// The pattern "#" returns the element itself.
+//
+// Desc - Sort in descending order.
+//
+// Alpha - Sort lexicographically instead of numerically.
+type SortOptions struct {
+	By     string
+	Offset uint
+	Count  uint
+	Get    []string
+	Desc   bool
+	Alpha  bool
+}
+
+// sortCommand appends the options of the SORT family of commands to the command.
+func sortCommand(cmd []string, options SortOptions) []string {
+	if options.By != "" {
+		cmd = append(cmd, "BY", options.By)
+	}
+	if options.Count != 0 {
+		cmd = append(cmd, "LIMIT", strconv.FormatUint(uint64(options.Offset), 10), strconv.FormatUint(uint64(options.Count), 10))
+	}
+	for _, pattern := range options.Get {
+		cmd = append(cmd, "GET", pattern)
+	}
+	if options.Desc {
+		cmd = append(cmd, "DESC")
+	}
+	if options.Alpha {
+		cmd = append(cmd, "ALPHA")
+	}
+	return cmd
+}
+
+// Sort returns the elements of the list, set or sorted set at the key, sorted numerically in ascending order.
+//
+// Parameters:
+//
+// `key` - string - the key of the list, set or sorted set.
+//
+// `options` - SortOptions.
+//
+// Returns: the sorted elements, or the values of the Get patterns for each element.
+// A pattern that has no value for an element is an empty string.
+//
+// Errors:
+//
+// "value at <key> is not a list, set or sorted set" - when the key holds another type.
+//
+// "one or more scores can't be converted into double" - when sorting numerically and an element or weight is not a number.
+func (server *EchoVault) Sort(key string, options SortOptions) ([]string, error) {
+	b, err := server.handleCommand(server.context, internal.EncodeCommand(sortCommand([]string{"SORT", key}, options)), nil, false, true)
+	if err != nil {
+		return nil, err
+	}
+	return internal.ParseStringArrayResponse(b)
+}
+
+// SortRO is the read-only variant of Sort. It returns the same elements as Sort.
+func (server *EchoVault) SortRO(key string, options SortOptions) ([]string, error) {
+	b, err := server.handleCommand(server.context, internal.EncodeCommand(sortCommand([]string{"SORT_RO", key}, options)), nil, false, true)
+	if err != nil {
+		return nil, err
+	}
+	return internal.ParseStringArrayResponse(b)
+}
+
+// SortStore sorts the elements like Sort and stores the result as a list at the destination key,
+// replacing its value. The destination is deleted if the result is empty.
+//
+// Parameters:
+//
+// `key` - string - the key of the list, set or sorted set.
+//
+// `destination` - string - the key to store the result at.
+//
+// `options` - SortOptions.
+//
+// Returns: the length of the stored list.
+func (server *EchoVault) SortStore(key, destination string, options SortOptions) (int, error) {
+	cmd := append(sortCommand([]string{"SORT", key}, options), "STORE", destination)
+	b, err := server.handleCommand(server.context, internal.EncodeCommand(cmd), nil, false, true)
+	if err != nil {
+		return 0, err
+	}
+	return internal.ParseIntegerResponse(b)
+}
+
+// ExpiredKey is passed to the callbacks registered with OnExpire.
+type ExpiredKey struct {
+	Key      string
+	Value    interface{} // The value that the key held when it was deleted.
+	ExpireAt time.Time   // The expiry time of the key.
+}
+
+// ExpireCallback is called with each key that expires.
+type ExpireCallback func(key ExpiredKey)
+
+// OnExpire registers a callback that is called with the value of each key at the moment it's deleted
+// because it expired. Keys that are deleted with Del or overwritten are not reported.
+//
+// Callbacks are best-effort: each one is called in its own goroutine, so the callbacks for different keys
+// can run in any order, and keys that expire while the server is stopped are not reported.
+// In a raft cluster, the callbacks are only called on the leader.
+//
+// The value is a string, int or float64 for string values, map[string]interface{} for hashes and
+// []interface{} for lists. The values of other types hold internal representations.
+//
+// Parameters:
+//
+// `tag` - string - The tag that identifies the callback. Registering a callback with an existing tag replaces it.
+//
+// `callback` - ExpireCallback - The function called with each expired key.
+func (server *EchoVault) OnExpire(tag string, callback ExpireCallback) {
+	server.expireCallbacks.rwMutex.Lock()
+	defer server.expireCallbacks.rwMutex.Unlock()
+	server.expireCallbacks.callbacks[tag] = callback
+}
+
+// RemoveExpireCallback removes the callback registered with OnExpire with the tag.
+// Has no effect if there's no callback with the tag.
+//
+// Parameters:
+//
+// `tag` - string - The tag of the callback to remove.
+func (server *EchoVault) RemoveExpireCallback(tag string) {
+	server.expireCallbacks.rwMutex.Lock()
+	defer server.expireCallbacks.rwMutex.Unlock()
+	delete(server.expireCallbacks.callbacks, tag)
+}
+
 // Persist removes the expiry associated with a key and makes it permanent.
 // Has no effect on a key that is already persistent.
 //
@@ -267,6 +923,102 @@ func (server *EchoVault) PTTL(key string) (int, error) {
 	return internal.ParseIntegerResponse(b)
 }
 
+// ObjectRefCount returns the number of references to the value stored at the key.
+// Values are not shared between keys, so the reference count of an existing key is always 1.
+//
+// Parameters:
+//
+// `key` - string.
+//
+// Returns: 1 if the key exists, 0 if the key does not exist.
+func (server *EchoVault) ObjectRefCount(key string) (int, error) {
+	b, err := server.handleCommand(server.context, internal.EncodeCommand([]string{"OBJECT", "REFCOUNT", key}), nil, false, true)
+	if err != nil {
+		return 0, err
+	}
+	return internal.ParseIntegerResponse(b)
+}
+
+// ObjectVersion returns the version of the key.
+// The version is replaced with a higher version every time the key is written.
+//
+// Parameters:
+//
+// `key` - string.
+//
+// Returns: The version of the key, or 0 if the key does not exist.
+func (server *EchoVault) ObjectVersion(key string) (uint64, error) {
+	b, err := server.handleCommand(server.context, internal.EncodeCommand([]string{"OBJECT", "VERSION", key}), nil, false, true)
+	if err != nil {
+		return 0, err
+	}
+	version, err := internal.ParseIntegerResponse(b)
+	return uint64(version), err
+}
+
+// GetWithVersion retrieves the value at the provided key together with the version of the key.
+// The version is replaced with a higher version every time the key is written, so it can be passed to
+// SetIfVersion to only update the key if it has not been written since it was read.
+//
+// Parameters:
+//
+// `key` - string - the key whose value should be retrieved.
+//
+// Returns: The value at the key and the version of the key. If the key does not exist, an empty string
+// and version 0 are returned.
+func (server *EchoVault) GetWithVersion(key string) (string, uint64, error) {
+	var version uint64
+	b, err := server.executeExclusive(server.context, func(ctx context.Context) ([]byte, error) {
+		b, err := server.handleCommand(ctx, internal.EncodeCommand([]string{"GET", key}), nil, false, true)
+		version = server.getKeyVersion(key)
+		return b, err
+	})
+	if err != nil {
+		return "", 0, err
+	}
+	value, err := internal.ParseStringResponse(b)
+	if err != nil {
+		return "", 0, err
+	}
+	return value, version, nil
+}
+
+// SetIfVersion sets the value at the key only if the version of the key is still the provided version.
+// This allows compare-and-set updates without a transaction. Pass version 0 to only set the key if it does not exist.
+//
+// Parameters:
+//
+// `key` - string - the key to update.
+//
+// `value` - string - the value to place at the key.
+//
+// `version` - uint64 - the version returned by GetWithVersion.
+//
+// Returns: true if the value was set, false if the key has been written since the version was read.
+//
+// Errors:
+//
+// "conditional set is not supported in cluster mode" - when the server is in a raft cluster.
+func (server *EchoVault) SetIfVersion(key, value string, version uint64) (bool, error) {
+	if server.isInCluster() {
+		return false, errors.New("conditional set is not supported in cluster mode")
+	}
+	var set bool
+	_, err := server.executeExclusive(server.context, func(ctx context.Context) ([]byte, error) {
+		// Delete the key if it has expired, so that its version is reset.
+		server.KeyExists(ctx, key)
+		if server.getKeyVersion(key) != version {
+			return nil, nil
+		}
+		set = true
+		return server.handleCommand(ctx, internal.EncodeCommand([]string{"SET", key, value}), nil, false, true)
+	})
+	if err != nil {
+		return false, err
+	}
+	return set, nil
+}
+
 // Expire set the given key's expiry in seconds from now.
 // This command turns a persistent key into a volatile one.
 //
@@ -282,16 +1034,7 @@ func (server *EchoVault) PTTL(key string) (int, error) {
 func (server *EchoVault) Expire(key string, seconds int, options ExpireOptions) (int, error) {
 	cmd := []string{"EXPIRE", key, strconv.Itoa(seconds)}
 
-	switch {
-	case options.NX:
-		cmd = append(cmd, "NX")
-	case options.XX:
-		cmd = append(cmd, "XX")
-	case options.LT:
-		cmd = append(cmd, "LT")
-	case options.GT:
-		cmd = append(cmd, "GT")
-	}
+	cmd = append(cmd, expireFlags(options)...)
 
 	b, err := server.handleCommand(server.context, internal.EncodeCommand(cmd), nil, false, true)
 	if err != nil {
@@ -316,16 +1059,7 @@ func (server *EchoVault) Expire(key string, seconds int, options ExpireOptions)
 func (server *EchoVault) PExpire(key string, milliseconds int, options PExpireOptions) (int, error) {
 	cmd := []string{"PEXPIRE", key, strconv.Itoa(milliseconds)}
 
-	switch {
-	case options.NX:
-		cmd = append(cmd, "NX")
-	case options.XX:
-		cmd = append(cmd, "XX")
-	case options.LT:
-		cmd = append(cmd, "LT")
-	case options.GT:
-		cmd = append(cmd, "GT")
-	}
+	cmd = append(cmd, expireFlags(ExpireOptions(options))...)
 
 	b, err := server.handleCommand(server.context, internal.EncodeCommand(cmd), nil, false, true)
 	if err != nil {
@@ -350,16 +1084,7 @@ func (server *EchoVault) PExpire(key string, milliseconds int, options PExpireOp
 func (server *EchoVault) ExpireAt(key string, unixSeconds int, options ExpireAtOptions) (int, error) {
 	cmd := []string{"EXPIREAT", key, strconv.Itoa(unixSeconds)}
 
-	switch {
-	case options.NX:
-		cmd = append(cmd, "NX")
-	case options.XX:
-		cmd = append(cmd, "XX")
-	case options.LT:
-		cmd = append(cmd, "LT")
-	case options.GT:
-		cmd = append(cmd, "GT")
-	}
+	cmd = append(cmd, expireFlags(ExpireOptions(options))...)
 
 	b, err := server.handleCommand(server.context, internal.EncodeCommand(cmd), nil, false, true)
 	if err != nil {
@@ -384,16 +1109,7 @@ func (server *EchoVault) ExpireAt(key string, unixSeconds int, options ExpireAtO
 func (server *EchoVault) PExpireAt(key string, unixMilliseconds int, options PExpireAtOptions) (int, error) {
 	cmd := []string{"PEXPIREAT", key, strconv.Itoa(unixMilliseconds)}
 
-	switch {
-	case options.NX:
-		cmd = append(cmd, "NX")
-	case options.XX:
-		cmd = append(cmd, "XX")
-	case options.LT:
-		cmd = append(cmd, "LT")
-	case options.GT:
-		cmd = append(cmd, "GT")
-	}
+	cmd = append(cmd, expireFlags(ExpireOptions(options))...)
 
 	b, err := server.handleCommand(server.context, internal.EncodeCommand(cmd), nil, false, true)
 	if err != nil {
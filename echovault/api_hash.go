@@ -257,6 +257,49 @@ func (server *EchoVault) HGetAll(key string) ([]string, error) {
 	return internal.ParseStringArrayResponse(b)
 }
 
+// HScanOptions modifies the behaviour of HScan.
+//
+// Match - Only return the fields that match the glob-style pattern.
+//
+// Count - The number of fields to visit in each call. Defaults to 10.
+type HScanOptions struct {
+	Match string
+	Count uint
+}
+
+// HScan incrementally iterates the fields of a hash map. Start the iteration with a cursor of 0 and pass the
+// returned cursor to the next call. The iteration is complete when the returned cursor is 0.
+//
+// Parameters:
+//
+// `key` - string - the key to the hash map.
+//
+// `cursor` - uint64 - the cursor returned by the previous call, or 0 to start the iteration.
+//
+// `options` - HScanOptions.
+//
+// Returns: the cursor to pass to the next call and a map of a batch of fields to their values.
+//
+// Errors:
+//
+// "value at <key> is not a hash" - when the provided key exists but is not a hash.
+func (server *EchoVault) HScan(key string, cursor uint64, options HScanOptions) (uint64, map[string]string, error) {
+	cmd := scanCommand([]string{"HSCAN", key}, cursor, options.Match, options.Count)
+	b, err := server.handleCommand(server.context, internal.EncodeCommand(cmd), nil, false, true)
+	if err != nil {
+		return 0, nil, err
+	}
+	next, elems, err := parseScanResponse(b)
+	if err != nil {
+		return 0, nil, err
+	}
+	fields := make(map[string]string, len(elems)/2)
+	for i := 0; i+1 < len(elems); i += 2 {
+		fields[elems[i]] = elems[i+1]
+	}
+	return next, fields, nil
+}
+
 // HExists checks if a field exists in a hash map.
 //
 // Parameters:
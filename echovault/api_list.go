@@ -15,11 +15,26 @@
 package echovault
 
 import (
+	"bytes"
 	"fmt"
 	"github.com/echovault/echovault/internal"
+	"github.com/tidwall/resp"
 	"strconv"
 )
 
+// LMPopOptions allows you to modify the result of the LMPop command.
+//
+// Left instructs EchoVault to pop elements from the start of the list. Left is higher priority than Right.
+//
+// Right instructs EchoVault to pop elements from the end of the list.
+//
+// Count specifies the maximum number of elements to pop.
+type LMPopOptions struct {
+	Left  bool
+	Right bool
+	Count uint
+}
+
 // LLen returns the length of the list.
 //
 // Parameters:
@@ -149,6 +164,83 @@ func (server *EchoVault) LRem(key string, count int, value string) (string, erro
 	return internal.ParseStringResponse(b)
 }
 
+// LInsert inserts the element in the list before or after the first occurrence of the pivot element.
+//
+// Parameters:
+//
+// `key` - string - the key to the list.
+//
+// `position` - string - "BEFORE" or "AFTER" the pivot.
+//
+// `pivot` - string - the element to insert before or after.
+//
+// `element` - string - the element to insert.
+//
+// Returns: The length of the list after the insert. -1 if the pivot was not found. 0 if the key does not exist.
+//
+// Errors:
+//
+// "LINSERT command on non-list item" - when the provided key exists but is not a list.
+//
+// "position must be BEFORE or AFTER" - when the position is not BEFORE or AFTER.
+func (server *EchoVault) LInsert(key string, position string, pivot string, element string) (int, error) {
+	b, err := server.handleCommand(server.context, internal.EncodeCommand([]string{"LINSERT", key, position, pivot, element}), nil, false, true)
+	if err != nil {
+		return 0, err
+	}
+	return internal.ParseIntegerResponse(b)
+}
+
+// BLPop removes and returns the first element of the first non-empty list.
+// If all the lists are empty, BLPop blocks until an element is pushed to one of the lists or the timeout expires.
+// BLPop is only supported in standalone mode.
+//
+// Parameters:
+//
+// `timeout` - float64 - the number of seconds to block for. A timeout of 0 blocks indefinitely.
+//
+// `keys` - ...string - the keys of the lists to pop from, in order of priority.
+//
+// Returns: A string slice containing the key of the list and the popped element.
+// If the timeout expires, an empty slice is returned.
+//
+// Errors:
+//
+// "BLPOP command on non-list item" - when one of the provided keys exists but is not a list.
+func (server *EchoVault) BLPop(timeout float64, keys ...string) ([]string, error) {
+	cmd := append(append([]string{"BLPOP"}, keys...), strconv.FormatFloat(timeout, 'f', -1, 64))
+	b, err := server.handleCommand(server.context, internal.EncodeCommand(cmd), nil, false, true)
+	if err != nil {
+		return nil, err
+	}
+	return internal.ParseStringArrayResponse(b)
+}
+
+// BRPop removes and returns the last element of the first non-empty list.
+// If all the lists are empty, BRPop blocks until an element is pushed to one of the lists or the timeout expires.
+// BRPop is only supported in standalone mode.
+//
+// Parameters:
+//
+// `timeout` - float64 - the number of seconds to block for. A timeout of 0 blocks indefinitely.
+//
+// `keys` - ...string - the keys of the lists to pop from, in order of priority.
+//
+// Returns: A string slice containing the key of the list and the popped element.
+// If the timeout expires, an empty slice is returned.
+//
+// Errors:
+//
+// "BRPOP command on non-list item" - when one of the provided keys exists but is not a list.
+func (server *EchoVault) BRPop(timeout float64, keys ...string) ([]string, error) {
+	cmd := append(append([]string{"BRPOP"}, keys...), strconv.FormatFloat(timeout, 'f', -1, 64))
+	b, err := server.handleCommand(server.context, internal.EncodeCommand(cmd), nil, false, true)
+	if err != nil {
+		return nil, err
+	}
+	return internal.ParseStringArrayResponse(b)
+}
+
 // LMove moves an element from one list to another.
 //
 // Parameters:
@@ -163,11 +255,13 @@ func (server *EchoVault) LRem(key string, count int, value string) (string, erro
 // `whereTo` - string - either "LEFT" or "RIGHT". If "LEFT", the element is added to the beginning of the destination list.
 // If "RIGHT", the element is added to the end of the destination list.
 //
-// Returns: "OK" if the removal was successful.
+// Returns: The element that was moved. If the source list is empty, an empty string is returned.
+// The destination list is created if it does not exist.
 //
 // Errors:
 //
-// "both source and destination must be lists" - when either source or destination are not lists.
+// "both source and destination must be lists" - when either source or destination are not lists,
+// or when the source does not exist.
 //
 // "wherefrom and whereto arguments must be either LEFT or RIGHT" - if whereFrom or whereTo are not either "LEFT" or "RIGHT".
 func (server *EchoVault) LMove(source, destination, whereFrom, whereTo string) (string, error) {
@@ -178,6 +272,80 @@ func (server *EchoVault) LMove(source, destination, whereFrom, whereTo string) (
 	return internal.ParseStringResponse(b)
 }
 
+// BLMove is the blocking version of LMove. If the source list is empty or does not exist, BLMove blocks until
+// an element is pushed to it or the timeout expires. BLMove is only supported in standalone mode.
+//
+// Parameters:
+//
+// `source`, `destination`, `whereFrom` and `whereTo` are the same as in LMove.
+//
+// `timeout` - float64 - the number of seconds to block for. A timeout of 0 blocks indefinitely.
+//
+// Returns: The element that was moved. If the timeout expires, an empty string is returned.
+//
+// Errors:
+//
+// "both source and destination must be lists" - when either source or destination exist but are not lists.
+func (server *EchoVault) BLMove(source, destination, whereFrom, whereTo string, timeout float64) (string, error) {
+	cmd := []string{"BLMOVE", source, destination, whereFrom, whereTo, strconv.FormatFloat(timeout, 'f', -1, 64)}
+	b, err := server.handleCommand(server.context, internal.EncodeCommand(cmd), nil, false, true)
+	if err != nil {
+		return "", err
+	}
+	return internal.ParseStringResponse(b)
+}
+
+// LMPop pops up to 'count' elements from the first non-empty list.
+//
+// Parameters:
+//
+// `keys` - []string - the keys of the lists to pop from, in order of priority.
+//
+// `options` - LMPopOptions
+//
+// Returns: The key of the list that the elements were popped from, and the popped elements in the order that
+// they were popped. If all the lists are empty, an empty key and a nil slice are returned.
+//
+// Errors:
+//
+// "LMPOP command on non-list item" - when a key that is checked before a non-empty list is found is not a list.
+func (server *EchoVault) LMPop(keys []string, options LMPopOptions) (string, []string, error) {
+	cmd := []string{"LMPOP"}
+	if len(keys) > 0 && internal.IsRedisCompat(server.getConfig().ProtocolCompat) {
+		// The Redis protocol-compat levels take numkeys before the keys.
+		cmd = append(cmd, strconv.Itoa(len(keys)))
+	}
+	cmd = append(cmd, keys...)
+
+	switch {
+	case options.Left:
+		cmd = append(cmd, "LEFT")
+	case options.Right:
+		cmd = append(cmd, "RIGHT")
+	default:
+		cmd = append(cmd, "LEFT")
+	}
+
+	if options.Count != 0 {
+		cmd = append(cmd, "COUNT", strconv.Itoa(int(options.Count)))
+	}
+
+	b, err := server.handleCommand(server.context, internal.EncodeCommand(cmd), nil, false, true)
+	if err != nil {
+		return "", nil, err
+	}
+
+	v, _, err := resp.NewReader(bytes.NewReader(b)).ReadValue()
+	if err != nil || v.IsNull() {
+		return "", nil, err
+	}
+	elements := make([]string, len(v.Array()[1].Array()))
+	for i, element := range v.Array()[1].Array() {
+		elements[i] = element.String()
+	}
+	return v.Array()[0].String(), elements, nil
+}
+
 // LPop pops an element from the start of the list and return it.
 //
 // Parameters:
@@ -19,6 +19,7 @@ import (
 	"github.com/echovault/echovault/internal"
 	"github.com/tidwall/resp"
 	"net"
+	"strconv"
 )
 
 type conn struct {
@@ -47,16 +48,37 @@ type ReadPubSubMessage func() []string
 // Returns: ReadPubSubMessage function which reads the next message sent to the subscription instance.
 // This function is blocking.
 func (server *EchoVault) Subscribe(tag string, channels ...string) ReadPubSubMessage {
+	return server.subscribe(tag, append([]string{"SUBSCRIBE"}, channels...))
+}
+
+// SubscribeReplay subscribes the caller to the list of provided channels, and replays up to `replay` of the
+// messages retained by each channel. Messages are only retained on channels configured with PubSubRetain.
+//
+// Parameters:
+//
+// `tag` - string - The tag used to identify this subscription instance.
+//
+// `replay` - uint - The maximum number of retained messages to replay from each channel.
+//
+// `channels` - ...string - The list of channels to subscribe to.
+//
+// Returns: ReadPubSubMessage function which reads the next message sent to the subscription instance.
+// The replayed messages are read first. This function is blocking.
+func (server *EchoVault) SubscribeReplay(tag string, replay uint, channels ...string) ReadPubSubMessage {
+	return server.subscribe(tag, append([]string{"SUBSCRIBE", "REPLAY", strconv.Itoa(int(replay))}, channels...))
+}
+
+// subscribe runs the subscribe command on the connection identified by the tag, creating the connection if it
+// does not exist.
+func (server *EchoVault) subscribe(tag string, cmd []string) ReadPubSubMessage {
 	// Initialize connection tracker if calling subscribe for the first time
 	if connections == nil {
 		connections = make(map[string]conn)
 	}
 
 	// If connection with this name does not exist, create new connection it
-	var readConn net.Conn
-	var writeConn net.Conn
 	if _, ok := connections[tag]; !ok {
-		readConn, writeConn = net.Pipe()
+		readConn, writeConn := net.Pipe()
 		connections[tag] = conn{
 			readConn:  &readConn,
 			writeConn: &writeConn,
@@ -64,11 +86,11 @@ func (server *EchoVault) Subscribe(tag string, channels ...string) ReadPubSubMes
 	}
 
 	// Subscribe connection to the provided channels
-	cmd := append([]string{"SUBSCRIBE"}, channels...)
 	go func() {
 		_, _ = server.handleCommand(server.context, internal.EncodeCommand(cmd), connections[tag].writeConn, false, true)
 	}()
 
+	readConn := *connections[tag].readConn
 	return func() []string {
 		r := resp.NewConn(readConn)
 		v, _, _ := r.ReadValue()
@@ -113,39 +135,7 @@ func (server *EchoVault) Unsubscribe(tag string, channels ...string) {
 // Returns: ReadPubSubMessage function which reads the next message sent to the subscription instance.
 // This function is blocking.
 func (server *EchoVault) PSubscribe(tag string, patterns ...string) ReadPubSubMessage {
-	// Initialize connection tracker if calling subscribe for the first time
-	if connections == nil {
-		connections = make(map[string]conn)
-	}
-
-	// If connection with this name does not exist, create new connection it
-	var readConn net.Conn
-	var writeConn net.Conn
-	if _, ok := connections[tag]; !ok {
-		readConn, writeConn = net.Pipe()
-		connections[tag] = conn{
-			readConn:  &readConn,
-			writeConn: &writeConn,
-		}
-	}
-
-	// Subscribe connection to the provided channels
-	cmd := append([]string{"PSUBSCRIBE"}, patterns...)
-	go func() {
-		_, _ = server.handleCommand(server.context, internal.EncodeCommand(cmd), connections[tag].writeConn, false, true)
-	}()
-
-	return func() []string {
-		r := resp.NewConn(readConn)
-		v, _, _ := r.ReadValue()
-
-		res := make([]string, len(v.Array()))
-		for i := 0; i < len(res); i++ {
-			res[i] = v.Array()[i].String()
-		}
-
-		return res
-	}
+	return server.subscribe(tag, append([]string{"PSUBSCRIBE"}, patterns...))
 }
 
 // PUnsubscribe unsubscribes the caller from the given glob patterns.
@@ -186,6 +176,25 @@ func (server *EchoVault) Publish(channel, message string) (string, error) {
 	return internal.ParseStringResponse(b)
 }
 
+// PubSubRetain keeps the last `count` messages published to the channel, so that subscribers can replay them
+// with SubscribeReplay. The messages are kept in memory on each node and are not persisted.
+//
+// Parameters:
+//
+// `channel` - string - The channel whose messages are retained.
+//
+// `count` - uint - The number of messages to retain. When 0 is passed, retention is disabled.
+//
+// Returns: "OK" when the retention is updated.
+func (server *EchoVault) PubSubRetain(channel string, count uint) (string, error) {
+	cmd := []string{"PUBSUB", "RETAIN", channel, strconv.Itoa(int(count))}
+	b, err := server.handleCommand(server.context, internal.EncodeCommand(cmd), nil, false, true)
+	if err != nil {
+		return "", err
+	}
+	return internal.ParseStringResponse(b)
+}
+
 // PubSubChannels returns the list of channels & patterns that match the glob pattern provided.
 //
 // Parameters:
@@ -223,6 +232,7 @@ func (server *EchoVault) PubSubNumPat() (int, error) {
 // `channels` - ...string - The list of channels whose number of subscribers is to be checked.
 //
 // Returns: A map of map[string]int where the key is the channel name and the value is the number of subscribers.
+// The number of messages delivered to each channel, which PUBSUB NUMSUB also returns, is not included.
 func (server *EchoVault) PubSubNmSub(channels ...string) (map[string]int, error) {
 	cmd := append([]string{"PUBSUB", "NUMSUB"}, channels...)
 
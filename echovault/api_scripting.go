@@ -0,0 +1,134 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package echovault
+
+import (
+	"bytes"
+	"github.com/echovault/echovault/internal"
+	"github.com/tidwall/resp"
+	"strconv"
+)
+
+// Eval runs the Lua script. No other command runs while the script is running.
+//
+// Parameters:
+//
+// `script` - string - The Lua script. The script can run commands with redis.call and redis.pcall.
+//
+// `keys` - []string - The keys that the script accesses, available to the script in the KEYS table.
+//
+// `args` - []string - The arguments available to the script in the ARGV table.
+//
+// Returns: The value returned by the script. Integers are returned as int, strings and status replies as string,
+// arrays as []interface{}, and nil when the script returns nil or false.
+//
+// Errors:
+//
+// An error raised by the script, or by a command called by the script with redis.call.
+func (server *EchoVault) Eval(script string, keys []string, args []string) (interface{}, error) {
+	cmd := append([]string{"EVAL", script, strconv.Itoa(len(keys))}, keys...)
+	b, err := server.handleCommand(server.context, internal.EncodeCommand(append(cmd, args...)), nil, false, true)
+	if err != nil {
+		return nil, err
+	}
+	return parseScriptReply(b)
+}
+
+// EvalSha runs the cached script with the given SHA1 digest. Scripts are cached by Eval and ScriptLoad.
+//
+// Parameters:
+//
+// `sha` - string - The SHA1 digest of the script.
+//
+// `keys` - []string - The keys that the script accesses, available to the script in the KEYS table.
+//
+// `args` - []string - The arguments available to the script in the ARGV table.
+//
+// Returns: The value returned by the script, in the same form as Eval.
+//
+// Errors:
+//
+// "NOSCRIPT No matching script. Please use EVAL." - If there's no cached script with the digest.
+func (server *EchoVault) EvalSha(sha string, keys []string, args []string) (interface{}, error) {
+	cmd := append([]string{"EVALSHA", sha, strconv.Itoa(len(keys))}, keys...)
+	b, err := server.handleCommand(server.context, internal.EncodeCommand(append(cmd, args...)), nil, false, true)
+	if err != nil {
+		return nil, err
+	}
+	return parseScriptReply(b)
+}
+
+// ScriptLoad adds the script to the script cache without running it.
+//
+// Returns: The SHA1 digest of the script, to be used with EvalSha.
+func (server *EchoVault) ScriptLoad(script string) (string, error) {
+	b, err := server.handleCommand(server.context, internal.EncodeCommand([]string{"SCRIPT", "LOAD", script}), nil, false, true)
+	if err != nil {
+		return "", err
+	}
+	return internal.ParseStringResponse(b)
+}
+
+// ScriptExists checks whether scripts are in the script cache.
+//
+// Parameters:
+//
+// `shas` - ...string - The SHA1 digests of the scripts.
+//
+// Returns: A boolean slice with true for each digest of a cached script.
+func (server *EchoVault) ScriptExists(shas ...string) ([]bool, error) {
+	b, err := server.handleCommand(server.context, internal.EncodeCommand(append([]string{"SCRIPT", "EXISTS"}, shas...)), nil, false, true)
+	if err != nil {
+		return nil, err
+	}
+	return internal.ParseBooleanArrayResponse(b)
+}
+
+// ScriptFlush removes all the scripts from the script cache.
+//
+// Returns: "OK" when the cache is flushed.
+func (server *EchoVault) ScriptFlush() (string, error) {
+	b, err := server.handleCommand(server.context, internal.EncodeCommand([]string{"SCRIPT", "FLUSH"}), nil, false, true)
+	if err != nil {
+		return "", err
+	}
+	return internal.ParseStringResponse(b)
+}
+
+func parseScriptReply(b []byte) (interface{}, error) {
+	v, _, err := resp.NewReader(bytes.NewReader(b)).ReadValue()
+	if err != nil {
+		return nil, err
+	}
+	return scriptValue(v), nil
+}
+
+func scriptValue(v resp.Value) interface{} {
+	if v.IsNull() {
+		return nil
+	}
+	switch v.Type() {
+	case resp.Integer:
+		return v.Integer()
+	case resp.Array:
+		arr := make([]interface{}, len(v.Array()))
+		for i, elem := range v.Array() {
+			arr[i] = scriptValue(elem)
+		}
+		return arr
+	default:
+		return v.String()
+	}
+}
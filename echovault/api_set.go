@@ -15,7 +15,9 @@
 package echovault
 
 import (
+	"fmt"
 	"github.com/echovault/echovault/internal"
+	"github.com/echovault/echovault/internal/modules/set"
 	"strconv"
 )
 
@@ -134,7 +136,12 @@ func (server *EchoVault) SInter(keys ...string) ([]string, error) {
 //
 // "not enough sets in the keys provided" - when only one of the provided keys is a valid set.
 func (server *EchoVault) SInterCard(keys []string, limit uint) (int, error) {
-	cmd := append([]string{"SINTERCARD"}, keys...)
+	cmd := []string{"SINTERCARD"}
+	if len(keys) > 0 && internal.IsRedisCompat(server.getConfig().ProtocolCompat) {
+		// The Redis protocol-compat levels take numkeys before the keys.
+		cmd = append(cmd, strconv.Itoa(len(keys)))
+	}
+	cmd = append(cmd, keys...)
 	if limit > 0 {
 		cmd = append(cmd, []string{"LIMIT", strconv.Itoa(int(limit))}...)
 	}
@@ -177,6 +184,41 @@ func (server *EchoVault) SisMember(key, member string) (bool, error) {
 	return internal.ParseBooleanResponse(b)
 }
 
+// SScanOptions modifies the behaviour of SScan.
+//
+// Match - Only return the members that match the glob-style pattern.
+//
+// Count - The number of members to visit in each call. Defaults to 10.
+type SScanOptions struct {
+	Match string
+	Count uint
+}
+
+// SScan incrementally iterates the members of a set. Start the iteration with a cursor of 0 and pass the
+// returned cursor to the next call. The iteration is complete when the returned cursor is 0.
+//
+// Parameters:
+//
+// `key` - string - The key of the set.
+//
+// `cursor` - uint64 - The cursor returned by the previous call, or 0 to start the iteration.
+//
+// `options` - SScanOptions.
+//
+// Returns: The cursor to pass to the next call and a batch of members.
+//
+// Errors:
+//
+// "value at <key> is not a set" - when the provided key exists but is not a set.
+func (server *EchoVault) SScan(key string, cursor uint64, options SScanOptions) (uint64, []string, error) {
+	cmd := scanCommand([]string{"SSCAN", key}, cursor, options.Match, options.Count)
+	b, err := server.handleCommand(server.context, internal.EncodeCommand(cmd), nil, false, true)
+	if err != nil {
+		return 0, nil, err
+	}
+	return parseScanResponse(b)
+}
+
 // SMembers Returns all the members of the specified set.
 //
 // Parameters:
@@ -196,6 +238,40 @@ func (server *EchoVault) SMembers(key string) ([]string, error) {
 	return internal.ParseStringArrayResponse(b)
 }
 
+// SMembersIter calls f for each member of the specified set without collecting the members into a slice,
+// which keeps the memory usage low when reading very large sets. The members are visited in no particular order
+// and the iteration stops when f returns false.
+// The set is read-locked while it is iterated, so f must not modify the set.
+//
+// Parameters:
+//
+// `key` - string - The key of the set.
+//
+// `f` - func(member string) bool - The function called with each member.
+//
+// Errors:
+//
+// "value at <key> is not a set" - when the provided key exists but is not a set.
+func (server *EchoVault) SMembersIter(key string, f func(member string) bool) error {
+	ctx, cancel := server.withCommandTimeout(server.context)
+	defer cancel()
+
+	if !server.KeyExists(ctx, key) {
+		return nil
+	}
+	if _, err := server.KeyRLock(ctx, key); err != nil {
+		return err
+	}
+	defer server.KeyRUnlock(ctx, key)
+
+	s, ok := server.GetValue(ctx, key).(*set.Set)
+	if !ok {
+		return fmt.Errorf("value at key %s is not a set", key)
+	}
+	s.Range(f)
+	return nil
+}
+
 // SMisMember Returns the membership status of all the specified members.
 //
 // Parameters:
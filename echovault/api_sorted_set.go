@@ -15,7 +15,9 @@
 package echovault
 
 import (
+	"bytes"
 	"github.com/echovault/echovault/internal"
+	"github.com/tidwall/resp"
 	"strconv"
 )
 
@@ -97,7 +99,54 @@ type ZRangeOptions struct {
 	Offset     uint
 	Count      uint
 }
-type ZRangeStoreOptions ZRangeOptions
+
+// ZRangeStoreOptions allows you to modify the effects of the ZRangeStore command.
+//
+// WithScores has no effect as the scores are always stored. It is kept for compatibility with ZRangeOptions.
+//
+// ByScore selects the members within the numerical range specified. ByScore is higher priority than ByLex.
+//
+// ByLex selects the members within the lexicographical range specified.
+// If neither ByScore nor ByLex is true, the range is by rank.
+//
+// Rev reverses the order of the members. Ranks count from the highest score, and the score or lexicographical
+// range takes the maximum as start and the minimum as stop.
+//
+// Offset specifies the number of members in the range to skip.
+//
+// Count specifies the maximum number of members to store. The LIMIT clause is only sent when Count is not 0,
+// and only applies to ByScore and ByLex ranges.
+type ZRangeStoreOptions struct {
+	WithScores bool
+	ByScore    bool
+	ByLex      bool
+	Rev        bool
+	Offset     uint
+	Count      uint
+}
+
+// ZRangeByScoreOptions allows you to modify the effects of the ZRangeByScore and ZRevRangeByScore commands.
+//
+// WithScores specifies whether to return the associated scores.
+//
+// Offset specifies the number of members in the range to skip.
+//
+// Count specifies the maximum number of members to return. The LIMIT clause is only sent when Count is not 0.
+type ZRangeByScoreOptions struct {
+	WithScores bool
+	Offset     uint
+	Count      uint
+}
+
+// ZRangeByLexOptions allows you to modify the effects of the ZRangeByLex and ZRevRangeByLex commands.
+//
+// Offset specifies the number of members in the range to skip.
+//
+// Count specifies the maximum number of members to return. The LIMIT clause is only sent when Count is not 0.
+type ZRangeByLexOptions struct {
+	Offset uint
+	Count  uint
+}
 
 func buildMemberScoreMap(arr [][]string, withscores bool) (map[string]float64, error) {
 	result := make(map[string]float64, len(arr))
@@ -548,45 +597,54 @@ func (server *EchoVault) ZMPop(keys []string, options ZMPopOptions) ([][]string,
 //
 // `members` - ...string - Them members whose scores will be returned.
 //
-// Returns: A slice of interface{} with the result scores. For existing members, the entry will be represented by a string.
-// For non-existent members, the score will be nil. You will have to format the string score into a float64 if you
-// would like to use it as a float64.
+// Returns: A slice of interface{} with the result scores. For existing members, the entry will be a float64.
+// For non-existent members, the score will be nil. Use ZMScoreFloat to get the scores as a float64 slice.
 //
 // Errors:
 //
 // "value at <key> is not a sorted set" - when a key exists but is not a sorted set.
 func (server *EchoVault) ZMScore(key string, members ...string) ([]interface{}, error) {
-	cmd := []string{"ZMSCORE", key}
-	for _, member := range members {
-		cmd = append(cmd, member)
-	}
-
-	b, err := server.handleCommand(server.context, internal.EncodeCommand(cmd), nil, false, true)
+	floats, exists, err := server.ZMScoreFloat(key, members...)
 	if err != nil {
 		return nil, err
 	}
 
-	arr, err := internal.ParseStringArrayResponse(b)
-	if err != nil {
-		return nil, err
-	}
-
-	scores := make([]interface{}, len(arr))
-	for i, e := range arr {
-		if e == "" {
-			scores[i] = nil
-			continue
-		}
-		score, err := strconv.ParseFloat(e, 64)
-		if err != nil {
-			return nil, err
+	scores := make([]interface{}, len(floats))
+	for i, score := range floats {
+		if exists[i] {
+			scores[i] = score
 		}
-		scores[i] = score
 	}
 
 	return scores, nil
 }
 
+// ZMScoreFloat returns the scores of the specified members in the sorted set as float64 values.
+//
+// Parameters:
+//
+// `key` - string - The key to the sorted set.
+//
+// `members` - ...string - The members whose scores will be returned.
+//
+// Returns: A float64 slice with the score of each member, in the order of the members, and a bool slice reporting
+// whether each member exists. The score of a member that does not exist is 0. If the sorted set does not exist,
+// none of the members exist.
+//
+// Errors:
+//
+// "value at <key> is not a sorted set" - when a key exists but is not a sorted set.
+func (server *EchoVault) ZMScoreFloat(key string, members ...string) ([]float64, []bool, error) {
+	cmd := append([]string{"ZMSCORE", key}, members...)
+
+	b, err := server.handleCommand(server.context, internal.EncodeCommand(cmd), nil, false, true)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return internal.ParseFloatArrayResponse(b)
+}
+
 // ZLexCount returns the number of elements in the sorted set within the lexicographical range between min and max.
 // This function only returns a non-zero value if all the members have the same score.
 //
@@ -623,6 +681,7 @@ func (server *EchoVault) ZLexCount(key, min, max string) (int, error) {
 // and 1 element will be popped instead.
 //
 // Returns: A 2-dimensional slice where each slice contains a member and its score at the 0 and 1 indices respectively.
+// The members are returned in the order that they were popped.
 // The returned scores are strings. If you'd like to use them as float64 or another numeric type, you will have to
 // format them.
 //
@@ -647,6 +706,7 @@ func (server *EchoVault) ZPopMax(key string, count uint) ([][]string, error) {
 // and 1 element will be popped instead.
 //
 // Returns: A 2-dimensional slice where each slice contains a member and its score at the 0 and 1 indices respectively.
+// The members are returned in the order that they were popped.
 // The returned scores are strings. If you'd like to use them as float64 or another numeric type, you will have to
 // format them.
 //
@@ -661,6 +721,92 @@ func (server *EchoVault) ZPopMin(key string, count uint) ([][]string, error) {
 	return internal.ParseNestedStringArrayResponse(b)
 }
 
+// BZPopMin removes and returns the member with the lowest score from the first non-empty sorted set.
+// If all the sorted sets are empty, BZPopMin blocks until a member is added to one of them or the timeout expires.
+// BZPopMin is only supported in standalone mode.
+//
+// Parameters:
+//
+// `timeout` - float64 - the number of seconds to block for. A timeout of 0 blocks indefinitely.
+//
+// `keys` - ...string - the keys of the sorted sets to pop from, in order of priority.
+//
+// Returns: A string slice containing the key of the sorted set, the popped member and its score.
+// If the timeout expires, an empty slice is returned.
+//
+// Errors:
+//
+// "value at key <key> is not a sorted set" - when one of the provided keys exists but is not a sorted set.
+func (server *EchoVault) BZPopMin(timeout float64, keys ...string) ([]string, error) {
+	cmd := append(append([]string{"BZPOPMIN"}, keys...), strconv.FormatFloat(timeout, 'f', -1, 64))
+	b, err := server.handleCommand(server.context, internal.EncodeCommand(cmd), nil, false, true)
+	if err != nil {
+		return nil, err
+	}
+	return internal.ParseStringArrayResponse(b)
+}
+
+// BZPopMax works like BZPopMin but pops the member with the highest score.
+func (server *EchoVault) BZPopMax(timeout float64, keys ...string) ([]string, error) {
+	cmd := append(append([]string{"BZPOPMAX"}, keys...), strconv.FormatFloat(timeout, 'f', -1, 64))
+	b, err := server.handleCommand(server.context, internal.EncodeCommand(cmd), nil, false, true)
+	if err != nil {
+		return nil, err
+	}
+	return internal.ParseStringArrayResponse(b)
+}
+
+// BZMPop is the blocking version of ZMPop. It pops 'count' members from the first non-empty sorted set.
+// If all the sorted sets are empty, BZMPop blocks until a member is added to one of them or the timeout expires.
+// BZMPop is only supported in standalone mode.
+//
+// Parameters:
+//
+// `timeout` - float64 - the number of seconds to block for. A timeout of 0 blocks indefinitely.
+//
+// `keys` - []string - the keys of the sorted sets to pop from, in order of priority.
+//
+// `options` - ZMPopOptions
+//
+// Returns: The key of the sorted set that the members were popped from, and a 2-dimensional slice where each
+// slice contains a member and its score in the order that they were popped.
+// If the timeout expires, an empty key and a nil slice are returned.
+//
+// Errors:
+//
+// "value at key <key> is not a sorted set" - when one of the provided keys exists but is not a sorted set.
+func (server *EchoVault) BZMPop(timeout float64, keys []string, options ZMPopOptions) (string, [][]string, error) {
+	cmd := append([]string{"BZMPOP", strconv.FormatFloat(timeout, 'f', -1, 64)}, keys...)
+
+	switch {
+	case options.Min:
+		cmd = append(cmd, "MIN")
+	case options.Max:
+		cmd = append(cmd, "MAX")
+	default:
+		cmd = append(cmd, "MIN")
+	}
+
+	if options.Count != 0 {
+		cmd = append(cmd, "COUNT", strconv.Itoa(int(options.Count)))
+	}
+
+	b, err := server.handleCommand(server.context, internal.EncodeCommand(cmd), nil, false, true)
+	if err != nil {
+		return "", nil, err
+	}
+
+	v, _, err := resp.NewReader(bytes.NewReader(b)).ReadValue()
+	if err != nil || v.IsNull() {
+		return "", nil, err
+	}
+	members := make([][]string, len(v.Array()[1].Array()))
+	for i, member := range v.Array()[1].Array() {
+		members[i] = []string{member.Array()[0].String(), member.Array()[1].String()}
+	}
+	return v.Array()[0].String(), members, nil
+}
+
 // ZRandMember Returns a list of length equivalent to 'count' containing random members of the sorted set.
 // If count is negative, repeated elements are allowed. If count is positive, the returned elements will be distinct.
 // The default count is 1. If a count of 0 is passed, it will be ignored.
@@ -770,6 +916,53 @@ func (server *EchoVault) ZRevRank(key string, member string, withscores bool) (m
 	return buildIntegerScoreMap(arr, withscores)
 }
 
+// ZScanOptions modifies the behaviour of ZScan.
+//
+// Match - Only return the members that match the glob-style pattern.
+//
+// Count - The number of members to visit in each call. Defaults to 10.
+type ZScanOptions struct {
+	Match string
+	Count uint
+}
+
+// ZScan incrementally iterates the members of a sorted set. Start the iteration with a cursor of 0 and pass the
+// returned cursor to the next call. The iteration is complete when the returned cursor is 0.
+//
+// Parameters:
+//
+// `key` - string - The key to the sorted set.
+//
+// `cursor` - uint64 - The cursor returned by the previous call, or 0 to start the iteration.
+//
+// `options` - ZScanOptions.
+//
+// Returns: The cursor to pass to the next call and a map of a batch of members to their scores.
+//
+// Errors:
+//
+// "value at <key> is not a sorted set" - when a key exists but is not a sorted set.
+func (server *EchoVault) ZScan(key string, cursor uint64, options ZScanOptions) (uint64, map[string]float64, error) {
+	cmd := scanCommand([]string{"ZSCAN", key}, cursor, options.Match, options.Count)
+	b, err := server.handleCommand(server.context, internal.EncodeCommand(cmd), nil, false, true)
+	if err != nil {
+		return 0, nil, err
+	}
+	next, elems, err := parseScanResponse(b)
+	if err != nil {
+		return 0, nil, err
+	}
+	members := make(map[string]float64, len(elems)/2)
+	for i := 0; i+1 < len(elems); i += 2 {
+		score, err := strconv.ParseFloat(elems[i+1], 64)
+		if err != nil {
+			return 0, nil, err
+		}
+		members[elems[i]] = score
+	}
+	return next, members, nil
+}
+
 // ZScore Returns the score of the member in the sorted set.
 //
 // Parameters:
@@ -779,33 +972,53 @@ func (server *EchoVault) ZRevRank(key string, member string, withscores bool) (m
 // `member` - string - The member whose rank will be returned.
 //
 // Returns: An interface representing the score of the member. If the member does not exist in the sorted set, nil is
-// returned. Otherwise, a float64 is returned.
+// returned. Otherwise, a float64 is returned. Use ZScoreFloat to get the score as a float64.
 //
 // Errors:
 //
 // "value at <key> is not a sorted set" - when a key exists but is not a sorted set.
 func (server *EchoVault) ZScore(key string, member string) (interface{}, error) {
-	cmd := []string{"ZSCORE", key, member}
-	b, err := server.handleCommand(server.context, internal.EncodeCommand(cmd), nil, false, true)
+	score, exists, err := server.ZScoreFloat(key, member)
 	if err != nil {
 		return 0, err
 	}
+	if !exists {
+		return nil, nil
+	}
+	return score, nil
+}
 
-	isNil, err := internal.ParseNilResponse(b)
+// ZScoreFloat returns the score of the member in the sorted set as a float64.
+//
+// Parameters:
+//
+// `key` - string - The key to the sorted set.
+//
+// `member` - string - The member whose score will be returned.
+//
+// Returns: The score of the member, and whether the member exists in the sorted set.
+// The score is 0 if the member does not exist.
+//
+// Errors:
+//
+// "value at <key> is not a sorted set" - when a key exists but is not a sorted set.
+func (server *EchoVault) ZScoreFloat(key string, member string) (float64, bool, error) {
+	b, err := server.handleCommand(server.context, internal.EncodeCommand([]string{"ZSCORE", key, member}), nil, false, true)
 	if err != nil {
-		return nil, err
+		return 0, false, err
 	}
 
-	if isNil {
-		return nil, nil
+	isNil, err := internal.ParseNilResponse(b)
+	if err != nil || isNil {
+		return 0, false, err
 	}
 
 	score, err := internal.ParseFloatResponse(b)
 	if err != nil {
-		return 0, err
+		return 0, false, err
 	}
 
-	return score, nil
+	return score, true, nil
 }
 
 // ZRem Removes the listed members from the sorted set.
@@ -864,6 +1077,58 @@ func (server *EchoVault) ZRemRangeByScore(key string, min float64, max float64)
 	return internal.ParseIntegerResponse(b)
 }
 
+// ZRemRangeByRank Removes the elements whose ranks are in the range between start and stop.
+// Members are ranked by score, and members with the same score are ranked lexicographically.
+//
+// Parameters:
+//
+// `key` - string - The keys to the sorted set.
+//
+// `start` - int - The start rank. Negative ranks are counted from the end of the sorted set.
+//
+// `stop` - int - The stop rank. Negative ranks are counted from the end of the sorted set.
+//
+// Returns: The number of elements that were successfully removed.
+//
+// Errors:
+//
+// "value at <key> is not a sorted set" - when a key exists but is not a sorted set.
+//
+// "indices out of bounds" - when start or stop are outside the sorted set.
+func (server *EchoVault) ZRemRangeByRank(key string, start int, stop int) (int, error) {
+	cmd := []string{"ZREMRANGEBYRANK", key, strconv.Itoa(start), strconv.Itoa(stop)}
+	b, err := server.handleCommand(server.context, internal.EncodeCommand(cmd), nil, false, true)
+	if err != nil {
+		return 0, err
+	}
+	return internal.ParseIntegerResponse(b)
+}
+
+// ZRemRangeByLex Removes the elements in the lexicographical range between min and max.
+// This function only removes elements if all the members have the same score.
+//
+// Parameters:
+//
+// `key` - string - The keys to the sorted set.
+//
+// `min` - string - The minimum lex boundary. Prefix the boundary with "(" to exclude it, or use "-" for no minimum.
+//
+// `max` - string - The maximum lex boundary. Prefix the boundary with "(" to exclude it, or use "+" for no maximum.
+//
+// Returns: The number of elements that were successfully removed.
+//
+// Errors:
+//
+// "value at <key> is not a sorted set" - when a key exists but is not a sorted set.
+func (server *EchoVault) ZRemRangeByLex(key, min, max string) (int, error) {
+	cmd := []string{"ZREMRANGEBYLEX", key, min, max}
+	b, err := server.handleCommand(server.context, internal.EncodeCommand(cmd), nil, false, true)
+	if err != nil {
+		return 0, err
+	}
+	return internal.ParseIntegerResponse(b)
+}
+
 // ZRange Returns the range of elements in the sorted set.
 //
 // Parameters:
@@ -922,13 +1187,13 @@ func (server *EchoVault) ZRange(key, start, stop string, options ZRangeOptions)
 //
 // `key` - string - The keys to the sorted set.
 //
-// `start` - string - The minimum boundary.
+// `start` - string - The start rank, or the minimum boundary for score and lexicographical ranges.
 //
-// `stop` - string - The maximum boundary.
+// `stop` - string - The stop rank, or the maximum boundary for score and lexicographical ranges.
 //
 // `options` - ZRangeStoreOptions
 //
-// Returns: The cardinality of the new sorted set.
+// Returns: The cardinality of the new sorted set. The destination is deleted if the range is empty.
 //
 // Errors:
 //
@@ -941,11 +1206,13 @@ func (server *EchoVault) ZRangeStore(destination, source, start, stop string, op
 		cmd = append(cmd, "BYSCORE")
 	case options.ByLex:
 		cmd = append(cmd, "BYLEX")
-	default:
-		cmd = append(cmd, "BYSCORE")
 	}
 
-	if options.Offset != 0 && options.Count != 0 {
+	if options.Rev {
+		cmd = append(cmd, "REV")
+	}
+
+	if (options.ByScore || options.ByLex) && options.Count != 0 {
 		cmd = append(cmd, []string{"LIMIT", strconv.Itoa(int(options.Offset)), strconv.Itoa(int(options.Count))}...)
 	}
 
@@ -956,3 +1223,397 @@ func (server *EchoVault) ZRangeStore(destination, source, start, stop string, op
 
 	return internal.ParseIntegerResponse(b)
 }
+
+// ZRangeByScore returns the members of the sorted set with scores between min and max, ordered from the lowest score.
+//
+// Parameters:
+//
+// `key` - string - The key to the sorted set.
+//
+// `min` - string - The minimum score. Prefix with "(" to exclude it, or use "-inf".
+//
+// `max` - string - The maximum score. Prefix with "(" to exclude it, or use "+inf".
+//
+// `options` - ZRangeByScoreOptions
+//
+// Returns: A [][]string in range order, where each entry is the member, followed by its score if WithScores is true.
+//
+// Errors:
+//
+// "value at <key> is not a sorted set" - when a key exists but is not a sorted set.
+func (server *EchoVault) ZRangeByScore(key, min, max string, options ZRangeByScoreOptions) ([][]string, error) {
+	return server.zrangeByScore("ZRANGEBYSCORE", key, min, max, options)
+}
+
+// ZRevRangeByScore works like ZRangeByScore but orders the members from the highest score.
+// Note that max comes before min.
+func (server *EchoVault) ZRevRangeByScore(key, max, min string, options ZRangeByScoreOptions) ([][]string, error) {
+	return server.zrangeByScore("ZREVRANGEBYSCORE", key, max, min, options)
+}
+
+func (server *EchoVault) zrangeByScore(command, key, start, stop string, options ZRangeByScoreOptions) ([][]string, error) {
+	cmd := []string{command, key, start, stop}
+	if options.WithScores {
+		cmd = append(cmd, "WITHSCORES")
+	}
+	if options.Count != 0 {
+		cmd = append(cmd, []string{"LIMIT", strconv.Itoa(int(options.Offset)), strconv.Itoa(int(options.Count))}...)
+	}
+	b, err := server.handleCommand(server.context, internal.EncodeCommand(cmd), nil, false, true)
+	if err != nil {
+		return nil, err
+	}
+	return internal.ParseNestedStringArrayResponse(b)
+}
+
+// ZRangeByLex returns the members of the sorted set between min and max in lexicographical order.
+// All the members must have the same score, otherwise an empty result is returned.
+//
+// Parameters:
+//
+// `key` - string - The key to the sorted set.
+//
+// `min` - string - The minimum boundary. Prefix with "[" to include it or "(" to exclude it, or use "-".
+//
+// `max` - string - The maximum boundary. Prefix with "[" to include it or "(" to exclude it, or use "+".
+//
+// `options` - ZRangeByLexOptions
+//
+// Returns: A []string of the members in range order.
+//
+// Errors:
+//
+// "value at <key> is not a sorted set" - when a key exists but is not a sorted set.
+func (server *EchoVault) ZRangeByLex(key, min, max string, options ZRangeByLexOptions) ([]string, error) {
+	return server.zrangeByLex("ZRANGEBYLEX", key, min, max, options)
+}
+
+// ZRevRangeByLex works like ZRangeByLex but returns the members in reverse lexicographical order.
+// Note that max comes before min.
+func (server *EchoVault) ZRevRangeByLex(key, max, min string, options ZRangeByLexOptions) ([]string, error) {
+	return server.zrangeByLex("ZREVRANGEBYLEX", key, max, min, options)
+}
+
+func (server *EchoVault) zrangeByLex(command, key, start, stop string, options ZRangeByLexOptions) ([]string, error) {
+	cmd := []string{command, key, start, stop}
+	if options.Count != 0 {
+		cmd = append(cmd, []string{"LIMIT", strconv.Itoa(int(options.Offset)), strconv.Itoa(int(options.Count))}...)
+	}
+	b, err := server.handleCommand(server.context, internal.EncodeCommand(cmd), nil, false, true)
+	if err != nil {
+		return nil, err
+	}
+	arr, err := internal.ParseNestedStringArrayResponse(b)
+	if err != nil {
+		return nil, err
+	}
+	members := make([]string, len(arr))
+	for i, entry := range arr {
+		members[i] = entry[0]
+	}
+	return members, nil
+}
+
+// GeoLocation is a member of a sorted set with its location.
+type GeoLocation struct {
+	Longitude float64
+	Latitude  float64
+	Member    string
+}
+
+// GeoAddOptions allows you to modify the effects of the GeoAdd command.
+//
+// "NX" only adds new members. The "NX" flag takes higher priority than the "XX" flag.
+//
+// "XX" only updates the locations of members that exist in the sorted set.
+//
+// "CH" modifies the result to return total number of members changed + added, instead of only new members added.
+type GeoAddOptions struct {
+	NX bool
+	XX bool
+	CH bool
+}
+
+// GeoSearchOptions specifies the area searched by the GeoSearch and GeoSearchStore commands.
+//
+// FromMember is the member at the center of the search. It's used when FromLonLat is false.
+//
+// FromLonLat centers the search on the Longitude and Latitude instead of a member.
+//
+// Radius is the radius of the search. It's used when ByBox is false.
+//
+// ByBox searches the box of Width and Height instead of the circle of Radius.
+//
+// Unit is the unit of Radius, Width, Height and the returned distances. It is one of "m", "km", "ft" or "mi",
+// and defaults to "m".
+//
+// Order sorts the results by their distance from the center. It is "ASC", "DESC" or empty.
+//
+// Count limits the number of results to the Count closest results. When Any is true, the first Count results found
+// are returned instead.
+type GeoSearchOptions struct {
+	FromMember string
+	FromLonLat bool
+	Longitude  float64
+	Latitude   float64
+	Radius     float64
+	ByBox      bool
+	Width      float64
+	Height     float64
+	Unit       string
+	Order      string
+	Count      uint
+	Any        bool
+}
+
+// GeoSearchResult is a member of a sorted set that matches a GeoSearch.
+type GeoSearchResult struct {
+	Member    string
+	Distance  float64 // The distance from the center of the search, in the unit of the search.
+	Hash      uint64  // The geohash of the member, which is its score in the sorted set.
+	Longitude float64
+	Latitude  float64
+}
+
+// GeoAdd adds the locations to the sorted set at the key. The score of each member is the geohash of its location.
+//
+// Parameters:
+//
+// `key` - string - The key to update.
+//
+// `options` - GeoAddOptions
+//
+// `locations` - ...GeoLocation - The members to add and their locations.
+//
+// Returns: The number of members added, or the number of members added + changed when the CH flag is set.
+//
+// Errors:
+//
+// "value at <key> is not a sorted set" - when the provided key exists but is not a sorted set.
+//
+// "invalid longitude,latitude pair <longitude>,<latitude>" - when a location is out of range.
+func (server *EchoVault) GeoAdd(key string, options GeoAddOptions, locations ...GeoLocation) (int, error) {
+	cmd := []string{"GEOADD", key}
+
+	switch {
+	case options.NX:
+		cmd = append(cmd, "NX")
+	case options.XX:
+		cmd = append(cmd, "XX")
+	}
+	if options.CH {
+		cmd = append(cmd, "CH")
+	}
+
+	for _, location := range locations {
+		cmd = append(cmd,
+			strconv.FormatFloat(location.Longitude, 'f', -1, 64),
+			strconv.FormatFloat(location.Latitude, 'f', -1, 64),
+			location.Member,
+		)
+	}
+
+	b, err := server.handleCommand(server.context, internal.EncodeCommand(cmd), nil, false, true)
+	if err != nil {
+		return 0, err
+	}
+
+	return internal.ParseIntegerResponse(b)
+}
+
+// GeoDist returns the distance between two members of the sorted set.
+//
+// Parameters:
+//
+// `key` - string - The key of the sorted set.
+//
+// `member1` - string - The first member.
+//
+// `member2` - string - The second member.
+//
+// `unit` - string - The unit of the distance. It is one of "m", "km", "ft" or "mi", and defaults to "m" when empty.
+//
+// Returns: An interface representing the distance. If the key or one of the members does not exist, nil is returned.
+// Otherwise, a float64 is returned.
+//
+// Errors:
+//
+// "value at <key> is not a sorted set" - when the provided key exists but is not a sorted set.
+func (server *EchoVault) GeoDist(key, member1, member2, unit string) (interface{}, error) {
+	cmd := []string{"GEODIST", key, member1, member2}
+	if unit != "" {
+		cmd = append(cmd, unit)
+	}
+
+	b, err := server.handleCommand(server.context, internal.EncodeCommand(cmd), nil, false, true)
+	if err != nil {
+		return nil, err
+	}
+
+	isNil, err := internal.ParseNilResponse(b)
+	if err != nil {
+		return nil, err
+	}
+	if isNil {
+		return nil, nil
+	}
+
+	return internal.ParseFloatResponse(b)
+}
+
+// GeoPos returns the locations of the members of the sorted set.
+//
+// Parameters:
+//
+// `key` - string - The key of the sorted set.
+//
+// `members` - ...string - The members whose locations will be returned.
+//
+// Returns: A slice with the location of each member, in the order of the members provided.
+// The location is nil for each member that does not exist.
+//
+// Errors:
+//
+// "value at <key> is not a sorted set" - when the provided key exists but is not a sorted set.
+func (server *EchoVault) GeoPos(key string, members ...string) ([]*GeoLocation, error) {
+	cmd := append([]string{"GEOPOS", key}, members...)
+
+	b, err := server.handleCommand(server.context, internal.EncodeCommand(cmd), nil, false, true)
+	if err != nil {
+		return nil, err
+	}
+
+	v, _, err := resp.NewReader(bytes.NewReader(b)).ReadValue()
+	if err != nil {
+		return nil, err
+	}
+
+	locations := make([]*GeoLocation, len(v.Array()))
+	for i, position := range v.Array() {
+		if position.IsNull() {
+			continue
+		}
+		locations[i] = &GeoLocation{
+			Longitude: position.Array()[0].Float(),
+			Latitude:  position.Array()[1].Float(),
+			Member:    members[i],
+		}
+	}
+
+	return locations, nil
+}
+
+// geoSearchCommand builds the arguments of GEOSEARCH and GEOSEARCHSTORE after the key.
+func geoSearchCommand(options GeoSearchOptions) []string {
+	var cmd []string
+
+	if options.FromLonLat {
+		cmd = append(cmd, "FROMLONLAT",
+			strconv.FormatFloat(options.Longitude, 'f', -1, 64),
+			strconv.FormatFloat(options.Latitude, 'f', -1, 64))
+	} else {
+		cmd = append(cmd, "FROMMEMBER", options.FromMember)
+	}
+
+	unit := options.Unit
+	if unit == "" {
+		unit = "m"
+	}
+	if options.ByBox {
+		cmd = append(cmd, "BYBOX",
+			strconv.FormatFloat(options.Width, 'f', -1, 64),
+			strconv.FormatFloat(options.Height, 'f', -1, 64),
+			unit)
+	} else {
+		cmd = append(cmd, "BYRADIUS", strconv.FormatFloat(options.Radius, 'f', -1, 64), unit)
+	}
+
+	if options.Order != "" {
+		cmd = append(cmd, options.Order)
+	}
+	if options.Count > 0 {
+		cmd = append(cmd, "COUNT", strconv.Itoa(int(options.Count)))
+		if options.Any {
+			cmd = append(cmd, "ANY")
+		}
+	}
+
+	return cmd
+}
+
+// GeoSearch returns the members of the sorted set within the circle or the box centered on a member or a location.
+//
+// Parameters:
+//
+// `key` - string - The key of the sorted set.
+//
+// `options` - GeoSearchOptions - The area of the search, and the order and number of results.
+//
+// Returns: The members that match, with their distance from the center, geohash and location.
+//
+// Errors:
+//
+// "value at <key> is not a sorted set" - when the provided key exists but is not a sorted set.
+//
+// "could not decode requested zset member" - when FromMember does not exist in the sorted set.
+func (server *EchoVault) GeoSearch(key string, options GeoSearchOptions) ([]GeoSearchResult, error) {
+	cmd := append([]string{"GEOSEARCH", key}, geoSearchCommand(options)...)
+	cmd = append(cmd, "WITHCOORD", "WITHDIST", "WITHHASH")
+
+	b, err := server.handleCommand(server.context, internal.EncodeCommand(cmd), nil, false, true)
+	if err != nil {
+		return nil, err
+	}
+
+	v, _, err := resp.NewReader(bytes.NewReader(b)).ReadValue()
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]GeoSearchResult, len(v.Array()))
+	for i, result := range v.Array() {
+		fields := result.Array()
+		results[i] = GeoSearchResult{
+			Member:    fields[0].String(),
+			Distance:  fields[1].Float(),
+			Hash:      uint64(fields[2].Integer()),
+			Longitude: fields[3].Array()[0].Float(),
+			Latitude:  fields[3].Array()[1].Float(),
+		}
+	}
+
+	return results, nil
+}
+
+// GeoSearchStore works like GeoSearch but stores the matching members in the sorted set at the destination key.
+//
+// Parameters:
+//
+// `destination` - string - The key at which to store the matching members.
+//
+// `source` - string - The key of the sorted set to search.
+//
+// `options` - GeoSearchOptions - The area of the search, and the order and number of results.
+//
+// `storeDist` - bool - Whether to store the distances from the center as the scores instead of the geohashes.
+//
+// Returns: The number of members stored.
+//
+// Errors:
+//
+// "value at <key> is not a sorted set" - when the source key exists but is not a sorted set.
+//
+// "could not decode requested zset member" - when FromMember does not exist in the sorted set.
+func (server *EchoVault) GeoSearchStore(destination, source string, options GeoSearchOptions, storeDist bool) (int, error) {
+	cmd := append([]string{"GEOSEARCHSTORE", destination, source}, geoSearchCommand(options)...)
+	if storeDist {
+		cmd = append(cmd, "STOREDIST")
+	}
+
+	b, err := server.handleCommand(server.context, internal.EncodeCommand(cmd), nil, false, true)
+	if err != nil {
+		return 0, err
+	}
+
+	return internal.ParseIntegerResponse(b)
+}
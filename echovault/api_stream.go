@@ -0,0 +1,579 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package echovault
+
+import (
+	"bytes"
+	"github.com/echovault/echovault/internal"
+	"github.com/tidwall/resp"
+	"strconv"
+	"time"
+)
+
+// StreamEntry is an entry of a stream.
+//
+// ID is the ID of the entry in the "<ms>-<seq>" format.
+//
+// Fields holds the field-value pairs of the entry, alternating between fields and values.
+type StreamEntry struct {
+	ID     string
+	Fields []string
+}
+
+// XAddOptions allows you to modify the effects of the XAdd command.
+//
+// NoMkStream prevents the stream from being created if it does not exist.
+//
+// MaxLen caps the stream by removing the oldest entries so that the stream has at most MaxLen entries.
+// When MaxLen is 0, the stream is not capped.
+type XAddOptions struct {
+	NoMkStream bool
+	MaxLen     uint
+}
+
+// XReadOptions allows you to modify the effects of the XRead command.
+//
+// Count specifies the maximum number of entries to return from each stream. When Count is 0, all the entries are returned.
+//
+// Block instructs XRead to block until an entry is available in one of the streams or the Timeout expires.
+// A Timeout of 0 blocks indefinitely.
+type XReadOptions struct {
+	Count   uint
+	Block   bool
+	Timeout time.Duration
+}
+
+// XReadGroupOptions allows you to modify the effects of the XReadGroup command.
+//
+// Count specifies the maximum number of entries to return from each stream. When Count is 0, all the entries are returned.
+//
+// Block instructs XReadGroup to block until an entry is available in one of the streams or the Timeout expires.
+// A Timeout of 0 blocks indefinitely.
+//
+// NoAck delivers the entries without adding them to the pending entries list of the consumer.
+type XReadGroupOptions struct {
+	Count   uint
+	Block   bool
+	Timeout time.Duration
+	NoAck   bool
+}
+
+// StreamPendingSummary is the summary of the pending entries of a consumer group.
+//
+// Count is the number of pending entries in the group.
+//
+// MinID and MaxID are the smallest and largest IDs of the pending entries. They're empty when Count is 0.
+//
+// Consumers maps the consumers that have pending entries to the number of entries pending for them.
+type StreamPendingSummary struct {
+	Count     int
+	MinID     string
+	MaxID     string
+	Consumers map[string]int
+}
+
+// StreamPendingEntry is an entry in the pending entries list of a consumer group.
+//
+// ID is the ID of the entry.
+//
+// Consumer is the consumer the entry was delivered to.
+//
+// Idle is the time elapsed since the entry was last delivered.
+//
+// DeliveryCount is the number of times the entry has been delivered.
+type StreamPendingEntry struct {
+	ID            string
+	Consumer      string
+	Idle          time.Duration
+	DeliveryCount int
+}
+
+// XPendingRangeOptions allows you to modify the effects of the XPendingRange command.
+//
+// MinIdle only returns the entries that have been idle for at least MinIdle.
+//
+// Consumer only returns the entries pending for the consumer. When Consumer is empty, the entries of all
+// the consumers are returned.
+type XPendingRangeOptions struct {
+	MinIdle  time.Duration
+	Consumer string
+}
+
+// XClaimOptions allows you to modify the effects of the XClaim command.
+//
+// Idle sets the idle time of the claimed entries. Time sets their last delivery time instead.
+// When neither is provided, the claimed entries are considered delivered at the time XClaim is called.
+//
+// RetryCount sets the delivery count of the claimed entries. When RetryCount is nil, the delivery count
+// is incremented, unless JustID is true.
+//
+// Force claims the entries that are not in the pending entries list, as long as they exist in the stream.
+//
+// JustID returns only the IDs of the claimed entries.
+type XClaimOptions struct {
+	Idle       time.Duration
+	Time       time.Time
+	RetryCount *uint
+	Force      bool
+	JustID     bool
+}
+
+func parseStreamEntries(v resp.Value) []StreamEntry {
+	entries := make([]StreamEntry, len(v.Array()))
+	for i, e := range v.Array() {
+		// Pending entries that have been removed from the stream have no fields.
+		if e.Array()[1].IsNull() {
+			entries[i] = StreamEntry{ID: e.Array()[0].String()}
+			continue
+		}
+		entry := StreamEntry{ID: e.Array()[0].String(), Fields: make([]string, 0)}
+		for _, field := range e.Array()[1].Array() {
+			entry.Fields = append(entry.Fields, field.String())
+		}
+		entries[i] = entry
+	}
+	return entries
+}
+
+// XAdd appends an entry to the stream.
+//
+// Parameters:
+//
+// `key` - string - the key of the stream.
+//
+// `id` - string - the ID of the entry. Pass "*" to generate the ID from the current time,
+// or "<ms>-*" to only generate the sequence number.
+//
+// `fields` - []string - the field-value pairs of the entry, alternating between fields and values.
+//
+// `options` - XAddOptions.
+//
+// Returns: The ID of the added entry. Returns an empty string if the stream does not exist and NoMkStream is true.
+//
+// Errors:
+//
+// "value at key <key> is not a stream" - when the provided key exists but is not a stream.
+//
+// "the ID specified in XADD is equal or smaller than the target stream top item" - when the ID is not greater than
+// the ID of the last entry in the stream.
+func (server *EchoVault) XAdd(key string, id string, fields []string, options XAddOptions) (string, error) {
+	cmd := []string{"XADD", key}
+	if options.NoMkStream {
+		cmd = append(cmd, "NOMKSTREAM")
+	}
+	if options.MaxLen > 0 {
+		cmd = append(cmd, "MAXLEN", strconv.FormatUint(uint64(options.MaxLen), 10))
+	}
+	cmd = append(append(cmd, id), fields...)
+
+	b, err := server.handleCommand(server.context, internal.EncodeCommand(cmd), nil, false, true)
+	if err != nil {
+		return "", err
+	}
+	return internal.ParseStringResponse(b)
+}
+
+// XLen returns the number of entries in the stream.
+//
+// Parameters:
+//
+// `key` - string - the key of the stream.
+//
+// Returns: The number of entries in the stream. Returns 0 if the key does not exist.
+//
+// Errors:
+//
+// "value at key <key> is not a stream" - when the provided key exists but is not a stream.
+func (server *EchoVault) XLen(key string) (int, error) {
+	b, err := server.handleCommand(server.context, internal.EncodeCommand([]string{"XLEN", key}), nil, false, true)
+	if err != nil {
+		return 0, err
+	}
+	return internal.ParseIntegerResponse(b)
+}
+
+func (server *EchoVault) xrange(command string, key string, from string, to string, count uint) ([]StreamEntry, error) {
+	cmd := []string{command, key, from, to}
+	if count > 0 {
+		cmd = append(cmd, "COUNT", strconv.FormatUint(uint64(count), 10))
+	}
+
+	b, err := server.handleCommand(server.context, internal.EncodeCommand(cmd), nil, false, true)
+	if err != nil {
+		return nil, err
+	}
+
+	v, _, err := resp.NewReader(bytes.NewReader(b)).ReadValue()
+	if err != nil {
+		return nil, err
+	}
+	return parseStreamEntries(v), nil
+}
+
+// XRange returns the entries of the stream with IDs between start and end, in ascending order of ID.
+//
+// Parameters:
+//
+// `key` - string - the key of the stream.
+//
+// `start` - string - the smallest ID to return. "-" is the smallest possible ID. Prefix the ID with "(" to exclude it.
+//
+// `end` - string - the largest ID to return. "+" is the largest possible ID. Prefix the ID with "(" to exclude it.
+//
+// `count` - uint - the maximum number of entries to return. When count is 0, all the entries in the range are returned.
+//
+// Returns: The entries in the range. Returns an empty slice if the key does not exist.
+//
+// Errors:
+//
+// "value at key <key> is not a stream" - when the provided key exists but is not a stream.
+func (server *EchoVault) XRange(key string, start string, end string, count uint) ([]StreamEntry, error) {
+	return server.xrange("XRANGE", key, start, end, count)
+}
+
+// XRevRange works like XRange, but returns the entries in descending order of ID.
+// Note that the end ID is provided before the start ID.
+func (server *EchoVault) XRevRange(key string, end string, start string, count uint) ([]StreamEntry, error) {
+	return server.xrange("XREVRANGE", key, end, start, count)
+}
+
+// XRead returns the entries with IDs greater than the provided IDs from each of the streams.
+//
+// Parameters:
+//
+// `streams` - map[string]string - a map of the stream keys to the ID after which to read entries.
+// Pass "$" as the ID to only read the entries added after XRead is called.
+//
+// `options` - XReadOptions.
+//
+// Returns: A map of the stream keys to their new entries. Streams without new entries are omitted.
+// Returns an empty map if none of the streams have new entries before the timeout expires.
+//
+// Errors:
+//
+// "value at key <key> is not a stream" - when one of the provided keys exists but is not a stream.
+func (server *EchoVault) XRead(streams map[string]string, options XReadOptions) (map[string][]StreamEntry, error) {
+	cmd := []string{"XREAD"}
+	if options.Count > 0 {
+		cmd = append(cmd, "COUNT", strconv.FormatUint(uint64(options.Count), 10))
+	}
+	if options.Block {
+		// Round the timeout up to the next millisecond so that a short timeout does not block indefinitely.
+		timeout := (options.Timeout + time.Millisecond - 1).Milliseconds()
+		cmd = append(cmd, "BLOCK", strconv.FormatInt(timeout, 10))
+	}
+	cmd = append(cmd, "STREAMS")
+	var ids []string
+	for key, id := range streams {
+		cmd = append(cmd, key)
+		ids = append(ids, id)
+	}
+	cmd = append(cmd, ids...)
+
+	b, err := server.handleCommand(server.context, internal.EncodeCommand(cmd), nil, false, true)
+	if err != nil {
+		return nil, err
+	}
+
+	v, _, err := resp.NewReader(bytes.NewReader(b)).ReadValue()
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string][]StreamEntry)
+	for _, s := range v.Array() {
+		res[s.Array()[0].String()] = parseStreamEntries(s.Array()[1])
+	}
+	return res, nil
+}
+
+// XGroupCreate creates a consumer group for the stream.
+//
+// Parameters:
+//
+// `key` - string - the key of the stream.
+//
+// `group` - string - the name of the group.
+//
+// `id` - string - the group delivers the entries with IDs greater than this ID.
+// Pass "$" to only deliver the entries added after the group is created.
+//
+// `mkStream` - bool - create an empty stream if the key does not exist.
+//
+// Returns: "OK" when the group is created.
+//
+// Errors:
+//
+// "key <key> does not exist, use MKSTREAM to create an empty stream" - when the key does not exist and mkStream is false.
+//
+// "consumer group <group> already exists" - when the stream already has a group with the provided name.
+func (server *EchoVault) XGroupCreate(key string, group string, id string, mkStream bool) (string, error) {
+	cmd := []string{"XGROUP", "CREATE", key, group, id}
+	if mkStream {
+		cmd = append(cmd, "MKSTREAM")
+	}
+	b, err := server.handleCommand(server.context, internal.EncodeCommand(cmd), nil, false, true)
+	if err != nil {
+		return "", err
+	}
+	return internal.ParseStringResponse(b)
+}
+
+// XGroupDestroy deletes the consumer group along with its pending entries.
+//
+// Parameters:
+//
+// `key` - string - the key of the stream.
+//
+// `group` - string - the name of the group.
+//
+// Returns: true if the group was deleted, false if the group does not exist.
+//
+// Errors:
+//
+// "key <key> does not exist" - when the key does not exist.
+func (server *EchoVault) XGroupDestroy(key string, group string) (bool, error) {
+	b, err := server.handleCommand(server.context, internal.EncodeCommand([]string{"XGROUP", "DESTROY", key, group}), nil, false, true)
+	if err != nil {
+		return false, err
+	}
+	return internal.ParseBooleanResponse(b)
+}
+
+// XReadGroup reads the entries of the streams on behalf of a consumer of the group.
+//
+// Parameters:
+//
+// `group` - string - the name of the group.
+//
+// `consumer` - string - the name of the consumer.
+//
+// `streams` - map[string]string - a map of the stream keys to the ID after which to read entries.
+// Pass ">" as the ID to read the entries that have not been delivered to any consumer of the group.
+// Any other ID returns the consumer's pending entries with IDs greater than the ID.
+//
+// `options` - XReadGroupOptions.
+//
+// Returns: A map of the stream keys to the entries read. Streams without new entries are omitted.
+// Pending entries that have been removed from the stream are returned with nil Fields.
+//
+// Errors:
+//
+// "no such key <key> or consumer group <group>" - when one of the streams or its group does not exist.
+func (server *EchoVault) XReadGroup(group string, consumer string, streams map[string]string, options XReadGroupOptions) (map[string][]StreamEntry, error) {
+	cmd := []string{"XREADGROUP", "GROUP", group, consumer}
+	if options.Count > 0 {
+		cmd = append(cmd, "COUNT", strconv.FormatUint(uint64(options.Count), 10))
+	}
+	if options.Block {
+		// Round the timeout up to the next millisecond so that a short timeout does not block indefinitely.
+		timeout := (options.Timeout + time.Millisecond - 1).Milliseconds()
+		cmd = append(cmd, "BLOCK", strconv.FormatInt(timeout, 10))
+	}
+	if options.NoAck {
+		cmd = append(cmd, "NOACK")
+	}
+	cmd = append(cmd, "STREAMS")
+	var ids []string
+	for key, id := range streams {
+		cmd = append(cmd, key)
+		ids = append(ids, id)
+	}
+	cmd = append(cmd, ids...)
+
+	b, err := server.handleCommand(server.context, internal.EncodeCommand(cmd), nil, false, true)
+	if err != nil {
+		return nil, err
+	}
+
+	v, _, err := resp.NewReader(bytes.NewReader(b)).ReadValue()
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[string][]StreamEntry)
+	for _, s := range v.Array() {
+		res[s.Array()[0].String()] = parseStreamEntries(s.Array()[1])
+	}
+	return res, nil
+}
+
+// XAck removes the entries from the pending entries list of the group.
+//
+// Parameters:
+//
+// `key` - string - the key of the stream.
+//
+// `group` - string - the name of the group.
+//
+// `ids` - ...string - the IDs of the entries to acknowledge.
+//
+// Returns: The number of entries acknowledged. Returns 0 if the stream or the group does not exist.
+func (server *EchoVault) XAck(key string, group string, ids ...string) (int, error) {
+	cmd := append([]string{"XACK", key, group}, ids...)
+	b, err := server.handleCommand(server.context, internal.EncodeCommand(cmd), nil, false, true)
+	if err != nil {
+		return 0, err
+	}
+	return internal.ParseIntegerResponse(b)
+}
+
+// XPending returns a summary of the pending entries of the group.
+//
+// Parameters:
+//
+// `key` - string - the key of the stream.
+//
+// `group` - string - the name of the group.
+//
+// Returns: A StreamPendingSummary.
+//
+// Errors:
+//
+// "no such key <key> or consumer group <group>" - when the stream or the group does not exist.
+func (server *EchoVault) XPending(key string, group string) (StreamPendingSummary, error) {
+	b, err := server.handleCommand(server.context, internal.EncodeCommand([]string{"XPENDING", key, group}), nil, false, true)
+	if err != nil {
+		return StreamPendingSummary{}, err
+	}
+
+	v, _, err := resp.NewReader(bytes.NewReader(b)).ReadValue()
+	if err != nil {
+		return StreamPendingSummary{}, err
+	}
+	summary := StreamPendingSummary{
+		Count:     v.Array()[0].Integer(),
+		MinID:     v.Array()[1].String(),
+		MaxID:     v.Array()[2].String(),
+		Consumers: make(map[string]int),
+	}
+	for _, c := range v.Array()[3].Array() {
+		summary.Consumers[c.Array()[0].String()] = c.Array()[1].Integer()
+	}
+	return summary, nil
+}
+
+// XPendingRange returns the pending entries of the group with IDs between start and end.
+//
+// Parameters:
+//
+// `key` - string - the key of the stream.
+//
+// `group` - string - the name of the group.
+//
+// `start` - string - the smallest ID to return. "-" is the smallest possible ID.
+//
+// `end` - string - the largest ID to return. "+" is the largest possible ID.
+//
+// `count` - uint - the maximum number of entries to return.
+//
+// `options` - XPendingRangeOptions.
+//
+// Returns: The pending entries in the range in ascending order of ID.
+//
+// Errors:
+//
+// "no such key <key> or consumer group <group>" - when the stream or the group does not exist.
+func (server *EchoVault) XPendingRange(key string, group string, start string, end string, count uint, options XPendingRangeOptions) ([]StreamPendingEntry, error) {
+	cmd := []string{"XPENDING", key, group}
+	if options.MinIdle > 0 {
+		cmd = append(cmd, "IDLE", strconv.FormatInt(options.MinIdle.Milliseconds(), 10))
+	}
+	cmd = append(cmd, start, end, strconv.FormatUint(uint64(count), 10))
+	if options.Consumer != "" {
+		cmd = append(cmd, options.Consumer)
+	}
+
+	b, err := server.handleCommand(server.context, internal.EncodeCommand(cmd), nil, false, true)
+	if err != nil {
+		return nil, err
+	}
+
+	v, _, err := resp.NewReader(bytes.NewReader(b)).ReadValue()
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]StreamPendingEntry, len(v.Array()))
+	for i, e := range v.Array() {
+		entries[i] = StreamPendingEntry{
+			ID:            e.Array()[0].String(),
+			Consumer:      e.Array()[1].String(),
+			Idle:          time.Duration(e.Array()[2].Integer()) * time.Millisecond,
+			DeliveryCount: e.Array()[3].Integer(),
+		}
+	}
+	return entries, nil
+}
+
+// XClaim transfers the ownership of pending entries of the group to the consumer.
+//
+// Parameters:
+//
+// `key` - string - the key of the stream.
+//
+// `group` - string - the name of the group.
+//
+// `consumer` - string - the consumer that claims the entries.
+//
+// `minIdle` - time.Duration - only the entries that have been idle for at least minIdle are claimed.
+//
+// `ids` - []string - the IDs of the entries to claim.
+//
+// `options` - XClaimOptions.
+//
+// Returns: The claimed entries. When JustID is true, the Fields of the entries are nil.
+//
+// Errors:
+//
+// "no such key <key> or consumer group <group>" - when the stream or the group does not exist.
+func (server *EchoVault) XClaim(key string, group string, consumer string, minIdle time.Duration, ids []string, options XClaimOptions) ([]StreamEntry, error) {
+	cmd := append([]string{"XCLAIM", key, group, consumer, strconv.FormatInt(minIdle.Milliseconds(), 10)}, ids...)
+	switch {
+	case !options.Time.IsZero():
+		cmd = append(cmd, "TIME", strconv.FormatInt(options.Time.UnixMilli(), 10))
+	case options.Idle > 0:
+		cmd = append(cmd, "IDLE", strconv.FormatInt(options.Idle.Milliseconds(), 10))
+	}
+	if options.RetryCount != nil {
+		cmd = append(cmd, "RETRYCOUNT", strconv.FormatUint(uint64(*options.RetryCount), 10))
+	}
+	if options.Force {
+		cmd = append(cmd, "FORCE")
+	}
+	if options.JustID {
+		cmd = append(cmd, "JUSTID")
+	}
+
+	b, err := server.handleCommand(server.context, internal.EncodeCommand(cmd), nil, false, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if !options.JustID {
+		v, _, err := resp.NewReader(bytes.NewReader(b)).ReadValue()
+		if err != nil {
+			return nil, err
+		}
+		return parseStreamEntries(v), nil
+	}
+
+	claimed, err := internal.ParseStringArrayResponse(b)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]StreamEntry, len(claimed))
+	for i, id := range claimed {
+		entries[i] = StreamEntry{ID: id}
+	}
+	return entries, nil
+}
@@ -20,13 +20,19 @@ import (
 )
 
 // SetRange replaces a portion of the string at the provided key starting at the offset with a new string.
-// If the string does not exist, a new string is created.
+// If the string does not exist, a new string is created. If the offset is past the end of the string,
+// the string is padded with zero bytes up to the offset.
+//
+// When the protocol-compat config parameter is echovault, a negative offset prepends the new string and an offset
+// past the end of the string appends the new string instead.
 //
 // Returns: The length of the new string as an integers.
 //
 // Errors:
 //
 // - "value at key <key> is not a string" when the key provided does not hold a string.
+//
+// - "offset is out of range" when the offset is negative and the protocol-compat config parameter is redis-6 or redis-7.
 func (server *EchoVault) SetRange(key string, offset int, new string) (int, error) {
 	b, err := server.handleCommand(server.context, internal.EncodeCommand([]string{"SETRANGE", key, strconv.Itoa(offset), new}), nil, false, true)
 	if err != nil {
@@ -35,6 +41,105 @@ func (server *EchoVault) SetRange(key string, offset int, new string) (int, erro
 	return internal.ParseIntegerResponse(b)
 }
 
+// SetIf atomically replaces the value at the key with new only if the current value equals expected.
+// When ttl is greater than 0, the key expires after ttl seconds if the value is replaced.
+//
+// Returns: The value held at the key before the command. The swap took place if this equals expected.
+// If the key does not exist, an empty string is returned and nothing is set.
+//
+// Errors:
+//
+// - "value at key <key> is not a string" - when the value at the key is not a string.
+func (server *EchoVault) SetIf(key, expected, new string, ttl uint) (string, error) {
+	cmd := []string{"SETIF", key, expected, new}
+	if ttl > 0 {
+		cmd = append(cmd, "EX", strconv.Itoa(int(ttl)))
+	}
+	b, err := server.handleCommand(server.context, internal.EncodeCommand(cmd), nil, false, true)
+	if err != nil {
+		return "", err
+	}
+	return internal.ParseStringResponse(b)
+}
+
+// Append appends the value to the string at the key. If the key does not exist, it is created with the value.
+// Integer and float values are treated as their string representation.
+//
+// Returns: The length of the string after the append operation.
+//
+// Errors:
+//
+// - "value at key <key> is not a string" - when the value at the key is not a string.
+func (server *EchoVault) Append(key, value string) (int, error) {
+	b, err := server.handleCommand(server.context, internal.EncodeCommand([]string{"APPEND", key, value}), nil, false, true)
+	if err != nil {
+		return 0, err
+	}
+	return internal.ParseIntegerResponse(b)
+}
+
+// Incr increments the integer at the key by 1. If the key does not exist, it is set to 0 before the operation.
+//
+// Returns: The value at the key after the increment.
+//
+// Errors:
+//
+// - "value at key <key> is not an integer or out of range" - when the value at the key is not an integer.
+//
+// - "increment or decrement would overflow" - when the result does not fit in a 64-bit integer.
+func (server *EchoVault) Incr(key string) (int, error) {
+	b, err := server.handleCommand(server.context, internal.EncodeCommand([]string{"INCR", key}), nil, false, true)
+	if err != nil {
+		return 0, err
+	}
+	return internal.ParseIntegerResponse(b)
+}
+
+// Decr behaves like Incr but decrements the integer at the key by 1.
+func (server *EchoVault) Decr(key string) (int, error) {
+	b, err := server.handleCommand(server.context, internal.EncodeCommand([]string{"DECR", key}), nil, false, true)
+	if err != nil {
+		return 0, err
+	}
+	return internal.ParseIntegerResponse(b)
+}
+
+// IncrBy behaves like Incr but increments the integer at the key by the provided increment.
+func (server *EchoVault) IncrBy(key string, increment int) (int, error) {
+	b, err := server.handleCommand(server.context, internal.EncodeCommand([]string{"INCRBY", key, strconv.Itoa(increment)}), nil, false, true)
+	if err != nil {
+		return 0, err
+	}
+	return internal.ParseIntegerResponse(b)
+}
+
+// DecrBy behaves like Incr but decrements the integer at the key by the provided decrement.
+func (server *EchoVault) DecrBy(key string, decrement int) (int, error) {
+	b, err := server.handleCommand(server.context, internal.EncodeCommand([]string{"DECRBY", key, strconv.Itoa(decrement)}), nil, false, true)
+	if err != nil {
+		return 0, err
+	}
+	return internal.ParseIntegerResponse(b)
+}
+
+// IncrByFloat increments the number at the key by the provided float increment.
+// If the key does not exist, it is set to 0 before the operation.
+//
+// Returns: The value at the key after the increment.
+//
+// Errors:
+//
+// - "value at key <key> is not a valid float" - when the value at the key is not a number.
+//
+// - "increment would produce NaN or Infinity" - when the result is not a finite number.
+func (server *EchoVault) IncrByFloat(key string, increment float64) (float64, error) {
+	b, err := server.handleCommand(server.context, internal.EncodeCommand([]string{"INCRBYFLOAT", key, strconv.FormatFloat(increment, 'f', -1, 64)}), nil, false, true)
+	if err != nil {
+		return 0, err
+	}
+	return internal.ParseFloatResponse(b)
+}
+
 // StrLen returns the length of the string at the provided key.
 //
 // Returns: The length of the string as an integer.
@@ -51,7 +156,11 @@ func (server *EchoVault) StrLen(key string) (int, error) {
 }
 
 // SubStr returns a substring from the string at the key.
-// The start and end indices are integers that specify the lower and upper bound respectively.
+// The start and end indices are integers that specify the lower and upper bound respectively, both inclusive.
+// Negative indices count from the end of the string, and an empty string is returned when the start index
+// is past the end index.
+//
+// When the protocol-compat config parameter is echovault, an inverted range returns the reversed substring instead.
 //
 // Returns: The substring from the start index to the end index.
 //
@@ -76,3 +185,149 @@ func (server *EchoVault) GetRange(key string, start, end int) (string, error) {
 	}
 	return internal.ParseStringResponse(b)
 }
+
+// BitCountOptions allows you to modify the effects of the BitCount command.
+//
+// WithRange limits the count to the range between Start and End, both inclusive.
+// Negative indices count from the end of the string.
+//
+// Bit makes Start and End bit indices instead of byte indices.
+type BitCountOptions struct {
+	WithRange bool
+	Start     int
+	End       int
+	Bit       bool
+}
+
+// BitPosOptions allows you to modify the effects of the BitPos command.
+//
+// WithStart starts the search at Start. WithEnd ends the search at End, and requires WithStart.
+// Negative indices count from the end of the string.
+//
+// Bit makes Start and End bit indices instead of byte indices, and requires WithEnd.
+type BitPosOptions struct {
+	WithStart bool
+	Start     int
+	WithEnd   bool
+	End       int
+	Bit       bool
+}
+
+// SetBit sets or clears the bit at the offset of the string at the key.
+// The string is created if it does not exist, and padded with zero bytes so that it contains the offset.
+//
+// Parameters:
+//
+// `key` - string - the key of the string.
+//
+// `offset` - uint - the bit offset, counting from the most significant bit of the first byte.
+//
+// `bit` - int - 1 to set the bit, 0 to clear it.
+//
+// Returns: The original value of the bit.
+//
+// Errors:
+//
+// - "value at key <key> is not a string" - when the value at the key is not a string.
+//
+// - "bit is not an integer or out of range" - when the bit is not 0 or 1.
+func (server *EchoVault) SetBit(key string, offset uint, bit int) (int, error) {
+	cmd := []string{"SETBIT", key, strconv.FormatUint(uint64(offset), 10), strconv.Itoa(bit)}
+	b, err := server.handleCommand(server.context, internal.EncodeCommand(cmd), nil, false, true)
+	if err != nil {
+		return 0, err
+	}
+	return internal.ParseIntegerResponse(b)
+}
+
+// GetBit returns the bit at the offset of the string at the key.
+//
+// Returns: The value of the bit. Returns 0 if the offset is past the end of the string or the key does not exist.
+//
+// Errors:
+//
+// - "value at key <key> is not a string" - when the value at the key is not a string.
+func (server *EchoVault) GetBit(key string, offset uint) (int, error) {
+	cmd := []string{"GETBIT", key, strconv.FormatUint(uint64(offset), 10)}
+	b, err := server.handleCommand(server.context, internal.EncodeCommand(cmd), nil, false, true)
+	if err != nil {
+		return 0, err
+	}
+	return internal.ParseIntegerResponse(b)
+}
+
+// BitCount returns the number of set bits in the string at the key.
+//
+// Returns: The number of set bits. Returns 0 if the key does not exist.
+//
+// Errors:
+//
+// - "value at key <key> is not a string" - when the value at the key is not a string.
+func (server *EchoVault) BitCount(key string, options BitCountOptions) (int, error) {
+	cmd := []string{"BITCOUNT", key}
+	if options.WithRange {
+		cmd = append(cmd, strconv.Itoa(options.Start), strconv.Itoa(options.End))
+		if options.Bit {
+			cmd = append(cmd, "BIT")
+		}
+	}
+	b, err := server.handleCommand(server.context, internal.EncodeCommand(cmd), nil, false, true)
+	if err != nil {
+		return 0, err
+	}
+	return internal.ParseIntegerResponse(b)
+}
+
+// BitPos returns the position of the first bit set to the provided value in the string at the key.
+//
+// Returns: The bit position, or -1 if there is no such bit. When looking for a clear bit without an end index,
+// the bits past the end of the string are considered clear.
+//
+// Errors:
+//
+// - "value at key <key> is not a string" - when the value at the key is not a string.
+//
+// - "the bit argument must be 1 or 0" - when the bit is not 0 or 1.
+func (server *EchoVault) BitPos(key string, bit int, options BitPosOptions) (int, error) {
+	cmd := []string{"BITPOS", key, strconv.Itoa(bit)}
+	if options.WithStart {
+		cmd = append(cmd, strconv.Itoa(options.Start))
+		if options.WithEnd {
+			cmd = append(cmd, strconv.Itoa(options.End))
+			if options.Bit {
+				cmd = append(cmd, "BIT")
+			}
+		}
+	}
+	b, err := server.handleCommand(server.context, internal.EncodeCommand(cmd), nil, false, true)
+	if err != nil {
+		return 0, err
+	}
+	return internal.ParseIntegerResponse(b)
+}
+
+// BitOp performs a bitwise operation between the strings at the keys and stores the result in the destination.
+//
+// Parameters:
+//
+// `operation` - string - one of "AND", "OR", "XOR" or "NOT".
+//
+// `destination` - string - the key to store the result in. The key is deleted if the result is empty.
+//
+// `keys` - ...string - the keys of the strings. Shorter strings are padded with zero bytes. NOT takes a single key.
+//
+// Returns: The length of the result in bytes.
+//
+// Errors:
+//
+// - "value at key <key> is not a string" - when the value at one of the keys is not a string.
+//
+// - "BITOP NOT must be called with a single source key" - when NOT is called with more than one key.
+func (server *EchoVault) BitOp(operation string, destination string, keys ...string) (int, error) {
+	cmd := append([]string{"BITOP", operation, destination}, keys...)
+	b, err := server.handleCommand(server.context, internal.EncodeCommand(cmd), nil, false, true)
+	if err != nil {
+		return 0, err
+	}
+	return internal.ParseIntegerResponse(b)
+}
@@ -16,14 +16,22 @@ package echovault
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
 	"github.com/echovault/echovault/internal"
-	"time"
+	"slices"
 )
 
 func (server *EchoVault) isInCluster() bool {
-	return server.config.BootstrapCluster || server.config.JoinAddr != ""
+	return server.config.BootstrapCluster ||
+		server.config.JoinAddr != "" ||
+		slices.Contains([]string{"dns-srv", "kubernetes"}, server.config.PeerDiscovery)
+}
+
+// forwardPublish delivers a message published on this node to the subscribers on the other nodes of the cluster.
+func (server *EchoVault) forwardPublish(ctx context.Context, channel string, message string) {
+	if !server.isInCluster() {
+		return
+	}
+	server.memberList.ForwardPublish(ctx, channel, message)
 }
 
 func (server *EchoVault) raftApplyDeleteKey(ctx context.Context, key string) error {
@@ -36,23 +44,11 @@ func (server *EchoVault) raftApplyDeleteKey(ctx context.Context, key string) err
 		Key:          key,
 	}
 
-	b, err := json.Marshal(deleteKeyRequest)
+	r, err := server.raft.Propose(ctx, deleteKeyRequest)
 	if err != nil {
-		return fmt.Errorf("could not parse delete key request for key: %s", key)
-	}
-
-	applyFuture := server.raft.Apply(b, 500*time.Millisecond)
-
-	if err = applyFuture.Error(); err != nil {
 		return err
 	}
 
-	r, ok := applyFuture.Response().(internal.ApplyResponse)
-
-	if !ok {
-		return fmt.Errorf("unprocessable entity %v", r)
-	}
-
 	if r.Error != nil {
 		return r.Error
 	}
@@ -71,23 +67,11 @@ func (server *EchoVault) raftApplyCommand(ctx context.Context, cmd []string) ([]
 		CMD:          cmd,
 	}
 
-	b, err := json.Marshal(applyRequest)
+	r, err := server.raft.Propose(ctx, applyRequest)
 	if err != nil {
-		return nil, fmt.Errorf("could not parse command request for commad: %+v", cmd)
-	}
-
-	applyFuture := server.raft.Apply(b, 500*time.Millisecond)
-
-	if err = applyFuture.Error(); err != nil {
 		return nil, err
 	}
 
-	r, ok := applyFuture.Response().(internal.ApplyResponse)
-
-	if !ok {
-		return nil, fmt.Errorf("unprocessable entity %v", r)
-	}
-
 	if r.Error != nil {
 		return nil, r.Error
 	}
@@ -1,7 +1,15 @@
 package echovault
 
 import (
+	"fmt"
+	"github.com/echovault/echovault/internal"
 	"github.com/echovault/echovault/internal/config"
+	"github.com/echovault/echovault/internal/constants"
+	"github.com/echovault/echovault/internal/modules/set"
+	"github.com/echovault/echovault/internal/modules/sorted_set"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // DefaultConfig returns the default configuration.
@@ -9,3 +17,132 @@ import (
 func DefaultConfig() config.Config {
 	return config.DefaultConfig()
 }
+
+// getConfig returns a copy of the current configuration.
+func (server *EchoVault) getConfig() config.Config {
+	server.configMutex.RLock()
+	defer server.configMutex.RUnlock()
+	return server.config
+}
+
+// getConfigParameters returns the current values of the configuration parameters that can be updated at runtime.
+func (server *EchoVault) getConfigParameters() map[string]string {
+	conf := server.getConfig()
+	return map[string]string{
+		"snapshot-threshold":      strconv.FormatUint(conf.SnapShotThreshold, 10),
+		"snapshot-interval":       conf.SnapshotInterval.String(),
+		"data-dir":                conf.DataDir,
+		"protocol-compat":         conf.ProtocolCompat,
+		"slowlog-log-slower-than": conf.SlowlogThreshold.String(),
+		"slowlog-max-len":         strconv.FormatUint(uint64(conf.SlowlogMaxLen), 10),
+		"lfu-log-factor":          strconv.FormatUint(uint64(conf.LFULogFactor), 10),
+		"lfu-decay-time":          conf.LFUDecayTime.String(),
+		"max-key-length":          strconv.FormatUint(conf.MaxKeyLength, 10),
+		"lua-time-limit":          conf.LuaTimeLimit.String(),
+
+		// The listpack limits of the set and sorted set encodings.
+		"set-max-listpack-entries":  strconv.FormatUint(uint64(conf.SetListpackSize), 10),
+		"set-max-listpack-value":    strconv.FormatUint(uint64(conf.SetListpackValue), 10),
+		"zset-max-listpack-entries": strconv.FormatUint(uint64(conf.ZSetListpackSize), 10),
+		"zset-max-listpack-value":   strconv.FormatUint(uint64(conf.ZSetListpackValue), 10),
+	}
+}
+
+// setConfigParameter updates a configuration parameter at runtime.
+// The snapshot and AOF engines read these parameters every time they use them,
+// so the new values take effect without restarting the engines.
+func (server *EchoVault) setConfigParameter(parameter string, value string) error {
+	server.configMutex.Lock()
+	defer server.configMutex.Unlock()
+
+	switch strings.ToLower(parameter) {
+	default:
+		return fmt.Errorf("unsupported config parameter %s", parameter)
+	case "snapshot-threshold":
+		threshold, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("snapshot-threshold must be a positive integer, got %s", value)
+		}
+		server.config.SnapShotThreshold = threshold
+	case "snapshot-interval":
+		interval, err := time.ParseDuration(value)
+		if err != nil || interval < 0 {
+			return fmt.Errorf("snapshot-interval must be a positive duration (e.g. 5m30s), got %s", value)
+		}
+		server.config.SnapshotInterval = interval
+	case "data-dir":
+		if value == "" {
+			return fmt.Errorf("data-dir cannot be empty")
+		}
+		server.config.DataDir = value
+	case "slowlog-log-slower-than":
+		threshold, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("slowlog-log-slower-than must be a duration (e.g. 10ms), got %s", value)
+		}
+		server.config.SlowlogThreshold = threshold
+	case "slowlog-max-len":
+		maxLen, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return fmt.Errorf("slowlog-max-len must be a positive integer, got %s", value)
+		}
+		server.config.SlowlogMaxLen = uint(maxLen)
+	case "lfu-log-factor":
+		factor, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return fmt.Errorf("lfu-log-factor must be a positive integer, got %s", value)
+		}
+		server.config.LFULogFactor = uint(factor)
+	case "lfu-decay-time":
+		decayTime, err := time.ParseDuration(value)
+		if err != nil || decayTime < 0 {
+			return fmt.Errorf("lfu-decay-time must be a positive duration (e.g. 1m), got %s", value)
+		}
+		server.config.LFUDecayTime = decayTime
+	case "max-key-length":
+		maxKeyLength, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("max-key-length must be a positive integer, got %s", value)
+		}
+		server.config.MaxKeyLength = maxKeyLength
+	case "lua-time-limit":
+		limit, err := time.ParseDuration(value)
+		if err != nil || limit < 0 {
+			return fmt.Errorf("lua-time-limit must be a positive duration (e.g. 5s), got %s", value)
+		}
+		server.config.LuaTimeLimit = limit
+	case "set-max-listpack-entries", "set-max-listpack-value", "zset-max-listpack-entries", "zset-max-listpack-value":
+		limit, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return fmt.Errorf("%s must be a positive integer, got %s", strings.ToLower(parameter), value)
+		}
+		switch strings.ToLower(parameter) {
+		case "set-max-listpack-entries":
+			server.config.SetListpackSize = uint(limit)
+		case "set-max-listpack-value":
+			server.config.SetListpackValue = uint(limit)
+		case "zset-max-listpack-entries":
+			server.config.ZSetListpackSize = uint(limit)
+		case "zset-max-listpack-value":
+			server.config.ZSetListpackValue = uint(limit)
+		}
+		server.setListpackLimits()
+	case "protocol-compat":
+		switch level := strings.ToLower(value); level {
+		default:
+			return fmt.Errorf("protocol-compat must be echovault, redis-6 or redis-7, got %s", value)
+		case constants.ProtocolCompatEchoVault, constants.ProtocolCompatRedis6, constants.ProtocolCompatRedis7:
+			server.config.ProtocolCompat = level
+			internal.SetProtocolCompat(level)
+		}
+	}
+
+	return nil
+}
+
+// setListpackLimits applies the listpack limits of the configuration to the set and sorted set encodings.
+// The limits are shared by all the instances in the process, so the instance that was configured last takes effect.
+func (server *EchoVault) setListpackLimits() {
+	set.SetListpackLimits(int(server.config.SetListpackSize), int(server.config.SetListpackValue))
+	sorted_set.SetListpackLimits(int(server.config.ZSetListpackSize), int(server.config.ZSetListpackValue))
+}
@@ -16,8 +16,11 @@ package echovault
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha1"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"github.com/echovault/echovault/internal"
@@ -26,7 +29,11 @@ import (
 	"github.com/echovault/echovault/internal/config"
 	"github.com/echovault/echovault/internal/constants"
 	"github.com/echovault/echovault/internal/eviction"
+	"github.com/echovault/echovault/internal/keyindex"
+	"github.com/echovault/echovault/internal/keywait"
+	"github.com/echovault/echovault/internal/latency"
 	"github.com/echovault/echovault/internal/memberlist"
+	"github.com/echovault/echovault/internal/metrics"
 	"github.com/echovault/echovault/internal/modules/acl"
 	"github.com/echovault/echovault/internal/modules/admin"
 	"github.com/echovault/echovault/internal/modules/connection"
@@ -34,15 +41,24 @@ import (
 	"github.com/echovault/echovault/internal/modules/hash"
 	"github.com/echovault/echovault/internal/modules/list"
 	"github.com/echovault/echovault/internal/modules/pubsub"
+	"github.com/echovault/echovault/internal/modules/scripting"
 	"github.com/echovault/echovault/internal/modules/set"
 	"github.com/echovault/echovault/internal/modules/sorted_set"
+	"github.com/echovault/echovault/internal/modules/stream"
 	str "github.com/echovault/echovault/internal/modules/string"
+	"github.com/echovault/echovault/internal/modules/transaction"
 	"github.com/echovault/echovault/internal/raft"
+	"github.com/echovault/echovault/internal/replycache"
+	"github.com/echovault/echovault/internal/selftest"
+	"github.com/echovault/echovault/internal/slowlog"
 	"github.com/echovault/echovault/internal/snapshot"
+	"github.com/echovault/echovault/internal/trace"
 	"io"
 	"log"
 	"net"
+	"net/http"
 	"os"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -54,7 +70,8 @@ type EchoVault struct {
 	getClock func() clock.Clock
 
 	// config holds the echovault configuration variables.
-	config config.Config
+	config      config.Config
+	configMutex sync.RWMutex // Mutex for the configuration parameters that can be updated at runtime.
 
 	// The current index for the latest connection id.
 	// This number is incremented everytime there's a new connection and
@@ -64,6 +81,9 @@ type EchoVault struct {
 	store           map[string]internal.KeyData // Data store to hold the keys and their associated data, expiry time, etc.
 	keyLocks        map[string]*sync.RWMutex    // Map to hold all the individual key locks.
 	keyCreationLock *sync.Mutex                 // The mutex for creating a new key. Only one goroutine should be able to create a key at a time.
+	keyspaceLock    sync.RWMutex                // Guards the store and keyLocks maps. The data of each key is guarded by its key lock.
+	keyIndex        *keyindex.Index             // Index of all the keys in the store used for cursor based iteration.
+	keyWaiters      *keywait.Registry           // Registry of blocking commands waiting for keys to be modified.
 
 	// Holds all the keys that are currently associated with an expiry.
 	keysWithExpiry struct {
@@ -81,6 +101,50 @@ type EchoVault struct {
 		cache eviction.CacheLRU // LRU cache represented by a max head.
 	}
 
+	// Holds the current version of each key in the store.
+	// A key's version is replaced with a new, higher version every time the key is modified.
+	keyVersions struct {
+		rwMutex  sync.RWMutex      // Mutex as only one process should be able to update the versions at a time.
+		counter  uint64            // The latest version handed out to a key.
+		versions map[string]uint64 // Map of key to its current version.
+	}
+	// Held for writing while EXEC runs a transaction, and for reading while any other command runs.
+	transactionLock sync.RWMutex
+	// Holds the callbacks registered with OnExpire, by tag.
+	expireCallbacks struct {
+		rwMutex   sync.RWMutex
+		callbacks map[string]ExpireCallback
+	}
+	// Holds the state of the background keyspace compaction.
+	defrag struct {
+		peakKeys     atomic.Int64  // The highest number of keys in the store since the last compaction.
+		runs         atomic.Uint64 // The number of compactions carried out.
+		releasedKeys atomic.Uint64 // The total number of deleted key entries released by compactions.
+		lastRun      atomic.Int64  // Unix epoch in milliseconds of the last compaction.
+	}
+	// Cache of pre-encoded replies for idempotent read commands. This is nil when the reply cache is disabled.
+	replyCache *replycache.Cache
+
+	// Records every executed command to the trace file. This is nil when tracing is disabled.
+	tracer *trace.Recorder
+
+	// Holds the latency histogram of each command executed on this node.
+	latencyRegistry    *latency.Registry
+	getLatencyRegistry func() *latency.Registry
+
+	// Holds the server-wide counters reported by INFO.
+	metrics    *metrics.Registry
+	getMetrics func() *metrics.Registry
+	startTime  time.Time // The time the server was created, used to report the uptime.
+
+	// Holds the commands that took longer than the slow log threshold to execute.
+	slowLog    *slowlog.Log
+	getSlowLog func() *slowlog.Log
+
+	// Runs the DEBUG SELFTEST measurements and holds the result of the most recent run.
+	selfTester    *selftest.Tester
+	getSelfTester func() *selftest.Tester
+
 	// Holds the list of all commands supported by the echovault.
 	commands    []internal.Command
 	getCommands func() []internal.Command
@@ -89,6 +153,17 @@ type EchoVault struct {
 	memberList *memberlist.MemberList // The memberlist layer for the echovault.
 
 	context context.Context
+	// Cancels the context, which stops the listeners, closes the client connections and stops the background loops.
+	cancel context.CancelFunc
+	// Tracks the accept loops and the client connections, so that ShutDown can wait for them to stop.
+	connections sync.WaitGroup
+	// Tracks the background loops, e.g. key eviction and keyspace maintenance, so that ShutDown can wait for them to stop.
+	backgroundLoops sync.WaitGroup
+	// Guards shuttingDown, so that no accept loop is added to connections after ShutDown starts waiting for them.
+	listenersMutex sync.Mutex
+	shuttingDown   bool
+	// Default deadline of embedded API calls when the context has no deadline. Zero means no default deadline.
+	commandTimeout time.Duration
 
 	acl    *acl.ACL
 	getACL func() interface{}
@@ -96,13 +171,46 @@ type EchoVault struct {
 	pubSub    *pubsub.PubSub
 	getPubSub func() interface{}
 
+	scripts    *scripting.Scripts // Cache of the scripts run with EVAL or loaded with SCRIPT LOAD.
+	getScripts func() interface{}
+
+	clientRegistry    *connection.ClientRegistry // Registry of all the connected TCP clients.
+	getClientRegistry func() interface{}
+
 	snapshotInProgress         atomic.Bool      // Atomic boolean that's true when actively taking a snapshot.
+	snapshotStartedAt          atomic.Int64     // Unix epoch in nanoseconds at which the snapshot in progress started.
+	stallProtection            atomic.Bool      // Atomic boolean that's true while writes are rejected because persistence has stalled.
 	rewriteAOFInProgress       atomic.Bool      // Atomic boolean that's true when actively rewriting AOF file is in progress.
 	stateCopyInProgress        atomic.Bool      // Atomic boolean that's true when actively copying state for snapshotting or preamble generation.
 	stateMutationInProgress    atomic.Bool      // Atomic boolean that is set to true when state mutation is in progress.
 	latestSnapshotMilliseconds atomic.Int64     // Unix epoch in milliseconds
 	snapshotEngine             *snapshot.Engine // Snapshot engine for standalone mode
 	aofEngine                  *aof.Engine      // AOF engine for standalone mode
+
+	// Holds the replication ID and offset in standalone mode.
+	replication struct {
+		mutex  sync.RWMutex
+		seed   string        // Random seed the replication ID is derived from. Empty in cluster mode until it's changed.
+		offset atomic.Uint64 // The number of bytes of write commands processed in standalone mode.
+	}
+
+	restored     atomic.Bool  // Atomic boolean that's true once the state has been restored on startup.
+	clusterReady atomic.Bool  // Atomic boolean that's true once the raft and memberlist layers are initialised.
+	healthServer *http.Server // HTTP server for the health probes. Nil when the health port is not configured.
+
+	// Holds the progress of the AOF, snapshot or raft snapshot restore in progress.
+	// Client commands are rejected with a LOADING error while a restore is in progress.
+	loading struct {
+		inProgress atomic.Bool
+		loaded     atomic.Int64 // The number of keys and commands restored so far.
+		total      atomic.Int64 // The number of keys and commands to restore.
+	}
+
+	// Results of the integrity checks of the snapshot and AOF files carried out on startup.
+	integrity struct {
+		snapshotFailed bool
+		aofFailed      bool
+	}
 }
 
 // WithContext is an options that for the NewEchoVault function that allows you to
@@ -123,8 +231,28 @@ func WithConfig(config config.Config) func(echovault *EchoVault) {
 	}
 }
 
+// WithClock is an option for the NewEchoVault function that sets the clock used for key expiry, eviction
+// and snapshots. The clock can be any value with the methods Now() time.Time and After(d time.Duration) <-chan time.Time,
+// which allows tests to control the passage of time instead of sleeping.
+// If not specified, EchoVault uses the system clock.
+func WithClock(clock clock.Clock) func(echovault *EchoVault) {
+	return func(echovault *EchoVault) {
+		echovault.clock = clock
+	}
+}
+
+// WithCommandTimeout is an option for the NewEchoVault function that sets the default deadline of
+// every embedded API call whose context does not already have a deadline.
+// This prevents an embedded API call from hanging indefinitely, e.g. while waiting for a key lock.
+// If not specified, embedded API calls have no default deadline.
+func WithCommandTimeout(timeout time.Duration) func(echovault *EchoVault) {
+	return func(echovault *EchoVault) {
+		echovault.commandTimeout = timeout
+	}
+}
+
 // NewEchoVault creates a new EchoVault instance.
-// This functions accepts the WithContext, WithConfig and WithCommands options.
+// This functions accepts the WithContext, WithConfig, WithClock, WithCommandTimeout and WithCommands options.
 func NewEchoVault(options ...func(echovault *EchoVault)) (*EchoVault, error) {
 	echovault := &EchoVault{
 		clock:           clock.NewClock(),
@@ -133,6 +261,8 @@ func NewEchoVault(options ...func(echovault *EchoVault)) (*EchoVault, error) {
 		store:           make(map[string]internal.KeyData),
 		keyLocks:        make(map[string]*sync.RWMutex),
 		keyCreationLock: &sync.Mutex{},
+		keyIndex:        keyindex.NewIndex(),
+		keyWaiters:      keywait.NewRegistry(),
 		commands: func() []internal.Command {
 			var commands []internal.Command
 			commands = append(commands, acl.Commands()...)
@@ -142,9 +272,12 @@ func NewEchoVault(options ...func(echovault *EchoVault)) (*EchoVault, error) {
 			commands = append(commands, list.Commands()...)
 			commands = append(commands, connection.Commands()...)
 			commands = append(commands, pubsub.Commands()...)
+			commands = append(commands, scripting.Commands()...)
 			commands = append(commands, set.Commands()...)
 			commands = append(commands, sorted_set.Commands()...)
+			commands = append(commands, stream.Commands()...)
 			commands = append(commands, str.Commands()...)
+			commands = append(commands, transaction.Commands()...)
 			return commands
 		}(),
 	}
@@ -153,10 +286,52 @@ func NewEchoVault(options ...func(echovault *EchoVault)) (*EchoVault, error) {
 		option(echovault)
 	}
 
+	echovault.keyVersions.versions = make(map[string]uint64)
+	echovault.expireCallbacks.callbacks = make(map[string]ExpireCallback)
+	echovault.setListpackLimits()
+	internal.SetProtocolCompat(echovault.config.ProtocolCompat)
+
+	// In cluster mode, the seed is left empty so that all the nodes derive the same replication ID from the raft term.
+	if !echovault.isInCluster() {
+		echovault.changeReplicationID()
+	}
+
+	// Check whether this is the first startup before the persistence engines create their files in the data directory.
+	firstBoot := echovault.config.InitFile != "" && isFirstBoot(echovault.config.DataDir)
+
+	// Start the health probes first so that they can report on the progress of the restore.
+	if echovault.config.HealthPort != 0 {
+		echovault.startHealthServer()
+	}
+
+	// Open the trace file if tracing is enabled
+	if echovault.config.TraceFile != "" {
+		f, err := os.OpenFile(echovault.config.TraceFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("could not open trace file: %+v", err)
+		}
+		echovault.tracer = trace.NewRecorder(f)
+	}
+
+	// Set up the reply cache if it's enabled
+	if echovault.config.ReplyCacheSize > 0 {
+		echovault.replyCache = replycache.NewCache(int(echovault.config.ReplyCacheSize))
+	}
+
+	echovault.latencyRegistry = latency.NewRegistry()
+	echovault.metrics = metrics.NewRegistry()
+	echovault.startTime = echovault.clock.Now()
+	echovault.selfTester = selftest.NewTester(func() string { return echovault.getConfig().DataDir })
+	echovault.slowLog = slowlog.NewLog(
+		func() time.Duration { return echovault.getConfig().SlowlogThreshold },
+		func() int { return int(echovault.getConfig().SlowlogMaxLen) },
+	)
+
 	echovault.context = context.WithValue(
 		echovault.context, "ServerID",
 		internal.ContextServerID(echovault.config.ServerID),
 	)
+	echovault.context, echovault.cancel = context.WithCancel(echovault.context)
 
 	// Function for server commands retrieval
 	echovault.getCommands = func() []internal.Command {
@@ -168,6 +343,32 @@ func NewEchoVault(options ...func(echovault *EchoVault)) (*EchoVault, error) {
 		return echovault.clock
 	}
 
+	// Function for latency registry retrieval
+	echovault.getLatencyRegistry = func() *latency.Registry {
+		return echovault.latencyRegistry
+	}
+
+	// Function for metrics registry retrieval
+	echovault.getMetrics = func() *metrics.Registry {
+		return echovault.metrics
+	}
+
+	// Function for slow log retrieval
+	echovault.getSlowLog = func() *slowlog.Log {
+		return echovault.slowLog
+	}
+
+	// Function for self-tester retrieval
+	echovault.getSelfTester = func() *selftest.Tester {
+		return echovault.selfTester
+	}
+
+	// Set up client registry
+	echovault.clientRegistry = connection.NewClientRegistry()
+	echovault.getClientRegistry = func() interface{} {
+		return echovault.clientRegistry
+	}
+
 	// Set up ACL module
 	echovault.acl = acl.NewACL(echovault.config)
 	echovault.getACL = func() interface{} {
@@ -180,9 +381,16 @@ func NewEchoVault(options ...func(echovault *EchoVault)) (*EchoVault, error) {
 		return echovault.pubSub
 	}
 
+	// Set up scripting module
+	echovault.scripts = scripting.NewScripts()
+	echovault.getScripts = func() interface{} {
+		return echovault.scripts
+	}
+
 	if echovault.isInCluster() {
 		echovault.raft = raft.NewRaft(raft.Opts{
 			Config:                echovault.config,
+			Clock:                 echovault.clock,
 			EchoVault:             echovault,
 			GetCommand:            echovault.getCommand,
 			CreateKeyAndLock:      echovault.CreateKeyAndLock,
@@ -193,6 +401,9 @@ func NewEchoVault(options ...func(echovault *EchoVault)) (*EchoVault, error) {
 			StartSnapshot:         echovault.startSnapshot,
 			FinishSnapshot:        echovault.finishSnapshot,
 			SetLatestSnapshotTime: echovault.setLatestSnapshot,
+			StartRestore:          echovault.startLoading,
+			FinishRestore:         echovault.finishLoading,
+			SetRestoreProgress:    echovault.setLoadingProgress,
 			GetHandlerFuncParams:  echovault.getHandlerFuncParams,
 			GetState: func() map[string]internal.KeyData {
 				state := make(map[string]internal.KeyData)
@@ -212,18 +423,28 @@ func NewEchoVault(options ...func(echovault *EchoVault)) (*EchoVault, error) {
 			IsRaftLeader:     echovault.raft.IsRaftLeader,
 			ApplyMutate:      echovault.raftApplyCommand,
 			ApplyDeleteKey:   echovault.raftApplyDeleteKey,
+			Publish: func(ctx context.Context, channel string, message string) {
+				echovault.pubSub.Publish(ctx, message, channel)
+			},
 		})
 	} else {
 		// Set up standalone snapshot engine
 		echovault.snapshotEngine = snapshot.NewSnapshotEngine(
 			snapshot.WithClock(echovault.clock),
-			snapshot.WithDirectory(echovault.config.DataDir),
-			snapshot.WithThreshold(echovault.config.SnapShotThreshold),
-			snapshot.WithInterval(echovault.config.SnapshotInterval),
+			snapshot.WithDirectoryFunc(func() string {
+				return echovault.getConfig().DataDir
+			}),
+			snapshot.WithThresholdFunc(func() uint64 {
+				return echovault.getConfig().SnapShotThreshold
+			}),
+			snapshot.WithIntervalFunc(func() time.Duration {
+				return echovault.getConfig().SnapshotInterval
+			}),
 			snapshot.WithStartSnapshotFunc(echovault.startSnapshot),
 			snapshot.WithFinishSnapshotFunc(echovault.finishSnapshot),
 			snapshot.WithSetLatestSnapshotTimeFunc(echovault.setLatestSnapshot),
 			snapshot.WithGetLatestSnapshotTimeFunc(echovault.getLatestSnapshotTime),
+			snapshot.WithRestoreProgressFunc(echovault.setLoadingProgress),
 			snapshot.WithGetStateFunc(func() map[string]internal.KeyData {
 				state := make(map[string]internal.KeyData)
 				for k, v := range echovault.getState() {
@@ -248,10 +469,16 @@ func NewEchoVault(options ...func(echovault *EchoVault)) (*EchoVault, error) {
 		// Set up standalone AOF engine
 		echovault.aofEngine = aof.NewAOFEngine(
 			aof.WithClock(echovault.clock),
-			aof.WithDirectory(echovault.config.DataDir),
+			aof.WithDirectoryFunc(func() string {
+				return echovault.getConfig().DataDir
+			}),
 			aof.WithStrategy(echovault.config.AOFSyncStrategy),
+			aof.WithSegmentSize(echovault.config.AOFSegmentSize),
+			aof.WithQueueLimit(echovault.config.AOFQueueLimit),
+			aof.WithQueuePolicy(echovault.config.AOFQueuePolicy),
 			aof.WithStartRewriteFunc(echovault.startRewriteAOF),
 			aof.WithFinishRewriteFunc(echovault.finishRewriteAOF),
+			aof.WithRestoreProgressFunc(echovault.setLoadingProgress),
 			aof.WithGetStateFunc(func() map[string]internal.KeyData {
 				state := make(map[string]internal.KeyData)
 				for k, v := range echovault.getState() {
@@ -281,18 +508,55 @@ func NewEchoVault(options ...func(echovault *EchoVault)) (*EchoVault, error) {
 		)
 	}
 
-	// If eviction policy is not noeviction, start a goroutine to evict keys every 100 milliseconds.
+	// If eviction policy is not noeviction, start a goroutine to evict keys at every eviction interval.
 	if echovault.config.EvictionPolicy != constants.NoEviction {
-		go func() {
-			for {
-				<-echovault.clock.After(echovault.config.EvictionInterval)
-				if err := echovault.evictKeysWithExpiredTTL(context.Background()); err != nil {
+		echovault.runBackgroundLoop(echovault.config.EvictionInterval, func() {
+			if err := echovault.evictKeysWithExpiredTTL(echovault.context); err != nil {
+				log.Println(err)
+			}
+			if echovault.config.EvictionPolicy == constants.VolatileIdle {
+				if err := echovault.evictIdleKeys(echovault.context); err != nil {
 					log.Println(err)
 				}
 			}
+		})
+	}
+
+	// Sample the number of commands processed every 100 milliseconds to calculate the instantaneous operations per second.
+	echovault.runBackgroundLoop(100*time.Millisecond, func() {
+		echovault.metrics.Sample(echovault.clock.Now())
+	})
+
+	// Run the self-test in the background, so that it does not hold up the startup.
+	if echovault.config.SelfTestOnStartup {
+		go func() {
+			result, err := echovault.selfTester.Run()
+			if err != nil {
+				log.Println(err)
+				return
+			}
+			log.Printf("self-test: fsync avg %s max %s, memory bandwidth %d MB/s, lock throughput %d ops/s\n",
+				result.FsyncAvg, result.FsyncMax, result.MemoryBandwidth/(1024*1024), result.LockThroughput)
 		}()
 	}
 
+	// If stall detection is enabled, reject writes while the AOF or snapshot engine is stalled.
+	if !echovault.isInCluster() && echovault.config.StallThreshold > 0 {
+		threshold := echovault.config.StallThreshold
+		echovault.runBackgroundLoop(min(max(threshold/10, 10*time.Millisecond), time.Second), func() {
+			echovault.checkWriteStall(threshold)
+		})
+	}
+
+	// If keyspace maintenance is enabled, periodically remove the locks of keys that no longer exist
+	// and check whether the keyspace should be compacted.
+	if echovault.config.DefragInterval > 0 {
+		echovault.runBackgroundLoop(echovault.config.DefragInterval, func() {
+			echovault.sweepKeyLocks()
+			echovault.compactKeyspace()
+		})
+	}
+
 	if echovault.config.TLS && len(echovault.config.CertKeyPairs) <= 0 {
 		return nil, errors.New("must provide certificate and key file paths for TLS mode")
 	}
@@ -301,13 +565,25 @@ func NewEchoVault(options ...func(echovault *EchoVault)) (*EchoVault, error) {
 		// Initialise raft and memberlist
 		echovault.raft.RaftInit(echovault.context)
 		echovault.memberList.MemberListInit(echovault.context)
+		// The health probes can run before raft is created, so they only read it once this is set.
+		echovault.clusterReady.Store(true)
 		if echovault.raft.IsRaftLeader() {
 			echovault.initialiseCaches()
+			// Only the node that bootstraps the cluster runs the init file, the other nodes receive its writes through raft.
+			if firstBoot {
+				if err := echovault.runInitFile(echovault.config.InitFile); err != nil {
+					return nil, err
+				}
+			}
 		}
 	}
 
 	if !echovault.isInCluster() {
 		echovault.initialiseCaches()
+		// Validate the persisted files before restoring them so that a bad state is reported even if it's not restored.
+		echovault.checkIntegrity()
+		// Client commands are rejected with a LOADING error until the restore is complete.
+		echovault.startLoading()
 		// Restore from AOF by default if it's enabled
 		if echovault.config.RestoreAOF {
 			err := echovault.aofEngine.Restore()
@@ -323,8 +599,19 @@ func NewEchoVault(options ...func(echovault *EchoVault)) (*EchoVault, error) {
 				log.Println(err)
 			}
 		}
+		echovault.finishLoading()
+
+		// Provision the store with the init file on first startup. There's nothing to restore at this point,
+		// and the commands are written to the AOF so that they're not executed again on the next startup.
+		if firstBoot {
+			if err := echovault.runInitFile(echovault.config.InitFile); err != nil {
+				return nil, err
+			}
+		}
 	}
 
+	echovault.restored.Store(true)
+
 	return echovault, nil
 }
 
@@ -338,6 +625,10 @@ func (server *EchoVault) startTCP() {
 	listener, err := listenConfig.Listen(server.context, "tcp", fmt.Sprintf("%s:%d", conf.BindAddr, conf.Port))
 
 	if err != nil {
+		if server.context.Err() != nil {
+			// The server was shut down before it started listening.
+			return
+		}
 		log.Fatal(err)
 	}
 
@@ -390,32 +681,98 @@ func (server *EchoVault) startTCP() {
 		})
 	}
 
-	// Listen to connection
-	for {
-		conn, err := listener.Accept()
-		if err != nil {
-			fmt.Println("Could not establish connection")
-			continue
-		}
-		// Read loop for connection
-		go server.handleConnection(conn)
+	server.acceptConnections(listener, tcpListener)
+}
+
+// errorPrefix returns the prefix of error replies. Redis protocol-compat levels use the "ERR" prefix
+// that Redis clients expect.
+func (server *EchoVault) errorPrefix() string {
+	switch server.getConfig().ProtocolCompat {
+	case constants.ProtocolCompatRedis6, constants.ProtocolCompatRedis7:
+		return "ERR"
+	default:
+		return "Error"
+	}
+}
+
+// errorReply encodes the error as a RESP error reply.
+// Line breaks in the message, e.g. from a binary key name, are replaced so that they don't terminate the reply early.
+func (server *EchoVault) errorReply(err error) []byte {
+	message := strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ").Replace(err.Error())
+	// LOADING errors carry their own error code.
+	if errors.Is(err, errLoading) {
+		return []byte(fmt.Sprintf("-%s\r\n", message))
 	}
+	return []byte(fmt.Sprintf("-%s %s\r\n", server.errorPrefix(), message))
 }
 
-func (server *EchoVault) handleConnection(conn net.Conn) {
+func (server *EchoVault) handleConnection(conn net.Conn, listener string) {
 	// If ACL module is loaded, register the connection with the ACL
 	if server.acl != nil {
-		server.acl.RegisterConnection(&conn)
+		if err := server.acl.RegisterConnection(&conn); err != nil {
+			writeReply(conn, server.errorReply(err))
+			if err = conn.Close(); err != nil {
+				log.Println(err)
+			}
+			return
+		}
+		defer server.acl.UnregisterConnection(&conn)
 	}
 
-	w, r := io.Writer(conn), io.Reader(conn)
+	w, r := io.Writer(conn), internal.NewMessageReader(conn)
 
 	cid := server.connId.Add(1)
 	ctx := context.WithValue(server.context, internal.ContextConnID("ConnectionID"),
 		fmt.Sprintf("%s-%d", server.context.Value(internal.ContextServerID("ServerID")), cid))
 
+	// Closing the connection when the server context is cancelled unblocks the read loop below.
+	stop := context.AfterFunc(ctx, func() {
+		_ = conn.Close()
+	})
+	defer stop()
+
+	ctx = context.WithValue(ctx, internal.ContextListener("Listener"), listener)
+	ctx = context.WithValue(ctx, internal.ContextTransaction("Transaction"), transaction.NewTransaction())
+
+	// The messages are read in their own goroutine, so that a client that disconnects while a blocking command runs
+	// cancels the command instead of leaving it to pop an element for a client that's gone.
+	// The other commands still run, as the client may have closed the connection right after sending them.
+	disconnected, disconnect := context.WithCancel(context.Background())
+	defer disconnect()
+	ctx = context.WithValue(ctx, internal.ContextDisconnected("Disconnected"), disconnected)
+	type readResult struct {
+		message []byte
+		err     error
+	}
+	messages := make(chan readResult)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			message, err := r.ReadMessage()
+			// A protocol error is replied to once the commands before it are complete, any other error means
+			// the connection can no longer be read.
+			if err != nil && !errors.Is(err, internal.ErrProtocol) {
+				disconnect()
+			}
+			select {
+			case messages <- readResult{message: message, err: err}:
+			case <-done:
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	server.metrics.ConnectionReceived()
+	server.clientRegistry.RegisterClient(ctx, &conn)
+	defer server.clientRegistry.UnregisterClient(ctx)
+
 	for {
-		message, err := internal.ReadMessage(r)
+		read := <-messages
+		message, err := read.message, read.err
 
 		if err != nil && errors.Is(err, io.EOF) {
 			// Connection closed
@@ -423,6 +780,15 @@ func (server *EchoVault) handleConnection(conn net.Conn) {
 			break
 		}
 
+		if err != nil && errors.Is(err, internal.ErrProtocol) {
+			// The rest of the stream cannot be parsed, so reply with the error and close the connection.
+			server.clientRegistry.LockWrites(ctx)
+			writeReply(w, server.errorReply(err))
+			server.clientRegistry.UnlockWrites(ctx)
+			log.Println(err)
+			break
+		}
+
 		if err != nil {
 			log.Println(err)
 			break
@@ -435,54 +801,64 @@ func (server *EchoVault) handleConnection(conn net.Conn) {
 		}
 
 		if err != nil {
-			if _, err = w.Write([]byte(fmt.Sprintf("-Error %s\r\n", err.Error()))); err != nil {
-				log.Println(err)
-			}
-			continue
+			res = server.errorReply(err)
 		}
 
-		chunkSize := 1024
+		// Hold the client's write lock so that invalidation messages are not written in the middle of the reply.
+		server.clientRegistry.LockWrites(ctx)
+		writeReply(w, res)
+		server.clientRegistry.UnlockWrites(ctx)
+	}
 
-		// If the length of the response is 0, return nothing to the client
-		if len(res) == 0 {
-			continue
-		}
+	if err := conn.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
+		log.Println(err)
+	}
+}
 
-		if len(res) <= chunkSize {
-			_, _ = w.Write(res)
-			continue
-		}
+// writeReply writes the reply to the connection. Large replies are written in chunks.
+func writeReply(w io.Writer, res []byte) {
+	chunkSize := 1024
 
-		// If the response is large, send it in chunks.
-		startIndex := 0
-		for {
-			// If the current start index is less than chunkSize from length, return the remaining bytes.
-			if len(res)-1-startIndex < chunkSize {
-				_, err = w.Write(res[startIndex:])
-				if err != nil {
-					log.Println(err)
-				}
-				break
-			}
-			n, _ := w.Write(res[startIndex : startIndex+chunkSize])
-			if n < chunkSize {
-				break
-			}
-			startIndex += chunkSize
+	// If the length of the response is 0, return nothing to the client
+	if len(res) == 0 {
+		return
+	}
+
+	if len(res) <= chunkSize {
+		if _, err := w.Write(res); err != nil {
+			log.Println(err)
 		}
+		return
 	}
 
-	if err := conn.Close(); err != nil {
-		log.Println(err)
+	// If the response is large, send it in chunks.
+	startIndex := 0
+	for {
+		// If the current start index is less than chunkSize from length, return the remaining bytes.
+		if len(res)-1-startIndex < chunkSize {
+			if _, err := w.Write(res[startIndex:]); err != nil {
+				log.Println(err)
+			}
+			break
+		}
+		n, _ := w.Write(res[startIndex : startIndex+chunkSize])
+		if n < chunkSize {
+			break
+		}
+		startIndex += chunkSize
 	}
 }
 
-// Start starts the EchoVault instance's TCP listener.
+// Start starts the EchoVault instance's TCP listener, and the unix socket listener if a unix socket is configured.
 // This allows the instance to accept connections handle client commands over TCP.
+// Start blocks until the context passed with WithContext is cancelled or ShutDown is called.
 //
 // You can still use command functions like echovault.Set if you're embedding EchoVault in your application.
 // However, if you'd like to also accept TCP request on the same instance, you must call this function.
 func (server *EchoVault) Start() {
+	if server.config.UnixSocket != "" {
+		server.startUnix()
+	}
 	server.startTCP()
 }
 
@@ -510,17 +886,29 @@ func (server *EchoVault) takeSnapshot() error {
 }
 
 func (server *EchoVault) startSnapshot() {
+	server.snapshotStartedAt.Store(server.clock.Now().UnixNano())
 	server.snapshotInProgress.Store(true)
 }
 
 func (server *EchoVault) finishSnapshot() {
 	server.snapshotInProgress.Store(false)
+	server.snapshotStartedAt.Store(0)
 }
 
 func (server *EchoVault) setLatestSnapshot(msec int64) {
 	server.latestSnapshotMilliseconds.Store(msec)
 }
 
+// withCommandTimeout derives a context with the default command deadline if the context does not have a deadline
+// and a command timeout is configured. The returned cancel function must always be called.
+func (server *EchoVault) withCommandTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok || server.commandTimeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeoutCause(ctx, server.commandTimeout,
+		fmt.Errorf("command timed out after %s", server.commandTimeout))
+}
+
 // getLatestSnapshotTime returns the latest snapshot time in unix epoch milliseconds.
 func (server *EchoVault) getLatestSnapshotTime() int64 {
 	return server.latestSnapshotMilliseconds.Load()
@@ -547,13 +935,181 @@ func (server *EchoVault) rewriteAOF() error {
 	return nil
 }
 
+// checkIntegrity validates the checksum of the latest snapshot and the tail of the AOF log and logs the results.
+// This only applies in standalone mode.
+func (server *EchoVault) checkIntegrity() {
+	if err := server.snapshotEngine.Verify(); err != nil {
+		server.integrity.snapshotFailed = true
+		log.Println(fmt.Errorf("snapshot integrity check failed: %+v", err))
+	} else {
+		log.Println("snapshot integrity check passed")
+	}
+
+	if err := server.aofEngine.VerifyTail(); err != nil {
+		server.integrity.aofFailed = true
+		log.Println(fmt.Errorf("aof integrity check failed: %+v", err))
+	} else {
+		log.Println("aof integrity check passed")
+	}
+}
+
+// getPersistenceInfo returns the status of the snapshot and AOF files.
+func (server *EchoVault) getPersistenceInfo() internal.PersistenceInfo {
+	info := internal.PersistenceInfo{
+		LastSnapshotTime:      server.getLatestSnapshotTime(),
+		LastSnapshotSucceeded: true,
+		SnapshotIntegrityOK:   !server.integrity.snapshotFailed,
+		LastRewriteSucceeded:  true,
+		AOFIntegrityOK:        !server.integrity.aofFailed,
+	}
+	if server.snapshotEngine != nil {
+		info.LastSnapshotSucceeded = server.snapshotEngine.LastSnapshotSucceeded()
+	}
+	info.StallProtection = server.stallProtection.Load()
+	info.Loading = server.loading.inProgress.Load()
+	if info.Loading {
+		info.LoadingPercentage = server.loadingPercentage()
+	}
+	info.SnapshotInProgress = server.snapshotInProgress.Load()
+	info.RewriteInProgress = server.rewriteAOFInProgress.Load()
+	if server.aofEngine != nil {
+		info.LastRewriteSucceeded = server.aofEngine.LastRewriteSucceeded()
+		info.AOFQueueDepth = server.aofEngine.QueueDepth()
+		info.AOFRejectedWrites = server.aofEngine.RejectedCommands()
+		info.AOFCurrentSize = server.aofEngine.CurrentSize()
+		info.AOFBaseSize = server.aofEngine.BaseSize()
+		info.AOFLastSyncTime = server.aofEngine.LastSync()
+	}
+	return info
+}
+
+// changeReplicationID replaces the replication ID of this node with a new random one.
+func (server *EchoVault) changeReplicationID() {
+	b := make([]byte, 20)
+	_, _ = rand.Read(b)
+
+	server.replication.mutex.Lock()
+	defer server.replication.mutex.Unlock()
+	server.replication.seed = hex.EncodeToString(b)
+}
+
+// getServerInfo returns the general information about the server and its clients.
+func (server *EchoVault) getServerInfo() internal.ServerInfo {
+	conf := server.getConfig()
+	info := internal.ServerInfo{
+		Version:          constants.Version,
+		Mode:             "standalone",
+		ServerID:         conf.ServerID,
+		ProcessID:        os.Getpid(),
+		TCPPort:          int(conf.Port),
+		Uptime:           server.clock.Now().Sub(server.startTime),
+		ConnectedClients: server.clientRegistry.Count(),
+	}
+	if server.isInCluster() {
+		info.Mode = "cluster"
+	}
+	return info
+}
+
+// getReplicationInfo returns the replication ID and offset of this node.
+// The replication ID is derived from the seed and the raft term, so it changes whenever a new leader is elected.
+func (server *EchoVault) getReplicationInfo() internal.ReplicationInfo {
+	info := internal.ReplicationInfo{
+		Mode:   "standalone",
+		Role:   "master",
+		Offset: server.replication.offset.Load(),
+	}
+
+	if server.isInCluster() {
+		info.Mode = "cluster"
+		var servers int
+		info.Term, info.Offset, servers = server.raft.ReplicationState()
+		if server.raft.IsRaftLeader() {
+			info.ConnectedReplicas = max(servers-1, 0)
+		} else {
+			info.Role = "slave"
+			info.MasterAddr = server.memberList.NodeClientAddr(server.raft.LeaderID())
+		}
+	}
+
+	server.replication.mutex.RLock()
+	seed := server.replication.seed
+	server.replication.mutex.RUnlock()
+
+	h := sha1.Sum([]byte(fmt.Sprintf("%s:%d", seed, info.Term)))
+	info.ReplicationID = hex.EncodeToString(h[:])
+
+	return info
+}
+
 // ShutDown gracefully shuts down the EchoVault instance.
-// This function shuts down the memberlist and raft layers.
-func (server *EchoVault) ShutDown() {
+// This function stops the listeners and closes the client connections, waiting for the commands in progress
+// to complete, then shuts down the health probe listener, and the memberlist and raft layers.
+//
+// If the context is done before the commands in progress complete, ShutDown stops waiting for them and
+// shuts down the other layers anyway, returning the context's error.
+func (server *EchoVault) ShutDown(ctx context.Context) error {
+	server.listenersMutex.Lock()
+	server.shuttingDown = true
+	server.listenersMutex.Unlock()
+
+	server.cancel()
+
+	err := waitContext(ctx, &server.connections)
+	if err != nil {
+		log.Println(fmt.Errorf("shutdown stopped waiting for the client connections: %+v", err))
+	} else if err = waitContext(ctx, &server.backgroundLoops); err != nil {
+		log.Println(fmt.Errorf("shutdown stopped waiting for the background loops: %+v", err))
+	}
+
+	if server.tracer != nil {
+		if err := server.tracer.Close(); err != nil {
+			log.Println(fmt.Errorf("trace file close error: %+v", err))
+		}
+	}
+	if server.healthServer != nil {
+		if err := server.healthServer.Close(); err != nil {
+			log.Println(fmt.Errorf("health server close error: %+v", err))
+		}
+	}
 	if server.isInCluster() {
 		server.raft.RaftShutdown()
 		server.memberList.MemberListShutdown()
 	}
+
+	return err
+}
+
+// runBackgroundLoop calls fn every interval in its own goroutine until the server context is cancelled.
+// The interval is fixed when the loop starts.
+func (server *EchoVault) runBackgroundLoop(interval time.Duration, fn func()) {
+	server.backgroundLoops.Add(1)
+	go func() {
+		defer server.backgroundLoops.Done()
+		for {
+			select {
+			case <-server.context.Done():
+				return
+			case <-server.clock.After(interval):
+				fn()
+			}
+		}
+	}()
+}
+
+// waitContext waits for the wait group, or until the context is done, in which case the context's error is returned.
+func waitContext(ctx context.Context, wg *sync.WaitGroup) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
 func (server *EchoVault) initialiseCaches() {
@@ -563,7 +1119,11 @@ func (server *EchoVault) initialiseCaches() {
 		cache eviction.CacheLFU
 	}{
 		mutex: sync.Mutex{},
-		cache: eviction.NewCacheLFU(),
+		cache: eviction.NewCacheLFU(
+			server.clock,
+			eviction.WithLogFactorFunc(func() uint { return server.getConfig().LFULogFactor }),
+			eviction.WithDecayTimeFunc(func() time.Duration { return server.getConfig().LFUDecayTime }),
+		),
 	}
 	// set up LRU cache
 	server.lruCache = struct {
@@ -571,6 +1131,6 @@ func (server *EchoVault) initialiseCaches() {
 		cache eviction.CacheLRU
 	}{
 		mutex: sync.Mutex{},
-		cache: eviction.NewCacheLRU(),
+		cache: eviction.NewCacheLRU(server.clock),
 	}
 }
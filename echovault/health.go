@@ -0,0 +1,104 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package echovault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// startHealthServer starts the HTTP listener that serves the health probes:
+//
+// /healthz and /livez - The process is up and the server has not been shut down.
+//
+// /readyz - The state has been restored, the node has joined the cluster, and it can accept writes.
+//
+// Each probe responds with 200 when it passes, and 503 with the reasons when it fails.
+func (server *EchoVault) startHealthServer() {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", server.handleLiveness)
+	mux.HandleFunc("/livez", server.handleLiveness)
+	mux.HandleFunc("/readyz", server.handleReadiness)
+
+	listener, err := net.Listen("tcp", fmt.Sprintf("%s:%d", server.config.BindAddr, server.config.HealthPort))
+	if err != nil {
+		log.Println(fmt.Errorf("health server error: %+v", err))
+		return
+	}
+
+	server.healthServer = &http.Server{
+		Handler:     mux,
+		BaseContext: func(net.Listener) context.Context { return server.context },
+	}
+
+	go func() {
+		if err := server.healthServer.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Println(fmt.Errorf("health server error: %+v", err))
+		}
+	}()
+}
+
+func (server *EchoVault) handleLiveness(w http.ResponseWriter, _ *http.Request) {
+	if err := server.context.Err(); err != nil {
+		writeProbeResponse(w, []string{"server is shutting down"})
+		return
+	}
+	writeProbeResponse(w, nil)
+}
+
+func (server *EchoVault) handleReadiness(w http.ResponseWriter, _ *http.Request) {
+	writeProbeResponse(w, server.readinessFailures())
+}
+
+// readinessFailures returns the reasons the node is not ready to serve traffic. Returns nil if the node is ready.
+func (server *EchoVault) readinessFailures() []string {
+	var failures []string
+
+	if !server.restored.Load() {
+		failures = append(failures, "state restore in progress")
+	}
+
+	if server.isInCluster() && !server.clusterReady.Load() {
+		failures = append(failures, "cluster layer is initialising")
+	} else if server.isInCluster() {
+		isLeader := server.raft.IsRaftLeader()
+		if !isLeader && !server.raft.HasJoinedCluster() {
+			failures = append(failures, "node has not joined the cluster")
+		}
+		// Followers can only accept writes if they forward them to the leader.
+		if !isLeader && !server.config.ForwardCommand {
+			failures = append(failures, "node is not accepting writes")
+		}
+	}
+
+	return failures
+}
+
+// writeProbeResponse responds with 200 if there are no failures, otherwise it responds with 503 and the failures.
+func writeProbeResponse(w http.ResponseWriter, failures []string) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if len(failures) > 0 {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte(strings.Join(failures, "\n") + "\n"))
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("ok\n"))
+}
@@ -0,0 +1,74 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package echovault
+
+import (
+	"errors"
+	"fmt"
+	"github.com/echovault/echovault/internal"
+	"io"
+	"log"
+	"os"
+	"strings"
+)
+
+// isFirstBoot returns true if the data directory does not exist yet or is empty,
+// i.e. nothing has been persisted by a previous run.
+// It must be called before the persistence engines are created, as they create their files in the directory.
+func isFirstBoot(dataDir string) bool {
+	if dataDir == "" {
+		return true
+	}
+	entries, err := os.ReadDir(dataDir)
+	if err != nil {
+		return errors.Is(err, os.ErrNotExist)
+	}
+	return len(entries) == 0
+}
+
+// runInitFile executes the commands in the init file in order. The commands are either RESP arrays
+// or inline commands, one per line. A command that fails is logged and the following commands are still executed,
+// so that a single bad line does not leave the rest of the environment unprovisioned.
+// An error is only returned if the file cannot be opened or parsed.
+func (server *EchoVault) runInitFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("could not open init file: %w", err)
+	}
+	defer func() {
+		if err = f.Close(); err != nil {
+			log.Println(err)
+		}
+	}()
+
+	r := internal.NewMessageReader(f)
+	for i := 1; ; i++ {
+		message, err := r.ReadMessage()
+		if err != nil && errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("could not read command %d of init file %s: %w", i, path, err)
+		}
+
+		res, err := server.handleCommand(server.context, message, nil, false, true)
+		if err == nil && len(res) > 0 && res[0] == '-' {
+			err = errors.New(strings.TrimSpace(string(res[1:])))
+		}
+		if err != nil {
+			log.Printf("init file %s: command %d failed: %v\n", path, i, err)
+		}
+	}
+}
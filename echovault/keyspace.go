@@ -45,10 +45,12 @@ func (server *EchoVault) KeyLock(ctx context.Context, key string) (bool, error)
 	for {
 		select {
 		default:
-			if server.keyLocks[key] == nil {
+			// Load the lock once as the key might be deleted between the nil check and the lock attempt.
+			keyLock := server.getKeyLock(key)
+			if keyLock == nil {
 				return false, fmt.Errorf("key %s not found", key)
 			}
-			ok := server.keyLocks[key].TryLock()
+			ok := keyLock.TryLock()
 			if ok {
 				return true, nil
 			}
@@ -63,8 +65,8 @@ func (server *EchoVault) KeyLock(ctx context.Context, key string) (bool, error)
 // If this functions is called on a node in a replication cluster, the key is only unlocked
 // on that particular node.
 func (server *EchoVault) KeyUnlock(_ context.Context, key string) {
-	if _, ok := server.keyLocks[key]; ok {
-		server.keyLocks[key].Unlock()
+	if keyLock := server.getKeyLock(key); keyLock != nil {
+		keyLock.Unlock()
 	}
 }
 
@@ -84,10 +86,12 @@ func (server *EchoVault) KeyRLock(ctx context.Context, key string) (bool, error)
 	for {
 		select {
 		default:
-			if server.keyLocks[key] == nil {
+			// Load the lock once as the key might be deleted between the nil check and the lock attempt.
+			keyLock := server.getKeyLock(key)
+			if keyLock == nil {
 				return false, fmt.Errorf("key %s not found", key)
 			}
-			ok := server.keyLocks[key].TryRLock()
+			ok := keyLock.TryRLock()
 			if ok {
 				return true, nil
 			}
@@ -102,42 +106,69 @@ func (server *EchoVault) KeyRLock(ctx context.Context, key string) (bool, error)
 // If this functions is called on a node in a replication cluster, the key is only unlocked
 // on that particular node.
 func (server *EchoVault) KeyRUnlock(_ context.Context, key string) {
-	if _, ok := server.keyLocks[key]; ok {
-		server.keyLocks[key].RUnlock()
+	if keyLock := server.getKeyLock(key); keyLock != nil {
+		keyLock.RUnlock()
 	}
 }
 
+// getKeyLock returns the lock of the key, or nil if the key has no lock.
+func (server *EchoVault) getKeyLock(key string) *sync.RWMutex {
+	server.keyspaceLock.RLock()
+	defer server.keyspaceLock.RUnlock()
+	return server.keyLocks[key]
+}
+
+// getKeyData returns the data held at the key and whether the key exists in the store.
+// The key must be read-locked before calling this function to read data that's consistent with the key's value.
+func (server *EchoVault) getKeyData(key string) (internal.KeyData, bool) {
+	server.keyspaceLock.RLock()
+	defer server.keyspaceLock.RUnlock()
+	data, ok := server.store[key]
+	return data, ok
+}
+
+// readKeyData returns the data held at the key, holding the key's read lock while the data is read.
+// Returns false if the key does not exist or its read lock could not be acquired.
+func (server *EchoVault) readKeyData(ctx context.Context, key string) (internal.KeyData, bool) {
+	if _, err := server.KeyRLock(ctx, key); err != nil {
+		return internal.KeyData{}, false
+	}
+	defer server.KeyRUnlock(ctx, key)
+	return server.getKeyData(key)
+}
+
+// randomKey returns a random key from the store. Returns false if the store is empty.
+func (server *EchoVault) randomKey() (string, bool) {
+	server.keyspaceLock.RLock()
+	defer server.keyspaceLock.RUnlock()
+	if len(server.keyLocks) == 0 {
+		return "", false
+	}
+	idx := rand.Intn(len(server.keyLocks))
+	for key := range server.keyLocks {
+		if idx == 0 {
+			return key, true
+		}
+		idx--
+	}
+	return "", false
+}
+
 // KeyExists returns true if the key exists in the store.
 //
 // If the key is volatile and expired, checking for its existence with KeyExists will trigger a key deletion and
 // then return false. If the key is determined to be expired by KeyExists, it will be evicted across the entire
 // replication cluster.
 func (server *EchoVault) KeyExists(ctx context.Context, key string) bool {
-	entry, ok := server.store[key]
+	entry, ok := server.getKeyData(key)
 	if !ok {
 		return false
 	}
 
 	if entry.ExpireAt != (time.Time{}) && entry.ExpireAt.Before(server.clock.Now()) {
-		if !server.isInCluster() {
-			// If in standalone mode, delete the key directly.
-			err := server.DeleteKey(ctx, key)
-			if err != nil {
-				log.Printf("keyExists: %+v\n", err)
-			}
-		} else if server.isInCluster() && server.raft.IsRaftLeader() {
-			// If we're in a raft cluster, and we're the leader, send command to delete the key in the cluster.
-			err := server.raftApplyDeleteKey(ctx, key)
-			if err != nil {
-				log.Printf("keyExists: %+v\n", err)
-			}
-		} else if server.isInCluster() && !server.raft.IsRaftLeader() {
-			// Forward message to leader to initiate key deletion.
-			// This is always called regardless of ForwardCommand config value
-			// because we always want to remove expired keys.
-			server.memberList.ForwardDeleteKey(ctx, key)
+		if err := server.expireKey(ctx, key); err != nil {
+			log.Printf("keyExists: %+v\n", err)
 		}
-
 		return false
 	}
 
@@ -161,12 +192,21 @@ func (server *EchoVault) CreateKeyAndLock(ctx context.Context, key string) (bool
 		// Create Lock
 		keyLock := &sync.RWMutex{}
 		keyLock.Lock()
+		server.keyspaceLock.Lock()
 		server.keyLocks[key] = keyLock
 		// Create key entry
 		server.store[key] = internal.KeyData{
 			Value:    nil,
 			ExpireAt: time.Time{},
 		}
+		count := int64(len(server.store))
+		server.keyspaceLock.Unlock()
+		server.updateKeyVersion(ctx, key)
+		server.keyIndex.Add(key)
+		// Track the peak number of keys to determine when the keyspace should be compacted.
+		if count > server.defrag.peakKeys.Load() {
+			server.defrag.peakKeys.Store(count)
+		}
 		return true, nil
 	}
 
@@ -174,15 +214,21 @@ func (server *EchoVault) CreateKeyAndLock(ctx context.Context, key string) (bool
 }
 
 // GetValue retrieves the current value at the specified key.
+// If the calling client has the NO-TOUCH flag set, the key's access count/time is not updated.
 // The key must be read-locked before calling this function.
 func (server *EchoVault) GetValue(ctx context.Context, key string) interface{} {
-	if err := server.updateKeyInCache(ctx, key); err != nil {
-		log.Printf("GetValue error: %+v\n", err)
+	if !server.clientRegistry.IsNoTouch(ctx) {
+		if err := server.updateKeyInCache(ctx, key); err != nil {
+			log.Printf("GetValue error: %+v\n", err)
+		}
 	}
-	return server.store[key].Value
+	data, _ := server.getKeyData(key)
+	return data.Value
 }
 
 // SetValue updates the value in the store at the specified key with the given value.
+// The expiry time of the key is kept, so commands that modify an existing value (e.g. SETRANGE, HSET or LPUSH)
+// don't turn a volatile key into a persistent one. Commands that change the expiry time must do so with SetExpiry.
 // If we're in not in cluster (i.e. in standalone mode), then the change count is incremented in the snapshot engine.
 // This count triggers a snapshot when the threshold is reached.
 // The key must be locked prior to calling this function.
@@ -191,10 +237,15 @@ func (server *EchoVault) SetValue(ctx context.Context, key string, value interfa
 		return errors.New("max memory reached, key value not set")
 	}
 
+	server.keyspaceLock.Lock()
 	server.store[key] = internal.KeyData{
 		Value:    value,
 		ExpireAt: server.store[key].ExpireAt,
 	}
+	server.keyspaceLock.Unlock()
+	server.updateKeyVersion(ctx, key)
+	// Wake up the blocking commands waiting on this key.
+	server.keyWaiters.Notify(key)
 
 	err := server.updateKeyInCache(ctx, key)
 	if err != nil {
@@ -209,12 +260,16 @@ func (server *EchoVault) SetValue(ctx context.Context, key string, value interfa
 }
 
 // The GetExpiry function returns the expiry time associated with the provided key.
+// If the calling client has the NO-TOUCH flag set, the key's access count/time is not updated.
 // The key must be read locked before calling this function.
 func (server *EchoVault) GetExpiry(ctx context.Context, key string) time.Time {
-	if err := server.updateKeyInCache(ctx, key); err != nil {
-		log.Printf("GetKeyExpiry error: %+v\n", err)
+	if !server.clientRegistry.IsNoTouch(ctx) {
+		if err := server.updateKeyInCache(ctx, key); err != nil {
+			log.Printf("GetKeyExpiry error: %+v\n", err)
+		}
 	}
-	return server.store[key].ExpireAt
+	data, _ := server.getKeyData(key)
+	return data.ExpireAt
 }
 
 // The SetExpiry receiver function sets the expiry time of a key.
@@ -224,10 +279,13 @@ func (server *EchoVault) GetExpiry(ctx context.Context, key string) time.Time {
 // or the access time on lru eviction policy.
 // The key must be locked prior to calling this function.
 func (server *EchoVault) SetExpiry(ctx context.Context, key string, expireAt time.Time, touch bool) {
+	server.keyspaceLock.Lock()
 	server.store[key] = internal.KeyData{
 		Value:    server.store[key].Value,
 		ExpireAt: expireAt,
 	}
+	server.keyspaceLock.Unlock()
+	server.updateKeyVersion(ctx, key)
 
 	// If the slice of keys associated with expiry time does not contain the current key, add the key.
 	server.keysWithExpiry.rwMutex.Lock()
@@ -247,12 +305,32 @@ func (server *EchoVault) SetExpiry(ctx context.Context, key string, expireAt tim
 
 // RemoveExpiry is called by commands that remove key expiry (e.g. Persist).
 // The key must be locked prior ro calling this function.
-func (server *EchoVault) RemoveExpiry(_ context.Context, key string) {
+func (server *EchoVault) RemoveExpiry(ctx context.Context, key string) {
+	server.removeExpiry(ctx, key)
+	// Keys without an expiry cannot be evicted by the volatile eviction policies, so remove the key from the cache.
+	switch server.config.EvictionPolicy {
+	case constants.VolatileLFU:
+		server.lfuCache.mutex.Lock()
+		server.lfuCache.cache.Delete(key)
+		server.lfuCache.mutex.Unlock()
+	case constants.VolatileLRU, constants.VolatileIdle:
+		server.lruCache.mutex.Lock()
+		server.lruCache.cache.Delete(key)
+		server.lruCache.mutex.Unlock()
+	}
+}
+
+// removeExpiry removes the expiry of the key without removing the key from the cache.
+// It's used when the key is deleted, as the caller may already hold the lock of the cache it evicts the key from.
+func (server *EchoVault) removeExpiry(ctx context.Context, key string) {
 	// Reset expiry time
+	server.keyspaceLock.Lock()
 	server.store[key] = internal.KeyData{
 		Value:    server.store[key].Value,
 		ExpireAt: time.Time{},
 	}
+	server.keyspaceLock.Unlock()
+	server.updateKeyVersion(ctx, key)
 	// Remove key from slice of keys associated with expiry
 	server.keysWithExpiry.rwMutex.Lock()
 	defer server.keysWithExpiry.rwMutex.Unlock()
@@ -266,6 +344,10 @@ func (server *EchoVault) RemoveExpiry(_ context.Context, key string) {
 // functions that require a deep copy of the state.
 // The copy only starts when there's no current copy in progress (represented by stateCopyInProgress atomic boolean)
 // and when there's no current state mutation in progress (represented by stateMutationInProgress atomic boolean)
+//
+// Each value is deep copied with internal.CopyValue, so the returned state shares no mutable data with the store.
+// This allows persistence and replication to read the state after the copy is complete while
+// commands continue to mutate the values in the store.
 func (server *EchoVault) getState() map[string]interface{} {
 	// Wait unit there's no state mutation or copy in progress before starting a new copy process.
 	for {
@@ -275,9 +357,14 @@ func (server *EchoVault) getState() map[string]interface{} {
 		}
 	}
 	data := make(map[string]interface{})
+	server.keyspaceLock.RLock()
 	for k, v := range server.store {
-		data[k] = v
+		data[k] = internal.KeyData{
+			Value:    internal.CopyValue(v.Value),
+			ExpireAt: v.ExpireAt,
+		}
 	}
+	server.keyspaceLock.RUnlock()
 	server.stateCopyInProgress.Store(false)
 	return data
 }
@@ -287,30 +374,142 @@ func (server *EchoVault) getState() map[string]interface{} {
 // If this functions is called on a node in a replication cluster, the key is only deleted
 // on that particular node.
 func (server *EchoVault) DeleteKey(ctx context.Context, key string) error {
+	_, err := server.deleteKey(ctx, key)
+	return err
+}
+
+// deleteKey deletes the key like DeleteKey and returns the data that the key held when it was deleted.
+func (server *EchoVault) deleteKey(ctx context.Context, key string) (internal.KeyData, error) {
 	if _, err := server.KeyLock(ctx, key); err != nil {
-		return fmt.Errorf("deleteKey error: %+v", err)
+		return internal.KeyData{}, fmt.Errorf("deleteKey error: %+v", err)
 	}
+	data, _ := server.getKeyData(key)
+	server.DeleteLockedKey(ctx, key)
+	return data, nil
+}
+
+// DeleteLockedKey removes a key that the caller holds the write lock for, and releases the lock,
+// so that a command can delete a key without releasing it first. KeyUnlock must not be called for the key afterwards.
+//
+// If this functions is called on a node in a replication cluster, the key is only deleted
+// on that particular node.
+func (server *EchoVault) DeleteLockedKey(ctx context.Context, key string) {
+	keyLock := server.getKeyLock(key)
 
-	// Remove key expiry.
-	server.RemoveExpiry(ctx, key)
+	// Remove key expiry. The key is removed from the cache below.
+	server.removeExpiry(ctx, key)
 
 	// Delete the key from keyLocks and store.
+	server.keyspaceLock.Lock()
 	delete(server.keyLocks, key)
 	delete(server.store, key)
+	server.keyspaceLock.Unlock()
+	server.deleteKeyVersion(ctx, key)
+	server.keyIndex.Remove(key)
+
+	// Release the lock now that it's no longer reachable from keyLocks. Goroutines waiting
+	// on the lock reload it from keyLocks on each attempt, so they'll find that the key no longer exists.
+	keyLock.Unlock()
 
 	// Remove the key from the cache.
 	switch {
 	case slices.Contains([]string{constants.AllKeysLFU, constants.VolatileLFU}, server.config.EvictionPolicy):
 		server.lfuCache.cache.Delete(key)
-	case slices.Contains([]string{constants.AllKeysLRU, constants.VolatileLRU}, server.config.EvictionPolicy):
+	case slices.Contains([]string{constants.AllKeysLRU, constants.VolatileLRU, constants.VolatileIdle}, server.config.EvictionPolicy):
 		server.lruCache.cache.Delete(key)
 	}
 
 	log.Printf("deleted key %s\n", key)
+}
 
+// expireKey deletes a key that has expired and calls the expire callbacks with the data it held.
+//
+// In a raft cluster, the leader applies the deletion across the cluster and calls the callbacks.
+// Other nodes forward the deletion to the leader without calling the callbacks, so that each
+// expired key is only reported once.
+func (server *EchoVault) expireKey(ctx context.Context, key string) error {
+	switch {
+	case !server.isInCluster():
+		// If in standalone mode, delete the key directly.
+		data, err := server.deleteKey(ctx, key)
+		if err != nil {
+			return err
+		}
+		server.metrics.KeyExpired()
+		server.callExpireCallbacks(key, data)
+	case server.raft.IsRaftLeader():
+		// If we're in a raft cluster, and we're the leader, send command to delete the key in the cluster.
+		if _, err := server.KeyRLock(ctx, key); err != nil {
+			return err
+		}
+		data, _ := server.getKeyData(key)
+		server.KeyRUnlock(ctx, key)
+		if err := server.raftApplyDeleteKey(ctx, key); err != nil {
+			return err
+		}
+		server.metrics.KeyExpired()
+		server.callExpireCallbacks(key, data)
+	default:
+		// Forward message to leader to initiate key deletion.
+		// This is always called regardless of ForwardCommand config value
+		// because we always want to remove expired keys.
+		server.memberList.ForwardDeleteKey(ctx, key)
+	}
 	return nil
 }
 
+// callExpireCallbacks calls each callback registered with OnExpire in its own goroutine,
+// so that slow callbacks don't hold up the command or the eviction that expired the key.
+func (server *EchoVault) callExpireCallbacks(key string, data internal.KeyData) {
+	server.expireCallbacks.rwMutex.RLock()
+	defer server.expireCallbacks.rwMutex.RUnlock()
+	for _, callback := range server.expireCallbacks.callbacks {
+		go callback(ExpiredKey{
+			Key:      key,
+			Value:    data.Value,
+			ExpireAt: data.ExpireAt,
+		})
+	}
+}
+
+// updateKeyVersion assigns a new version to the key, invalidates the key for the clients tracking it,
+// and records the mutation in the trace if tracing is enabled.
+// It is called every time the key is created or modified so that cached replies that read the key become stale.
+func (server *EchoVault) updateKeyVersion(ctx context.Context, key string) {
+	server.keyVersions.rwMutex.Lock()
+	server.keyVersions.counter += 1
+	server.keyVersions.versions[key] = server.keyVersions.counter
+	server.keyVersions.rwMutex.Unlock()
+
+	server.clientRegistry.Invalidate(key)
+
+	// The mutations replayed while restoring the state are not recorded.
+	if server.tracer != nil && server.restored.Load() {
+		server.tracer.Mutation(ctx, key, false)
+	}
+}
+
+// deleteKeyVersion removes the version of a deleted key, invalidates the key for the clients tracking it,
+// and records the deletion in the trace if tracing is enabled.
+func (server *EchoVault) deleteKeyVersion(ctx context.Context, key string) {
+	server.keyVersions.rwMutex.Lock()
+	delete(server.keyVersions.versions, key)
+	server.keyVersions.rwMutex.Unlock()
+
+	server.clientRegistry.Invalidate(key)
+
+	if server.tracer != nil && server.restored.Load() {
+		server.tracer.Mutation(ctx, key, true)
+	}
+}
+
+// getKeyVersion returns the current version of the key. Returns 0 if the key does not exist.
+func (server *EchoVault) getKeyVersion(key string) uint64 {
+	server.keyVersions.rwMutex.RLock()
+	defer server.keyVersions.rwMutex.RUnlock()
+	return server.keyVersions.versions[key]
+}
+
 // updateKeyInCache updates either the key access count or the most recent access time in the cache
 // depending on whether an LFU or LRU strategy was used.
 func (server *EchoVault) updateKeyInCache(ctx context.Context, key string) error {
@@ -318,32 +517,36 @@ func (server *EchoVault) updateKeyInCache(ctx context.Context, key string) error
 	if server.isInCluster() || (server.isInCluster() && !server.raft.IsRaftLeader()) {
 		return nil
 	}
-	// If max memory is 0, there's no max so no need to update caches
-	if server.config.MaxMemory == 0 {
+	// If max memory is 0, there's no max so no need to update caches.
+	// The volatile-idle policy evicts idle keys below max memory too, so it always needs the access times.
+	if server.config.MaxMemory == 0 && server.config.EvictionPolicy != constants.VolatileIdle {
 		return nil
 	}
-	switch strings.ToLower(server.config.EvictionPolicy) {
-	case constants.AllKeysLFU:
-		server.lfuCache.mutex.Lock()
-		defer server.lfuCache.mutex.Unlock()
-		server.lfuCache.cache.Update(key)
-	case constants.AllKeysLRU:
-		server.lruCache.mutex.Lock()
-		defer server.lruCache.mutex.Unlock()
-		server.lruCache.cache.Update(key)
-	case constants.VolatileLFU:
-		server.lfuCache.mutex.Lock()
-		defer server.lfuCache.mutex.Unlock()
-		if server.store[key].ExpireAt != (time.Time{}) {
+	// The cache is unlocked before adjusting the memory usage, as adjustMemoryUsage locks the cache it evicts from.
+	func() {
+		switch strings.ToLower(server.config.EvictionPolicy) {
+		case constants.AllKeysLFU:
+			server.lfuCache.mutex.Lock()
+			defer server.lfuCache.mutex.Unlock()
 			server.lfuCache.cache.Update(key)
-		}
-	case constants.VolatileLRU:
-		server.lruCache.mutex.Lock()
-		defer server.lruCache.mutex.Unlock()
-		if server.store[key].ExpireAt != (time.Time{}) {
+		case constants.AllKeysLRU:
+			server.lruCache.mutex.Lock()
+			defer server.lruCache.mutex.Unlock()
 			server.lruCache.cache.Update(key)
+		case constants.VolatileLFU:
+			server.lfuCache.mutex.Lock()
+			defer server.lfuCache.mutex.Unlock()
+			if data, _ := server.getKeyData(key); data.ExpireAt != (time.Time{}) {
+				server.lfuCache.cache.Update(key)
+			}
+		case constants.VolatileLRU, constants.VolatileIdle:
+			server.lruCache.mutex.Lock()
+			defer server.lruCache.mutex.Unlock()
+			if data, _ := server.getKeyData(key); data.ExpireAt != (time.Time{}) {
+				server.lruCache.cache.Update(key)
+			}
 		}
-	}
+	}()
 	if err := server.adjustMemoryUsage(ctx); err != nil {
 		return fmt.Errorf("updateKeyInCache: %+v", err)
 	}
@@ -385,17 +588,9 @@ func (server *EchoVault) adjustMemoryUsage(ctx context.Context) error {
 				return fmt.Errorf("adjsutMemoryUsage -> LFU cache empty")
 			}
 
-			key := server.lfuCache.cache.Pop().(string)
-			if !server.isInCluster() {
-				// If in standalone mode, directly delete the key
-				if err := server.DeleteKey(ctx, key); err != nil {
-					return fmt.Errorf("adjustMemoryUsage -> LFU cache eviction: %+v", err)
-				}
-			} else if server.isInCluster() && server.raft.IsRaftLeader() {
-				// If in raft cluster, send command to delete key from cluster
-				if err := server.raftApplyDeleteKey(ctx, key); err != nil {
-					return fmt.Errorf("adjustMemoryUsage -> LFU cache eviction: %+v", err)
-				}
+			key := server.lfuCache.cache.PopLeastFrequent()
+			if err := server.evictKey(ctx, key); err != nil {
+				return fmt.Errorf("adjustMemoryUsage -> LFU cache eviction: %+v", err)
 			}
 
 			// Run garbage collection
@@ -406,7 +601,7 @@ func (server *EchoVault) adjustMemoryUsage(ctx context.Context) error {
 				return nil
 			}
 		}
-	case slices.Contains([]string{constants.AllKeysLRU, constants.VolatileLRU}, strings.ToLower(server.config.EvictionPolicy)):
+	case slices.Contains([]string{constants.AllKeysLRU, constants.VolatileLRU, constants.VolatileIdle}, strings.ToLower(server.config.EvictionPolicy)):
 		// Remove keys from th LRU cache until we're below the max memory limit or
 		// until the LRU cache is empty.
 		server.lruCache.mutex.Lock()
@@ -417,18 +612,9 @@ func (server *EchoVault) adjustMemoryUsage(ctx context.Context) error {
 				return fmt.Errorf("adjsutMemoryUsage -> LRU cache empty")
 			}
 
-			key := server.lruCache.cache.Pop().(string)
-			if !server.isInCluster() {
-				// If in standalone mode, directly delete the key.
-				if err := server.DeleteKey(ctx, key); err != nil {
-					return fmt.Errorf("adjustMemoryUsage -> LRU cache eviction: %+v", err)
-				}
-			} else if server.isInCluster() && server.raft.IsRaftLeader() {
-				// If in cluster mode and the node is a cluster leader,
-				// send command to delete the key from the cluster.
-				if err := server.raftApplyDeleteKey(ctx, key); err != nil {
-					return fmt.Errorf("adjustMemoryUsage -> LRU cache eviction: %+v", err)
-				}
+			key := server.lruCache.cache.PopLeastRecent()
+			if err := server.evictKey(ctx, key); err != nil {
+				return fmt.Errorf("adjustMemoryUsage -> LRU cache eviction: %+v", err)
 			}
 
 			// Run garbage collection
@@ -443,34 +629,21 @@ func (server *EchoVault) adjustMemoryUsage(ctx context.Context) error {
 		// Remove random keys until we're below the max memory limit
 		// or there are no more keys remaining.
 		for {
-			// If there are no keys, return error
-			if len(server.keyLocks) == 0 {
+			// Get random key. If there are no keys, return error
+			key, ok := server.randomKey()
+			if !ok {
 				err := errors.New("no keys to evict")
 				return fmt.Errorf("adjustMemoryUsage -> all keys random: %+v", err)
 			}
-			// Get random key
-			idx := rand.Intn(len(server.keyLocks))
-			for key, _ := range server.keyLocks {
-				if idx == 0 {
-					if !server.isInCluster() {
-						// If in standalone mode, directly delete the key
-						if err := server.DeleteKey(ctx, key); err != nil {
-							return fmt.Errorf("adjustMemoryUsage -> all keys random: %+v", err)
-						}
-					} else if server.isInCluster() && server.raft.IsRaftLeader() {
-						if err := server.raftApplyDeleteKey(ctx, key); err != nil {
-							return fmt.Errorf("adjustMemoryUsage -> all keys random: %+v", err)
-						}
-					}
-					// Run garbage collection
-					runtime.GC()
-					// Return if we're below max memory
-					runtime.ReadMemStats(&memStats)
-					if memStats.HeapInuse < server.config.MaxMemory {
-						return nil
-					}
-				}
-				idx--
+			if err := server.evictKey(ctx, key); err != nil {
+				return fmt.Errorf("adjustMemoryUsage -> all keys random: %+v", err)
+			}
+			// Run garbage collection
+			runtime.GC()
+			// Return if we're below max memory
+			runtime.ReadMemStats(&memStats)
+			if memStats.HeapInuse < server.config.MaxMemory {
+				return nil
 			}
 		}
 	case slices.Contains([]string{constants.VolatileRandom}, strings.ToLower(server.config.EvictionPolicy)):
@@ -483,15 +656,8 @@ func (server *EchoVault) adjustMemoryUsage(ctx context.Context) error {
 			key := server.keysWithExpiry.keys[idx]
 			server.keysWithExpiry.rwMutex.RUnlock()
 
-			if !server.isInCluster() {
-				// If in standalone mode, directly delete the key
-				if err := server.DeleteKey(ctx, key); err != nil {
-					return fmt.Errorf("adjustMemoryUsage -> volatile keys random: %+v", err)
-				}
-			} else if server.isInCluster() && server.raft.IsRaftLeader() {
-				if err := server.raftApplyDeleteKey(ctx, key); err != nil {
-					return fmt.Errorf("adjustMemoryUsage -> volatile keys randome: %+v", err)
-				}
+			if err := server.evictKey(ctx, key); err != nil {
+				return fmt.Errorf("adjustMemoryUsage -> volatile keys random: %+v", err)
 			}
 
 			// Run garbage collection
@@ -507,6 +673,60 @@ func (server *EchoVault) adjustMemoryUsage(ctx context.Context) error {
 	}
 }
 
+// evictKey deletes a key to bring the memory usage below the max memory limit.
+// In standalone mode, the key is deleted directly. In a raft cluster, the leader applies the deletion across
+// the cluster, and other nodes leave the eviction to the leader.
+func (server *EchoVault) evictKey(ctx context.Context, key string) error {
+	switch {
+	case !server.isInCluster():
+		if err := server.DeleteKey(ctx, key); err != nil {
+			return err
+		}
+	case server.raft.IsRaftLeader():
+		if err := server.raftApplyDeleteKey(ctx, key); err != nil {
+			return err
+		}
+	default:
+		return nil
+	}
+	server.metrics.KeyEvicted()
+	return nil
+}
+
+// evictIdleKeys evicts the keys with an expiration that have not been accessed for longer than the idle threshold.
+// It's used by the volatile-idle eviction policy, and is only executed in standalone mode or by the raft cluster leader.
+func (server *EchoVault) evictIdleKeys(ctx context.Context) error {
+	if server.isInCluster() && !server.raft.IsRaftLeader() {
+		return nil
+	}
+	threshold := server.config.IdleThreshold
+	if threshold == 0 {
+		return nil
+	}
+
+	// Collect the idle keys before evicting them, as the cache must not be locked while the keys are locked.
+	var keys []string
+	server.lruCache.mutex.Lock()
+	for {
+		key, ok := server.lruCache.cache.PopIdle(threshold)
+		if !ok {
+			break
+		}
+		keys = append(keys, key)
+	}
+	server.lruCache.mutex.Unlock()
+
+	for _, key := range keys {
+		if err := server.evictKey(ctx, key); err != nil {
+			return fmt.Errorf("evictIdleKeys: %+v", err)
+		}
+	}
+	if len(keys) > 0 {
+		log.Printf("%d keys idle for longer than %s evicted\n", len(keys), threshold)
+	}
+	return nil
+}
+
 // evictKeysWithExpiredTTL is a function that samples keys with an associated TTL
 // and evicts keys that are currently expired.
 // This function will sample 20 keys from the list of keys with an associated TTL,
@@ -553,7 +773,7 @@ func (server *EchoVault) evictKeysWithExpiredTTL(ctx context.Context) error {
 		}
 
 		// If the current key is not expired, skip to the next key
-		if server.store[k].ExpireAt.After(server.clock.Now()) {
+		if data, _ := server.getKeyData(k); data.ExpireAt.After(server.clock.Now()) {
 			server.KeyRUnlock(ctx, k)
 			continue
 		}
@@ -561,14 +781,8 @@ func (server *EchoVault) evictKeysWithExpiredTTL(ctx context.Context) error {
 		// Delete the expired key
 		deletedCount += 1
 		server.KeyRUnlock(ctx, k)
-		if !server.isInCluster() {
-			if err := server.DeleteKey(ctx, k); err != nil {
-				return fmt.Errorf("evictKeysWithExpiredTTL -> standalone delete: %+v", err)
-			}
-		} else if server.isInCluster() && server.raft.IsRaftLeader() {
-			if err := server.raftApplyDeleteKey(ctx, k); err != nil {
-				return fmt.Errorf("evictKeysWithExpiredTTL -> cluster delete: %+v", err)
-			}
+		if err := server.expireKey(ctx, k); err != nil {
+			return fmt.Errorf("evictKeysWithExpiredTTL -> delete: %+v", err)
 		}
 	}
 
@@ -589,3 +803,114 @@ func (server *EchoVault) evictKeysWithExpiredTTL(ctx context.Context) error {
 
 	return nil
 }
+
+// sweepKeyLocks removes the locks of keys that no longer exist in the store.
+// A lock is only removed if it can be acquired immediately, which guarantees that no goroutine is holding it.
+// Returns the number of locks that were removed.
+func (server *EchoVault) sweepKeyLocks() int {
+	server.keyCreationLock.Lock()
+	defer server.keyCreationLock.Unlock()
+	server.keyspaceLock.Lock()
+	defer server.keyspaceLock.Unlock()
+
+	removed := 0
+	for key, keyLock := range server.keyLocks {
+		if _, ok := server.store[key]; ok {
+			continue
+		}
+		if !keyLock.TryLock() {
+			// The lock is currently held, try again on the next sweep.
+			continue
+		}
+		delete(server.keyLocks, key)
+		keyLock.Unlock()
+		removed += 1
+	}
+
+	if removed > 0 {
+		log.Printf("removed %d locks of keys that no longer exist\n", removed)
+	}
+
+	return removed
+}
+
+// compactKeyspace rebuilds the store, keyLocks and key version maps when the number of keys has dropped below
+// the configured fraction of the peak number of keys. Go maps never shrink, so after mass deletions
+// the maps hold on to memory for the buckets of the deleted keys until they're rebuilt.
+// Compaction is disabled when the threshold is 0.
+// Returns true if the keyspace was compacted.
+func (server *EchoVault) compactKeyspace() bool {
+	if server.config.DefragThreshold == 0 {
+		return false
+	}
+
+	// Hold the keyspace lock for writing, so that no command reads or writes the old maps
+	// while they're copied, and every command afterwards finds the new maps.
+	server.keyspaceLock.Lock()
+	defer server.keyspaceLock.Unlock()
+
+	peak := server.defrag.peakKeys.Load()
+	if peak == 0 || float64(len(server.store)) >= server.config.DefragThreshold*float64(peak) {
+		return false
+	}
+
+	store := make(map[string]internal.KeyData, len(server.store))
+	for key, data := range server.store {
+		store[key] = data
+	}
+	server.store = store
+
+	keyLocks := make(map[string]*sync.RWMutex, len(server.keyLocks))
+	for key, lock := range server.keyLocks {
+		keyLocks[key] = lock
+	}
+	server.keyLocks = keyLocks
+
+	server.keyVersions.rwMutex.Lock()
+	versions := make(map[string]uint64, len(server.keyVersions.versions))
+	for key, version := range server.keyVersions.versions {
+		versions[key] = version
+	}
+	server.keyVersions.versions = versions
+	server.keyVersions.rwMutex.Unlock()
+
+	released := uint64(peak - int64(len(server.store)))
+
+	server.defrag.peakKeys.Store(int64(len(server.store)))
+	server.defrag.runs.Add(1)
+	server.defrag.releasedKeys.Add(released)
+	server.defrag.lastRun.Store(server.clock.Now().UnixMilli())
+
+	log.Printf("compacted keyspace from peak of %d keys to %d keys\n", peak, len(server.store))
+
+	return true
+}
+
+// getMemoryStats returns the memory usage of the server and the results of keyspace compaction.
+func (server *EchoVault) getMemoryStats() internal.MemoryStats {
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+
+	server.keyspaceLock.RLock()
+	keysCount := len(server.store)
+	server.keyspaceLock.RUnlock()
+
+	server.keysWithExpiry.rwMutex.RLock()
+	volatileKeysCount := len(server.keysWithExpiry.keys)
+	server.keysWithExpiry.rwMutex.RUnlock()
+
+	conf := server.getConfig()
+
+	return internal.MemoryStats{
+		TotalAllocated:     memStats.HeapAlloc,
+		SystemMemory:       memStats.Sys,
+		MaxMemory:          conf.MaxMemory,
+		EvictionPolicy:     conf.EvictionPolicy,
+		KeysCount:          keysCount,
+		VolatileKeysCount:  volatileKeysCount,
+		KeysPeak:           int(server.defrag.peakKeys.Load()),
+		DefragRuns:         server.defrag.runs.Load(),
+		DefragReleasedKeys: server.defrag.releasedKeys.Load(),
+		DefragLastRun:      server.defrag.lastRun.Load(),
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package echovault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/echovault/echovault/internal"
+	"log"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	tcpListener  = "tcp"
+	unixListener = "unix"
+)
+
+// startUnix starts the unix socket listener and accepts its connections in the background.
+// A stale socket file left behind by a previous run is removed first.
+func (server *EchoVault) startUnix() {
+	path := server.config.UnixSocket
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Fatal(err)
+	}
+
+	listenConfig := net.ListenConfig{}
+	listener, err := listenConfig.Listen(server.context, "unix", path)
+	if err != nil {
+		if server.context.Err() != nil {
+			// The server was shut down before it started listening.
+			return
+		}
+		log.Fatal(err)
+	}
+
+	// Restrict the processes that are allowed to connect to the socket.
+	if server.config.UnixSocketPerm != "" {
+		perm, err := strconv.ParseUint(server.config.UnixSocketPerm, 8, 32)
+		if err != nil {
+			log.Fatal(fmt.Errorf("invalid unix socket permissions %s: %+v", server.config.UnixSocketPerm, err))
+		}
+		if err = os.Chmod(path, os.FileMode(perm)); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	fmt.Printf("Starting unix socket echovault at %s...\n", path)
+
+	go server.acceptConnections(listener, unixListener)
+}
+
+// The bounds of the delay before accepting again after an accept error. The delay doubles with each consecutive
+// error, so that a persistent error (e.g. running out of file descriptors) doesn't make the accept loop spin.
+const (
+	minAcceptBackoff = 5 * time.Millisecond
+	maxAcceptBackoff = 1 * time.Second
+)
+
+// acceptConnections accepts the connections of the listener and handles each one in its own goroutine.
+// The listener is closed when the server context is cancelled, and acceptConnections returns once it's closed.
+func (server *EchoVault) acceptConnections(listener net.Listener, name string) {
+	server.listenersMutex.Lock()
+	if server.shuttingDown {
+		server.listenersMutex.Unlock()
+		if err := listener.Close(); err != nil {
+			log.Println(fmt.Errorf("%s listener close error: %+v", name, err))
+		}
+		return
+	}
+	server.connections.Add(1)
+	server.listenersMutex.Unlock()
+	defer server.connections.Done()
+
+	stop := context.AfterFunc(server.context, func() {
+		if err := listener.Close(); err != nil {
+			log.Println(fmt.Errorf("%s listener close error: %+v", name, err))
+		}
+	})
+	defer stop()
+
+	var backoff time.Duration
+	for {
+		conn, err := listener.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return
+		}
+		if err != nil {
+			server.metrics.AcceptError()
+			backoff = min(max(2*backoff, minAcceptBackoff), maxAcceptBackoff)
+			log.Printf("could not accept %s connection, retrying in %v: %+v\n", name, backoff, err)
+			select {
+			case <-server.context.Done():
+				return
+			case <-time.After(backoff):
+			}
+			continue
+		}
+		backoff = 0
+		server.connections.Add(1)
+		go func() {
+			defer server.connections.Done()
+			server.handleConnection(conn, name)
+		}()
+	}
+}
+
+// checkListenerCommand returns an error if the command is not allowed on the listener that accepted the connection.
+// A command is rejected if it matches the listener's deny list, or if the listener has an allow list
+// and the command does not match it.
+func (server *EchoVault) checkListenerCommand(ctx context.Context, command internal.Command, subCommand internal.SubCommand) error {
+	listener, ok := ctx.Value(internal.ContextListener("Listener")).(string)
+	if !ok {
+		return nil
+	}
+
+	conf := server.getConfig()
+	var allow, deny []string
+	switch listener {
+	case tcpListener:
+		allow, deny = conf.TCPAllowCommands, conf.TCPDenyCommands
+	case unixListener:
+		allow, deny = conf.UnixAllowCommands, conf.UnixDenyCommands
+	}
+
+	name := strings.ToLower(command.Command)
+	categories := command.Categories
+	if subCommand.Command != "" {
+		name = fmt.Sprintf("%s|%s", name, strings.ToLower(subCommand.Command))
+		categories = subCommand.Categories
+	}
+
+	matches := func(entry string) bool {
+		entry = strings.ToLower(entry)
+		if category, ok := strings.CutPrefix(entry, "@"); ok {
+			return slices.Contains(categories, category)
+		}
+		// A command entry matches all of its sub-commands.
+		return entry == name || strings.HasPrefix(name, entry+"|")
+	}
+
+	if slices.ContainsFunc(deny, matches) || (len(allow) > 0 && !slices.ContainsFunc(allow, matches)) {
+		return fmt.Errorf("%s is not allowed on the %s listener", strings.ToUpper(name), listener)
+	}
+
+	return nil
+}
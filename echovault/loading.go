@@ -0,0 +1,64 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package echovault
+
+import (
+	"errors"
+	"fmt"
+	"github.com/echovault/echovault/internal"
+	"github.com/echovault/echovault/internal/constants"
+	"slices"
+	"strings"
+)
+
+// errLoading is wrapped by the error returned to client commands while a restore is in progress.
+// It's replied with the LOADING error code instead of the usual error prefix.
+var errLoading = errors.New("LOADING")
+
+func (server *EchoVault) startLoading() {
+	server.loading.loaded.Store(0)
+	server.loading.total.Store(0)
+	server.loading.inProgress.Store(true)
+}
+
+func (server *EchoVault) finishLoading() {
+	server.loading.inProgress.Store(false)
+}
+
+func (server *EchoVault) setLoadingProgress(loaded, total int) {
+	server.loading.total.Store(int64(total))
+	server.loading.loaded.Store(int64(loaded))
+}
+
+// loadingPercentage returns the percentage of the keys and commands read so far that have been restored.
+func (server *EchoVault) loadingPercentage() float64 {
+	total := server.loading.total.Load()
+	if total == 0 {
+		return 0
+	}
+	return float64(server.loading.loaded.Load()) / float64(total) * 100
+}
+
+// checkLoading returns a LOADING error if a restore is in progress and the command can't be run while loading.
+// Connection commands and INFO are allowed so that clients can authenticate and follow the progress of the restore.
+func (server *EchoVault) checkLoading(command internal.Command) error {
+	if !server.loading.inProgress.Load() {
+		return nil
+	}
+	if slices.Contains(command.Categories, constants.ConnectionCategory) || strings.EqualFold(command.Command, "info") {
+		return nil
+	}
+	return fmt.Errorf("%w EchoVault is loading the dataset in memory (%.2f%%)", errLoading, server.loadingPercentage())
+}
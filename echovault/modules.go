@@ -15,15 +15,26 @@
 package echovault
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"github.com/echovault/echovault/internal"
 	"github.com/echovault/echovault/internal/constants"
+	"github.com/echovault/echovault/internal/trace"
+	"github.com/tidwall/resp"
+	"log"
 	"net"
+	"slices"
 	"strings"
+	"time"
 )
 
+// nonIdempotentReadCommands are read commands whose replies can change without any of their keys being modified.
+// Replies to these commands are never served from the reply cache. SORT and SORT_RO read keys through their BY and
+// GET patterns that are not reported by their key extraction function.
+var nonIdempotentReadCommands = []string{"hrandfield", "srandmember", "zrandmember", "ttl", "pttl", "xread", "xpending", "sort", "sort_ro"}
+
 func (server *EchoVault) getCommand(cmd string) (internal.Command, error) {
 	for _, command := range server.commands {
 		if strings.EqualFold(command.Command, cmd) {
@@ -33,6 +44,26 @@ func (server *EchoVault) getCommand(cmd string) (internal.Command, error) {
 	return internal.Command{}, fmt.Errorf("command %s not supported", cmd)
 }
 
+// unknownCommandError returns the error for a command that is not supported.
+// When a Redis protocol-compat level is configured, the error follows the wording of that Redis version.
+func (server *EchoVault) unknownCommandError(cmd []string, err error) error {
+	var args strings.Builder
+	switch server.getConfig().ProtocolCompat {
+	default:
+		return err
+	case constants.ProtocolCompatRedis6:
+		for _, arg := range cmd[1:] {
+			args.WriteString(fmt.Sprintf("`%s`, ", arg))
+		}
+		return fmt.Errorf("unknown command `%s`, with args beginning with: %s", cmd[0], args.String())
+	case constants.ProtocolCompatRedis7:
+		for _, arg := range cmd[1:] {
+			args.WriteString(fmt.Sprintf("'%s' ", arg))
+		}
+		return fmt.Errorf("unknown command '%s', with args beginning with: %s", cmd[0], args.String())
+	}
+}
+
 func (server *EchoVault) getHandlerFuncParams(ctx context.Context, cmd []string, conn *net.Conn) internal.HandlerFuncParams {
 	return internal.HandlerFuncParams{
 		Context:               ctx,
@@ -49,25 +80,56 @@ func (server *EchoVault) getHandlerFuncParams(ctx context.Context, cmd []string,
 		GetExpiry:             server.GetExpiry,
 		SetExpiry:             server.SetExpiry,
 		DeleteKey:             server.DeleteKey,
+		DeleteLockedKey:       server.DeleteLockedKey,
+		ScanKeys:              server.keyIndex.Scan,
 		TakeSnapshot:          server.takeSnapshot,
 		GetLatestSnapshotTime: server.getLatestSnapshotTime,
+		GetMemoryStats:        server.getMemoryStats,
+		GetPersistenceInfo:    server.getPersistenceInfo,
+		GetServerInfo:         server.getServerInfo,
+		GetReplicationInfo:    server.getReplicationInfo,
+		ChangeReplicationID:   server.changeReplicationID,
+		GetConfigParameters:   server.getConfigParameters,
+		SetConfigParameter:    server.setConfigParameter,
 		RewriteAOF:            server.rewriteAOF,
 		GetClock:              server.getClock,
+		GetLatencyRegistry:    server.getLatencyRegistry,
+		GetMetrics:            server.getMetrics,
+		GetSlowLog:            server.getSlowLog,
+		GetSelfTester:         server.getSelfTester,
 		GetPubSub:             server.getPubSub,
 		GetACL:                server.getACL,
+		GetClientRegistry:     server.getClientRegistry,
 		GetAllCommands:        server.getCommands,
+		WatchKeys:             server.keyWaiters.Watch,
+		ForwardPublish:        server.forwardPublish,
+		GetKeyVersion:         server.getKeyVersion,
+		ExecuteTransaction:    server.executeTransaction,
+		GetScripts:            server.getScripts,
+		ExecuteScript:         server.executeScript,
 	}
 }
 
-func (server *EchoVault) handleCommand(ctx context.Context, message []byte, conn *net.Conn, replay bool, embedded bool) ([]byte, error) {
+func (server *EchoVault) handleCommand(ctx context.Context, message []byte, conn *net.Conn, replay bool, embedded bool) (res []byte, err error) {
 	cmd, err := internal.Decode(message)
 	if err != nil {
 		return nil, err
 	}
 
+	// Record the command and the keys it modified in the trace once it completes.
+	// Replayed commands are not recorded, as they were recorded when they were first executed.
+	if server.tracer != nil && !replay {
+		var traced *trace.Command
+		ctx, traced = trace.WithCommand(ctx)
+		defer func() {
+			server.tracer.Record(cmd, res, err, traced)
+		}()
+	}
+
 	command, err := server.getCommand(cmd[0])
 	if err != nil {
-		return nil, err
+		abortTransaction(ctx)
+		return nil, server.unknownCommandError(cmd, err)
 	}
 
 	synchronize := command.Sync
@@ -75,6 +137,7 @@ func (server *EchoVault) handleCommand(ctx context.Context, message []byte, conn
 
 	sc, err := internal.GetSubCommand(command, cmd)
 	if err != nil {
+		abortTransaction(ctx)
 		return nil, err
 	}
 	subCommand, ok := sc.(internal.SubCommand)
@@ -83,32 +146,220 @@ func (server *EchoVault) handleCommand(ctx context.Context, message []byte, conn
 		handler = subCommand.HandlerFunc
 	}
 
+	// Record the latency of the command, including time spent waiting for locks and replication, and whether it failed.
+	// Sub-commands are recorded as "command|subcommand".
+	latencyName := command.Command
+	if ok {
+		latencyName = fmt.Sprintf("%s|%s", command.Command, subCommand.Command)
+	}
+	// Remember the last command of the client for CLIENT LIST.
+	if conn != nil && !embedded {
+		server.clientRegistry.RecordCommand(ctx, strings.ToLower(latencyName))
+	}
+	// Replayed commands are not recorded, as they were recorded when they were first executed.
+	if !replay {
+		server.metrics.CommandProcessed()
+		defer func(start time.Time) {
+			server.latencyRegistry.Record(latencyName, time.Since(start), err != nil)
+		}(time.Now())
+	}
+
+	// Blocking commands are executed locally, so their writes would not be replicated in a cluster.
+	blocking := slices.Contains(command.Categories, constants.BlockingCategory)
+	if blocking && internal.IsWriteCommand(command, subCommand) && server.isInCluster() {
+		return nil, fmt.Errorf("%s is not supported in cluster mode", strings.ToUpper(command.Command))
+	}
+	// Cancel a blocking command when its client disconnects, so that it does not modify the store for a client that's gone.
+	if disconnected, ok := ctx.Value(internal.ContextDisconnected("Disconnected")).(context.Context); ok && blocking {
+		var cancel context.CancelCauseFunc
+		ctx, cancel = context.WithCancelCause(ctx)
+		defer cancel(nil)
+		stop := context.AfterFunc(disconnected, func() {
+			cancel(errors.New("client disconnected"))
+		})
+		defer stop()
+	}
+	// The queued commands of a transaction, and the commands called by a script, are not replicated together,
+	// so transactions and scripts are only supported in standalone mode.
+	if (strings.EqualFold(command.Command, "multi") || slices.Contains(scriptCommands, strings.ToLower(command.Command))) &&
+		server.isInCluster() {
+		return nil, fmt.Errorf("%s is not supported in cluster mode", strings.ToUpper(command.Command))
+	}
+
+	if embedded {
+		var cancel context.CancelFunc
+		ctx, cancel = server.withCommandTimeout(ctx)
+		defer cancel()
+	}
+
+	// Reject client commands while a restore is replacing the state, so they're not interleaved with the restored writes.
+	if conn != nil && !embedded && !replay {
+		if err = server.checkLoading(command); err != nil {
+			abortTransaction(ctx)
+			return nil, err
+		}
+	}
+
+	// Enforce the command lists of the listener that accepted the connection before the ACL rules.
+	if conn != nil && !embedded {
+		if err = server.checkListenerCommand(ctx, command, subCommand); err != nil {
+			abortTransaction(ctx)
+			return nil, err
+		}
+	}
+
 	if conn != nil && server.acl != nil && !embedded {
 		// Authorize connection if it's provided and if ACL module is present
 		// and the embedded parameter is false.
 		if err = server.acl.AuthorizeConnection(conn, cmd, command, subCommand); err != nil {
+			abortTransaction(ctx)
 			return nil, err
 		}
 	}
 
+	// Reject keys that are longer than max-key-length. Replayed commands are exempt so that existing data can be restored.
+	if !replay {
+		if err = server.checkKeyLength(command, subCommand, cmd); err != nil {
+			abortTransaction(ctx)
+			return nil, err
+		}
+	}
+
+	// While a transaction is open, queue the command to be run by EXEC.
+	if tx, ok := activeTransaction(ctx); ok && !slices.Contains(transactionCommands, strings.ToLower(command.Command)) {
+		return queueCommand(tx, command, subCommand, cmd, message)
+	}
+
+	// If clients are paused, wait until they're unpaused before processing the command.
+	// The CLIENT command is exempt so that a paused client is still able to unpause.
+	// The commands run by EXEC or by a script are exempt as the EXEC or EVAL command has already waited.
+	if conn != nil && !embedded && !strings.EqualFold(command.Command, "client") && !isExecutingTransaction(ctx) {
+		if err = server.clientRegistry.WaitIfPaused(ctx, internal.IsWriteCommand(command, subCommand)); err != nil {
+			return nil, err
+		}
+	}
+
+	// Stream the command to the monitors. Admin commands are not streamed, and neither are replayed commands
+	// as they were streamed when they were first executed.
+	if !replay && server.clientRegistry.IsMonitored() {
+		categories := command.Categories
+		if ok {
+			categories = subCommand.Categories
+		}
+		if !slices.Contains(categories, constants.AdminCategory) {
+			server.clientRegistry.Feed(time.Now(), server.clientRegistry.GetAddr(ctx), cmd)
+		}
+	}
+
+	// Hold the transaction lock while the command runs, so that no command runs while EXEC runs a transaction
+	// or while a script runs. Blocking commands are exempt as they could hold up EXEC for as long as they're blocked.
+	if !blocking && !strings.EqualFold(command.Command, "exec") && !slices.Contains(scriptCommands, strings.ToLower(command.Command)) &&
+		!isExecutingTransaction(ctx) {
+		server.transactionLock.RLock()
+		defer server.transactionLock.RUnlock()
+	}
+
+	// Remember the keys read by the command if the client has tracking turned on.
+	if conn != nil && !embedded && server.clientRegistry.IsTracking(ctx) {
+		server.trackReadKeys(ctx, command, subCommand, cmd)
+	}
+
+	// Count the keys looked up by read commands as keyspace hits or misses.
+	if !replay {
+		server.countKeyspaceLookups(ctx, command, subCommand, cmd)
+	}
+
+	// If the reply for this command is cached and none of the keys it reads have changed, return the cached reply.
+	var versions map[string]uint64
+	cacheKeys, cacheable := server.getReplyCacheKeys(command, subCommand, cmd)
+	if cacheable {
+		if res, ok := server.getCachedReply(ctx, cmd, cacheKeys); ok {
+			// The handler doesn't run, so update the keys' access count or time as its GetValue calls would have.
+			server.touchCachedKeys(ctx, cacheKeys)
+			return res, nil
+		}
+		versions = server.getKeyVersions(cacheKeys)
+	}
+
 	// If the command is a write command, wait for state copy to finish.
-	if internal.IsWriteCommand(command, subCommand) {
-		for {
-			if !server.stateCopyInProgress.Load() {
-				server.stateMutationInProgress.Store(true)
-				break
-			}
+	// Blocking commands are skipped as they could hold up the state copy for as long as they're blocked.
+	// They wait with params.WaitForStateCopy once they're about to modify the store instead.
+	if internal.IsWriteCommand(command, subCommand) && !blocking {
+		server.beginStateMutation()
+	}
+
+	// Confirm that this node can serve a linearizable read if the read consistency requires it.
+	if server.isInCluster() && !synchronize && server.config.ReadConsistency != "local" && server.config.ReadConsistency != "" &&
+		(slices.Contains(command.Categories, constants.ReadCategory) || slices.Contains(subCommand.Categories, constants.ReadCategory)) {
+		if err = server.raft.ConfirmRead(ctx, server.config.ReadConsistency == "lease"); err != nil {
+			return nil, err
 		}
 	}
 
 	if !server.isInCluster() || !synchronize {
-		res, err := handler(server.getHandlerFuncParams(ctx, cmd, conn))
+		// Reject the write before it's executed if persistence has stalled or the write cannot be logged,
+		// rather than leave the AOF behind the keyspace.
+		if internal.IsWriteCommand(command, subCommand) && !replay {
+			if server.stallProtection.Load() {
+				server.stateMutationInProgress.Store(false)
+				return nil, errWriteStall
+			}
+			// There's no AOF engine in cluster mode, where the raft log persists the writes.
+			if server.aofEngine != nil {
+				if err = server.aofEngine.Admit(); err != nil {
+					server.stateMutationInProgress.Store(false)
+					return nil, err
+				}
+			}
+		}
+
+		params := server.getHandlerFuncParams(ctx, cmd, conn)
+		params.Replay = replay
+		if internal.IsWriteCommand(command, subCommand) && blocking {
+			params.BeginStateMutation = func() func() {
+				server.beginStateMutation()
+				return func() { server.stateMutationInProgress.Store(false) }
+			}
+		}
+		stream := server.newReplyStream(ctx, conn, embedded, replay, cacheable)
+		if stream != nil {
+			params.NewReplyWriter = stream.newWriter
+		}
+		start := time.Now()
+		res, err := internal.CallHandler(handler, params)
+		if stream != nil {
+			res, err = stream.finish(res, err)
+		}
+		if !replay {
+			server.recordSlowCommand(ctx, cmd, start)
+		}
 		if err != nil {
 			return nil, err
 		}
 
+		if cacheable {
+			server.setCachedReply(cmd, cacheKeys, versions, res)
+		}
+
 		if internal.IsWriteCommand(command, subCommand) && !replay {
-			go server.aofEngine.QueueCommand(message)
+			entry := message
+			switch strings.ToLower(command.Command) {
+			case "blpop", "brpop":
+				entry = blockingPopLogEntry(cmd, res)
+			case "bzpopmin", "bzpopmax", "bzmpop":
+				entry = blockingZPopLogEntry(cmd, res)
+			case "xadd":
+				entry = xaddLogEntry(cmd, res)
+			case "xreadgroup":
+				entry = xreadgroupLogEntry(cmd, res)
+			}
+			if entry != nil {
+				server.replication.offset.Add(uint64(len(entry)))
+				// Queued synchronously so that the commands are logged in order and a full queue blocks the writer.
+				if server.aofEngine != nil {
+					server.aofEngine.QueueCommand(entry)
+				}
+			}
 		}
 
 		server.stateMutationInProgress.Store(false)
@@ -119,7 +370,9 @@ func (server *EchoVault) handleCommand(ctx context.Context, message []byte, conn
 	// Handle other commands that need to be synced across the cluster
 	if server.raft.IsRaftLeader() {
 		var res []byte
+		start := time.Now()
 		res, err = server.raftApplyCommand(ctx, cmd)
+		server.recordSlowCommand(ctx, cmd, start)
 		if err != nil {
 			return nil, err
 		}
@@ -134,3 +387,264 @@ func (server *EchoVault) handleCommand(ctx context.Context, message []byte, conn
 
 	return nil, errors.New("not cluster leader, cannot carry out command")
 }
+
+// blockingPopLogEntry returns the command to log in the AOF for a blocking pop command.
+// The blocking pop is logged as the equivalent non-blocking pop on the key that the element was popped from,
+// so that replaying the log never blocks. Returns nil if the command timed out without popping an element.
+func blockingPopLogEntry(cmd []string, res []byte) []byte {
+	v, _, err := resp.NewReader(bytes.NewReader(res)).ReadValue()
+	if err != nil || v.IsNull() || len(v.Array()) != 2 {
+		return nil
+	}
+	pop := "LPOP"
+	if strings.EqualFold(cmd[0], "brpop") {
+		pop = "RPOP"
+	}
+	return internal.EncodeCommand([]string{pop, v.Array()[0].String()})
+}
+
+// blockingZPopLogEntry returns the command to log in the AOF for a blocking sorted set pop command.
+// The blocking pop is logged as a ZREM of the popped members, so that replaying the log never blocks.
+// Returns nil if the command timed out without popping a member.
+func blockingZPopLogEntry(cmd []string, res []byte) []byte {
+	v, _, err := resp.NewReader(bytes.NewReader(res)).ReadValue()
+	if err != nil || v.IsNull() || len(v.Array()) < 2 {
+		return nil
+	}
+	entry := []string{"ZREM", v.Array()[0].String()}
+	if strings.EqualFold(cmd[0], "bzmpop") {
+		for _, member := range v.Array()[1].Array() {
+			entry = append(entry, member.Array()[0].String())
+		}
+	} else {
+		entry = append(entry, v.Array()[1].String())
+	}
+	return internal.EncodeCommand(entry)
+}
+
+// xaddLogEntry returns the command to log in the AOF for XADD.
+// The ID argument is replaced with the ID of the added entry, so that replaying the log adds the entry
+// with the same ID. Returns nil if no entry was added.
+func xaddLogEntry(cmd []string, res []byte) []byte {
+	v, _, err := resp.NewReader(bytes.NewReader(res)).ReadValue()
+	if err != nil || v.IsNull() {
+		return nil
+	}
+	// Skip the options that precede the ID. The command has already been validated by the handler.
+	entry := slices.Clone(cmd)
+	i := 2
+	for ; i < len(entry); i++ {
+		if strings.EqualFold(entry[i], "maxlen") {
+			if i++; entry[i] == "~" || entry[i] == "=" {
+				i++
+			}
+		} else if !strings.EqualFold(entry[i], "nomkstream") {
+			break
+		}
+	}
+	entry[i] = v.String()
+	return internal.EncodeCommand(entry)
+}
+
+// xreadgroupLogEntry returns the command to log in the AOF for XREADGROUP.
+// The BLOCK option is removed so that replaying the log never blocks. Returns nil if no entries were read.
+func xreadgroupLogEntry(cmd []string, res []byte) []byte {
+	v, _, err := resp.NewReader(bytes.NewReader(res)).ReadValue()
+	if err != nil || v.IsNull() {
+		return nil
+	}
+	entry := make([]string, 0, len(cmd))
+	for i := 0; i < len(cmd); i++ {
+		if strings.EqualFold(cmd[i], "streams") {
+			entry = append(entry, cmd[i:]...)
+			break
+		}
+		if i > 3 && strings.EqualFold(cmd[i], "block") {
+			i++
+			continue
+		}
+		entry = append(entry, cmd[i])
+	}
+	return internal.EncodeCommand(entry)
+}
+
+// keyExtractionFunc returns the subcommand's key extraction function if it has one,
+// otherwise the command's. It returns nil if neither defines one.
+func keyExtractionFunc(command internal.Command, subCommand internal.SubCommand) internal.KeyExtractionFunc {
+	if subCommand.KeyExtractionFunc != nil {
+		return subCommand.KeyExtractionFunc
+	}
+	return command.KeyExtractionFunc
+}
+
+// getReplyCacheKeys returns the keys read by the command and whether the command's reply can be cached.
+// Only read commands that do not write to keys or publish to channels, and whose replies depend solely on the
+// keys they read, are cacheable. The reply cache is only used in standalone mode.
+func (server *EchoVault) getReplyCacheKeys(command internal.Command, subCommand internal.SubCommand, cmd []string) ([]string, bool) {
+	if server.replyCache == nil || server.isInCluster() {
+		return nil, false
+	}
+
+	isReadCommand := slices.Contains(command.Categories, constants.ReadCategory) ||
+		slices.Contains(subCommand.Categories, constants.ReadCategory)
+	if !isReadCommand || internal.IsWriteCommand(command, subCommand) {
+		return nil, false
+	}
+	if slices.Contains(nonIdempotentReadCommands, strings.ToLower(command.Command)) {
+		return nil, false
+	}
+
+	extractKeys := keyExtractionFunc(command, subCommand)
+	if extractKeys == nil {
+		return nil, false
+	}
+	keys, err := extractKeys(cmd)
+	if err != nil || len(keys.ReadKeys) == 0 || len(keys.WriteKeys) > 0 || len(keys.Channels) > 0 {
+		return nil, false
+	}
+
+	return keys.ReadKeys, true
+}
+
+// trackReadKeys adds the keys read by a read-only command to the invalidation table, so that the client
+// is sent an invalidation message when one of the keys is modified.
+func (server *EchoVault) trackReadKeys(ctx context.Context, command internal.Command, subCommand internal.SubCommand, cmd []string) {
+	isReadCommand := slices.Contains(command.Categories, constants.ReadCategory) ||
+		slices.Contains(subCommand.Categories, constants.ReadCategory)
+	if !isReadCommand || internal.IsWriteCommand(command, subCommand) {
+		return
+	}
+
+	extractKeys := keyExtractionFunc(command, subCommand)
+	if extractKeys == nil {
+		return
+	}
+	keys, err := extractKeys(cmd)
+	if err != nil {
+		return
+	}
+	server.clientRegistry.Track(ctx, keys.ReadKeys)
+}
+
+// checkKeyLength returns an error if any of the keys of the command is longer than max-key-length.
+func (server *EchoVault) checkKeyLength(command internal.Command, subCommand internal.SubCommand, cmd []string) error {
+	maxKeyLength := server.getConfig().MaxKeyLength
+	if maxKeyLength == 0 {
+		return nil
+	}
+
+	extractKeys := keyExtractionFunc(command, subCommand)
+	if extractKeys == nil {
+		return nil
+	}
+	keys, err := extractKeys(cmd)
+	if err != nil {
+		// The handler returns the same error.
+		return nil
+	}
+
+	for _, keys := range [][]string{keys.ReadKeys, keys.WriteKeys} {
+		for _, key := range keys {
+			if uint64(len(key)) > maxKeyLength {
+				return fmt.Errorf("key length %d exceeds max-key-length of %d bytes", len(key), maxKeyLength)
+			}
+		}
+	}
+	return nil
+}
+
+// recordSlowCommand adds the command to the slow log if it has taken longer than the slow log threshold
+// since it started executing.
+func (server *EchoVault) recordSlowCommand(ctx context.Context, cmd []string, start time.Time) {
+	d := time.Since(start)
+	if !server.slowLog.IsSlow(d) {
+		return
+	}
+	server.slowLog.Record(start, d, cmd, server.clientRegistry.GetAddr(ctx), server.clientRegistry.GetName(ctx))
+}
+
+// countKeyspaceLookups counts each key read by a read-only command as a keyspace hit if it exists,
+// or as a miss if it does not exist or has expired.
+func (server *EchoVault) countKeyspaceLookups(ctx context.Context, command internal.Command, subCommand internal.SubCommand, cmd []string) {
+	isReadCommand := slices.Contains(command.Categories, constants.ReadCategory) ||
+		slices.Contains(subCommand.Categories, constants.ReadCategory)
+	if !isReadCommand || internal.IsWriteCommand(command, subCommand) {
+		return
+	}
+
+	extractKeys := keyExtractionFunc(command, subCommand)
+	if extractKeys == nil {
+		return
+	}
+	keys, err := extractKeys(cmd)
+	if err != nil {
+		return
+	}
+
+	for _, key := range keys.ReadKeys {
+		entry, ok := server.readKeyData(ctx, key)
+		hit := ok && (entry.ExpireAt == (time.Time{}) || entry.ExpireAt.After(server.clock.Now()))
+		server.metrics.KeyspaceLookup(hit)
+	}
+}
+
+// getCachedReply returns the cached reply for the command if none of its keys have changed since it was cached.
+// Replies that read a volatile key that has already expired are never served from the cache.
+func (server *EchoVault) getCachedReply(ctx context.Context, cmd []string, keys []string) ([]byte, bool) {
+	for _, key := range keys {
+		data, ok := server.readKeyData(ctx, key)
+		if ok && data.ExpireAt != (time.Time{}) && data.ExpireAt.Before(server.clock.Now()) {
+			return nil, false
+		}
+	}
+	return server.replyCache.Get(string(internal.EncodeCommand(cmd)), server.getKeyVersion)
+}
+
+// touchCachedKeys updates the access count or time of the keys read by a reply served from the cache.
+// The keys are not touched if the client has the NO-TOUCH flag set.
+func (server *EchoVault) touchCachedKeys(ctx context.Context, keys []string) {
+	if server.clientRegistry.IsNoTouch(ctx) {
+		return
+	}
+	for _, key := range keys {
+		// Keys that no longer exist are skipped, so that they're not added back to the cache.
+		if _, err := server.KeyRLock(ctx, key); err != nil {
+			continue
+		}
+		if err := server.updateKeyInCache(ctx, key); err != nil {
+			log.Printf("touchCachedKeys error: %+v\n", err)
+		}
+		server.KeyRUnlock(ctx, key)
+	}
+}
+
+// setCachedReply caches the reply for the command if none of the keys it read were modified while the
+// command was being handled. versions are the key versions captured before the handler was called.
+func (server *EchoVault) setCachedReply(cmd []string, keys []string, versions map[string]uint64, res []byte) {
+	for _, key := range keys {
+		if server.getKeyVersion(key) != versions[key] {
+			return
+		}
+	}
+	server.replyCache.Set(string(internal.EncodeCommand(cmd)), versions, res)
+}
+
+// getKeyVersions returns the current version of each of the provided keys.
+func (server *EchoVault) getKeyVersions(keys []string) map[string]uint64 {
+	versions := make(map[string]uint64, len(keys))
+	for _, key := range keys {
+		versions[key] = server.getKeyVersion(key)
+	}
+	return versions
+}
+
+// beginStateMutation waits until no state copy is in progress and marks a state mutation in progress,
+// so that getState does not copy the store while it's being modified.
+func (server *EchoVault) beginStateMutation() {
+	for {
+		if !server.stateCopyInProgress.Load() {
+			server.stateMutationInProgress.Store(true)
+			break
+		}
+	}
+}
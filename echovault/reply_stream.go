@@ -0,0 +1,78 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package echovault
+
+import (
+	"context"
+	"github.com/echovault/echovault/internal/modules/connection"
+	"github.com/echovault/echovault/types"
+	"log"
+	"net"
+)
+
+// replyStreamFlushSize is the number of bytes of a streamed reply that are buffered before they're written to the connection.
+const replyStreamFlushSize = 64 * 1024
+
+// replyStream writes the reply of a command to the connection while the handler is still writing it.
+// The client's write lock is acquired on the first write and held until the end of the reply, so that push messages
+// are not written in the middle of the reply.
+type replyStream struct {
+	ctx      context.Context
+	conn     net.Conn
+	registry *connection.ClientRegistry
+	locked   bool
+}
+
+// newReplyStream returns a reply stream for the command, or nil if its reply cannot be streamed.
+// Replies are only streamed to connections. The replies of the commands run by EXEC or by a script are returned
+// to EXEC or to the script, and cached and traced replies must be complete, so they are not streamed either.
+func (server *EchoVault) newReplyStream(ctx context.Context, conn *net.Conn, embedded bool, replay bool, cacheable bool) *replyStream {
+	if conn == nil || embedded || replay || cacheable || server.tracer != nil || isExecutingTransaction(ctx) {
+		return nil
+	}
+	return &replyStream{ctx: ctx, conn: *conn, registry: server.clientRegistry}
+}
+
+func (stream *replyStream) Write(b []byte) (int, error) {
+	if !stream.locked {
+		stream.registry.LockWrites(stream.ctx)
+		stream.locked = true
+	}
+	return stream.conn.Write(b)
+}
+
+func (stream *replyStream) newWriter() *types.ResponseWriter {
+	return types.NewStreamingResponseWriter(stream, replyStreamFlushSize)
+}
+
+// finish writes the rest of the reply to the connection if part of it has already been streamed, and releases
+// the client's write lock. The connection has nothing left to write in that case, so finish returns an empty reply.
+// If the handler fails after part of the reply was streamed, the reply cannot be completed so the connection is closed.
+func (stream *replyStream) finish(res []byte, err error) ([]byte, error) {
+	if !stream.locked {
+		return res, err
+	}
+	defer stream.registry.UnlockWrites(stream.ctx)
+
+	if err != nil {
+		log.Printf("closing connection after a partially streamed reply: %+v\n", err)
+		if closeErr := stream.conn.Close(); closeErr != nil {
+			log.Println(closeErr)
+		}
+		return nil, err
+	}
+	writeReply(stream.conn, res)
+	return nil, nil
+}
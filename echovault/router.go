@@ -0,0 +1,119 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package echovault
+
+import (
+	"context"
+	"errors"
+	"github.com/echovault/echovault/internal"
+	"github.com/echovault/echovault/internal/constants"
+	"slices"
+	"sync/atomic"
+)
+
+// Router executes commands on a group of embedded EchoVault nodes that belong to the same cluster,
+// e.g. when several nodes run in one process for tests or edge deployments.
+// Write commands are executed on the raft leader, and read commands are spread across the followers in turn.
+// The leader is looked up on every command, so the router follows leadership changes.
+type Router struct {
+	nodes []*EchoVault
+	next  atomic.Uint64
+}
+
+// NewRouter creates a Router over the provided nodes. A standalone node is treated as a leader with no followers.
+//
+// Errors:
+//
+// "router requires at least one node" - If no nodes are provided.
+func NewRouter(nodes ...*EchoVault) (*Router, error) {
+	if len(nodes) == 0 {
+		return nil, errors.New("router requires at least one node")
+	}
+	return &Router{nodes: nodes}, nil
+}
+
+// Leader returns the node that is currently the raft leader.
+//
+// Errors:
+//
+// "no leader available" - If none of the nodes is the leader, e.g. during an election.
+func (router *Router) Leader() (*EchoVault, error) {
+	for _, node := range router.nodes {
+		if node.isLeader() {
+			return node, nil
+		}
+	}
+	return nil, errors.New("no leader available")
+}
+
+// Followers returns the nodes that are not the raft leader, in the order they were passed to NewRouter.
+func (router *Router) Followers() []*EchoVault {
+	var followers []*EchoVault
+	for _, node := range router.nodes {
+		if !node.isLeader() {
+			followers = append(followers, node)
+		}
+	}
+	return followers
+}
+
+// ExecuteCommand executes the command on the node chosen from the command's categories in the command table.
+// Commands in the read category that do not write are executed on the followers in round-robin order,
+// so they might not observe the most recent writes. If there are no followers, or if a follower's read-consistency
+// requires the leader to confirm reads, the command is executed on the leader. All the other commands are executed
+// on the leader.
+//
+// The parameters, reply and errors are the same as EchoVault.ExecuteCommand. Additional errors returned include:
+//
+// "no leader available" - If the command must be executed on the leader and none of the nodes is the leader.
+func (router *Router) ExecuteCommand(ctx context.Context, args ...string) (Reply, error) {
+	if len(args) > 0 && router.isReadOnly(args) {
+		followers := slices.DeleteFunc(router.Followers(), func(node *EchoVault) bool {
+			consistency := node.getConfig().ReadConsistency
+			return consistency != "" && consistency != "local"
+		})
+		if len(followers) > 0 {
+			node := followers[(router.next.Add(1)-1)%uint64(len(followers))]
+			return node.ExecuteCommand(ctx, args...)
+		}
+	}
+
+	leader, err := router.Leader()
+	if err != nil {
+		return Reply{}, err
+	}
+	return leader.ExecuteCommand(ctx, args...)
+}
+
+// isReadOnly returns true if the command is in the read category and not in the write category.
+// Unknown commands are not read-only, so that the leader reports the error.
+func (router *Router) isReadOnly(args []string) bool {
+	command, err := router.nodes[0].getCommand(args[0])
+	if err != nil {
+		return false
+	}
+	sc, err := internal.GetSubCommand(command, args)
+	if err != nil {
+		return false
+	}
+	subCommand, _ := sc.(internal.SubCommand)
+	categories := append(command.Categories[:len(command.Categories):len(command.Categories)], subCommand.Categories...)
+	return slices.Contains(categories, constants.ReadCategory) && !internal.IsWriteCommand(command, subCommand)
+}
+
+// isLeader returns true if the node accepts writes, i.e. it's in standalone mode or it's the raft leader.
+func (server *EchoVault) isLeader() bool {
+	return !server.isInCluster() || server.raft.IsRaftLeader()
+}
@@ -0,0 +1,58 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package echovault
+
+import (
+	"context"
+	"fmt"
+	"github.com/echovault/echovault/internal"
+	"github.com/echovault/echovault/internal/constants"
+	"github.com/echovault/echovault/internal/modules/scripting"
+	"net"
+	"slices"
+	"strings"
+)
+
+// scriptCommands are the commands that run scripts. They take the transaction lock for writing themselves.
+var scriptCommands = []string{"eval", "evalsha"}
+
+// executeScript runs the script with no other command running in between.
+// The commands called by the script are handled like commands sent on the connection, so they are
+// authorized, logged in the AOF and recorded by the latency registry individually.
+func (server *EchoVault) executeScript(ctx context.Context, conn *net.Conn, script string, keys []string, args []string) ([]byte, error) {
+	// A script run by EXEC already holds the transaction lock.
+	if !isExecutingTransaction(ctx) {
+		server.transactionLock.Lock()
+		defer server.transactionLock.Unlock()
+		ctx = context.WithValue(ctx, internal.ContextTransaction("Exec"), true)
+	}
+
+	return scripting.Run(ctx, script, keys, args, server.getConfig().LuaTimeLimit, func(cmd []string) ([]byte, error) {
+		command, err := server.getCommand(cmd[0])
+		if err != nil {
+			return nil, server.unknownCommandError(cmd, err)
+		}
+		name := strings.ToLower(command.Command)
+		if slices.Contains(command.Categories, constants.BlockingCategory) ||
+			slices.Contains(transactionCommands, name) || slices.Contains(scriptCommands, name) || name == "script" {
+			return nil, fmt.Errorf("%s is not allowed from scripts", strings.ToUpper(command.Command))
+		}
+		res, err := server.handleCommand(ctx, internal.EncodeCommand(cmd), conn, false, false)
+		if err == nil && len(res) == 0 {
+			res = []byte("$-1\r\n")
+		}
+		return res, err
+	})
+}
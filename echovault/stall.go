@@ -0,0 +1,56 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package echovault
+
+import (
+	"errors"
+	"log"
+	"time"
+)
+
+// errWriteStall is returned to write commands while the server is in stall protection mode.
+var errWriteStall = errors.New("persistence is stalled, write commands are rejected until it catches up")
+
+// writeStall returns how long the AOF write or fsync, or the snapshot, that has been running the longest
+// has been in progress. It returns 0 if neither is in progress.
+func (server *EchoVault) writeStall() time.Duration {
+	var stall time.Duration
+	if server.aofEngine != nil {
+		stall = server.aofEngine.WriteStall()
+	}
+	if startedAt := server.snapshotStartedAt.Load(); startedAt != 0 {
+		stall = max(stall, server.clock.Now().Sub(time.Unix(0, startedAt)))
+	}
+	return stall
+}
+
+// checkWriteStall is called periodically to check for persistence stalls.
+// Once a stall lasts for the threshold, the server enters stall protection mode and rejects write commands,
+// so that the writes don't pile up in memory behind the stalled disk. The server leaves the mode once the stalled
+// operation has completed and the queued AOF writes have been written.
+func (server *EchoVault) checkWriteStall(threshold time.Duration) {
+	stall := server.writeStall()
+	switch {
+	case stall >= threshold && !server.stallProtection.Load():
+		server.stallProtection.Store(true)
+		log.Printf("persistence has stalled for %s, rejecting write commands until it catches up\n", stall)
+	case stall < threshold && server.stallProtection.Load():
+		if server.aofEngine != nil && server.aofEngine.QueueDepth() > 0 {
+			return
+		}
+		server.stallProtection.Store(false)
+		log.Println("persistence has caught up, accepting write commands")
+	}
+}
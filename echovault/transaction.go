@@ -0,0 +1,108 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package echovault
+
+import (
+	"context"
+	"fmt"
+	"github.com/echovault/echovault/internal"
+	"github.com/echovault/echovault/internal/constants"
+	"github.com/echovault/echovault/internal/modules/transaction"
+	"net"
+	"slices"
+	"strings"
+)
+
+// transactionCommands are run straight away while a transaction is open, instead of being queued.
+var transactionCommands = []string{"multi", "exec", "discard", "watch", "unwatch"}
+
+// activeTransaction returns the transaction of the connection if it is between MULTI and EXEC or DISCARD.
+func activeTransaction(ctx context.Context) (*transaction.Transaction, bool) {
+	tx, ok := ctx.Value(internal.ContextTransaction("Transaction")).(*transaction.Transaction)
+	if !ok || !tx.Active() {
+		return nil, false
+	}
+	return tx, true
+}
+
+// isExecutingTransaction returns true if the command is being run by EXEC or by a script,
+// while the transaction lock is held for writing.
+func isExecutingTransaction(ctx context.Context) bool {
+	_, ok := ctx.Value(internal.ContextTransaction("Exec")).(bool)
+	return ok
+}
+
+// abortTransaction marks the connection's open transaction, if any, so that EXEC discards it.
+// It is called when a command sent after MULTI is rejected.
+func abortTransaction(ctx context.Context) {
+	if tx, ok := activeTransaction(ctx); ok {
+		tx.Abort()
+	}
+}
+
+// queueCommand adds the command to the transaction after checking that it can be run.
+// If the command is rejected, the transaction is aborted.
+func queueCommand(tx *transaction.Transaction, command internal.Command, subCommand internal.SubCommand, cmd []string, message []byte) ([]byte, error) {
+	if slices.Contains(command.Categories, constants.BlockingCategory) {
+		tx.Abort()
+		return nil, fmt.Errorf("%s is not allowed in a transaction", strings.ToUpper(command.Command))
+	}
+	extractKeys := keyExtractionFunc(command, subCommand)
+	if extractKeys != nil {
+		if _, err := extractKeys(cmd); err != nil {
+			tx.Abort()
+			return nil, err
+		}
+	}
+	tx.Queue(message)
+	return []byte("+QUEUED\r\n"), nil
+}
+
+// executeExclusive calls fn with no other command running in between, like EXEC runs a transaction.
+// The commands handled with the context passed to fn do not wait for the transaction lock.
+func (server *EchoVault) executeExclusive(ctx context.Context, fn func(ctx context.Context) ([]byte, error)) ([]byte, error) {
+	server.transactionLock.Lock()
+	defer server.transactionLock.Unlock()
+	return fn(context.WithValue(ctx, internal.ContextTransaction("Exec"), true))
+}
+
+// executeTransaction runs the commands queued by a transaction, with no other command running in between.
+// Returns a nil reply without running the commands if any of the watched keys has changed since it was watched.
+// The reply of a command that fails is an error in the returned array, and the commands after it still run.
+func (server *EchoVault) executeTransaction(ctx context.Context, conn *net.Conn, watched map[string]uint64, queue [][]byte) ([]byte, error) {
+	return server.executeExclusive(ctx, func(ctx context.Context) ([]byte, error) {
+		for key, version := range watched {
+			// Delete the key if it has expired since it was watched.
+			server.KeyExists(ctx, key)
+			if server.getKeyVersion(key) != version {
+				return []byte("*-1\r\n"), nil
+			}
+		}
+
+		res := []byte(fmt.Sprintf("*%d\r\n", len(queue)))
+		for _, message := range queue {
+			r, err := server.handleCommand(ctx, message, conn, false, false)
+			switch {
+			case err != nil:
+				res = append(res, server.errorReply(err)...)
+			case len(r) == 0:
+				res = append(res, []byte("$-1\r\n")...)
+			default:
+				res = append(res, r...)
+			}
+		}
+		return res, nil
+	})
+}
@@ -15,6 +15,7 @@
 package aof
 
 import (
+	"errors"
 	"fmt"
 	"github.com/echovault/echovault/internal"
 	logstore "github.com/echovault/echovault/internal/aof/log"
@@ -22,15 +23,23 @@ import (
 	"github.com/echovault/echovault/internal/clock"
 	"log"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // This package handles AOF logging in standalone mode only.
 // Logging in replication clusters is handled in the raft layer.
 
+// ErrQueueFull is returned by Admit when the log queue is full and the queue policy is "error".
+var ErrQueueFull = errors.New("aof queue is full, write command rejected")
+
 type Engine struct {
 	clock        clock.Clock
 	syncStrategy string
-	directory    string
+	segmentSize  uint64
+	queueLimit   int
+	queuePolicy  string
+	getDirectory func() string
 	preambleRW   preamble.PreambleReadWriter
 	appendRW     logstore.AppendReadWriter
 
@@ -45,6 +54,11 @@ type Engine struct {
 	getStateFunc      func() map[string]internal.KeyData
 	setKeyDataFunc    func(key string, data internal.KeyData)
 	handleCommand     func(command []byte)
+	progressFunc      func(loaded, total int)
+
+	lastRewriteFailed atomic.Bool   // True when the most recent log rewrite failed.
+	queueDepth        atomic.Int64  // The number of queued commands that have not been written yet.
+	rejected          atomic.Uint64 // The number of write commands rejected because the queue was full.
 }
 
 func WithClock(clock clock.Clock) func(engine *Engine) {
@@ -59,9 +73,41 @@ func WithStrategy(strategy string) func(engine *Engine) {
 	}
 }
 
+// WithSegmentSize sets the maximum size of each AOF log segment. When 0, the log is written to a single file.
+func WithSegmentSize(size uint64) func(engine *Engine) {
+	return func(engine *Engine) {
+		engine.segmentSize = size
+	}
+}
+
+// WithQueueLimit sets the maximum number of commands waiting to be written to the log.
+func WithQueueLimit(limit uint) func(engine *Engine) {
+	return func(engine *Engine) {
+		if limit > 0 {
+			engine.queueLimit = int(limit)
+		}
+	}
+}
+
+// WithQueuePolicy sets what happens to write commands when the queue is full.
+// "block" makes QueueCommand wait until there's room in the queue, "error" makes Admit reject the command.
+func WithQueuePolicy(policy string) func(engine *Engine) {
+	return func(engine *Engine) {
+		engine.queuePolicy = policy
+	}
+}
+
 func WithDirectory(directory string) func(engine *Engine) {
 	return func(engine *Engine) {
-		engine.directory = directory
+		engine.getDirectory = func() string { return directory }
+	}
+}
+
+// WithDirectoryFunc sets the function used to retrieve the AOF directory.
+// The preamble and log files are re-opened in the new directory when the directory changes.
+func WithDirectoryFunc(f func() string) func(engine *Engine) {
+	return func(engine *Engine) {
+		engine.getDirectory = f
 	}
 }
 
@@ -95,6 +141,15 @@ func WithHandleCommandFunc(f func(command []byte)) func(engine *Engine) {
 	}
 }
 
+// WithRestoreProgressFunc sets the function that's called as the preamble and the log are restored.
+// It's called with the number of keys and commands restored so far and the total number read so far,
+// so the total grows when the log is read after the preamble.
+func WithRestoreProgressFunc(f func(loaded, total int)) func(engine *Engine) {
+	return func(engine *Engine) {
+		engine.progressFunc = f
+	}
+}
+
 func WithPreambleReadWriter(rw preamble.PreambleReadWriter) func(engine *Engine) {
 	return func(engine *Engine) {
 		engine.preambleRW = rw
@@ -111,15 +166,17 @@ func NewAOFEngine(options ...func(engine *Engine)) *Engine {
 	engine := &Engine{
 		clock:             clock.NewClock(),
 		syncStrategy:      "everysec",
-		directory:         "",
+		queueLimit:        4096,
+		queuePolicy:       "block",
+		getDirectory:      func() string { return "" },
 		mut:               sync.Mutex{},
-		logChan:           make(chan []byte, 4096),
 		logCount:          0,
 		startRewriteFunc:  func() {},
 		finishRewriteFunc: func() {},
 		getStateFunc:      func() map[string]internal.KeyData { return nil },
 		setKeyDataFunc:    func(key string, data internal.KeyData) {},
 		handleCommand:     func(command []byte) {},
+		progressFunc:      func(loaded, total int) {},
 	}
 
 	// Setup AOFEngine options first as these options are used
@@ -128,22 +185,35 @@ func NewAOFEngine(options ...func(engine *Engine)) *Engine {
 		option(engine)
 	}
 
+	engine.logChan = make(chan []byte, engine.queueLimit)
+
+	// The keys restored from the preamble are counted towards the progress of the log restore.
+	var preambleKeys int
+
 	// Setup Preamble engine
 	engine.preambleStore = preamble.NewPreambleStore(
 		preamble.WithClock(engine.clock),
-		preamble.WithDirectory(engine.directory),
+		preamble.WithDirectoryFunc(engine.getDirectory),
 		preamble.WithReadWriter(engine.preambleRW),
 		preamble.WithGetStateFunc(engine.getStateFunc),
 		preamble.WithSetKeyDataFunc(engine.setKeyDataFunc),
+		preamble.WithRestoreProgressFunc(func(loaded, total int) {
+			preambleKeys = total
+			engine.progressFunc(loaded, total)
+		}),
 	)
 
 	// Setup AOF log store engine
 	engine.appendStore = logstore.NewAppendStore(
 		logstore.WithClock(engine.clock),
-		logstore.WithDirectory(engine.directory),
+		logstore.WithDirectoryFunc(engine.getDirectory),
 		logstore.WithStrategy(engine.syncStrategy),
+		logstore.WithSegmentSize(engine.segmentSize),
 		logstore.WithReadWriter(engine.appendRW),
 		logstore.WithHandleCommandFunc(engine.handleCommand),
+		logstore.WithRestoreProgressFunc(func(loaded, total int) {
+			engine.progressFunc(preambleKeys+loaded, preambleKeys+total)
+		}),
 	)
 
 	// 3. Start the goroutine to pick up queued commands in order to write them to the file.
@@ -154,16 +224,46 @@ func NewAOFEngine(options ...func(engine *Engine)) *Engine {
 			if err := engine.appendStore.Write(c); err != nil {
 				log.Println(fmt.Errorf("new aof engine error: %+v", err))
 			}
+			engine.queueDepth.Add(-1)
 		}
 	}()
 
 	return engine
 }
 
+// Admit is called before a write command is executed. It returns ErrQueueFull if the queue policy is "error"
+// and the queue is full, so that the command is rejected instead of being executed without being logged.
+func (engine *Engine) Admit() error {
+	if engine.queuePolicy == "error" && engine.queueDepth.Load() >= int64(engine.queueLimit) {
+		engine.rejected.Add(1)
+		return ErrQueueFull
+	}
+	return nil
+}
+
+// QueueCommand queues the command to be written to the log. The commands are written in the order they're queued.
+// If the queue is full, QueueCommand blocks until the writer has made room, so a slow disk slows down the writers
+// instead of the queue growing without bound.
 func (engine *Engine) QueueCommand(command []byte) {
+	engine.queueDepth.Add(1)
 	engine.logChan <- command
 }
 
+// QueueDepth returns the number of queued commands that have not been written to the log yet.
+func (engine *Engine) QueueDepth() int64 {
+	return engine.queueDepth.Load()
+}
+
+// WriteStall returns how long the log write or fsync in progress has been running, or 0 if there's none in progress.
+func (engine *Engine) WriteStall() time.Duration {
+	return engine.appendStore.Stalled()
+}
+
+// RejectedCommands returns the number of write commands rejected by Admit because the queue was full.
+func (engine *Engine) RejectedCommands() uint64 {
+	return engine.rejected.Load()
+}
+
 func (engine *Engine) RewriteLog() error {
 	engine.mut.Lock()
 	defer engine.mut.Unlock()
@@ -171,19 +271,50 @@ func (engine *Engine) RewriteLog() error {
 	engine.startRewriteFunc()
 	defer engine.finishRewriteFunc()
 
+	failed := false
+
 	// Create AOF preamble
 	if err := engine.preambleStore.CreatePreamble(); err != nil {
 		log.Println(fmt.Errorf("rewrite log -> create preamble error: %+v", err))
+		failed = true
 	}
 
 	// Truncate the AOF file.
 	if err := engine.appendStore.Truncate(); err != nil {
 		log.Println(fmt.Errorf("rewrite log -> create aof error: %+v", err))
+		failed = true
 	}
 
+	engine.lastRewriteFailed.Store(failed)
+
 	return nil
 }
 
+// VerifyTail checks that the last command in the AOF log was written completely, without restoring the log.
+func (engine *Engine) VerifyTail() error {
+	return engine.appendStore.VerifyTail()
+}
+
+// CurrentSize returns the number of bytes in the preamble and the log.
+func (engine *Engine) CurrentSize() int64 {
+	return engine.preambleStore.Size() + engine.appendStore.Size()
+}
+
+// BaseSize returns the number of bytes in the preamble, which holds the state as of the last rewrite.
+func (engine *Engine) BaseSize() int64 {
+	return engine.preambleStore.Size()
+}
+
+// LastSync returns the unix epoch in milliseconds of the last successful fsync of the log, or 0 if there was none.
+func (engine *Engine) LastSync() int64 {
+	return engine.appendStore.LastSync()
+}
+
+// LastRewriteSucceeded returns false if the most recent log rewrite failed.
+func (engine *Engine) LastRewriteSucceeded() bool {
+	return !engine.lastRewriteFailed.Load()
+}
+
 func (engine *Engine) Restore() error {
 	if err := engine.preambleStore.Restore(); err != nil {
 		log.Println(fmt.Errorf("restore aof -> restore preamble error: %+v", err))
@@ -24,8 +24,10 @@ import (
 	"log"
 	"os"
 	"path"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -36,13 +38,28 @@ type AppendReadWriter interface {
 	Sync() error
 }
 
+// manifestFile is the name of the file that lists the AOF log segments in the order they were written.
+const manifestFile = "manifest"
+
 type AppendStore struct {
 	clock         clock.Clock
 	strategy      string               // Append file sync strategy. Can only be "always", "everysec", or "no
 	mut           sync.Mutex           // Store mutex
 	rw            AppendReadWriter     // The ReadWriter used to persist and load the log
-	directory     string               // The directory for the AOF file if we must create one
+	getDirectory  func() string        // Returns the directory for the AOF file if we must create one
+	fileDirectory string               // The directory of the currently open AOF file. Empty if rw was provided.
 	handleCommand func(command []byte) // Function to handle command read from AOF log after restore
+
+	segmentSize    uint64   // The size at which the current segment is closed and a new one is started. 0 disables segments.
+	segments       []string // The file names of the log segments in the order they were written. The last one is open.
+	segmentWritten uint64   // The number of bytes in the currently open segment.
+	segmentSeq     uint64   // The sequence number of the most recently created segment.
+
+	busySince atomic.Int64 // Unix epoch in nanoseconds at which the write or sync in progress started. 0 when idle.
+	size      atomic.Int64 // The number of bytes in the log, across all the segments.
+	lastSync  atomic.Int64 // Unix epoch in milliseconds of the last successful fsync. 0 if there was none.
+
+	progressFunc func(loaded, total int) // Called after each command is restored.
 }
 
 func WithClock(clock clock.Clock) func(store *AppendStore) {
@@ -63,9 +80,27 @@ func WithReadWriter(rw AppendReadWriter) func(store *AppendStore) {
 	}
 }
 
+// WithSegmentSize sets the maximum size of each log segment. When the open segment reaches this size, it is closed
+// and a new segment is started. When 0, the log is written to a single file, unless the directory already holds
+// a segmented log, in which case the log is appended to its last segment without starting new ones.
+// Segments are only used for files opened by the store, not a ReadWriter provided with WithReadWriter.
+func WithSegmentSize(size uint64) func(store *AppendStore) {
+	return func(store *AppendStore) {
+		store.segmentSize = size
+	}
+}
+
 func WithDirectory(directory string) func(store *AppendStore) {
 	return func(store *AppendStore) {
-		store.directory = directory
+		store.getDirectory = func() string { return directory }
+	}
+}
+
+// WithDirectoryFunc sets the function used to retrieve the directory of the AOF file.
+// If the directory changes, the AOF file is re-opened in the new directory on the next write.
+func WithDirectoryFunc(f func() string) func(store *AppendStore) {
+	return func(store *AppendStore) {
+		store.getDirectory = f
 	}
 }
 
@@ -75,14 +110,23 @@ func WithHandleCommandFunc(f func(command []byte)) func(store *AppendStore) {
 	}
 }
 
+// WithRestoreProgressFunc sets the function that's called with the number of commands restored so far
+// and the total number of commands in the log.
+func WithRestoreProgressFunc(f func(loaded, total int)) func(store *AppendStore) {
+	return func(store *AppendStore) {
+		store.progressFunc = f
+	}
+}
+
 func NewAppendStore(options ...func(store *AppendStore)) *AppendStore {
 	store := &AppendStore{
 		clock:         clock.NewClock(),
-		directory:     "",
+		getDirectory:  func() string { return "" },
 		strategy:      "everysec",
 		rw:            nil,
 		mut:           sync.Mutex{},
 		handleCommand: func(command []byte) {},
+		progressFunc:  func(loaded, total int) {},
 	}
 
 	for _, option := range options {
@@ -90,19 +134,14 @@ func NewAppendStore(options ...func(store *AppendStore)) *AppendStore {
 	}
 
 	// If rw is nil, use a default file at the provided directory
-	if store.rw == nil && store.directory != "" {
-		// Create the directory if it does not exist
-		err := os.MkdirAll(path.Join(store.directory, "aof"), os.ModePerm)
-		if err != nil {
-			log.Println(fmt.Errorf("new append store -> mkdir error: %+v", err))
-		}
-		f, err := os.OpenFile(path.Join(store.directory, "aof", "log.aof"), os.O_RDWR|os.O_CREATE|os.O_APPEND, os.ModePerm)
-		if err != nil {
-			log.Println(fmt.Errorf("new append store -> open file error: %+v", err))
+	if directory := store.getDirectory(); store.rw == nil && directory != "" {
+		if err := store.openFile(directory); err != nil {
+			log.Println(fmt.Errorf("new append store -> %+v", err))
 		}
-		store.rw = f
 	}
 
+	store.measure()
+
 	// Start another goroutine that takes handles syncing the content to the file system.
 	// No need to start this goroutine if sync strategy is anything other than 'everysec'.
 	if strings.EqualFold(store.strategy, "everysec") {
@@ -119,22 +158,241 @@ func NewAppendStore(options ...func(store *AppendStore)) *AppendStore {
 	return store
 }
 
+// openFile opens the AOF file in the provided directory, creating the directory and file if they don't exist.
+func (store *AppendStore) openFile(directory string) error {
+	// Create the directory if it does not exist
+	if err := os.MkdirAll(path.Join(directory, "aof"), os.ModePerm); err != nil {
+		return fmt.Errorf("mkdir error: %+v", err)
+	}
+	// A log that was written in segments is kept in segments, so that none of them are left out of a restore.
+	if _, err := os.Stat(path.Join(directory, "aof", manifestFile)); store.segmentSize > 0 || err == nil {
+		return store.openSegments(directory)
+	}
+	f, err := os.OpenFile(path.Join(directory, "aof", "log.aof"), os.O_RDWR|os.O_CREATE|os.O_APPEND, os.ModePerm)
+	if err != nil {
+		return fmt.Errorf("open file error: %+v", err)
+	}
+	store.rw = f
+	store.fileDirectory = directory
+	return nil
+}
+
+// openSegments loads the segment manifest in the provided directory and opens the last segment for appending.
+// If there's no manifest, a log.aof file from a non-segmented log is adopted as the first segment.
+func (store *AppendStore) openSegments(directory string) error {
+	segments, err := readManifest(directory)
+	if err != nil {
+		return err
+	}
+	if len(segments) == 0 {
+		if _, err = os.Stat(path.Join(directory, "aof", "log.aof")); err == nil {
+			segments = []string{"log.aof"}
+		}
+	}
+
+	store.segmentSeq = 0
+	for _, segment := range segments {
+		if seq, ok := segmentSeq(segment); ok && seq > store.segmentSeq {
+			store.segmentSeq = seq
+		}
+	}
+
+	if len(segments) == 0 {
+		store.segmentSeq += 1
+		segments = []string{segmentName(store.segmentSeq)}
+	}
+	if err = writeManifest(directory, segments); err != nil {
+		return err
+	}
+
+	current := path.Join(directory, "aof", segments[len(segments)-1])
+	f, err := os.OpenFile(current, os.O_RDWR|os.O_CREATE|os.O_APPEND, os.ModePerm)
+	if err != nil {
+		return fmt.Errorf("open file error: %+v", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return fmt.Errorf("stat file error: %+v", err)
+	}
+
+	store.rw = f
+	store.fileDirectory = directory
+	store.segments = segments
+	store.segmentWritten = uint64(info.Size())
+	return nil
+}
+
+// segmentName returns the file name of the segment with the provided sequence number.
+func segmentName(seq uint64) string {
+	return fmt.Sprintf("log.%d.aof", seq)
+}
+
+// segmentSeq returns the sequence number of the segment file name.
+// Returns false if the file name is not a numbered segment, e.g. an adopted log.aof file.
+func segmentSeq(name string) (uint64, bool) {
+	name = strings.TrimSuffix(strings.TrimPrefix(name, "log."), ".aof")
+	seq, err := strconv.ParseUint(name, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return seq, true
+}
+
+// readManifest returns the segment file names listed in the manifest in the provided directory.
+// Returns an empty list if the manifest does not exist.
+func readManifest(directory string) ([]string, error) {
+	b, err := os.ReadFile(path.Join(directory, "aof", manifestFile))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return []string{}, nil
+		}
+		return nil, fmt.Errorf("read manifest error: %+v", err)
+	}
+	var segments []string
+	for _, line := range strings.Split(string(b), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			segments = append(segments, line)
+		}
+	}
+	return segments, nil
+}
+
+// writeManifest replaces the manifest in the provided directory with the provided segment file names.
+// The manifest is written to a temporary file first and then renamed so that it's never partially written.
+func writeManifest(directory string, segments []string) error {
+	tmp := path.Join(directory, "aof", manifestFile+".tmp")
+	if err := os.WriteFile(tmp, []byte(strings.Join(segments, "\n")+"\n"), os.ModePerm); err != nil {
+		return fmt.Errorf("write manifest error: %+v", err)
+	}
+	if err := os.Rename(tmp, path.Join(directory, "aof", manifestFile)); err != nil {
+		return fmt.Errorf("rename manifest error: %+v", err)
+	}
+	return nil
+}
+
+// startSegment closes the open segment and starts a new one. If keep is false, the closed segments are removed
+// from the manifest and deleted. The store must be locked before calling this function.
+func (store *AppendStore) startSegment(keep bool) error {
+	if err := store.rw.Sync(); err != nil {
+		return err
+	}
+	store.lastSync.Store(store.clock.Now().UnixMilli())
+	if err := store.rw.Close(); err != nil {
+		return err
+	}
+
+	store.segmentSeq += 1
+	name := segmentName(store.segmentSeq)
+	f, err := os.OpenFile(path.Join(store.fileDirectory, "aof", name),
+		os.O_RDWR|os.O_CREATE|os.O_APPEND, os.ModePerm)
+	if err != nil {
+		return fmt.Errorf("open file error: %+v", err)
+	}
+	store.rw = f
+	store.segmentWritten = 0
+
+	obsolete := store.segments
+	if keep {
+		obsolete = nil
+		store.segments = append(store.segments, name)
+	} else {
+		store.segments = []string{name}
+		store.size.Store(0)
+	}
+	if err = writeManifest(store.fileDirectory, store.segments); err != nil {
+		return err
+	}
+
+	// Obsolete segments are only deleted after the manifest no longer references them.
+	for _, segment := range obsolete {
+		if err = os.Remove(path.Join(store.fileDirectory, "aof", segment)); err != nil {
+			log.Println(fmt.Errorf("remove segment error: %+v", err))
+		}
+	}
+	return nil
+}
+
+// reopenIfDirectoryChanged closes the current AOF file and opens a new one if the directory has changed.
+// This only applies to AOF files opened by the store, not a ReadWriter provided with WithReadWriter.
+// The store must be locked before calling this function.
+func (store *AppendStore) reopenIfDirectoryChanged() error {
+	directory := store.getDirectory()
+	if store.fileDirectory == "" || directory == "" || directory == store.fileDirectory {
+		return nil
+	}
+	if err := store.rw.Close(); err != nil {
+		log.Println(fmt.Errorf("reopen aof -> close error: %+v", err))
+	}
+	if err := store.openFile(directory); err != nil {
+		return err
+	}
+	store.measure()
+	return nil
+}
+
+// measure records the size of the log. The closed segments are measured from their files,
+// and the open segment or log from the ReadWriter. The store must be locked or not yet shared.
+func (store *AppendStore) measure() {
+	if store.rw == nil {
+		store.size.Store(0)
+		return
+	}
+	var size int64
+	if store.segments != nil {
+		for _, segment := range store.segments[:len(store.segments)-1] {
+			if info, err := os.Stat(path.Join(store.fileDirectory, "aof", segment)); err == nil {
+				size += info.Size()
+			}
+		}
+		size += int64(store.segmentWritten)
+		store.size.Store(size)
+		return
+	}
+	// Restore the read position after measuring so that a subsequent restore reads the whole log.
+	pos, err := store.rw.Seek(0, io.SeekCurrent)
+	if err != nil {
+		log.Println(fmt.Errorf("measure aof -> seek error: %+v", err))
+		return
+	}
+	if size, err = store.rw.Seek(0, io.SeekEnd); err != nil {
+		log.Println(fmt.Errorf("measure aof -> seek error: %+v", err))
+	}
+	if _, err = store.rw.Seek(pos, io.SeekStart); err != nil {
+		log.Println(fmt.Errorf("measure aof -> seek error: %+v", err))
+	}
+	store.size.Store(size)
+}
+
 func (store *AppendStore) Write(command []byte) error {
 	store.mut.Lock()
 	defer store.mut.Unlock()
+	if err := store.reopenIfDirectoryChanged(); err != nil {
+		return err
+	}
 	// Skip operation if ReadWriter is not defined
 	if store.rw == nil {
 		return nil
 	}
+	store.busySince.Store(store.clock.Now().UnixNano())
+	defer store.busySince.Store(0)
 	// Add new line before writing to AOF file.
 	out := append(command, []byte("\r\n")...)
-	if _, err := store.rw.Write(out); err != nil {
+	n, err := store.rw.Write(out)
+	store.size.Add(int64(n))
+	if err != nil {
 		return err
 	}
 	if strings.EqualFold(store.strategy, "always") {
-		if err := store.Sync(); err != nil {
+		if err = store.rw.Sync(); err != nil {
 			return err
 		}
+		store.lastSync.Store(store.clock.Now().UnixMilli())
+	}
+	// Start a new segment once the current one is full.
+	store.segmentWritten += uint64(n)
+	if store.segments != nil && store.segmentSize > 0 && store.segmentWritten >= store.segmentSize {
+		return store.startSegment(true)
 	}
 	return nil
 }
@@ -143,16 +401,92 @@ func (store *AppendStore) Sync() error {
 	store.mut.Lock()
 	defer store.mut.Unlock()
 	if store.rw != nil {
-		return store.rw.Sync()
+		store.busySince.Store(store.clock.Now().UnixNano())
+		defer store.busySince.Store(0)
+		if err := store.rw.Sync(); err != nil {
+			return err
+		}
+		store.lastSync.Store(store.clock.Now().UnixMilli())
 	}
 	return nil
 }
 
+// Stalled returns how long the write or sync in progress has been running, or 0 if there's none in progress.
+func (store *AppendStore) Stalled() time.Duration {
+	since := store.busySince.Load()
+	if since == 0 {
+		return 0
+	}
+	return store.clock.Now().Sub(time.Unix(0, since))
+}
+
 func (store *AppendStore) Restore() error {
 	store.mut.Lock()
 	defer store.mut.Unlock()
 
-	buf := bufio.NewReader(store.rw)
+	if store.segments != nil {
+		return store.restoreSegments()
+	}
+
+	commands, err := readCommands(store.rw)
+	if err != nil {
+		return err
+	}
+	for i, c := range commands {
+		store.handleCommand(c)
+		store.progressFunc(i+1, len(commands))
+	}
+
+	return nil
+}
+
+// restoreSegments reads all the segments in parallel and then handles their commands in the order the segments
+// were written. The store must be locked before calling this function.
+func (store *AppendStore) restoreSegments() error {
+	commands := make([][][]byte, len(store.segments))
+	errs := make([]error, len(store.segments))
+
+	wg := sync.WaitGroup{}
+	for i, segment := range store.segments {
+		wg.Add(1)
+		go func(i int, segment string) {
+			defer wg.Done()
+			f, err := os.Open(path.Join(store.fileDirectory, "aof", segment))
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			defer func() {
+				_ = f.Close()
+			}()
+			commands[i], errs[i] = readCommands(f)
+		}(i, segment)
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+
+	total := 0
+	for _, segment := range commands {
+		total += len(segment)
+	}
+	loaded := 0
+	for _, segment := range commands {
+		for _, c := range segment {
+			store.handleCommand(c)
+			loaded++
+			store.progressFunc(loaded, total)
+		}
+	}
+
+	return nil
+}
+
+// readCommands reads the commands from an AOF log.
+func readCommands(r io.Reader) ([][]byte, error) {
+	buf := bufio.NewReader(r)
 
 	var commands [][]byte
 	var line []byte
@@ -162,7 +496,7 @@ func (store *AppendStore) Restore() error {
 		if err != nil && errors.Is(err, io.EOF) {
 			break
 		} else if err != nil {
-			return err
+			return nil, err
 		}
 		if len(b) <= 0 {
 			line = append(line, []byte("\r\n\r\n")...)
@@ -177,8 +511,48 @@ func (store *AppendStore) Restore() error {
 		line = append(line, bytes.TrimLeft(b, "\x00")...)
 	}
 
-	for _, c := range commands {
-		store.handleCommand(c)
+	return commands, nil
+}
+
+// VerifyTail checks that the last command in the log was written completely, without restoring the log.
+// When the log is segmented, only the open segment is checked.
+func (store *AppendStore) VerifyTail() error {
+	store.mut.Lock()
+	defer store.mut.Unlock()
+
+	if store.rw == nil {
+		return nil
+	}
+
+	// Restore the read position after the check so that a subsequent restore reads the whole log.
+	pos, err := store.rw.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if _, err := store.rw.Seek(pos, io.SeekStart); err != nil {
+			log.Println(fmt.Errorf("verify tail -> seek error: %+v", err))
+		}
+	}()
+
+	size, err := store.rw.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	if size == 0 {
+		return nil
+	}
+
+	// Each command is terminated by an empty line, so a complete log always ends with "\r\n\r\n".
+	tail := make([]byte, min(size, 4))
+	if _, err = store.rw.Seek(size-int64(len(tail)), io.SeekStart); err != nil {
+		return err
+	}
+	if _, err = io.ReadFull(store.rw, tail); err != nil {
+		return err
+	}
+	if !bytes.Equal(tail, []byte("\r\n\r\n")) {
+		return errors.New("aof log tail is incomplete, the last command was not fully written")
 	}
 
 	return nil
@@ -187,9 +561,17 @@ func (store *AppendStore) Restore() error {
 func (store *AppendStore) Truncate() error {
 	store.mut.Lock()
 	defer store.mut.Unlock()
+	if err := store.reopenIfDirectoryChanged(); err != nil {
+		return err
+	}
+	// When the log is segmented, drop all the segments instead of truncating the file.
+	if store.segments != nil {
+		return store.startSegment(false)
+	}
 	if err := store.rw.Truncate(0); err != nil {
 		return err
 	}
+	store.size.Store(0)
 	// Seek to the beginning of the file after truncating
 	if _, err := store.rw.Seek(0, 0); err != nil {
 		return err
@@ -197,6 +579,16 @@ func (store *AppendStore) Truncate() error {
 	return nil
 }
 
+// Size returns the number of bytes in the log, across all the segments.
+func (store *AppendStore) Size() int64 {
+	return store.size.Load()
+}
+
+// LastSync returns the unix epoch in milliseconds of the last successful fsync of the log, or 0 if there was none.
+func (store *AppendStore) LastSync() int64 {
+	return store.lastSync.Load()
+}
+
 func (store *AppendStore) Close() error {
 	store.mut.Lock()
 	defer store.mut.Unlock()
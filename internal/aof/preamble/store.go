@@ -15,7 +15,6 @@
 package preamble
 
 import (
-	"encoding/json"
 	"fmt"
 	"github.com/echovault/echovault/internal"
 	"github.com/echovault/echovault/internal/clock"
@@ -24,6 +23,7 @@ import (
 	"os"
 	"path"
 	"sync"
+	"sync/atomic"
 )
 
 type PreambleReadWriter interface {
@@ -37,9 +37,12 @@ type PreambleStore struct {
 	clock          clock.Clock
 	rw             PreambleReadWriter
 	mut            sync.Mutex
-	directory      string
+	getDirectory   func() string // Returns the directory for the preamble file if we must create one.
+	fileDirectory  string        // The directory of the currently open preamble file. Empty if rw was provided.
 	getStateFunc   func() map[string]internal.KeyData
 	setKeyDataFunc func(key string, data internal.KeyData)
+	progressFunc   func(loaded, total int) // Called after each key is restored.
+	size           atomic.Int64            // The number of bytes in the preamble.
 }
 
 func WithClock(clock clock.Clock) func(store *PreambleStore) {
@@ -66,23 +69,40 @@ func WithSetKeyDataFunc(f func(key string, data internal.KeyData)) func(store *P
 	}
 }
 
+// WithRestoreProgressFunc sets the function that's called with the number of keys restored so far
+// and the total number of keys in the preamble.
+func WithRestoreProgressFunc(f func(loaded, total int)) func(store *PreambleStore) {
+	return func(store *PreambleStore) {
+		store.progressFunc = f
+	}
+}
+
 func WithDirectory(directory string) func(store *PreambleStore) {
 	return func(store *PreambleStore) {
-		store.directory = directory
+		store.getDirectory = func() string { return directory }
+	}
+}
+
+// WithDirectoryFunc sets the function used to retrieve the directory of the preamble file.
+// If the directory changes, the preamble file is re-opened in the new directory when the next preamble is created.
+func WithDirectoryFunc(f func() string) func(store *PreambleStore) {
+	return func(store *PreambleStore) {
+		store.getDirectory = f
 	}
 }
 
 func NewPreambleStore(options ...func(store *PreambleStore)) *PreambleStore {
 	store := &PreambleStore{
-		clock:     clock.NewClock(),
-		rw:        nil,
-		mut:       sync.Mutex{},
-		directory: "",
+		clock:        clock.NewClock(),
+		rw:           nil,
+		mut:          sync.Mutex{},
+		getDirectory: func() string { return "" },
 		getStateFunc: func() map[string]internal.KeyData {
 			// No-Op by default
 			return nil
 		},
 		setKeyDataFunc: func(key string, data internal.KeyData) {},
+		progressFunc:   func(loaded, total int) {},
 	}
 
 	for _, option := range options {
@@ -90,28 +110,60 @@ func NewPreambleStore(options ...func(store *PreambleStore)) *PreambleStore {
 	}
 
 	// If rw is nil, create the default
-	if store.rw == nil && store.directory != "" {
-		err := os.MkdirAll(path.Join(store.directory, "aof"), os.ModePerm)
-		if err != nil {
-			log.Println(fmt.Errorf("new preamble store -> mkdir error: %+v", err))
+	if directory := store.getDirectory(); store.rw == nil && directory != "" {
+		if err := store.openFile(directory); err != nil {
+			log.Println(fmt.Errorf("new preamble store -> %+v", err))
 		}
-		f, err := os.OpenFile(path.Join(store.directory, "aof", "preamble.bin"), os.O_RDWR|os.O_CREATE, os.ModePerm)
-		if err != nil {
-			log.Println(fmt.Errorf("new preamble store -> open file error: %+v", err))
-		}
-		store.rw = f
 	}
 
 	return store
 }
 
+// openFile opens the preamble file in the provided directory, creating the directory and file if they don't exist.
+func (store *PreambleStore) openFile(directory string) error {
+	if err := os.MkdirAll(path.Join(directory, "aof"), os.ModePerm); err != nil {
+		return fmt.Errorf("mkdir error: %+v", err)
+	}
+	f, err := os.OpenFile(path.Join(directory, "aof", "preamble.bin"), os.O_RDWR|os.O_CREATE, os.ModePerm)
+	if err != nil {
+		return fmt.Errorf("open file error: %+v", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return fmt.Errorf("stat file error: %+v", err)
+	}
+	store.rw = f
+	store.fileDirectory = directory
+	store.size.Store(info.Size())
+	return nil
+}
+
+// reopenIfDirectoryChanged closes the current preamble file and opens a new one if the directory has changed.
+// This only applies to preamble files opened by the store, not a ReadWriter provided with WithReadWriter.
+// The store must be locked before calling this function.
+func (store *PreambleStore) reopenIfDirectoryChanged() error {
+	directory := store.getDirectory()
+	if store.fileDirectory == "" || directory == "" || directory == store.fileDirectory {
+		return nil
+	}
+	if err := store.rw.Close(); err != nil {
+		log.Println(fmt.Errorf("reopen preamble -> close error: %+v", err))
+	}
+	return store.openFile(directory)
+}
+
 func (store *PreambleStore) CreatePreamble() error {
 	store.mut.Lock()
-	store.mut.Unlock()
+	defer store.mut.Unlock()
+
+	if err := store.reopenIfDirectoryChanged(); err != nil {
+		return err
+	}
 
 	// Get current state.
 	state := store.filterExpiredKeys(store.getStateFunc())
-	o, err := json.Marshal(state)
+	o, err := internal.EncodeState(state)
 	if err != nil {
 		return err
 	}
@@ -128,6 +180,7 @@ func (store *PreambleStore) CreatePreamble() error {
 	if _, err = store.rw.Write(o); err != nil {
 		return err
 	}
+	store.size.Store(int64(len(o)))
 
 	// Sync the changes
 	if err = store.rw.Sync(); err != nil {
@@ -146,24 +199,33 @@ func (store *PreambleStore) Restore() error {
 	if err != nil {
 		return err
 	}
+	store.size.Store(int64(len(b)))
 
 	if len(b) <= 0 {
 		return nil
 	}
 
-	state := make(map[string]internal.KeyData)
-
-	if err = json.Unmarshal(b, &state); err != nil {
+	state, err := internal.DecodeState(b)
+	if err != nil {
 		return err
 	}
 
-	for key, data := range store.filterExpiredKeys(state) {
+	state = store.filterExpiredKeys(state)
+	loaded := 0
+	for key, data := range state {
 		store.setKeyDataFunc(key, data)
+		loaded++
+		store.progressFunc(loaded, len(state))
 	}
 
 	return nil
 }
 
+// Size returns the number of bytes in the preamble.
+func (store *PreambleStore) Size() int64 {
+	return store.size.Load()
+}
+
 func (store *PreambleStore) Close() error {
 	store.mut.Lock()
 	defer store.mut.Unlock()
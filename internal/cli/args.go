@@ -0,0 +1,110 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// SplitArgs splits a command line into its arguments. Arguments are separated by whitespace
+// and can be quoted to include whitespace. Double-quoted arguments support the escape sequences
+// \n, \r, \t, \b, \a, \\, \" and \xHH. Single-quoted arguments only support the escape sequence \'.
+func SplitArgs(line string) ([]string, error) {
+	var args []string
+	runes := []rune(line)
+
+	for i := 0; i < len(runes); {
+		if unicode.IsSpace(runes[i]) {
+			i++
+			continue
+		}
+
+		var arg strings.Builder
+		switch runes[i] {
+		default:
+			for ; i < len(runes) && !unicode.IsSpace(runes[i]); i++ {
+				arg.WriteRune(runes[i])
+			}
+		case '"':
+			closed := false
+			for i++; i < len(runes); i++ {
+				if runes[i] == '"' {
+					closed = true
+					i++
+					break
+				}
+				if runes[i] != '\\' || i+1 == len(runes) {
+					arg.WriteRune(runes[i])
+					continue
+				}
+				i++
+				switch runes[i] {
+				case 'n':
+					arg.WriteByte('\n')
+				case 'r':
+					arg.WriteByte('\r')
+				case 't':
+					arg.WriteByte('\t')
+				case 'b':
+					arg.WriteByte('\b')
+				case 'a':
+					arg.WriteByte('\a')
+				case 'x':
+					if i+2 < len(runes) {
+						if b, err := strconv.ParseUint(string(runes[i+1:i+3]), 16, 8); err == nil {
+							arg.WriteByte(byte(b))
+							i += 2
+							continue
+						}
+					}
+					arg.WriteRune('x')
+				default:
+					arg.WriteRune(runes[i])
+				}
+			}
+			if !closed {
+				return nil, errors.New("unbalanced quotes in command line")
+			}
+		case '\'':
+			closed := false
+			for i++; i < len(runes); i++ {
+				if runes[i] == '\'' {
+					closed = true
+					i++
+					break
+				}
+				if runes[i] == '\\' && i+1 < len(runes) && runes[i+1] == '\'' {
+					i++
+				}
+				arg.WriteRune(runes[i])
+			}
+			if !closed {
+				return nil, errors.New("unbalanced quotes in command line")
+			}
+		}
+
+		// Unquoted arguments end at whitespace, so this only rejects a closing quote followed by another character.
+		if i < len(runes) && !unicode.IsSpace(runes[i]) {
+			return nil, errors.New("closing quote must be followed by a space")
+		}
+
+		args = append(args, arg.String())
+	}
+
+	return args, nil
+}
@@ -0,0 +1,223 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"github.com/echovault/echovault/internal"
+	"github.com/tidwall/resp"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// notLeaderError is the error that a follower replies with when it can't run a command because it's not the
+// cluster leader, either because the command is a write or because it's a linearizable read.
+const notLeaderError = "not cluster leader"
+
+// Client is a connection to an EchoVault server that sends commands and reads their replies.
+type Client struct {
+	addr           string
+	username       string
+	password       string
+	tlsConfig      *tls.Config
+	dialTimeout    time.Duration
+	followRedirect bool
+	redirectOut    io.Writer
+
+	conn   net.Conn
+	reader *resp.Reader
+}
+
+// WithAuth authenticates the connection with the username and password. The username can be empty,
+// in which case the connection is authenticated as the default user.
+func WithAuth(username, password string) func(client *Client) {
+	return func(client *Client) {
+		client.username = username
+		client.password = password
+	}
+}
+
+// WithTLS connects to the server over TLS with the provided configuration.
+func WithTLS(config *tls.Config) func(client *Client) {
+	return func(client *Client) {
+		client.tlsConfig = config
+	}
+}
+
+// WithDialTimeout sets the timeout for establishing a connection. The default is 5 seconds.
+func WithDialTimeout(timeout time.Duration) func(client *Client) {
+	return func(client *Client) {
+		client.dialTimeout = timeout
+	}
+}
+
+// WithRedirects makes the client follow the cluster leader. When a follower rejects a command because it's
+// not the leader, the client connects to the leader and sends the command again.
+// Each redirect is reported on the writer, which can be nil.
+func WithRedirects(w io.Writer) func(client *Client) {
+	return func(client *Client) {
+		client.followRedirect = true
+		client.redirectOut = w
+	}
+}
+
+// NewClient connects to the server at the address, in the form host:port.
+func NewClient(addr string, options ...func(client *Client)) (*Client, error) {
+	client := &Client{
+		addr:        addr,
+		dialTimeout: 5 * time.Second,
+	}
+
+	for _, option := range options {
+		option(client)
+	}
+
+	if err := client.connect(addr); err != nil {
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// Addr returns the address of the server that the client is currently connected to.
+// This changes when the client follows a redirect.
+func (client *Client) Addr() string {
+	return client.addr
+}
+
+// connect closes the current connection, if any, and connects to the address.
+// The new connection is authenticated if credentials were provided.
+func (client *Client) connect(addr string) error {
+	var conn net.Conn
+	var err error
+	dialer := &net.Dialer{Timeout: client.dialTimeout}
+	if client.tlsConfig != nil {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, client.tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("could not connect to %s: %+v", addr, err)
+	}
+
+	if client.conn != nil {
+		_ = client.conn.Close()
+	}
+	client.addr = addr
+	client.conn = conn
+	client.reader = resp.NewReader(bufio.NewReader(conn))
+
+	if client.password == "" {
+		return nil
+	}
+
+	cmd := []string{"AUTH", client.password}
+	if client.username != "" {
+		cmd = []string{"AUTH", client.username, client.password}
+	}
+	res, err := client.roundTrip(cmd)
+	if err != nil {
+		return err
+	}
+	if res.Type() == resp.Error {
+		return fmt.Errorf("authentication failed: %s", res.String())
+	}
+	return nil
+}
+
+// roundTrip sends the command on the current connection and reads its reply.
+func (client *Client) roundTrip(cmd []string) (resp.Value, error) {
+	if err := client.Send(cmd); err != nil {
+		return resp.Value{}, err
+	}
+	return client.Receive()
+}
+
+// Send writes the command to the server without waiting for the reply.
+func (client *Client) Send(cmd []string) error {
+	if _, err := client.conn.Write(internal.EncodeCommand(cmd)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Receive reads the next reply from the server.
+func (client *Client) Receive() (resp.Value, error) {
+	value, _, err := client.reader.ReadValue()
+	return value, err
+}
+
+// Do sends the command and returns its reply. When redirects are enabled and a follower rejects the command
+// because it's not the cluster leader, the client connects to the leader and sends the command once more.
+func (client *Client) Do(cmd []string) (resp.Value, error) {
+	res, err := client.roundTrip(cmd)
+	if err != nil || !client.followRedirect || !isNotLeader(res) {
+		return res, err
+	}
+
+	addr, err := client.leaderAddr()
+	if err != nil || addr == "" {
+		// The leader is not known, so return the original reply.
+		return res, nil
+	}
+	if client.redirectOut != nil {
+		_, _ = fmt.Fprintf(client.redirectOut, "-> Redirected to %s\n", addr)
+	}
+	if err = client.connect(addr); err != nil {
+		return resp.Value{}, err
+	}
+	return client.roundTrip(cmd)
+}
+
+// leaderAddr asks the connected node for the client address of the cluster leader,
+// which followers report as master_host and master_port in INFO replication.
+// Returns an empty address if the node doesn't know the leader.
+func (client *Client) leaderAddr() (string, error) {
+	res, err := client.roundTrip([]string{"INFO", "replication"})
+	if err != nil {
+		return "", err
+	}
+	if res.Type() == resp.Error {
+		return "", nil
+	}
+	var host, port string
+	for _, line := range strings.Fields(res.String()) {
+		if value, ok := strings.CutPrefix(line, "master_host:"); ok {
+			host = value
+		}
+		if value, ok := strings.CutPrefix(line, "master_port:"); ok {
+			port = value
+		}
+	}
+	if host == "" || port == "" {
+		return "", nil
+	}
+	return net.JoinHostPort(host, port), nil
+}
+
+// Close closes the connection to the server.
+func (client *Client) Close() error {
+	return client.conn.Close()
+}
+
+// isNotLeader returns true if the reply is the error that a follower sends for a command that only the
+// cluster leader can run, e.g. "Error not cluster leader, cannot carry out command".
+func isNotLeader(value resp.Value) bool {
+	return value.Type() == resp.Error && strings.Contains(value.String(), notLeaderError)
+}
@@ -0,0 +1,92 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"fmt"
+	"github.com/tidwall/resp"
+	"strconv"
+	"strings"
+)
+
+// FormatReply formats a reply for display.
+// When raw is false, the reply is formatted for humans: strings are quoted, integers, nil replies and errors
+// are labelled, and array elements are numbered.
+// When raw is true, the reply is formatted for scripts: each value is printed as is on its own line.
+func FormatReply(value resp.Value, raw bool) string {
+	if raw {
+		return formatRaw(value)
+	}
+	return formatHuman(value, "")
+}
+
+func formatRaw(value resp.Value) string {
+	switch value.Type() {
+	case resp.Array:
+		if value.IsNull() {
+			return ""
+		}
+		elements := make([]string, len(value.Array()))
+		for i, element := range value.Array() {
+			elements[i] = formatRaw(element)
+		}
+		return strings.Join(elements, "\n")
+	case resp.Integer:
+		return strconv.Itoa(value.Integer())
+	default:
+		if value.IsNull() {
+			return ""
+		}
+		return value.String()
+	}
+}
+
+// formatHuman formats the value. The prefix is the indentation of the nested array that contains the value,
+// which is used to align the elements of nested arrays under their parent's number.
+func formatHuman(value resp.Value, prefix string) string {
+	switch value.Type() {
+	case resp.Error:
+		return fmt.Sprintf("(error) %s", value.String())
+	case resp.Integer:
+		return fmt.Sprintf("(integer) %d", value.Integer())
+	case resp.SimpleString:
+		return value.String()
+	case resp.BulkString:
+		if value.IsNull() {
+			return "(nil)"
+		}
+		return strconv.Quote(value.String())
+	case resp.Array:
+		if value.IsNull() {
+			return "(nil)"
+		}
+		if len(value.Array()) == 0 {
+			return "(empty array)"
+		}
+		width := len(strconv.Itoa(len(value.Array())))
+		var b strings.Builder
+		for i, element := range value.Array() {
+			number := fmt.Sprintf("%*d) ", width, i+1)
+			if i > 0 {
+				b.WriteString("\n" + prefix)
+			}
+			b.WriteString(number)
+			b.WriteString(formatHuman(element, prefix+strings.Repeat(" ", len(number))))
+		}
+		return b.String()
+	default:
+		return value.String()
+	}
+}
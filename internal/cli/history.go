@@ -0,0 +1,118 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// maxHistory is the number of lines kept in the history.
+const maxHistory = 1000
+
+// History is the list of command lines entered in the interactive prompt.
+// When it has a file, the history is loaded from the file and every new line is appended to it,
+// so that the history is kept between sessions.
+type History struct {
+	file  string
+	lines []string
+}
+
+// NewHistory creates a history backed by the file. If the file is empty, the history is only kept in memory.
+// Lines from previous sessions are loaded from the file if it exists.
+func NewHistory(file string) (*History, error) {
+	history := &History{file: file}
+	if file == "" {
+		return history, nil
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return history, nil
+		}
+		return nil, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			history.lines = append(history.lines, line)
+		}
+	}
+	if len(history.lines) > maxHistory {
+		history.lines = history.lines[len(history.lines)-maxHistory:]
+	}
+
+	return history, scanner.Err()
+}
+
+// Add appends the line to the history. Consecutive duplicate lines are only added once.
+func (history *History) Add(line string) error {
+	if line == "" || (len(history.lines) > 0 && history.lines[len(history.lines)-1] == line) {
+		return nil
+	}
+
+	history.lines = append(history.lines, line)
+	if len(history.lines) > maxHistory {
+		history.lines = history.lines[1:]
+	}
+
+	if history.file == "" {
+		return nil
+	}
+	f, err := os.OpenFile(history.file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	_, err = fmt.Fprintln(f, line)
+	return err
+}
+
+// Lines returns the lines in the history, from the oldest to the most recent.
+func (history *History) Lines() []string {
+	return history.lines
+}
+
+// Expand replaces a history reference with the line that it refers to. "!!" refers to the most recent line
+// and "!<n>" refers to the line numbered n in the output of the history command. Other lines are returned as is.
+func (history *History) Expand(line string) (string, error) {
+	if !strings.HasPrefix(line, "!") {
+		return line, nil
+	}
+
+	if line == "!!" {
+		if len(history.lines) == 0 {
+			return "", errors.New("history is empty")
+		}
+		return history.lines[len(history.lines)-1], nil
+	}
+
+	n, err := strconv.Atoi(line[1:])
+	if err != nil || n < 1 || n > len(history.lines) {
+		return "", fmt.Errorf("%s: event not found", line)
+	}
+	return history.lines[n-1], nil
+}
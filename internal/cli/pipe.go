@@ -0,0 +1,124 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"github.com/tidwall/resp"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// PipeResult is the summary of a bulk load with Pipe.
+type PipeResult struct {
+	Replies int // The number of replies received.
+	Errors  int // The number of replies that were errors.
+}
+
+// Pipe sends every command read from r to the server and reports the error replies on w.
+// The input is either a stream of commands encoded as RESP arrays, as produced for redis-cli --pipe,
+// or one command per line in the same format as the interactive prompt. Empty lines are skipped.
+//
+// The server reads one command at a time from a connection, so each command is sent once the reply
+// to the previous command has been received.
+func Pipe(client *Client, r io.Reader, w io.Writer) (PipeResult, error) {
+	var result PipeResult
+	input := bufio.NewReader(r)
+
+	next, err := pipeCommandReader(input)
+	if err != nil {
+		return result, err
+	}
+
+	for {
+		cmd, err := next()
+		if errors.Is(err, io.EOF) {
+			return result, nil
+		}
+		if err != nil {
+			return result, err
+		}
+		if len(cmd) == 0 {
+			continue
+		}
+
+		res, err := client.Do(cmd)
+		if err != nil {
+			return result, err
+		}
+		result.Replies++
+		if res.Type() == resp.Error {
+			result.Errors++
+			if _, err = fmt.Fprintln(w, res.String()); err != nil {
+				return result, err
+			}
+		}
+	}
+}
+
+// pipeCommandReader returns a function that reads the next command from the input.
+// The format of the input is detected from its first non-whitespace character.
+func pipeCommandReader(input *bufio.Reader) (func() ([]string, error), error) {
+	for {
+		r, _, err := input.ReadRune()
+		if errors.Is(err, io.EOF) {
+			return func() ([]string, error) { return nil, io.EOF }, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if unicode.IsSpace(r) {
+			continue
+		}
+		if err = input.UnreadRune(); err != nil {
+			return nil, err
+		}
+		if r == '*' {
+			break
+		}
+
+		// Read one command per line.
+		return func() ([]string, error) {
+			line, err := input.ReadString('\n')
+			if errors.Is(err, io.EOF) && line != "" {
+				err = nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			return SplitArgs(strings.TrimSpace(line))
+		}, nil
+	}
+
+	// Read RESP arrays.
+	reader := resp.NewReader(input)
+	return func() ([]string, error) {
+		value, _, err := reader.ReadValue()
+		if err != nil {
+			return nil, err
+		}
+		if value.Type() != resp.Array {
+			return nil, fmt.Errorf("expected a RESP array, got %s", value.Type())
+		}
+		cmd := make([]string, len(value.Array()))
+		for i, arg := range value.Array() {
+			cmd[i] = arg.String()
+		}
+		return cmd, nil
+	}, nil
+}
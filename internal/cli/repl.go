@@ -0,0 +1,126 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// REPL is the interactive prompt of the CLI.
+type REPL struct {
+	Client  *Client
+	History *History
+	Raw     bool // Print replies in raw mode.
+	Prompt  bool // Print the prompt before reading each line. This is disabled when the input is not a terminal.
+}
+
+// Run reads command lines from in until it's closed or the quit command is entered,
+// and writes the replies to out.
+// Besides server commands, the prompt supports the following commands:
+// "history" prints the numbered history, "!!" runs the previous line again, "!<n>" runs the line numbered n
+// in the history, "clear" clears the screen and "quit" or "exit" exits the prompt.
+func (repl *REPL) Run(in io.Reader, out io.Writer) error {
+	scanner := bufio.NewScanner(in)
+	// Allow long lines, e.g. when pasting large values.
+	scanner.Buffer(make([]byte, 64*1024), 512*1024*1024)
+
+	for {
+		if repl.Prompt {
+			if _, err := fmt.Fprintf(out, "%s> ", repl.Client.Addr()); err != nil {
+				return err
+			}
+		}
+		if !scanner.Scan() {
+			return scanner.Err()
+		}
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		expanded, err := repl.History.Expand(line)
+		if err != nil {
+			_, _ = fmt.Fprintf(out, "(error) %s\n", err.Error())
+			continue
+		}
+		if expanded != line && repl.Prompt {
+			_, _ = fmt.Fprintln(out, expanded)
+		}
+		line = expanded
+
+		switch strings.ToLower(line) {
+		case "quit", "exit":
+			return nil
+		case "history":
+			for i, l := range repl.History.Lines() {
+				_, _ = fmt.Fprintf(out, "%5d  %s\n", i+1, l)
+			}
+			continue
+		case "clear":
+			_, _ = fmt.Fprint(out, "\033[H\033[2J")
+			continue
+		}
+
+		if err = repl.History.Add(line); err != nil {
+			_, _ = fmt.Fprintf(out, "(error) could not save history: %s\n", err.Error())
+		}
+
+		cmd, err := SplitArgs(line)
+		if err != nil {
+			_, _ = fmt.Fprintf(out, "(error) %s\n", err.Error())
+			continue
+		}
+
+		if err = repl.Exec(cmd, out); err != nil {
+			return err
+		}
+	}
+}
+
+// Exec runs a single command and writes its reply to out.
+// The subscribe commands keep printing the published messages until the connection is closed.
+func (repl *REPL) Exec(cmd []string, out io.Writer) error {
+	if slices.ContainsFunc([]string{"subscribe", "psubscribe"}, func(s string) bool {
+		return strings.EqualFold(s, cmd[0])
+	}) {
+		if err := repl.Client.Send(cmd); err != nil {
+			return err
+		}
+		if !repl.Raw {
+			_, _ = fmt.Fprintln(out, "Reading messages... (press Ctrl-C to quit)")
+		}
+		for {
+			res, err := repl.Client.Receive()
+			if err != nil {
+				return err
+			}
+			if _, err = fmt.Fprintln(out, FormatReply(res, repl.Raw)); err != nil {
+				return err
+			}
+		}
+	}
+
+	res, err := repl.Client.Do(cmd)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(out, FormatReply(res, repl.Raw))
+	return err
+}
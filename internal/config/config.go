@@ -24,6 +24,7 @@ import (
 	"log"
 	"os"
 	"path"
+	"reflect"
 	"slices"
 	"strings"
 	"time"
@@ -32,32 +33,101 @@ import (
 )
 
 type Config struct {
-	TLS                bool          `json:"TLS" yaml:"TLS"`
-	MTLS               bool          `json:"MTLS" yaml:"MTLS"`
-	CertKeyPairs       [][]string    `json:"CertKeyPairs" yaml:"CertKeyPairs"`
-	ClientCAs          []string      `json:"ClientCAs" yaml:"ClientCAs"`
-	Port               uint16        `json:"Port" yaml:"Port"`
-	ServerID           string        `json:"ServerId" yaml:"ServerId"`
-	JoinAddr           string        `json:"JoinAddr" yaml:"JoinAddr"`
-	BindAddr           string        `json:"BindAddr" yaml:"BindAddr"`
-	RaftBindPort       uint16        `json:"RaftPort" yaml:"RaftPort"`
-	MemberListBindPort uint16        `json:"MlPort" yaml:"MlPort"`
-	InMemory           bool          `json:"InMemory" yaml:"InMemory"`
-	DataDir            string        `json:"DataDir" yaml:"DataDir"`
-	BootstrapCluster   bool          `json:"BootstrapCluster" yaml:"BootstrapCluster"`
-	AclConfig          string        `json:"AclConfig" yaml:"AclConfig"`
-	ForwardCommand     bool          `json:"ForwardCommand" yaml:"ForwardCommand"`
-	RequirePass        bool          `json:"RequirePass" yaml:"RequirePass"`
-	Password           string        `json:"Password" yaml:"Password"`
-	SnapShotThreshold  uint64        `json:"SnapshotThreshold" yaml:"SnapshotThreshold"`
-	SnapshotInterval   time.Duration `json:"SnapshotInterval" yaml:"SnapshotInterval"`
-	RestoreSnapshot    bool          `json:"RestoreSnapshot" yaml:"RestoreSnapshot"`
-	RestoreAOF         bool          `json:"RestoreAOF" yaml:"RestoreAOF"`
-	AOFSyncStrategy    string        `json:"AOFSyncStrategy" yaml:"AOFSyncStrategy"`
-	MaxMemory          uint64        `json:"MaxMemory" yaml:"MaxMemory"`
-	EvictionPolicy     string        `json:"EvictionPolicy" yaml:"EvictionPolicy"`
-	EvictionSample     uint          `json:"EvictionSample" yaml:"EvictionSample"`
-	EvictionInterval   time.Duration `json:"EvictionInterval" yaml:"EvictionInterval"`
+	TLS                bool          `json:"TLS" yaml:"TLS" flag:"tls"`
+	MTLS               bool          `json:"MTLS" yaml:"MTLS" flag:"mtls"`
+	CertKeyPairs       [][]string    `json:"CertKeyPairs" yaml:"CertKeyPairs" flag:"cert-key-pair"`
+	ClientCAs          []string      `json:"ClientCAs" yaml:"ClientCAs" flag:"client-ca"`
+	Port               uint16        `json:"Port" yaml:"Port" flag:"port"`
+	ServerID           string        `json:"ServerId" yaml:"ServerId" flag:"server-id"`
+	JoinAddr           string        `json:"JoinAddr" yaml:"JoinAddr" flag:"join-addr"`
+	PeerDiscovery      string        `json:"PeerDiscovery" yaml:"PeerDiscovery" flag:"peer-discovery"`
+	DiscoveryDNS       string        `json:"DiscoveryDNS" yaml:"DiscoveryDNS" flag:"discovery-dns"`
+	DiscoveryNamespace string        `json:"DiscoveryNamespace" yaml:"DiscoveryNamespace" flag:"discovery-namespace"`
+	DiscoverySelector  string        `json:"DiscoverySelector" yaml:"DiscoverySelector" flag:"discovery-selector"`
+	DiscoveryInterval  time.Duration `json:"DiscoveryInterval" yaml:"DiscoveryInterval" flag:"discovery-interval"`
+	BindAddr           string        `json:"BindAddr" yaml:"BindAddr" flag:"bind-addr"`
+	RaftBindPort       uint16        `json:"RaftPort" yaml:"RaftPort" flag:"raft-port"`
+	MemberListBindPort uint16        `json:"MlPort" yaml:"MlPort" flag:"memberlist-port"`
+	ClusterBindAddr    string        `json:"ClusterBindAddr" yaml:"ClusterBindAddr" flag:"cluster-bind-addr"`
+	ClusterTLS         bool          `json:"ClusterTLS" yaml:"ClusterTLS" flag:"cluster-tls"`
+	ClusterCertKeyPair []string      `json:"ClusterCertKeyPair" yaml:"ClusterCertKeyPair" flag:"cluster-cert-key-pair"`
+	ClusterCA          string        `json:"ClusterCA" yaml:"ClusterCA" flag:"cluster-ca"`
+	ClusterGossipKey   string        `json:"ClusterGossipKey" yaml:"ClusterGossipKey" flag:"cluster-gossip-key"`
+	HealthPort         uint16        `json:"HealthPort" yaml:"HealthPort" flag:"health-port"`
+	InMemory           bool          `json:"InMemory" yaml:"InMemory" flag:"in-memory"`
+	DataDir            string        `json:"DataDir" yaml:"DataDir" flag:"data-dir"`
+	BootstrapCluster   bool          `json:"BootstrapCluster" yaml:"BootstrapCluster" flag:"bootstrap-cluster"`
+	AclConfig          string        `json:"AclConfig" yaml:"AclConfig" flag:"acl-config"`
+	AclAutoSave        bool          `json:"AclAutoSave" yaml:"AclAutoSave" flag:"acl-autosave"`
+	AuthRateLimit      uint          `json:"AuthRateLimit" yaml:"AuthRateLimit" flag:"auth-rate-limit"`
+	ForwardCommand     bool          `json:"ForwardCommand" yaml:"ForwardCommand" flag:"forward-commands"`
+	RequirePass        bool          `json:"RequirePass" yaml:"RequirePass" flag:"require-pass"`
+	Password           string        `json:"Password" yaml:"Password" flag:"password"`
+	SnapShotThreshold  uint64        `json:"SnapshotThreshold" yaml:"SnapshotThreshold" flag:"snapshot-threshold"`
+	SnapshotInterval   time.Duration `json:"SnapshotInterval" yaml:"SnapshotInterval" flag:"snapshot-interval"`
+	RestoreSnapshot    bool          `json:"RestoreSnapshot" yaml:"RestoreSnapshot" flag:"restore-snapshot"`
+	RestoreAOF         bool          `json:"RestoreAOF" yaml:"RestoreAOF" flag:"restore-aof"`
+	AOFSyncStrategy    string        `json:"AOFSyncStrategy" yaml:"AOFSyncStrategy" flag:"aof-sync-strategy"`
+	AOFSegmentSize     uint64        `json:"AOFSegmentSize" yaml:"AOFSegmentSize" flag:"aof-segment-size"`
+	AOFQueueLimit      uint          `json:"AOFQueueLimit" yaml:"AOFQueueLimit" flag:"aof-queue-limit"`
+	AOFQueuePolicy     string        `json:"AOFQueuePolicy" yaml:"AOFQueuePolicy" flag:"aof-queue-policy"`
+	StallThreshold     time.Duration `json:"StallThreshold" yaml:"StallThreshold" flag:"stall-threshold"`
+	SlowlogThreshold   time.Duration `json:"SlowlogThreshold" yaml:"SlowlogThreshold" flag:"slowlog-log-slower-than"`
+	SlowlogMaxLen      uint          `json:"SlowlogMaxLen" yaml:"SlowlogMaxLen" flag:"slowlog-max-len"`
+	SelfTestOnStartup  bool          `json:"SelfTestOnStartup" yaml:"SelfTestOnStartup" flag:"selftest-on-startup"`
+	RaftBatchSize      uint          `json:"RaftBatchSize" yaml:"RaftBatchSize" flag:"raft-batch-size"`
+	ReadConsistency    string        `json:"ReadConsistency" yaml:"ReadConsistency" flag:"read-consistency"`
+	LeaseClockSkew     time.Duration `json:"LeaseClockSkew" yaml:"LeaseClockSkew" flag:"lease-clock-skew"`
+	MaxMemory          uint64        `json:"MaxMemory" yaml:"MaxMemory" flag:"max-memory"`
+	MaxKeyLength       uint64        `json:"MaxKeyLength" yaml:"MaxKeyLength" flag:"max-key-length"`
+	SetListpackSize    uint          `json:"SetListpackSize" yaml:"SetListpackSize" flag:"set-max-listpack-entries"`
+	SetListpackValue   uint          `json:"SetListpackValue" yaml:"SetListpackValue" flag:"set-max-listpack-value"`
+	ZSetListpackSize   uint          `json:"ZSetListpackSize" yaml:"ZSetListpackSize" flag:"zset-max-listpack-entries"`
+	ZSetListpackValue  uint          `json:"ZSetListpackValue" yaml:"ZSetListpackValue" flag:"zset-max-listpack-value"`
+	EvictionPolicy     string        `json:"EvictionPolicy" yaml:"EvictionPolicy" flag:"eviction-policy"`
+	EvictionSample     uint          `json:"EvictionSample" yaml:"EvictionSample" flag:"eviction-sample"`
+	EvictionInterval   time.Duration `json:"EvictionInterval" yaml:"EvictionInterval" flag:"eviction-interval"`
+	LFULogFactor       uint          `json:"LFULogFactor" yaml:"LFULogFactor" flag:"lfu-log-factor"`
+	LFUDecayTime       time.Duration `json:"LFUDecayTime" yaml:"LFUDecayTime" flag:"lfu-decay-time"`
+	IdleThreshold      time.Duration `json:"IdleThreshold" yaml:"IdleThreshold" flag:"idle-threshold"`
+	ReplyCacheSize     uint          `json:"ReplyCacheSize" yaml:"ReplyCacheSize" flag:"reply-cache-size"`
+	DefragInterval     time.Duration `json:"DefragInterval" yaml:"DefragInterval" flag:"defrag-interval"`
+	DefragThreshold    float64       `json:"DefragThreshold" yaml:"DefragThreshold" flag:"defrag-threshold"`
+	ProtocolCompat     string        `json:"ProtocolCompat" yaml:"ProtocolCompat" flag:"protocol-compat"`
+	UnixSocket         string        `json:"UnixSocket" yaml:"UnixSocket" flag:"unix-socket"`
+	UnixSocketPerm     string        `json:"UnixSocketPerm" yaml:"UnixSocketPerm" flag:"unix-socket-perm"`
+	TCPAllowCommands   []string      `json:"TCPAllowCommands" yaml:"TCPAllowCommands" flag:"tcp-allow-commands"`
+	TCPDenyCommands    []string      `json:"TCPDenyCommands" yaml:"TCPDenyCommands" flag:"tcp-deny-commands"`
+	UnixAllowCommands  []string      `json:"UnixAllowCommands" yaml:"UnixAllowCommands" flag:"unix-allow-commands"`
+	UnixDenyCommands   []string      `json:"UnixDenyCommands" yaml:"UnixDenyCommands" flag:"unix-deny-commands"`
+	TraceFile          string        `json:"TraceFile" yaml:"TraceFile" flag:"trace-file"`
+	InitFile           string        `json:"InitFile" yaml:"InitFile" flag:"init-file"`
+	LuaTimeLimit       time.Duration `json:"LuaTimeLimit" yaml:"LuaTimeLimit" flag:"lua-time-limit"`
+}
+
+// ClusterAddr returns the address that the raft and memberlist listeners bind to.
+// This is cluster-bind-addr if it's set, so that intra-cluster traffic can be kept on a separate network
+// from the client traffic, otherwise it's bind-addr.
+func (config Config) ClusterAddr() string {
+	if config.ClusterBindAddr != "" {
+		return config.ClusterBindAddr
+	}
+	return config.BindAddr
+}
+
+// ClientAddr returns the address of this node's TCP listener.
+func (config Config) ClientAddr() string {
+	return fmt.Sprintf("%s:%d", config.BindAddr, config.Port)
+}
+
+// RaftAddr returns the address of this node's raft listener.
+func (config Config) RaftAddr() string {
+	return fmt.Sprintf("%s:%d", config.ClusterAddr(), config.RaftBindPort)
+}
+
+// MemberListAddr returns the address of this node's memberlist listener.
+func (config Config) MemberListAddr() string {
+	return fmt.Sprintf("%s:%d", config.ClusterAddr(), config.MemberListBindPort)
 }
 
 func GetConfig() (Config, error) {
@@ -83,6 +153,43 @@ func GetConfig() (Config, error) {
 		return nil
 	})
 
+	var clusterCertKeyPair []string
+	flag.Func("cluster-cert-key-pair", `The certificate and key file paths, separated by a comma, that the raft transport
+presents to the other nodes when cluster-tls is true.`, func(s string) error {
+		pair := strings.Split(strings.TrimSpace(s), ",")
+		for i := 0; i < len(pair); i++ {
+			pair[i] = strings.TrimSpace(pair[i])
+		}
+		if len(pair) != 2 {
+			return errors.New("cluster-cert-key-pair must be 2 comma separated strings")
+		}
+		clusterCertKeyPair = pair
+		return nil
+	})
+
+	// Each listener's command lists are passed as comma separated entries, and the flags can be repeated.
+	var tcpAllowCommands, tcpDenyCommands, unixAllowCommands, unixDenyCommands []string
+	commandListFlag := func(list *[]string) func(string) error {
+		return func(s string) error {
+			for _, entry := range strings.Split(s, ",") {
+				if entry = strings.ToLower(strings.TrimSpace(entry)); entry != "" {
+					*list = append(*list, entry)
+				}
+			}
+			return nil
+		}
+	}
+	commandListUsage := `Comma separated list of the commands %s on the %s listener.
+An entry is a command (e.g. config), a sub-command (e.g. config|set) or a category prefixed with '@' (e.g. @admin).`
+	flag.Func("tcp-allow-commands", fmt.Sprintf(commandListUsage+`
+When the list is empty, all the commands are allowed.`, "allowed", "TCP"), commandListFlag(&tcpAllowCommands))
+	flag.Func("tcp-deny-commands", fmt.Sprintf(commandListUsage+`
+The deny list takes precedence over the allow list.`, "denied", "TCP"), commandListFlag(&tcpDenyCommands))
+	flag.Func("unix-allow-commands", fmt.Sprintf(commandListUsage+`
+When the list is empty, all the commands are allowed.`, "allowed", "unix socket"), commandListFlag(&unixAllowCommands))
+	flag.Func("unix-deny-commands", fmt.Sprintf(commandListUsage+`
+The deny list takes precedence over the allow list.`, "denied", "unix socket"), commandListFlag(&unixDenyCommands))
+
 	aofSyncStrategy := "everysec"
 	flag.Func("aof-sync-strategy", `How often to flush the file contents written to append only file.
 The options are 'always' for syncing on each command, 'everysec' to sync every second, and 'no' to leave it up to the os.`,
@@ -96,6 +203,48 @@ The options are 'always' for syncing on each command, 'everysec' to sync every s
 			return nil
 		})
 
+	var aofSegmentSize uint64
+	flag.Func("aof-segment-size", `The maximum size of each append only file segment before a new segment is started.
+Supported units (kb, mb, gb, tb, pb). When 0 is passed, the log is written to a single file. Default is 0.`,
+		func(size string) error {
+			b, err := internal.ParseMemory(size)
+			if err != nil {
+				return err
+			}
+			aofSegmentSize = b
+			return nil
+		})
+
+	aofQueueLimit := flag.Uint("aof-queue-limit", 4096, `The maximum number of commands waiting to be written to the append only file.
+The queue fills up when the disk is slower than the write rate. Default is 4096.`)
+
+	aofQueuePolicy := "block"
+	flag.Func("aof-queue-policy", `What to do with write commands when the append only file queue is full.
+The options are 'block' to make the writer wait until there's room in the queue, and 'error' to reject the command.
+Default is 'block'.`,
+		func(option string) error {
+			if !slices.ContainsFunc([]string{"block", "error"}, func(s string) bool {
+				return strings.EqualFold(s, option)
+			}) {
+				return errors.New("aofQueuePolicy must be 'block' or 'error'")
+			}
+			aofQueuePolicy = strings.ToLower(option)
+			return nil
+		})
+
+	stallThreshold := flag.Duration("stall-threshold", 0, `How long an append only file write or fsync, or a snapshot, can run before
+the server stops accepting write commands until persistence catches up. When 0 is passed, stall detection is disabled.`)
+
+	slowlogThreshold := flag.Duration("slowlog-log-slower-than", 10*time.Millisecond, `Commands that take at least this long
+to execute are recorded in the slow log. A negative duration disables the slow log and 0 records every command. Default is 10ms.`)
+	slowlogMaxLen := flag.Uint("slowlog-max-len", 128, "The maximum number of entries kept in the slow log. Default is 128.")
+
+	luaTimeLimit := flag.Duration("lua-time-limit", 5*time.Second, `The longest a Lua script can run before it's stopped with an error.
+The commands the script has already run are not rolled back. When 0 is passed, scripts can run indefinitely. Default is 5s.`)
+
+	selfTestOnStartup := flag.Bool("selftest-on-startup", false, `Measure the fsync latency, memory bandwidth and lock throughput
+of the host in the background on startup. The results are logged and reported in INFO selftest.`)
+
 	var maxMemory uint64 = 0
 	flag.Func("max-memory", `Upper memory limit before triggering eviction. 
 Supported units (kb, mb, gb, tb, pb). When 0 is passed, there will be no memory limit.
@@ -117,11 +266,14 @@ There is no limit by default.`, func(memory string) error {
 4) volatile-lfu - Evict the least frequently used keys with an expiration.
 5) volatile-lru - Evict the least recently used keys with an expiration.
 6) allkeys-random - Evict random keys until we get under the max-memory limit.
-7) volatile-random - Evict random keys with an expiration.`, func(policy string) error {
+7) volatile-random - Evict random keys with an expiration.
+8) volatile-idle - Evict the keys with an expiration that have been idle for longer than the idle threshold,
+even below max-memory. Above max-memory, evict the least recently used keys with an expiration.`, func(policy string) error {
 			policies := []string{
 				constants.NoEviction,
 				constants.AllKeysLFU, constants.AllKeysLRU, constants.AllKeysRandom,
 				constants.VolatileLFU, constants.VolatileLRU, constants.VolatileRandom,
+				constants.VolatileIdle,
 			}
 			policyIdx := slices.Index(policies, strings.ToLower(policy))
 			if policyIdx == -1 {
@@ -136,19 +288,124 @@ There is no limit by default.`, func(memory string) error {
 	port := flag.Int("port", 7480, "Port to use. Default is 7480")
 	serverId := flag.String("server-id", "1", "EchoVault ID in raft cluster. Leave empty for client.")
 	joinAddr := flag.String("join-addr", "", "Address of cluster member in a cluster to you want to join.")
+	peerDiscovery := "static"
+	flag.Func("peer-discovery", `How to discover the cluster members to join. The options are:
+1) static - Join the member at join-addr.
+2) dns-srv - Join the members listed in the DNS SRV records of discovery-dns.
+3) kubernetes - Join the running pods that match discovery-selector, using the Kubernetes API.
+With dns-srv and kubernetes, exactly one node must still be started with bootstrap-cluster to create the cluster
+the other nodes join.`, func(option string) error {
+		if !slices.ContainsFunc([]string{"static", "dns-srv", "kubernetes"}, func(s string) bool {
+			return strings.EqualFold(s, option)
+		}) {
+			return errors.New("peer-discovery must be 'static', 'dns-srv' or 'kubernetes'")
+		}
+		peerDiscovery = strings.ToLower(option)
+		return nil
+	})
+	discoveryDNS := flag.String("discovery-dns", "", `The DNS SRV record name to resolve when peer-discovery is dns-srv.
+e.g. _memberlist._tcp.echovault.default.svc.cluster.local`)
+	discoveryNamespace := flag.String("discovery-namespace", "", `The Kubernetes namespace to search for pods when peer-discovery is kubernetes.
+Defaults to the namespace of the pod's service account.`)
+	discoverySelector := flag.String("discovery-selector", "", `The Kubernetes label selector of the pods to join when peer-discovery is kubernetes.
+e.g. app=echovault`)
+	discoveryInterval := flag.Duration("discovery-interval", 30*time.Second, `The interval between each re-resolution of the cluster members
+when peer-discovery is dns-srv or kubernetes. Newly discovered members are joined.`)
 	bindAddr := flag.String("bind-addr", "", "Address to bind the echovault to.")
+	unixSocket := flag.String("unix-socket", "", `Path of the unix socket to accept connections on, in addition to the TCP listener.
+When empty, the unix socket listener is disabled. It is disabled by default.`)
+	unixSocketPerm := flag.String("unix-socket-perm", "", `The permissions of the unix socket file in octal, e.g. 770.
+When empty, the permissions are left as created by the process umask. It is empty by default.`)
 	raftBindPort := flag.Uint("raft-port", 7481, "Port to use for intra-cluster communication. Leave on the client.")
 	mlBindPort := flag.Uint("memberlist-port", 7946, "Port to use for memberlist communication.")
+	clusterBindAddr := flag.String("cluster-bind-addr", "", `Address to bind the raft and memberlist listeners to.
+Use this to keep the intra-cluster traffic on a different network interface from the clients. Defaults to bind-addr.`)
+	clusterTLS := flag.Bool("cluster-tls", false, `Encrypt the raft traffic between the nodes with TLS. Both sides of each raft
+connection are verified with cluster-ca. This is independent of the client TLS settings. Default is false.`)
+	clusterCA := flag.String("cluster-ca", "", "Path to the certificate authority used to verify the other nodes when cluster-tls is true.")
+	clusterGossipKey := flag.String("cluster-gossip-key", "", `Base64 encoded 16, 24 or 32 byte key used to encrypt the memberlist
+gossip between the nodes with AES. All the nodes must use the same key. When empty, gossip is not encrypted.`)
+	readConsistency := "local"
+	flag.Func("read-consistency", `How read commands are served in a raft cluster. The options are:
+1) local - Serve reads from the local state of any node. Reads on followers might be stale.
+2) lease - Only serve reads on the leader. Reads are served without a raft round trip while the leader lease is valid,
+and leadership is confirmed with a quorum when the lease has expired.
+3) readindex - Only serve reads on the leader, after confirming leadership with a quorum on every read.`, func(option string) error {
+		if !slices.ContainsFunc([]string{"local", "lease", "readindex"}, func(s string) bool {
+			return strings.EqualFold(s, option)
+		}) {
+			return errors.New("read-consistency must be 'local', 'lease' or 'readindex'")
+		}
+		readConsistency = strings.ToLower(option)
+		return nil
+	})
+	leaseClockSkew := flag.Duration("lease-clock-skew", 100*time.Millisecond, `The maximum clock drift between cluster nodes.
+The leader lease is shortened by this duration so that reads are never served after another node could have been elected.`)
+	raftBatchSize := flag.Uint("raft-batch-size", 128, `The maximum number of concurrent write commands applied to the raft log in a single entry.
+When 1 is passed, each write command is applied in its own entry.`)
+	healthPort := flag.Uint("health-port", 0, `Port for the HTTP listener that serves the /healthz, /livez and /readyz probes.
+When 0 is passed, the HTTP listener is disabled. It is disabled by default.`)
 	inMemory := flag.Bool("in-memory", false, "Whether to use memory or persistent storage for raft logs and snapshots.")
 	dataDir := flag.String("data-dir", "/var/lib/echovault", "Directory to store snapshots and logs.")
-	bootstrapCluster := flag.Bool("bootstrap-cluster", false, "Whether this instance should bootstrap a new cluster.")
+	bootstrapCluster := flag.Bool("bootstrap-cluster", false, `Whether this instance should bootstrap a new cluster.
+Exactly one node of a new cluster must set it, including when the peers are found with peer-discovery.`)
 	aclConfig := flag.String("acl-config", "", "ACL config file path.")
+	authRateLimit := flag.Uint("auth-rate-limit", 10, `The maximum number of AUTH attempts per second from a single client address.
+Further attempts fail until the limit allows them again. 0 disables the limit. Default is 10.`)
+	aclAutoSave := flag.Bool("acl-autosave", false, "Whether to rewrite the ACL config file in the background whenever the ACL users change.")
 	snapshotThreshold := flag.Uint64("snapshot-threshold", 1000, "The number of entries that trigger a snapshot. Default is 1000.")
 	snapshotInterval := flag.Duration("snapshot-interval", 5*time.Minute, "The time interval between snapshots (in seconds). Default is 5 minutes.")
 	restoreSnapshot := flag.Bool("restore-snapshot", false, "This flag prompts the echovault to restore state from snapshot when set to true. Only works in standalone mode. Higher priority than restoreAOF.")
 	restoreAOF := flag.Bool("restore-aof", false, "This flag prompts the echovault to restore state from append-only logs. Only works in standalone mode. Lower priority than restoreSnapshot.")
+	maxKeyLength := flag.Uint64("max-key-length", 512*1024*1024, `The maximum length of a key in bytes. Commands with a longer key fail with an error.
+When 0 is passed, the key length is not limited. The default is 512MB.`)
+	setListpackEntries := flag.Uint("set-max-listpack-entries", 128, `The maximum number of members of a set with the compact listpack encoding.
+Larger sets are converted to the hashtable encoding. The default is 128.`)
+	setListpackValue := flag.Uint("set-max-listpack-value", 64, `The maximum length in bytes of the members of a set with the compact listpack encoding.
+A set with a longer member is converted to the hashtable encoding. The default is 64.`)
+	zsetListpackEntries := flag.Uint("zset-max-listpack-entries", 128, `The maximum number of members of a sorted set with the compact listpack encoding.
+Larger sorted sets are converted to the skiplist encoding. The default is 128.`)
+	zsetListpackValue := flag.Uint("zset-max-listpack-value", 64, `The maximum length in bytes of the members of a sorted set with the compact listpack encoding.
+A sorted set with a longer member is converted to the skiplist encoding. The default is 64.`)
 	evictionSample := flag.Uint("eviction-sample", 20, "An integer specifying the number of keys to sample when checking for expired keys.")
 	evictionInterval := flag.Duration("eviction-interval", 100*time.Millisecond, "The interval between each sampling of keys to evict.")
+	lfuLogFactor := flag.Uint("lfu-log-factor", 10, `The factor that slows down the growth of the access counters of the LFU eviction policies.
+The higher the factor, the more accesses are needed to increment a counter. When 0 is passed, every access increments the counter.`)
+	lfuDecayTime := flag.Duration("lfu-decay-time", 1*time.Minute, `The idle time after which the access counter of a key is decremented by the LFU eviction policies,
+so that keys that were hot in the past but are now idle can be evicted. When 0 is passed, the counters never decay.`)
+	idleThreshold := flag.Duration("idle-threshold", 30*time.Minute, `The idle time after which keys with an expiration are evicted by the volatile-idle eviction policy,
+whether or not max-memory is reached. When 0 is passed, idle keys are only evicted when max-memory is reached.`)
+	replyCacheSize := flag.Uint("reply-cache-size", 0, `The maximum number of pre-encoded replies to cache for idempotent read commands.
+Cached replies are served until one of the keys they read is modified. When 0 is passed, the reply cache is disabled.`)
+	defragInterval := flag.Duration("defrag-interval", 1*time.Minute, `The interval between each keyspace maintenance run.
+Each run removes the locks of keys that no longer exist and compacts the keyspace if it has shrunk below the threshold.
+When 0 is passed, keyspace maintenance is disabled.`)
+	defragThreshold := flag.Float64("defrag-threshold", 0, `The keyspace is compacted when the number of keys drops below this fraction
+of the peak number of keys since the last compaction. Must be between 0 and 1. When 0 is passed, the keyspace is never compacted.`)
+	protocolCompat := constants.ProtocolCompatEchoVault
+	flag.Func("protocol-compat", `The semantics that replies follow. The options are:
+1) echovault - Keep EchoVault's own error wording and replies. SETRANGE prepends the value when the offset is negative
+and appends it when the offset is past the end of the string, SUBSTR/GETRANGE return the reversed substring when the start
+index is greater than the end index, and SINTERCARD takes its keys without the numkeys argument.
+2) redis-6 - Use the "ERR" error prefix and Redis 6 error wording, and reply with nil or an empty array
+instead of an error when reading a key that does not exist. SETRANGE, SUBSTR/GETRANGE and SINTERCARD follow Redis.
+3) redis-7 - Same as redis-6 with the Redis 7 error wording.`, func(option string) error {
+		if !slices.ContainsFunc([]string{
+			constants.ProtocolCompatEchoVault, constants.ProtocolCompatRedis6, constants.ProtocolCompatRedis7,
+		}, func(s string) bool {
+			return strings.EqualFold(s, option)
+		}) {
+			return errors.New("protocol-compat must be 'echovault', 'redis-6' or 'redis-7'")
+		}
+		protocolCompat = strings.ToLower(option)
+		return nil
+	})
+	traceFile := flag.String("trace-file", "", `Path of the file that every executed command is traced to.
+Each command is appended as a line of JSON with its reply and the keys it modified and deleted.
+This is meant for regression tests of command sequences. When empty, tracing is disabled. It is disabled by default.`)
+	initFile := flag.String("init-file", "", `Path of a file of commands, in RESP or inline format, that is executed once on first startup.
+The file is only executed when the data directory is empty, so that ACL users, initial keys and config
+can be provisioned without an external init job. When empty, no commands are executed. It is empty by default.`)
 	forwardCommand := flag.Bool(
 		"forward-commands",
 		false,
@@ -168,11 +425,24 @@ It is a plain text value by default but you can provide a SHA256 hash by adding
 	config := flag.String(
 		"config",
 		"",
-		`File path to a JSON or YAML config file.The values in this config file will override the flag values.`,
+		`File path to a JSON or YAML config file. The values in the config file override the defaults,
+and are overridden by the environment variables and the flags.`,
 	)
 
 	flag.Parse()
 
+	// Each flag that isn't passed on the command line can be set with an environment variable instead,
+	// e.g. ECHOVAULT_EVICTION_POLICY for --eviction-policy.
+	if err := setFlagsFromEnv(flag.CommandLine); err != nil {
+		return Config{}, err
+	}
+
+	// The flags passed on the command line or set with environment variables take precedence over the config file.
+	overrides := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) {
+		overrides[f.Name] = true
+	})
+
 	conf := Config{
 		CertKeyPairs:       certKeyPairs,
 		ClientCAs:          clientCAs,
@@ -181,13 +451,26 @@ It is a plain text value by default but you can provide a SHA256 hash by adding
 		Port:               uint16(*port),
 		ServerID:           *serverId,
 		JoinAddr:           *joinAddr,
+		PeerDiscovery:      peerDiscovery,
+		DiscoveryDNS:       *discoveryDNS,
+		DiscoveryNamespace: *discoveryNamespace,
+		DiscoverySelector:  *discoverySelector,
+		DiscoveryInterval:  *discoveryInterval,
 		BindAddr:           *bindAddr,
 		RaftBindPort:       uint16(*raftBindPort),
 		MemberListBindPort: uint16(*mlBindPort),
+		ClusterBindAddr:    *clusterBindAddr,
+		ClusterTLS:         *clusterTLS,
+		ClusterCertKeyPair: clusterCertKeyPair,
+		ClusterCA:          *clusterCA,
+		ClusterGossipKey:   *clusterGossipKey,
+		HealthPort:         uint16(*healthPort),
 		InMemory:           *inMemory,
 		DataDir:            *dataDir,
 		BootstrapCluster:   *bootstrapCluster,
 		AclConfig:          *aclConfig,
+		AclAutoSave:        *aclAutoSave,
+		AuthRateLimit:      *authRateLimit,
 		ForwardCommand:     *forwardCommand,
 		RequirePass:        *requirePass,
 		Password:           *password,
@@ -196,16 +479,49 @@ It is a plain text value by default but you can provide a SHA256 hash by adding
 		RestoreSnapshot:    *restoreSnapshot,
 		RestoreAOF:         *restoreAOF,
 		AOFSyncStrategy:    aofSyncStrategy,
+		AOFSegmentSize:     aofSegmentSize,
+		AOFQueueLimit:      *aofQueueLimit,
+		AOFQueuePolicy:     aofQueuePolicy,
+		StallThreshold:     *stallThreshold,
+		SlowlogThreshold:   *slowlogThreshold,
+		SlowlogMaxLen:      *slowlogMaxLen,
+		SelfTestOnStartup:  *selfTestOnStartup,
+		RaftBatchSize:      *raftBatchSize,
+		ReadConsistency:    readConsistency,
+		LeaseClockSkew:     *leaseClockSkew,
 		MaxMemory:          maxMemory,
+		MaxKeyLength:       *maxKeyLength,
+		SetListpackSize:    *setListpackEntries,
+		SetListpackValue:   *setListpackValue,
+		ZSetListpackSize:   *zsetListpackEntries,
+		ZSetListpackValue:  *zsetListpackValue,
 		EvictionPolicy:     evictionPolicy,
 		EvictionSample:     *evictionSample,
 		EvictionInterval:   *evictionInterval,
+		LFULogFactor:       *lfuLogFactor,
+		LFUDecayTime:       *lfuDecayTime,
+		IdleThreshold:      *idleThreshold,
+		ReplyCacheSize:     *replyCacheSize,
+		DefragInterval:     *defragInterval,
+		DefragThreshold:    *defragThreshold,
+		ProtocolCompat:     protocolCompat,
+		UnixSocket:         *unixSocket,
+		UnixSocketPerm:     *unixSocketPerm,
+		TCPAllowCommands:   tcpAllowCommands,
+		TCPDenyCommands:    tcpDenyCommands,
+		UnixAllowCommands:  unixAllowCommands,
+		UnixDenyCommands:   unixDenyCommands,
+		TraceFile:          *traceFile,
+		InitFile:           *initFile,
+		LuaTimeLimit:       *luaTimeLimit,
 	}
 
 	if len(*config) > 0 {
-		// Override configurations from file
+		// Override the defaults with the config file, then override the config file with the flags that were set.
+		flagConf := conf
+
 		if f, err := os.Open(*config); err != nil {
-			panic(err)
+			return Config{}, fmt.Errorf("could not open config file: %w", err)
 		} else {
 			defer func() {
 				if err = f.Close(); err != nil {
@@ -217,24 +533,69 @@ It is a plain text value by default but you can provide a SHA256 hash by adding
 
 			if ext == ".json" {
 				if err = json.NewDecoder(f).Decode(&conf); err != nil {
-					return Config{}, nil
+					return Config{}, fmt.Errorf("could not parse config file %s: %w", f.Name(), err)
 				}
 			}
 
 			if ext == ".yaml" || ext == ".yml" {
 				if err = yaml.NewDecoder(f).Decode(&conf); err != nil {
-					return Config{}, err
+					return Config{}, fmt.Errorf("could not parse config file %s: %w", f.Name(), err)
 				}
 			}
 		}
-	}
 
-	// If requirePass is set to true, then password must be provided as well
-	var err error = nil
+		applyOverrides(&conf, flagConf, overrides)
+	}
 
-	if conf.RequirePass && conf.Password == "" {
-		err = errors.New("password cannot be empty if requirePass is generic to true")
+	// Values from the config file are not checked by the flags, so validate the final config before starting.
+	if err := conf.Validate(); err != nil {
+		return Config{}, err
 	}
 
-	return conf, err
+	return conf, nil
+}
+
+// EnvPrefix is the prefix of the environment variables that set the flags.
+const EnvPrefix = "ECHOVAULT_"
+
+// EnvName returns the name of the environment variable that sets the flag,
+// e.g. ECHOVAULT_EVICTION_POLICY for eviction-policy.
+func EnvName(flagName string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// setFlagsFromEnv sets each flag that wasn't passed on the command line from its environment variable, if it's set.
+// The value is parsed by the flag, so an invalid value is reported in the same way as an invalid flag.
+func setFlagsFromEnv(flags *flag.FlagSet) error {
+	passed := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) {
+		passed[f.Name] = true
+	})
+
+	var errs []error
+	flags.VisitAll(func(f *flag.Flag) {
+		if passed[f.Name] {
+			return
+		}
+		value, ok := os.LookupEnv(EnvName(f.Name))
+		if !ok {
+			return
+		}
+		if err := flags.Set(f.Name, value); err != nil {
+			errs = append(errs, fmt.Errorf("invalid value %q for environment variable %s: %w", value, EnvName(f.Name), err))
+		}
+	})
+	return errors.Join(errs...)
+}
+
+// applyOverrides copies the fields of the overridden flags from flagConf to conf.
+// The fields are matched to the flags with the flag struct tag.
+func applyOverrides(conf *Config, flagConf Config, overrides map[string]bool) {
+	dst := reflect.ValueOf(conf).Elem()
+	src := reflect.ValueOf(flagConf)
+	for i := 0; i < dst.NumField(); i++ {
+		if overrides[dst.Type().Field(i).Tag.Get("flag")] {
+			dst.Field(i).Set(src.Field(i))
+		}
+	}
 }
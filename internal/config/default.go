@@ -14,13 +14,26 @@ func DefaultConfig() Config {
 		Port:               7480,
 		ServerID:           "",
 		JoinAddr:           "",
+		PeerDiscovery:      "static",
+		DiscoveryDNS:       "",
+		DiscoveryNamespace: "",
+		DiscoverySelector:  "",
+		DiscoveryInterval:  30 * time.Second,
 		BindAddr:           "localhost",
 		RaftBindPort:       7481,
 		MemberListBindPort: 7946,
+		ClusterBindAddr:    "",
+		ClusterTLS:         false,
+		ClusterCertKeyPair: make([]string, 0),
+		ClusterCA:          "",
+		ClusterGossipKey:   "",
+		HealthPort:         0,
 		InMemory:           false,
 		DataDir:            ".",
 		BootstrapCluster:   false,
 		AclConfig:          "",
+		AclAutoSave:        false,
+		AuthRateLimit:      10,
 		ForwardCommand:     false,
 		RequirePass:        false,
 		Password:           "",
@@ -29,9 +42,40 @@ func DefaultConfig() Config {
 		RestoreAOF:         false,
 		RestoreSnapshot:    false,
 		AOFSyncStrategy:    "everysec",
+		AOFSegmentSize:     0,
+		AOFQueueLimit:      4096,
+		AOFQueuePolicy:     "block",
+		StallThreshold:     0,
+		SlowlogThreshold:   10 * time.Millisecond,
+		SlowlogMaxLen:      128,
+		SelfTestOnStartup:  false,
+		RaftBatchSize:      128,
+		ReadConsistency:    "local",
+		LeaseClockSkew:     100 * time.Millisecond,
 		MaxMemory:          0,
+		MaxKeyLength:       512 * 1024 * 1024,
+		SetListpackSize:    128,
+		SetListpackValue:   64,
+		ZSetListpackSize:   128,
+		ZSetListpackValue:  64,
 		EvictionPolicy:     constants.NoEviction,
 		EvictionSample:     20,
 		EvictionInterval:   100 * time.Millisecond,
+		LFULogFactor:       10,
+		LFUDecayTime:       1 * time.Minute,
+		IdleThreshold:      30 * time.Minute,
+		ReplyCacheSize:     0,
+		DefragInterval:     1 * time.Minute,
+		DefragThreshold:    0,
+		ProtocolCompat:     constants.ProtocolCompatEchoVault,
+		UnixSocket:         "",
+		UnixSocketPerm:     "",
+		TCPAllowCommands:   make([]string, 0),
+		TCPDenyCommands:    make([]string, 0),
+		UnixAllowCommands:  make([]string, 0),
+		UnixDenyCommands:   make([]string, 0),
+		TraceFile:          "",
+		InitFile:           "",
+		LuaTimeLimit:       5 * time.Second,
 	}
 }
@@ -0,0 +1,206 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/echovault/echovault/internal/constants"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Validate checks the configuration for values that the server cannot start with, such as TLS without
+// certificates, unknown eviction policies, zero intervals or a data directory that cannot be created.
+// All the problems found are returned together, each naming the flag/config field to fix.
+// Validate is called on startup after the flags and the config file are parsed.
+func (config Config) Validate() error {
+	var errs []error
+	invalid := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	oneOf := func(name string, value string, options ...string) {
+		if !slices.Contains(options, value) {
+			invalid("%s must be one of '%s', got '%s'", name, strings.Join(options, "', '"), value)
+		}
+	}
+
+	// TLS
+	if (config.TLS || config.MTLS) && len(config.CertKeyPairs) == 0 {
+		invalid("tls and mtls require at least one cert-key-pair")
+	}
+	for _, pair := range config.CertKeyPairs {
+		if len(pair) != 2 {
+			invalid("cert-key-pair must be a certificate path and a key path, got %v", pair)
+			continue
+		}
+		for _, file := range pair {
+			if _, err := os.Stat(file); err != nil {
+				invalid("cert-key-pair file %s cannot be read: %v", file, err)
+			}
+		}
+	}
+	if config.MTLS && len(config.ClientCAs) == 0 {
+		invalid("mtls requires at least one client-ca to verify the client certificates")
+	}
+	if !config.MTLS && len(config.ClientCAs) > 0 {
+		invalid("client-ca is only used in mtls mode, set mtls to true or remove client-ca")
+	}
+	for _, file := range config.ClientCAs {
+		if _, err := os.Stat(file); err != nil {
+			invalid("client-ca file %s cannot be read: %v", file, err)
+		}
+	}
+
+	// Cluster bus
+	if config.ClusterTLS && (len(config.ClusterCertKeyPair) == 0 || config.ClusterCA == "") {
+		invalid("cluster-tls requires a cluster-cert-key-pair and a cluster-ca")
+	}
+	if len(config.ClusterCertKeyPair) != 0 && len(config.ClusterCertKeyPair) != 2 {
+		invalid("cluster-cert-key-pair must be a certificate path and a key path, got %v", config.ClusterCertKeyPair)
+	}
+	for _, file := range append(config.ClusterCertKeyPair[:len(config.ClusterCertKeyPair):len(config.ClusterCertKeyPair)], config.ClusterCA) {
+		if file == "" {
+			continue
+		}
+		if _, err := os.Stat(file); err != nil {
+			invalid("cluster TLS file %s cannot be read: %v", file, err)
+		}
+	}
+	if config.ClusterGossipKey != "" {
+		if key, err := base64.StdEncoding.DecodeString(config.ClusterGossipKey); err != nil ||
+			!slices.Contains([]int{16, 24, 32}, len(key)) {
+			invalid("cluster-gossip-key must be a base64 encoded 16, 24 or 32 byte key")
+		}
+	}
+
+	// Authentication
+	if config.RequirePass && config.Password == "" {
+		invalid("password cannot be empty if require-pass is set to true")
+	}
+
+	// Options with a fixed set of values. These are checked by the flags, but not when read from the config file.
+	oneOf("eviction-policy", config.EvictionPolicy,
+		constants.NoEviction,
+		constants.AllKeysLFU, constants.AllKeysLRU, constants.AllKeysRandom,
+		constants.VolatileLFU, constants.VolatileLRU, constants.VolatileRandom,
+		constants.VolatileIdle)
+	oneOf("aof-sync-strategy", config.AOFSyncStrategy, "always", "everysec", "no")
+	oneOf("aof-queue-policy", config.AOFQueuePolicy, "block", "error")
+	oneOf("read-consistency", config.ReadConsistency, "local", "lease", "readindex")
+	oneOf("peer-discovery", config.PeerDiscovery, "static", "dns-srv", "kubernetes")
+	oneOf("protocol-compat", config.ProtocolCompat,
+		constants.ProtocolCompatEchoVault, constants.ProtocolCompatRedis6, constants.ProtocolCompatRedis7)
+
+	// Intervals and sizes
+	if config.SnapshotInterval <= 0 {
+		invalid("snapshot-interval must be greater than 0, got %s", config.SnapshotInterval)
+	}
+	if config.EvictionInterval <= 0 {
+		invalid("eviction-interval must be greater than 0, got %s", config.EvictionInterval)
+	}
+	if config.EvictionSample == 0 {
+		invalid("eviction-sample must be greater than 0")
+	}
+	if config.DefragInterval < 0 {
+		invalid("defrag-interval must not be negative, pass 0 to disable keyspace maintenance")
+	}
+	if config.DefragThreshold < 0 || config.DefragThreshold > 1 {
+		invalid("defrag-threshold must be between 0 and 1, got %v", config.DefragThreshold)
+	}
+	if config.LeaseClockSkew < 0 {
+		invalid("lease-clock-skew must not be negative, got %s", config.LeaseClockSkew)
+	}
+	if config.LFUDecayTime < 0 {
+		invalid("lfu-decay-time must not be negative, pass 0 to disable counter decay")
+	}
+	if config.IdleThreshold < 0 {
+		invalid("idle-threshold must not be negative, pass 0 to only evict idle keys when max-memory is reached")
+	}
+	if config.StallThreshold < 0 {
+		invalid("stall-threshold must not be negative, pass 0 to disable stall detection")
+	}
+	if config.AOFQueueLimit == 0 {
+		invalid("aof-queue-limit must be at least 1")
+	}
+	if config.RaftBatchSize == 0 {
+		invalid("raft-batch-size must be at least 1")
+	}
+
+	// Peer discovery
+	switch strings.ToLower(config.PeerDiscovery) {
+	case "dns-srv":
+		if config.DiscoveryDNS == "" {
+			invalid("discovery-dns is required when peer-discovery is dns-srv")
+		}
+		if config.DiscoveryInterval <= 0 {
+			invalid("discovery-interval must be greater than 0, got %s", config.DiscoveryInterval)
+		}
+	case "kubernetes":
+		if config.DiscoverySelector == "" {
+			invalid("discovery-selector is required when peer-discovery is kubernetes")
+		}
+		if config.DiscoveryInterval <= 0 {
+			invalid("discovery-interval must be greater than 0, got %s", config.DiscoveryInterval)
+		}
+	}
+
+	if config.InitFile != "" {
+		if info, err := os.Stat(config.InitFile); err != nil {
+			invalid("init-file %s cannot be read: %v", config.InitFile, err)
+		} else if info.IsDir() {
+			invalid("init-file %s is a directory", config.InitFile)
+		}
+	}
+
+	if config.UnixSocketPerm != "" {
+		if perm, err := strconv.ParseUint(config.UnixSocketPerm, 8, 32); err != nil || perm > 0777 {
+			invalid("unix-socket-perm must be octal permissions between 0 and 777, got '%s'", config.UnixSocketPerm)
+		}
+	}
+
+	// Ports
+	if config.Port == 0 {
+		invalid("port must be greater than 0")
+	}
+	if config.HealthPort != 0 && config.HealthPort == config.Port {
+		invalid("health-port %d is already used by port", config.HealthPort)
+	}
+	if (config.RaftBindPort != 0 && config.RaftBindPort == config.Port) ||
+		(config.MemberListBindPort != 0 && config.MemberListBindPort == config.Port) {
+		invalid("raft-port and memberlist-port must be different from port, so that cluster and client traffic are separated")
+	}
+
+	// Data directory. Snapshots and the AOF are written here, as well as the raft logs unless in-memory is set.
+	// The engines create the directory if it's missing, so make sure that they'll be able to.
+	if config.DataDir == "" {
+		invalid("data-dir is required")
+	} else if info, err := os.Stat(config.DataDir); err == nil && !info.IsDir() {
+		invalid("data-dir %s is not a directory", config.DataDir)
+	} else if err != nil {
+		if err = os.MkdirAll(config.DataDir, os.ModePerm); err != nil {
+			invalid("data-dir %s cannot be created: %v", config.DataDir, err)
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("invalid config:\n%w", errors.Join(errs...))
+}
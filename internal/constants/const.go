@@ -14,17 +14,35 @@
 
 package constants
 
+// ServerName is the name of the server reported to clients.
+const ServerName = "echovault"
+
+// Version is the version of the server reported to clients.
+// Release builds set it with -ldflags "-X github.com/echovault/echovault/internal/constants.Version=<version>".
+var Version = "0.0.0"
+
+// Protocol compatibility levels. The EchoVault level keeps EchoVault's own semantics,
+// while the Redis levels follow the semantics of the corresponding Redis version.
+const (
+	ProtocolCompatEchoVault = "echovault"
+	ProtocolCompatRedis6    = "redis-6"
+	ProtocolCompatRedis7    = "redis-7"
+)
+
 const (
-	ACLModule        = "acl"
-	AdminModule      = "admin"
-	ConnectionModule = "connection"
-	GenericModule    = "generic"
-	HashModule       = "hash"
-	ListModule       = "list"
-	PubSubModule     = "pubsub"
-	SetModule        = "set"
-	SortedSetModule  = "sortedset"
-	StringModule     = "string"
+	ACLModule         = "acl"
+	AdminModule       = "admin"
+	ConnectionModule  = "connection"
+	GenericModule     = "generic"
+	HashModule        = "hash"
+	ListModule        = "list"
+	PubSubModule      = "pubsub"
+	ScriptingModule   = "scripting"
+	SetModule         = "set"
+	SortedSetModule   = "sortedset"
+	StreamModule      = "stream"
+	StringModule      = "string"
+	TransactionModule = "transaction"
 )
 
 const (
@@ -64,4 +82,5 @@ const (
 	VolatileLFU    = "volatile-lfu"
 	AllKeysRandom  = "allkeys-random"
 	VolatileRandom = "volatile-random"
+	VolatileIdle   = "volatile-idle"
 )
@@ -16,26 +16,60 @@ package eviction
 
 import (
 	"container/heap"
+	"github.com/echovault/echovault/internal/clock"
+	"math/rand"
 	"slices"
 	"time"
 )
 
+const (
+	lfuInitCount = 5   // The count of a new entry, so that new keys are not evicted before they have a chance to be accessed.
+	lfuMaxCount  = 255 // The maximum count of an entry.
+)
+
 type EntryLFU struct {
 	key       string // The key, matching the key in the store
-	count     int    // The number of times this key has been accessed
+	count     int    // The logarithmic access counter of the key
 	addedTime int64  // The time this entry was added to the cache in unix milliseconds
+	decayTime int64  // The time the count was last decayed in unix milliseconds
 	index     int    // The index of the entry in the heap
 }
 
 type CacheLFU struct {
-	keys    map[string]bool
-	entries []*EntryLFU
+	clock            clock.Clock
+	getLogFactorFunc func() uint          // Returns the factor that slows down the growth of the access counter.
+	getDecayTimeFunc func() time.Duration // Returns the idle time after which the access counter is decremented.
+	keys             map[string]bool
+	entries          []*EntryLFU
+}
+
+// WithLogFactorFunc sets the function used to retrieve the log factor. The higher the log factor, the more
+// accesses are needed to increment the counter of a key. With a log factor of 0, every access increments the counter.
+func WithLogFactorFunc(f func() uint) func(cache *CacheLFU) {
+	return func(cache *CacheLFU) {
+		cache.getLogFactorFunc = f
+	}
+}
+
+// WithDecayTimeFunc sets the function used to retrieve the decay time. The counter of a key is decremented once
+// for every decay time that it's not accessed, so that keys that were hot in the past but are now idle can be evicted.
+// With a decay time of 0, the counters never decay.
+func WithDecayTimeFunc(f func() time.Duration) func(cache *CacheLFU) {
+	return func(cache *CacheLFU) {
+		cache.getDecayTimeFunc = f
+	}
 }
 
-func NewCacheLFU() CacheLFU {
+func NewCacheLFU(clock clock.Clock, options ...func(cache *CacheLFU)) CacheLFU {
 	cache := CacheLFU{
-		keys:    make(map[string]bool),
-		entries: make([]*EntryLFU, 0),
+		clock:            clock,
+		getLogFactorFunc: func() uint { return 0 },
+		getDecayTimeFunc: func() time.Duration { return 0 },
+		keys:             make(map[string]bool),
+		entries:          make([]*EntryLFU, 0),
+	}
+	for _, option := range options {
+		option(&cache)
 	}
 	heap.Init(&cache)
 	return cache
@@ -62,10 +96,12 @@ func (cache *CacheLFU) Swap(i, j int) {
 
 func (cache *CacheLFU) Push(key any) {
 	n := len(cache.entries)
+	now := cache.clock.Now().UnixMilli()
 	cache.entries = append(cache.entries, &EntryLFU{
 		key:       key.(string),
-		count:     1,
-		addedTime: time.Now().UnixMilli(),
+		count:     lfuInitCount,
+		addedTime: now,
+		decayTime: now,
 		index:     n,
 	})
 	cache.keys[key.(string)] = true
@@ -93,10 +129,53 @@ func (cache *CacheLFU) Update(key string) {
 		return e.key == key
 	})
 	entry := cache.entries[entryIdx]
-	entry.count += 1
+	cache.decay(entry)
+	cache.increment(entry)
+	// The counter decays when the key is idle, so restart the decay period on every access.
+	entry.decayTime = cache.clock.Now().UnixMilli()
 	heap.Fix(cache, entryIdx)
 }
 
+// PopLeastFrequent decays the counters of all the entries and removes the entry with the lowest counter
+// from the cache. Returns the key of the entry. The cache must not be empty.
+func (cache *CacheLFU) PopLeastFrequent() string {
+	// Counters only decay when they're read, so decay all the counters before picking the entry to remove.
+	if cache.getDecayTimeFunc() > 0 {
+		for _, entry := range cache.entries {
+			cache.decay(entry)
+		}
+		heap.Init(cache)
+	}
+	return heap.Pop(cache).(string)
+}
+
+// decay decrements the counter of the entry once for every decay time elapsed since the counter was last decayed.
+func (cache *CacheLFU) decay(entry *EntryLFU) {
+	decayTime := cache.getDecayTimeFunc().Milliseconds()
+	if decayTime <= 0 {
+		return
+	}
+	now := cache.clock.Now().UnixMilli()
+	periods := (now - entry.decayTime) / decayTime
+	if periods <= 0 {
+		return
+	}
+	entry.count = max(entry.count-int(periods), 0)
+	entry.decayTime += periods * decayTime
+}
+
+// increment increments the counter of the entry with a probability that falls as the counter grows,
+// so that the counter grows logarithmically with the number of accesses.
+func (cache *CacheLFU) increment(entry *EntryLFU) {
+	if entry.count >= lfuMaxCount {
+		return
+	}
+	base := float64(max(entry.count-lfuInitCount, 0))
+	if rand.Float64() < 1/(base*float64(cache.getLogFactorFunc())+1) {
+		entry.count += 1
+	}
+}
+
 func (cache *CacheLFU) Delete(key string) {
 	entryIdx := slices.IndexFunc(cache.entries, func(entry *EntryLFU) bool {
 		return entry.key == key
@@ -16,23 +16,26 @@ package eviction
 
 import (
 	"container/heap"
+	"github.com/echovault/echovault/internal/clock"
 	"slices"
 	"time"
 )
 
 type EntryLRU struct {
 	key      string // The key, matching the key in the store
-	unixTime int64  // Unix time in milliseconds when this key was accessed
+	unixTime int64  // Unix time in milliseconds when this key was last accessed
 	index    int    // The index of the entry in the heap
 }
 
 type CacheLRU struct {
+	clock   clock.Clock
 	keys    map[string]bool
 	entries []*EntryLRU
 }
 
-func NewCacheLRU() CacheLRU {
+func NewCacheLRU(clock clock.Clock) CacheLRU {
 	cache := CacheLRU{
+		clock:   clock,
 		keys:    make(map[string]bool),
 		entries: make([]*EntryLRU, 0),
 	}
@@ -45,7 +48,8 @@ func (cache *CacheLRU) Len() int {
 }
 
 func (cache *CacheLRU) Less(i, j int) bool {
-	return cache.entries[i].unixTime > cache.entries[j].unixTime
+	// The least recently used entry is at the root of the heap.
+	return cache.entries[i].unixTime < cache.entries[j].unixTime
 }
 
 func (cache *CacheLRU) Swap(i, j int) {
@@ -58,9 +62,10 @@ func (cache *CacheLRU) Push(key any) {
 	n := len(cache.entries)
 	cache.entries = append(cache.entries, &EntryLRU{
 		key:      key.(string),
-		unixTime: time.Now().Unix(),
+		unixTime: cache.clock.Now().UnixMilli(),
 		index:    n,
 	})
+	cache.keys[key.(string)] = true
 }
 
 func (cache *CacheLRU) Pop() any {
@@ -84,10 +89,24 @@ func (cache *CacheLRU) Update(key string) {
 		return e.key == key
 	})
 	entry := cache.entries[entryIdx]
-	entry.unixTime = time.Now().Unix()
+	entry.unixTime = cache.clock.Now().UnixMilli()
 	heap.Fix(cache, entryIdx)
 }
 
+// PopLeastRecent removes the least recently used entry from the cache and returns its key. The cache must not be empty.
+func (cache *CacheLRU) PopLeastRecent() string {
+	return heap.Pop(cache).(string)
+}
+
+// PopIdle removes the least recently used entry from the cache and returns its key if the entry has not been
+// accessed for at least the idle time. Returns false if the cache is empty or no entry has been idle for that long.
+func (cache *CacheLRU) PopIdle(idle time.Duration) (string, bool) {
+	if len(cache.entries) == 0 || cache.clock.Now().UnixMilli()-cache.entries[0].unixTime < idle.Milliseconds() {
+		return "", false
+	}
+	return cache.PopLeastRecent(), true
+}
+
 func (cache *CacheLRU) Delete(key string) {
 	entryIdx := slices.IndexFunc(cache.entries, func(entry *EntryLRU) bool {
 		return entry.key == key
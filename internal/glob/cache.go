@@ -0,0 +1,81 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package glob
+
+import (
+	"container/list"
+	"sync"
+)
+
+// DefaultCacheSize is the number of compiled patterns held by the shared cache used by Cached and Match.
+const DefaultCacheSize = 1024
+
+var defaultCache = NewCache(DefaultCacheSize)
+
+// Cache holds recently compiled patterns so that patterns that are matched repeatedly are only compiled once.
+// The cache is bounded, when it's full the least recently used pattern is dropped.
+type Cache struct {
+	mutex   sync.Mutex
+	size    int                      // Maximum number of patterns held in the cache.
+	entries map[string]*list.Element // Map of pattern to its element in the recency list.
+	recency *list.List               // Front of the list is the most recently used pattern.
+}
+
+func NewCache(size int) *Cache {
+	return &Cache{
+		mutex:   sync.Mutex{},
+		size:    size,
+		entries: make(map[string]*list.Element),
+		recency: list.New(),
+	}
+}
+
+// Compile returns the compiled pattern from the cache, compiling and caching it if it's not cached yet.
+// The returned Glob is shared between callers and must not be modified.
+func (cache *Cache) Compile(pattern string) *Glob {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	if element, ok := cache.entries[pattern]; ok {
+		cache.recency.MoveToFront(element)
+		return element.Value.(*Glob)
+	}
+
+	g := Compile(pattern)
+	if cache.size <= 0 {
+		return g
+	}
+
+	for cache.recency.Len() >= cache.size {
+		oldest := cache.recency.Back()
+		cache.recency.Remove(oldest)
+		delete(cache.entries, oldest.Value.(*Glob).source)
+	}
+
+	cache.entries[pattern] = cache.recency.PushFront(g)
+	return g
+}
+
+// Len returns the number of patterns currently held in the cache.
+func (cache *Cache) Len() int {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	return cache.recency.Len()
+}
+
+// Cached returns the compiled pattern from the shared cache.
+func Cached(pattern string) *Glob {
+	return defaultCache.Compile(pattern)
+}
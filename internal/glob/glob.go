@@ -0,0 +1,152 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package glob
+
+import "unicode/utf8"
+
+// Glob is a compiled glob pattern. It supports the same syntax as Redis:
+//
+// `?` matches any single character.
+//
+// `*` matches any sequence of characters, including the empty sequence.
+//
+// `[abc]` matches one of the characters in the brackets. `[^abc]` matches any character that is not in the brackets.
+// `[a-z]` matches any character in the range.
+//
+// `\` escapes the following character so that it is matched literally, both inside and outside brackets.
+//
+// Patterns are never invalid: an unterminated bracket matches up to the end of the pattern,
+// and a trailing `\` matches a literal backslash.
+//
+// Patterns and strings are matched byte for byte where they are not valid UTF-8,
+// so binary keys only match patterns that contain the same bytes.
+type Glob struct {
+	source  string
+	pattern []rune
+}
+
+// Compile compiles the pattern so that it can be matched against many strings.
+func Compile(pattern string) *Glob {
+	return &Glob{source: pattern, pattern: decodeRunes(pattern)}
+}
+
+// invalidByte is added to each byte that is not part of a valid UTF-8 sequence.
+// The result is above the largest Unicode code point, so invalid bytes never match a valid character,
+// and each invalid byte remains distinct instead of collapsing into utf8.RuneError.
+const invalidByte = utf8.MaxRune + 1
+
+// decodeRunes decodes the string into runes, preserving the bytes that are not valid UTF-8.
+func decodeRunes(s string) []rune {
+	runes := make([]rune, 0, len(s))
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			r = invalidByte + rune(s[i])
+		}
+		runes = append(runes, r)
+		i += size
+	}
+	return runes
+}
+
+// Match reports whether the string matches the pattern. The pattern is compiled through the shared cache.
+func Match(pattern string, s string) bool {
+	return Cached(pattern).Match(s)
+}
+
+// String returns the source pattern.
+func (g *Glob) String() string {
+	return g.source
+}
+
+// Match reports whether the whole string matches the pattern.
+func (g *Glob) Match(s string) bool {
+	p := g.pattern
+	str := decodeRunes(s)
+
+	pi, si := 0, 0
+	// Position of the last star in the pattern and the position in the string it's currently matched up to.
+	// When a match fails, the star absorbs one more character and matching resumes after it.
+	starP, starS := -1, 0
+
+	for si < len(str) {
+		if pi < len(p) {
+			if p[pi] == '*' {
+				starP, starS = pi, si
+				pi++
+				continue
+			}
+			if next, ok := matchOne(p, pi, str[si]); ok {
+				pi, si = next, si+1
+				continue
+			}
+		}
+		if starP < 0 {
+			return false
+		}
+		starS++
+		pi, si = starP+1, starS
+	}
+
+	for pi < len(p) && p[pi] == '*' {
+		pi++
+	}
+	return pi == len(p)
+}
+
+// matchOne matches the single character r against the pattern element at position i.
+// It returns the position of the next pattern element and whether the character matched.
+func matchOne(p []rune, i int, r rune) (int, bool) {
+	switch p[i] {
+	case '?':
+		return i + 1, true
+	case '\\':
+		if i+1 < len(p) {
+			i++
+		}
+		return i + 1, p[i] == r
+	case '[':
+		i++
+		negate := i < len(p) && p[i] == '^'
+		if negate {
+			i++
+		}
+		matched := false
+		for i < len(p) && p[i] != ']' {
+			switch {
+			case p[i] == '\\' && i+1 < len(p):
+				i++
+				matched = matched || p[i] == r
+			case i+2 < len(p) && p[i+1] == '-' && p[i+2] != ']':
+				lo, hi := p[i], p[i+2]
+				if lo > hi {
+					lo, hi = hi, lo
+				}
+				matched = matched || (r >= lo && r <= hi)
+				i += 2
+			default:
+				matched = matched || p[i] == r
+			}
+			i++
+		}
+		if negate {
+			matched = !matched
+		}
+		// Skip the closing bracket if there is one.
+		return min(i+1, len(p)), matched
+	default:
+		return i + 1, p[i] == r
+	}
+}
@@ -0,0 +1,239 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keyindex
+
+import (
+	"cmp"
+	"container/heap"
+	"hash/fnv"
+	"math/bits"
+	"slices"
+	"sync"
+)
+
+// minBuckets is the smallest number of buckets the index will shrink to.
+const minBuckets = 16
+
+// Index holds all the keys in the store in hash buckets so that they can be iterated with a stateless cursor.
+//
+// The cursor is the bit-reversed hash of the next key to return. Bucket i of a table with 2^n buckets holds
+// the keys whose hash ends with the n bits of i, so in bit-reversed order, each bucket covers one contiguous range
+// of cursors regardless of the table size. Iterating the buckets in order of their bit-reversed index therefore
+// visits every cursor range exactly once, even if the table grows or shrinks between calls to Scan.
+// Keys that exist for the entire iteration are returned exactly once.
+type Index struct {
+	mutex   sync.RWMutex
+	buckets [][]string // The number of buckets is always a power of 2.
+	count   int        // The number of keys in the index.
+}
+
+func NewIndex() *Index {
+	return &Index{
+		mutex:   sync.RWMutex{},
+		buckets: make([][]string, minBuckets),
+		count:   0,
+	}
+}
+
+// hash returns the hash of the key.
+func hash(key string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	return h.Sum64()
+}
+
+// position returns the cursor position of the key, which is its bit-reversed hash.
+func position(key string) uint64 {
+	return bits.Reverse64(hash(key))
+}
+
+// Add adds the key to the index. Adding a key that's already in the index is a no-op.
+func (index *Index) Add(key string) {
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+
+	b := hash(key) & uint64(len(index.buckets)-1)
+	for _, k := range index.buckets[b] {
+		if k == key {
+			return
+		}
+	}
+	index.buckets[b] = append(index.buckets[b], key)
+	index.count += 1
+
+	// Grow the table when there's more than 1 key per bucket on average.
+	if index.count > len(index.buckets) {
+		index.resize(len(index.buckets) * 2)
+	}
+}
+
+// Remove removes the key from the index. Removing a key that's not in the index is a no-op.
+func (index *Index) Remove(key string) {
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+
+	b := hash(key) & uint64(len(index.buckets)-1)
+	for i, k := range index.buckets[b] {
+		if k == key {
+			index.buckets[b] = append(index.buckets[b][:i], index.buckets[b][i+1:]...)
+			index.count -= 1
+			break
+		}
+	}
+
+	// Shrink the table when it's less than 1/8 full.
+	if len(index.buckets) > minBuckets && index.count < len(index.buckets)/8 {
+		index.resize(len(index.buckets) / 2)
+	}
+}
+
+// resize rehashes all the keys into a table with the provided number of buckets.
+// The index must be locked before calling this function.
+func (index *Index) resize(size int) {
+	buckets := make([][]string, size)
+	for _, bucket := range index.buckets {
+		for _, key := range bucket {
+			b := hash(key) & uint64(size-1)
+			buckets[b] = append(buckets[b], key)
+		}
+	}
+	index.buckets = buckets
+}
+
+// Len returns the number of keys in the index.
+func (index *Index) Len() int {
+	index.mutex.RLock()
+	defer index.mutex.RUnlock()
+	return index.count
+}
+
+// Scan returns the keys from the provided cursor onwards, one bucket at a time, until at least count keys
+// have been visited or the iteration is complete. Only the keys for which match returns true are returned.
+// If match is nil, all the keys are returned.
+//
+// Returns the keys and the cursor to pass to the next call to Scan. A cursor of 0 starts a new iteration,
+// and a returned cursor of 0 means the iteration is complete.
+func (index *Index) Scan(cursor uint64, count int, match func(key string) bool) ([]string, uint64) {
+	index.mutex.RLock()
+	defer index.mutex.RUnlock()
+
+	if count <= 0 {
+		count = 10
+	}
+
+	n := bits.TrailingZeros64(uint64(len(index.buckets)))
+	var keys []string
+	visited := 0
+
+	for {
+		// The bucket that holds the cursor position, and the first cursor position of the following bucket.
+		b := bits.Reverse64(cursor) & uint64(len(index.buckets)-1)
+		next := (cursor>>(64-n) + 1) << (64 - n)
+
+		for _, key := range index.buckets[b] {
+			// If the table has shrunk since the cursor was returned, the bucket might contain keys
+			// that were already returned from before the cursor position.
+			if position(key) < cursor {
+				continue
+			}
+			visited += 1
+			if match == nil || match(key) {
+				keys = append(keys, key)
+			}
+		}
+
+		cursor = next
+		// The cursor wraps around to 0 after the last bucket.
+		if cursor == 0 || visited >= count {
+			return keys, cursor
+		}
+	}
+}
+
+// positions is a max-heap of cursor positions.
+type positions []uint64
+
+func (p *positions) Len() int           { return len(*p) }
+func (p *positions) Less(i, j int) bool { return (*p)[i] > (*p)[j] }
+func (p *positions) Swap(i, j int)      { (*p)[i], (*p)[j] = (*p)[j], (*p)[i] }
+func (p *positions) Push(x any)         { *p = append(*p, x.(uint64)) }
+func (p *positions) Pop() any {
+	old := *p
+	x := old[len(old)-1]
+	*p = old[:len(old)-1]
+	return x
+}
+
+// ScanMembers iterates the members of a collection, such as a hash or a set, with the same cursor as Scan.
+// The members are returned in the order of their cursor position, so members that exist for the entire
+// iteration are returned exactly once, even though the collection itself has no stable order.
+//
+// Returns up to count members from the cursor onwards and the cursor to pass to the next call.
+// Members that share a cursor position are always returned together, so more than count members
+// can be returned. A returned cursor of 0 means the iteration is complete.
+func ScanMembers(members []string, cursor uint64, count int) ([]string, uint64) {
+	if count <= 0 {
+		count = 10
+	}
+
+	type member struct {
+		value    string
+		position uint64
+	}
+
+	// Keep the count smallest positions from the cursor onwards in a max-heap, so that only the
+	// members of the page have to be sorted rather than the whole collection.
+	remaining := make([]member, 0, len(members))
+	smallest := make(positions, 0, count)
+	for _, m := range members {
+		p := position(m)
+		if p < cursor {
+			continue
+		}
+		remaining = append(remaining, member{value: m, position: p})
+		if len(smallest) < count {
+			heap.Push(&smallest, p)
+		} else if p < smallest[0] {
+			smallest[0] = p
+			heap.Fix(&smallest, 0)
+		}
+	}
+	if len(smallest) == 0 {
+		return nil, 0
+	}
+
+	// The page holds every member up to the largest of the smallest positions. The next cursor
+	// is the smallest position after it, or 0 if there's none.
+	limit := smallest[0]
+	var page []member
+	next := uint64(0)
+	for _, m := range remaining {
+		switch {
+		case m.position <= limit:
+			page = append(page, m)
+		case next == 0 || m.position < next:
+			next = m.position
+		}
+	}
+	slices.SortFunc(page, func(a, b member) int {
+		return cmp.Compare(a.position, b.position)
+	})
+
+	res := make([]string, len(page))
+	for i, m := range page {
+		res[i] = m.value
+	}
+	return res, next
+}
@@ -0,0 +1,86 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keywait
+
+import (
+	"sync"
+)
+
+// Registry allows blocking command handlers to wait until one of a set of keys is modified.
+type Registry struct {
+	mutex    sync.Mutex
+	watchers map[string]map[*Watcher]struct{} // Map of key to the watchers waiting on the key.
+}
+
+// Watcher receives a signal on C every time one of its keys is modified.
+// Signals are coalesced, so a single receive on C may correspond to several modifications.
+type Watcher struct {
+	C        <-chan struct{}
+	c        chan struct{}
+	keys     []string
+	registry *Registry
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		mutex:    sync.Mutex{},
+		watchers: make(map[string]map[*Watcher]struct{}),
+	}
+}
+
+// Watch returns a watcher that is signalled when any of the keys is modified.
+// The watcher must be registered before checking the keys, so that a modification between the check
+// and the wait is not missed. Close must be called once the watcher is no longer needed.
+func (registry *Registry) Watch(keys ...string) *Watcher {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	c := make(chan struct{}, 1)
+	watcher := &Watcher{C: c, c: c, keys: keys, registry: registry}
+	for _, key := range keys {
+		if _, ok := registry.watchers[key]; !ok {
+			registry.watchers[key] = make(map[*Watcher]struct{})
+		}
+		registry.watchers[key][watcher] = struct{}{}
+	}
+	return watcher
+}
+
+// Notify signals all the watchers of the key.
+func (registry *Registry) Notify(key string) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	for watcher := range registry.watchers[key] {
+		select {
+		case watcher.c <- struct{}{}:
+		default:
+			// The watcher already has a pending signal.
+		}
+	}
+}
+
+// Close removes the watcher from the registry.
+func (watcher *Watcher) Close() {
+	watcher.registry.mutex.Lock()
+	defer watcher.registry.mutex.Unlock()
+
+	for _, key := range watcher.keys {
+		delete(watcher.registry.watchers[key], watcher)
+		if len(watcher.registry.watchers[key]) == 0 {
+			delete(watcher.registry.watchers, key)
+		}
+	}
+}
@@ -0,0 +1,241 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package latency
+
+import (
+	"fmt"
+	"io"
+	"math/bits"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// bucketCount is the number of buckets in a histogram.
+// Bucket i counts latencies of up to 2^i microseconds, so the last bucket covers just over 6 days.
+const bucketCount = 40
+
+// quantiles are the quantiles reported in the Prometheus summaries.
+var quantiles = []float64{0.5, 0.9, 0.99, 0.999}
+
+// Histogram records a latency distribution in power-of-two microsecond buckets,
+// along with the number of calls that returned an error.
+type Histogram struct {
+	mutex   sync.Mutex
+	calls   uint64
+	failed  uint64
+	sum     time.Duration
+	buckets [bucketCount]uint64
+}
+
+// HistogramSnapshot is a point-in-time copy of a Histogram.
+//
+// Calls is the total number of recorded latencies.
+//
+// Failed is the number of recorded calls that returned an error.
+//
+// Sum is the sum of all the recorded latencies.
+//
+// Buckets maps the upper bound of each non-empty bucket in microseconds to the number of latencies that fall in
+// that bucket.
+type HistogramSnapshot struct {
+	Calls   uint64
+	Failed  uint64
+	Sum     time.Duration
+	Buckets map[uint64]uint64
+}
+
+func (histogram *Histogram) Record(d time.Duration, failed bool) {
+	usec := uint64(d.Microseconds())
+	idx := 0
+	if usec > 1 {
+		// The index of the smallest power of 2 that is >= usec.
+		idx = bits.Len64(usec - 1)
+	}
+	if idx >= bucketCount {
+		idx = bucketCount - 1
+	}
+
+	histogram.mutex.Lock()
+	defer histogram.mutex.Unlock()
+
+	histogram.calls += 1
+	if failed {
+		histogram.failed += 1
+	}
+	histogram.sum += d
+	histogram.buckets[idx] += 1
+}
+
+func (histogram *Histogram) Snapshot() HistogramSnapshot {
+	histogram.mutex.Lock()
+	defer histogram.mutex.Unlock()
+
+	snapshot := HistogramSnapshot{
+		Calls:   histogram.calls,
+		Failed:  histogram.failed,
+		Sum:     histogram.sum,
+		Buckets: make(map[uint64]uint64),
+	}
+	for i, count := range histogram.buckets {
+		if count > 0 {
+			snapshot.Buckets[1<<i] = count
+		}
+	}
+	return snapshot
+}
+
+// Quantile returns the upper bound of the bucket that contains the q-th quantile of the recorded latencies.
+func (snapshot HistogramSnapshot) Quantile(q float64) time.Duration {
+	if snapshot.Calls == 0 {
+		return 0
+	}
+	target := uint64(q * float64(snapshot.Calls))
+	if target == 0 {
+		target = 1
+	}
+	var cumulative uint64
+	for _, bound := range snapshot.Bounds() {
+		cumulative += snapshot.Buckets[bound]
+		if cumulative >= target {
+			return time.Duration(bound) * time.Microsecond
+		}
+	}
+	return 0
+}
+
+// Bounds returns the upper bounds of the non-empty buckets in ascending order.
+func (snapshot HistogramSnapshot) Bounds() []uint64 {
+	bounds := make([]uint64, 0, len(snapshot.Buckets))
+	for bound := range snapshot.Buckets {
+		bounds = append(bounds, bound)
+	}
+	slices.Sort(bounds)
+	return bounds
+}
+
+// Registry holds the latency histogram of every command that has been executed.
+type Registry struct {
+	mutex      sync.RWMutex
+	histograms map[string]*Histogram
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		mutex:      sync.RWMutex{},
+		histograms: make(map[string]*Histogram),
+	}
+}
+
+// Record adds the latency to the histogram of the command, and counts the call as failed if it returned an error.
+// The histogram is created if it does not exist. Sub-commands are recorded as "command|subcommand".
+func (registry *Registry) Record(command string, d time.Duration, failed bool) {
+	command = strings.ToLower(command)
+
+	registry.mutex.RLock()
+	histogram, ok := registry.histograms[command]
+	registry.mutex.RUnlock()
+
+	if !ok {
+		registry.mutex.Lock()
+		if histogram, ok = registry.histograms[command]; !ok {
+			histogram = &Histogram{}
+			registry.histograms[command] = histogram
+		}
+		registry.mutex.Unlock()
+	}
+
+	histogram.Record(d, failed)
+}
+
+// Histograms returns a snapshot of the histograms of the provided commands.
+// If no commands are provided, the histograms of all the commands that have been executed are returned.
+// Commands that have not been executed are omitted from the result.
+func (registry *Registry) Histograms(commands ...string) map[string]HistogramSnapshot {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+
+	res := make(map[string]HistogramSnapshot)
+
+	if len(commands) == 0 {
+		for command, histogram := range registry.histograms {
+			res[command] = histogram.Snapshot()
+		}
+		return res
+	}
+
+	for _, command := range commands {
+		if histogram, ok := registry.histograms[strings.ToLower(command)]; ok {
+			res[strings.ToLower(command)] = histogram.Snapshot()
+		}
+	}
+	return res
+}
+
+// Reset removes the histograms of the provided commands. If no commands are provided, all histograms are removed.
+// Returns the number of histograms that were removed.
+func (registry *Registry) Reset(commands ...string) int {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	if len(commands) == 0 {
+		count := len(registry.histograms)
+		registry.histograms = make(map[string]*Histogram)
+		return count
+	}
+
+	count := 0
+	for _, command := range commands {
+		if _, ok := registry.histograms[strings.ToLower(command)]; ok {
+			delete(registry.histograms, strings.ToLower(command))
+			count += 1
+		}
+	}
+	return count
+}
+
+// WritePrometheus writes the latency of each command as a Prometheus summary in the text exposition format.
+func (registry *Registry) WritePrometheus(w io.Writer) error {
+	histograms := registry.Histograms()
+
+	commands := make([]string, 0, len(histograms))
+	for command := range histograms {
+		commands = append(commands, command)
+	}
+	sort.Strings(commands)
+
+	out := "# HELP echovault_command_latency_seconds Latency of the commands executed by the server.\n"
+	out += "# TYPE echovault_command_latency_seconds summary\n"
+	for _, command := range commands {
+		snapshot := histograms[command]
+		for _, q := range quantiles {
+			out += fmt.Sprintf("echovault_command_latency_seconds{command=%q,quantile=\"%g\"} %g\n",
+				command, q, snapshot.Quantile(q).Seconds())
+		}
+		out += fmt.Sprintf("echovault_command_latency_seconds_sum{command=%q} %g\n", command, snapshot.Sum.Seconds())
+		out += fmt.Sprintf("echovault_command_latency_seconds_count{command=%q} %d\n", command, snapshot.Calls)
+	}
+
+	out += "# HELP echovault_command_errors_total Number of commands executed by the server that returned an error.\n"
+	out += "# TYPE echovault_command_errors_total counter\n"
+	for _, command := range commands {
+		out += fmt.Sprintf("echovault_command_errors_total{command=%q} %d\n", command, histograms[command].Failed)
+	}
+
+	_, err := w.Write([]byte(out))
+	return err
+}
@@ -18,6 +18,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/hashicorp/memberlist"
+	"sync"
 )
 
 type BroadcastMessage struct {
@@ -26,6 +27,42 @@ type BroadcastMessage struct {
 	Content     []byte   `json:"Content"`
 	ContentHash [16]byte `json:"ContentHash"`
 	ConnId      string   `json:"ConnId"`
+	MessageID   string   `json:"MessageID,omitempty"`
+}
+
+// messageLog records the IDs of the most recent messages a node has seen,
+// so that a message that reaches a node more than once is only delivered once.
+type messageLog struct {
+	mutex    sync.Mutex
+	capacity int
+	ids      map[string]struct{}
+	order    []string
+}
+
+func newMessageLog(capacity int) *messageLog {
+	return &messageLog{
+		capacity: capacity,
+		ids:      make(map[string]struct{}, capacity),
+		order:    make([]string, 0, capacity),
+	}
+}
+
+// add records the message ID. Returns false if the ID has already been seen.
+// When the log is full, the oldest ID is forgotten.
+func (l *messageLog) add(id string) bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if _, ok := l.ids[id]; ok {
+		return false
+	}
+	if len(l.order) == l.capacity {
+		delete(l.ids, l.order[0])
+		l.order = l.order[1:]
+	}
+	l.ids[id] = struct{}{}
+	l.order = append(l.order, id)
+	return true
 }
 
 // Invalidates Implements Broadcast interface
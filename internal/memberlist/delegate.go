@@ -37,6 +37,8 @@ type DelegateOpts struct {
 	isRaftLeader   func() bool
 	applyMutate    func(ctx context.Context, cmd []string) ([]byte, error)
 	applyDeleteKey func(ctx context.Context, key string) error
+	publish        func(ctx context.Context, channel string, message string)
+	seenMessages   *messageLog
 }
 
 func NewDelegate(opts DelegateOpts) *Delegate {
@@ -48,10 +50,10 @@ func NewDelegate(opts DelegateOpts) *Delegate {
 // NodeMeta implements Delegate interface
 func (delegate *Delegate) NodeMeta(limit int) []byte {
 	meta := NodeMeta{
-		ServerID: raft.ServerID(delegate.options.config.ServerID),
-		RaftAddr: raft.ServerAddress(
-			fmt.Sprintf("%s:%d", delegate.options.config.BindAddr, delegate.options.config.RaftBindPort)),
-		MemberlistAddr: fmt.Sprintf("%s:%d", delegate.options.config.BindAddr, delegate.options.config.MemberListBindPort),
+		ServerID:       raft.ServerID(delegate.options.config.ServerID),
+		RaftAddr:       raft.ServerAddress(delegate.options.config.RaftAddr()),
+		MemberlistAddr: delegate.options.config.MemberListAddr(),
+		ClientAddr:     delegate.options.config.ClientAddr(),
 	}
 
 	b, err := json.Marshal(&meta)
@@ -121,6 +123,21 @@ func (delegate *Delegate) NotifyMsg(msgBytes []byte) {
 		if _, err := delegate.options.applyMutate(ctx, cmd); err != nil {
 			log.Println(err)
 		}
+
+	case "Publish":
+		// The publishing node sends the message to each node directly, so it's not forwarded any further.
+		// Messages that have already been seen are dropped, so that a message is never delivered twice.
+		if !delegate.options.seenMessages.add(msg.MessageID) {
+			return
+		}
+
+		cmd, err := internal.Decode(msg.Content)
+		if err != nil || len(cmd) != 2 {
+			log.Printf("could not decode published message %s\n", msg.MessageID)
+			return
+		}
+		ctx := context.WithValue(context.Background(), internal.ContextServerID("ServerID"), string(msg.ServerID))
+		delegate.options.publish(ctx, cmd[0], cmd[1])
 	}
 }
 
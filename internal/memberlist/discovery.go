@@ -0,0 +1,157 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memberlist
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/echovault/echovault/internal/config"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// serviceAccountDir is where Kubernetes mounts the credentials of the pod's service account.
+const serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// peerResolver returns the memberlist addresses of the cluster members to join.
+type peerResolver func(ctx context.Context) ([]string, error)
+
+// newPeerResolver returns the peer resolver for the peer discovery mode in the config.
+func newPeerResolver(conf config.Config) (peerResolver, error) {
+	switch conf.PeerDiscovery {
+	case "dns-srv":
+		if conf.DiscoveryDNS == "" {
+			return nil, errors.New("discovery-dns is required when peer-discovery is dns-srv")
+		}
+		return newDNSResolver(conf.DiscoveryDNS), nil
+	case "kubernetes":
+		if conf.DiscoverySelector == "" {
+			return nil, errors.New("discovery-selector is required when peer-discovery is kubernetes")
+		}
+		return newKubernetesResolver(conf.DiscoveryNamespace, conf.DiscoverySelector, conf.MemberListBindPort), nil
+	default:
+		return func(ctx context.Context) ([]string, error) {
+			if conf.JoinAddr == "" {
+				return []string{}, nil
+			}
+			return []string{conf.JoinAddr}, nil
+		}, nil
+	}
+}
+
+// newDNSResolver returns a peer resolver that looks up the targets of the SRV records with the provided name.
+func newDNSResolver(name string) peerResolver {
+	return func(ctx context.Context) ([]string, error) {
+		_, records, err := net.DefaultResolver.LookupSRV(ctx, "", "", name)
+		if err != nil {
+			return nil, fmt.Errorf("lookup srv %s: %+v", name, err)
+		}
+		peers := make([]string, len(records))
+		for i, record := range records {
+			peers[i] = net.JoinHostPort(strings.TrimSuffix(record.Target, "."), strconv.Itoa(int(record.Port)))
+		}
+		return peers, nil
+	}
+}
+
+// newKubernetesResolver returns a peer resolver that lists the running pods that match the label selector
+// using the Kubernetes API. The resolver authenticates with the pod's service account.
+// If namespace is empty, the namespace of the service account is used.
+func newKubernetesResolver(namespace string, selector string, port uint16) peerResolver {
+	// The client is created on the first call and reused, so that its connections to the API server are kept alive.
+	var client *http.Client
+	return func(ctx context.Context) ([]string, error) {
+		host, apiPort := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+		if host == "" || apiPort == "" {
+			return nil, errors.New("kubernetes discovery requires the pod to run in a kubernetes cluster")
+		}
+
+		if namespace == "" {
+			b, err := os.ReadFile(path.Join(serviceAccountDir, "namespace"))
+			if err != nil {
+				return nil, fmt.Errorf("read service account namespace: %+v", err)
+			}
+			namespace = strings.TrimSpace(string(b))
+		}
+
+		// The token is read on each call because Kubernetes rotates service account tokens.
+		token, err := os.ReadFile(path.Join(serviceAccountDir, "token"))
+		if err != nil {
+			return nil, fmt.Errorf("read service account token: %+v", err)
+		}
+		if client == nil {
+			ca, err := os.ReadFile(path.Join(serviceAccountDir, "ca.crt"))
+			if err != nil {
+				return nil, fmt.Errorf("read service account ca: %+v", err)
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(ca) {
+				return nil, errors.New("service account ca is not a valid PEM certificate")
+			}
+			client = &http.Client{
+				Timeout:   10 * time.Second,
+				Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}},
+			}
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("https://%s/api/v1/namespaces/%s/pods?labelSelector=%s",
+			net.JoinHostPort(host, apiPort), url.PathEscape(namespace), url.QueryEscape(selector)), nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+
+		res, err := client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("list pods: %+v", err)
+		}
+		defer func() {
+			_ = res.Body.Close()
+		}()
+		if res.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("list pods: unexpected status %s", res.Status)
+		}
+
+		var pods struct {
+			Items []struct {
+				Status struct {
+					Phase string `json:"phase"`
+					PodIP string `json:"podIP"`
+				} `json:"status"`
+			} `json:"items"`
+		}
+		if err = json.NewDecoder(res.Body).Decode(&pods); err != nil {
+			return nil, fmt.Errorf("decode pods: %+v", err)
+		}
+
+		var peers []string
+		for _, pod := range pods.Items {
+			if pod.Status.Phase == "Running" && pod.Status.PodIP != "" {
+				peers = append(peers, net.JoinHostPort(pod.Status.PodIP, strconv.Itoa(int(port))))
+			}
+		}
+		return peers, nil
+	}
+}
@@ -28,6 +28,10 @@ type EventDelegateOpts struct {
 	incrementNodes   func()
 	decrementNodes   func()
 	removeRaftServer func(meta NodeMeta) error
+	// Called with the node that joined or left the cluster. They're called while memberlist holds its node lock,
+	// so they must not call back into memberlist.
+	memberJoined func(node *memberlist.Node)
+	memberLeft   func(node *memberlist.Node)
 }
 
 func NewEventDelegate(opts EventDelegateOpts) *EventDelegate {
@@ -39,11 +43,13 @@ func NewEventDelegate(opts EventDelegateOpts) *EventDelegate {
 // NotifyJoin implements EventDelegate interface
 func (eventDelegate *EventDelegate) NotifyJoin(node *memberlist.Node) {
 	eventDelegate.options.incrementNodes()
+	eventDelegate.options.memberJoined(node)
 }
 
 // NotifyLeave implements EventDelegate interface
 func (eventDelegate *EventDelegate) NotifyLeave(node *memberlist.Node) {
 	eventDelegate.options.decrementNodes()
+	eventDelegate.options.memberLeft(node)
 
 	var meta NodeMeta
 
@@ -17,10 +17,19 @@ package memberlist
 import (
 	"context"
 	"crypto/md5"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/echovault/echovault/internal"
 	"github.com/echovault/echovault/internal/config"
 	"log"
+	"net"
+	"os"
+	"path"
+	"slices"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/hashicorp/memberlist"
@@ -32,6 +41,7 @@ type NodeMeta struct {
 	ServerID       raft.ServerID      `json:"ServerID"`
 	MemberlistAddr string             `json:"MemberlistAddr"`
 	RaftAddr       raft.ServerAddress `json:"RaftAddr"`
+	ClientAddr     string             `json:"ClientAddr"`
 }
 
 type Opts struct {
@@ -42,6 +52,7 @@ type Opts struct {
 	IsRaftLeader     func() bool
 	ApplyMutate      func(ctx context.Context, cmd []string) ([]byte, error)
 	ApplyDeleteKey   func(ctx context.Context, key string) error
+	Publish          func(ctx context.Context, channel string, message string)
 }
 
 type MemberList struct {
@@ -49,6 +60,13 @@ type MemberList struct {
 	broadcastQueue *memberlist.TransmitLimitedQueue
 	numOfNodes     int
 	memberList     *memberlist.Memberlist
+	localName      string            // The memberlist name of this node.
+	membersMutex   sync.Mutex        // Guards members.
+	members        map[string]string // The addresses of the cluster members, keyed by their memberlist name.
+	membersChanged chan struct{}     // Signalled when a member joins or leaves, to persist the peers.
+	seenMessages   *messageLog       // The IDs of the recently published messages this node has handled.
+	publishCount   atomic.Uint64     // Used to generate the IDs of the messages published on this node.
+	publishQueue   chan []byte       // The published messages waiting to be sent to the other nodes, in order.
 }
 
 func NewMemberList(opts Opts) *MemberList {
@@ -56,13 +74,25 @@ func NewMemberList(opts Opts) *MemberList {
 		options:        opts,
 		broadcastQueue: new(memberlist.TransmitLimitedQueue),
 		numOfNodes:     0,
+		members:        make(map[string]string),
+		membersChanged: make(chan struct{}, 1),
+		seenMessages:   newMessageLog(8192),
+		publishQueue:   make(chan []byte, 1024),
 	}
 }
 
 func (m *MemberList) MemberListInit(ctx context.Context) {
 	cfg := memberlist.DefaultLocalConfig()
-	cfg.BindAddr = m.options.Config.BindAddr
+	cfg.BindAddr = m.options.Config.ClusterAddr()
 	cfg.BindPort = int(m.options.Config.MemberListBindPort)
+	// Encrypt the gossip with the shared key if one is configured. The key is checked when the config is validated.
+	if m.options.Config.ClusterGossipKey != "" {
+		key, err := base64.StdEncoding.DecodeString(m.options.Config.ClusterGossipKey)
+		if err != nil {
+			log.Fatal(fmt.Errorf("invalid cluster gossip key: %+v", err))
+		}
+		cfg.SecretKey = key
+	}
 	cfg.Delegate = NewDelegate(DelegateOpts{
 		config:         m.options.Config,
 		broadcastQueue: m.broadcastQueue,
@@ -70,12 +100,19 @@ func (m *MemberList) MemberListInit(ctx context.Context) {
 		isRaftLeader:   m.options.IsRaftLeader,
 		applyMutate:    m.options.ApplyMutate,
 		applyDeleteKey: m.options.ApplyDeleteKey,
+		publish:        m.options.Publish,
+		seenMessages:   m.seenMessages,
 	})
 	cfg.Events = NewEventDelegate(EventDelegateOpts{
 		incrementNodes:   func() { m.numOfNodes += 1 },
 		decrementNodes:   func() { m.numOfNodes -= 1 },
 		removeRaftServer: m.options.RemoveRaftServer,
+		memberJoined:     m.memberJoined,
+		memberLeft:       m.memberLeft,
 	})
+	m.localName = cfg.Name
+	go m.persistPeersOnChange(ctx)
+	go m.sendPublishedMessages(ctx)
 
 	m.broadcastQueue.RetransmitMult = 1
 	m.broadcastQueue.NumNodes = func() int {
@@ -89,11 +126,28 @@ func (m *MemberList) MemberListInit(ctx context.Context) {
 		log.Fatal(err)
 	}
 
-	if m.options.Config.JoinAddr != "" {
-		backoffPolicy := internal.RetryBackoff(retry.NewFibonacci(1*time.Second), 5, 200*time.Millisecond, 0, 0)
+	resolvePeers, err := newPeerResolver(m.options.Config)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	peers, err := resolvePeers(ctx)
+	if err != nil {
+		log.Println(fmt.Errorf("peer discovery error: %+v", err))
+	}
+	// Also try the members that were known before a restart, so the node can rejoin without the join address.
+	for _, peer := range m.loadPeers() {
+		if !slices.Contains(peers, peer) {
+			peers = append(peers, peer)
+		}
+	}
+	peers = m.unknownPeers(ctx, peers)
+
+	if len(peers) > 0 {
+		backoffPolicy := internal.RetryBackoff(retry.NewExponential(500*time.Millisecond), 0, 200*time.Millisecond, 30*time.Second, 2*time.Minute)
 
 		err = retry.Do(ctx, backoffPolicy, func(ctx context.Context) error {
-			_, err = list.Join([]string{m.options.Config.JoinAddr})
+			_, err = list.Join(peers)
 			if err != nil {
 				return retry.RetryableError(err)
 			}
@@ -106,6 +160,204 @@ func (m *MemberList) MemberListInit(ctx context.Context) {
 
 		m.broadcastRaftAddress()
 	}
+
+	// Periodically re-resolve the peers so that members that join later, e.g. rescheduled pods, are discovered.
+	if m.options.Config.PeerDiscovery != "static" && m.options.Config.DiscoveryInterval > 0 {
+		go m.refreshPeers(ctx, resolvePeers)
+	}
+}
+
+// peersFile returns the path of the file the known peers are persisted to.
+// Returns an empty string if there's no data directory.
+func (m *MemberList) peersFile() string {
+	if m.options.Config.DataDir == "" {
+		return ""
+	}
+	return path.Join(m.options.Config.DataDir, "memberlist", "peers.json")
+}
+
+// memberJoined records the address of a node that joined the cluster.
+// It's called by the event delegate while memberlist holds its node lock, so it must not call back into memberlist.
+// The peers are persisted by persistPeersOnChange instead.
+func (m *MemberList) memberJoined(node *memberlist.Node) {
+	m.membersMutex.Lock()
+	m.members[node.Name] = node.Address()
+	m.membersMutex.Unlock()
+	m.notifyMembersChanged()
+}
+
+// memberLeft forgets the address of a node that left the cluster. Like memberJoined, it must not call back into memberlist.
+func (m *MemberList) memberLeft(node *memberlist.Node) {
+	m.membersMutex.Lock()
+	delete(m.members, node.Name)
+	m.membersMutex.Unlock()
+	m.notifyMembersChanged()
+}
+
+// notifyMembersChanged signals persistPeersOnChange without blocking.
+// Changes that happen while the peers are being persisted are coalesced into the next write.
+func (m *MemberList) notifyMembersChanged() {
+	select {
+	case m.membersChanged <- struct{}{}:
+	default:
+	}
+}
+
+// persistPeersOnChange persists the peers each time a member joins or leaves, until the context is cancelled.
+func (m *MemberList) persistPeersOnChange(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-m.membersChanged:
+			m.persistPeers()
+		}
+	}
+}
+
+// persistPeers writes the addresses of the other cluster members to the peers file.
+// The file is not updated when there are no other members, so the last known peers are kept
+// when the rest of the cluster restarts.
+func (m *MemberList) persistPeers() {
+	file := m.peersFile()
+	if file == "" {
+		return
+	}
+
+	var peers []string
+	m.membersMutex.Lock()
+	for name, address := range m.members {
+		if name != m.localName {
+			peers = append(peers, address)
+		}
+	}
+	m.membersMutex.Unlock()
+	if len(peers) == 0 {
+		return
+	}
+	slices.Sort(peers)
+
+	b, err := json.Marshal(peers)
+	if err != nil {
+		log.Println(fmt.Errorf("persist peers error: %+v", err))
+		return
+	}
+
+	if err = os.MkdirAll(path.Dir(file), os.ModePerm); err != nil {
+		log.Println(fmt.Errorf("persist peers error: %+v", err))
+		return
+	}
+	// Write to a temporary file first so that the peers file is never partially written.
+	if err = os.WriteFile(file+".tmp", b, os.ModePerm); err != nil {
+		log.Println(fmt.Errorf("persist peers error: %+v", err))
+		return
+	}
+	if err = os.Rename(file+".tmp", file); err != nil {
+		log.Println(fmt.Errorf("persist peers error: %+v", err))
+	}
+}
+
+// loadPeers returns the peers persisted before the last restart.
+func (m *MemberList) loadPeers() []string {
+	file := m.peersFile()
+	if file == "" {
+		return []string{}
+	}
+	b, err := os.ReadFile(file)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Println(fmt.Errorf("load peers error: %+v", err))
+		}
+		return []string{}
+	}
+	var peers []string
+	if err = json.Unmarshal(b, &peers); err != nil {
+		log.Println(fmt.Errorf("load peers error: %+v", err))
+		return []string{}
+	}
+	return peers
+}
+
+// refreshPeers resolves the peers every discovery interval and joins the ones that are not yet members
+// until the context is cancelled.
+func (m *MemberList) refreshPeers(ctx context.Context, resolvePeers peerResolver) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(m.options.Config.DiscoveryInterval):
+		}
+
+		peers, err := resolvePeers(ctx)
+		if err != nil {
+			log.Println(fmt.Errorf("peer discovery error: %+v", err))
+			continue
+		}
+		peers = m.unknownPeers(ctx, peers)
+		if len(peers) == 0 {
+			continue
+		}
+
+		if _, err = m.memberList.Join(peers); err != nil {
+			log.Println(fmt.Errorf("peer discovery join error: %+v", err))
+			continue
+		}
+		// If no peers were found on startup, this node has not joined the raft cluster yet.
+		if !m.options.IsRaftLeader() && !m.options.HasJoinedCluster() {
+			m.broadcastRaftAddress()
+		}
+	}
+}
+
+// NodeClientAddr returns the client address of the cluster member with the server ID,
+// or an empty string if there's no such member.
+func (m *MemberList) NodeClientAddr(id raft.ServerID) string {
+	for _, node := range m.memberList.Members() {
+		var meta NodeMeta
+		if err := json.Unmarshal(node.Meta, &meta); err != nil {
+			continue
+		}
+		if meta.ServerID == id {
+			return meta.ClientAddr
+		}
+	}
+	return ""
+}
+
+// unknownPeers returns the peers that are not members of the cluster, including this node.
+func (m *MemberList) unknownPeers(ctx context.Context, peers []string) []string {
+	members := make(map[string]struct{})
+	for _, member := range m.memberList.Members() {
+		members[member.Address()] = struct{}{}
+	}
+
+	var unknown []string
+	for _, peer := range peers {
+		host, port, err := net.SplitHostPort(peer)
+		if err != nil {
+			// Let memberlist handle addresses without a port.
+			unknown = append(unknown, peer)
+			continue
+		}
+		addresses := []string{host}
+		if net.ParseIP(host) == nil {
+			// Member addresses are IP addresses, so host names must be resolved before comparing them.
+			if resolved, err := net.DefaultResolver.LookupHost(ctx, host); err == nil {
+				addresses = resolved
+			}
+		}
+		known := false
+		for _, address := range addresses {
+			if _, ok := members[net.JoinHostPort(address, port)]; ok {
+				known = true
+				break
+			}
+		}
+		if !known {
+			unknown = append(unknown, peer)
+		}
+	}
+	return unknown
 }
 
 func (m *MemberList) broadcastRaftAddress() {
@@ -113,8 +365,7 @@ func (m *MemberList) broadcastRaftAddress() {
 		Action: "RaftJoin",
 		NodeMeta: NodeMeta{
 			ServerID: raft.ServerID(m.options.Config.ServerID),
-			RaftAddr: raft.ServerAddress(fmt.Sprintf("%s:%d",
-				m.options.Config.BindAddr, m.options.Config.RaftBindPort)),
+			RaftAddr: raft.ServerAddress(m.options.Config.RaftAddr()),
 		},
 	}
 	m.broadcastQueue.QueueBroadcast(&msg)
@@ -131,8 +382,7 @@ func (m *MemberList) ForwardDeleteKey(ctx context.Context, key string) {
 		ConnId:      connId,
 		NodeMeta: NodeMeta{
 			ServerID: raft.ServerID(m.options.Config.ServerID),
-			RaftAddr: raft.ServerAddress(fmt.Sprintf("%s:%d",
-				m.options.Config.BindAddr, m.options.Config.RaftBindPort)),
+			RaftAddr: raft.ServerAddress(m.options.Config.RaftAddr()),
 		},
 	})
 }
@@ -148,12 +398,58 @@ func (m *MemberList) ForwardDataMutation(ctx context.Context, cmd []byte) {
 		ConnId:      connId,
 		NodeMeta: NodeMeta{
 			ServerID: raft.ServerID(m.options.Config.ServerID),
-			RaftAddr: raft.ServerAddress(fmt.Sprintf("%s:%d",
-				m.options.Config.BindAddr, m.options.Config.RaftBindPort)),
+			RaftAddr: raft.ServerAddress(m.options.Config.RaftAddr()),
 		},
 	})
 }
 
+// ForwardPublish delivers a published message to the subscribers on the other nodes.
+// Unlike data mutations, published messages are not applied through raft. Each node delivers the message to its
+// own subscribers.
+func (m *MemberList) ForwardPublish(ctx context.Context, channel string, message string) {
+	connId, _ := ctx.Value(internal.ContextConnID("ConnectionID")).(string)
+	content := internal.EncodeCommand([]string{channel, message})
+	id := fmt.Sprintf("%s-%d", m.options.Config.ServerID, m.publishCount.Add(1))
+	m.seenMessages.add(id)
+	msg := &BroadcastMessage{
+		Action:      "Publish",
+		Content:     content,
+		ContentHash: md5.Sum(content),
+		ConnId:      connId,
+		MessageID:   id,
+		NodeMeta: NodeMeta{
+			ServerID: raft.ServerID(m.options.Config.ServerID),
+			RaftAddr: raft.ServerAddress(m.options.Config.RaftAddr()),
+		},
+	}
+	select {
+	case m.publishQueue <- msg.Message():
+	case <-ctx.Done():
+	}
+}
+
+// sendPublishedMessages sends the published messages to each of the other nodes until the context is cancelled.
+// The messages are sent over TCP rather than gossiped, as a message larger than a UDP packet would be dropped
+// by the broadcast queue. A single goroutine sends the messages, so that each node receives them in the order
+// they were published on this node.
+func (m *MemberList) sendPublishedMessages(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg := <-m.publishQueue:
+			for _, node := range m.memberList.Members() {
+				if node.Name == m.localName {
+					continue
+				}
+				if err := m.memberList.SendReliable(node, msg); err != nil {
+					log.Printf("could not forward published message to %s: %+v\n", node.Name, err)
+				}
+			}
+		}
+	}
+}
+
 func (m *MemberList) MemberListShutdown() {
 	// Gracefully leave memberlist cluster
 	err := m.memberList.Leave(500 * time.Millisecond)
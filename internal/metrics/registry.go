@@ -0,0 +1,147 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// sampleCount is the number of samples averaged to calculate the instantaneous operations per second.
+const sampleCount = 16
+
+// Registry holds the server-wide counters reported in the stats section of INFO.
+// The counters are incremented by the connection loop, the command dispatcher and the keyspace.
+type Registry struct {
+	connectionsReceived atomic.Uint64
+	commandsProcessed   atomic.Uint64
+	keyspaceHits        atomic.Uint64
+	keyspaceMisses      atomic.Uint64
+	expiredKeys         atomic.Uint64
+	evictedKeys         atomic.Uint64
+	acceptErrors        atomic.Uint64
+
+	mutex           sync.Mutex
+	samples         [sampleCount]float64 // Operations per second measured by the most recent calls to Sample.
+	sampleIndex     int
+	lastSampleTime  time.Time
+	lastSampleCount uint64
+}
+
+// Stats is a point-in-time copy of the counters of a Registry.
+//
+// InstantaneousOps is the average number of commands processed per second over the most recent samples.
+type Stats struct {
+	ConnectionsReceived uint64
+	CommandsProcessed   uint64
+	InstantaneousOps    float64
+	KeyspaceHits        uint64
+	KeyspaceMisses      uint64
+	ExpiredKeys         uint64
+	EvictedKeys         uint64
+	AcceptErrors        uint64
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// ConnectionReceived counts a new client connection.
+func (registry *Registry) ConnectionReceived() {
+	registry.connectionsReceived.Add(1)
+}
+
+// CommandProcessed counts a command executed by the server.
+func (registry *Registry) CommandProcessed() {
+	registry.commandsProcessed.Add(1)
+}
+
+// KeyspaceLookup counts a lookup of a key by a read command as a hit if the key was found, otherwise as a miss.
+func (registry *Registry) KeyspaceLookup(hit bool) {
+	if hit {
+		registry.keyspaceHits.Add(1)
+		return
+	}
+	registry.keyspaceMisses.Add(1)
+}
+
+// KeyExpired counts a key deleted because its expiry time had passed.
+func (registry *Registry) KeyExpired() {
+	registry.expiredKeys.Add(1)
+}
+
+// KeyEvicted counts a key deleted to bring the memory usage below the max memory limit.
+func (registry *Registry) KeyEvicted() {
+	registry.evictedKeys.Add(1)
+}
+
+// AcceptError counts a failed attempt to accept a client connection.
+func (registry *Registry) AcceptError() {
+	registry.acceptErrors.Add(1)
+}
+
+// Sample records the number of commands processed per second since the previous sample.
+// It's called periodically by the server so that the instantaneous operations per second follow the recent load.
+func (registry *Registry) Sample(now time.Time) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	count := registry.commandsProcessed.Load()
+	if !registry.lastSampleTime.IsZero() && now.After(registry.lastSampleTime) && count >= registry.lastSampleCount {
+		elapsed := now.Sub(registry.lastSampleTime).Seconds()
+		registry.samples[registry.sampleIndex] = float64(count-registry.lastSampleCount) / elapsed
+		registry.sampleIndex = (registry.sampleIndex + 1) % sampleCount
+	}
+	registry.lastSampleTime = now
+	registry.lastSampleCount = count
+}
+
+// Stats returns a snapshot of the counters.
+func (registry *Registry) Stats() Stats {
+	registry.mutex.Lock()
+	var sum float64
+	for _, sample := range registry.samples {
+		sum += sample
+	}
+	registry.mutex.Unlock()
+
+	return Stats{
+		ConnectionsReceived: registry.connectionsReceived.Load(),
+		CommandsProcessed:   registry.commandsProcessed.Load(),
+		InstantaneousOps:    sum / sampleCount,
+		KeyspaceHits:        registry.keyspaceHits.Load(),
+		KeyspaceMisses:      registry.keyspaceMisses.Load(),
+		ExpiredKeys:         registry.expiredKeys.Load(),
+		EvictedKeys:         registry.evictedKeys.Load(),
+		AcceptErrors:        registry.acceptErrors.Load(),
+	}
+}
+
+// Reset sets all the counters and samples back to 0.
+func (registry *Registry) Reset() {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	registry.connectionsReceived.Store(0)
+	registry.commandsProcessed.Store(0)
+	registry.keyspaceHits.Store(0)
+	registry.keyspaceMisses.Store(0)
+	registry.expiredKeys.Store(0)
+	registry.evictedKeys.Store(0)
+	registry.acceptErrors.Store(0)
+	registry.samples = [sampleCount]float64{}
+	registry.lastSampleCount = 0
+}
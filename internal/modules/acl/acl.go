@@ -23,12 +23,13 @@ import (
 	"github.com/echovault/echovault/internal"
 	"github.com/echovault/echovault/internal/config"
 	"github.com/echovault/echovault/internal/constants"
-	"github.com/gobwas/glob"
+	"github.com/echovault/echovault/internal/glob"
 	"gopkg.in/yaml.v3"
 	"log"
 	"net"
 	"os"
 	"path"
+	"path/filepath"
 	"reflect"
 	"slices"
 	"strings"
@@ -46,7 +47,13 @@ type ACL struct {
 	UsersMutex   sync.RWMutex             // RWMutex for concurrency control when accessing ACL profile list
 	Connections  map[*net.Conn]Connection // Connections to the echovault that are currently registered with the ACL module
 	Config       config.Config            // EchoVault configuration that contains the relevant ACL config options
-	GlobPatterns map[string]glob.Glob
+	GlobPatterns map[string]*glob.Glob
+
+	saveMutex  sync.Mutex    // Serialises writes to the ACL config file
+	saveSignal chan struct{} // Signals the background saver that the users have changed
+
+	commandLimiter *rateLimiter // Limits the commands per second of each user with a rate limit
+	authLimiter    *rateLimiter // Limits the AUTH attempts per second of each client address
 }
 
 func NewACL(config config.Config) *ACL {
@@ -68,7 +75,10 @@ func NewACL(config config.Config) *ACL {
 	if config.AclConfig != "" {
 		// Override acl configurations from file
 		if f, err := os.Open(config.AclConfig); err != nil {
-			panic(err)
+			// With autosave enabled, a missing file is created on the first save.
+			if !(config.AclAutoSave && errors.Is(err, os.ErrNotExist)) {
+				panic(err)
+			}
 		} else {
 			defer func() {
 				if err := f.Close(); err != nil {
@@ -114,15 +124,24 @@ func NewACL(config config.Config) *ACL {
 		UsersMutex:   sync.RWMutex{},
 		Connections:  make(map[*net.Conn]Connection),
 		Config:       config,
-		GlobPatterns: make(map[string]glob.Glob),
+		GlobPatterns: make(map[string]*glob.Glob),
+
+		commandLimiter: newRateLimiter(),
+		authLimiter:    newRateLimiter(),
 	}
 
 	acl.CompileGlobs()
 
+	// 5. Rewrite the ACL config file in the background whenever the users change
+	if config.AclConfig != "" && config.AclAutoSave {
+		acl.saveSignal = make(chan struct{}, 1)
+		go acl.autoSave()
+	}
+
 	return &acl
 }
 
-func (acl *ACL) RegisterConnection(conn *net.Conn) {
+func (acl *ACL) RegisterConnection(conn *net.Conn) error {
 	acl.LockUsers()
 	defer acl.UnlockUsers()
 
@@ -131,10 +150,51 @@ func (acl *ACL) RegisterConnection(conn *net.Conn) {
 		return user.Username == "default"
 	})
 	defaultUser := acl.Users[defaultUserIdx]
+	if err := acl.checkMaxConnections(conn, defaultUser); err != nil {
+		return err
+	}
 	acl.Connections[conn] = Connection{
 		Authenticated: defaultUser.NoPassword,
 		User:          defaultUser,
 	}
+	return nil
+}
+
+// UnregisterConnection removes the connection from the ACL. This is called when the connection is closed.
+func (acl *ACL) UnregisterConnection(conn *net.Conn) {
+	acl.LockUsers()
+	defer acl.UnlockUsers()
+	delete(acl.Connections, conn)
+}
+
+// Username returns the name of the user that the connection is associated with.
+// Returns an empty string if the connection is not registered with the ACL.
+func (acl *ACL) Username(conn *net.Conn) string {
+	acl.RLockUsers()
+	defer acl.RUnlockUsers()
+	connection, ok := acl.Connections[conn]
+	if !ok || connection.User == nil {
+		return ""
+	}
+	return connection.User.Username
+}
+
+// checkMaxConnections returns an error if associating the connection with the user would exceed the user's
+// maximum number of connections. The users must be locked by the caller.
+func (acl *ACL) checkMaxConnections(conn *net.Conn, user *User) error {
+	if user.MaxConnections <= 0 {
+		return nil
+	}
+	count := 0
+	for c, connection := range acl.Connections {
+		if c != conn && connection.User != nil && connection.User.Username == user.Username {
+			count += 1
+		}
+	}
+	if count >= user.MaxConnections {
+		return fmt.Errorf("too many connections for user %s", user.Username)
+	}
+	return nil
 }
 
 func (acl *ACL) SetUser(cmd []string) error {
@@ -149,6 +209,7 @@ func (acl *ACL) SetUser(cmd []string) error {
 				return err
 			} else {
 				acl.CompileGlobs()
+				acl.scheduleSave()
 				return nil
 			}
 		}
@@ -165,6 +226,7 @@ func (acl *ACL) SetUser(cmd []string) error {
 	acl.Users = append(acl.Users, user)
 
 	acl.CompileGlobs()
+	acl.scheduleSave()
 
 	return nil
 }
@@ -174,6 +236,8 @@ func (acl *ACL) AddUsers(users []*User) {
 	defer acl.UnlockUsers()
 
 	acl.Users = append(acl.Users, users...)
+
+	acl.CompileGlobs()
 }
 
 func (acl *ACL) DeleteUser(_ context.Context, usernames []string) error {
@@ -207,12 +271,19 @@ func (acl *ACL) DeleteUser(_ context.Context, usernames []string) error {
 			return u.Username == user.Username
 		})
 	}
+	acl.scheduleSave()
 	return nil
 }
 
 func (acl *ACL) AuthenticateConnection(_ context.Context, conn *net.Conn, cmd []string) error {
-	acl.RLockUsers()
-	defer acl.RUnlockUsers()
+	// The connection list is updated on successful authentication.
+	acl.LockUsers()
+	defer acl.UnlockUsers()
+
+	// Limit the authentication attempts of each client address to make brute-forcing passwords impractical.
+	if !acl.authLimiter.allow(clientAddress(conn), int(acl.Config.AuthRateLimit), time.Now()) {
+		return errors.New("too many authentication attempts, try again later")
+	}
 
 	var passwords []Password
 	var user *User
@@ -259,6 +330,10 @@ func (acl *ACL) AuthenticateConnection(_ context.Context, conn *net.Conn, cmd []
 		return fmt.Errorf("user %s is disabled", user.Username)
 	}
 
+	if err := acl.checkMaxConnections(conn, user); err != nil {
+		return err
+	}
+
 	// If user is set to NoPassword, then immediately authenticate connection without considering the password
 	if user.NoPassword {
 		acl.Connections[conn] = Connection{
@@ -286,6 +361,22 @@ func (acl *ACL) AuthenticateConnection(_ context.Context, conn *net.Conn, cmd []
 	return errors.New("could not authenticate user")
 }
 
+// helloHasAuth reports whether the HELLO command has the AUTH option.
+func helloHasAuth(cmd []string) bool {
+	for i := 2; i < len(cmd); i++ {
+		switch strings.ToLower(cmd[i]) {
+		case "auth":
+			return true
+		case "setname":
+			// Skip the client name, which could be "auth".
+			i += 1
+		default:
+			return false
+		}
+	}
+	return false
+}
+
 func (acl *ACL) AuthorizeConnection(conn *net.Conn, cmd []string, command internal.Command, subCommand internal.SubCommand) error {
 	acl.RLockUsers()
 	defer acl.RUnlockUsers()
@@ -299,19 +390,20 @@ func (acl *ACL) AuthorizeConnection(conn *net.Conn, cmd []string, command intern
 		return err
 	}
 
-	channels := keys.Channels
-	readKeys := keys.ReadKeys
-	writeKeys := keys.WriteKeys
-
 	if !reflect.DeepEqual(subCommand, internal.SubCommand{}) {
 		comm = fmt.Sprintf("%s|%s", comm, subCommand.Command)
-		categories = append(categories, subCommand.Categories...)
+		// Clone the categories so that the command's categories are not modified.
+		categories = append(slices.Clone(categories), subCommand.Categories...)
 		keys, err = subCommand.KeyExtractionFunc(cmd)
 		if err != nil {
 			return err
 		}
 	}
 
+	channels := keys.Channels
+	readKeys := keys.ReadKeys
+	writeKeys := keys.WriteKeys
+
 	// Skip ack
 	if strings.EqualFold(comm, "ack") {
 		return nil
@@ -327,9 +419,19 @@ func (acl *ACL) AuthorizeConnection(conn *net.Conn, cmd []string, command intern
 		return nil
 	}
 
+	// HELLO with the AUTH option authenticates the connection itself, so allow it like AUTH
+	if strings.EqualFold(comm, "hello") && helloHasAuth(cmd) {
+		return nil
+	}
+
 	// Get current connection ACL details
 	connection := acl.Connections[conn]
 
+	// Enforce the rate limit of the connection's user across all the user's connections.
+	if connection.User != nil && !acl.commandLimiter.allow(connection.User.Username, connection.User.RateLimit, time.Now()) {
+		return fmt.Errorf("rate limit exceeded for user %s", connection.User.Username)
+	}
+
 	// If password is not required, allow the connection
 	if !acl.Config.RequirePass {
 		return nil
@@ -344,7 +446,7 @@ func (acl *ACL) AuthorizeConnection(conn *net.Conn, cmd []string, command intern
 	var notAllowed []string
 	if !slices.ContainsFunc(categories, func(category string) bool {
 		return slices.ContainsFunc(connection.User.IncludedCategories, func(includedCategory string) bool {
-			if includedCategory == "*" || includedCategory == category {
+			if acl.matchGlob(includedCategory, category) {
 				return true
 			}
 			notAllowed = append(notAllowed, fmt.Sprintf("@%s", category))
@@ -360,7 +462,7 @@ func (acl *ACL) AuthorizeConnection(conn *net.Conn, cmd []string, command intern
 	// 3. Check if commands category is in ExcludedCategories
 	if slices.ContainsFunc(categories, func(category string) bool {
 		return slices.ContainsFunc(connection.User.ExcludedCategories, func(excludedCategory string) bool {
-			if excludedCategory == "*" || excludedCategory == category {
+			if acl.matchGlob(excludedCategory, category) {
 				notAllowed = []string{fmt.Sprintf("@%s", category)}
 				return true
 			}
@@ -370,33 +472,44 @@ func (acl *ACL) AuthorizeConnection(conn *net.Conn, cmd []string, command intern
 		return fmt.Errorf("unauthorized access to the following categories: %+v", notAllowed)
 	}
 
-	// 4. Check if commands are in IncludedCommands
-	if !slices.ContainsFunc(connection.User.IncludedCommands, func(includedCommand string) bool {
-		return includedCommand == "*" || includedCommand == comm
-	}) {
+	// 4. Check if commands are in IncludedCommands.
+	// A rule matches a sub-command if it matches the sub-command (e.g. config|get) or its command (e.g. config).
+	matchesCommand := func(rule string) bool {
+		return acl.matchGlob(rule, comm) || acl.matchGlob(rule, command.Command)
+	}
+	if !slices.ContainsFunc(connection.User.IncludedCommands, matchesCommand) {
 		return fmt.Errorf("not authorised to run %s command", comm)
 	}
 
 	// 5. Check if command are in ExcludedCommands
-	if slices.ContainsFunc(connection.User.ExcludedCommands, func(excludedCommand string) bool {
-		return excludedCommand == "*" || excludedCommand == comm
-	}) {
+	if slices.ContainsFunc(connection.User.ExcludedCommands, matchesCommand) {
 		return fmt.Errorf("not authorised to run %s command", comm)
 	}
 
 	// 6. PUBSUB authorisation.
 	if slices.Contains(categories, constants.PubSubCategory) {
+		// The channels of PSUBSCRIBE and PUNSUBSCRIBE are patterns.
+		isPattern := slices.Contains([]string{"psubscribe", "punsubscribe"}, strings.ToLower(comm))
 		// Loop through each of the channels accessed by this command
 		for _, channel := range channels {
-			// 2.1) Check if the channel is in IncludedPubSubChannels
+			// 2.1) Check if the channel is in IncludedPubSubChannels.
+			// A pattern must be the same as an included pattern, otherwise it could match more channels than
+			// the included pattern does.
 			if !slices.ContainsFunc(connection.User.IncludedPubSubChannels, func(includedChannelGlob string) bool {
-				return acl.GlobPatterns[includedChannelGlob].Match(channel)
+				if isPattern {
+					return includedChannelGlob == "*" || includedChannelGlob == channel
+				}
+				return acl.matchGlob(includedChannelGlob, channel)
 			}) {
 				return fmt.Errorf("not authorised to access channel &%s", channel)
 			}
-			// 2.2) Check if the channel is in ExcludedPubSubChannels
+			// 2.2) Check if the channel is in ExcludedPubSubChannels.
+			// A pattern is also rejected if it matches an excluded channel.
 			if slices.ContainsFunc(connection.User.ExcludedPubSubChannels, func(excludedChannelGlob string) bool {
-				return acl.GlobPatterns[excludedChannelGlob].Match(channel)
+				if isPattern && glob.Match(channel, excludedChannelGlob) {
+					return true
+				}
+				return acl.matchGlob(excludedChannelGlob, channel)
 			}) {
 				return fmt.Errorf("not authorised to access channel &%s", channel)
 			}
@@ -404,47 +517,77 @@ func (acl *ACL) AuthorizeConnection(conn *net.Conn, cmd []string, command intern
 		return nil
 	}
 
-	if len(append(readKeys, writeKeys...)) > 0 {
+	if len(readKeys)+len(writeKeys) > 0 {
 		// 7. Check if nokeys is true
 		if connection.User.NoKeys {
 			return errors.New("not authorised to access any keys")
 		}
 
-		// 8. Check if readKeys are in IncludedReadKeys
-		if !slices.ContainsFunc(readKeys, func(key string) bool {
-			return slices.ContainsFunc(connection.User.IncludedReadKeys, func(readKeyGlob string) bool {
-				if acl.GlobPatterns[readKeyGlob].Match(key) {
-					return true
-				}
-				notAllowed = append(notAllowed, fmt.Sprintf("%s~%s", "%R", key))
-				return false
-			})
-		}) {
-			return fmt.Errorf("not authorised to access the following keys %+v", notAllowed)
+		// 8. Check if all the readKeys are in IncludedReadKeys
+		var notAllowedKeys []string
+		for _, key := range readKeys {
+			if !slices.ContainsFunc(connection.User.IncludedReadKeys, func(readKeyGlob string) bool {
+				return acl.matchGlob(readKeyGlob, key)
+			}) {
+				notAllowedKeys = append(notAllowedKeys, fmt.Sprintf("%s~%s", "%R", key))
+			}
 		}
 
-		// 9. Check if keys are in IncludedWriteKeys
-		if !slices.ContainsFunc(writeKeys, func(key string) bool {
-			return slices.ContainsFunc(connection.User.IncludedWriteKeys, func(writeKeyGlob string) bool {
-				if acl.GlobPatterns[writeKeyGlob].Match(key) {
-					return true
-				}
-				notAllowed = append(notAllowed, fmt.Sprintf("%s~%s", "%W", key))
-				return false
-			})
-		}) {
-			return fmt.Errorf("not authorised to access the following keys %+v", notAllowed)
+		// 9. Check if all the writeKeys are in IncludedWriteKeys
+		for _, key := range writeKeys {
+			if !slices.ContainsFunc(connection.User.IncludedWriteKeys, func(writeKeyGlob string) bool {
+				return acl.matchGlob(writeKeyGlob, key)
+			}) {
+				notAllowedKeys = append(notAllowedKeys, fmt.Sprintf("%s~%s", "%W", key))
+			}
+		}
+
+		if len(notAllowedKeys) > 0 {
+			return fmt.Errorf("not authorised to access the following keys %+v", notAllowedKeys)
+		}
+
+		// 10. The keys that the BY and GET patterns of SORT read are only known when the command runs,
+		// so the patterns require read access to all the keys.
+		if strings.EqualFold(comm, "sort") || strings.EqualFold(comm, "sort_ro") {
+			if option := sortPatternOption(cmd); option != "" && !slices.Contains(connection.User.IncludedReadKeys, "*") {
+				return fmt.Errorf("%s option of SORT denied due to insufficient ACL permissions", option)
+			}
 		}
 	}
 
 	return nil
 }
 
+// sortPatternOption returns the first option of the SORT command that reads keys through a pattern, either BY with a
+// pattern containing "*" or GET. Returns an empty string if the command has no such option.
+func sortPatternOption(cmd []string) string {
+	for i := 2; i < len(cmd); i++ {
+		switch strings.ToLower(cmd[i]) {
+		case "by":
+			if i+1 < len(cmd) && strings.Contains(cmd[i+1], "*") {
+				return "BY"
+			}
+			i += 1
+		case "get":
+			return "GET"
+		case "limit":
+			i += 2
+		case "store":
+			i += 1
+		}
+	}
+	return ""
+}
+
 func (acl *ACL) CompileGlobs() {
 	// Extract all the relevant globs from all the users
 	var allGlobs []string
 	var userGlobs []string
 	for _, user := range acl.Users {
+		userGlobs = append(userGlobs, user.IncludedCategories...)
+		userGlobs = append(userGlobs, user.ExcludedCategories...)
+		userGlobs = append(userGlobs, user.IncludedCommands...)
+		userGlobs = append(userGlobs, user.ExcludedCommands...)
 		userGlobs = append(userGlobs, user.IncludedPubSubChannels...)
 		userGlobs = append(userGlobs, user.ExcludedPubSubChannels...)
 		userGlobs = append(userGlobs, user.IncludedReadKeys...)
@@ -459,7 +602,162 @@ func (acl *ACL) CompileGlobs() {
 	// Compile the globs that have not been compiled yet
 	for _, g := range allGlobs {
 		if acl.GlobPatterns[g] == nil {
-			acl.GlobPatterns[g] = glob.MustCompile(g)
+			acl.GlobPatterns[g] = glob.Cached(g)
+		}
+	}
+}
+
+// clientAddress returns the host of the connection's remote address, so that all the connections of a client share
+// the same AUTH limit. Connections without a remote host (e.g. unix socket connections) share a single limit.
+func clientAddress(conn *net.Conn) string {
+	if conn == nil || *conn == nil || (*conn).RemoteAddr() == nil {
+		return ""
+	}
+	addr := (*conn).RemoteAddr().String()
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// matchGlob reports whether s matches the glob pattern of an ACL rule, using the pattern compiled by CompileGlobs.
+// A pattern that has not been compiled yet is taken from the shared glob cache, as the users might only be read locked.
+func (acl *ACL) matchGlob(pattern string, s string) bool {
+	if g := acl.GlobPatterns[pattern]; g != nil {
+		return g.Match(s)
+	}
+	return glob.Match(pattern, s)
+}
+
+// Load reads the users from the configured ACL config file into the in-memory ACL.
+// Users that already exist are merged with the loaded user when merge is true, otherwise they are replaced.
+// Users that do not exist yet are added.
+func (acl *ACL) Load(merge bool) error {
+	if acl.Config.AclConfig == "" {
+		return errors.New("acl config file not set")
+	}
+
+	b, err := os.ReadFile(acl.Config.AclConfig)
+	if err != nil {
+		return err
+	}
+
+	var users []*User
+	switch ext := path.Ext(acl.Config.AclConfig); ext {
+	case ".json":
+		if err = json.Unmarshal(b, &users); err != nil {
+			return err
+		}
+	case ".yaml", ".yml":
+		if err = yaml.Unmarshal(b, &users); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unsupported acl config file extension %s", ext)
+	}
+
+	acl.LockUsers()
+	defer acl.UnlockUsers()
+
+	for _, user := range users {
+		user.Normalise()
+		idx := slices.IndexFunc(acl.Users, func(u *User) bool {
+			return u.Username == user.Username
+		})
+		// Users that are not in the ACL yet are added as they are
+		if idx == -1 {
+			acl.Users = append(acl.Users, user)
+			continue
+		}
+		if merge {
+			acl.Users[idx].Merge(user)
+		} else {
+			acl.Users[idx].Replace(user)
+		}
+		acl.Users[idx].Normalise()
+	}
+
+	acl.CompileGlobs()
+
+	return nil
+}
+
+// Save writes the in-memory users to the configured ACL config file, encoded according to the file's extension.
+// The users are written to a temporary file in the same directory, which is then renamed over the config file.
+// This way, a failed or interrupted save never leaves a partially written config file behind.
+func (acl *ACL) Save() error {
+	if acl.Config.AclConfig == "" {
+		return errors.New("acl config file not set")
+	}
+
+	acl.saveMutex.Lock()
+	defer acl.saveMutex.Unlock()
+
+	var out []byte
+	var err error
+
+	acl.RLockUsers()
+	switch ext := path.Ext(acl.Config.AclConfig); ext {
+	case ".json":
+		out, err = json.MarshalIndent(acl.Users, "", "  ")
+	case ".yaml", ".yml":
+		out, err = yaml.Marshal(acl.Users)
+	default:
+		err = fmt.Errorf("unsupported acl config file extension %s", ext)
+	}
+	acl.RUnlockUsers()
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(acl.Config.AclConfig), filepath.Base(acl.Config.AclConfig)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// Only succeeds if the rename did not happen.
+		_ = os.Remove(f.Name())
+	}()
+
+	// Keep the permissions of the existing config file. New files keep the temp file's owner-only permissions.
+	if info, err := os.Stat(acl.Config.AclConfig); err == nil {
+		if err = f.Chmod(info.Mode().Perm()); err != nil {
+			_ = f.Close()
+			return err
+		}
+	}
+
+	if _, err = f.Write(out); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err = f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), acl.Config.AclConfig)
+}
+
+// scheduleSave notifies the background saver that the users have changed.
+// Notifications are coalesced, so a burst of changes results in as few rewrites as possible.
+func (acl *ACL) scheduleSave() {
+	if acl.saveSignal == nil {
+		return
+	}
+	select {
+	case acl.saveSignal <- struct{}{}:
+	default:
+	}
+}
+
+func (acl *ACL) autoSave() {
+	for range acl.saveSignal {
+		if err := acl.Save(); err != nil {
+			log.Printf("acl autosave error: %+v\n", err)
 		}
 	}
 }
@@ -15,15 +15,10 @@
 package acl
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/echovault/echovault/internal"
 	"github.com/echovault/echovault/internal/constants"
-	"gopkg.in/yaml.v3"
-	"log"
-	"os"
-	"path"
 	"slices"
 	"strings"
 )
@@ -52,109 +47,37 @@ func handleGetUser(params internal.HandlerFuncParams) ([]byte, error) {
 		return nil, errors.New("could not load ACL")
 	}
 
-	var user *User
-	userFound := false
-	for _, u := range acl.Users {
-		if u.Username == params.Command[2] {
-			user = u
-			userFound = true
-			break
-		}
-	}
+	acl.RLockUsers()
+	defer acl.RUnlockUsers()
 
-	if !userFound {
+	idx := slices.IndexFunc(acl.Users, func(u *User) bool {
+		return u.Username == params.Command[2]
+	})
+	if idx == -1 {
 		return nil, errors.New("user not found")
 	}
+	user := acl.Users[idx]
 
-	// username,
-	res := fmt.Sprintf("*12\r\n+username\r\n*1\r\n+%s", user.Username)
-
-	// flags
-	var flags []string
-	if user.Enabled {
-		flags = append(flags, "on")
-	} else {
-		flags = append(flags, "off")
-	}
-	if user.NoPassword {
-		flags = append(flags, "nopass")
-	}
-	if user.NoKeys {
-		flags = append(flags, "nokeys")
-	}
-
-	res = res + fmt.Sprintf("\r\n+flags\r\n*%d", len(flags))
-	for _, flag := range flags {
-		res = fmt.Sprintf("%s\r\n+%s", res, flag)
-	}
-
-	// categories
-	res = res + fmt.Sprintf("\r\n+categories\r\n*%d", len(user.IncludedCategories)+len(user.ExcludedCategories))
-	for _, category := range user.IncludedCategories {
-		if category == "*" {
-			res = res + fmt.Sprintf("\r\n++@all")
-			continue
-		}
-		res = res + fmt.Sprintf("\r\n++@%s", category)
-	}
-	for _, category := range user.ExcludedCategories {
-		if category == "*" {
-			res = res + fmt.Sprintf("\r\n+-@all")
-			continue
-		}
-		res = res + fmt.Sprintf("\r\n+-@%s", category)
-	}
-
-	// commands
-	res = res + fmt.Sprintf("\r\n+commands\r\n*%d", len(user.IncludedCommands)+len(user.ExcludedCommands))
-	for _, command := range user.IncludedCommands {
-		if command == "*" {
-			res = res + fmt.Sprintf("\r\n++all")
-			continue
-		}
-		res = res + fmt.Sprintf("\r\n++%s", command)
-	}
-	for _, command := range user.ExcludedCommands {
-		if command == "*" {
-			res = res + fmt.Sprintf("\r\n+-all")
-			continue
-		}
-		res = res + fmt.Sprintf("\r\n+-%s", command)
+	sections := []struct {
+		name  string
+		rules []string
+	}{
+		{name: "username", rules: []string{user.Username}},
+		{name: "flags", rules: user.FlagRules()},
+		{name: "passwords", rules: user.PasswordRules()},
+		{name: "categories", rules: user.CategoryRules()},
+		{name: "commands", rules: user.CommandRules()},
+		{name: "keys", rules: user.KeyRules()},
+		{name: "channels", rules: user.ChannelRules()},
 	}
 
-	// keys
-	allKeys := user.IncludedReadKeys
-	for _, key := range append(user.IncludedWriteKeys, user.IncludedReadKeys...) {
-		if !slices.Contains(allKeys, key) {
-			allKeys = append(allKeys, key)
+	res := fmt.Sprintf("*%d\r\n", len(sections)*2)
+	for _, section := range sections {
+		res += fmt.Sprintf("+%s\r\n*%d\r\n", section.name, len(section.rules))
+		for _, rule := range section.rules {
+			res += fmt.Sprintf("$%d\r\n%s\r\n", len(rule), rule)
 		}
 	}
-	res = res + fmt.Sprintf("\r\n+keys\r\n*%d", len(allKeys))
-	for _, key := range allKeys {
-		switch {
-		case slices.Contains(user.IncludedWriteKeys, key) && slices.Contains(user.IncludedReadKeys, key):
-			// Key is RW
-			res = res + fmt.Sprintf("\r\n+%s~%s", "%RW", key)
-		case slices.Contains(user.IncludedWriteKeys, key):
-			// Keys is W-Only
-			res = res + fmt.Sprintf("\r\n+%s~%s", "%W", key)
-		case slices.Contains(user.IncludedReadKeys, key):
-			// Key is R-Only
-			res = res + fmt.Sprintf("\r\n+%s~%s", "%R", key)
-		}
-	}
-
-	// channels
-	res = res + fmt.Sprintf("\r\n+channels\r\n*%d",
-		len(user.IncludedPubSubChannels)+len(user.ExcludedPubSubChannels))
-	for _, channel := range user.IncludedPubSubChannels {
-		res = res + fmt.Sprintf("\r\n++&%s", channel)
-	}
-	for _, channel := range user.ExcludedPubSubChannels {
-		res = res + fmt.Sprintf("\r\n+-&%s", channel)
-	}
-
-	res += "\r\n"
 
 	return []byte(res), nil
 }
@@ -190,6 +113,7 @@ func handleCat(params internal.HandlerFuncParams) ([]byte, error) {
 			cats = append(cats, key)
 			length += 1
 		}
+		slices.Sort(cats)
 		res := fmt.Sprintf("*%d", length)
 		for i, cat := range cats {
 			res = fmt.Sprintf("%s\r\n+%s", res, cat)
@@ -204,6 +128,7 @@ func handleCat(params internal.HandlerFuncParams) ([]byte, error) {
 		var res string
 		for category, commands := range categories {
 			if strings.EqualFold(category, params.Command[2]) {
+				slices.Sort(commands)
 				res = fmt.Sprintf("*%d", len(commands))
 				for i, command := range commands {
 					res = fmt.Sprintf("%s\r\n+%s", res, command)
@@ -224,6 +149,8 @@ func handleUsers(params internal.HandlerFuncParams) ([]byte, error) {
 	if !ok {
 		return nil, errors.New("could not load ACL")
 	}
+	acl.RLockUsers()
+	defer acl.RUnlockUsers()
 	res := fmt.Sprintf("*%d", len(acl.Users))
 	for _, user := range acl.Users {
 		res += fmt.Sprintf("\r\n$%d\r\n%s", len(user.Username), user.Username)
@@ -262,8 +189,14 @@ func handleWhoAmI(params internal.HandlerFuncParams) ([]byte, error) {
 	if !ok {
 		return nil, errors.New("could not load ACL")
 	}
-	connectionInfo := acl.Connections[params.Connection]
-	return []byte(fmt.Sprintf("+%s\r\n", connectionInfo.User.Username)), nil
+	acl.RLockUsers()
+	defer acl.RUnlockUsers()
+	// Embedded calls are not associated with a connection, and run as the default user.
+	username := "default"
+	if connectionInfo, ok := acl.Connections[params.Connection]; ok && connectionInfo.User != nil {
+		username = connectionInfo.User.Username
+	}
+	return []byte(fmt.Sprintf("+%s\r\n", username)), nil
 }
 
 func handleList(params internal.HandlerFuncParams) ([]byte, error) {
@@ -274,90 +207,13 @@ func handleList(params internal.HandlerFuncParams) ([]byte, error) {
 	if !ok {
 		return nil, errors.New("could not load ACL")
 	}
+	acl.RLockUsers()
+	defer acl.RUnlockUsers()
 	res := fmt.Sprintf("*%d", len(acl.Users))
-	s := ""
 	for _, user := range acl.Users {
-		s = user.Username
-		// User enabled
-		if user.Enabled {
-			s += " on"
-		} else {
-			s += " off"
-		}
-		// NoPassword
-		if user.NoPassword {
-			s += " nopass"
-		}
-		// No keys
-		if user.NoKeys {
-			s += " nokeys"
-		}
-		// Passwords
-		for _, password := range user.Passwords {
-			if strings.EqualFold(password.PasswordType, "plaintext") {
-				s += fmt.Sprintf(" >%s", password.PasswordValue)
-			}
-			if strings.EqualFold(password.PasswordType, "SHA256") {
-				s += fmt.Sprintf(" #%s", password.PasswordValue)
-			}
-		}
-		// Included categories
-		for _, category := range user.IncludedCategories {
-			if category == "*" {
-				s += " +@all"
-				continue
-			}
-			s += fmt.Sprintf(" +@%s", category)
-		}
-		// Excluded categories
-		for _, category := range user.ExcludedCategories {
-			if category == "*" {
-				s += " -@all"
-				continue
-			}
-			s += fmt.Sprintf(" -@%s", category)
-		}
-		// Included commands
-		for _, command := range user.IncludedCommands {
-			if command == "*" {
-				s += " +all"
-				continue
-			}
-			s += fmt.Sprintf(" +%s", command)
-		}
-		// Excluded commands
-		for _, command := range user.ExcludedCommands {
-			if command == "*" {
-				s += " -all"
-				continue
-			}
-			s += fmt.Sprintf(" -%s", command)
-		}
-		// Included read keys
-		for _, key := range user.IncludedReadKeys {
-			if slices.Contains(user.IncludedWriteKeys, key) {
-				s += fmt.Sprintf(" %s~%s", "%RW", key)
-				continue
-			}
-			s += fmt.Sprintf(" %s~%s", "%R", key)
-		}
-		// Included write keys
-		for _, key := range user.IncludedReadKeys {
-			if !slices.Contains(user.IncludedReadKeys, key) {
-				s += fmt.Sprintf(" %s~%s", "%W", key)
-			}
-		}
-		// Included Pub/Sub channels
-		for _, channel := range user.IncludedPubSubChannels {
-			s += fmt.Sprintf(" +&%s", channel)
-		}
-		// Excluded Pup/Sub channels
-		for _, channel := range user.ExcludedPubSubChannels {
-			s += fmt.Sprintf(" -&%s", channel)
-		}
+		s := strings.Join(append([]string{user.Username}, user.Rules()...), " ")
 		res = res + fmt.Sprintf("\r\n$%d\r\n%s", len(s), s)
 	}
-
 	res = res + "\r\n"
 	return []byte(res), nil
 }
@@ -372,58 +228,18 @@ func handleLoad(params internal.HandlerFuncParams) ([]byte, error) {
 		return nil, errors.New("could not load ACL")
 	}
 
-	acl.LockUsers()
-	defer acl.RUnlockUsers()
-
-	f, err := os.Open(acl.Config.AclConfig)
-	if err != nil {
-		return nil, err
-	}
-
-	defer func() {
-		if err := f.Close(); err != nil {
-			log.Println(err)
-		}
-	}()
-
-	ext := path.Ext(f.Name())
-
-	var users []*User
-
-	if ext == ".json" {
-		if err := json.NewDecoder(f).Decode(&users); err != nil {
-			return nil, err
-		}
-	}
-
-	if ext == ".yaml" || ext == ".yml" {
-		if err := yaml.NewDecoder(f).Decode(&users); err != nil {
-			return nil, err
-		}
+	var merge bool
+	switch strings.ToLower(params.Command[2]) {
+	case "merge":
+		merge = true
+	case "replace":
+		merge = false
+	default:
+		return nil, fmt.Errorf("expected MERGE or REPLACE, got %s", params.Command[2])
 	}
 
-	// Normalise each user
-	for _, user := range users {
-		user.Normalise()
-		// Traverse the list of users.
-		userFound := false
-		for _, u := range acl.Users {
-			if u.Username == user.Username {
-				userFound = true
-				// If we have a user with the current username and are in merge mode, merge the two users.
-				if strings.EqualFold(params.Command[2], "merge") {
-					u.Merge(user)
-				} else {
-					// If we have a user with the current username and are in replace mode, merge the two users.
-					u.Replace(user)
-				}
-				break
-			}
-		}
-		// If the no user with current loaded username is already in acl list, then append the user to the list
-		if !userFound {
-			acl.Users = append(acl.Users, user)
-		}
+	if err := acl.Load(merge); err != nil {
+		return nil, err
 	}
 
 	return []byte(constants.OkResponse), nil
@@ -439,48 +255,7 @@ func handleSave(params internal.HandlerFuncParams) ([]byte, error) {
 		return nil, errors.New("could not load ACL")
 	}
 
-	acl.RLockUsers()
-	acl.RUnlockUsers()
-
-	f, err := os.OpenFile(acl.Config.AclConfig, os.O_WRONLY|os.O_CREATE, os.ModeAppend)
-	if err != nil {
-		return nil, err
-	}
-
-	defer func() {
-		if err := f.Close(); err != nil {
-			log.Println(err)
-		}
-	}()
-
-	ext := path.Ext(f.Name())
-
-	if ext == ".json" {
-		// Write to JSON config file
-		out, err := json.Marshal(acl.Users)
-		if err != nil {
-			return nil, err
-		}
-		_, err = f.Write(out)
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	if ext == ".yaml" || ext == ".yml" {
-		// Write to yaml file
-		out, err := yaml.Marshal(acl.Users)
-		if err != nil {
-			return nil, err
-		}
-		_, err = f.Write(out)
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	err = f.Sync()
-	if err != nil {
+	if err := acl.Save(); err != nil {
 		return nil, err
 	}
 
@@ -0,0 +1,76 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package acl
+
+import (
+	"sync"
+	"time"
+)
+
+// tokenBucket allows up to rate events per second, with bursts of up to rate events.
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// refill adds the tokens accumulated since the last refill, up to a capacity of rate tokens.
+func (bucket *tokenBucket) refill(now time.Time, rate int) {
+	bucket.tokens = min(float64(rate), bucket.tokens+now.Sub(bucket.last).Seconds()*float64(rate))
+	bucket.last = now
+}
+
+// rateLimiter holds a token bucket for each key (e.g. a username or a client address).
+type rateLimiter struct {
+	mutex   sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// allow takes a token from the bucket of the key and reports whether one was available.
+// A rate of 0 or less disables the limit.
+func (limiter *rateLimiter) allow(key string, rate int, now time.Time) bool {
+	if rate <= 0 {
+		return true
+	}
+
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+
+	bucket, ok := limiter.buckets[key]
+	if !ok {
+		// Full buckets don't limit anything, so drop them before adding a new bucket to keep the map small.
+		if len(limiter.buckets) >= 1024 {
+			for k, b := range limiter.buckets {
+				if b.tokens+now.Sub(b.last).Seconds()*float64(rate) >= float64(rate) {
+					delete(limiter.buckets, k)
+				}
+			}
+		}
+		bucket = &tokenBucket{tokens: float64(rate), last: now}
+		limiter.buckets[key] = bucket
+	}
+
+	bucket.refill(now, rate)
+	if bucket.tokens < 1 {
+		return false
+	}
+	bucket.tokens -= 1
+	return true
+}
@@ -15,7 +15,9 @@
 package acl
 
 import (
+	"fmt"
 	"slices"
+	"strconv"
 	"strings"
 )
 
@@ -48,6 +50,9 @@ type User struct {
 
 	IncludedPubSubChannels []string `json:"IncludedPubSubChannels" yaml:"IncludedPubSubChannels"`
 	ExcludedPubSubChannels []string `json:"ExcludedPubSubChannels" yaml:"ExcludedPubSubChannels"`
+
+	RateLimit      int `json:"RateLimit,omitempty" yaml:"RateLimit,omitempty"`           // Maximum commands per second across the user's connections. 0 means unlimited.
+	MaxConnections int `json:"MaxConnections,omitempty" yaml:"MaxConnections,omitempty"` // Maximum connections authenticated as the user. 0 means unlimited.
 }
 
 func (user *User) Normalise() {
@@ -109,6 +114,23 @@ func RemoveDuplicateEntries(entries []string, allAlias string) (res []string) {
 
 func (user *User) UpdateUser(cmd []string) error {
 	for _, str := range cmd {
+		// Parse limits
+		if len(str) > 10 && strings.EqualFold(str[0:10], "ratelimit:") {
+			limit, err := strconv.Atoi(str[10:])
+			if err != nil || limit < 0 {
+				return fmt.Errorf("invalid rate limit %s", str[10:])
+			}
+			user.RateLimit = limit
+			continue
+		}
+		if len(str) > 9 && strings.EqualFold(str[0:9], "maxconns:") {
+			limit, err := strconv.Atoi(str[9:])
+			if err != nil || limit < 0 {
+				return fmt.Errorf("invalid max connections %s", str[9:])
+			}
+			user.MaxConnections = limit
+			continue
+		}
 		// Parse enabled
 		if strings.EqualFold(str, "on") {
 			user.Enabled = true
@@ -153,6 +175,14 @@ func (user *User) UpdateUser(cmd []string) error {
 			user.IncludedCategories = []string{"*"}
 			continue
 		}
+		if strings.EqualFold(str, "+@all") {
+			user.IncludedCategories = append(user.IncludedCategories, "*")
+			continue
+		}
+		if strings.EqualFold(str, "-@all") {
+			user.ExcludedCategories = append(user.ExcludedCategories, "*")
+			continue
+		}
 		if len(str) > 3 && str[1] == '@' {
 			if str[0] == '+' {
 				user.IncludedCategories = append(user.IncludedCategories, str[2:])
@@ -207,6 +237,14 @@ func (user *User) UpdateUser(cmd []string) error {
 			user.ExcludedCommands = []string{}
 			continue
 		}
+		if strings.EqualFold(str, "+all") {
+			user.IncludedCommands = append(user.IncludedCommands, "*")
+			continue
+		}
+		if strings.EqualFold(str, "-all") {
+			user.ExcludedCommands = append(user.ExcludedCommands, "*")
+			continue
+		}
 		if len(str) > 2 && !slices.Contains([]uint8{'&', '@'}, str[1]) {
 			if str[0] == '+' {
 				user.IncludedCommands = append(user.IncludedCommands, str[1:])
@@ -240,8 +278,8 @@ func (user *User) UpdateUser(cmd []string) error {
 			user.IncludedCategories = []string{}
 			user.ExcludedCategories = []string{"*"}
 		}
-		// If resetkeys is provided, reset all keys that the user can access
-		if strings.EqualFold(str, "resetkeys") {
+		// If resetkeys or nokeys is provided, reset all keys that the user can access
+		if strings.EqualFold(str, "resetkeys") || strings.EqualFold(str, "nokeys") {
 			user.IncludedReadKeys = []string{}
 			user.IncludedWriteKeys = []string{}
 			user.NoKeys = true
@@ -255,8 +293,123 @@ func (user *User) UpdateUser(cmd []string) error {
 	return nil
 }
 
+// Rules returns the user's profile in ACL rule syntax, as accepted by ACL SETUSER.
+// The rules are ordered as flags, passwords, categories, commands, keys and channels.
+func (user *User) Rules() []string {
+	var rules []string
+	rules = append(rules, user.FlagRules()...)
+	rules = append(rules, user.PasswordRules()...)
+	rules = append(rules, user.CategoryRules()...)
+	rules = append(rules, user.CommandRules()...)
+	rules = append(rules, user.KeyRules()...)
+	rules = append(rules, user.ChannelRules()...)
+	return rules
+}
+
+// FlagRules returns the user's flags: "on" or "off", followed by "nopass" and "nokeys" when they're set,
+// and "ratelimit:<n>" and "maxconns:<n>" when the user is limited.
+func (user *User) FlagRules() []string {
+	flags := []string{"off"}
+	if user.Enabled {
+		flags[0] = "on"
+	}
+	if user.NoPassword {
+		flags = append(flags, "nopass")
+	}
+	if user.NoKeys {
+		flags = append(flags, "nokeys")
+	}
+	if user.RateLimit > 0 {
+		flags = append(flags, fmt.Sprintf("ratelimit:%d", user.RateLimit))
+	}
+	if user.MaxConnections > 0 {
+		flags = append(flags, fmt.Sprintf("maxconns:%d", user.MaxConnections))
+	}
+	return flags
+}
+
+// PasswordRules returns ">password" for each plaintext password and "#hash" for each SHA256 password.
+func (user *User) PasswordRules() []string {
+	rules := make([]string, 0, len(user.Passwords))
+	for _, password := range user.Passwords {
+		switch {
+		case strings.EqualFold(password.PasswordType, PasswordPlainText):
+			rules = append(rules, fmt.Sprintf(">%s", password.PasswordValue))
+		case strings.EqualFold(password.PasswordType, PasswordSHA256):
+			rules = append(rules, fmt.Sprintf("#%s", password.PasswordValue))
+		}
+	}
+	return rules
+}
+
+// CategoryRules returns "+@category" for each included category and "-@category" for each excluded category.
+// The "*" wildcard is returned as "+@all" or "-@all".
+func (user *User) CategoryRules() []string {
+	rules := make([]string, 0, len(user.IncludedCategories)+len(user.ExcludedCategories))
+	for _, category := range user.IncludedCategories {
+		rules = append(rules, "+@"+allAlias(category))
+	}
+	for _, category := range user.ExcludedCategories {
+		rules = append(rules, "-@"+allAlias(category))
+	}
+	return rules
+}
+
+// CommandRules returns "+command" for each included command and "-command" for each excluded command.
+// The "*" wildcard is returned as "+all" or "-all".
+func (user *User) CommandRules() []string {
+	rules := make([]string, 0, len(user.IncludedCommands)+len(user.ExcludedCommands))
+	for _, command := range user.IncludedCommands {
+		rules = append(rules, "+"+allAlias(command))
+	}
+	for _, command := range user.ExcludedCommands {
+		rules = append(rules, "-"+allAlias(command))
+	}
+	return rules
+}
+
+// KeyRules returns "%RW~pattern" for each key pattern the user can read and write,
+// followed by "%R~pattern" and "%W~pattern" for the patterns the user can only read or only write.
+func (user *User) KeyRules() []string {
+	rules := make([]string, 0, len(user.IncludedReadKeys)+len(user.IncludedWriteKeys))
+	for _, key := range user.IncludedReadKeys {
+		if slices.Contains(user.IncludedWriteKeys, key) {
+			rules = append(rules, "%RW~"+key)
+			continue
+		}
+		rules = append(rules, "%R~"+key)
+	}
+	for _, key := range user.IncludedWriteKeys {
+		if !slices.Contains(user.IncludedReadKeys, key) {
+			rules = append(rules, "%W~"+key)
+		}
+	}
+	return rules
+}
+
+// ChannelRules returns "+&channel" for each included channel and "-&channel" for each excluded channel.
+func (user *User) ChannelRules() []string {
+	rules := make([]string, 0, len(user.IncludedPubSubChannels)+len(user.ExcludedPubSubChannels))
+	for _, channel := range user.IncludedPubSubChannels {
+		rules = append(rules, "+&"+channel)
+	}
+	for _, channel := range user.ExcludedPubSubChannels {
+		rules = append(rules, "-&"+channel)
+	}
+	return rules
+}
+
+func allAlias(rule string) string {
+	if rule == "*" {
+		return "all"
+	}
+	return rule
+}
+
 func (user *User) Merge(new *User) {
 	user.Enabled = new.Enabled
+	user.RateLimit = new.RateLimit
+	user.MaxConnections = new.MaxConnections
 	user.NoKeys = new.NoKeys
 	user.NoPassword = new.NoPassword
 	user.Passwords = append(user.Passwords, new.Passwords...)
@@ -273,6 +426,8 @@ func (user *User) Merge(new *User) {
 
 func (user *User) Replace(new *User) {
 	user.Enabled = new.Enabled
+	user.RateLimit = new.RateLimit
+	user.MaxConnections = new.MaxConnections
 	user.NoKeys = new.NoKeys
 	user.NoPassword = new.NoPassword
 	user.Passwords = new.Passwords
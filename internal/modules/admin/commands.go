@@ -15,13 +15,21 @@
 package admin
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/echovault/echovault/internal"
 	"github.com/echovault/echovault/internal/constants"
-	"github.com/gobwas/glob"
+	"github.com/echovault/echovault/internal/glob"
+	"github.com/echovault/echovault/internal/modules/set"
+	"github.com/echovault/echovault/internal/modules/sorted_set"
+	"github.com/echovault/echovault/internal/modules/stream"
+	"math"
+	"net"
 	"slices"
+	"strconv"
 	"strings"
+	"time"
 )
 
 func handleGetAllCommands(params internal.HandlerFuncParams) ([]byte, error) {
@@ -138,7 +146,7 @@ func handleCommandList(params internal.HandlerFuncParams) ([]byte, error) {
 		} else if strings.EqualFold("PATTERN", params.Command[3]) {
 			// Pattern filter
 			commands := params.GetAllCommands()
-			g := glob.MustCompile(params.Command[4])
+			g := glob.Compile(params.Command[4])
 			for _, command := range commands {
 				if command.SubCommands != nil && len(command.SubCommands) > 0 {
 					for _, subcommand := range command.SubCommands {
@@ -189,6 +197,609 @@ func handleCommandDocs(params internal.HandlerFuncParams) ([]byte, error) {
 	return []byte("*0\r\n"), nil
 }
 
+func handleLatencyHistogram(params internal.HandlerFuncParams) ([]byte, error) {
+	histograms := params.GetLatencyRegistry().Histograms(params.Command[2:]...)
+
+	commands := make([]string, 0, len(histograms))
+	for command := range histograms {
+		commands = append(commands, command)
+	}
+	slices.Sort(commands)
+
+	res := fmt.Sprintf("*%d\r\n", len(commands)*2)
+	for _, command := range commands {
+		histogram := histograms[command]
+		bounds := histogram.Bounds()
+
+		res += fmt.Sprintf("$%d\r\n%s\r\n", len(command), command)
+		res += fmt.Sprintf("*4\r\n+calls\r\n:%d\r\n+histogram_usec\r\n*%d\r\n", histogram.Calls, len(bounds)*2)
+		// Each bucket is reported with the cumulative count of calls up to and including that bucket.
+		var cumulative uint64
+		for _, bound := range bounds {
+			cumulative += histogram.Buckets[bound]
+			res += fmt.Sprintf(":%d\r\n:%d\r\n", bound, cumulative)
+		}
+	}
+
+	return []byte(res), nil
+}
+
+func handleDebugSelfTest(params internal.HandlerFuncParams) ([]byte, error) {
+	if len(params.Command) != 2 {
+		return nil, errors.New(constants.WrongArgsResponse)
+	}
+
+	result, err := params.GetSelfTester().Run()
+	if err != nil {
+		return nil, err
+	}
+
+	fields := []struct {
+		name  string
+		value int64
+	}{
+		{name: "fsync.avg.usec", value: result.FsyncAvg.Microseconds()},
+		{name: "fsync.max.usec", value: result.FsyncMax.Microseconds()},
+		{name: "memory.bandwidth.bytes.per.sec", value: int64(result.MemoryBandwidth)},
+		{name: "lock.ops.per.sec", value: int64(result.LockThroughput)},
+	}
+
+	res := fmt.Sprintf("*%d\r\n", len(fields)*2)
+	for _, field := range fields {
+		res += fmt.Sprintf("$%d\r\n%s\r\n:%d\r\n", len(field.name), field.name, field.value)
+	}
+
+	return []byte(res), nil
+}
+
+func handleSlowLogGet(params internal.HandlerFuncParams) ([]byte, error) {
+	if len(params.Command) > 3 {
+		return nil, errors.New(constants.WrongArgsResponse)
+	}
+
+	count := 10
+	if len(params.Command) == 3 {
+		var err error
+		count, err = strconv.Atoi(params.Command[2])
+		if err != nil || count < -1 {
+			return nil, errors.New("count should be greater than or equal to -1")
+		}
+	}
+
+	entries := params.GetSlowLog().Get(count)
+	res := fmt.Sprintf("*%d\r\n", len(entries))
+	for _, entry := range entries {
+		res += fmt.Sprintf("*6\r\n:%d\r\n:%d\r\n:%d\r\n*%d\r\n",
+			entry.ID, entry.Time.Unix(), entry.Duration.Microseconds(), len(entry.Args))
+		for _, arg := range entry.Args {
+			res += fmt.Sprintf("$%d\r\n%s\r\n", len(arg), arg)
+		}
+		res += fmt.Sprintf("$%d\r\n%s\r\n$%d\r\n%s\r\n",
+			len(entry.ClientAddr), entry.ClientAddr, len(entry.ClientName), entry.ClientName)
+	}
+
+	return []byte(res), nil
+}
+
+func handleSlowLogLen(params internal.HandlerFuncParams) ([]byte, error) {
+	if len(params.Command) != 2 {
+		return nil, errors.New(constants.WrongArgsResponse)
+	}
+	return []byte(fmt.Sprintf(":%d\r\n", params.GetSlowLog().Len())), nil
+}
+
+func handleSlowLogReset(params internal.HandlerFuncParams) ([]byte, error) {
+	if len(params.Command) != 2 {
+		return nil, errors.New(constants.WrongArgsResponse)
+	}
+	params.GetSlowLog().Reset()
+	return []byte(constants.OkResponse), nil
+}
+
+func handleMemoryStats(params internal.HandlerFuncParams) ([]byte, error) {
+	if len(params.Command) != 2 {
+		return nil, errors.New(constants.WrongArgsResponse)
+	}
+
+	stats := params.GetMemoryStats()
+	fields := []struct {
+		name  string
+		value int64
+	}{
+		{name: "total.allocated", value: int64(stats.TotalAllocated)},
+		{name: "keys.count", value: int64(stats.KeysCount)},
+		{name: "keys.peak", value: int64(stats.KeysPeak)},
+		{name: "defrag.runs", value: int64(stats.DefragRuns)},
+		{name: "defrag.released.keys", value: int64(stats.DefragReleasedKeys)},
+		{name: "defrag.last.run", value: stats.DefragLastRun},
+	}
+
+	res := fmt.Sprintf("*%d\r\n", len(fields)*2)
+	for _, field := range fields {
+		res += fmt.Sprintf("$%d\r\n%s\r\n:%d\r\n", len(field.name), field.name, field.value)
+	}
+
+	return []byte(res), nil
+}
+
+// infoStatus returns the INFO representation of the result of a persistence operation.
+func infoStatus(ok bool) string {
+	if ok {
+		return "ok"
+	}
+	return "err"
+}
+
+// infoFlag returns the INFO representation of a flag, 1 if it's set and 0 otherwise.
+func infoFlag(set bool) int {
+	if set {
+		return 1
+	}
+	return 0
+}
+
+func handleInfo(params internal.HandlerFuncParams) ([]byte, error) {
+	sections := map[string]func() []string{
+		"server": func() []string {
+			info := params.GetServerInfo()
+			return []string{
+				"# Server",
+				fmt.Sprintf("echovault_version:%s", info.Version),
+				fmt.Sprintf("server_mode:%s", info.Mode),
+				fmt.Sprintf("server_id:%s", info.ServerID),
+				fmt.Sprintf("process_id:%d", info.ProcessID),
+				fmt.Sprintf("tcp_port:%d", info.TCPPort),
+				fmt.Sprintf("uptime_in_seconds:%d", int64(info.Uptime.Seconds())),
+				fmt.Sprintf("uptime_in_days:%d", int64(info.Uptime.Hours()/24)),
+			}
+		},
+		"clients": func() []string {
+			info := params.GetServerInfo()
+			return []string{
+				"# Clients",
+				fmt.Sprintf("connected_clients:%d", info.ConnectedClients),
+			}
+		},
+		"memory": func() []string {
+			stats := params.GetMemoryStats()
+			return []string{
+				"# Memory",
+				fmt.Sprintf("used_memory:%d", stats.TotalAllocated),
+				fmt.Sprintf("used_memory_sys:%d", stats.SystemMemory),
+				fmt.Sprintf("maxmemory:%d", stats.MaxMemory),
+				fmt.Sprintf("maxmemory_policy:%s", stats.EvictionPolicy),
+			}
+		},
+		"stats": func() []string {
+			stats := params.GetMetrics().Stats()
+			return []string{
+				"# Stats",
+				fmt.Sprintf("total_connections_received:%d", stats.ConnectionsReceived),
+				fmt.Sprintf("total_commands_processed:%d", stats.CommandsProcessed),
+				fmt.Sprintf("instantaneous_ops_per_sec:%d", int64(math.Round(stats.InstantaneousOps))),
+				fmt.Sprintf("keyspace_hits:%d", stats.KeyspaceHits),
+				fmt.Sprintf("keyspace_misses:%d", stats.KeyspaceMisses),
+				fmt.Sprintf("expired_keys:%d", stats.ExpiredKeys),
+				fmt.Sprintf("evicted_keys:%d", stats.EvictedKeys),
+				fmt.Sprintf("total_accept_errors:%d", stats.AcceptErrors),
+			}
+		},
+		"keyspace": func() []string {
+			stats := params.GetMemoryStats()
+			lines := []string{"# Keyspace"}
+			// As there's a single keyspace, it's reported as db0. The line is omitted when the keyspace is empty.
+			if stats.KeysCount > 0 {
+				lines = append(lines, fmt.Sprintf("db0:keys=%d,expires=%d", stats.KeysCount, stats.VolatileKeysCount))
+			}
+			return lines
+		},
+		"persistence": func() []string {
+			info := params.GetPersistenceInfo()
+			return []string{
+				"# Persistence",
+				fmt.Sprintf("loading:%d", infoFlag(info.Loading)),
+				fmt.Sprintf("loading_loaded_perc:%.2f", info.LoadingPercentage),
+				fmt.Sprintf("rdb_bgsave_in_progress:%d", infoFlag(info.SnapshotInProgress)),
+				fmt.Sprintf("rdb_last_save_time:%d", info.LastSnapshotTime/1000),
+				fmt.Sprintf("rdb_last_save_status:%s", infoStatus(info.LastSnapshotSucceeded)),
+				fmt.Sprintf("rdb_integrity_check_status:%s", infoStatus(info.SnapshotIntegrityOK)),
+				fmt.Sprintf("aof_rewrite_in_progress:%d", infoFlag(info.RewriteInProgress)),
+				fmt.Sprintf("aof_last_bgrewrite_status:%s", infoStatus(info.LastRewriteSucceeded)),
+				fmt.Sprintf("aof_current_size:%d", info.AOFCurrentSize),
+				fmt.Sprintf("aof_base_size:%d", info.AOFBaseSize),
+				fmt.Sprintf("aof_last_fsync_time:%d", info.AOFLastSyncTime/1000),
+				fmt.Sprintf("aof_integrity_check_status:%s", infoStatus(info.AOFIntegrityOK)),
+				fmt.Sprintf("aof_queue_depth:%d", info.AOFQueueDepth),
+				fmt.Sprintf("aof_rejected_writes:%d", info.AOFRejectedWrites),
+				fmt.Sprintf("write_stall_protection:%d", infoFlag(info.StallProtection)),
+			}
+		},
+		"replication": func() []string {
+			info := params.GetReplicationInfo()
+			lines := []string{"# Replication", fmt.Sprintf("role:%s", info.Role)}
+			// Followers report the leader's client address so that clients can redirect writes to it.
+			if host, port, err := net.SplitHostPort(info.MasterAddr); err == nil {
+				lines = append(lines, fmt.Sprintf("master_host:%s", host), fmt.Sprintf("master_port:%s", port))
+			}
+			return append(lines,
+				fmt.Sprintf("connected_slaves:%d", info.ConnectedReplicas),
+				fmt.Sprintf("master_replid:%s", info.ReplicationID),
+				fmt.Sprintf("master_repl_offset:%d", info.Offset),
+				fmt.Sprintf("raft_term:%d", info.Term),
+			)
+		},
+		"selftest": func() []string {
+			result, ok := params.GetSelfTester().Last()
+			if !ok {
+				return []string{"# Selftest", "selftest_last_run:0"}
+			}
+			return []string{
+				"# Selftest",
+				fmt.Sprintf("selftest_last_run:%d", result.Time.Unix()),
+				fmt.Sprintf("selftest_fsync_avg_usec:%d", result.FsyncAvg.Microseconds()),
+				fmt.Sprintf("selftest_fsync_max_usec:%d", result.FsyncMax.Microseconds()),
+				fmt.Sprintf("selftest_memory_bandwidth_bytes_per_sec:%d", result.MemoryBandwidth),
+				fmt.Sprintf("selftest_lock_ops_per_sec:%d", result.LockThroughput),
+			}
+		},
+		"commandstats": func() []string {
+			histograms := params.GetLatencyRegistry().Histograms()
+			commands := make([]string, 0, len(histograms))
+			for command := range histograms {
+				commands = append(commands, command)
+			}
+			slices.Sort(commands)
+
+			lines := []string{"# Commandstats"}
+			for _, command := range commands {
+				histogram := histograms[command]
+				usec := histogram.Sum.Microseconds()
+				lines = append(lines, fmt.Sprintf("cmdstat_%s:calls=%d,usec=%d,usec_per_call=%.2f,failed_calls=%d",
+					command, histogram.Calls, usec, float64(usec)/float64(max(histogram.Calls, 1)), histogram.Failed))
+			}
+			return lines
+		},
+	}
+	// The default sections are returned with no arguments or with "default".
+	// "commandstats" is only returned when it's requested, or with "all" or "everything".
+	order := []string{"server", "clients", "memory", "persistence", "stats", "replication", "selftest", "keyspace"}
+	all := append(order[:len(order):len(order)], "commandstats")
+
+	requested := order
+	if len(params.Command) > 1 {
+		requested = []string{}
+		for _, section := range params.Command[1:] {
+			section = strings.ToLower(section)
+			if section == "default" {
+				for _, s := range order {
+					if !slices.Contains(requested, s) {
+						requested = append(requested, s)
+					}
+				}
+				continue
+			}
+			if section == "all" || section == "everything" {
+				requested = all
+				break
+			}
+			if _, ok := sections[section]; ok && !slices.Contains(requested, section) {
+				requested = append(requested, section)
+			}
+		}
+	}
+
+	var lines []string
+	for i, section := range requested {
+		if i > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, sections[section]()...)
+	}
+
+	res := strings.Join(lines, "\r\n")
+	if len(lines) > 0 {
+		res += "\r\n"
+	}
+	return []byte(fmt.Sprintf("$%d\r\n%s\r\n", len(res), res)), nil
+}
+
+func handleConfigGet(params internal.HandlerFuncParams) ([]byte, error) {
+	if len(params.Command) < 3 {
+		return nil, errors.New(constants.WrongArgsResponse)
+	}
+
+	parameters := params.GetConfigParameters()
+
+	// Collect the parameters that match any of the provided glob patterns.
+	var names []string
+	for _, pattern := range params.Command[2:] {
+		g := glob.Compile(strings.ToLower(pattern))
+		for name := range parameters {
+			if g.Match(name) && !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	slices.Sort(names)
+
+	res := fmt.Sprintf("*%d\r\n", len(names)*2)
+	for _, name := range names {
+		res += fmt.Sprintf("$%d\r\n%s\r\n$%d\r\n%s\r\n", len(name), name, len(parameters[name]), parameters[name])
+	}
+
+	return []byte(res), nil
+}
+
+func handleConfigSet(params internal.HandlerFuncParams) ([]byte, error) {
+	if len(params.Command) < 4 || len(params.Command[2:])%2 != 0 {
+		return nil, errors.New(constants.WrongArgsResponse)
+	}
+
+	for i := 2; i < len(params.Command); i += 2 {
+		if err := params.SetConfigParameter(params.Command[i], params.Command[i+1]); err != nil {
+			return nil, err
+		}
+	}
+
+	return []byte(constants.OkResponse), nil
+}
+
+func handleConfigResetStat(params internal.HandlerFuncParams) ([]byte, error) {
+	if len(params.Command) != 2 {
+		return nil, errors.New(constants.WrongArgsResponse)
+	}
+	params.GetLatencyRegistry().Reset()
+	params.GetMetrics().Reset()
+	return []byte(constants.OkResponse), nil
+}
+
+func handleExport(params internal.HandlerFuncParams) ([]byte, error) {
+	match := "*"
+	switch len(params.Command) {
+	case 1:
+	case 3:
+		if !strings.EqualFold(params.Command[1], "match") {
+			return nil, fmt.Errorf("expected MATCH, got %s", strings.ToUpper(params.Command[1]))
+		}
+		match = params.Command[2]
+	default:
+		return nil, errors.New(constants.WrongArgsResponse)
+	}
+
+	// Collect the keys in batches so that the key index is not locked for the whole iteration.
+	g := glob.Compile(match)
+	var keys []string
+	var cursor uint64
+	for {
+		var batch []string
+		batch, cursor = params.ScanKeys(cursor, 1024, g.Match)
+		keys = append(keys, batch...)
+		if cursor == 0 {
+			break
+		}
+	}
+	// Export the keys in lexicographic order so that exports of the same keyspace are identical.
+	slices.Sort(keys)
+
+	now := params.GetClock().Now()
+	var res strings.Builder
+	count := 0
+	for _, key := range keys {
+		// Skip the keys that have expired or have been deleted since they were scanned.
+		if !params.KeyExists(params.Context, key) {
+			continue
+		}
+		if _, err := params.KeyRLock(params.Context, key); err != nil {
+			if !params.KeyExists(params.Context, key) {
+				continue
+			}
+			return nil, err
+		}
+		value := params.GetValue(params.Context, key)
+		record := internal.ExportRecord{
+			Key:   internal.EncodeBinaryString(key),
+			Type:  internal.TypeName(value),
+			TTL:   -1,
+			Value: internal.ExportValue(value),
+		}
+		if expireAt := params.GetExpiry(params.Context, key); expireAt != (time.Time{}) {
+			record.TTL = max(expireAt.Sub(now).Milliseconds(), 0)
+		}
+		params.KeyRUnlock(params.Context, key)
+
+		b, err := json.Marshal(record)
+		if err != nil {
+			return nil, err
+		}
+		res.WriteString(fmt.Sprintf("$%d\r\n%s\r\n", len(b), b))
+		count += 1
+	}
+
+	return []byte(fmt.Sprintf("*%d\r\n%s", count, res.String())), nil
+}
+
+// importRecord is a line of EXPORT output as read by IMPORT.
+// The value is decoded once the type of the record is known.
+type importRecord struct {
+	Key   string          `json:"key"`
+	Type  string          `json:"type"`
+	TTL   int64           `json:"ttl"`
+	Value json.RawMessage `json:"value"`
+}
+
+type importOptions struct {
+	replace bool
+	match   *glob.Glob // Nil when no MATCH pattern was provided.
+}
+
+// parseImportCommand parses the options of the IMPORT command and the records that match the MATCH pattern.
+// The records start at the first argument that is a JSON object, so they can't be mistaken for options.
+func parseImportCommand(cmd []string) (importOptions, []importRecord, error) {
+	if len(cmd) < 2 {
+		return importOptions{}, nil, errors.New(constants.WrongArgsResponse)
+	}
+
+	options := importOptions{}
+	i := 1
+	for ; i < len(cmd) && !strings.HasPrefix(cmd[i], "{"); i++ {
+		switch strings.ToLower(cmd[i]) {
+		case "replace":
+			options.replace = true
+		case "match":
+			if i+1 >= len(cmd) {
+				return importOptions{}, nil, errors.New(constants.WrongArgsResponse)
+			}
+			options.match = glob.Compile(cmd[i+1])
+			i++
+		default:
+			return importOptions{}, nil, fmt.Errorf("expected REPLACE or MATCH, got %s", strings.ToUpper(cmd[i]))
+		}
+	}
+
+	records := make([]importRecord, 0, len(cmd)-i)
+	for ; i < len(cmd); i++ {
+		var record importRecord
+		if err := json.Unmarshal([]byte(cmd[i]), &record); err != nil {
+			return importOptions{}, nil, fmt.Errorf("invalid import record: %+v", err)
+		}
+		if record.Key == "" {
+			return importOptions{}, nil, errors.New("invalid import record: missing key")
+		}
+		key, err := internal.DecodeBinaryString(record.Key)
+		if err != nil {
+			return importOptions{}, nil, fmt.Errorf("invalid import record: %+v", err)
+		}
+		record.Key = key
+		if options.match != nil && !options.match.Match(record.Key) {
+			continue
+		}
+		records = append(records, record)
+	}
+
+	return options, records, nil
+}
+
+// importValue decodes the value of a record into the representation of its type in the store.
+func importValue(record importRecord) (interface{}, error) {
+	var err error
+	switch record.Type {
+	case "string":
+		var value string
+		if err = json.Unmarshal(record.Value, &value); err == nil {
+			return internal.AdaptType(value), nil
+		}
+	case "hash":
+		var value map[string]string
+		if err = json.Unmarshal(record.Value, &value); err == nil {
+			hash := make(map[string]interface{}, len(value))
+			for field, v := range value {
+				hash[field] = internal.AdaptType(v)
+			}
+			return hash, nil
+		}
+	case "list":
+		var value []string
+		if err = json.Unmarshal(record.Value, &value); err == nil {
+			list := make([]interface{}, len(value))
+			for i, v := range value {
+				list[i] = internal.AdaptType(v)
+			}
+			return list, nil
+		}
+	case "set":
+		var value []string
+		if err = json.Unmarshal(record.Value, &value); err == nil {
+			return set.NewSet(value), nil
+		}
+	case "zset":
+		var value []struct {
+			Member string `json:"member"`
+			Score  string `json:"score"`
+		}
+		if err = json.Unmarshal(record.Value, &value); err == nil {
+			members := make([]sorted_set.MemberParam, len(value))
+			for i, m := range value {
+				score, err := strconv.ParseFloat(m.Score, 64)
+				if err != nil {
+					return nil, fmt.Errorf("invalid score %s for key %s", m.Score, record.Key)
+				}
+				members[i] = sorted_set.MemberParam{Value: sorted_set.Value(m.Member), Score: sorted_set.Score(score)}
+			}
+			return sorted_set.NewSortedSet(members), nil
+		}
+	case "stream":
+		var value []struct {
+			ID     string   `json:"id"`
+			Fields []string `json:"fields"`
+		}
+		if err = json.Unmarshal(record.Value, &value); err == nil {
+			s := stream.NewStream()
+			for _, entry := range value {
+				id, err := stream.ParseID(entry.ID, 0)
+				if err != nil {
+					return nil, fmt.Errorf("invalid stream ID %s for key %s", entry.ID, record.Key)
+				}
+				if err = s.Add(id, entry.Fields); err != nil {
+					return nil, fmt.Errorf("invalid stream entry %s for key %s: %+v", entry.ID, record.Key, err)
+				}
+			}
+			return s, nil
+		}
+	default:
+		return nil, fmt.Errorf("unsupported type %s for key %s", record.Type, record.Key)
+	}
+	return nil, fmt.Errorf("invalid %s value for key %s: %+v", record.Type, record.Key, err)
+}
+
+func handleImport(params internal.HandlerFuncParams) ([]byte, error) {
+	options, records, err := parseImportCommand(params.Command)
+	if err != nil {
+		return nil, err
+	}
+
+	// Decode all the values before writing any key, so that an invalid record doesn't leave a partial import behind.
+	values := make([]interface{}, len(records))
+	for i, record := range records {
+		if values[i], err = importValue(record); err != nil {
+			return nil, err
+		}
+		if !options.replace && params.KeyExists(params.Context, record.Key) {
+			return nil, fmt.Errorf("key %s already exists", record.Key)
+		}
+	}
+
+	now := params.GetClock().Now()
+	count := 0
+	for i, record := range records {
+		// A TTL of 0 means the key expired while it was being exported.
+		if record.TTL == 0 {
+			continue
+		}
+		if !params.KeyExists(params.Context, record.Key) {
+			_, err = params.CreateKeyAndLock(params.Context, record.Key)
+		} else {
+			_, err = params.KeyLock(params.Context, record.Key)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err = params.SetValue(params.Context, record.Key, values[i]); err != nil {
+			params.KeyUnlock(params.Context, record.Key)
+			return nil, err
+		}
+		// SetValue keeps the expiry of a replaced key, so the expiry is always set from the record.
+		expireAt := time.Time{}
+		if record.TTL > 0 {
+			expireAt = now.Add(time.Duration(record.TTL) * time.Millisecond)
+		}
+		params.SetExpiry(params.Context, record.Key, expireAt, false)
+		params.KeyUnlock(params.Context, record.Key)
+		count += 1
+	}
+
+	return []byte(fmt.Sprintf(":%d\r\n", count)), nil
+}
+
 func Commands() []internal.Command {
 	return []internal.Command{
 		{
@@ -268,6 +879,270 @@ Allows for filtering by ACL category or glob pattern.`,
 				},
 			},
 		},
+		{
+			Command:     "latency",
+			Module:      constants.AdminModule,
+			Categories:  []string{},
+			Description: "Commands pertaining to command latency",
+			Sync:        false,
+			KeyExtractionFunc: func(cmd []string) (internal.KeyExtractionFuncResult, error) {
+				return internal.KeyExtractionFuncResult{
+					Channels:  make([]string, 0),
+					ReadKeys:  make([]string, 0),
+					WriteKeys: make([]string, 0),
+				}, nil
+			},
+			SubCommands: []internal.SubCommand{
+				{
+					Command:    "histogram",
+					Module:     constants.AdminModule,
+					Categories: []string{constants.AdminCategory, constants.SlowCategory, constants.DangerousCategory},
+					Description: `(LATENCY HISTOGRAM [command ...]) Get the latency distribution of the provided commands.
+If no commands are provided, the distribution of all the commands that have been executed is returned.
+Sub-commands are specified as "command|subcommand".`,
+					Sync: false,
+					KeyExtractionFunc: func(cmd []string) (internal.KeyExtractionFuncResult, error) {
+						return internal.KeyExtractionFuncResult{
+							Channels:  make([]string, 0),
+							ReadKeys:  make([]string, 0),
+							WriteKeys: make([]string, 0),
+						}, nil
+					},
+					HandlerFunc: handleLatencyHistogram,
+				},
+			},
+		},
+		{
+			Command:     "slowlog",
+			Module:      constants.AdminModule,
+			Categories:  []string{},
+			Description: "Commands pertaining to the slow log of commands that took longer than slowlog-log-slower-than to execute",
+			Sync:        false,
+			KeyExtractionFunc: func(cmd []string) (internal.KeyExtractionFuncResult, error) {
+				return internal.KeyExtractionFuncResult{
+					Channels:  make([]string, 0),
+					ReadKeys:  make([]string, 0),
+					WriteKeys: make([]string, 0),
+				}, nil
+			},
+			SubCommands: []internal.SubCommand{
+				{
+					Command:    "get",
+					Module:     constants.AdminModule,
+					Categories: []string{constants.AdminCategory, constants.SlowCategory, constants.DangerousCategory},
+					Description: `(SLOWLOG GET [count]) Get the most recent entries of the slow log, newest first.
+10 entries are returned by default, and -1 returns all the entries. Each entry is made up of the entry ID,
+the unix time the command started, the execution time in microseconds, the command arguments,
+and the address and name of the client.`,
+					Sync: false,
+					KeyExtractionFunc: func(cmd []string) (internal.KeyExtractionFuncResult, error) {
+						return internal.KeyExtractionFuncResult{
+							Channels:  make([]string, 0),
+							ReadKeys:  make([]string, 0),
+							WriteKeys: make([]string, 0),
+						}, nil
+					},
+					HandlerFunc: handleSlowLogGet,
+				},
+				{
+					Command:     "len",
+					Module:      constants.AdminModule,
+					Categories:  []string{constants.AdminCategory, constants.SlowCategory, constants.DangerousCategory},
+					Description: `(SLOWLOG LEN) Get the number of entries in the slow log.`,
+					Sync:        false,
+					KeyExtractionFunc: func(cmd []string) (internal.KeyExtractionFuncResult, error) {
+						return internal.KeyExtractionFuncResult{
+							Channels:  make([]string, 0),
+							ReadKeys:  make([]string, 0),
+							WriteKeys: make([]string, 0),
+						}, nil
+					},
+					HandlerFunc: handleSlowLogLen,
+				},
+				{
+					Command:     "reset",
+					Module:      constants.AdminModule,
+					Categories:  []string{constants.AdminCategory, constants.SlowCategory, constants.DangerousCategory},
+					Description: `(SLOWLOG RESET) Remove all the entries from the slow log.`,
+					Sync:        false,
+					KeyExtractionFunc: func(cmd []string) (internal.KeyExtractionFuncResult, error) {
+						return internal.KeyExtractionFuncResult{
+							Channels:  make([]string, 0),
+							ReadKeys:  make([]string, 0),
+							WriteKeys: make([]string, 0),
+						}, nil
+					},
+					HandlerFunc: handleSlowLogReset,
+				},
+			},
+		},
+		{
+			Command:     "config",
+			Module:      constants.AdminModule,
+			Categories:  []string{},
+			Description: "Commands pertaining to the server configuration",
+			Sync:        false,
+			KeyExtractionFunc: func(cmd []string) (internal.KeyExtractionFuncResult, error) {
+				return internal.KeyExtractionFuncResult{
+					Channels:  make([]string, 0),
+					ReadKeys:  make([]string, 0),
+					WriteKeys: make([]string, 0),
+				}, nil
+			},
+			SubCommands: []internal.SubCommand{
+				{
+					Command:    "get",
+					Module:     constants.AdminModule,
+					Categories: []string{constants.AdminCategory, constants.SlowCategory, constants.DangerousCategory},
+					Description: `(CONFIG GET parameter [parameter ...]) Get the values of the configuration parameters
+that match the provided glob patterns. Only the parameters that can be updated at runtime are returned.`,
+					Sync: false,
+					KeyExtractionFunc: func(cmd []string) (internal.KeyExtractionFuncResult, error) {
+						return internal.KeyExtractionFuncResult{
+							Channels:  make([]string, 0),
+							ReadKeys:  make([]string, 0),
+							WriteKeys: make([]string, 0),
+						}, nil
+					},
+					HandlerFunc: handleConfigGet,
+				},
+				{
+					Command:    "set",
+					Module:     constants.AdminModule,
+					Categories: []string{constants.AdminCategory, constants.SlowCategory, constants.DangerousCategory},
+					Description: `(CONFIG SET parameter value [parameter value ...]) Update configuration parameters at runtime.
+The supported parameters are snapshot-threshold, snapshot-interval and data-dir.`,
+					Sync: false,
+					KeyExtractionFunc: func(cmd []string) (internal.KeyExtractionFuncResult, error) {
+						return internal.KeyExtractionFuncResult{
+							Channels:  make([]string, 0),
+							ReadKeys:  make([]string, 0),
+							WriteKeys: make([]string, 0),
+						}, nil
+					},
+					HandlerFunc: handleConfigSet,
+				},
+				{
+					Command:    "resetstat",
+					Module:     constants.AdminModule,
+					Categories: []string{constants.AdminCategory, constants.SlowCategory, constants.DangerousCategory},
+					Description: `(CONFIG RESETSTAT) Reset the command statistics reported by INFO commandstats,
+the counters reported by INFO stats and the latency histograms reported by LATENCY HISTOGRAM.`,
+					Sync: false,
+					KeyExtractionFunc: func(cmd []string) (internal.KeyExtractionFuncResult, error) {
+						return internal.KeyExtractionFuncResult{
+							Channels:  make([]string, 0),
+							ReadKeys:  make([]string, 0),
+							WriteKeys: make([]string, 0),
+						}, nil
+					},
+					HandlerFunc: handleConfigResetStat,
+				},
+			},
+		},
+		{
+			Command:     "memory",
+			Module:      constants.AdminModule,
+			Categories:  []string{},
+			Description: "Commands pertaining to memory usage",
+			Sync:        false,
+			KeyExtractionFunc: func(cmd []string) (internal.KeyExtractionFuncResult, error) {
+				return internal.KeyExtractionFuncResult{
+					Channels:  make([]string, 0),
+					ReadKeys:  make([]string, 0),
+					WriteKeys: make([]string, 0),
+				}, nil
+			},
+			SubCommands: []internal.SubCommand{
+				{
+					Command:    "stats",
+					Module:     constants.AdminModule,
+					Categories: []string{constants.AdminCategory, constants.SlowCategory},
+					Description: `(MEMORY STATS) Get the memory usage of the server, the number of keys,
+and the number of deleted keys released by keyspace compaction.`,
+					Sync: false,
+					KeyExtractionFunc: func(cmd []string) (internal.KeyExtractionFuncResult, error) {
+						return internal.KeyExtractionFuncResult{
+							Channels:  make([]string, 0),
+							ReadKeys:  make([]string, 0),
+							WriteKeys: make([]string, 0),
+						}, nil
+					},
+					HandlerFunc: handleMemoryStats,
+				},
+			},
+		},
+		{
+			Command:    "info",
+			Module:     constants.AdminModule,
+			Categories: []string{constants.AdminCategory, constants.SlowCategory, constants.DangerousCategory},
+			Description: `(INFO [section [section ...]]) Get information and statistics about the server.
+The sections are server, clients, memory, persistence, stats, replication, selftest, keyspace and commandstats.
+commandstats is only returned when requested or with "all".`,
+			Sync: false,
+			KeyExtractionFunc: func(cmd []string) (internal.KeyExtractionFuncResult, error) {
+				return internal.KeyExtractionFuncResult{
+					Channels:  make([]string, 0),
+					ReadKeys:  make([]string, 0),
+					WriteKeys: make([]string, 0),
+				}, nil
+			},
+			HandlerFunc: handleInfo,
+		},
+		{
+			Command:     "debug",
+			Module:      constants.AdminModule,
+			Categories:  []string{},
+			Description: "Commands for debugging the server",
+			Sync:        false,
+			KeyExtractionFunc: func(cmd []string) (internal.KeyExtractionFuncResult, error) {
+				return internal.KeyExtractionFuncResult{
+					Channels:  make([]string, 0),
+					ReadKeys:  make([]string, 0),
+					WriteKeys: make([]string, 0),
+				}, nil
+			},
+			SubCommands: []internal.SubCommand{
+				{
+					Command:    "selftest",
+					Module:     constants.AdminModule,
+					Categories: []string{constants.AdminCategory, constants.SlowCategory, constants.DangerousCategory},
+					Description: `(DEBUG SELFTEST) Measure the fsync latency of the data directory, the memory bandwidth
+and the lock throughput of the host. The results are returned and reported in INFO selftest.`,
+					Sync: false,
+					KeyExtractionFunc: func(cmd []string) (internal.KeyExtractionFuncResult, error) {
+						return internal.KeyExtractionFuncResult{
+							Channels:  make([]string, 0),
+							ReadKeys:  make([]string, 0),
+							WriteKeys: make([]string, 0),
+						}, nil
+					},
+					HandlerFunc: handleDebugSelfTest,
+				},
+				{
+					Command:    "change-repl-id",
+					Module:     constants.AdminModule,
+					Categories: []string{constants.AdminCategory, constants.SlowCategory, constants.DangerousCategory},
+					Description: `(DEBUG CHANGE-REPL-ID) Replace the replication ID of this node with a new random one.
+In cluster mode, only the replication ID of this node is changed.`,
+					Sync: false,
+					KeyExtractionFunc: func(cmd []string) (internal.KeyExtractionFuncResult, error) {
+						return internal.KeyExtractionFuncResult{
+							Channels:  make([]string, 0),
+							ReadKeys:  make([]string, 0),
+							WriteKeys: make([]string, 0),
+						}, nil
+					},
+					HandlerFunc: func(params internal.HandlerFuncParams) ([]byte, error) {
+						if len(params.Command) != 2 {
+							return nil, errors.New(constants.WrongArgsResponse)
+						}
+						params.ChangeReplicationID()
+						return []byte(constants.OkResponse), nil
+					},
+				},
+			},
+		},
 		{
 			Command:     "save",
 			Module:      constants.AdminModule,
@@ -309,6 +1184,48 @@ Allows for filtering by ACL category or glob pattern.`,
 				return []byte(fmt.Sprintf(":%d\r\n", msec)), nil
 			},
 		},
+		{
+			Command:    "export",
+			Module:     constants.AdminModule,
+			Categories: []string{constants.AdminCategory, constants.SlowCategory, constants.DangerousCategory},
+			Description: `(EXPORT [MATCH pattern]) Export the keys that match the pattern in lexicographic order.
+Each key is returned as a line of JSON with the key, its type, its remaining time to live in milliseconds (-1 if the key
+has no expiry) and its value.`,
+			Sync: false,
+			KeyExtractionFunc: func(cmd []string) (internal.KeyExtractionFuncResult, error) {
+				return internal.KeyExtractionFuncResult{
+					Channels:  make([]string, 0),
+					ReadKeys:  make([]string, 0),
+					WriteKeys: make([]string, 0),
+				}, nil
+			},
+			HandlerFunc: handleExport,
+		},
+		{
+			Command:    "import",
+			Module:     constants.AdminModule,
+			Categories: []string{constants.AdminCategory, constants.WriteCategory, constants.SlowCategory, constants.DangerousCategory},
+			Description: `(IMPORT [REPLACE] [MATCH pattern] record [record ...]) Restore the keys from lines of EXPORT output.
+Only the records whose key matches the pattern are imported. Without REPLACE, the import fails if any of the keys
+already exists. Returns the number of keys imported.`,
+			Sync: true,
+			KeyExtractionFunc: func(cmd []string) (internal.KeyExtractionFuncResult, error) {
+				_, records, err := parseImportCommand(cmd)
+				if err != nil {
+					return internal.KeyExtractionFuncResult{}, err
+				}
+				keys := make([]string, len(records))
+				for i, record := range records {
+					keys[i] = record.Key
+				}
+				return internal.KeyExtractionFuncResult{
+					Channels:  make([]string, 0),
+					ReadKeys:  make([]string, 0),
+					WriteKeys: keys,
+				}, nil
+			},
+			HandlerFunc: handleImport,
+		},
 		{
 			Command:     "rewriteaof",
 			Module:      constants.AdminModule,
@@ -15,10 +15,16 @@
 package connection
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/echovault/echovault/internal"
 	"github.com/echovault/echovault/internal/constants"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 )
 
 func handlePing(params internal.HandlerFuncParams) ([]byte, error) {
@@ -32,6 +38,423 @@ func handlePing(params internal.HandlerFuncParams) ([]byte, error) {
 	}
 }
 
+func handleHello(params internal.HandlerFuncParams) ([]byte, error) {
+	registry, ok := params.GetClientRegistry().(*ClientRegistry)
+	if !ok {
+		return nil, errors.New("could not load client registry")
+	}
+
+	var proto int
+	if len(params.Command) >= 2 {
+		var err error
+		if proto, err = strconv.Atoi(params.Command[1]); err != nil {
+			return nil, errors.New("protocol version is not an integer or out of range")
+		}
+		if proto != 2 && proto != 3 {
+			return nil, fmt.Errorf("unsupported protocol version %d", proto)
+		}
+	}
+
+	// Parse the AUTH username password and SETNAME name options that follow the protocol version.
+	var auth []string
+	var name *string
+	for i := 2; i < len(params.Command); i++ {
+		switch strings.ToLower(params.Command[i]) {
+		default:
+			return nil, fmt.Errorf("syntax error in HELLO option '%s'", params.Command[i])
+		case "auth":
+			if i+2 >= len(params.Command) {
+				return nil, fmt.Errorf("syntax error in HELLO option '%s'", params.Command[i])
+			}
+			auth = []string{"AUTH", params.Command[i+1], params.Command[i+2]}
+			i += 2
+		case "setname":
+			if i+1 >= len(params.Command) {
+				return nil, fmt.Errorf("syntax error in HELLO option '%s'", params.Command[i])
+			}
+			if err := validateClientName(params.Command[i+1]); err != nil {
+				return nil, err
+			}
+			name = &params.Command[i+1]
+			i += 1
+		}
+	}
+
+	// Authenticate before changing the connection, so that a failed HELLO leaves the connection as it was.
+	if auth != nil {
+		var acl authenticator
+		if params.GetACL != nil {
+			acl, _ = params.GetACL().(authenticator)
+		}
+		if acl == nil {
+			return nil, errors.New("could not load ACL")
+		}
+		if err := acl.AuthenticateConnection(params.Context, params.Connection, auth); err != nil {
+			return nil, err
+		}
+	}
+	if name != nil {
+		if err := registry.SetName(params.Context, *name); err != nil {
+			return nil, err
+		}
+	}
+	// RESP3 clients receive the same replies as RESP2 clients, which RESP3 clients accept,
+	// with the addition of push messages.
+	if proto != 0 && proto != registry.GetProtocol(params.Context) {
+		if err := registry.SetProtocol(params.Context, proto); err != nil {
+			return nil, err
+		}
+	}
+	proto = registry.GetProtocol(params.Context)
+
+	info := params.GetReplicationInfo()
+	// Followers are reported as replicas, the same way Redis does in its HELLO response.
+	role := info.Role
+	if role == "slave" {
+		role = "replica"
+	}
+
+	var modules []string
+	for _, command := range params.GetAllCommands() {
+		if !slices.Contains(modules, command.Module) {
+			modules = append(modules, command.Module)
+		}
+	}
+	slices.Sort(modules)
+
+	res := "*12\r\n"
+	if proto == 3 {
+		res = "%6\r\n"
+	}
+	for _, field := range [][2]string{
+		{"server", constants.ServerName},
+		{"version", constants.Version},
+	} {
+		res += fmt.Sprintf("$%d\r\n%s\r\n$%d\r\n%s\r\n", len(field[0]), field[0], len(field[1]), field[1])
+	}
+	res += fmt.Sprintf("$5\r\nproto\r\n:%d\r\n", proto)
+	for _, field := range [][2]string{
+		{"mode", info.Mode},
+		{"role", role},
+	} {
+		res += fmt.Sprintf("$%d\r\n%s\r\n$%d\r\n%s\r\n", len(field[0]), field[0], len(field[1]), field[1])
+	}
+	res += fmt.Sprintf("$7\r\nmodules\r\n*%d\r\n", len(modules))
+	for _, module := range modules {
+		res += fmt.Sprintf("$%d\r\n%s\r\n", len(module), module)
+	}
+
+	return []byte(res), nil
+}
+
+func handleClientNoTouch(params internal.HandlerFuncParams) ([]byte, error) {
+	if len(params.Command) != 3 {
+		return nil, errors.New(constants.WrongArgsResponse)
+	}
+	registry, ok := params.GetClientRegistry().(*ClientRegistry)
+	if !ok {
+		return nil, errors.New("could not load client registry")
+	}
+	switch strings.ToLower(params.Command[2]) {
+	default:
+		return nil, fmt.Errorf("unknown option %s", params.Command[2])
+	case "on":
+		if err := registry.SetNoTouch(params.Context, true); err != nil {
+			return nil, err
+		}
+	case "off":
+		if err := registry.SetNoTouch(params.Context, false); err != nil {
+			return nil, err
+		}
+	}
+	return []byte(constants.OkResponse), nil
+}
+
+func handleClientNoEvict(params internal.HandlerFuncParams) ([]byte, error) {
+	if len(params.Command) != 3 {
+		return nil, errors.New(constants.WrongArgsResponse)
+	}
+	registry, ok := params.GetClientRegistry().(*ClientRegistry)
+	if !ok {
+		return nil, errors.New("could not load client registry")
+	}
+	switch strings.ToLower(params.Command[2]) {
+	default:
+		return nil, fmt.Errorf("unknown option %s", params.Command[2])
+	case "on":
+		if err := registry.SetNoEvict(params.Context, true); err != nil {
+			return nil, err
+		}
+	case "off":
+		if err := registry.SetNoEvict(params.Context, false); err != nil {
+			return nil, err
+		}
+	}
+	return []byte(constants.OkResponse), nil
+}
+
+func handleClientTracking(params internal.HandlerFuncParams) ([]byte, error) {
+	if len(params.Command) != 3 {
+		return nil, errors.New(constants.WrongArgsResponse)
+	}
+	registry, ok := params.GetClientRegistry().(*ClientRegistry)
+	if !ok {
+		return nil, errors.New("could not load client registry")
+	}
+	switch strings.ToLower(params.Command[2]) {
+	default:
+		return nil, fmt.Errorf("unknown option %s", params.Command[2])
+	case "on":
+		if err := registry.SetTracking(params.Context, true); err != nil {
+			return nil, err
+		}
+	case "off":
+		if err := registry.SetTracking(params.Context, false); err != nil {
+			return nil, err
+		}
+	}
+	return []byte(constants.OkResponse), nil
+}
+
+func handleClientUnpause(params internal.HandlerFuncParams) ([]byte, error) {
+	if len(params.Command) != 2 {
+		return nil, errors.New(constants.WrongArgsResponse)
+	}
+	registry, ok := params.GetClientRegistry().(*ClientRegistry)
+	if !ok {
+		return nil, errors.New("could not load client registry")
+	}
+	registry.Unpause()
+	return []byte(constants.OkResponse), nil
+}
+
+// authenticator is implemented by the ACL module to authenticate a connection with AUTH username password.
+type authenticator interface {
+	AuthenticateConnection(ctx context.Context, conn *net.Conn, cmd []string) error
+}
+
+// validateClientName returns an error if the name cannot be used as a client name.
+// Names are space separated in CLIENT LIST, so they're restricted to printable characters without spaces.
+func validateClientName(name string) error {
+	for _, c := range name {
+		if c <= ' ' || c > '~' {
+			return errors.New("client names cannot contain spaces, newlines or special characters")
+		}
+	}
+	return nil
+}
+
+// userLookup is implemented by the ACL module to report the user that a connection is authenticated as.
+type userLookup interface {
+	Username(conn *net.Conn) string
+}
+
+// clientUser returns the user that the client's connection is associated with, or an empty string if it's unknown.
+func clientUser(params internal.HandlerFuncParams, client ClientInfo) string {
+	if params.GetACL == nil {
+		return ""
+	}
+	acl, ok := params.GetACL().(userLookup)
+	if !ok {
+		return ""
+	}
+	return acl.Username(client.Conn)
+}
+
+func handleClientSetName(params internal.HandlerFuncParams) ([]byte, error) {
+	if len(params.Command) != 3 {
+		return nil, errors.New(constants.WrongArgsResponse)
+	}
+	registry, ok := params.GetClientRegistry().(*ClientRegistry)
+	if !ok {
+		return nil, errors.New("could not load client registry")
+	}
+	if err := validateClientName(params.Command[2]); err != nil {
+		return nil, err
+	}
+	if err := registry.SetName(params.Context, params.Command[2]); err != nil {
+		return nil, err
+	}
+	return []byte(constants.OkResponse), nil
+}
+
+func handleClientGetName(params internal.HandlerFuncParams) ([]byte, error) {
+	if len(params.Command) != 2 {
+		return nil, errors.New(constants.WrongArgsResponse)
+	}
+	registry, ok := params.GetClientRegistry().(*ClientRegistry)
+	if !ok {
+		return nil, errors.New("could not load client registry")
+	}
+	name := registry.GetName(params.Context)
+	if name == "" {
+		return []byte("$-1\r\n"), nil
+	}
+	return []byte(fmt.Sprintf("$%d\r\n%s\r\n", len(name), name)), nil
+}
+
+func handleClientList(params internal.HandlerFuncParams) ([]byte, error) {
+	registry, ok := params.GetClientRegistry().(*ClientRegistry)
+	if !ok {
+		return nil, errors.New("could not load client registry")
+	}
+
+	var ids []string
+	switch {
+	case len(params.Command) == 2:
+	case len(params.Command) > 3 && strings.EqualFold(params.Command[2], "id"):
+		ids = params.Command[3:]
+	default:
+		return nil, errors.New(constants.WrongArgsResponse)
+	}
+
+	now := time.Now()
+	var res strings.Builder
+	for _, client := range registry.Clients() {
+		if ids != nil && !slices.Contains(ids, client.ID) {
+			continue
+		}
+		res.WriteString(formatClient(params, client, now))
+	}
+
+	return []byte(fmt.Sprintf("$%d\r\n%s\r\n", res.Len(), res.String())), nil
+}
+
+func handleClientInfo(params internal.HandlerFuncParams) ([]byte, error) {
+	if len(params.Command) != 2 {
+		return nil, errors.New(constants.WrongArgsResponse)
+	}
+	registry, ok := params.GetClientRegistry().(*ClientRegistry)
+	if !ok {
+		return nil, errors.New("could not load client registry")
+	}
+
+	id := getConnectionID(params.Context)
+	for _, client := range registry.Clients() {
+		if client.ID == id {
+			info := formatClient(params, client, time.Now())
+			return []byte(fmt.Sprintf("$%d\r\n%s\r\n", len(info), info)), nil
+		}
+	}
+	return nil, errors.New("client not found")
+}
+
+// formatClient returns the line that describes the client in CLIENT LIST and CLIENT INFO.
+func formatClient(params internal.HandlerFuncParams, client ClientInfo, now time.Time) string {
+	return fmt.Sprintf("id=%s addr=%s name=%s age=%d idle=%d flags=%s resp=%d user=%s cmd=%s\n",
+		client.ID, client.Addr, client.Name,
+		int64(now.Sub(client.CreatedAt).Seconds()), int64(now.Sub(client.LastActive).Seconds()),
+		client.Flags, client.Proto, clientUser(params, client), client.LastCommand)
+}
+
+func handleClientKill(params internal.HandlerFuncParams) ([]byte, error) {
+	if len(params.Command) < 3 {
+		return nil, errors.New(constants.WrongArgsResponse)
+	}
+	registry, ok := params.GetClientRegistry().(*ClientRegistry)
+	if !ok {
+		return nil, errors.New("could not load client registry")
+	}
+	clients := registry.Clients()
+
+	// The old form, CLIENT KILL addr:port, kills the client with the address and replies with OK.
+	if len(params.Command) == 3 {
+		for _, client := range clients {
+			if client.Addr == params.Command[2] {
+				registry.Kill(client.ID)
+				return []byte(constants.OkResponse), nil
+			}
+		}
+		return nil, errors.New("no such client")
+	}
+
+	// The new form, CLIENT KILL <filter> <value> [<filter> <value> ...], kills the clients that match all the filters
+	// and replies with the number of clients killed. The calling client is skipped unless SKIPME is no.
+	if len(params.Command[2:])%2 != 0 {
+		return nil, errors.New(constants.WrongArgsResponse)
+	}
+	var filters []func(client ClientInfo) bool
+	skipMe := true
+	for i := 2; i < len(params.Command); i += 2 {
+		value := params.Command[i+1]
+		switch strings.ToLower(params.Command[i]) {
+		default:
+			return nil, fmt.Errorf("unknown filter %s", params.Command[i])
+		case "id":
+			filters = append(filters, func(client ClientInfo) bool { return client.ID == value })
+		case "addr":
+			filters = append(filters, func(client ClientInfo) bool { return client.Addr == value })
+		case "user":
+			filters = append(filters, func(client ClientInfo) bool { return clientUser(params, client) == value })
+		case "skipme":
+			switch strings.ToLower(value) {
+			default:
+				return nil, errors.New("skipme must be yes or no")
+			case "yes":
+				skipMe = true
+			case "no":
+				skipMe = false
+			}
+		}
+	}
+
+	self := getConnectionID(params.Context)
+	var ids []string
+	for _, client := range clients {
+		if skipMe && client.ID == self {
+			continue
+		}
+		if !slices.ContainsFunc(filters, func(filter func(client ClientInfo) bool) bool {
+			return !filter(client)
+		}) {
+			ids = append(ids, client.ID)
+		}
+	}
+
+	return []byte(fmt.Sprintf(":%d\r\n", registry.Kill(ids...))), nil
+}
+
+func handleClientPause(params internal.HandlerFuncParams) ([]byte, error) {
+	if len(params.Command) < 3 || len(params.Command) > 4 {
+		return nil, errors.New(constants.WrongArgsResponse)
+	}
+	registry, ok := params.GetClientRegistry().(*ClientRegistry)
+	if !ok {
+		return nil, errors.New("could not load client registry")
+	}
+	timeout, err := strconv.ParseInt(params.Command[2], 10, 64)
+	if err != nil || timeout < 0 {
+		return nil, errors.New("timeout is not an integer or out of range")
+	}
+	writeOnly := false
+	if len(params.Command) == 4 {
+		switch strings.ToLower(params.Command[3]) {
+		default:
+			return nil, fmt.Errorf("unknown mode %s, expected WRITE or ALL", params.Command[3])
+		case "write":
+			writeOnly = true
+		case "all":
+		}
+	}
+	registry.Pause(time.Now().Add(time.Duration(timeout)*time.Millisecond), writeOnly)
+	return []byte(constants.OkResponse), nil
+}
+
+func handleMonitor(params internal.HandlerFuncParams) ([]byte, error) {
+	if len(params.Command) != 1 {
+		return nil, errors.New(constants.WrongArgsResponse)
+	}
+	registry, ok := params.GetClientRegistry().(*ClientRegistry)
+	if !ok {
+		return nil, errors.New("could not load client registry")
+	}
+	// The OK reply is written by the registry, so that it precedes the feed.
+	if err := registry.Monitor(params.Context); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
 func Commands() []internal.Command {
 	return []internal.Command{
 		{
@@ -49,5 +472,241 @@ func Commands() []internal.Command {
 			},
 			HandlerFunc: handlePing,
 		},
+		{
+			Command:    "hello",
+			Module:     constants.ConnectionModule,
+			Categories: []string{constants.FastCategory, constants.ConnectionCategory},
+			Description: `(HELLO [protover [AUTH username password] [SETNAME clientname]]) Returns the server name, version,
+protocol version, mode (standalone or cluster), role and loaded modules so that client libraries can adapt to the server.
+Protocol version 3 is required for client tracking. The replies sent to RESP3 clients are the same as the RESP2 replies,
+with the addition of push messages. AUTH authenticates the connection like the AUTH command, and SETNAME sets the name
+of the client like CLIENT SETNAME.`,
+			Sync: false,
+			KeyExtractionFunc: func(cmd []string) (internal.KeyExtractionFuncResult, error) {
+				return internal.KeyExtractionFuncResult{
+					Channels:  make([]string, 0),
+					ReadKeys:  make([]string, 0),
+					WriteKeys: make([]string, 0),
+				}, nil
+			},
+			HandlerFunc: handleHello,
+		},
+		{
+			Command:     "client",
+			Module:      constants.ConnectionModule,
+			Categories:  []string{},
+			Description: "Commands pertaining to client connections",
+			Sync:        false,
+			KeyExtractionFunc: func(cmd []string) (internal.KeyExtractionFuncResult, error) {
+				return internal.KeyExtractionFuncResult{
+					Channels:  make([]string, 0),
+					ReadKeys:  make([]string, 0),
+					WriteKeys: make([]string, 0),
+				}, nil
+			},
+			SubCommands: []internal.SubCommand{
+				{
+					Command:    "no-touch",
+					Module:     constants.ConnectionModule,
+					Categories: []string{constants.FastCategory, constants.ConnectionCategory},
+					Description: `(CLIENT NO-TOUCH <ON | OFF>) When turned on, the commands sent by the current client
+do not update the LRU/LFU statistics of the keys they read.`,
+					Sync: false,
+					KeyExtractionFunc: func(cmd []string) (internal.KeyExtractionFuncResult, error) {
+						return internal.KeyExtractionFuncResult{
+							Channels:  make([]string, 0),
+							ReadKeys:  make([]string, 0),
+							WriteKeys: make([]string, 0),
+						}, nil
+					},
+					HandlerFunc: handleClientNoTouch,
+				},
+				{
+					Command: "no-evict",
+					Module:  constants.ConnectionModule,
+					Categories: []string{
+						constants.AdminCategory, constants.SlowCategory,
+						constants.DangerousCategory, constants.ConnectionCategory,
+					},
+					Description: `(CLIENT NO-EVICT <ON | OFF>) When turned on, the current client is exempt from client eviction.`,
+					Sync:        false,
+					KeyExtractionFunc: func(cmd []string) (internal.KeyExtractionFuncResult, error) {
+						return internal.KeyExtractionFuncResult{
+							Channels:  make([]string, 0),
+							ReadKeys:  make([]string, 0),
+							WriteKeys: make([]string, 0),
+						}, nil
+					},
+					HandlerFunc: handleClientNoEvict,
+				},
+				{
+					Command:    "tracking",
+					Module:     constants.ConnectionModule,
+					Categories: []string{constants.SlowCategory, constants.ConnectionCategory},
+					Description: `(CLIENT TRACKING <ON | OFF>) Turns server-assisted client-side caching on or off for the current client.
+While tracking is on, the server remembers the keys read by the client, and sends an invalidation push message
+when one of them is modified, deleted, expired or evicted. Requires protocol version 3.`,
+					Sync: false,
+					KeyExtractionFunc: func(cmd []string) (internal.KeyExtractionFuncResult, error) {
+						return internal.KeyExtractionFuncResult{
+							Channels:  make([]string, 0),
+							ReadKeys:  make([]string, 0),
+							WriteKeys: make([]string, 0),
+						}, nil
+					},
+					HandlerFunc: handleClientTracking,
+				},
+				{
+					Command: "unpause",
+					Module:  constants.ConnectionModule,
+					Categories: []string{
+						constants.AdminCategory, constants.SlowCategory,
+						constants.DangerousCategory, constants.ConnectionCategory,
+					},
+					Description: `(CLIENT UNPAUSE) Resume processing of commands from all the clients that were paused.`,
+					Sync:        false,
+					KeyExtractionFunc: func(cmd []string) (internal.KeyExtractionFuncResult, error) {
+						return internal.KeyExtractionFuncResult{
+							Channels:  make([]string, 0),
+							ReadKeys:  make([]string, 0),
+							WriteKeys: make([]string, 0),
+						}, nil
+					},
+					HandlerFunc: handleClientUnpause,
+				},
+				{
+					Command: "setname",
+					Module:  constants.ConnectionModule,
+					Categories: []string{
+						constants.SlowCategory, constants.ConnectionCategory,
+					},
+					Description: `(CLIENT SETNAME name) Set the name of the current client. The name is reported by CLIENT LIST.
+An empty name removes the name. Names cannot contain spaces, newlines or special characters.`,
+					Sync: false,
+					KeyExtractionFunc: func(cmd []string) (internal.KeyExtractionFuncResult, error) {
+						return internal.KeyExtractionFuncResult{
+							Channels:  make([]string, 0),
+							ReadKeys:  make([]string, 0),
+							WriteKeys: make([]string, 0),
+						}, nil
+					},
+					HandlerFunc: handleClientSetName,
+				},
+				{
+					Command: "getname",
+					Module:  constants.ConnectionModule,
+					Categories: []string{
+						constants.SlowCategory, constants.ConnectionCategory,
+					},
+					Description: `(CLIENT GETNAME) Get the name of the current client. Returns nil if the client has no name.`,
+					Sync:        false,
+					KeyExtractionFunc: func(cmd []string) (internal.KeyExtractionFuncResult, error) {
+						return internal.KeyExtractionFuncResult{
+							Channels:  make([]string, 0),
+							ReadKeys:  make([]string, 0),
+							WriteKeys: make([]string, 0),
+						}, nil
+					},
+					HandlerFunc: handleClientGetName,
+				},
+				{
+					Command: "list",
+					Module:  constants.ConnectionModule,
+					Categories: []string{
+						constants.AdminCategory, constants.SlowCategory,
+						constants.DangerousCategory, constants.ConnectionCategory,
+					},
+					Description: `(CLIENT LIST [ID id [id ...]]) List the connected clients, or only the clients with the provided IDs.
+Each client is a line of space separated fields: id, addr, name, age and idle in seconds, flags, resp, user and the last cmd.
+The flags are e for NO-EVICT, t for tracking and T for NO-TOUCH, or N when none are set.`,
+					Sync: false,
+					KeyExtractionFunc: func(cmd []string) (internal.KeyExtractionFuncResult, error) {
+						return internal.KeyExtractionFuncResult{
+							Channels:  make([]string, 0),
+							ReadKeys:  make([]string, 0),
+							WriteKeys: make([]string, 0),
+						}, nil
+					},
+					HandlerFunc: handleClientList,
+				},
+				{
+					Command: "info",
+					Module:  constants.ConnectionModule,
+					Categories: []string{
+						constants.SlowCategory, constants.ConnectionCategory,
+					},
+					Description: `(CLIENT INFO) Describe the current client in the same format as CLIENT LIST.`,
+					Sync:        false,
+					KeyExtractionFunc: func(cmd []string) (internal.KeyExtractionFuncResult, error) {
+						return internal.KeyExtractionFuncResult{
+							Channels:  make([]string, 0),
+							ReadKeys:  make([]string, 0),
+							WriteKeys: make([]string, 0),
+						}, nil
+					},
+					HandlerFunc: handleClientInfo,
+				},
+				{
+					Command: "kill",
+					Module:  constants.ConnectionModule,
+					Categories: []string{
+						constants.AdminCategory, constants.SlowCategory,
+						constants.DangerousCategory, constants.ConnectionCategory,
+					},
+					Description: `(CLIENT KILL addr:port | CLIENT KILL <ID id | ADDR addr:port | USER username | SKIPME yes/no> [...])
+Close the connections of the clients that match all the filters. Returns the number of clients killed.
+The current client is skipped unless SKIPME is no. The addr:port form kills one client and returns OK.`,
+					Sync: false,
+					KeyExtractionFunc: func(cmd []string) (internal.KeyExtractionFuncResult, error) {
+						return internal.KeyExtractionFuncResult{
+							Channels:  make([]string, 0),
+							ReadKeys:  make([]string, 0),
+							WriteKeys: make([]string, 0),
+						}, nil
+					},
+					HandlerFunc: handleClientKill,
+				},
+				{
+					Command: "pause",
+					Module:  constants.ConnectionModule,
+					Categories: []string{
+						constants.AdminCategory, constants.SlowCategory,
+						constants.DangerousCategory, constants.ConnectionCategory,
+					},
+					Description: `(CLIENT PAUSE timeout [WRITE | ALL]) Suspend processing of commands from all the clients for timeout milliseconds.
+With WRITE, only write commands are suspended. CLIENT commands are not suspended, so that clients can be unpaused
+with CLIENT UNPAUSE.`,
+					Sync: false,
+					KeyExtractionFunc: func(cmd []string) (internal.KeyExtractionFuncResult, error) {
+						return internal.KeyExtractionFuncResult{
+							Channels:  make([]string, 0),
+							ReadKeys:  make([]string, 0),
+							WriteKeys: make([]string, 0),
+						}, nil
+					},
+					HandlerFunc: handleClientPause,
+				},
+			},
+		},
+		{
+			Command: "monitor",
+			Module:  constants.ConnectionModule,
+			Categories: []string{
+				constants.AdminCategory, constants.SlowCategory,
+				constants.DangerousCategory, constants.ConnectionCategory,
+			},
+			Description: `(MONITOR) Stream every command processed by the server to the connection, with its timestamp,
+client address and database. Admin commands are not streamed and the credentials passed to AUTH and HELLO are redacted.
+A monitor that cannot keep up with the feed is disconnected. Only supported on TCP connections.`,
+			Sync: false,
+			KeyExtractionFunc: func(cmd []string) (internal.KeyExtractionFuncResult, error) {
+				return internal.KeyExtractionFuncResult{
+					Channels:  make([]string, 0),
+					ReadKeys:  make([]string, 0),
+					WriteKeys: make([]string, 0),
+				}, nil
+			},
+			HandlerFunc: handleMonitor,
+		},
 	}
 }
@@ -0,0 +1,165 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package connection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// monitorQueueSize is the number of lines that can wait to be written to a monitor.
+// A monitor that falls this far behind is disconnected, so that a slow monitor cannot stall the server
+// or make it buffer the feed without bound.
+const monitorQueueSize = 1024
+
+// Monitor writes the OK reply to the client associated with the context and starts streaming every command
+// processed by the server to it. The reply is written before the client is added to the monitors, so that it
+// precedes the first command in the feed.
+func (registry *ClientRegistry) Monitor(ctx context.Context) error {
+	registry.mutex.RLock()
+	client, ok := registry.clients[getConnectionID(ctx)]
+	registry.mutex.RUnlock()
+	if !ok {
+		return errors.New("MONITOR is only supported on TCP connections")
+	}
+
+	client.writeMutex.Lock()
+	_, err := (*client.Conn).Write([]byte("+OK\r\n"))
+	client.writeMutex.Unlock()
+	if err != nil {
+		return err
+	}
+
+	registry.monitors.mutex.Lock()
+	defer registry.monitors.mutex.Unlock()
+	if _, ok = registry.monitors.feeds[client.ID]; ok {
+		return nil
+	}
+
+	feed := make(chan []byte, monitorQueueSize)
+	registry.monitors.feeds[client.ID] = feed
+	registry.monitors.count.Add(1)
+
+	go func() {
+		for line := range feed {
+			client.writeMutex.Lock()
+			_, err := (*client.Conn).Write(line)
+			client.writeMutex.Unlock()
+			if err != nil {
+				log.Printf("monitor %s: %v\n", client.ID, err)
+				registry.stopMonitor(client.ID)
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+// IsMonitored returns true if at least one client is receiving the MONITOR feed.
+func (registry *ClientRegistry) IsMonitored() bool {
+	return registry.monitors.count.Load() > 0
+}
+
+// Feed sends the command to every monitor. addr is the address of the client that sent the command,
+// or an empty string for commands executed through the embedded API.
+// Monitors that have fallen behind by more than the queue size are disconnected instead of blocking the caller.
+func (registry *ClientRegistry) Feed(t time.Time, addr string, args []string) {
+	if !registry.IsMonitored() {
+		return
+	}
+
+	line := formatMonitorLine(t, addr, args)
+
+	var slow []string
+	registry.monitors.mutex.RLock()
+	for id, feed := range registry.monitors.feeds {
+		select {
+		case feed <- line:
+		default:
+			slow = append(slow, id)
+		}
+	}
+	registry.monitors.mutex.RUnlock()
+
+	for _, id := range slow {
+		log.Printf("monitor %s is too slow to keep up with the feed, disconnecting\n", id)
+		registry.stopMonitor(id)
+		registry.Kill(id)
+	}
+}
+
+// stopMonitor stops streaming commands to the client. Does nothing if the client is not a monitor.
+func (registry *ClientRegistry) stopMonitor(id string) {
+	registry.monitors.mutex.Lock()
+	defer registry.monitors.mutex.Unlock()
+	if feed, ok := registry.monitors.feeds[id]; ok {
+		delete(registry.monitors.feeds, id)
+		registry.monitors.count.Add(-1)
+		close(feed)
+	}
+}
+
+// formatMonitorLine formats a command as a MONITOR status line, e.g. +1700000000.123456 [0 127.0.0.1:6379] "SET" "key".
+// As there's a single keyspace, the database is always 0.
+func formatMonitorLine(t time.Time, addr string, args []string) []byte {
+	if addr == "" {
+		addr = "embedded"
+	}
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("+%d.%06d [0 %s]", t.Unix(), t.Nanosecond()/1000, addr))
+	for i, arg := range args {
+		// The credentials passed to AUTH and HELLO are not shown to monitors.
+		if i > 0 && (strings.EqualFold(args[0], "auth") || strings.EqualFold(args[0], "hello")) {
+			b.WriteString(` "(redacted)"`)
+			continue
+		}
+		b.WriteString(" ")
+		b.WriteString(quoteMonitorArg(arg))
+	}
+	b.WriteString("\r\n")
+	return []byte(b.String())
+}
+
+// quoteMonitorArg quotes the argument and escapes the characters that cannot be written in a status line.
+func quoteMonitorArg(arg string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(arg); i++ {
+		switch c := arg[i]; c {
+		case '\\', '"':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if c < 0x20 || c > 0x7e {
+				b.WriteString(fmt.Sprintf(`\x%02x`, c))
+			} else {
+				b.WriteByte(c)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
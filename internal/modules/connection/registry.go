@@ -0,0 +1,419 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package connection
+
+import (
+	"context"
+	"errors"
+	"github.com/echovault/echovault/internal"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Client holds the state of a single TCP client connection.
+type Client struct {
+	ID      string    // The connection ID. This is the same ID that's stored in the connection's context.
+	Conn    *net.Conn // The underlying TCP connection.
+	NoTouch bool      // When true, commands from this client do not update the LRU/LFU caches.
+	NoEvict bool      // When true, this client is exempt from client eviction.
+	// The RESP protocol version negotiated with HELLO. RESP3 clients can receive invalidation push messages.
+	Proto    int
+	Tracking bool // When true, the keys read by this client are tracked for client-side caching.
+	// Arbitrary metadata attached to the connection by the embedding application, e.g. a tenant ID or trace ID.
+	Metadata map[string]interface{}
+
+	Addr        string    // The remote address of the connection.
+	Name        string    // The name set with CLIENT SETNAME.
+	CreatedAt   time.Time // The time the connection was established.
+	LastActive  time.Time // The time the last command was received.
+	LastCommand string    // The name of the last command, as "command" or "command|subcommand".
+
+	writeMutex sync.Mutex // Held while a reply or push message is written to the connection.
+}
+
+// ClientRegistry tracks all the TCP clients currently connected to the server.
+type ClientRegistry struct {
+	mutex   sync.RWMutex
+	clients map[string]*Client // Map of connection ID to client.
+
+	// Holds the current client pause state.
+	pause struct {
+		until     time.Time     // The time when the pause expires. Zero time when clients are not paused.
+		writeOnly bool          // When true, only write commands are paused.
+		resume    chan struct{} // Closed when clients are unpaused before the pause expires.
+	}
+
+	// The clients that receive the MONITOR feed.
+	monitors struct {
+		mutex sync.RWMutex
+		feeds map[string]chan []byte // Map of client ID to the lines waiting to be written to the client.
+		count atomic.Int64           // The number of monitors.
+	}
+
+	// The invalidation table used for client-side caching.
+	tracking struct {
+		mutex   sync.Mutex
+		keys    map[string]map[string]struct{} // Map of key to the IDs of the clients that read the key.
+		clients atomic.Int64                   // The number of clients with tracking turned on.
+	}
+}
+
+func NewClientRegistry() *ClientRegistry {
+	registry := &ClientRegistry{
+		mutex:   sync.RWMutex{},
+		clients: make(map[string]*Client),
+	}
+	registry.pause.resume = make(chan struct{})
+	registry.tracking.keys = make(map[string]map[string]struct{})
+	registry.monitors.feeds = make(map[string]chan []byte)
+	return registry
+}
+
+// getConnectionID returns the connection ID from the context.
+// Returns an empty string if the context does not belong to a TCP connection.
+func getConnectionID(ctx context.Context) string {
+	id, ok := ctx.Value(internal.ContextConnID("ConnectionID")).(string)
+	if !ok {
+		return ""
+	}
+	return id
+}
+
+// RegisterClient adds the connection to the registry. ctx must be the connection's context.
+func (registry *ClientRegistry) RegisterClient(ctx context.Context, conn *net.Conn) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	id := getConnectionID(ctx)
+	now := time.Now()
+	client := &Client{
+		ID:         id,
+		Conn:       conn,
+		Proto:      2,
+		Metadata:   make(map[string]interface{}),
+		CreatedAt:  now,
+		LastActive: now,
+	}
+	if conn != nil && *conn != nil && (*conn).RemoteAddr() != nil {
+		client.Addr = (*conn).RemoteAddr().String()
+	}
+	registry.clients[id] = client
+}
+
+// UnregisterClient removes the connection from the registry. This is called when the connection is closed.
+func (registry *ClientRegistry) UnregisterClient(ctx context.Context) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	id := getConnectionID(ctx)
+	if client, ok := registry.clients[id]; ok && client.Tracking {
+		registry.tracking.clients.Add(-1)
+	}
+	delete(registry.clients, id)
+	registry.stopMonitor(id)
+}
+
+// LockWrites acquires the write lock of the client associated with the context.
+// The lock is held while a reply is written to the connection, so that push messages are not
+// written in the middle of the reply. Does nothing if the context does not belong to a registered client.
+func (registry *ClientRegistry) LockWrites(ctx context.Context) {
+	registry.mutex.RLock()
+	client, ok := registry.clients[getConnectionID(ctx)]
+	registry.mutex.RUnlock()
+	if ok {
+		client.writeMutex.Lock()
+	}
+}
+
+// UnlockWrites releases the write lock acquired with LockWrites.
+func (registry *ClientRegistry) UnlockWrites(ctx context.Context) {
+	registry.mutex.RLock()
+	client, ok := registry.clients[getConnectionID(ctx)]
+	registry.mutex.RUnlock()
+	if ok {
+		client.writeMutex.Unlock()
+	}
+}
+
+// SetNoTouch sets the NO-TOUCH flag for the client associated with the context.
+func (registry *ClientRegistry) SetNoTouch(ctx context.Context, noTouch bool) error {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	client, ok := registry.clients[getConnectionID(ctx)]
+	if !ok {
+		return errors.New("client not found")
+	}
+	client.NoTouch = noTouch
+	return nil
+}
+
+// IsNoTouch returns true if the client associated with the context has the NO-TOUCH flag set.
+// Returns false if the context does not belong to a registered client.
+func (registry *ClientRegistry) IsNoTouch(ctx context.Context) bool {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+
+	client, ok := registry.clients[getConnectionID(ctx)]
+	if !ok {
+		return false
+	}
+	return client.NoTouch
+}
+
+// SetNoEvict sets the NO-EVICT flag for the client associated with the context.
+func (registry *ClientRegistry) SetNoEvict(ctx context.Context, noEvict bool) error {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	client, ok := registry.clients[getConnectionID(ctx)]
+	if !ok {
+		return errors.New("client not found")
+	}
+	client.NoEvict = noEvict
+	return nil
+}
+
+// IsNoEvict returns true if the client associated with the context has the NO-EVICT flag set.
+// Returns false if the context does not belong to a registered client.
+func (registry *ClientRegistry) IsNoEvict(ctx context.Context) bool {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+
+	client, ok := registry.clients[getConnectionID(ctx)]
+	if !ok {
+		return false
+	}
+	return client.NoEvict
+}
+
+// SetMetadata attaches the value to the client associated with the context under the provided key.
+// If the value is nil, the key is removed from the client's metadata.
+func (registry *ClientRegistry) SetMetadata(ctx context.Context, key string, value interface{}) error {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	client, ok := registry.clients[getConnectionID(ctx)]
+	if !ok {
+		return errors.New("client not found")
+	}
+	if value == nil {
+		delete(client.Metadata, key)
+		return nil
+	}
+	client.Metadata[key] = value
+	return nil
+}
+
+// GetMetadata returns the value attached to the client associated with the context under the provided key.
+// Returns false if the context does not belong to a registered client or the key is not set.
+func (registry *ClientRegistry) GetMetadata(ctx context.Context, key string) (interface{}, bool) {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+
+	client, ok := registry.clients[getConnectionID(ctx)]
+	if !ok {
+		return nil, false
+	}
+	value, ok := client.Metadata[key]
+	return value, ok
+}
+
+// SetName sets the name of the client associated with the context. An empty name removes the name.
+func (registry *ClientRegistry) SetName(ctx context.Context, name string) error {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	client, ok := registry.clients[getConnectionID(ctx)]
+	if !ok {
+		return errors.New("client not found")
+	}
+	client.Name = name
+	return nil
+}
+
+// GetName returns the name of the client associated with the context.
+// Returns an empty string if the client has no name or the context does not belong to a registered client.
+func (registry *ClientRegistry) GetName(ctx context.Context) string {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+
+	client, ok := registry.clients[getConnectionID(ctx)]
+	if !ok {
+		return ""
+	}
+	return client.Name
+}
+
+// GetAddr returns the remote address of the client associated with the context.
+// Returns an empty string if the context does not belong to a registered client.
+func (registry *ClientRegistry) GetAddr(ctx context.Context) string {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+
+	client, ok := registry.clients[getConnectionID(ctx)]
+	if !ok {
+		return ""
+	}
+	return client.Addr
+}
+
+// RecordCommand records the command as the last command of the client associated with the context.
+// Does nothing if the context does not belong to a registered client.
+func (registry *ClientRegistry) RecordCommand(ctx context.Context, command string) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	client, ok := registry.clients[getConnectionID(ctx)]
+	if !ok {
+		return
+	}
+	client.LastCommand = command
+	client.LastActive = time.Now()
+}
+
+// ClientInfo is a point-in-time copy of the state of a client that's reported by CLIENT LIST.
+type ClientInfo struct {
+	ID          string
+	Conn        *net.Conn
+	Addr        string
+	Name        string
+	Proto       int
+	Flags       string // The client flags as reported by CLIENT LIST, e.g. "e" for NO-EVICT. "N" when none are set.
+	CreatedAt   time.Time
+	LastActive  time.Time
+	LastCommand string
+}
+
+// flags returns the CLIENT LIST flags of the client. The registry must be locked before calling this function.
+func (client *Client) flags() string {
+	var flags strings.Builder
+	if client.NoEvict {
+		flags.WriteString("e")
+	}
+	if client.Tracking {
+		flags.WriteString("t")
+	}
+	if client.NoTouch {
+		flags.WriteString("T")
+	}
+	if flags.Len() == 0 {
+		return "N"
+	}
+	return flags.String()
+}
+
+// Clients returns the state of all the connected clients, ordered by the time they connected.
+func (registry *ClientRegistry) Clients() []ClientInfo {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+
+	clients := make([]ClientInfo, 0, len(registry.clients))
+	for _, client := range registry.clients {
+		clients = append(clients, ClientInfo{
+			ID:          client.ID,
+			Conn:        client.Conn,
+			Addr:        client.Addr,
+			Name:        client.Name,
+			Proto:       client.Proto,
+			Flags:       client.flags(),
+			CreatedAt:   client.CreatedAt,
+			LastActive:  client.LastActive,
+			LastCommand: client.LastCommand,
+		})
+	}
+	slices.SortFunc(clients, func(a, b ClientInfo) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	return clients
+}
+
+// Count returns the number of connected clients.
+func (registry *ClientRegistry) Count() int {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+	return len(registry.clients)
+}
+
+// Kill closes the connections of the clients with the provided IDs. The clients are unregistered when their
+// connection loop exits. Returns the number of connections that were closed.
+func (registry *ClientRegistry) Kill(ids ...string) int {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+
+	count := 0
+	for _, id := range ids {
+		client, ok := registry.clients[id]
+		if !ok || client.Conn == nil || *client.Conn == nil {
+			continue
+		}
+		if err := (*client.Conn).Close(); err == nil {
+			count += 1
+		}
+	}
+	return count
+}
+
+// Pause suspends command processing for all clients until the provided time.
+// If writeOnly is true, only write commands are suspended.
+func (registry *ClientRegistry) Pause(until time.Time, writeOnly bool) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	registry.pause.until = until
+	registry.pause.writeOnly = writeOnly
+}
+
+// Unpause resumes command processing for all clients that were paused.
+func (registry *ClientRegistry) Unpause() {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	if registry.pause.until.IsZero() {
+		return
+	}
+	registry.pause.until = time.Time{}
+	registry.pause.writeOnly = false
+	// Wake up all the commands waiting for the pause to end.
+	close(registry.pause.resume)
+	registry.pause.resume = make(chan struct{})
+}
+
+// WaitIfPaused blocks until clients are unpaused, the pause expires, or the context is done.
+// write specifies whether the command waiting to be processed is a write command.
+func (registry *ClientRegistry) WaitIfPaused(ctx context.Context, write bool) error {
+	for {
+		registry.mutex.RLock()
+		until, writeOnly, resume := registry.pause.until, registry.pause.writeOnly, registry.pause.resume
+		registry.mutex.RUnlock()
+
+		remaining := time.Until(until)
+		if until.IsZero() || remaining <= 0 || (writeOnly && !write) {
+			return nil
+		}
+
+		select {
+		case <-resume:
+		case <-time.After(remaining):
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package connection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+)
+
+// SetProtocol sets the RESP protocol version of the client associated with the context.
+func (registry *ClientRegistry) SetProtocol(ctx context.Context, proto int) error {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	client, ok := registry.clients[getConnectionID(ctx)]
+	if !ok {
+		return errors.New("client not found")
+	}
+	if proto == 2 && client.Tracking {
+		return errors.New("turn client tracking off before switching to protocol version 2")
+	}
+	client.Proto = proto
+	return nil
+}
+
+// GetProtocol returns the RESP protocol version of the client associated with the context.
+// Returns 2 if the context does not belong to a registered client.
+func (registry *ClientRegistry) GetProtocol(ctx context.Context) int {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+
+	client, ok := registry.clients[getConnectionID(ctx)]
+	if !ok {
+		return 2
+	}
+	return client.Proto
+}
+
+// SetTracking turns client-side caching on or off for the client associated with the context.
+// Invalidation messages are sent as RESP3 push messages, so tracking can only be turned on for RESP3 clients.
+func (registry *ClientRegistry) SetTracking(ctx context.Context, tracking bool) error {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	client, ok := registry.clients[getConnectionID(ctx)]
+	if !ok {
+		return errors.New("client not found")
+	}
+	if tracking && client.Proto < 3 {
+		return errors.New("client tracking requires protocol version 3, switch with HELLO 3")
+	}
+	if tracking != client.Tracking {
+		if tracking {
+			registry.tracking.clients.Add(1)
+		} else {
+			registry.tracking.clients.Add(-1)
+		}
+	}
+	client.Tracking = tracking
+	return nil
+}
+
+// IsTracking returns true if the client associated with the context has tracking turned on.
+func (registry *ClientRegistry) IsTracking(ctx context.Context) bool {
+	if registry.tracking.clients.Load() == 0 {
+		return false
+	}
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+
+	client, ok := registry.clients[getConnectionID(ctx)]
+	return ok && client.Tracking
+}
+
+// Track adds the keys read by the client associated with the context to the invalidation table,
+// if the client has tracking turned on.
+func (registry *ClientRegistry) Track(ctx context.Context, keys []string) {
+	if registry.tracking.clients.Load() == 0 || len(keys) == 0 {
+		return
+	}
+
+	registry.mutex.RLock()
+	client, ok := registry.clients[getConnectionID(ctx)]
+	tracking := ok && client.Tracking
+	registry.mutex.RUnlock()
+	if !tracking {
+		return
+	}
+
+	registry.tracking.mutex.Lock()
+	defer registry.tracking.mutex.Unlock()
+	for _, key := range keys {
+		if _, ok = registry.tracking.keys[key]; !ok {
+			registry.tracking.keys[key] = make(map[string]struct{})
+		}
+		registry.tracking.keys[key][client.ID] = struct{}{}
+	}
+}
+
+// Invalidate sends an invalidation message for the key to each client that read the key since it was last
+// invalidated, and removes the key from the invalidation table. This is called every time a key is modified,
+// deleted, expired or evicted.
+func (registry *ClientRegistry) Invalidate(key string) {
+	if registry.tracking.clients.Load() == 0 {
+		return
+	}
+
+	registry.tracking.mutex.Lock()
+	ids, ok := registry.tracking.keys[key]
+	delete(registry.tracking.keys, key)
+	registry.tracking.mutex.Unlock()
+	if !ok {
+		return
+	}
+
+	message := []byte(fmt.Sprintf(">2\r\n$10\r\ninvalidate\r\n*1\r\n$%d\r\n%s\r\n", len(key), key))
+	for id := range ids {
+		registry.mutex.RLock()
+		client, ok := registry.clients[id]
+		tracking := ok && client.Tracking
+		registry.mutex.RUnlock()
+		// Skip the clients that turned tracking off or disconnected after reading the key.
+		if !tracking {
+			continue
+		}
+		client.writeMutex.Lock()
+		if _, err := (*client.Conn).Write(message); err != nil {
+			log.Printf("invalidate key %s for client %s: %v\n", key, id, err)
+		}
+		client.writeMutex.Unlock()
+	}
+}
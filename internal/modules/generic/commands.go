@@ -15,21 +15,22 @@
 package generic
 
 import (
+	"cmp"
 	"errors"
 	"fmt"
 	"github.com/echovault/echovault/internal"
 	"github.com/echovault/echovault/internal/constants"
+	"github.com/echovault/echovault/internal/glob"
+	"github.com/echovault/echovault/internal/modules/set"
+	"github.com/echovault/echovault/internal/modules/sorted_set"
+	"github.com/echovault/echovault/types"
 	"log"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 )
 
-type KeyObject struct {
-	value  interface{}
-	locked bool
-}
-
 func handleSet(params internal.HandlerFuncParams) ([]byte, error) {
 	keys, err := setKeyFunc(params.Command)
 	if err != nil {
@@ -38,7 +39,6 @@ func handleSet(params internal.HandlerFuncParams) ([]byte, error) {
 
 	key := keys.WriteKeys[0]
 	value := params.Command[2]
-	res := []byte(constants.OkResponse)
 	clock := params.GetClock()
 
 	options, err := getSetCommandOptions(clock, params.Command[3:], SetOptions{})
@@ -46,110 +46,150 @@ func handleSet(params internal.HandlerFuncParams) ([]byte, error) {
 		return nil, err
 	}
 
+	exists := params.KeyExists(params.Context, key)
+
+	// If the NX or XX condition is not met, the key is not set. The response is nil,
+	// or the current value if GET is provided.
+	if (options.exists == "NX" && exists) || (options.exists == "XX" && !exists) {
+		if !options.get || !exists {
+			return []byte("$-1\r\n"), nil
+		}
+		if _, err = params.KeyRLock(params.Context, key); err != nil {
+			return nil, err
+		}
+		defer params.KeyRUnlock(params.Context, key)
+		return []byte(fmt.Sprintf("+%v\r\n", params.GetValue(params.Context, key))), nil
+	}
+
+	if exists {
+		_, err = params.KeyLock(params.Context, key)
+	} else {
+		_, err = params.CreateKeyAndLock(params.Context, key)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer params.KeyUnlock(params.Context, key)
+
+	res := []byte(constants.OkResponse)
+
 	// If Get is provided, the response should be the current stored value.
 	// If there's no current value, then the response should be nil.
 	if options.get {
-		if !params.KeyExists(params.Context, key) {
+		if !exists {
 			res = []byte("$-1\r\n")
 		} else {
 			res = []byte(fmt.Sprintf("+%v\r\n", params.GetValue(params.Context, key)))
 		}
 	}
 
-	if "xx" == strings.ToLower(options.exists) {
-		// If XX is specified, make sure the key exists.
-		if !params.KeyExists(params.Context, key) {
-			return nil, fmt.Errorf("key %s does not exist", key)
+	if err = params.SetValue(params.Context, key, internal.AdaptType(value)); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case options.expireAt != nil:
+		// If expiresAt is set, set the key's expiry time as well
+		params.SetExpiry(params.Context, key, options.expireAt.(time.Time), false)
+	case exists && !options.keepTTL:
+		// Setting the key discards its previous expiry time unless KEEPTTL is provided.
+		if params.GetExpiry(params.Context, key) != (time.Time{}) {
+			params.RemoveExpiry(params.Context, key)
 		}
+	}
+
+	return res, nil
+}
+
+func handleSetEx(params internal.HandlerFuncParams) ([]byte, error) {
+	keys, err := setExKeyFunc(params.Command)
+	if err != nil {
+		return nil, err
+	}
+
+	key := keys.WriteKeys[0]
+
+	// SETEX takes the expiry time in seconds and PSETEX in milliseconds.
+	option := "EX"
+	if strings.EqualFold(params.Command[0], "psetex") {
+		option = "PX"
+	}
+	expireAt, err := parseExpiry(params.GetClock(), option, params.Command[2])
+	if err != nil {
+		return nil, err
+	}
+
+	if params.KeyExists(params.Context, key) {
 		_, err = params.KeyLock(params.Context, key)
-	} else if "nx" == strings.ToLower(options.exists) {
-		// If NX is specified, make sure that the key does not currently exist.
-		if params.KeyExists(params.Context, key) {
-			return nil, fmt.Errorf("key %s already exists", key)
-		}
-		_, err = params.CreateKeyAndLock(params.Context, key)
 	} else {
-		// Neither XX not NX are specified, lock or create the lock
-		if !params.KeyExists(params.Context, key) {
-			// Key does not exist, create it
-			_, err = params.CreateKeyAndLock(params.Context, key)
-		} else {
-			// Key exists, acquire the lock
-			_, err = params.KeyLock(params.Context, key)
-		}
+		_, err = params.CreateKeyAndLock(params.Context, key)
 	}
 	if err != nil {
 		return nil, err
 	}
 	defer params.KeyUnlock(params.Context, key)
 
-	if err = params.SetValue(params.Context, key, internal.AdaptType(value)); err != nil {
+	if err = params.SetValue(params.Context, key, internal.AdaptType(params.Command[3])); err != nil {
 		return nil, err
 	}
+	params.SetExpiry(params.Context, key, expireAt, false)
 
-	// If expiresAt is set, set the key's expiry time as well
-	if options.expireAt != nil {
-		params.SetExpiry(params.Context, key, options.expireAt.(time.Time), false)
-	}
-
-	return res, nil
+	return []byte(constants.OkResponse), nil
 }
 
 func handleMSet(params internal.HandlerFuncParams) ([]byte, error) {
-	_, err := msetKeyFunc(params.Command)
+	keys, err := msetKeyFunc(params.Command)
 	if err != nil {
 		return nil, err
 	}
 
-	entries := make(map[string]KeyObject)
+	unlock, err := lockKeys(params, keys.WriteKeys)
+	defer unlock()
+	if err != nil {
+		return nil, err
+	}
 
-	// Release all acquired key locks
-	defer func() {
-		for k, v := range entries {
-			if v.locked {
-				params.KeyUnlock(params.Context, k)
-				entries[k] = KeyObject{
-					value:  v.value,
-					locked: false,
-				}
-			}
+	// When a key is repeated, the last value given for it is the one that is set.
+	for i := 1; i < len(params.Command); i += 2 {
+		key := params.Command[i]
+		if err = params.SetValue(params.Context, key, internal.AdaptType(params.Command[i+1])); err != nil {
+			return nil, err
 		}
-	}()
-
-	// Extract all the key/value pairs
-	for i, key := range params.Command[1:] {
-		if i%2 == 0 {
-			entries[key] = KeyObject{
-				value:  internal.AdaptType(params.Command[1:][i+1]),
-				locked: false,
-			}
+		// Setting the key discards its previous expiry time.
+		if params.GetExpiry(params.Context, key) != (time.Time{}) {
+			params.RemoveExpiry(params.Context, key)
 		}
 	}
 
-	// Acquire all the locks for each key first
-	// If any key cannot be acquired, abandon transaction and release all currently held keys
-	for k, v := range entries {
-		if params.KeyExists(params.Context, k) {
-			if _, err := params.KeyLock(params.Context, k); err != nil {
-				return nil, err
-			}
-			entries[k] = KeyObject{value: v.value, locked: true}
-			continue
-		}
-		if _, err := params.CreateKeyAndLock(params.Context, k); err != nil {
-			return nil, err
+	return []byte(constants.OkResponse), nil
+}
+
+func handleMSetNX(params internal.HandlerFuncParams) ([]byte, error) {
+	keys, err := msetKeyFunc(params.Command)
+	if err != nil {
+		return nil, err
+	}
+
+	// None of the keys are set if any of them already exists.
+	for _, key := range keys.WriteKeys {
+		if params.KeyExists(params.Context, key) {
+			return []byte(":0\r\n"), nil
 		}
-		entries[k] = KeyObject{value: v.value, locked: true}
 	}
 
-	// Set all the values
-	for k, v := range entries {
-		if err := params.SetValue(params.Context, k, v.value); err != nil {
+	unlock, err := lockKeys(params, keys.WriteKeys)
+	defer unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := 1; i < len(params.Command); i += 2 {
+		if err = params.SetValue(params.Context, params.Command[i], internal.AdaptType(params.Command[i+1])); err != nil {
 			return nil, err
 		}
 	}
 
-	return []byte(constants.OkResponse), nil
+	return []byte(":1\r\n"), nil
 }
 
 func handleGet(params internal.HandlerFuncParams) ([]byte, error) {
@@ -170,6 +210,69 @@ func handleGet(params internal.HandlerFuncParams) ([]byte, error) {
 	defer params.KeyRUnlock(params.Context, key)
 
 	value := params.GetValue(params.Context, key)
+	switch value.(type) {
+	case string, int, float64:
+	default:
+		return nil, fmt.Errorf("value at key %s is not a string", key)
+	}
+
+	return []byte(fmt.Sprintf("+%v\r\n", value)), nil
+}
+
+func handleGetEx(params internal.HandlerFuncParams) ([]byte, error) {
+	keys, err := getExKeyFunc(params.Command)
+	if err != nil {
+		return nil, err
+	}
+	key := keys.WriteKeys[0]
+
+	options, err := getGetExCommandOptions(params.GetClock(), params.Command[2:])
+	if err != nil {
+		return nil, err
+	}
+
+	if !params.KeyExists(params.Context, key) {
+		return []byte("$-1\r\n"), nil
+	}
+
+	if _, err = params.KeyLock(params.Context, key); err != nil {
+		return nil, err
+	}
+	defer params.KeyUnlock(params.Context, key)
+
+	value := params.GetValue(params.Context, key)
+
+	switch {
+	case options.expireAt != nil:
+		params.SetExpiry(params.Context, key, options.expireAt.(time.Time), false)
+	case options.persist:
+		params.RemoveExpiry(params.Context, key)
+	}
+
+	return []byte(fmt.Sprintf("+%v\r\n", value)), nil
+}
+
+func handleGetDel(params internal.HandlerFuncParams) ([]byte, error) {
+	keys, err := getDelKeyFunc(params.Command)
+	if err != nil {
+		return nil, err
+	}
+	key := keys.WriteKeys[0]
+
+	if !params.KeyExists(params.Context, key) {
+		return []byte("$-1\r\n"), nil
+	}
+
+	if _, err = params.KeyRLock(params.Context, key); err != nil {
+		return nil, err
+	}
+	value := params.GetValue(params.Context, key)
+	// DeleteKey acquires the key's lock, so it's released first.
+	params.KeyRUnlock(params.Context, key)
+
+	if err = params.DeleteKey(params.Context, key); err != nil {
+		return nil, err
+	}
 
 	return []byte(fmt.Sprintf("+%v\r\n", value)), nil
 }
@@ -180,65 +283,341 @@ func handleMGet(params internal.HandlerFuncParams) ([]byte, error) {
 		return nil, err
 	}
 
+	// Read-lock the keys that exist in lexicographic order, so that the values are read atomically
+	// without deadlocking against commands that write-lock some of the same keys.
 	values := make(map[string]string)
+	var locked []string
+	defer func() {
+		for _, key := range locked {
+			params.KeyRUnlock(params.Context, key)
+		}
+	}()
+	for _, key := range uniqueSortedKeys(keys.ReadKeys) {
+		if !params.KeyExists(params.Context, key) {
+			continue
+		}
+		if _, err = params.KeyRLock(params.Context, key); err != nil {
+			return nil, fmt.Errorf("could not obtain lock for %s key", key)
+		}
+		locked = append(locked, key)
+		values[key] = fmt.Sprintf("%v", params.GetValue(params.Context, key))
+	}
+
+	bytes := []byte(fmt.Sprintf("*%d\r\n", len(keys.ReadKeys)))
 
-	locks := make(map[string]bool)
 	for _, key := range keys.ReadKeys {
-		if _, ok := values[key]; ok {
-			// Skip if we have already locked this key
+		value, ok := values[key]
+		if !ok {
+			bytes = append(bytes, []byte("$-1\r\n")...)
 			continue
 		}
+		bytes = append(bytes, []byte(fmt.Sprintf("$%d\r\n%s\r\n", len(value), value))...)
+	}
+
+	return bytes, nil
+}
+
+func handleDel(params internal.HandlerFuncParams) ([]byte, error) {
+	keys, err := delKeyFunc(params.Command)
+	if err != nil {
+		return nil, err
+	}
+	// Each key is deleted atomically on its own. A key that is repeated in the command is only counted once,
+	// as it no longer exists when its repetition is reached.
+	count := 0
+	for _, key := range keys.WriteKeys {
+		if !params.KeyExists(params.Context, key) {
+			continue
+		}
+		err = params.DeleteKey(params.Context, key)
+		if err != nil {
+			log.Printf("could not delete key %s due to error: %+v\n", key, err)
+			continue
+		}
+		count += 1
+	}
+	return []byte(fmt.Sprintf(":%d\r\n", count)), nil
+}
+
+func handleExists(params internal.HandlerFuncParams) ([]byte, error) {
+	keys, err := existsKeyFunc(params.Command)
+	if err != nil {
+		return nil, err
+	}
+	// Keys that are repeated in the command are counted once for each occurrence.
+	count := 0
+	for _, key := range keys.ReadKeys {
 		if params.KeyExists(params.Context, key) {
-			_, err = params.KeyRLock(params.Context, key)
-			if err != nil {
-				return nil, fmt.Errorf("could not obtain lock for %s key", key)
+			count += 1
+		}
+	}
+	return []byte(fmt.Sprintf(":%d\r\n", count)), nil
+}
+
+func handleType(params internal.HandlerFuncParams) ([]byte, error) {
+	keys, err := typeKeyFunc(params.Command)
+	if err != nil {
+		return nil, err
+	}
+
+	// A single key replies with its type. Multiple keys reply with an array of their types in the order of the keys.
+	if len(keys.ReadKeys) == 1 {
+		typeName, err := keyType(params, keys.ReadKeys[0])
+		if err != nil {
+			return nil, err
+		}
+		return []byte(fmt.Sprintf("+%s\r\n", typeName)), nil
+	}
+
+	res := fmt.Sprintf("*%d\r\n", len(keys.ReadKeys))
+	for _, key := range keys.ReadKeys {
+		typeName, err := keyType(params, key)
+		if err != nil {
+			return nil, err
+		}
+		res += fmt.Sprintf("+%s\r\n", typeName)
+	}
+	return []byte(res), nil
+}
+
+// keyType returns the type name of the value at the key, or "none" if the key does not exist.
+func keyType(params internal.HandlerFuncParams, key string) (string, error) {
+	if !params.KeyExists(params.Context, key) {
+		return "none", nil
+	}
+
+	if _, err := params.KeyRLock(params.Context, key); err != nil {
+		return "", err
+	}
+	defer params.KeyRUnlock(params.Context, key)
+
+	return internal.TypeName(params.GetValue(params.Context, key)), nil
+}
+
+func handleKeys(params internal.HandlerFuncParams) ([]byte, error) {
+	if _, err := keysKeyFunc(params.Command); err != nil {
+		return nil, err
+	}
+
+	g := glob.Compile(params.Command[1])
+
+	// Iterate the keyspace in batches so that the key index is not locked for the whole iteration.
+	var keys []string
+	var cursor uint64
+	for {
+		var batch []string
+		batch, cursor = params.ScanKeys(cursor, 1024, g.Match)
+		for _, key := range batch {
+			if params.KeyExists(params.Context, key) {
+				keys = append(keys, key)
 			}
-			locks[key] = true
+		}
+		if cursor == 0 {
+			break
+		}
+	}
+
+	res := fmt.Sprintf("*%d\r\n", len(keys))
+	for _, key := range keys {
+		res += fmt.Sprintf("$%d\r\n%s\r\n", len(key), key)
+	}
+	return []byte(res), nil
+}
+
+func handleScan(params internal.HandlerFuncParams) ([]byte, error) {
+	if _, err := scanKeyFunc(params.Command); err != nil {
+		return nil, err
+	}
+
+	options, err := internal.ParseScanOptions(params.Command[1:], true)
+	if err != nil {
+		return nil, err
+	}
+
+	var match func(key string) bool
+	if options.Match != nil {
+		match = options.Match.Match
+	}
+
+	keys, cursor := params.ScanKeys(options.Cursor, options.Count, match)
+
+	res := make([]string, 0, len(keys))
+	for _, key := range keys {
+		// Checking the existence of the key removes it if it's expired.
+		if !params.KeyExists(params.Context, key) {
 			continue
 		}
-		values[key] = ""
+		if options.Type != "" {
+			if _, err = params.KeyRLock(params.Context, key); err != nil {
+				continue
+			}
+			typeName := internal.TypeName(params.GetValue(params.Context, key))
+			params.KeyRUnlock(params.Context, key)
+			if typeName != options.Type {
+				continue
+			}
+		}
+		res = append(res, key)
+	}
+
+	return internal.EncodeScanReply(cursor, res), nil
+}
+
+// storeValue sets the destination key to the value and expiry time, replacing the destination if it exists.
+func storeValue(params internal.HandlerFuncParams, destination string, value interface{}, expireAt time.Time) error {
+	if _, err := params.CreateKeyAndLock(params.Context, destination); err != nil {
+		return err
+	}
+	defer params.KeyUnlock(params.Context, destination)
+	return setLockedValue(params, destination, value, expireAt)
+}
+
+// setLockedValue replaces the value and expiry time of a write-locked key.
+func setLockedValue(params internal.HandlerFuncParams, key string, value interface{}, expireAt time.Time) error {
+	if err := params.SetValue(params.Context, key, value); err != nil {
+		return err
+	}
+	switch {
+	case expireAt != (time.Time{}):
+		params.SetExpiry(params.Context, key, expireAt, false)
+	case params.GetExpiry(params.Context, key) != (time.Time{}):
+		params.RemoveExpiry(params.Context, key)
 	}
+	return nil
+}
+
+// moveValue moves the value and expiry time of the source key to the destination key, or copies them when
+// keepSource is true. Both keys are locked in lexicographic order, and the destination is written and the
+// source deleted while both locks are held, so that no other command sees the value under both keys or neither.
+// Returns false without changing the keys when replace is false and the destination already exists,
+// and errNoSuchKey when the source does not exist.
+func moveValue(params internal.HandlerFuncParams, source, destination string, replace, keepSource bool) (bool, error) {
+	ctx := params.Context
+	var sourceLocked, destinationLocked, destinationCreated bool
 	defer func() {
-		for key, locked := range locks {
-			if locked {
-				params.KeyRUnlock(params.Context, key)
-				locks[key] = false
-			}
+		if sourceLocked {
+			params.KeyUnlock(ctx, source)
+		}
+		switch {
+		case destinationLocked && destinationCreated:
+			// Don't leave behind the empty destination when the value wasn't written to it.
+			params.DeleteLockedKey(ctx, destination)
+		case destinationLocked:
+			params.KeyUnlock(ctx, destination)
 		}
 	}()
 
-	for key, _ := range locks {
-		values[key] = fmt.Sprintf("%v", params.GetValue(params.Context, key))
+	for _, key := range uniqueSortedKeys([]string{source, destination}) {
+		if key == source {
+			if _, err := params.KeyLock(ctx, source); err != nil {
+				if !params.KeyExists(ctx, source) {
+					return false, errNoSuchKey
+				}
+				return false, err
+			}
+			sourceLocked = true
+			continue
+		}
+		if _, err := params.CreateKeyAndLock(ctx, destination); err != nil {
+			return false, err
+		}
+		destinationLocked = true
+		// A key that was just created has no value yet.
+		destinationCreated = params.GetValue(ctx, destination) == nil
 	}
 
-	bytes := []byte(fmt.Sprintf("*%d\r\n", len(params.Command[1:])))
+	if !replace && !destinationCreated {
+		return false, nil
+	}
 
-	for _, key := range params.Command[1:] {
-		if values[key] == "" {
-			bytes = append(bytes, []byte("$-1\r\n")...)
-			continue
+	value := params.GetValue(ctx, source)
+	if keepSource {
+		// Copy the value so that the keys don't share mutable data.
+		value = internal.CopyValue(value)
+	}
+	if err := setLockedValue(params, destination, value, params.GetExpiry(ctx, source)); err != nil {
+		return false, err
+	}
+	destinationCreated = false
+
+	if !keepSource {
+		params.DeleteLockedKey(ctx, source)
+		sourceLocked = false
+	}
+
+	return true, nil
+}
+
+func handleRename(params internal.HandlerFuncParams) ([]byte, error) {
+	keys, err := renameKeyFunc(params.Command)
+	if err != nil {
+		return nil, err
+	}
+
+	source, destination := keys.WriteKeys[0], keys.WriteKeys[1]
+	nx := strings.ToLower(params.Command[0]) == "renamenx"
+
+	if !params.KeyExists(params.Context, source) {
+		return nil, errNoSuchKey
+	}
+
+	if source == destination {
+		if nx {
+			return []byte(":0\r\n"), nil
 		}
-		bytes = append(bytes, []byte(fmt.Sprintf("$%d\r\n%s\r\n", len(values[key]), values[key]))...)
+		return []byte(constants.OkResponse), nil
 	}
 
-	return bytes, nil
+	moved, err := moveValue(params, source, destination, !nx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if nx {
+		if !moved {
+			return []byte(":0\r\n"), nil
+		}
+		return []byte(":1\r\n"), nil
+	}
+	return []byte(constants.OkResponse), nil
 }
 
-func handleDel(params internal.HandlerFuncParams) ([]byte, error) {
-	keys, err := delKeyFunc(params.Command)
+func handleCopy(params internal.HandlerFuncParams) ([]byte, error) {
+	keys, err := copyKeyFunc(params.Command)
+	if err != nil {
+		return nil, err
+	}
+
+	source, destination := keys.ReadKeys[0], keys.WriteKeys[0]
+
+	replace := false
+	if len(params.Command) == 4 {
+		if strings.ToLower(params.Command[3]) != "replace" {
+			return nil, fmt.Errorf("unknown option %s", strings.ToUpper(params.Command[3]))
+		}
+		replace = true
+	}
+
+	if !params.KeyExists(params.Context, source) {
+		return []byte(":0\r\n"), nil
+	}
+
+	if source == destination {
+		return nil, errors.New("source and destination objects are the same")
+	}
+
+	copied, err := moveValue(params, source, destination, replace, true)
+	if errors.Is(err, errNoSuchKey) {
+		return []byte(":0\r\n"), nil
+	}
 	if err != nil {
 		return nil, err
 	}
-	count := 0
-	for _, key := range keys.WriteKeys {
-		err = params.DeleteKey(params.Context, key)
-		if err != nil {
-			log.Printf("could not delete key %s due to error: %+v\n", key, err)
-			continue
-		}
-		count += 1
+
+	if !copied {
+		return []byte(":0\r\n"), nil
 	}
-	return []byte(fmt.Sprintf(":%d\r\n", count)), nil
+	return []byte(":1\r\n"), nil
 }
 
 func handlePersist(params internal.HandlerFuncParams) ([]byte, error) {
@@ -354,122 +733,370 @@ func handleExpire(params internal.HandlerFuncParams) ([]byte, error) {
 		expireAt = params.GetClock().Now().Add(time.Duration(n) * time.Millisecond)
 	}
 
+	return setKeyExpiry(params, key, expireAt)
+}
+
+func handleExpireAt(params internal.HandlerFuncParams) ([]byte, error) {
+	keys, err := expireKeyFunc(params.Command)
+	if err != nil {
+		return nil, err
+	}
+
+	key := keys.WriteKeys[0]
+
+	// Extract time
+	n, err := strconv.ParseInt(params.Command[2], 10, 64)
+	if err != nil {
+		return nil, errors.New("expire time must be integer")
+	}
+	expireAt := time.Unix(n, 0)
+	if strings.ToLower(params.Command[0]) == "pexpireat" {
+		expireAt = time.UnixMilli(n)
+	}
+
+	return setKeyExpiry(params, key, expireAt)
+}
+
+// setKeyExpiry sets the expiry time of the key, subject to the NX, XX, GT and LT flags in params.Command[3:].
+// The key is deleted straight away when the expiry time is not in the future.
+func setKeyExpiry(params internal.HandlerFuncParams, key string, expireAt time.Time) ([]byte, error) {
+	var nx, xx, gt, lt bool
+	for _, option := range params.Command[3:] {
+		switch strings.ToLower(option) {
+		case "nx":
+			nx = true
+		case "xx":
+			xx = true
+		case "gt":
+			gt = true
+		case "lt":
+			lt = true
+		default:
+			return nil, fmt.Errorf("unknown option %s", strings.ToUpper(option))
+		}
+	}
+	if nx && (xx || gt || lt) {
+		return nil, errors.New("NX and XX, GT or LT options at the same time are not compatible")
+	}
+	if gt && lt {
+		return nil, errors.New("GT and LT options at the same time are not compatible")
+	}
+
 	if !params.KeyExists(params.Context, key) {
 		return []byte(":0\r\n"), nil
 	}
 
-	if _, err = params.KeyLock(params.Context, key); err != nil {
+	if _, err := params.KeyLock(params.Context, key); err != nil {
 		return nil, err
 	}
-	defer params.KeyUnlock(params.Context, key)
-
-	if len(params.Command) == 3 {
-		params.SetExpiry(params.Context, key, expireAt, true)
-		return []byte(":1\r\n"), nil
-	}
 
 	currentExpireAt := params.GetExpiry(params.Context, key)
+	hasExpiry := currentExpireAt != (time.Time{})
+
+	// A key without an expiry time is treated as having an infinite TTL.
+	if (nx && hasExpiry) ||
+		(xx && !hasExpiry) ||
+		(gt && (!hasExpiry || expireAt.Before(currentExpireAt))) ||
+		(lt && hasExpiry && currentExpireAt.Before(expireAt)) {
+		params.KeyUnlock(params.Context, key)
+		return []byte(":0\r\n"), nil
+	}
 
-	switch strings.ToLower(params.Command[3]) {
-	case "nx":
-		if currentExpireAt != (time.Time{}) {
-			return []byte(":0\r\n"), nil
-		}
-		params.SetExpiry(params.Context, key, expireAt, false)
-	case "xx":
-		if currentExpireAt == (time.Time{}) {
-			return []byte(":0\r\n"), nil
-		}
-		params.SetExpiry(params.Context, key, expireAt, false)
-	case "gt":
-		if currentExpireAt == (time.Time{}) {
-			return []byte(":0\r\n"), nil
-		}
-		if expireAt.Before(currentExpireAt) {
-			return []byte(":0\r\n"), nil
-		}
-		params.SetExpiry(params.Context, key, expireAt, false)
-	case "lt":
-		if currentExpireAt != (time.Time{}) {
-			if currentExpireAt.Before(expireAt) {
-				return []byte(":0\r\n"), nil
-			}
-			params.SetExpiry(params.Context, key, expireAt, false)
+	if !expireAt.After(params.GetClock().Now()) {
+		params.KeyUnlock(params.Context, key)
+		if err := params.DeleteKey(params.Context, key); err != nil {
+			return nil, err
 		}
-		params.SetExpiry(params.Context, key, expireAt, false)
-	default:
-		return nil, fmt.Errorf("unknown option %s", strings.ToUpper(params.Command[3]))
+		return []byte(":1\r\n"), nil
 	}
 
+	params.SetExpiry(params.Context, key, expireAt, len(params.Command) == 3)
+	params.KeyUnlock(params.Context, key)
 	return []byte(":1\r\n"), nil
 }
 
-func handleExpireAt(params internal.HandlerFuncParams) ([]byte, error) {
-	keys, err := expireKeyFunc(params.Command)
+func handleObjectRefCount(params internal.HandlerFuncParams) ([]byte, error) {
+	keys, err := objectKeyFunc(params.Command)
 	if err != nil {
 		return nil, err
 	}
 
-	key := keys.WriteKeys[0]
+	key := keys.ReadKeys[0]
 
-	// Extract time
-	n, err := strconv.ParseInt(params.Command[2], 10, 64)
+	if !params.KeyExists(params.Context, key) {
+		return []byte("$-1\r\n"), nil
+	}
+
+	// Values are not shared between keys, so each value is only referenced by its own key.
+	return []byte(":1\r\n"), nil
+}
+
+func handleObjectEncoding(params internal.HandlerFuncParams) ([]byte, error) {
+	keys, err := objectKeyFunc(params.Command)
 	if err != nil {
-		return nil, errors.New("expire time must be integer")
+		return nil, err
 	}
-	expireAt := time.Unix(n, 0)
-	if strings.ToLower(params.Command[0]) == "pexpireat" {
-		expireAt = time.UnixMilli(n)
+
+	key := keys.ReadKeys[0]
+
+	if !params.KeyExists(params.Context, key) {
+		return []byte("$-1\r\n"), nil
+	}
+
+	if _, err = params.KeyRLock(params.Context, key); err != nil {
+		return nil, err
+	}
+	defer params.KeyRUnlock(params.Context, key)
+
+	encoding := internal.EncodingName(params.GetValue(params.Context, key))
+	return []byte(fmt.Sprintf("$%d\r\n%s\r\n", len(encoding), encoding)), nil
+}
+
+func handleObjectVersion(params internal.HandlerFuncParams) ([]byte, error) {
+	keys, err := objectKeyFunc(params.Command)
+	if err != nil {
+		return nil, err
 	}
 
+	key := keys.ReadKeys[0]
+
 	if !params.KeyExists(params.Context, key) {
-		return []byte(":0\r\n"), nil
+		return []byte("$-1\r\n"), nil
 	}
 
-	if _, err = params.KeyLock(params.Context, key); err != nil {
+	return []byte(fmt.Sprintf(":%d\r\n", params.GetKeyVersion(key))), nil
+}
+
+func handleSort(params internal.HandlerFuncParams) ([]byte, error) {
+	keys, err := sortKeyFunc(params.Command)
+	if err != nil {
+		return nil, err
+	}
+	options, err := getSortCommandOptions(params.Command[2:])
+	if err != nil {
 		return nil, err
 	}
-	defer params.KeyUnlock(params.Context, key)
 
-	if len(params.Command) == 3 {
-		params.SetExpiry(params.Context, key, expireAt, true)
-		return []byte(":1\r\n"), nil
+	elems, isSortedSet, err := sortElements(params, keys.ReadKeys[0])
+	if err != nil {
+		return nil, err
 	}
 
-	currentExpireAt := params.GetExpiry(params.Context, key)
+	if options.sort {
+		if elems, err = sortByWeight(params, elems, options); err != nil {
+			return nil, err
+		}
+	} else if isSortedSet && options.desc {
+		// When sorting is skipped, the members of a sorted set are still returned in the order of their scores.
+		slices.Reverse(elems)
+	}
 
-	switch strings.ToLower(params.Command[3]) {
-	case "nx":
-		if currentExpireAt != (time.Time{}) {
-			return []byte(":0\r\n"), nil
+	start := min(max(options.offset, 0), len(elems))
+	end := len(elems)
+	if options.count >= 0 {
+		end = min(start+options.count, len(elems))
+	}
+	elems = elems[start:end]
+
+	// Each element is replaced with the values of the GET patterns. A nil value is a pattern that has no value.
+	var values []interface{}
+	for _, elem := range elems {
+		if len(options.get) == 0 {
+			values = append(values, elem)
+			continue
 		}
-		params.SetExpiry(params.Context, key, expireAt, false)
-	case "xx":
-		if currentExpireAt == (time.Time{}) {
-			return []byte(":0\r\n"), nil
+		for _, pattern := range options.get {
+			value, ok, err := lookupSortPattern(params, pattern, elem)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				values = append(values, nil)
+				continue
+			}
+			values = append(values, value)
 		}
-		params.SetExpiry(params.Context, key, expireAt, false)
-	case "gt":
-		if currentExpireAt == (time.Time{}) {
+	}
+
+	if options.store != "" {
+		if len(values) == 0 {
+			if params.KeyExists(params.Context, options.store) {
+				if err = params.DeleteKey(params.Context, options.store); err != nil {
+					return nil, err
+				}
+			}
 			return []byte(":0\r\n"), nil
 		}
-		if expireAt.Before(currentExpireAt) {
-			return []byte(":0\r\n"), nil
+		list := make([]interface{}, len(values))
+		for i, value := range values {
+			if value == nil {
+				value = ""
+			}
+			list[i] = internal.AdaptType(value.(string))
 		}
-		params.SetExpiry(params.Context, key, expireAt, false)
-	case "lt":
-		if currentExpireAt != (time.Time{}) {
-			if currentExpireAt.Before(expireAt) {
-				return []byte(":0\r\n"), nil
+		if err = storeValue(params, options.store, list, time.Time{}); err != nil {
+			return nil, err
+		}
+		return []byte(fmt.Sprintf(":%d\r\n", len(list))), nil
+	}
+
+	res := new(types.ResponseWriter).Array(len(values))
+	for _, value := range values {
+		if value == nil {
+			res.Null()
+			continue
+		}
+		res.Bulk(value.(string))
+	}
+	return res.Bytes(), nil
+}
+
+// sortElements returns the elements of the list, set or sorted set at the key, and whether the value is a sorted set.
+// Set members are returned in lexicographic order and sorted set members in the order of their scores,
+// so that the result is the same on every node when sorting is skipped.
+func sortElements(params internal.HandlerFuncParams, key string) ([]string, bool, error) {
+	if !params.KeyExists(params.Context, key) {
+		return nil, false, nil
+	}
+
+	if _, err := params.KeyRLock(params.Context, key); err != nil {
+		return nil, false, err
+	}
+	defer params.KeyRUnlock(params.Context, key)
+
+	switch value := params.GetValue(params.Context, key).(type) {
+	case []interface{}:
+		elems := make([]string, len(value))
+		for i, e := range value {
+			elems[i] = fmt.Sprintf("%v", e)
+		}
+		return elems, false, nil
+	case *set.Set:
+		elems := value.GetAll()
+		slices.Sort(elems)
+		return elems, false, nil
+	case *sorted_set.SortedSet:
+		members := value.GetAll()
+		slices.SortFunc(members, func(a, b sorted_set.MemberParam) int {
+			if c := cmp.Compare(a.Score, b.Score); c != 0 {
+				return c
 			}
-			params.SetExpiry(params.Context, key, expireAt, false)
+			return strings.Compare(string(a.Value), string(b.Value))
+		})
+		elems := make([]string, len(members))
+		for i, member := range members {
+			elems[i] = string(member.Value)
 		}
-		params.SetExpiry(params.Context, key, expireAt, false)
+		return elems, true, nil
 	default:
-		return nil, fmt.Errorf("unknown option %s", strings.ToUpper(params.Command[3]))
+		return nil, false, fmt.Errorf("value at %s is not a list, set or sorted set", key)
+	}
+}
+
+// sortByWeight sorts the elements by their weights. The weight of an element is the element itself,
+// or the value of the BY pattern for the element. Elements with equal weights are sorted lexicographically.
+func sortByWeight(params internal.HandlerFuncParams, elems []string, options SortOptions) ([]string, error) {
+	type weightedElem struct {
+		elem   string
+		weight string
+		exists bool // False when the BY pattern has no value for the element.
+		score  float64
 	}
 
-	return []byte(":1\r\n"), nil
+	weighted := make([]weightedElem, len(elems))
+	for i, elem := range elems {
+		weighted[i] = weightedElem{elem: elem, weight: elem, exists: true}
+		if options.by != "" {
+			weight, ok, err := lookupSortPattern(params, options.by, elem)
+			if err != nil {
+				return nil, err
+			}
+			weighted[i].weight, weighted[i].exists = weight, ok
+		}
+		if !options.alpha && weighted[i].exists {
+			score, err := strconv.ParseFloat(weighted[i].weight, 64)
+			if err != nil {
+				return nil, errors.New("one or more scores can't be converted into double")
+			}
+			weighted[i].score = score
+		}
+	}
+
+	slices.SortFunc(weighted, func(a, b weightedElem) int {
+		var c int
+		switch {
+		case !options.alpha:
+			// Elements without a weight have a score of 0.
+			c = cmp.Compare(a.score, b.score)
+		case !a.exists && !b.exists:
+			c = 0
+		case !a.exists:
+			// Elements without a weight come first.
+			c = -1
+		case !b.exists:
+			c = 1
+		default:
+			c = strings.Compare(a.weight, b.weight)
+		}
+		if c == 0 {
+			c = strings.Compare(a.elem, b.elem)
+		}
+		if options.desc {
+			return -c
+		}
+		return c
+	})
+
+	sorted := make([]string, len(weighted))
+	for i, w := range weighted {
+		sorted[i] = w.elem
+	}
+	return sorted, nil
+}
+
+// lookupSortPattern returns the value of a BY or GET pattern for the element. The first "*" in the pattern is
+// replaced with the element to get the key. A pattern ending in "->field" gets the field of the hash at the key,
+// and the pattern "#" is the element itself. Returns false if the key or field does not exist, or if it doesn't hold
+// a string value.
+func lookupSortPattern(params internal.HandlerFuncParams, pattern string, elem string) (string, bool, error) {
+	if pattern == "#" {
+		return elem, true, nil
+	}
+
+	star := strings.Index(pattern, "*")
+	if star == -1 {
+		return "", false, nil
+	}
+
+	key, field := pattern, ""
+	if i := strings.LastIndex(pattern, "->"); i > star && i+2 < len(pattern) {
+		key, field = pattern[:i], pattern[i+2:]
+	}
+	key = key[:star] + elem + key[star+1:]
+
+	if !params.KeyExists(params.Context, key) {
+		return "", false, nil
+	}
+
+	if _, err := params.KeyRLock(params.Context, key); err != nil {
+		return "", false, err
+	}
+	defer params.KeyRUnlock(params.Context, key)
+
+	value := params.GetValue(params.Context, key)
+	if field != "" {
+		hash, ok := value.(map[string]interface{})
+		if !ok {
+			return "", false, nil
+		}
+		if value, ok = hash[field]; !ok {
+			return "", false, nil
+		}
+	}
+	if internal.TypeName(value) != "string" {
+		return "", false, nil
+	}
+	return fmt.Sprintf("%v", value), true, nil
 }
 
 func Commands() []internal.Command {
@@ -479,28 +1106,56 @@ func Commands() []internal.Command {
 			Module:     constants.GenericModule,
 			Categories: []string{constants.WriteCategory, constants.SlowCategory},
 			Description: `
-(SET key value [NX | XX] [GET] [EX seconds | PX milliseconds | EXAT unix-time-seconds | PXAT unix-time-milliseconds]) 
+(SET key value [NX | XX] [GET] [EX seconds | PX milliseconds | EXAT unix-time-seconds | PXAT unix-time-milliseconds | KEEPTTL]) 
 Set the value of a key, considering the value's type.
-NX - Only set if the key does not exist.
-XX - Only set if the key exists.
+NX - Only set if the key does not exist. If the key is not set, the response is nil.
+XX - Only set if the key exists. If the key is not set, the response is nil.
 GET - Return the old value stored at key, or nil if the value does not exist.
 EX - Expire the key after the specified number of seconds (positive integer).
 PX - Expire the key after the specified number of milliseconds (positive integer).
 EXAT - Expire at the exact time in unix seconds (positive integer).
-PXAT - Expire at the exat time in unix milliseconds (positive integer).`,
+PXAT - Expire at the exat time in unix milliseconds (positive integer).
+KEEPTTL - Keep the expiry time of the key. Otherwise, setting the key removes its expiry time.`,
 			Sync:              true,
 			KeyExtractionFunc: setKeyFunc,
 			HandlerFunc:       handleSet,
 		},
+		{
+			Command:           "setex",
+			Module:            constants.GenericModule,
+			Categories:        []string{constants.WriteCategory, constants.SlowCategory},
+			Description:       "(SETEX key seconds value) Set the value of a key and expire it after the specified number of seconds.",
+			Sync:              true,
+			KeyExtractionFunc: setExKeyFunc,
+			HandlerFunc:       handleSetEx,
+		},
+		{
+			Command:           "psetex",
+			Module:            constants.GenericModule,
+			Categories:        []string{constants.WriteCategory, constants.SlowCategory},
+			Description:       "(PSETEX key milliseconds value) Set the value of a key and expire it after the specified number of milliseconds.",
+			Sync:              true,
+			KeyExtractionFunc: setExKeyFunc,
+			HandlerFunc:       handleSetEx,
+		},
 		{
 			Command:           "mset",
 			Module:            constants.GenericModule,
 			Categories:        []string{constants.WriteCategory, constants.SlowCategory},
-			Description:       "(MSET key value [key value ...]) Automatically generic or modify multiple key/value pairs.",
+			Description:       "(MSET key value [key value ...]) Atomically set or modify multiple key/value pairs.",
 			Sync:              true,
 			KeyExtractionFunc: msetKeyFunc,
 			HandlerFunc:       handleMSet,
 		},
+		{
+			Command:           "msetnx",
+			Module:            constants.GenericModule,
+			Categories:        []string{constants.WriteCategory, constants.SlowCategory},
+			Description:       "(MSETNX key value [key value ...]) Atomically set multiple key/value pairs only if none of the keys exist.",
+			Sync:              true,
+			KeyExtractionFunc: msetKeyFunc,
+			HandlerFunc:       handleMSetNX,
+		},
 		{
 			Command:           "get",
 			Module:            constants.GenericModule,
@@ -510,6 +1165,30 @@ PXAT - Expire at the exat time in unix milliseconds (positive integer).`,
 			KeyExtractionFunc: getKeyFunc,
 			HandlerFunc:       handleGet,
 		},
+		{
+			Command:    "getex",
+			Module:     constants.GenericModule,
+			Categories: []string{constants.WriteCategory, constants.FastCategory},
+			Description: `(GETEX key [EX seconds | PX milliseconds | EXAT unix-time-seconds | PXAT unix-time-milliseconds | PERSIST])
+Get the value at the specified key and optionally set its expiry time.
+EX - Expire the key after the specified number of seconds (positive integer).
+PX - Expire the key after the specified number of milliseconds (positive integer).
+EXAT - Expire at the exact time in unix seconds (positive integer).
+PXAT - Expire at the exat time in unix milliseconds (positive integer).
+PERSIST - Remove the expiry time of the key.`,
+			Sync:              true,
+			KeyExtractionFunc: getExKeyFunc,
+			HandlerFunc:       handleGetEx,
+		},
+		{
+			Command:           "getdel",
+			Module:            constants.GenericModule,
+			Categories:        []string{constants.WriteCategory, constants.FastCategory},
+			Description:       "(GETDEL key) Get the value at the specified key and delete the key.",
+			Sync:              true,
+			KeyExtractionFunc: getDelKeyFunc,
+			HandlerFunc:       handleGetDel,
+		},
 		{
 			Command:           "mget",
 			Module:            constants.GenericModule,
@@ -523,11 +1202,118 @@ PXAT - Expire at the exat time in unix milliseconds (positive integer).`,
 			Command:           "del",
 			Module:            constants.GenericModule,
 			Categories:        []string{constants.KeyspaceCategory, constants.WriteCategory, constants.FastCategory},
-			Description:       "(DEL key [key ...]) Removes one or more keys from the store.",
+			Description:       "(DEL key [key ...]) Removes one or more keys from the store. Returns the number of keys removed.",
 			Sync:              true,
 			KeyExtractionFunc: delKeyFunc,
 			HandlerFunc:       handleDel,
 		},
+		{
+			Command:    "exists",
+			Module:     constants.GenericModule,
+			Categories: []string{constants.KeyspaceCategory, constants.ReadCategory, constants.FastCategory},
+			Description: `(EXISTS key [key ...]) Returns the number of the specified keys that exist.
+A key that is specified multiple times is counted multiple times.`,
+			Sync:              false,
+			KeyExtractionFunc: existsKeyFunc,
+			HandlerFunc:       handleExists,
+		},
+		{
+			Command:    "type",
+			Module:     constants.GenericModule,
+			Categories: []string{constants.KeyspaceCategory, constants.ReadCategory, constants.FastCategory},
+			Description: `(TYPE key [key ...]) Returns the type of the value stored at the key.
+The type is one of string, list, set, zset, hash or stream. Returns none if the key does not exist.
+If multiple keys are specified, returns an array of the types in the order of the keys.`,
+			Sync:              false,
+			KeyExtractionFunc: typeKeyFunc,
+			HandlerFunc:       handleType,
+		},
+		{
+			Command:    "keys",
+			Module:     constants.GenericModule,
+			Categories: []string{constants.KeyspaceCategory, constants.ReadCategory, constants.SlowCategory, constants.DangerousCategory},
+			Description: `(KEYS pattern) Returns all the keys that match the glob-style pattern.
+This command iterates the entire keyspace, so SCAN should be preferred to iterate large keyspaces.`,
+			Sync:              false,
+			KeyExtractionFunc: keysKeyFunc,
+			HandlerFunc:       handleKeys,
+		},
+		{
+			Command:    "scan",
+			Module:     constants.GenericModule,
+			Categories: []string{constants.KeyspaceCategory, constants.ReadCategory, constants.SlowCategory},
+			Description: `(SCAN cursor [MATCH pattern] [COUNT count] [TYPE type])
+Incrementally iterates the keyspace. Returns the cursor to pass to the next call and a batch of keys.
+Start the iteration with a cursor of 0. The iteration is complete when the returned cursor is 0.
+Keys that exist for the entire iteration are returned exactly once.
+MATCH - Only return the keys that match the glob-style pattern.
+COUNT - The number of keys to visit in each call. Defaults to 10.
+TYPE - Only return the keys that hold values of the type (e.g. string, list, set, zset, hash or stream).`,
+			Sync:              false,
+			KeyExtractionFunc: scanKeyFunc,
+			HandlerFunc:       handleScan,
+		},
+		{
+			Command:    "sort",
+			Module:     constants.GenericModule,
+			Categories: []string{constants.WriteCategory, constants.SetCategory, constants.SortedSetCategory, constants.ListCategory, constants.SlowCategory, constants.DangerousCategory},
+			Description: `(SORT key [BY pattern] [LIMIT offset count] [GET pattern [GET pattern ...]] [ASC | DESC] [ALPHA] [STORE destination])
+Returns the elements of the list, set or sorted set at the key, sorted numerically in ascending order.
+BY - Sort by the values of the keys that the pattern gives for each element, replacing the first "*" in the pattern
+with the element. A pattern ending in "->field" uses the field of the hash at the key. If the pattern has no "*",
+the elements are not sorted.
+LIMIT - Skip offset elements and return at most count elements. A negative count returns all the remaining elements.
+GET - Return the values of the keys that the pattern gives for each element instead of the element. "#" returns the
+element itself. Multiple GET patterns return the values for each element in the order of the patterns.
+ASC - Sort in ascending order (default).
+DESC - Sort in descending order.
+ALPHA - Sort lexicographically instead of numerically.
+STORE - Store the result as a list at the destination key, replacing its value, and return the length of the list.`,
+			Sync:              true,
+			KeyExtractionFunc: sortKeyFunc,
+			HandlerFunc:       handleSort,
+		},
+		{
+			Command:    "sort_ro",
+			Module:     constants.GenericModule,
+			Categories: []string{constants.ReadCategory, constants.SetCategory, constants.SortedSetCategory, constants.ListCategory, constants.SlowCategory, constants.DangerousCategory},
+			Description: `(SORT_RO key [BY pattern] [LIMIT offset count] [GET pattern [GET pattern ...]] [ASC | DESC] [ALPHA])
+The read-only variant of SORT, which has the same options except STORE.`,
+			Sync:              false,
+			KeyExtractionFunc: sortKeyFunc,
+			HandlerFunc:       handleSort,
+		},
+		{
+			Command:    "rename",
+			Module:     constants.GenericModule,
+			Categories: []string{constants.KeyspaceCategory, constants.WriteCategory, constants.SlowCategory},
+			Description: `(RENAME key newkey) Renames the key to newkey, keeping its expiry time.
+If newkey already exists, it is overwritten. Returns an error if the key does not exist.`,
+			Sync:              true,
+			KeyExtractionFunc: renameKeyFunc,
+			HandlerFunc:       handleRename,
+		},
+		{
+			Command:    "renamenx",
+			Module:     constants.GenericModule,
+			Categories: []string{constants.KeyspaceCategory, constants.WriteCategory, constants.FastCategory},
+			Description: `(RENAMENX key newkey) Renames the key to newkey only if newkey does not exist.
+Returns 1 if the key was renamed and 0 if newkey already exists. Returns an error if the key does not exist.`,
+			Sync:              true,
+			KeyExtractionFunc: renameKeyFunc,
+			HandlerFunc:       handleRename,
+		},
+		{
+			Command:    "copy",
+			Module:     constants.GenericModule,
+			Categories: []string{constants.KeyspaceCategory, constants.WriteCategory, constants.SlowCategory},
+			Description: `(COPY source destination [REPLACE]) Copies the value and expiry time of the source key to the destination key.
+REPLACE - Overwrite the destination key if it already exists.
+Returns 1 if the value was copied and 0 otherwise.`,
+			Sync:              true,
+			KeyExtractionFunc: copyKeyFunc,
+			HandlerFunc:       handleCopy,
+		},
 		{
 			Command:    "persist",
 			Module:     constants.GenericModule,
@@ -591,7 +1377,8 @@ Expire the key in the specified number of seconds. This commands turns a key int
 NX - Only set the expiry time if the key has no associated expiry.
 XX - Only set the expiry time if the key already has an expiry time.
 GT - Only set the expiry time if the new expiry time is greater than the current one.
-LT - Only set the expiry time if the new expiry time is less than the current one.`,
+LT - Only set the expiry time if the new expiry time is less than the current one.
+XX can be combined with GT or LT. A time in the past deletes the key.`,
 			Sync:              true,
 			KeyExtractionFunc: expireKeyFunc,
 			HandlerFunc:       handleExpire,
@@ -605,7 +1392,8 @@ Expire the key in the specified number of milliseconds. This commands turns a ke
 NX - Only set the expiry time if the key has no associated expiry.
 XX - Only set the expiry time if the key already has an expiry time.
 GT - Only set the expiry time if the new expiry time is greater than the current one.
-LT - Only set the expiry time if the new expiry time is less than the current one.`,
+LT - Only set the expiry time if the new expiry time is less than the current one.
+XX can be combined with GT or LT. A time in the past deletes the key.`,
 			Sync:              true,
 			KeyExtractionFunc: expireKeyFunc,
 			HandlerFunc:       handleExpire,
@@ -620,7 +1408,8 @@ This commands turns a key into a volatile one.
 NX - Only set the expiry time if the key has no associated expiry.
 XX - Only set the expiry time if the key already has an expiry time.
 GT - Only set the expiry time if the new expiry time is greater than the current one.
-LT - Only set the expiry time if the new expiry time is less than the current one.`,
+LT - Only set the expiry time if the new expiry time is less than the current one.
+XX can be combined with GT or LT. A time in the past deletes the key.`,
 			Sync:              true,
 			KeyExtractionFunc: expireAtKeyFunc,
 			HandlerFunc:       handleExpireAt,
@@ -635,10 +1424,61 @@ This commands turns a key into a volatile one.
 NX - Only set the expiry time if the key has no associated expiry.
 XX - Only set the expiry time if the key already has an expiry time.
 GT - Only set the expiry time if the new expiry time is greater than the current one.
-LT - Only set the expiry time if the new expiry time is less than the current one.`,
+LT - Only set the expiry time if the new expiry time is less than the current one.
+XX can be combined with GT or LT. A time in the past deletes the key.`,
 			Sync:              true,
 			KeyExtractionFunc: expireAtKeyFunc,
 			HandlerFunc:       handleExpireAt,
 		},
+		{
+			Command:     "object",
+			Module:      constants.GenericModule,
+			Categories:  []string{},
+			Description: "Commands for inspecting the values stored at keys",
+			Sync:        false,
+			KeyExtractionFunc: func(cmd []string) (internal.KeyExtractionFuncResult, error) {
+				return internal.KeyExtractionFuncResult{
+					Channels:  make([]string, 0),
+					ReadKeys:  make([]string, 0),
+					WriteKeys: make([]string, 0),
+				}, nil
+			},
+			SubCommands: []internal.SubCommand{
+				{
+					Command:    "refcount",
+					Module:     constants.GenericModule,
+					Categories: []string{constants.KeyspaceCategory, constants.ReadCategory, constants.SlowCategory},
+					Description: `(OBJECT REFCOUNT key) Returns the number of references to the value stored at the key.
+Values are not shared between keys, so the reference count of an existing key is always 1.
+If the key does not exist, nil is returned.`,
+					Sync:              false,
+					KeyExtractionFunc: objectKeyFunc,
+					HandlerFunc:       handleObjectRefCount,
+				},
+				{
+					Command:    "encoding",
+					Module:     constants.GenericModule,
+					Categories: []string{constants.KeyspaceCategory, constants.ReadCategory, constants.SlowCategory},
+					Description: `(OBJECT ENCODING key) Returns the internal representation of the value stored at the key.
+Small sets and sorted sets use the compact listpack encoding, and are converted to the hashtable and skiplist
+encodings once they exceed the set-max-listpack-* and zset-max-listpack-* limits.
+If the key does not exist, nil is returned.`,
+					Sync:              false,
+					KeyExtractionFunc: objectKeyFunc,
+					HandlerFunc:       handleObjectEncoding,
+				},
+				{
+					Command:    "version",
+					Module:     constants.GenericModule,
+					Categories: []string{constants.KeyspaceCategory, constants.ReadCategory, constants.FastCategory},
+					Description: `(OBJECT VERSION key) Returns the version of the key. The version is replaced with a higher version
+every time the key is written, so it can be compared to detect concurrent writes.
+If the key does not exist, nil is returned.`,
+					Sync:              false,
+					KeyExtractionFunc: objectKeyFunc,
+					HandlerFunc:       handleObjectVersion,
+				},
+			},
+		},
 	}
 }
@@ -17,7 +17,9 @@ package generic
 import (
 	"errors"
 	"fmt"
+	"github.com/echovault/echovault/internal"
 	"github.com/echovault/echovault/internal/clock"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -26,6 +28,7 @@ import (
 type SetOptions struct {
 	exists   string
 	get      bool
+	keepTTL  bool
 	expireAt interface{} // Exact expireAt time un unix milliseconds
 }
 
@@ -52,67 +55,189 @@ func getSetCommandOptions(clock clock.Clock, cmd []string, options SetOptions) (
 		options.exists = "XX"
 		return getSetCommandOptions(clock, cmd[1:], options)
 
-	case "ex":
-		if len(cmd) < 2 {
-			return SetOptions{}, errors.New("seconds value required after EX")
+	case "keepttl":
+		if options.expireAt != nil {
+			return SetOptions{}, errors.New("cannot specify KEEPTTL when expiry time is already set")
 		}
+		options.keepTTL = true
+		return getSetCommandOptions(clock, cmd[1:], options)
+
+	case "ex", "px", "exat", "pxat":
+		option := strings.ToUpper(cmd[0])
 		if options.expireAt != nil {
-			return SetOptions{}, errors.New("cannot specify EX when expiry time is already set")
+			return SetOptions{}, fmt.Errorf("cannot specify %s when expiry time is already set", option)
 		}
-		secondsStr := cmd[1]
-		seconds, err := strconv.ParseInt(secondsStr, 10, 64)
-		if err != nil {
-			return SetOptions{}, errors.New("seconds value should be an integer")
+		if options.keepTTL {
+			return SetOptions{}, fmt.Errorf("cannot specify %s when KEEPTTL is already specified", option)
 		}
-		options.expireAt = clock.Now().Add(time.Duration(seconds) * time.Second)
-		return getSetCommandOptions(clock, cmd[2:], options)
-
-	case "px":
 		if len(cmd) < 2 {
-			return SetOptions{}, errors.New("milliseconds value required after PX")
-		}
-		if options.expireAt != nil {
-			return SetOptions{}, errors.New("cannot specify PX when expiry time is already set")
+			return SetOptions{}, fmt.Errorf("%s value required after %s", expiryUnit(option), option)
 		}
-		millisecondsStr := cmd[1]
-		milliseconds, err := strconv.ParseInt(millisecondsStr, 10, 64)
+		expireAt, err := parseExpiry(clock, option, cmd[1])
 		if err != nil {
-			return SetOptions{}, errors.New("milliseconds value should be an integer")
+			return SetOptions{}, err
 		}
-		options.expireAt = clock.Now().Add(time.Duration(milliseconds) * time.Millisecond)
+		options.expireAt = expireAt
 		return getSetCommandOptions(clock, cmd[2:], options)
 
-	case "exat":
-		if len(cmd) < 2 {
-			return SetOptions{}, errors.New("seconds value required after EXAT")
-		}
-		if options.expireAt != nil {
-			return SetOptions{}, errors.New("cannot specify EXAT when expiry time is already set")
+	default:
+		return SetOptions{}, fmt.Errorf("unknown option %s for set command", strings.ToUpper(cmd[0]))
+	}
+}
+
+// GetExOptions holds the expiry options of the GETEX command.
+type GetExOptions struct {
+	persist  bool
+	expireAt interface{} // Exact expireAt time, nil if the expiry time is not changed.
+}
+
+func getGetExCommandOptions(clock clock.Clock, cmd []string) (GetExOptions, error) {
+	var options GetExOptions
+	for i := 0; i < len(cmd); i++ {
+		option := strings.ToUpper(cmd[i])
+		if options.persist || options.expireAt != nil {
+			return GetExOptions{}, fmt.Errorf("cannot specify %s when expiry option is already specified", option)
 		}
-		secondsStr := cmd[1]
-		seconds, err := strconv.ParseInt(secondsStr, 10, 64)
-		if err != nil {
-			return SetOptions{}, errors.New("seconds value should be an integer")
+		switch option {
+		case "PERSIST":
+			options.persist = true
+		case "EX", "PX", "EXAT", "PXAT":
+			if i+1 >= len(cmd) {
+				return GetExOptions{}, fmt.Errorf("%s value required after %s", expiryUnit(option), option)
+			}
+			expireAt, err := parseExpiry(clock, option, cmd[i+1])
+			if err != nil {
+				return GetExOptions{}, err
+			}
+			options.expireAt = expireAt
+			i++
+		default:
+			return GetExOptions{}, fmt.Errorf("unknown option %s for getex command", option)
 		}
-		options.expireAt = time.Unix(seconds, 0)
-		return getSetCommandOptions(clock, cmd[2:], options)
+	}
+	return options, nil
+}
 
-	case "pxat":
-		if len(cmd) < 2 {
-			return SetOptions{}, errors.New("milliseconds value required after PXAT")
+// expiryUnit returns the unit of the value of the EX, PX, EXAT or PXAT option.
+func expiryUnit(option string) string {
+	if option == "EX" || option == "EXAT" {
+		return "seconds"
+	}
+	return "milliseconds"
+}
+
+// parseExpiry returns the expiry time of the EX, PX, EXAT or PXAT option with the provided value.
+// The value must be a positive integer.
+func parseExpiry(clock clock.Clock, option string, value string) (time.Time, error) {
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s value should be an integer", expiryUnit(option))
+	}
+	if n <= 0 {
+		return time.Time{}, fmt.Errorf("%s value should be a positive integer", expiryUnit(option))
+	}
+	switch option {
+	case "EX":
+		return clock.Now().Add(time.Duration(n) * time.Second), nil
+	case "PX":
+		return clock.Now().Add(time.Duration(n) * time.Millisecond), nil
+	case "EXAT":
+		return time.Unix(n, 0), nil
+	default:
+		return time.UnixMilli(n), nil
+	}
+}
+
+// errNoSuchKey is returned by the commands that require the source key to exist.
+var errNoSuchKey = errors.New("no such key")
+
+// uniqueSortedKeys returns the distinct keys in lexicographic order.
+// Commands that lock multiple keys lock them in this order, so that two commands locking
+// overlapping keys cannot each hold a lock that the other is waiting for.
+func uniqueSortedKeys(keys []string) []string {
+	sorted := slices.Clone(keys)
+	slices.Sort(sorted)
+	return slices.Compact(sorted)
+}
+
+// lockKeys write-locks the keys in lexicographic order, creating the keys that don't exist.
+// The returned function releases the locks that were acquired. It must be called even when an error is returned.
+func lockKeys(params internal.HandlerFuncParams, keys []string) (func(), error) {
+	var locked []string
+	unlock := func() {
+		for _, key := range locked {
+			params.KeyUnlock(params.Context, key)
 		}
-		if options.expireAt != nil {
-			return SetOptions{}, errors.New("cannot specify PXAT when expiry time is already set")
+	}
+	for _, key := range uniqueSortedKeys(keys) {
+		var err error
+		if params.KeyExists(params.Context, key) {
+			_, err = params.KeyLock(params.Context, key)
+		} else {
+			_, err = params.CreateKeyAndLock(params.Context, key)
 		}
-		millisecondsStr := cmd[1]
-		milliseconds, err := strconv.ParseInt(millisecondsStr, 10, 64)
 		if err != nil {
-			return SetOptions{}, errors.New("milliseconds value should be an integer")
+			return unlock, err
 		}
-		options.expireAt = time.UnixMilli(milliseconds)
-		return getSetCommandOptions(clock, cmd[2:], options)
+		locked = append(locked, key)
+	}
+	return unlock, nil
+}
 
-	default:
-		return SetOptions{}, fmt.Errorf("unknown option %s for set command", strings.ToUpper(cmd[0]))
+type SortOptions struct {
+	by     string
+	sort   bool // False when the BY pattern has no "*", which skips sorting.
+	offset int
+	count  int // The maximum number of elements to return, or -1 to return all the elements after the offset.
+	get    []string
+	desc   bool
+	alpha  bool
+	store  string
+}
+
+func getSortCommandOptions(cmd []string) (SortOptions, error) {
+	options := SortOptions{sort: true, count: -1}
+	for i := 0; i < len(cmd); i++ {
+		switch strings.ToLower(cmd[i]) {
+		case "asc":
+			options.desc = false
+		case "desc":
+			options.desc = true
+		case "alpha":
+			options.alpha = true
+		case "by":
+			if i+1 >= len(cmd) {
+				return SortOptions{}, errors.New("syntax error")
+			}
+			i += 1
+			options.by = cmd[i]
+			options.sort = strings.Contains(options.by, "*")
+		case "limit":
+			if i+2 >= len(cmd) {
+				return SortOptions{}, errors.New("syntax error")
+			}
+			offset, offsetOk := internal.AdaptType(cmd[i+1]).(int)
+			count, countOk := internal.AdaptType(cmd[i+2]).(int)
+			if !offsetOk || !countOk {
+				return SortOptions{}, errors.New("limit offset and count must be integers")
+			}
+			options.offset, options.count = offset, count
+			i += 2
+		case "get":
+			if i+1 >= len(cmd) {
+				return SortOptions{}, errors.New("syntax error")
+			}
+			i += 1
+			options.get = append(options.get, cmd[i])
+		case "store":
+			if i+1 >= len(cmd) {
+				return SortOptions{}, errors.New("syntax error")
+			}
+			i += 1
+			options.store = cmd[i]
+		default:
+			return SortOptions{}, errors.New("syntax error")
+		}
 	}
+	return options, nil
 }
@@ -19,6 +19,8 @@ import (
 	"fmt"
 	"github.com/echovault/echovault/internal"
 	"github.com/echovault/echovault/internal/constants"
+	"github.com/echovault/echovault/internal/keyindex"
+	"github.com/echovault/echovault/types"
 	"math/rand"
 	"slices"
 	"strconv"
@@ -51,7 +53,7 @@ func handleHSET(params internal.HandlerFuncParams) ([]byte, error) {
 		if err = params.SetValue(params.Context, key, entries); err != nil {
 			return nil, err
 		}
-		return []byte(fmt.Sprintf(":%d\r\n", len(entries))), nil
+		return new(types.ResponseWriter).Integer(len(entries)).Bytes(), nil
 	}
 
 	if _, err = params.KeyLock(params.Context, key); err != nil {
@@ -80,7 +82,7 @@ func handleHSET(params internal.HandlerFuncParams) ([]byte, error) {
 		return nil, err
 	}
 
-	return []byte(fmt.Sprintf(":%d\r\n", count)), nil
+	return new(types.ResponseWriter).Integer(count).Bytes(), nil
 }
 
 func handleHGET(params internal.HandlerFuncParams) ([]byte, error) {
@@ -106,32 +108,12 @@ func handleHGET(params internal.HandlerFuncParams) ([]byte, error) {
 		return nil, fmt.Errorf("value at %s is not a hash", key)
 	}
 
-	var value interface{}
-
-	res := fmt.Sprintf("*%d\r\n", len(fields))
+	res := new(types.ResponseWriter).Array(len(fields))
 	for _, field := range fields {
-		value = hash[field]
-		if value == nil {
-			res += "$-1\r\n"
-			continue
-		}
-		if s, ok := value.(string); ok {
-			res += fmt.Sprintf("$%d\r\n%s\r\n", len(s), s)
-			continue
-		}
-		if d, ok := value.(int); ok {
-			res += fmt.Sprintf(":%d\r\n", d)
-			continue
-		}
-		if f, ok := value.(float64); ok {
-			fs := strconv.FormatFloat(f, 'f', -1, 64)
-			res += fmt.Sprintf("$%d\r\n%s\r\n", len(fs), fs)
-			continue
-		}
-		res += fmt.Sprintf("$-1\r\n")
+		writeValue(res, hash[field])
 	}
 
-	return []byte(res), nil
+	return res.Bytes(), nil
 }
 
 func handleHSTRLEN(params internal.HandlerFuncParams) ([]byte, error) {
@@ -159,30 +141,25 @@ func handleHSTRLEN(params internal.HandlerFuncParams) ([]byte, error) {
 
 	var value interface{}
 
-	res := fmt.Sprintf("*%d\r\n", len(fields))
+	res := new(types.ResponseWriter).Array(len(fields))
 	for _, field := range fields {
 		value = hash[field]
-		if value == nil {
-			res += ":0\r\n"
-			continue
-		}
 		if s, ok := value.(string); ok {
-			res += fmt.Sprintf(":%d\r\n", len(s))
+			res.Integer(len(s))
 			continue
 		}
 		if f, ok := value.(float64); ok {
-			fs := strconv.FormatFloat(f, 'f', -1, 64)
-			res += fmt.Sprintf(":%d\r\n", len(fs))
+			res.Integer(len(strconv.FormatFloat(f, 'f', -1, 64)))
 			continue
 		}
 		if d, ok := value.(int); ok {
-			res += fmt.Sprintf(":%d\r\n", len(strconv.Itoa(d)))
+			res.Integer(len(strconv.Itoa(d)))
 			continue
 		}
-		res += ":0\r\n"
+		res.Integer(0)
 	}
 
-	return []byte(res), nil
+	return res.Bytes(), nil
 }
 
 func handleHVALS(params internal.HandlerFuncParams) ([]byte, error) {
@@ -207,23 +184,12 @@ func handleHVALS(params internal.HandlerFuncParams) ([]byte, error) {
 		return nil, fmt.Errorf("value at %s is not a hash", key)
 	}
 
-	res := fmt.Sprintf("*%d\r\n", len(hash))
+	res := params.ReplyWriter().Array(len(hash))
 	for _, val := range hash {
-		if s, ok := val.(string); ok {
-			res += fmt.Sprintf("$%d\r\n%s\r\n", len(s), s)
-			continue
-		}
-		if f, ok := val.(float64); ok {
-			fs := strconv.FormatFloat(f, 'f', -1, 64)
-			res += fmt.Sprintf("$%d\r\n%s\r\n", len(fs), fs)
-			continue
-		}
-		if d, ok := val.(int); ok {
-			res += fmt.Sprintf(":%d\r\n", d)
-		}
+		writeValue(res, val)
 	}
 
-	return []byte(res), nil
+	return res.Bytes(), nil
 }
 
 func handleHRANDFIELD(params internal.HandlerFuncParams) ([]byte, error) {
@@ -271,29 +237,19 @@ func handleHRANDFIELD(params internal.HandlerFuncParams) ([]byte, error) {
 
 	// If count is the >= hash length, then return the entire hash
 	if count >= len(hash) {
-		res := fmt.Sprintf("*%d\r\n", len(hash))
+		res := new(types.ResponseWriter)
 		if withvalues {
-			res = fmt.Sprintf("*%d\r\n", len(hash)*2)
+			res.Map(len(hash))
+		} else {
+			res.Array(len(hash))
 		}
 		for field, value := range hash {
-			res += fmt.Sprintf("$%d\r\n%s\r\n", len(field), field)
+			res.Bulk(field)
 			if withvalues {
-				if s, ok := value.(string); ok {
-					res += fmt.Sprintf("$%d\r\n%s\r\n", len(s), s)
-					continue
-				}
-				if f, ok := value.(float64); ok {
-					fs := strconv.FormatFloat(f, 'f', -1, 64)
-					res += fmt.Sprintf("$%d\r\n%s\r\n", len(fs), fs)
-					continue
-				}
-				if d, ok := value.(int); ok {
-					res += fmt.Sprintf(":%d\r\n", d)
-					continue
-				}
+				writeValue(res, value)
 			}
 		}
-		return []byte(res), nil
+		return res.Bytes(), nil
 	}
 
 	// Get all the fields
@@ -316,30 +272,20 @@ func handleHRANDFIELD(params internal.HandlerFuncParams) ([]byte, error) {
 		}
 	}
 
-	res := fmt.Sprintf("*%d\r\n", len(pluckedFields))
+	res := new(types.ResponseWriter)
 	if withvalues {
-		res = fmt.Sprintf("*%d\r\n", len(pluckedFields)*2)
+		res.Map(len(pluckedFields))
+	} else {
+		res.Array(len(pluckedFields))
 	}
 	for _, field := range pluckedFields {
-		res += fmt.Sprintf("$%d\r\n%s\r\n", len(field), field)
+		res.Bulk(field)
 		if withvalues {
-			if s, ok := hash[field].(string); ok {
-				res += fmt.Sprintf("$%d\r\n%s\r\n", len(s), s)
-				continue
-			}
-			if f, ok := hash[field].(float64); ok {
-				fs := strconv.FormatFloat(f, 'f', -1, 64)
-				res += fmt.Sprintf("$%d\r\n%s\r\n", len(fs), fs)
-				continue
-			}
-			if d, ok := hash[field].(int); ok {
-				res += fmt.Sprintf(":%d\r\n", d)
-				continue
-			}
+			writeValue(res, hash[field])
 		}
 	}
 
-	return []byte(res), nil
+	return res.Bytes(), nil
 }
 
 func handleHLEN(params internal.HandlerFuncParams) ([]byte, error) {
@@ -364,7 +310,7 @@ func handleHLEN(params internal.HandlerFuncParams) ([]byte, error) {
 		return nil, fmt.Errorf("value at %s is not a hash", key)
 	}
 
-	return []byte(fmt.Sprintf(":%d\r\n", len(hash))), nil
+	return new(types.ResponseWriter).Integer(len(hash)).Bytes(), nil
 }
 
 func handleHKEYS(params internal.HandlerFuncParams) ([]byte, error) {
@@ -389,12 +335,12 @@ func handleHKEYS(params internal.HandlerFuncParams) ([]byte, error) {
 		return nil, fmt.Errorf("value at %s is not a hash", key)
 	}
 
-	res := fmt.Sprintf("*%d\r\n", len(hash))
-	for field, _ := range hash {
-		res += fmt.Sprintf("$%d\r\n%s\r\n", len(field), field)
+	res := params.ReplyWriter().Array(len(hash))
+	for field := range hash {
+		res.Bulk(field)
 	}
 
-	return []byte(res), nil
+	return res.Bytes(), nil
 }
 
 func handleHINCRBY(params internal.HandlerFuncParams) ([]byte, error) {
@@ -434,13 +380,13 @@ func handleHINCRBY(params internal.HandlerFuncParams) ([]byte, error) {
 			if err = params.SetValue(params.Context, key, hash); err != nil {
 				return nil, err
 			}
-			return []byte(fmt.Sprintf("+%s\r\n", strconv.FormatFloat(floatIncrement, 'f', -1, 64))), nil
+			return new(types.ResponseWriter).Simple(strconv.FormatFloat(floatIncrement, 'f', -1, 64)).Bytes(), nil
 		} else {
 			hash[field] = intIncrement
 			if err = params.SetValue(params.Context, key, hash); err != nil {
 				return nil, err
 			}
-			return []byte(fmt.Sprintf(":%d\r\n", intIncrement)), nil
+			return new(types.ResponseWriter).Integer(intIncrement).Bytes(), nil
 		}
 	}
 
@@ -482,11 +428,11 @@ func handleHINCRBY(params internal.HandlerFuncParams) ([]byte, error) {
 	}
 
 	if f, ok := hash[field].(float64); ok {
-		return []byte(fmt.Sprintf("+%s\r\n", strconv.FormatFloat(f, 'f', -1, 64))), nil
+		return new(types.ResponseWriter).Simple(strconv.FormatFloat(f, 'f', -1, 64)).Bytes(), nil
 	}
 
 	i, _ := hash[field].(int)
-	return []byte(fmt.Sprintf(":%d\r\n", i)), nil
+	return new(types.ResponseWriter).Integer(i).Bytes(), nil
 }
 
 func handleHGETALL(params internal.HandlerFuncParams) ([]byte, error) {
@@ -511,22 +457,62 @@ func handleHGETALL(params internal.HandlerFuncParams) ([]byte, error) {
 		return nil, fmt.Errorf("value at %s is not a hash", key)
 	}
 
-	res := fmt.Sprintf("*%d\r\n", len(hash)*2)
+	res := params.ReplyWriter().Map(len(hash))
 	for field, value := range hash {
-		res += fmt.Sprintf("$%d\r\n%s\r\n", len(field), field)
-		if s, ok := value.(string); ok {
-			res += fmt.Sprintf("$%d\r\n%s\r\n", len(s), s)
-		}
-		if f, ok := value.(float64); ok {
-			fs := strconv.FormatFloat(f, 'f', -1, 64)
-			res += fmt.Sprintf("$%d\r\n%s\r\n", len(fs), fs)
+		res.Bulk(field)
+		writeValue(res, value)
+	}
+
+	return res.Bytes(), nil
+}
+
+func handleHSCAN(params internal.HandlerFuncParams) ([]byte, error) {
+	keys, err := hscanKeyFunc(params.Command)
+	if err != nil {
+		return nil, err
+	}
+
+	key := keys.ReadKeys[0]
+
+	options, err := internal.ParseScanOptions(params.Command[2:], false)
+	if err != nil {
+		return nil, err
+	}
+
+	if !params.KeyExists(params.Context, key) {
+		return internal.EncodeScanReply(0, nil), nil
+	}
+
+	if _, err = params.KeyRLock(params.Context, key); err != nil {
+		return nil, err
+	}
+	defer params.KeyRUnlock(params.Context, key)
+
+	hash, ok := params.GetValue(params.Context, key).(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("value at %s is not a hash", key)
+	}
+
+	fields := make([]string, 0, len(hash))
+	for field := range hash {
+		fields = append(fields, field)
+	}
+	fields, cursor := keyindex.ScanMembers(fields, options.Cursor, options.Count)
+
+	res := make([]string, 0, len(fields)*2)
+	for _, field := range fields {
+		if options.Match != nil && !options.Match.Match(field) {
+			continue
 		}
-		if d, ok := value.(int); ok {
-			res += fmt.Sprintf(":%d\r\n", d)
+		switch value := hash[field].(type) {
+		case float64:
+			res = append(res, field, strconv.FormatFloat(value, 'f', -1, 64))
+		default:
+			res = append(res, field, fmt.Sprintf("%v", value))
 		}
 	}
 
-	return []byte(res), nil
+	return internal.EncodeScanReply(cursor, res), nil
 }
 
 func handleHEXISTS(params internal.HandlerFuncParams) ([]byte, error) {
@@ -595,7 +581,22 @@ func handleHDEL(params internal.HandlerFuncParams) ([]byte, error) {
 		return nil, err
 	}
 
-	return []byte(fmt.Sprintf(":%d\r\n", count)), nil
+	return new(types.ResponseWriter).Integer(count).Bytes(), nil
+}
+
+// writeValue writes a hash value to the reply. Strings and floats are written as bulk strings, integers as
+// integers, and missing values as nil.
+func writeValue(res *types.ResponseWriter, value interface{}) {
+	switch v := value.(type) {
+	case string:
+		res.Bulk(v)
+	case float64:
+		res.Float(v)
+	case int:
+		res.Integer(v)
+	default:
+		res.Null()
+	}
 }
 
 func Commands() []internal.Command {
@@ -700,6 +701,17 @@ Return the string length of the values stored at the specified fields. 0 if the
 			KeyExtractionFunc: hgetallKeyFunc,
 			HandlerFunc:       handleHGETALL,
 		},
+		{
+			Command:    "hscan",
+			Module:     constants.HashModule,
+			Categories: []string{constants.HashCategory, constants.ReadCategory, constants.SlowCategory},
+			Description: `(HSCAN key cursor [MATCH pattern] [COUNT count])
+Incrementally iterates the fields of a hash. Returns the cursor to pass to the next call and
+a batch of fields with their values. The iteration is complete when the returned cursor is 0.`,
+			Sync:              false,
+			KeyExtractionFunc: hscanKeyFunc,
+			HandlerFunc:       handleHSCAN,
+		},
 		{
 			Command:           "hexists",
 			Module:            constants.HashModule,
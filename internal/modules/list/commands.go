@@ -15,15 +15,24 @@
 package list
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/echovault/echovault/internal"
 	"github.com/echovault/echovault/internal/constants"
 	"math"
 	"slices"
+	"strconv"
 	"strings"
+	"time"
 )
 
+// redisCompat reports whether a Redis protocol-compat level is configured. Redis replies to reads
+// on keys that do not exist with nil or an empty array instead of an error.
+func redisCompat(params internal.HandlerFuncParams) bool {
+	return internal.IsRedisCompat(params.GetConfigParameters()["protocol-compat"])
+}
+
 func handleLLen(params internal.HandlerFuncParams) ([]byte, error) {
 	keys, err := llenKeyFunc(params.Command)
 	if err != nil {
@@ -98,6 +107,9 @@ func handleLRange(params internal.HandlerFuncParams) ([]byte, error) {
 	}
 
 	if !params.KeyExists(params.Context, key) {
+		if redisCompat(params) {
+			return []byte("*0\r\n"), nil
+		}
 		return nil, errors.New("LRANGE command on non-list item")
 	}
 
@@ -250,6 +262,54 @@ func handleLTrim(params internal.HandlerFuncParams) ([]byte, error) {
 	return []byte(constants.OkResponse), nil
 }
 
+func handleLInsert(params internal.HandlerFuncParams) ([]byte, error) {
+	keys, err := linsertKeyFunc(params.Command)
+	if err != nil {
+		return nil, err
+	}
+
+	key := keys.WriteKeys[0]
+	position := strings.ToLower(params.Command[2])
+	pivot := params.Command[3]
+
+	if !slices.Contains([]string{"before", "after"}, position) {
+		return nil, errors.New("position must be BEFORE or AFTER")
+	}
+
+	if !params.KeyExists(params.Context, key) {
+		// If the key does not exist, the list is considered empty and no operation is performed.
+		return []byte(":0\r\n"), nil
+	}
+
+	if _, err = params.KeyLock(params.Context, key); err != nil {
+		return nil, err
+	}
+	defer params.KeyUnlock(params.Context, key)
+
+	list, ok := params.GetValue(params.Context, key).([]interface{})
+	if !ok {
+		return nil, errors.New("LINSERT command on non-list item")
+	}
+
+	index := slices.IndexFunc(list, func(elem interface{}) bool {
+		return fmt.Sprintf("%v", elem) == pivot
+	})
+	if index == -1 {
+		// Return -1 if the pivot was not found.
+		return []byte(":-1\r\n"), nil
+	}
+	if position == "after" {
+		index += 1
+	}
+
+	list = slices.Insert(list, index, internal.AdaptType(params.Command[4]))
+	if err = params.SetValue(params.Context, key, list); err != nil {
+		return nil, err
+	}
+
+	return []byte(fmt.Sprintf(":%d\r\n", len(list))), nil
+}
+
 func handleLRem(params internal.HandlerFuncParams) ([]byte, error) {
 	keys, err := lremKeyFunc(params.Command)
 	if err != nil {
@@ -332,50 +392,246 @@ func handleLMove(params internal.HandlerFuncParams) ([]byte, error) {
 		return nil, errors.New("wherefrom and whereto arguments must be either LEFT or RIGHT")
 	}
 
-	if !params.KeyExists(params.Context, source) || !params.KeyExists(params.Context, destination) {
+	if !params.KeyExists(params.Context, source) && !redisCompat(params) {
 		return nil, errors.New("both source and destination must be lists")
 	}
 
-	if _, err = params.KeyLock(params.Context, source); err != nil {
+	element, ok, err := moveElement(params.Context, params, source, destination, whereFrom, whereTo)
+	if err != nil {
 		return nil, err
 	}
-	defer params.KeyUnlock(params.Context, source)
+	if !ok {
+		return []byte("$-1\r\n"), nil
+	}
 
-	_, err = params.KeyLock(params.Context, destination)
+	return []byte(fmt.Sprintf("$%d\r\n%s\r\n", len(element), element)), nil
+}
+
+func handleBLMove(params internal.HandlerFuncParams) ([]byte, error) {
+	keys, err := blmoveKeyFunc(params.Command)
 	if err != nil {
 		return nil, err
 	}
-	defer params.KeyUnlock(params.Context, destination)
 
-	sourceList, sourceOk := params.GetValue(params.Context, source).([]interface{})
-	destinationList, destinationOk := params.GetValue(params.Context, destination).([]interface{})
+	source, destination := keys.WriteKeys[0], keys.WriteKeys[1]
+	whereFrom := strings.ToLower(params.Command[3])
+	whereTo := strings.ToLower(params.Command[4])
+
+	if !slices.Contains([]string{"left", "right"}, whereFrom) || !slices.Contains([]string{"left", "right"}, whereTo) {
+		return nil, errors.New("wherefrom and whereto arguments must be either LEFT or RIGHT")
+	}
+
+	timeout, err := strconv.ParseFloat(params.Command[5], 64)
+	if err != nil || timeout < 0 {
+		return nil, errors.New("timeout must be a non-negative number")
+	}
 
-	if !sourceOk || !destinationOk {
-		return nil, errors.New("both source and destination must be lists")
+	// A timeout of 0 blocks until an element is available.
+	ctx := params.Context
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(timeout*float64(time.Second)))
+		defer cancel()
+	}
+
+	// Start watching the source before checking it so that a push between the check and the wait is not missed.
+	watcher := params.WatchKeys(source)
+	defer watcher.Close()
+
+	for {
+		element, ok, err := moveElement(ctx, params, source, destination, whereFrom, whereTo)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return []byte(fmt.Sprintf("$%d\r\n%s\r\n", len(element), element)), nil
+		}
+
+		select {
+		case <-watcher.C:
+			// The source was modified, try again.
+		case <-ctx.Done():
+			if params.Context.Err() != nil {
+				return nil, context.Cause(params.Context)
+			}
+			// Return nil when the timeout expires.
+			return []byte("$-1\r\n"), nil
+		}
 	}
+}
 
-	switch whereFrom {
-	case "left":
-		err = params.SetValue(params.Context, source, append([]interface{}{}, sourceList[1:]...))
-		if whereTo == "left" {
-			err = params.SetValue(params.Context, destination, append(sourceList[0:1], destinationList...))
-		} else if whereTo == "right" {
-			err = params.SetValue(params.Context, destination, append(destinationList, sourceList[0]))
+// moveElement pops an element from the whereFrom end of the source list and pushes it to the whereTo end
+// of the destination list, creating the destination list if it does not exist.
+// Returns false if the source does not exist or is empty.
+func moveElement(ctx context.Context, params internal.HandlerFuncParams, source, destination, whereFrom, whereTo string) (string, bool, error) {
+	if !params.KeyExists(ctx, source) {
+		return "", false, nil
+	}
+
+	// Wait for a state copy in progress to finish before modifying the store.
+	done := params.WaitForStateCopy()
+	defer done()
+
+	// Lock the keys in lexicographic order, so that two moves in opposite directions between
+	// the same lists cannot deadlock each other. A destination that doesn't exist yet is created
+	// when it's locked, and deleted again if nothing is moved to it, so that a failed move does
+	// not leave an empty key behind.
+	var sourceLocked, destinationLocked, destinationCreated bool
+	defer func() {
+		if sourceLocked {
+			params.KeyUnlock(ctx, source)
+		}
+		switch {
+		case destinationLocked && destinationCreated:
+			params.DeleteLockedKey(ctx, destination)
+		case destinationLocked:
+			params.KeyUnlock(ctx, destination)
 		}
-	case "right":
-		err = params.SetValue(params.Context, source, append([]interface{}{}, sourceList[:len(sourceList)-1]...))
-		if whereTo == "left" {
-			err = params.SetValue(params.Context, destination, append(sourceList[len(sourceList)-1:], destinationList...))
-		} else if whereTo == "right" {
-			err = params.SetValue(params.Context, destination, append(destinationList, sourceList[len(sourceList)-1]))
+	}()
+	keys := []string{source}
+	if destination != source {
+		keys = append(keys, destination)
+		slices.Sort(keys)
+	}
+	for _, key := range keys {
+		if key == source {
+			if _, err := params.KeyLock(ctx, source); err != nil {
+				if !params.KeyExists(ctx, source) {
+					return "", false, nil
+				}
+				return "", false, err
+			}
+			sourceLocked = true
+			continue
+		}
+		if _, err := params.CreateKeyAndLock(ctx, destination); err != nil {
+			return "", false, err
+		}
+		destinationLocked = true
+		// A key that was just created has no value yet.
+		destinationCreated = params.GetValue(ctx, destination) == nil
+	}
+
+	sourceList, ok := params.GetValue(ctx, source).([]interface{})
+	if !ok {
+		return "", false, errors.New("both source and destination must be lists")
+	}
+	var destinationList []interface{}
+	if destination != source && !destinationCreated {
+		if destinationList, ok = params.GetValue(ctx, destination).([]interface{}); !ok {
+			return "", false, errors.New("both source and destination must be lists")
 		}
 	}
+	if len(sourceList) == 0 {
+		return "", false, nil
+	}
+
+	var element interface{}
+	if whereFrom == "left" {
+		element, sourceList = sourceList[0], slices.Clone(sourceList[1:])
+	} else {
+		element, sourceList = sourceList[len(sourceList)-1], slices.Clone(sourceList[:len(sourceList)-1])
+	}
+
+	if destination == source {
+		// Moving within the same list rotates it.
+		destinationList = sourceList
+	} else if err := params.SetValue(ctx, source, sourceList); err != nil {
+		return "", false, err
+	}
+
+	if whereTo == "left" {
+		destinationList = append([]interface{}{element}, destinationList...)
+	} else {
+		destinationList = append(slices.Clone(destinationList), element)
+	}
+	if err := params.SetValue(ctx, destination, destinationList); err != nil {
+		return "", false, err
+	}
+	destinationCreated = false
 
+	return fmt.Sprintf("%v", element), true, nil
+}
+
+func handleLMPop(params internal.HandlerFuncParams) ([]byte, error) {
+	// The Redis protocol-compat levels take numkeys before the keys.
+	keys, args, err := lmpopArgs(params.Command, redisCompat(params))
 	if err != nil {
 		return nil, err
 	}
 
-	return []byte(constants.OkResponse), nil
+	count, where, err := parseLMPOPOptions(args)
+	if err != nil {
+		return nil, err
+	}
+
+	// Pop from the first non-empty list in the order that the keys were provided.
+	for _, key := range keys {
+		if !params.KeyExists(params.Context, key) {
+			continue
+		}
+
+		if _, err = params.KeyLock(params.Context, key); err != nil {
+			return nil, err
+		}
+
+		list, ok := params.GetValue(params.Context, key).([]interface{})
+		if !ok {
+			params.KeyUnlock(params.Context, key)
+			return nil, errors.New("LMPOP command on non-list item")
+		}
+		if len(list) == 0 {
+			params.KeyUnlock(params.Context, key)
+			continue
+		}
+
+		n := min(count, len(list))
+		var popped []interface{}
+		if where == "left" {
+			popped, list = list[:n], slices.Clone(list[n:])
+		} else {
+			popped, list = slices.Clone(list[len(list)-n:]), slices.Clone(list[:len(list)-n])
+			slices.Reverse(popped)
+		}
+		err = params.SetValue(params.Context, key, list)
+		params.KeyUnlock(params.Context, key)
+		if err != nil {
+			return nil, err
+		}
+
+		res := fmt.Sprintf("*2\r\n$%d\r\n%s\r\n*%d\r\n", len(key), key, len(popped))
+		for _, element := range popped {
+			str := fmt.Sprintf("%v", element)
+			res += fmt.Sprintf("$%d\r\n%s\r\n", len(str), str)
+		}
+		return []byte(res), nil
+	}
+
+	// Return a null array when all the lists are empty.
+	return []byte("*-1\r\n"), nil
+}
+
+// parseLMPOPOptions parses the <LEFT | RIGHT> [COUNT count] arguments of LMPOP.
+func parseLMPOPOptions(args []string) (int, string, error) {
+	where := strings.ToLower(args[0])
+	if where != "left" && where != "right" {
+		return 0, "", errors.New(constants.WrongArgsResponse)
+	}
+	switch len(args) {
+	case 1:
+		return 1, where, nil
+	case 3:
+		if !strings.EqualFold(args[1], "count") {
+			return 0, "", fmt.Errorf("unknown option %s", args[1])
+		}
+		count, err := strconv.Atoi(args[2])
+		if err != nil || count <= 0 {
+			return 0, "", errors.New("count must be a positive integer")
+		}
+		return count, where, nil
+	default:
+		return 0, "", errors.New(constants.WrongArgsResponse)
+	}
 }
 
 func handleLPush(params internal.HandlerFuncParams) ([]byte, error) {
@@ -481,6 +737,9 @@ func handlePop(params internal.HandlerFuncParams) ([]byte, error) {
 	key := keys.WriteKeys[0]
 
 	if !params.KeyExists(params.Context, key) {
+		if redisCompat(params) {
+			return []byte("$-1\r\n"), nil
+		}
 		return nil, fmt.Errorf("%s command on non-list item", strings.ToUpper(params.Command[0]))
 	}
 
@@ -508,6 +767,91 @@ func handlePop(params internal.HandlerFuncParams) ([]byte, error) {
 	}
 }
 
+func handleBlockingPop(params internal.HandlerFuncParams) ([]byte, error) {
+	keys, err := blockingPopKeyFunc(params.Command)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout, err := strconv.ParseFloat(params.Command[len(params.Command)-1], 64)
+	if err != nil || timeout < 0 {
+		return nil, errors.New("timeout must be a non-negative number")
+	}
+
+	// A timeout of 0 blocks until an element is available.
+	ctx := params.Context
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(timeout*float64(time.Second)))
+		defer cancel()
+	}
+
+	// Start watching the keys before checking them so that a push between the check and the wait is not missed.
+	watcher := params.WatchKeys(keys.WriteKeys...)
+	defer watcher.Close()
+
+	for {
+		// Pop from the first non-empty list in the order that the keys were provided.
+		for _, key := range keys.WriteKeys {
+			element, ok, err := popElement(ctx, params, key)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				return []byte(fmt.Sprintf("*2\r\n$%d\r\n%s\r\n$%d\r\n%s\r\n", len(key), key, len(element), element)), nil
+			}
+		}
+
+		select {
+		case <-watcher.C:
+			// One of the keys was modified, try again.
+		case <-ctx.Done():
+			if params.Context.Err() != nil {
+				return nil, context.Cause(params.Context)
+			}
+			// Return a null array when the timeout expires.
+			return []byte("*-1\r\n"), nil
+		}
+	}
+}
+
+// popElement pops an element from the list at the key for a blocking pop command.
+// Returns false if the key does not exist or the list is empty.
+func popElement(ctx context.Context, params internal.HandlerFuncParams, key string) (string, bool, error) {
+	if !params.KeyExists(ctx, key) {
+		return "", false, nil
+	}
+
+	// Wait for a state copy in progress to finish before modifying the store.
+	done := params.WaitForStateCopy()
+	defer done()
+
+	if _, err := params.KeyLock(ctx, key); err != nil {
+		return "", false, err
+	}
+	defer params.KeyUnlock(ctx, key)
+
+	list, ok := params.GetValue(ctx, key).([]interface{})
+	if !ok {
+		return "", false, fmt.Errorf("%s command on non-list item", strings.ToUpper(params.Command[0]))
+	}
+	if len(list) == 0 {
+		return "", false, nil
+	}
+
+	var element interface{}
+	if strings.EqualFold(params.Command[0], "brpop") {
+		element, list = list[len(list)-1], list[:len(list)-1]
+	} else {
+		element, list = list[0], list[1:]
+	}
+	if err := params.SetValue(ctx, key, list); err != nil {
+		return "", false, err
+	}
+
+	return fmt.Sprintf("%v", element), true, nil
+}
+
 func Commands() []internal.Command {
 	return []internal.Command{
 		{
@@ -537,6 +881,37 @@ func Commands() []internal.Command {
 			KeyExtractionFunc: popKeyFunc,
 			HandlerFunc:       handlePop,
 		},
+		{
+			Command:    "blpop",
+			Module:     constants.ListModule,
+			Categories: []string{constants.ListCategory, constants.WriteCategory, constants.BlockingCategory, constants.SlowCategory},
+			Description: `(BLPOP key [key ...] timeout) Removes and returns the first element of the first non-empty list.
+Blocks until an element is available or the timeout in seconds expires. A timeout of 0 blocks indefinitely.`,
+			Sync:              false,
+			KeyExtractionFunc: blockingPopKeyFunc,
+			HandlerFunc:       handleBlockingPop,
+		},
+		{
+			Command:    "blmove",
+			Module:     constants.ListModule,
+			Categories: []string{constants.ListCategory, constants.WriteCategory, constants.BlockingCategory, constants.SlowCategory},
+			Description: `(BLMOVE source destination <LEFT | RIGHT> <LEFT | RIGHT> timeout) Blocking version of LMOVE.
+Blocks until an element is available or the timeout in seconds expires. A timeout of 0 blocks indefinitely.`,
+			Sync:              false,
+			KeyExtractionFunc: blmoveKeyFunc,
+			HandlerFunc:       handleBLMove,
+		},
+		{
+			Command:    "lmpop",
+			Module:     constants.ListModule,
+			Categories: []string{constants.ListCategory, constants.WriteCategory, constants.SlowCategory},
+			Description: `(LMPOP [numkeys] key [key ...] <LEFT | RIGHT> [COUNT count])
+Pops up to 'count' elements from the first non-empty list and returns the key with the popped elements.
+numkeys is required in the redis-6 and redis-7 protocol-compat levels and not accepted otherwise.`,
+			Sync:              true,
+			KeyExtractionFunc: lmpopKeyFunc,
+			HandlerFunc:       handleLMPop,
+		},
 		{
 			Command:           "llen",
 			Module:            constants.ListModule,
@@ -592,10 +967,23 @@ func Commands() []internal.Command {
 			HandlerFunc:       handleLRem,
 		},
 		{
-			Command:           "lmove",
-			Module:            constants.ListModule,
-			Categories:        []string{constants.ListCategory, constants.WriteCategory, constants.SlowCategory},
-			Description:       "(LMOVE source destination <LEFT | RIGHT> <LEFT | RIGHT>) Move element from one list to the other specifying left/right for both lists.",
+			Command:    "linsert",
+			Module:     constants.ListModule,
+			Categories: []string{constants.ListCategory, constants.WriteCategory, constants.SlowCategory},
+			Description: `(LINSERT key <BEFORE | AFTER> pivot element) Inserts the element in the list before or after the
+first occurrence of the pivot. Returns the length of the list after the insert, -1 if the pivot was not found,
+or 0 if the key does not exist.`,
+			Sync:              true,
+			KeyExtractionFunc: linsertKeyFunc,
+			HandlerFunc:       handleLInsert,
+		},
+		{
+			Command:    "lmove",
+			Module:     constants.ListModule,
+			Categories: []string{constants.ListCategory, constants.WriteCategory, constants.SlowCategory},
+			Description: `(LMOVE source destination <LEFT | RIGHT> <LEFT | RIGHT>)
+Moves an element from one end of the source list to one end of the destination list and returns it.
+The destination list is created if it does not exist.`,
 			Sync:              true,
 			KeyExtractionFunc: lmoveKeyFunc,
 			HandlerFunc:       handleLMove,
@@ -609,6 +997,16 @@ func Commands() []internal.Command {
 			KeyExtractionFunc: popKeyFunc,
 			HandlerFunc:       handlePop,
 		},
+		{
+			Command:    "brpop",
+			Module:     constants.ListModule,
+			Categories: []string{constants.ListCategory, constants.WriteCategory, constants.BlockingCategory, constants.SlowCategory},
+			Description: `(BRPOP key [key ...] timeout) Removes and returns the last element of the first non-empty list.
+Blocks until an element is available or the timeout in seconds expires. A timeout of 0 blocks indefinitely.`,
+			Sync:              false,
+			KeyExtractionFunc: blockingPopKeyFunc,
+			HandlerFunc:       handleBlockingPop,
+		},
 		{
 			Command:           "rpush",
 			Module:            constants.ListModule,
@@ -18,6 +18,9 @@ import (
 	"errors"
 	"github.com/echovault/echovault/internal"
 	"github.com/echovault/echovault/internal/constants"
+	"slices"
+	"strconv"
+	"strings"
 )
 
 func lpushKeyFunc(cmd []string) (internal.KeyExtractionFuncResult, error) {
@@ -42,6 +45,17 @@ func popKeyFunc(cmd []string) (internal.KeyExtractionFuncResult, error) {
 	}, nil
 }
 
+func blockingPopKeyFunc(cmd []string) (internal.KeyExtractionFuncResult, error) {
+	if len(cmd) < 3 {
+		return internal.KeyExtractionFuncResult{}, errors.New(constants.WrongArgsResponse)
+	}
+	return internal.KeyExtractionFuncResult{
+		Channels:  make([]string, 0),
+		ReadKeys:  make([]string, 0),
+		WriteKeys: cmd[1 : len(cmd)-1],
+	}, nil
+}
+
 func llenKeyFunc(cmd []string) (internal.KeyExtractionFuncResult, error) {
 	if len(cmd) != 2 {
 		return internal.KeyExtractionFuncResult{}, errors.New(constants.WrongArgsResponse)
@@ -108,6 +122,17 @@ func lremKeyFunc(cmd []string) (internal.KeyExtractionFuncResult, error) {
 	}, nil
 }
 
+func linsertKeyFunc(cmd []string) (internal.KeyExtractionFuncResult, error) {
+	if len(cmd) != 5 {
+		return internal.KeyExtractionFuncResult{}, errors.New(constants.WrongArgsResponse)
+	}
+	return internal.KeyExtractionFuncResult{
+		Channels:  make([]string, 0),
+		ReadKeys:  make([]string, 0),
+		WriteKeys: cmd[1:2],
+	}, nil
+}
+
 func rpushKeyFunc(cmd []string) (internal.KeyExtractionFuncResult, error) {
 	if len(cmd) < 3 {
 		return internal.KeyExtractionFuncResult{}, errors.New(constants.WrongArgsResponse)
@@ -129,3 +154,57 @@ func lmoveKeyFunc(cmd []string) (internal.KeyExtractionFuncResult, error) {
 		WriteKeys: cmd[1:3],
 	}, nil
 }
+
+func blmoveKeyFunc(cmd []string) (internal.KeyExtractionFuncResult, error) {
+	if len(cmd) != 6 {
+		return internal.KeyExtractionFuncResult{}, errors.New(constants.WrongArgsResponse)
+	}
+	return internal.KeyExtractionFuncResult{
+		Channels:  make([]string, 0),
+		ReadKeys:  make([]string, 0),
+		WriteKeys: cmd[1:3],
+	}, nil
+}
+
+// lmpopArgs parses the arguments of LMPOP. With numKeys, the command takes the Redis form
+// LMPOP numkeys key [key ...] <LEFT | RIGHT> [COUNT count]. Otherwise, it takes the form
+// LMPOP key [key ...] <LEFT | RIGHT> [COUNT count]. Returns the keys and the arguments that follow them.
+func lmpopArgs(cmd []string, numKeys bool) ([]string, []string, error) {
+	if numKeys {
+		if len(cmd) < 4 {
+			return nil, nil, errors.New(constants.WrongArgsResponse)
+		}
+		n, err := strconv.Atoi(cmd[1])
+		if err != nil || n <= 0 {
+			return nil, nil, errors.New("numkeys should be greater than 0")
+		}
+		// The keys must be followed by at least LEFT or RIGHT.
+		if n > len(cmd)-3 {
+			return nil, nil, errors.New("number of keys can't be greater than number of args")
+		}
+		return cmd[2 : 2+n], cmd[2+n:], nil
+	}
+
+	if len(cmd) < 3 {
+		return nil, nil, errors.New(constants.WrongArgsResponse)
+	}
+	endIdx := slices.IndexFunc(cmd[2:], func(s string) bool {
+		return slices.Contains([]string{"LEFT", "RIGHT"}, strings.ToUpper(s))
+	})
+	if endIdx == -1 {
+		return nil, nil, errors.New(constants.WrongArgsResponse)
+	}
+	return cmd[1 : endIdx+2], cmd[endIdx+2:], nil
+}
+
+func lmpopKeyFunc(cmd []string) (internal.KeyExtractionFuncResult, error) {
+	keys, _, err := lmpopArgs(cmd, internal.IsRedisCompat(internal.ProtocolCompat()))
+	if err != nil {
+		return internal.KeyExtractionFuncResult{}, err
+	}
+	return internal.KeyExtractionFuncResult{
+		Channels:  make([]string, 0),
+		ReadKeys:  make([]string, 0),
+		WriteKeys: keys,
+	}, nil
+}
@@ -15,19 +15,24 @@
 package pubsub
 
 import (
-	"github.com/gobwas/glob"
+	"github.com/echovault/echovault/internal/glob"
 	"github.com/tidwall/resp"
 	"log"
 	"net"
+	"slices"
 	"sync"
+	"sync/atomic"
 )
 
 type Channel struct {
 	name             string                   // Channel name. This can be a glob pattern string.
-	pattern          glob.Glob                // Compiled glob pattern. This is nil if the channel is not a pattern channel.
+	pattern          *glob.Glob               // Compiled glob pattern. This is nil if the channel is not a pattern channel.
 	subscribersRWMut sync.RWMutex             // RWMutex to concurrency control when accessing channel subscribers.
 	subscribers      map[*net.Conn]*resp.Conn // Map containing the channel subscribers.
 	messageChan      *chan string             // Messages published to this channel will be sent to this channel.
+	delivered        atomic.Uint64            // The number of messages delivered to the channel's subscribers.
+	retention        int                      // The number of recent messages kept for replay. 0 disables retention.
+	retained         []string                 // The most recent messages published to the channel, oldest first.
 }
 
 // WithName option sets the channels name.
@@ -41,7 +46,7 @@ func WithName(name string) func(channel *Channel) {
 func WithPattern(pattern string) func(channel *Channel) {
 	return func(channel *Channel) {
 		channel.name = pattern
-		channel.pattern = glob.MustCompile(pattern)
+		channel.pattern = glob.Cached(pattern)
 	}
 }
 
@@ -68,8 +73,17 @@ func (ch *Channel) Start() {
 		for {
 			message := <-*ch.messageChan
 
-			ch.subscribersRWMut.RLock()
+			// The write lock keeps the retained messages consistent with the replays in SubscribeAndReplay.
+			ch.subscribersRWMut.Lock()
 
+			if ch.retention > 0 {
+				ch.retained = append(ch.retained, message)
+				if len(ch.retained) > ch.retention {
+					ch.retained = ch.retained[len(ch.retained)-ch.retention:]
+				}
+			}
+
+			ch.delivered.Add(uint64(len(ch.subscribers)))
 			for _, conn := range ch.subscribers {
 				go func(conn *resp.Conn) {
 					if err := conn.WriteArray([]resp.Value{
@@ -82,7 +96,7 @@ func (ch *Channel) Start() {
 				}(conn)
 			}
 
-			ch.subscribersRWMut.RUnlock()
+			ch.subscribersRWMut.Unlock()
 		}
 	}()
 }
@@ -91,18 +105,51 @@ func (ch *Channel) Name() string {
 	return ch.name
 }
 
-func (ch *Channel) Pattern() glob.Glob {
+func (ch *Channel) Pattern() *glob.Glob {
 	return ch.pattern
 }
 
 func (ch *Channel) Subscribe(conn *net.Conn) bool {
+	return ch.SubscribeAndReplay(conn, 0, func() {})
+}
+
+// SubscribeAndReplay subscribes the connection to the channel and writes up to count of the most recent
+// retained messages to it. onSubscribe is called after subscribing and before replaying the messages.
+// No new message is delivered to any subscriber until the replay is complete, so the connection receives
+// the replayed messages and the new messages in order, without gaps.
+func (ch *Channel) SubscribeAndReplay(conn *net.Conn, count int, onSubscribe func()) bool {
 	ch.subscribersRWMut.Lock()
 	defer ch.subscribersRWMut.Unlock()
 	if _, ok := ch.subscribers[conn]; !ok {
 		ch.subscribers[conn] = resp.NewConn(*conn)
 	}
-	_, ok := ch.subscribers[conn]
-	return ok
+
+	onSubscribe()
+
+	messages := ch.retained[max(0, len(ch.retained)-count):]
+	for _, message := range messages {
+		if err := ch.subscribers[conn].WriteArray([]resp.Value{
+			resp.StringValue("message"),
+			resp.StringValue(ch.name),
+			resp.StringValue(message),
+		}); err != nil {
+			log.Println(err)
+			break
+		}
+	}
+
+	return true
+}
+
+// SetRetention sets the number of recent messages the channel keeps for replay.
+// When 0 is passed, retention is disabled and the retained messages are discarded.
+func (ch *Channel) SetRetention(count int) {
+	ch.subscribersRWMut.Lock()
+	defer ch.subscribersRWMut.Unlock()
+	ch.retention = count
+	if len(ch.retained) > count {
+		ch.retained = slices.Clone(ch.retained[len(ch.retained)-count:])
+	}
 }
 
 func (ch *Channel) Unsubscribe(conn *net.Conn) bool {
@@ -137,6 +184,12 @@ func (ch *Channel) NumSubs() int {
 	return n
 }
 
+// Delivered returns the number of messages delivered to the channel's subscribers.
+// A message published while the channel has n subscribers counts as n deliveries.
+func (ch *Channel) Delivered() uint64 {
+	return ch.delivered.Load()
+}
+
 func (ch *Channel) Subscribers() map[*net.Conn]*resp.Conn {
 	ch.subscribersRWMut.RLock()
 	defer ch.subscribersRWMut.RUnlock()
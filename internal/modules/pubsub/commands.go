@@ -19,23 +19,48 @@ import (
 	"fmt"
 	"github.com/echovault/echovault/internal"
 	"github.com/echovault/echovault/internal/constants"
+	"strconv"
 	"strings"
 )
 
+// subscribeChannels returns the channels of a SUBSCRIBE command and the number of retained messages to replay.
+// The REPLAY option must come before the channels.
+func subscribeChannels(cmd []string) ([]string, int, error) {
+	if len(cmd) >= 4 && strings.EqualFold(cmd[1], "replay") {
+		replay, err := strconv.Atoi(cmd[2])
+		if err != nil || replay < 0 {
+			return nil, 0, errors.New("replay count must be a non-negative integer")
+		}
+		return cmd[3:], replay, nil
+	}
+	if len(cmd) < 2 {
+		return nil, 0, errors.New(constants.WrongArgsResponse)
+	}
+	return cmd[1:], 0, nil
+}
+
 func handleSubscribe(params internal.HandlerFuncParams) ([]byte, error) {
 	pubsub, ok := params.GetPubSub().(*PubSub)
 	if !ok {
 		return nil, errors.New("could not load pubsub module")
 	}
 
+	withPattern := strings.EqualFold(params.Command[0], "psubscribe")
+
 	channels := params.Command[1:]
+	replay := 0
+	if !withPattern {
+		var err error
+		if channels, replay, err = subscribeChannels(params.Command); err != nil {
+			return nil, err
+		}
+	}
 
 	if len(channels) == 0 {
 		return nil, errors.New(constants.WrongArgsResponse)
 	}
 
-	withPattern := strings.EqualFold(params.Command[0], "psubscribe")
-	pubsub.Subscribe(params.Context, params.Connection, channels, withPattern)
+	pubsub.Subscribe(params.Context, params.Connection, channels, withPattern, replay)
 
 	return nil, nil
 }
@@ -62,6 +87,10 @@ func handlePublish(params internal.HandlerFuncParams) ([]byte, error) {
 		return nil, errors.New(constants.WrongArgsResponse)
 	}
 	pubsub.Publish(params.Context, params.Command[2], params.Command[1])
+	// In a cluster, deliver the message to the subscribers connected to the other nodes.
+	if params.ForwardPublish != nil {
+		params.ForwardPublish(params.Context, params.Command[1], params.Command[2])
+	}
 	return []byte(constants.OkResponse), nil
 }
 
@@ -92,6 +121,22 @@ func handlePubSubNumPat(params internal.HandlerFuncParams) ([]byte, error) {
 	return []byte(fmt.Sprintf(":%d\r\n", num)), nil
 }
 
+func handlePubSubRetain(params internal.HandlerFuncParams) ([]byte, error) {
+	if len(params.Command) != 4 {
+		return nil, errors.New(constants.WrongArgsResponse)
+	}
+	pubsub, ok := params.GetPubSub().(*PubSub)
+	if !ok {
+		return nil, errors.New("could not load pubsub module")
+	}
+	count, err := strconv.Atoi(params.Command[3])
+	if err != nil || count < 0 {
+		return nil, errors.New("count must be a non-negative integer")
+	}
+	pubsub.Retain(params.Command[2], count)
+	return []byte(constants.OkResponse), nil
+}
+
 func handlePubSubNumSubs(params internal.HandlerFuncParams) ([]byte, error) {
 	pubsub, ok := params.GetPubSub().(*PubSub)
 	if !ok {
@@ -103,18 +148,20 @@ func handlePubSubNumSubs(params internal.HandlerFuncParams) ([]byte, error) {
 func Commands() []internal.Command {
 	return []internal.Command{
 		{
-			Command:     "subscribe",
-			Module:      constants.PubSubModule,
-			Categories:  []string{constants.PubSubCategory, constants.ConnectionCategory, constants.SlowCategory},
-			Description: "(SUBSCRIBE channel [channel ...]) Subscribe to one or more channels.",
-			Sync:        false,
+			Command:    "subscribe",
+			Module:     constants.PubSubModule,
+			Categories: []string{constants.PubSubCategory, constants.ConnectionCategory, constants.SlowCategory},
+			Description: `(SUBSCRIBE [REPLAY count] channel [channel ...]) Subscribe to one or more channels.
+REPLAY - After subscribing, send up to count of the messages retained by each channel with PUBSUB RETAIN.`,
+			Sync: false,
 			KeyExtractionFunc: func(cmd []string) (internal.KeyExtractionFuncResult, error) {
 				// Treat the channels as keys
-				if len(cmd) < 2 {
-					return internal.KeyExtractionFuncResult{}, errors.New(constants.WrongArgsResponse)
+				channels, _, err := subscribeChannels(cmd)
+				if err != nil {
+					return internal.KeyExtractionFuncResult{}, err
 				}
 				return internal.KeyExtractionFuncResult{
-					Channels:  cmd[1:],
+					Channels:  channels,
 					ReadKeys:  make([]string, 0),
 					WriteKeys: make([]string, 0),
 				}, nil
@@ -141,11 +188,12 @@ func Commands() []internal.Command {
 			HandlerFunc: handleSubscribe,
 		},
 		{
-			Command:     "publish",
-			Module:      constants.PubSubModule,
-			Categories:  []string{constants.PubSubCategory, constants.FastCategory},
-			Description: "(PUBLISH channel message) Publish a message to the specified channel.",
-			Sync:        true,
+			Command:    "publish",
+			Module:     constants.PubSubModule,
+			Categories: []string{constants.PubSubCategory, constants.FastCategory},
+			Description: `(PUBLISH channel message) Publish a message to the specified channel.
+In a cluster, the message is also delivered to the subscribers connected to the other nodes.`,
+			Sync: false,
 			KeyExtractionFunc: func(cmd []string) (internal.KeyExtractionFuncResult, error) {
 				// Treat the channel as a key
 				if len(cmd) != 3 {
@@ -208,7 +256,7 @@ it's currently subscribe to.`,
 				}, nil
 			},
 			HandlerFunc: func(_ internal.HandlerFuncParams) ([]byte, error) {
-				return nil, errors.New("provide CHANNELS, NUMPAT, or NUMSUB subcommand")
+				return nil, errors.New("provide CHANNELS, NUMPAT, NUMSUB, or RETAIN subcommand")
 			},
 			SubCommands: []internal.SubCommand{
 				{
@@ -248,7 +296,8 @@ channels with 1 or more subscribers.`,
 					Module:     constants.PubSubModule,
 					Categories: []string{constants.PubSubCategory, constants.SlowCategory},
 					Description: `(PUBSUB NUMSUB [channel [channel ...]]) Return an array of arrays containing the provided
-channel name and how many clients are currently subscribed to the channel.`,
+channel name, how many clients are currently subscribed to the channel, and how many messages this node has
+delivered to the channel's subscribers. Each entry has 3 elements, where Redis returns 2.`,
 					Sync: false,
 					KeyExtractionFunc: func(cmd []string) (internal.KeyExtractionFuncResult, error) {
 						return internal.KeyExtractionFuncResult{
@@ -259,6 +308,26 @@ channel name and how many clients are currently subscribed to the channel.`,
 					},
 					HandlerFunc: handlePubSubNumSubs,
 				},
+				{
+					Command:    "retain",
+					Module:     constants.PubSubModule,
+					Categories: []string{constants.PubSubCategory, constants.SlowCategory},
+					Description: `(PUBSUB RETAIN channel count) Keep the last count messages published to the channel, so that
+subscribers can replay them with SUBSCRIBE REPLAY. When count is 0, retention is disabled.
+The retained messages are kept in memory on each node and are not persisted.`,
+					Sync: false,
+					KeyExtractionFunc: func(cmd []string) (internal.KeyExtractionFuncResult, error) {
+						if len(cmd) != 4 {
+							return internal.KeyExtractionFuncResult{}, errors.New(constants.WrongArgsResponse)
+						}
+						return internal.KeyExtractionFuncResult{
+							Channels:  cmd[2:3],
+							ReadKeys:  make([]string, 0),
+							WriteKeys: make([]string, 0),
+						}, nil
+					},
+					HandlerFunc: handlePubSubRetain,
+				},
 			},
 		},
 	}
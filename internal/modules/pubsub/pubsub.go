@@ -17,7 +17,7 @@ package pubsub
 import (
 	"context"
 	"fmt"
-	"github.com/gobwas/glob"
+	"github.com/echovault/echovault/internal/glob"
 	"github.com/tidwall/resp"
 	"log"
 	"net"
@@ -37,7 +37,9 @@ func NewPubSub() *PubSub {
 	}
 }
 
-func (ps *PubSub) Subscribe(_ context.Context, conn *net.Conn, channels []string, withPattern bool) {
+// Subscribe subscribes the connection to the channels or patterns. For each channel, up to replay of the
+// channel's retained messages are written to the connection after the subscription is confirmed.
+func (ps *PubSub) Subscribe(_ context.Context, conn *net.Conn, channels []string, withPattern bool, replay int) {
 	ps.channelsRWMut.Lock()
 	defer ps.channelsRWMut.Unlock()
 
@@ -65,7 +67,7 @@ func (ps *PubSub) Subscribe(_ context.Context, conn *net.Conn, channels []string
 				newChan = NewChannel(WithName(channels[i]))
 			}
 			newChan.Start()
-			if newChan.Subscribe(conn) {
+			newChan.SubscribeAndReplay(conn, replay, func() {
 				if err := r.WriteArray([]resp.Value{
 					resp.StringValue(action),
 					resp.StringValue(newChan.name),
@@ -73,11 +75,11 @@ func (ps *PubSub) Subscribe(_ context.Context, conn *net.Conn, channels []string
 				}); err != nil {
 					log.Println(err)
 				}
-			}
+			})
 			ps.channels = append(ps.channels, newChan)
 		} else {
 			// Subscribe to existing channel
-			if ps.channels[channelIdx].Subscribe(conn) {
+			ps.channels[channelIdx].SubscribeAndReplay(conn, replay, func() {
 				if err := r.WriteArray([]resp.Value{
 					resp.StringValue(action),
 					resp.StringValue(ps.channels[channelIdx].name),
@@ -85,7 +87,7 @@ func (ps *PubSub) Subscribe(_ context.Context, conn *net.Conn, channels []string
 				}); err != nil {
 					log.Println(err)
 				}
-			}
+			})
 		}
 	}
 }
@@ -146,7 +148,7 @@ func (ps *PubSub) Unsubscribe(_ context.Context, conn *net.Conn, channels []stri
 	// also unsubscribe from channels where the name matches the given pattern.
 	if withPattern {
 		for _, pattern := range channels {
-			g := glob.MustCompile(pattern)
+			g := glob.Cached(pattern)
 			for _, channel := range ps.channels {
 				// If it's a pattern channel, directly compare the patterns
 				if channel.pattern != nil && channel.name == pattern {
@@ -212,7 +214,7 @@ func (ps *PubSub) Channels(pattern string) []byte {
 		return []byte(res)
 	}
 
-	g := glob.MustCompile(pattern)
+	g := glob.Compile(pattern)
 
 	for _, channel := range ps.channels {
 		// If channel is a pattern channel, then directly compare the channel name to pattern
@@ -244,6 +246,9 @@ func (ps *PubSub) NumPat() int {
 	return count
 }
 
+// NumSub returns the PUBSUB NUMSUB reply. Unlike Redis, where each entry holds the channel and its number of
+// subscribers, each entry also holds a third element: the number of messages this node has delivered to the
+// channel's subscribers. Clients that read the entries as pairs must read the counter too.
 func (ps *PubSub) NumSub(channels []string) []byte {
 	ps.channelsRWMut.RLock()
 	defer ps.channelsRWMut.RUnlock()
@@ -255,14 +260,32 @@ func (ps *PubSub) NumSub(channels []string) []byte {
 			return c.name == channel
 		})
 		if chanIdx == -1 {
-			res += fmt.Sprintf("*2\r\n$%d\r\n%s\r\n:0\r\n", len(channel), channel)
+			res += fmt.Sprintf("*3\r\n$%d\r\n%s\r\n:0\r\n:0\r\n", len(channel), channel)
 			continue
 		}
-		res += fmt.Sprintf("*2\r\n$%d\r\n%s\r\n:%d\r\n", len(channel), channel, ps.channels[chanIdx].NumSubs())
+		res += fmt.Sprintf("*3\r\n$%d\r\n%s\r\n:%d\r\n:%d\r\n", len(channel), channel,
+			ps.channels[chanIdx].NumSubs(), ps.channels[chanIdx].Delivered())
 	}
 	return []byte(res)
 }
 
+// Retain sets the number of recent messages kept for replay on the channel, creating the channel if it does not exist.
+func (ps *PubSub) Retain(channelName string, count int) {
+	ps.channelsRWMut.Lock()
+	defer ps.channelsRWMut.Unlock()
+
+	channelIdx := slices.IndexFunc(ps.channels, func(channel *Channel) bool {
+		return channel.pattern == nil && channel.name == channelName
+	})
+	if channelIdx == -1 {
+		channel := NewChannel(WithName(channelName))
+		channel.Start()
+		ps.channels = append(ps.channels, channel)
+		channelIdx = len(ps.channels) - 1
+	}
+	ps.channels[channelIdx].SetRetention(count)
+}
+
 func (ps *PubSub) GetAllChannels() []*Channel {
 	ps.channelsRWMut.RLock()
 	defer ps.channelsRWMut.RUnlock()
@@ -0,0 +1,177 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scripting
+
+import (
+	"errors"
+	"fmt"
+	"github.com/echovault/echovault/internal"
+	"github.com/echovault/echovault/internal/constants"
+	"strings"
+)
+
+func handleEval(params internal.HandlerFuncParams) ([]byte, error) {
+	keys, err := evalKeyFunc(params.Command)
+	if err != nil {
+		return nil, err
+	}
+	scripts, ok := params.GetScripts().(*Scripts)
+	if !ok {
+		return nil, errors.New("could not load scripts")
+	}
+	script := params.Command[1]
+	scripts.Load(script)
+	args := params.Command[3+len(keys.WriteKeys):]
+	return params.ExecuteScript(params.Context, params.Connection, script, keys.WriteKeys, args)
+}
+
+func handleEvalSha(params internal.HandlerFuncParams) ([]byte, error) {
+	keys, err := evalKeyFunc(params.Command)
+	if err != nil {
+		return nil, err
+	}
+	scripts, ok := params.GetScripts().(*Scripts)
+	if !ok {
+		return nil, errors.New("could not load scripts")
+	}
+	script, ok := scripts.Get(params.Command[1])
+	if !ok {
+		return nil, errors.New("NOSCRIPT No matching script. Please use EVAL.")
+	}
+	args := params.Command[3+len(keys.WriteKeys):]
+	return params.ExecuteScript(params.Context, params.Connection, script, keys.WriteKeys, args)
+}
+
+func handleScriptLoad(params internal.HandlerFuncParams) ([]byte, error) {
+	if _, err := scriptLoadKeyFunc(params.Command); err != nil {
+		return nil, err
+	}
+	scripts, ok := params.GetScripts().(*Scripts)
+	if !ok {
+		return nil, errors.New("could not load scripts")
+	}
+	sha := scripts.Load(params.Command[2])
+	return []byte(fmt.Sprintf("$%d\r\n%s\r\n", len(sha), sha)), nil
+}
+
+func handleScriptExists(params internal.HandlerFuncParams) ([]byte, error) {
+	if _, err := scriptExistsKeyFunc(params.Command); err != nil {
+		return nil, err
+	}
+	scripts, ok := params.GetScripts().(*Scripts)
+	if !ok {
+		return nil, errors.New("could not load scripts")
+	}
+	res := fmt.Sprintf("*%d\r\n", len(params.Command[2:]))
+	for _, sha := range params.Command[2:] {
+		if _, ok := scripts.Get(sha); ok {
+			res += ":1\r\n"
+			continue
+		}
+		res += ":0\r\n"
+	}
+	return []byte(res), nil
+}
+
+func handleScriptFlush(params internal.HandlerFuncParams) ([]byte, error) {
+	if _, err := scriptFlushKeyFunc(params.Command); err != nil {
+		return nil, err
+	}
+	// The scripts are always flushed synchronously, the modes are accepted for compatibility.
+	if len(params.Command) == 3 && !strings.EqualFold(params.Command[2], "async") &&
+		!strings.EqualFold(params.Command[2], "sync") {
+		return nil, fmt.Errorf("unknown flush mode %s", params.Command[2])
+	}
+	scripts, ok := params.GetScripts().(*Scripts)
+	if !ok {
+		return nil, errors.New("could not load scripts")
+	}
+	scripts.Flush()
+	return []byte(constants.OkResponse), nil
+}
+
+func Commands() []internal.Command {
+	return []internal.Command{
+		{
+			Command:    "eval",
+			Module:     constants.ScriptingModule,
+			Categories: []string{constants.ScriptingCategory, constants.SlowCategory},
+			Description: `(EVAL script numkeys [key [key ...]] [arg [arg ...]]) Runs the Lua script.
+The keys are available to the script in the KEYS table, and the remaining arguments in the ARGV table.
+The script can run commands with redis.call, which raises command errors, or redis.pcall, which returns them.
+No other command runs while the script is running.`,
+			Sync:              false,
+			KeyExtractionFunc: evalKeyFunc,
+			HandlerFunc:       handleEval,
+		},
+		{
+			Command:    "evalsha",
+			Module:     constants.ScriptingModule,
+			Categories: []string{constants.ScriptingCategory, constants.SlowCategory},
+			Description: `(EVALSHA sha1 numkeys [key [key ...]] [arg [arg ...]]) Runs the cached script with the given SHA1 digest.
+Scripts are cached by EVAL and SCRIPT LOAD.`,
+			Sync:              false,
+			KeyExtractionFunc: evalKeyFunc,
+			HandlerFunc:       handleEvalSha,
+		},
+		{
+			Command:     "script",
+			Module:      constants.ScriptingModule,
+			Categories:  []string{},
+			Description: "",
+			Sync:        false,
+			KeyExtractionFunc: func(cmd []string) (internal.KeyExtractionFuncResult, error) {
+				return internal.KeyExtractionFuncResult{
+					Channels:  make([]string, 0),
+					ReadKeys:  make([]string, 0),
+					WriteKeys: make([]string, 0),
+				}, nil
+			},
+			HandlerFunc: func(_ internal.HandlerFuncParams) ([]byte, error) {
+				return nil, errors.New("provide LOAD, EXISTS, or FLUSH subcommand")
+			},
+			SubCommands: []internal.SubCommand{
+				{
+					Command:           "load",
+					Module:            constants.ScriptingModule,
+					Categories:        []string{constants.ScriptingCategory, constants.SlowCategory},
+					Description:       `(SCRIPT LOAD script) Adds the script to the script cache and returns its SHA1 digest.`,
+					Sync:              false,
+					KeyExtractionFunc: scriptLoadKeyFunc,
+					HandlerFunc:       handleScriptLoad,
+				},
+				{
+					Command:    "exists",
+					Module:     constants.ScriptingModule,
+					Categories: []string{constants.ScriptingCategory, constants.SlowCategory},
+					Description: `(SCRIPT EXISTS sha1 [sha1 ...]) Returns an array with 1 for each digest of a cached script,
+and 0 for each digest that is not cached.`,
+					Sync:              false,
+					KeyExtractionFunc: scriptExistsKeyFunc,
+					HandlerFunc:       handleScriptExists,
+				},
+				{
+					Command:           "flush",
+					Module:            constants.ScriptingModule,
+					Categories:        []string{constants.ScriptingCategory, constants.SlowCategory},
+					Description:       `(SCRIPT FLUSH [ASYNC | SYNC]) Removes all the scripts from the script cache.`,
+					Sync:              false,
+					KeyExtractionFunc: scriptFlushKeyFunc,
+					HandlerFunc:       handleScriptFlush,
+				},
+			},
+		},
+	}
+}
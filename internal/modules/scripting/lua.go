@@ -0,0 +1,239 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scripting
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/tidwall/resp"
+	lua "github.com/yuin/gopher-lua"
+	"strings"
+	"time"
+)
+
+// CallFunc runs a command called by a script with redis.call or redis.pcall, and returns its RESP reply.
+type CallFunc func(cmd []string) ([]byte, error)
+
+// Run runs the Lua script with the KEYS and ARGV globals set to keys and args, and returns the value returned
+// by the script encoded as a RESP reply. Commands called by the script are run with call.
+//
+// Each script runs in its own Lua state, with only the base, table, string and math libraries loaded.
+// A script that raises an error, or returns a table with an "err" field, fails with that error.
+// A script that runs for longer than the time limit is stopped with an error. When the time limit is 0,
+// the script runs until it returns or the context is cancelled.
+func Run(ctx context.Context, script string, keys []string, args []string, timeLimit time.Duration, call CallFunc) ([]byte, error) {
+	L := lua.NewState(lua.Options{SkipOpenLibs: true})
+	defer L.Close()
+	// The deadline only applies to the Lua state, so that the commands called by the script keep their own deadlines.
+	scriptCtx := ctx
+	if timeLimit > 0 {
+		var cancel context.CancelFunc
+		scriptCtx, cancel = context.WithTimeout(ctx, timeLimit)
+		defer cancel()
+	}
+	L.SetContext(scriptCtx)
+
+	for _, lib := range []struct {
+		name string
+		open lua.LGFunction
+	}{
+		{name: lua.BaseLibName, open: lua.OpenBase},
+		{name: lua.TabLibName, open: lua.OpenTable},
+		{name: lua.StringLibName, open: lua.OpenString},
+		{name: lua.MathLibName, open: lua.OpenMath},
+	} {
+		L.Push(L.NewFunction(lib.open))
+		L.Push(lua.LString(lib.name))
+		L.Call(1, 0)
+	}
+	// Scripts must not access the file system.
+	for _, name := range []string{"dofile", "loadfile"} {
+		L.SetGlobal(name, lua.LNil)
+	}
+
+	L.SetGlobal("KEYS", stringsToTable(L, keys))
+	L.SetGlobal("ARGV", stringsToTable(L, args))
+	L.SetGlobal("redis", L.SetFuncs(L.NewTable(), map[string]lua.LGFunction{
+		"call": func(L *lua.LState) int {
+			return callCommand(L, call, true)
+		},
+		"pcall": func(L *lua.LState) int {
+			return callCommand(L, call, false)
+		},
+		"error_reply": func(L *lua.LState) int {
+			L.Push(replyTable(L, "err", L.CheckString(1)))
+			return 1
+		},
+		"status_reply": func(L *lua.LState) int {
+			L.Push(replyTable(L, "ok", L.CheckString(1)))
+			return 1
+		},
+		"sha1hex": func(L *lua.LState) int {
+			L.Push(lua.LString(Digest(L.CheckString(1))))
+			return 1
+		},
+	}))
+
+	if err := L.DoString(script); err != nil {
+		if ctx.Err() == nil && errors.Is(scriptCtx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("script stopped after exceeding the time limit of %s", timeLimit)
+		}
+		var apiErr *lua.ApiError
+		if errors.As(err, &apiErr) {
+			if msg, ok := errorReply(apiErr.Object); ok {
+				return nil, errors.New(msg)
+			}
+			return nil, fmt.Errorf("error running script: %s", apiErr.Object.String())
+		}
+		return nil, fmt.Errorf("error running script: %s", err.Error())
+	}
+
+	if L.GetTop() == 0 {
+		return []byte("$-1\r\n"), nil
+	}
+	ret := L.Get(1)
+	if msg, ok := errorReply(ret); ok {
+		return nil, errors.New(msg)
+	}
+	return luaToResp(ret), nil
+}
+
+// callCommand calls the command with the arguments passed to redis.call or redis.pcall, and pushes its reply.
+// When raise is true, a command error is raised as a Lua error. Otherwise, it's returned as an error reply table.
+func callCommand(L *lua.LState, call CallFunc, raise bool) int {
+	cmd := make([]string, L.GetTop())
+	for i := range cmd {
+		switch arg := L.Get(i + 1).(type) {
+		case lua.LString:
+			cmd[i] = string(arg)
+		case lua.LNumber:
+			cmd[i] = arg.String()
+		default:
+			return commandError(L, errors.New("command arguments must be strings or integers"), raise)
+		}
+	}
+	if len(cmd) == 0 {
+		return commandError(L, errors.New("please specify at least one argument for this redis lib call"), raise)
+	}
+
+	res, err := call(cmd)
+	if err != nil {
+		return commandError(L, err, raise)
+	}
+	v, _, err := resp.NewReader(bytes.NewReader(res)).ReadValue()
+	if err != nil {
+		return commandError(L, err, raise)
+	}
+	L.Push(respToLua(L, v))
+	return 1
+}
+
+func commandError(L *lua.LState, err error, raise bool) int {
+	reply := replyTable(L, "err", err.Error())
+	if raise {
+		L.Error(reply, 1)
+		return 0
+	}
+	L.Push(reply)
+	return 1
+}
+
+func stringsToTable(L *lua.LState, elems []string) *lua.LTable {
+	table := L.CreateTable(len(elems), 0)
+	for _, elem := range elems {
+		table.Append(lua.LString(elem))
+	}
+	return table
+}
+
+// replyTable returns a table with a single field, used to represent status and error replies.
+func replyTable(L *lua.LState, field string, msg string) *lua.LTable {
+	table := L.NewTable()
+	table.RawSetString(field, lua.LString(msg))
+	return table
+}
+
+// errorReply returns the message of a table with an "err" field.
+func errorReply(lv lua.LValue) (string, bool) {
+	table, ok := lv.(*lua.LTable)
+	if !ok {
+		return "", false
+	}
+	msg, ok := table.RawGetString("err").(lua.LString)
+	return string(msg), ok
+}
+
+// respToLua converts a command reply to a Lua value. Null replies are converted to false.
+// Simple strings are converted to strings, as commands reply with simple strings for stored values.
+func respToLua(L *lua.LState, v resp.Value) lua.LValue {
+	if v.IsNull() {
+		return lua.LFalse
+	}
+	switch v.Type() {
+	case resp.Integer:
+		return lua.LNumber(v.Integer())
+	case resp.Error:
+		return replyTable(L, "err", v.String())
+	case resp.Array:
+		table := L.CreateTable(len(v.Array()), 0)
+		for _, elem := range v.Array() {
+			table.Append(respToLua(L, elem))
+		}
+		return table
+	default:
+		return lua.LString(v.String())
+	}
+}
+
+// luaToResp encodes a value returned by a script as a RESP reply.
+// Numbers are truncated to integers, true is converted to 1 and false to a null reply.
+// Arrays end at the first nil element.
+func luaToResp(lv lua.LValue) []byte {
+	switch value := lv.(type) {
+	case lua.LNumber:
+		return []byte(fmt.Sprintf(":%d\r\n", int64(value)))
+	case lua.LString:
+		return []byte(fmt.Sprintf("$%d\r\n%s\r\n", len(value), string(value)))
+	case lua.LBool:
+		if value {
+			return []byte(":1\r\n")
+		}
+		return []byte("$-1\r\n")
+	case *lua.LTable:
+		if msg, ok := errorReply(value); ok {
+			return []byte(fmt.Sprintf("-%s\r\n", strings.ReplaceAll(msg, "\r\n", " ")))
+		}
+		if msg, ok := value.RawGetString("ok").(lua.LString); ok {
+			return []byte(fmt.Sprintf("+%s\r\n", strings.ReplaceAll(string(msg), "\r\n", " ")))
+		}
+		var elems [][]byte
+		for i := 1; ; i++ {
+			elem := value.RawGetInt(i)
+			if elem == lua.LNil {
+				break
+			}
+			elems = append(elems, luaToResp(elem))
+		}
+		res := []byte(fmt.Sprintf("*%d\r\n", len(elems)))
+		for _, elem := range elems {
+			res = append(res, elem...)
+		}
+		return res
+	default:
+		return []byte("$-1\r\n")
+	}
+}
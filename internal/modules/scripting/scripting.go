@@ -0,0 +1,65 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scripting
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"strings"
+	"sync"
+)
+
+// Scripts is the cache of the scripts loaded with SCRIPT LOAD or run with EVAL, keyed by their SHA1 digest.
+type Scripts struct {
+	rwMutex sync.RWMutex
+	scripts map[string]string
+}
+
+func NewScripts() *Scripts {
+	return &Scripts{
+		rwMutex: sync.RWMutex{},
+		scripts: make(map[string]string),
+	}
+}
+
+// Digest returns the lowercase hex SHA1 digest that identifies the script.
+func Digest(script string) string {
+	sum := sha1.Sum([]byte(script))
+	return hex.EncodeToString(sum[:])
+}
+
+// Load adds the script to the cache and returns its digest.
+func (s *Scripts) Load(script string) string {
+	s.rwMutex.Lock()
+	defer s.rwMutex.Unlock()
+	sha := Digest(script)
+	s.scripts[sha] = script
+	return sha
+}
+
+// Get returns the script with the given digest. The digest is case-insensitive.
+func (s *Scripts) Get(sha string) (string, bool) {
+	s.rwMutex.RLock()
+	defer s.rwMutex.RUnlock()
+	script, ok := s.scripts[strings.ToLower(sha)]
+	return script, ok
+}
+
+// Flush removes all the scripts from the cache.
+func (s *Scripts) Flush() {
+	s.rwMutex.Lock()
+	defer s.rwMutex.Unlock()
+	clear(s.scripts)
+}
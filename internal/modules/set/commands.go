@@ -19,8 +19,8 @@ import (
 	"fmt"
 	"github.com/echovault/echovault/internal"
 	"github.com/echovault/echovault/internal/constants"
-	"slices"
-	"strings"
+	"github.com/echovault/echovault/internal/keyindex"
+	"github.com/echovault/echovault/types"
 )
 
 func handleSADD(params internal.HandlerFuncParams) ([]byte, error) {
@@ -42,7 +42,7 @@ func handleSADD(params internal.HandlerFuncParams) ([]byte, error) {
 			return nil, err
 		}
 		params.KeyUnlock(params.Context, key)
-		return []byte(fmt.Sprintf(":%d\r\n", len(params.Command[2:]))), nil
+		return new(types.ResponseWriter).Integer(len(params.Command[2:])).Bytes(), nil
 	}
 
 	if _, err = params.KeyLock(params.Context, key); err != nil {
@@ -56,8 +56,13 @@ func handleSADD(params internal.HandlerFuncParams) ([]byte, error) {
 	}
 
 	count := set.Add(params.Command[2:])
+	if count > 0 {
+		if err = params.SetValue(params.Context, key, set); err != nil {
+			return nil, err
+		}
+	}
 
-	return []byte(fmt.Sprintf(":%d\r\n", count)), nil
+	return new(types.ResponseWriter).Integer(count).Bytes(), nil
 }
 
 func handleSCARD(params internal.HandlerFuncParams) ([]byte, error) {
@@ -69,7 +74,7 @@ func handleSCARD(params internal.HandlerFuncParams) ([]byte, error) {
 	key := keys.ReadKeys[0]
 
 	if !params.KeyExists(params.Context, key) {
-		return []byte(fmt.Sprintf(":0\r\n")), nil
+		return []byte(":0\r\n"), nil
 	}
 
 	if _, err = params.KeyRLock(params.Context, key); err != nil {
@@ -84,7 +89,7 @@ func handleSCARD(params internal.HandlerFuncParams) ([]byte, error) {
 
 	cardinality := set.Cardinality()
 
-	return []byte(fmt.Sprintf(":%d\r\n", cardinality)), nil
+	return new(types.ResponseWriter).Integer(cardinality).Bytes(), nil
 }
 
 func handleSDIFF(params internal.HandlerFuncParams) ([]byte, error) {
@@ -137,15 +142,7 @@ func handleSDIFF(params internal.HandlerFuncParams) ([]byte, error) {
 	diff := baseSet.Subtract(sets)
 	elems := diff.GetAll()
 
-	res := fmt.Sprintf("*%d", len(elems))
-	for i, e := range elems {
-		res = fmt.Sprintf("%s\r\n$%d\r\n%s", res, len(e), e)
-		if i == len(elems)-1 {
-			res += "\r\n"
-		}
-	}
-
-	return []byte(res), nil
+	return new(types.ResponseWriter).BulkArray(elems).Bytes(), nil
 }
 
 func handleSDIFFSTORE(params internal.HandlerFuncParams) ([]byte, error) {
@@ -199,8 +196,11 @@ func handleSDIFFSTORE(params internal.HandlerFuncParams) ([]byte, error) {
 
 	diff := baseSet.Subtract(sets)
 	elems := diff.GetAll()
+	if len(elems) == 0 {
+		return internal.DeleteEmptyDestination(params, destination)
+	}
 
-	res := fmt.Sprintf(":%d\r\n", len(elems))
+	res := new(types.ResponseWriter).Integer(len(elems)).Bytes()
 
 	if params.KeyExists(params.Context, destination) {
 		if _, err = params.KeyLock(params.Context, destination); err != nil {
@@ -210,7 +210,7 @@ func handleSDIFFSTORE(params internal.HandlerFuncParams) ([]byte, error) {
 			return nil, err
 		}
 		params.KeyUnlock(params.Context, destination)
-		return []byte(res), nil
+		return res, nil
 	}
 
 	if _, err = params.CreateKeyAndLock(params.Context, destination); err != nil {
@@ -221,7 +221,7 @@ func handleSDIFFSTORE(params internal.HandlerFuncParams) ([]byte, error) {
 	}
 	params.KeyUnlock(params.Context, destination)
 
-	return []byte(res), nil
+	return res, nil
 }
 
 func handleSINTER(params internal.HandlerFuncParams) ([]byte, error) {
@@ -268,31 +268,19 @@ func handleSINTER(params internal.HandlerFuncParams) ([]byte, error) {
 	intersect, _ := Intersection(0, sets...)
 	elems := intersect.GetAll()
 
-	res := fmt.Sprintf("*%d", len(elems))
-	for i, e := range elems {
-		res = fmt.Sprintf("%s\r\n$%d\r\n%s", res, len(e), e)
-		if i == len(elems)-1 {
-			res += "\r\n"
-		}
-	}
-
-	return []byte(res), nil
+	return new(types.ResponseWriter).BulkArray(elems).Bytes(), nil
 }
 
 func handleSINTERCARD(params internal.HandlerFuncParams) ([]byte, error) {
-	keys, err := sintercardKeyFunc(params.Command)
+	// The Redis protocol-compat levels take numkeys before the keys.
+	numKeys := internal.IsRedisCompat(params.GetConfigParameters()["protocol-compat"])
+	keys, limitIdx, err := sintercardArgs(params.Command, numKeys)
 	if err != nil {
 		return nil, err
 	}
 
 	// Extract the limit from the command
 	var limit int
-	limitIdx := slices.IndexFunc(params.Command, func(s string) bool {
-		return strings.EqualFold(s, "limit")
-	})
-	if limitIdx >= 0 && limitIdx < 2 {
-		return nil, errors.New(constants.WrongArgsResponse)
-	}
 	if limitIdx != -1 {
 		limitIdx += 1
 		if limitIdx >= len(params.Command) {
@@ -315,7 +303,7 @@ func handleSINTERCARD(params internal.HandlerFuncParams) ([]byte, error) {
 		}
 	}()
 
-	for _, key := range keys.ReadKeys {
+	for _, key := range keys {
 		if !params.KeyExists(params.Context, key) {
 			// If key does not exist, then there is no intersection
 			return []byte(":0\r\n"), nil
@@ -343,7 +331,7 @@ func handleSINTERCARD(params internal.HandlerFuncParams) ([]byte, error) {
 
 	intersect, _ := Intersection(limit, sets...)
 
-	return []byte(fmt.Sprintf(":%d\r\n", intersect.Cardinality())), nil
+	return new(types.ResponseWriter).Integer(intersect.Cardinality()).Bytes(), nil
 }
 
 func handleSINTERSTORE(params internal.HandlerFuncParams) ([]byte, error) {
@@ -364,7 +352,7 @@ func handleSINTERSTORE(params internal.HandlerFuncParams) ([]byte, error) {
 	for _, key := range keys.ReadKeys {
 		if !params.KeyExists(params.Context, key) {
 			// If key does not exist, then there is no intersection
-			return []byte(":0\r\n"), nil
+			return internal.DeleteEmptyDestination(params, keys.WriteKeys[0])
 		}
 		if _, err = params.KeyRLock(params.Context, key); err != nil {
 			return nil, err
@@ -385,6 +373,9 @@ func handleSINTERSTORE(params internal.HandlerFuncParams) ([]byte, error) {
 
 	intersect, _ := Intersection(0, sets...)
 	destination := keys.WriteKeys[0]
+	if intersect.Cardinality() == 0 {
+		return internal.DeleteEmptyDestination(params, destination)
+	}
 
 	if params.KeyExists(params.Context, destination) {
 		if _, err = params.KeyLock(params.Context, destination); err != nil {
@@ -401,7 +392,7 @@ func handleSINTERSTORE(params internal.HandlerFuncParams) ([]byte, error) {
 	}
 	params.KeyUnlock(params.Context, destination)
 
-	return []byte(fmt.Sprintf(":%d\r\n", intersect.Cardinality())), nil
+	return new(types.ResponseWriter).Integer(intersect.Cardinality()).Bytes(), nil
 }
 
 func handleSISMEMBER(params internal.HandlerFuncParams) ([]byte, error) {
@@ -433,16 +424,21 @@ func handleSISMEMBER(params internal.HandlerFuncParams) ([]byte, error) {
 	return []byte(":1\r\n"), nil
 }
 
-func handleSMEMBERS(params internal.HandlerFuncParams) ([]byte, error) {
-	keys, err := smembersKeyFunc(params.Command)
+func handleSSCAN(params internal.HandlerFuncParams) ([]byte, error) {
+	keys, err := sscanKeyFunc(params.Command)
 	if err != nil {
 		return nil, err
 	}
 
 	key := keys.ReadKeys[0]
 
+	options, err := internal.ParseScanOptions(params.Command[2:], false)
+	if err != nil {
+		return nil, err
+	}
+
 	if !params.KeyExists(params.Context, key) {
-		return []byte("*0\r\n"), nil
+		return internal.EncodeScanReply(0, nil), nil
 	}
 
 	if _, err = params.KeyRLock(params.Context, key); err != nil {
@@ -455,17 +451,49 @@ func handleSMEMBERS(params internal.HandlerFuncParams) ([]byte, error) {
 		return nil, fmt.Errorf("value at key %s is not a set", key)
 	}
 
-	elems := set.GetAll()
+	members, cursor := keyindex.ScanMembers(set.GetAll(), options.Cursor, options.Count)
 
-	res := fmt.Sprintf("*%d", len(elems))
-	for i, e := range elems {
-		res = fmt.Sprintf("%s\r\n$%d\r\n%s", res, len(e), e)
-		if i == len(elems)-1 {
-			res += "\r\n"
+	res := make([]string, 0, len(members))
+	for _, member := range members {
+		if options.Match == nil || options.Match.Match(member) {
+			res = append(res, member)
 		}
 	}
 
-	return []byte(res), nil
+	return internal.EncodeScanReply(cursor, res), nil
+}
+
+func handleSMEMBERS(params internal.HandlerFuncParams) ([]byte, error) {
+	keys, err := smembersKeyFunc(params.Command)
+	if err != nil {
+		return nil, err
+	}
+
+	key := keys.ReadKeys[0]
+
+	if !params.KeyExists(params.Context, key) {
+		return []byte("*0\r\n"), nil
+	}
+
+	if _, err = params.KeyRLock(params.Context, key); err != nil {
+		return nil, err
+	}
+	defer params.KeyRUnlock(params.Context, key)
+
+	set, ok := params.GetValue(params.Context, key).(*Set)
+	if !ok {
+		return nil, fmt.Errorf("value at key %s is not a set", key)
+	}
+
+	// Write the members straight into the response instead of collecting them in a slice first.
+	// Large replies are streamed to the connection as they're written.
+	res := params.ReplyWriter().Array(set.Cardinality())
+	set.Range(func(member string) bool {
+		res.Bulk(member)
+		return true
+	})
+
+	return res.Bytes(), nil
 }
 
 func handleSMISMEMBER(params internal.HandlerFuncParams) ([]byte, error) {
@@ -478,14 +506,11 @@ func handleSMISMEMBER(params internal.HandlerFuncParams) ([]byte, error) {
 	members := params.Command[2:]
 
 	if !params.KeyExists(params.Context, key) {
-		res := fmt.Sprintf("*%d", len(members))
-		for i, _ := range members {
-			res = fmt.Sprintf("%s\r\n:0", res)
-			if i == len(members)-1 {
-				res += "\r\n"
-			}
+		res := new(types.ResponseWriter).Array(len(members))
+		for range members {
+			res.Integer(0)
 		}
-		return []byte(res), nil
+		return res.Bytes(), nil
 	}
 
 	if _, err = params.KeyRLock(params.Context, key); err != nil {
@@ -498,17 +523,16 @@ func handleSMISMEMBER(params internal.HandlerFuncParams) ([]byte, error) {
 		return nil, fmt.Errorf("value at key %s is not a set", key)
 	}
 
-	res := fmt.Sprintf("*%d", len(members))
+	res := new(types.ResponseWriter).Array(len(members))
 	for i := 0; i < len(members); i++ {
 		if set.Contains(members[i]) {
-			res += "\r\n:1"
+			res.Integer(1)
 		} else {
-			res += "\r\n:0"
+			res.Integer(0)
 		}
 	}
-	res += "\r\n"
 
-	return []byte(res), nil
+	return res.Bytes(), nil
 }
 
 func handleSMOVE(params internal.HandlerFuncParams) ([]byte, error) {
@@ -560,8 +584,16 @@ func handleSMOVE(params internal.HandlerFuncParams) ([]byte, error) {
 	}
 
 	res := sourceSet.Move(destinationSet, member)
+	if res == 1 {
+		if err = params.SetValue(params.Context, source, sourceSet); err != nil {
+			return nil, err
+		}
+		if err = params.SetValue(params.Context, destination, destinationSet); err != nil {
+			return nil, err
+		}
+	}
 
-	return []byte(fmt.Sprintf(":%d\r\n", res)), nil
+	return new(types.ResponseWriter).Integer(res).Bytes(), nil
 }
 
 func handleSPOP(params internal.HandlerFuncParams) ([]byte, error) {
@@ -596,16 +628,13 @@ func handleSPOP(params internal.HandlerFuncParams) ([]byte, error) {
 	}
 
 	members := set.Pop(count)
-
-	res := fmt.Sprintf("*%d", len(members))
-	for i, m := range members {
-		res = fmt.Sprintf("%s\r\n$%d\r\n%s", res, len(m), m)
-		if i == len(members)-1 {
-			res += "\r\n"
+	if len(members) > 0 {
+		if err = params.SetValue(params.Context, key, set); err != nil {
+			return nil, err
 		}
 	}
 
-	return []byte(res), nil
+	return new(types.ResponseWriter).BulkArray(members).Bytes(), nil
 }
 
 func handleSRANDMEMBER(params internal.HandlerFuncParams) ([]byte, error) {
@@ -641,15 +670,7 @@ func handleSRANDMEMBER(params internal.HandlerFuncParams) ([]byte, error) {
 
 	members := set.GetRandom(count)
 
-	res := fmt.Sprintf("*%d", len(members))
-	for i, m := range members {
-		res = fmt.Sprintf("%s\r\n$%d\r\n%s", res, len(m), m)
-		if i == len(members)-1 {
-			res += "\r\n"
-		}
-	}
-
-	return []byte(res), nil
+	return new(types.ResponseWriter).BulkArray(members).Bytes(), nil
 }
 
 func handleSREM(params internal.HandlerFuncParams) ([]byte, error) {
@@ -676,8 +697,13 @@ func handleSREM(params internal.HandlerFuncParams) ([]byte, error) {
 	}
 
 	count := set.Remove(members)
+	if count > 0 {
+		if err = params.SetValue(params.Context, key, set); err != nil {
+			return nil, err
+		}
+	}
 
-	return []byte(fmt.Sprintf(":%d\r\n", count)), nil
+	return new(types.ResponseWriter).Integer(count).Bytes(), nil
 }
 
 func handleSUNION(params internal.HandlerFuncParams) ([]byte, error) {
@@ -720,15 +746,7 @@ func handleSUNION(params internal.HandlerFuncParams) ([]byte, error) {
 
 	union := Union(sets...)
 
-	res := fmt.Sprintf("*%d", union.Cardinality())
-	for i, e := range union.GetAll() {
-		res = fmt.Sprintf("%s\r\n$%d\r\n%s", res, len(e), e)
-		if i == len(union.GetAll())-1 {
-			res += "\r\n"
-		}
-	}
-
-	return []byte(res), nil
+	return new(types.ResponseWriter).BulkArray(union.GetAll()).Bytes(), nil
 }
 
 func handleSUNIONSTORE(params internal.HandlerFuncParams) ([]byte, error) {
@@ -772,6 +790,9 @@ func handleSUNIONSTORE(params internal.HandlerFuncParams) ([]byte, error) {
 	union := Union(sets...)
 
 	destination := keys.WriteKeys[0]
+	if union.Cardinality() == 0 {
+		return internal.DeleteEmptyDestination(params, destination)
+	}
 
 	if params.KeyExists(params.Context, destination) {
 		if _, err = params.KeyLock(params.Context, destination); err != nil {
@@ -787,7 +808,7 @@ func handleSUNIONSTORE(params internal.HandlerFuncParams) ([]byte, error) {
 	if err = params.SetValue(params.Context, destination, union); err != nil {
 		return nil, err
 	}
-	return []byte(fmt.Sprintf(":%d\r\n", union.Cardinality())), nil
+	return new(types.ResponseWriter).Integer(union.Cardinality()).Bytes(), nil
 }
 
 func Commands() []internal.Command {
@@ -844,7 +865,7 @@ Returns the cardinality of the new set`,
 			Command:           "sintercard",
 			Module:            constants.SetModule,
 			Categories:        []string{constants.SetCategory, constants.ReadCategory, constants.SlowCategory},
-			Description:       "(SINTERCARD key [key...] [LIMIT limit]) Returns the cardinality of the intersection between multiple sets.",
+			Description:       "(SINTERCARD [numkeys] key [key...] [LIMIT limit]) Returns the cardinality of the intersection between multiple sets. numkeys is required in the redis-6 and redis-7 protocol-compat levels and not accepted otherwise.",
 			Sync:              false,
 			KeyExtractionFunc: sintercardKeyFunc,
 			HandlerFunc:       handleSINTERCARD,
@@ -876,6 +897,17 @@ Returns the cardinality of the new set`,
 			KeyExtractionFunc: smembersKeyFunc,
 			HandlerFunc:       handleSMEMBERS,
 		},
+		{
+			Command:    "sscan",
+			Module:     constants.SetModule,
+			Categories: []string{constants.SetCategory, constants.ReadCategory, constants.SlowCategory},
+			Description: `(SSCAN key cursor [MATCH pattern] [COUNT count])
+Incrementally iterates the members of a set. Returns the cursor to pass to the next call and
+a batch of members. The iteration is complete when the returned cursor is 0.`,
+			Sync:              false,
+			KeyExtractionFunc: sscanKeyFunc,
+			HandlerFunc:       handleSSCAN,
+		},
 		{
 			Command:           "smismember",
 			Module:            constants.SetModule,
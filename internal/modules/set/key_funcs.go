@@ -19,6 +19,7 @@ import (
 	"github.com/echovault/echovault/internal"
 	"github.com/echovault/echovault/internal/constants"
 	"slices"
+	"strconv"
 	"strings"
 )
 
@@ -77,26 +78,53 @@ func sinterKeyFunc(cmd []string) (internal.KeyExtractionFuncResult, error) {
 	}, nil
 }
 
-func sintercardKeyFunc(cmd []string) (internal.KeyExtractionFuncResult, error) {
+// sintercardArgs parses the arguments of SINTERCARD. With numKeys, the command takes the Redis form
+// SINTERCARD numkeys key [key...] [LIMIT limit]. Otherwise, it takes the form SINTERCARD key [key...] [LIMIT limit].
+// Returns the keys and the index of the LIMIT keyword (-1 if it's not provided).
+func sintercardArgs(cmd []string, numKeys bool) ([]string, int, error) {
 	if len(cmd) < 2 {
-		return internal.KeyExtractionFuncResult{}, errors.New(constants.WrongArgsResponse)
+		return nil, -1, errors.New(constants.WrongArgsResponse)
+	}
+
+	if numKeys {
+		n, err := strconv.Atoi(cmd[1])
+		if err != nil || n <= 0 {
+			return nil, -1, errors.New("numkeys should be greater than 0")
+		}
+		if n > len(cmd)-2 {
+			return nil, -1, errors.New("number of keys can't be greater than number of args")
+		}
+		switch len(cmd) - 2 {
+		case n:
+			return cmd[2:], -1, nil
+		case n + 2:
+			if strings.EqualFold(cmd[2+n], "limit") {
+				return cmd[2 : 2+n], 2 + n, nil
+			}
+		}
+		return nil, -1, errors.New("syntax error")
 	}
 
 	limitIdx := slices.IndexFunc(cmd, func(s string) bool {
 		return strings.EqualFold(s, "limit")
 	})
-
 	if limitIdx == -1 {
-		return internal.KeyExtractionFuncResult{
-			Channels:  make([]string, 0),
-			ReadKeys:  cmd[1:],
-			WriteKeys: make([]string, 0),
-		}, nil
+		return cmd[1:], -1, nil
 	}
+	if limitIdx < 2 {
+		return nil, -1, errors.New(constants.WrongArgsResponse)
+	}
+	return cmd[1:limitIdx], limitIdx, nil
+}
 
+func sintercardKeyFunc(cmd []string) (internal.KeyExtractionFuncResult, error) {
+	keys, _, err := sintercardArgs(cmd, internal.IsRedisCompat(internal.ProtocolCompat()))
+	if err != nil {
+		return internal.KeyExtractionFuncResult{}, err
+	}
 	return internal.KeyExtractionFuncResult{
 		Channels:  make([]string, 0),
-		ReadKeys:  cmd[1:limitIdx],
+		ReadKeys:  keys,
 		WriteKeys: make([]string, 0),
 	}, nil
 }
@@ -210,3 +238,14 @@ func sunionstoreKeyFunc(cmd []string) (internal.KeyExtractionFuncResult, error)
 		WriteKeys: cmd[1:2],
 	}, nil
 }
+
+func sscanKeyFunc(cmd []string) (internal.KeyExtractionFuncResult, error) {
+	if len(cmd) < 3 {
+		return internal.KeyExtractionFuncResult{}, errors.New(constants.WrongArgsResponse)
+	}
+	return internal.KeyExtractionFuncResult{
+		Channels:  make([]string, 0),
+		ReadKeys:  cmd[1:2],
+		WriteKeys: make([]string, 0),
+	}, nil
+}
@@ -18,46 +18,153 @@ import (
 	"github.com/echovault/echovault/internal"
 	"math/rand"
 	"slices"
+	"sync/atomic"
 )
 
+// Sets use a compact encoding while they hold at most maxListpackEntries members,
+// and none of the members is longer than maxListpackValue bytes.
+// The limits are configured with set-max-listpack-entries and set-max-listpack-value.
+var (
+	maxListpackEntries atomic.Int64
+	maxListpackValue   atomic.Int64
+)
+
+func init() {
+	SetListpackLimits(128, 64)
+}
+
+// SetListpackLimits sets the limits of the compact encoding. They apply to all the sets in the process.
+// Sets that already use the full encoding keep it, and compact sets are converted the next time they grow.
+func SetListpackLimits(entries int, value int) {
+	maxListpackEntries.Store(int64(entries))
+	maxListpackValue.Store(int64(value))
+}
+
+// Set is a set of strings. Small sets hold their members in a slice (the "listpack" encoding),
+// which uses a fraction of the memory of a map. A set is converted to a map (the "hashtable" encoding)
+// once it exceeds the listpack limits, and is never converted back.
 type Set struct {
-	members map[string]interface{}
-	length  int
+	members  map[string]interface{} // The members of a set with the hashtable encoding. Nil for the listpack encoding.
+	listpack []string               // The members of a set with the listpack encoding, in no particular order.
+	length   int
 }
 
 func NewSet(elems []string) *Set {
 	set := &Set{
-		members: make(map[string]interface{}),
-		length:  0,
+		length: 0,
 	}
 	set.Add(elems)
 	return set
 }
 
+// TypeName returns the name of the set type as reported by the TYPE command.
+func (set *Set) TypeName() string {
+	return "set"
+}
+
+// Export returns the members of the set in lexicographic order.
+func (set *Set) Export() interface{} {
+	members := set.GetAll()
+	slices.Sort(members)
+	if members == nil {
+		members = make([]string, 0)
+	}
+	return members
+}
+
+// Encoding returns the name of the encoding of the set as reported by OBJECT ENCODING.
+func (set *Set) Encoding() string {
+	if set.members == nil {
+		return "listpack"
+	}
+	return "hashtable"
+}
+
+// Clone returns a deep copy of the set.
+func (set *Set) Clone() interface{} {
+	if set.members == nil {
+		return &Set{
+			listpack: slices.Clone(set.listpack),
+			length:   set.length,
+		}
+	}
+	members := make(map[string]interface{}, len(set.members))
+	for e, v := range set.members {
+		members[e] = v
+	}
+	return &Set{
+		members: members,
+		length:  set.length,
+	}
+}
+
+// convert moves the members of a listpack set into a map.
+func (set *Set) convert() {
+	set.members = make(map[string]interface{}, len(set.listpack))
+	for _, e := range set.listpack {
+		set.members[e] = struct{}{}
+	}
+	set.listpack = nil
+}
+
 func (set *Set) Add(elems []string) int {
 	count := 0
 	for _, e := range elems {
-		if !set.Contains(e) {
+		if set.Contains(e) {
+			continue
+		}
+		if set.members == nil &&
+			(int64(len(set.listpack)) >= maxListpackEntries.Load() || int64(len(e)) > maxListpackValue.Load()) {
+			set.convert()
+		}
+		if set.members == nil {
+			set.listpack = append(set.listpack, e)
+		} else {
 			set.members[e] = struct{}{}
-			count += 1
 		}
+		count += 1
 	}
 	set.length += count
 	return count
 }
 
 func (set *Set) Get(e string) interface{} {
+	if set.members == nil {
+		if slices.Contains(set.listpack, e) {
+			return struct{}{}
+		}
+		return nil
+	}
 	return set.members[e]
 }
 
 func (set *Set) GetAll() []string {
 	var res []string
-	for e, _ := range set.members {
+	set.Range(func(e string) bool {
 		res = append(res, e)
-	}
+		return true
+	})
 	return res
 }
 
+// Range calls f for each member of the set in no particular order, without copying the members into a slice.
+// The iteration stops when f returns false.
+func (set *Set) Range(f func(member string) bool) {
+	if set.members == nil {
+		for _, e := range set.listpack {
+			if !f(e) {
+				return
+			}
+		}
+		return
+	}
+	for e := range set.members {
+		if !f(e) {
+			return
+		}
+	}
+}
+
 func (set *Set) Cardinality() int {
 	return set.length
 }
@@ -103,6 +210,17 @@ func (set *Set) GetRandom(count int) []string {
 func (set *Set) Remove(elems []string) int {
 	count := 0
 	for _, e := range elems {
+		if set.members == nil {
+			if i := slices.Index(set.listpack, e); i != -1 {
+				// The order of the listpack is not significant, so the last member takes the place of the removed one.
+				last := len(set.listpack) - 1
+				set.listpack[i] = set.listpack[last]
+				set.listpack[last] = ""
+				set.listpack = set.listpack[:last]
+				count += 1
+			}
+			continue
+		}
 		if set.Get(e) != nil {
 			delete(set.members, e)
 			count += 1
@@ -127,11 +245,12 @@ func (set *Set) Subtract(others []*Set) *Set {
 	diff := NewSet(set.GetAll())
 	var remove []string
 	for _, s := range others {
-		for k, _ := range s.members {
+		s.Range(func(k string) bool {
 			if diff.Contains(k) {
 				remove = append(remove, k)
 			}
-		}
+			return true
+		})
 	}
 	diff.Remove(remove)
 	return diff
@@ -183,6 +302,8 @@ func Intersection(limit int, sets ...*Set) (*Set, bool) {
 // Union takes a slice of sets and generates a union
 func Union(sets ...*Set) *Set {
 	switch len(sets) {
+	case 0:
+		return NewSet([]string{})
 	case 1:
 		return sets[0]
 	case 2:
@@ -16,14 +16,18 @@ package sorted_set
 
 import (
 	"cmp"
+	"context"
 	"errors"
 	"fmt"
 	"github.com/echovault/echovault/internal"
 	"github.com/echovault/echovault/internal/constants"
+	"github.com/echovault/echovault/internal/keyindex"
+	"github.com/echovault/echovault/types"
 	"math"
 	"slices"
 	"strconv"
 	"strings"
+	"time"
 )
 
 func handleZADD(params internal.HandlerFuncParams) ([]byte, error) {
@@ -154,13 +158,17 @@ func handleZADD(params internal.HandlerFuncParams) ([]byte, error) {
 		if err != nil {
 			return nil, err
 		}
+		// Store the set again so that the blocking pops waiting on the key are woken up.
+		if err = params.SetValue(params.Context, key, set); err != nil {
+			return nil, err
+		}
 		// If INCR option is provided, return the new score value
 		if incr != nil {
 			m := set.Get(members[0].Value)
-			return []byte(fmt.Sprintf("+%f\r\n", m.Score)), nil
+			return new(types.ResponseWriter).Simple(strconv.FormatFloat(float64(m.Score), 'f', 6, 64)).Bytes(), nil
 		}
 
-		return []byte(fmt.Sprintf(":%d\r\n", count)), nil
+		return new(types.ResponseWriter).Integer(count).Bytes(), nil
 	}
 
 	// Key does not exist
@@ -174,7 +182,7 @@ func handleZADD(params internal.HandlerFuncParams) ([]byte, error) {
 		return nil, err
 	}
 
-	return []byte(fmt.Sprintf(":%d\r\n", set.Cardinality())), nil
+	return new(types.ResponseWriter).Integer(set.Cardinality()).Bytes(), nil
 }
 
 func handleZCARD(params internal.HandlerFuncParams) ([]byte, error) {
@@ -198,7 +206,7 @@ func handleZCARD(params internal.HandlerFuncParams) ([]byte, error) {
 		return nil, fmt.Errorf("value at %s is not a sorted set", key)
 	}
 
-	return []byte(fmt.Sprintf(":%d\r\n", set.Cardinality())), nil
+	return new(types.ResponseWriter).Integer(set.Cardinality()).Bytes(), nil
 }
 
 func handleZCOUNT(params internal.HandlerFuncParams) ([]byte, error) {
@@ -209,40 +217,14 @@ func handleZCOUNT(params internal.HandlerFuncParams) ([]byte, error) {
 
 	key := keys.ReadKeys[0]
 
-	minimum := Score(math.Inf(-1))
-	switch internal.AdaptType(params.Command[2]).(type) {
-	default:
+	minimum, ok := parseScoreBound(params.Command[2])
+	if !ok {
 		return nil, errors.New("min constraint must be a double")
-	case string:
-		if strings.ToLower(params.Command[2]) == "+inf" {
-			minimum = Score(math.Inf(1))
-		} else {
-			return nil, errors.New("min constraint must be a double")
-		}
-	case float64:
-		s, _ := internal.AdaptType(params.Command[2]).(float64)
-		minimum = Score(s)
-	case int:
-		s, _ := internal.AdaptType(params.Command[2]).(int)
-		minimum = Score(s)
 	}
 
-	maximum := Score(math.Inf(1))
-	switch internal.AdaptType(params.Command[3]).(type) {
-	default:
+	maximum, ok := parseScoreBound(params.Command[3])
+	if !ok {
 		return nil, errors.New("max constraint must be a double")
-	case string:
-		if strings.ToLower(params.Command[3]) == "-inf" {
-			maximum = Score(math.Inf(-1))
-		} else {
-			return nil, errors.New("max constraint must be a double")
-		}
-	case float64:
-		s, _ := internal.AdaptType(params.Command[3]).(float64)
-		maximum = Score(s)
-	case int:
-		s, _ := internal.AdaptType(params.Command[3]).(int)
-		maximum = Score(s)
 	}
 
 	if !params.KeyExists(params.Context, key) {
@@ -261,12 +243,12 @@ func handleZCOUNT(params internal.HandlerFuncParams) ([]byte, error) {
 
 	var members []MemberParam
 	for _, m := range set.GetAll() {
-		if m.Score >= minimum && m.Score <= maximum {
+		if inScoreRange(m.Score, minimum, maximum) {
 			members = append(members, m)
 		}
 	}
 
-	return []byte(fmt.Sprintf(":%d\r\n", len(members))), nil
+	return new(types.ResponseWriter).Integer(len(members)).Bytes(), nil
 }
 
 func handleZLEXCOUNT(params internal.HandlerFuncParams) ([]byte, error) {
@@ -276,8 +258,8 @@ func handleZLEXCOUNT(params internal.HandlerFuncParams) ([]byte, error) {
 	}
 
 	key := keys.ReadKeys[0]
-	minimum := params.Command[2]
-	maximum := params.Command[3]
+	minimum := parseLexBound(params.Command[2])
+	maximum := parseLexBound(params.Command[3])
 
 	if !params.KeyExists(params.Context, key) {
 		return []byte(":0\r\n"), nil
@@ -305,13 +287,12 @@ func handleZLEXCOUNT(params internal.HandlerFuncParams) ([]byte, error) {
 	count := 0
 
 	for _, m := range members {
-		if slices.Contains([]int{1, 0}, internal.CompareLex(string(m.Value), minimum)) &&
-			slices.Contains([]int{-1, 0}, internal.CompareLex(string(m.Value), maximum)) {
+		if inLexRange(string(m.Value), minimum, maximum) {
 			count += 1
 		}
 	}
 
-	return []byte(fmt.Sprintf(":%d\r\n", count)), nil
+	return new(types.ResponseWriter).Integer(count).Bytes(), nil
 }
 
 func handleZDIFF(params internal.HandlerFuncParams) ([]byte, error) {
@@ -371,20 +352,9 @@ func handleZDIFF(params internal.HandlerFuncParams) ([]byte, error) {
 
 	var diff = baseSortedSet.Subtract(sets)
 
-	res := fmt.Sprintf("*%d", diff.Cardinality())
 	includeScores := withscoresIndex != -1 && withscoresIndex >= 2
 
-	for _, m := range diff.GetAll() {
-		if includeScores {
-			res += fmt.Sprintf("\r\n*2\r\n$%d\r\n%s\r\n+%s", len(m.Value), m.Value, strconv.FormatFloat(float64(m.Score), 'f', -1, 64))
-		} else {
-			res += fmt.Sprintf("\r\n*1\r\n$%d\r\n%s", len(m.Value), m.Value)
-		}
-	}
-
-	res += "\r\n"
-
-	return []byte(res), nil
+	return writeMembers(new(types.ResponseWriter), diff.GetAll(), includeScores).Bytes(), nil
 }
 
 func handleZDIFFSTORE(params internal.HandlerFuncParams) ([]byte, error) {
@@ -406,8 +376,8 @@ func handleZDIFFSTORE(params internal.HandlerFuncParams) ([]byte, error) {
 
 	// Extract base set
 	if !params.KeyExists(params.Context, keys.ReadKeys[0]) {
-		// If base set does not exist, return 0
-		return []byte(":0\r\n"), nil
+		// If base set does not exist, the difference is empty
+		return internal.DeleteEmptyDestination(params, destination)
 	}
 	if _, err = params.KeyRLock(params.Context, keys.ReadKeys[0]); err != nil {
 		return nil, err
@@ -434,6 +404,9 @@ func handleZDIFFSTORE(params internal.HandlerFuncParams) ([]byte, error) {
 	}
 
 	diff := baseSortedSet.Subtract(sets)
+	if diff.Cardinality() == 0 {
+		return internal.DeleteEmptyDestination(params, destination)
+	}
 
 	if params.KeyExists(params.Context, destination) {
 		if _, err = params.KeyLock(params.Context, destination); err != nil {
@@ -450,7 +423,7 @@ func handleZDIFFSTORE(params internal.HandlerFuncParams) ([]byte, error) {
 		return nil, err
 	}
 
-	return []byte(fmt.Sprintf(":%d\r\n", diff.Cardinality())), nil
+	return new(types.ResponseWriter).Integer(diff.Cardinality()).Bytes(), nil
 }
 
 func handleZINCRBY(params internal.HandlerFuncParams) ([]byte, error) {
@@ -496,7 +469,7 @@ func handleZINCRBY(params internal.HandlerFuncParams) ([]byte, error) {
 			return nil, err
 		}
 		params.KeyUnlock(params.Context, key)
-		return []byte(fmt.Sprintf("+%s\r\n", strconv.FormatFloat(float64(increment), 'f', -1, 64))), nil
+		return new(types.ResponseWriter).Simple(strconv.FormatFloat(float64(increment), 'f', -1, 64)).Bytes(), nil
 	}
 
 	if _, err = params.KeyLock(params.Context, key); err != nil {
@@ -516,8 +489,10 @@ func handleZINCRBY(params internal.HandlerFuncParams) ([]byte, error) {
 		"incr"); err != nil {
 		return nil, err
 	}
-	return []byte(fmt.Sprintf("+%s\r\n",
-		strconv.FormatFloat(float64(set.Get(member).Score), 'f', -1, 64))), nil
+	if err = params.SetValue(params.Context, key, set); err != nil {
+		return nil, err
+	}
+	return new(types.ResponseWriter).Simple(strconv.FormatFloat(float64(set.Get(member).Score), 'f', -1, 64)).Bytes(), nil
 }
 
 func handleZINTER(params internal.HandlerFuncParams) ([]byte, error) {
@@ -563,21 +538,7 @@ func handleZINTER(params internal.HandlerFuncParams) ([]byte, error) {
 
 	intersect := Intersect(aggregate, setParams...)
 
-	res := fmt.Sprintf("*%d", intersect.Cardinality())
-
-	if intersect.Cardinality() > 0 {
-		for _, m := range intersect.GetAll() {
-			if withscores {
-				res += fmt.Sprintf("\r\n*2\r\n$%d\r\n%s\r\n+%s", len(m.Value), m.Value, strconv.FormatFloat(float64(m.Score), 'f', -1, 64))
-			} else {
-				res += fmt.Sprintf("\r\n*1\r\n$%d\r\n%s", len(m.Value), m.Value)
-			}
-		}
-	}
-
-	res += "\r\n"
-
-	return []byte(res), nil
+	return writeMembers(new(types.ResponseWriter), intersect.GetAll(), withscores).Bytes(), nil
 }
 
 func handleZINTERSTORE(params internal.HandlerFuncParams) ([]byte, error) {
@@ -611,7 +572,7 @@ func handleZINTERSTORE(params internal.HandlerFuncParams) ([]byte, error) {
 
 	for i := 0; i < len(keys); i++ {
 		if !params.KeyExists(params.Context, keys[i]) {
-			return []byte(":0\r\n"), nil
+			return internal.DeleteEmptyDestination(params, destination)
 		}
 		if _, err = params.KeyRLock(params.Context, keys[i]); err != nil {
 			return nil, err
@@ -628,12 +589,15 @@ func handleZINTERSTORE(params internal.HandlerFuncParams) ([]byte, error) {
 	}
 
 	intersect := Intersect(aggregate, setParams...)
+	if intersect.Cardinality() == 0 {
+		return internal.DeleteEmptyDestination(params, destination)
+	}
 
-	if params.KeyExists(params.Context, destination) && intersect.Cardinality() > 0 {
+	if params.KeyExists(params.Context, destination) {
 		if _, err = params.KeyLock(params.Context, destination); err != nil {
 			return nil, err
 		}
-	} else if intersect.Cardinality() > 0 {
+	} else {
 		if _, err = params.CreateKeyAndLock(params.Context, destination); err != nil {
 			return nil, err
 		}
@@ -644,7 +608,7 @@ func handleZINTERSTORE(params internal.HandlerFuncParams) ([]byte, error) {
 		return nil, err
 	}
 
-	return []byte(fmt.Sprintf(":%d\r\n", intersect.Cardinality())), nil
+	return new(types.ResponseWriter).Integer(intersect.Cardinality()).Bytes(), nil
 }
 
 func handleZMPOP(params internal.HandlerFuncParams) ([]byte, error) {
@@ -653,73 +617,35 @@ func handleZMPOP(params internal.HandlerFuncParams) ([]byte, error) {
 		return nil, err
 	}
 
-	count := 1
-	policy := "min"
-	modifierIdx := -1
+	// The options follow the keys, so that a key named like an option is not mistaken for one.
+	count, policy, err := parseZMPOPOptions(params.Command[len(keys.WriteKeys)+1:])
+	if err != nil {
+		return nil, err
+	}
 
-	// Parse COUNT from command
-	countIdx := slices.IndexFunc(params.Command, func(s string) bool {
-		return strings.ToLower(s) == "count"
-	})
-	if countIdx != -1 {
-		if countIdx < 2 {
-			return nil, errors.New(constants.WrongArgsResponse)
-		}
-		if countIdx == len(params.Command)-1 {
-			return nil, errors.New("count must be a positive integer")
+	// Pop from the first non-empty sorted set in the order that the keys were provided.
+	// Keys that do not hold a sorted set are skipped.
+	for _, key := range keys.WriteKeys {
+		if !params.KeyExists(params.Context, key) {
+			continue
 		}
-		c, err := strconv.Atoi(params.Command[countIdx+1])
-		if err != nil {
+		if _, err = params.KeyLock(params.Context, key); err != nil {
 			return nil, err
 		}
-		if c <= 0 {
-			return nil, errors.New("count must be a positive integer")
-		}
-		count = c
-		modifierIdx = countIdx
-	}
-
-	// Parse MIN/MAX from the command
-	policyIdx := slices.IndexFunc(params.Command, func(s string) bool {
-		return slices.Contains([]string{"min", "max"}, strings.ToLower(s))
-	})
-	if policyIdx != -1 {
-		if policyIdx < 2 {
-			return nil, errors.New(constants.WrongArgsResponse)
+		set, ok := params.GetValue(params.Context, key).(*SortedSet)
+		if !ok || set.Cardinality() == 0 {
+			params.KeyUnlock(params.Context, key)
+			continue
 		}
-		policy = strings.ToLower(params.Command[policyIdx])
-		if modifierIdx == -1 || (policyIdx < modifierIdx) {
-			modifierIdx = policyIdx
+		popped, err := set.PopMembers(count, policy)
+		if err == nil && len(popped) > 0 {
+			err = params.SetValue(params.Context, key, set)
 		}
-	}
-
-	for i := 0; i < len(keys.WriteKeys); i++ {
-		if params.KeyExists(params.Context, keys.WriteKeys[i]) {
-			if _, err = params.KeyLock(params.Context, keys.WriteKeys[i]); err != nil {
-				continue
-			}
-			v, ok := params.GetValue(params.Context, keys.WriteKeys[i]).(*SortedSet)
-			if !ok || v.Cardinality() == 0 {
-				params.KeyUnlock(params.Context, keys.WriteKeys[i])
-				continue
-			}
-			popped, err := v.Pop(count, policy)
-			if err != nil {
-				params.KeyUnlock(params.Context, keys.WriteKeys[i])
-				return nil, err
-			}
-			params.KeyUnlock(params.Context, keys.WriteKeys[i])
-
-			res := fmt.Sprintf("*%d", popped.Cardinality())
-
-			for _, m := range popped.GetAll() {
-				res += fmt.Sprintf("\r\n*2\r\n$%d\r\n%s\r\n+%s", len(m.Value), m.Value, strconv.FormatFloat(float64(m.Score), 'f', -1, 64))
-			}
-
-			res += "\r\n"
-
-			return []byte(res), nil
+		params.KeyUnlock(params.Context, key)
+		if err != nil {
+			return nil, err
 		}
+		return writeMembers(new(types.ResponseWriter), popped, true).Bytes(), nil
 	}
 
 	return []byte("*0\r\n"), nil
@@ -763,61 +689,161 @@ func handleZPOP(params internal.HandlerFuncParams) ([]byte, error) {
 		return nil, fmt.Errorf("value at key %s is not a sorted set", key)
 	}
 
-	popped, err := set.Pop(count, policy)
+	popped, err := set.PopMembers(count, policy)
 	if err != nil {
 		return nil, err
 	}
+	if len(popped) > 0 {
+		if err = params.SetValue(params.Context, key, set); err != nil {
+			return nil, err
+		}
+	}
+
+	return writeMembers(new(types.ResponseWriter), popped, true).Bytes(), nil
+}
+
+// handleBZPOP handles BZPOPMIN, BZPOPMAX and BZMPOP.
+// The keys are checked in the order that they were provided, and the command blocks until one of the
+// sorted sets is non-empty or the timeout expires.
+func handleBZPOP(params internal.HandlerFuncParams) ([]byte, error) {
+	var keys internal.KeyExtractionFuncResult
+	var err error
+	var timeoutArg string
+	count := 1
+	policy := "min"
+
+	if strings.EqualFold(params.Command[0], "bzmpop") {
+		if keys, err = bzmpopKeyFunc(params.Command); err != nil {
+			return nil, err
+		}
+		timeoutArg = params.Command[1]
+		if count, policy, err = parseBZMPOPOptions(params.Command[len(keys.WriteKeys)+2:]); err != nil {
+			return nil, err
+		}
+	} else {
+		if keys, err = bzpopKeyFunc(params.Command); err != nil {
+			return nil, err
+		}
+		timeoutArg = params.Command[len(params.Command)-1]
+		if strings.EqualFold(params.Command[0], "bzpopmax") {
+			policy = "max"
+		}
+	}
+
+	timeout, err := strconv.ParseFloat(timeoutArg, 64)
+	if err != nil || timeout < 0 {
+		return nil, errors.New("timeout must be a non-negative number")
+	}
 
-	res := fmt.Sprintf("*%d", popped.Cardinality())
-	for _, m := range popped.GetAll() {
-		res += fmt.Sprintf("\r\n*2\r\n$%d\r\n%s\r\n+%s", len(m.Value), m.Value, strconv.FormatFloat(float64(m.Score), 'f', -1, 64))
+	// A timeout of 0 blocks until a member is available.
+	ctx := params.Context
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(timeout*float64(time.Second)))
+		defer cancel()
 	}
 
-	res += "\r\n"
+	// Start watching the keys before checking them so that a ZADD between the check and the wait is not missed.
+	watcher := params.WatchKeys(keys.WriteKeys...)
+	defer watcher.Close()
 
-	return []byte(res), nil
+	for {
+		for _, key := range keys.WriteKeys {
+			popped, err := popMembers(ctx, params, key, count, policy)
+			if err != nil {
+				return nil, err
+			}
+			if len(popped) == 0 {
+				continue
+			}
+			res := new(types.ResponseWriter)
+			if strings.EqualFold(params.Command[0], "bzmpop") {
+				return writeMembers(res.Array(2).Bulk(key), popped, true).Bytes(), nil
+			}
+			return res.Array(3).
+				Bulk(key).
+				Bulk(string(popped[0].Value)).
+				Bulk(strconv.FormatFloat(float64(popped[0].Score), 'f', -1, 64)).
+				Bytes(), nil
+		}
+
+		select {
+		case <-watcher.C:
+			// One of the keys was modified, try again.
+		case <-ctx.Done():
+			if params.Context.Err() != nil {
+				return nil, context.Cause(params.Context)
+			}
+			// Return a null array when the timeout expires.
+			return []byte("*-1\r\n"), nil
+		}
+	}
 }
 
-func handleZMSCORE(params internal.HandlerFuncParams) ([]byte, error) {
-	keys, err := zmscoreKeyFunc(params.Command)
-	if err != nil {
-		return nil, err
+// popMembers pops up to count members from the sorted set at the key for a blocking pop command.
+// Returns an empty slice if the key does not exist or the sorted set is empty.
+func popMembers(ctx context.Context, params internal.HandlerFuncParams, key string, count int, policy string) ([]MemberParam, error) {
+	if !params.KeyExists(ctx, key) {
+		return nil, nil
 	}
 
-	key := keys.ReadKeys[0]
+	// Wait for a state copy in progress to finish before modifying the store.
+	done := params.WaitForStateCopy()
+	defer done()
 
-	if !params.KeyExists(params.Context, key) {
-		return []byte("*0\r\n"), nil
+	if _, err := params.KeyLock(ctx, key); err != nil {
+		return nil, err
 	}
+	defer params.KeyUnlock(ctx, key)
 
-	if _, err = params.KeyRLock(params.Context, key); err != nil {
+	set, ok := params.GetValue(ctx, key).(*SortedSet)
+	if !ok {
+		return nil, fmt.Errorf("value at key %s is not a sorted set", key)
+	}
+
+	popped, err := set.PopMembers(count, policy)
+	if err != nil || len(popped) == 0 {
+		return popped, err
+	}
+	if err = params.SetValue(ctx, key, set); err != nil {
 		return nil, err
 	}
-	defer params.KeyRUnlock(params.Context, key)
+	return popped, nil
+}
 
-	set, ok := params.GetValue(params.Context, key).(*SortedSet)
-	if !ok {
-		return nil, fmt.Errorf("value at %s is not a sorted set", key)
+func handleZMSCORE(params internal.HandlerFuncParams) ([]byte, error) {
+	keys, err := zmscoreKeyFunc(params.Command)
+	if err != nil {
+		return nil, err
 	}
 
+	key := keys.ReadKeys[0]
 	members := params.Command[2:]
 
-	res := fmt.Sprintf("*%d", len(members))
-
-	var member MemberObject
+	// None of the members exist in a sorted set that does not exist.
+	set := NewSortedSet([]MemberParam{})
+	if params.KeyExists(params.Context, key) {
+		if _, err = params.KeyRLock(params.Context, key); err != nil {
+			return nil, err
+		}
+		defer params.KeyRUnlock(params.Context, key)
+		var ok bool
+		if set, ok = params.GetValue(params.Context, key).(*SortedSet); !ok {
+			return nil, fmt.Errorf("value at %s is not a sorted set", key)
+		}
+	}
 
-	for i := 0; i < len(members); i++ {
-		member = set.Get(Value(members[i]))
+	res := new(types.ResponseWriter).Array(len(members))
+	for _, m := range members {
+		member := set.Get(Value(m))
 		if !member.Exists {
-			res = fmt.Sprintf("%s\r\n$-1", res)
-		} else {
-			res = fmt.Sprintf("%s\r\n+%s", res, strconv.FormatFloat(float64(member.Score), 'f', -1, 64))
+			res.Null()
+			continue
 		}
+		res.Float(float64(member.Score))
 	}
 
-	res += "\r\n"
-
-	return []byte(res), nil
+	return res.Bytes(), nil
 }
 
 func handleZRANDMEMBER(params internal.HandlerFuncParams) ([]byte, error) {
@@ -864,18 +890,7 @@ func handleZRANDMEMBER(params internal.HandlerFuncParams) ([]byte, error) {
 
 	members := set.GetRandom(count)
 
-	res := fmt.Sprintf("*%d", len(members))
-	for _, m := range members {
-		if withscores {
-			res += fmt.Sprintf("\r\n*2\r\n$%d\r\n%s\r\n+%s", len(m.Value), m.Value, strconv.FormatFloat(float64(m.Score), 'f', -1, 64))
-		} else {
-			res += fmt.Sprintf("\r\n*1\r\n$%d\r\n%s", len(m.Value), m.Value)
-		}
-	}
-
-	res += "\r\n"
-
-	return []byte(res), nil
+	return writeMembers(new(types.ResponseWriter), members, withscores).Bytes(), nil
 }
 
 func handleZRANK(params internal.HandlerFuncParams) ([]byte, error) {
@@ -917,10 +932,9 @@ func handleZRANK(params internal.HandlerFuncParams) ([]byte, error) {
 	for i := 0; i < len(members); i++ {
 		if members[i].Value == Value(member) {
 			if withscores {
-				score := strconv.FormatFloat(float64(members[i].Score), 'f', -1, 64)
-				return []byte(fmt.Sprintf("*2\r\n:%d\r\n$%d\r\n%s\r\n", i, len(score), score)), nil
+				return new(types.ResponseWriter).Array(2).Integer(i).Float(float64(members[i].Score)).Bytes(), nil
 			} else {
-				return []byte(fmt.Sprintf("*1\r\n:%d\r\n", i)), nil
+				return new(types.ResponseWriter).Array(1).Integer(i).Bytes(), nil
 			}
 		}
 	}
@@ -956,8 +970,58 @@ func handleZREM(params internal.HandlerFuncParams) ([]byte, error) {
 			deletedCount += 1
 		}
 	}
+	if deletedCount > 0 {
+		if err = params.SetValue(params.Context, key, set); err != nil {
+			return nil, err
+		}
+	}
+
+	return new(types.ResponseWriter).Integer(deletedCount).Bytes(), nil
+}
+
+func handleZSCAN(params internal.HandlerFuncParams) ([]byte, error) {
+	keys, err := zscanKeyFunc(params.Command)
+	if err != nil {
+		return nil, err
+	}
+
+	key := keys.ReadKeys[0]
+
+	options, err := internal.ParseScanOptions(params.Command[2:], false)
+	if err != nil {
+		return nil, err
+	}
+
+	if !params.KeyExists(params.Context, key) {
+		return internal.EncodeScanReply(0, nil), nil
+	}
+
+	if _, err = params.KeyRLock(params.Context, key); err != nil {
+		return nil, err
+	}
+	defer params.KeyRUnlock(params.Context, key)
+
+	set, ok := params.GetValue(params.Context, key).(*SortedSet)
+	if !ok {
+		return nil, fmt.Errorf("value at %s is not a sorted set", key)
+	}
+
+	scores := make(map[string]Score, set.Cardinality())
+	members := make([]string, 0, set.Cardinality())
+	for _, member := range set.GetAll() {
+		scores[string(member.Value)] = member.Score
+		members = append(members, string(member.Value))
+	}
+	members, cursor := keyindex.ScanMembers(members, options.Cursor, options.Count)
+
+	res := make([]string, 0, len(members)*2)
+	for _, member := range members {
+		if options.Match == nil || options.Match.Match(member) {
+			res = append(res, member, strconv.FormatFloat(float64(scores[member]), 'f', -1, 64))
+		}
+	}
 
-	return []byte(fmt.Sprintf(":%d\r\n", deletedCount)), nil
+	return internal.EncodeScanReply(cursor, res), nil
 }
 
 func handleZSCORE(params internal.HandlerFuncParams) ([]byte, error) {
@@ -984,9 +1048,7 @@ func handleZSCORE(params internal.HandlerFuncParams) ([]byte, error) {
 		return []byte("$-1\r\n"), nil
 	}
 
-	score := strconv.FormatFloat(float64(member.Score), 'f', -1, 64)
-
-	return []byte(fmt.Sprintf("$%d\r\n%s\r\n", len(score), score)), nil
+	return new(types.ResponseWriter).Float(float64(member.Score)).Bytes(), nil
 }
 
 func handleZREMRANGEBYSCORE(params internal.HandlerFuncParams) ([]byte, error) {
@@ -997,16 +1059,14 @@ func handleZREMRANGEBYSCORE(params internal.HandlerFuncParams) ([]byte, error) {
 
 	key := keys.WriteKeys[0]
 
-	deletedCount := 0
-
-	minimum, err := strconv.ParseFloat(params.Command[2], 64)
-	if err != nil {
-		return nil, err
+	minimum, ok := parseScoreBound(params.Command[2])
+	if !ok {
+		return nil, errors.New("min constraint must be a double")
 	}
 
-	maximum, err := strconv.ParseFloat(params.Command[3], 64)
-	if err != nil {
-		return nil, err
+	maximum, ok := parseScoreBound(params.Command[3])
+	if !ok {
+		return nil, errors.New("max constraint must be a double")
 	}
 
 	if !params.KeyExists(params.Context, key) {
@@ -1016,21 +1076,31 @@ func handleZREMRANGEBYSCORE(params internal.HandlerFuncParams) ([]byte, error) {
 	if _, err = params.KeyLock(params.Context, key); err != nil {
 		return nil, err
 	}
-	defer params.KeyUnlock(params.Context, key)
 
 	set, ok := params.GetValue(params.Context, key).(*SortedSet)
 	if !ok {
+		params.KeyUnlock(params.Context, key)
 		return nil, fmt.Errorf("value at %s is not a sorted set", key)
 	}
 
+	deletedCount := 0
 	for _, m := range set.GetAll() {
-		if m.Score >= Score(minimum) && m.Score <= Score(maximum) {
+		if inScoreRange(m.Score, minimum, maximum) {
 			set.Remove(m.Value)
 			deletedCount += 1
 		}
 	}
 
-	return []byte(fmt.Sprintf(":%d\r\n", deletedCount)), nil
+	if deletedCount == 0 {
+		params.KeyUnlock(params.Context, key)
+		return []byte(":0\r\n"), nil
+	}
+
+	if err = commitRemoval(params, key, set); err != nil {
+		return nil, err
+	}
+
+	return new(types.ResponseWriter).Integer(deletedCount).Bytes(), nil
 }
 
 func handleZREMRANGEBYRANK(params internal.HandlerFuncParams) ([]byte, error) {
@@ -1043,12 +1113,12 @@ func handleZREMRANGEBYRANK(params internal.HandlerFuncParams) ([]byte, error) {
 
 	start, err := strconv.Atoi(params.Command[2])
 	if err != nil {
-		return nil, err
+		return nil, errors.New("start index must be an integer")
 	}
 
 	stop, err := strconv.Atoi(params.Command[3])
 	if err != nil {
-		return nil, err
+		return nil, errors.New("stop index must be an integer")
 	}
 
 	if !params.KeyExists(params.Context, key) {
@@ -1058,10 +1128,10 @@ func handleZREMRANGEBYRANK(params internal.HandlerFuncParams) ([]byte, error) {
 	if _, err = params.KeyLock(params.Context, key); err != nil {
 		return nil, err
 	}
-	defer params.KeyUnlock(params.Context, key)
 
 	set, ok := params.GetValue(params.Context, key).(*SortedSet)
 	if !ok {
+		params.KeyUnlock(params.Context, key)
 		return nil, fmt.Errorf("value at %s is not a sorted set", key)
 	}
 
@@ -1073,29 +1143,35 @@ func handleZREMRANGEBYRANK(params internal.HandlerFuncParams) ([]byte, error) {
 	}
 
 	if start < 0 || start > set.Cardinality()-1 || stop < 0 || stop > set.Cardinality()-1 {
+		params.KeyUnlock(params.Context, key)
 		return nil, errors.New("indices out of bounds")
 	}
 
+	if start > stop {
+		start, stop = stop, start
+	}
+
+	// Members with the same score are ranked lexicographically so that the removed
+	// members are the same when the command is replayed from the AOF log or applied on a raft follower.
 	members := set.GetAll()
 	slices.SortFunc(members, func(a, b MemberParam) int {
+		if a.Score == b.Score {
+			return internal.CompareLex(string(a.Value), string(b.Value))
+		}
 		return cmp.Compare(a.Score, b.Score)
 	})
 
 	deletedCount := 0
+	for i := start; i <= stop; i++ {
+		set.Remove(members[i].Value)
+		deletedCount += 1
+	}
 
-	if start < stop {
-		for i := start; i <= stop; i++ {
-			set.Remove(members[i].Value)
-			deletedCount += 1
-		}
-	} else {
-		for i := stop; i <= start; i++ {
-			set.Remove(members[i].Value)
-			deletedCount += 1
-		}
+	if err = commitRemoval(params, key, set); err != nil {
+		return nil, err
 	}
 
-	return []byte(fmt.Sprintf(":%d\r\n", deletedCount)), nil
+	return new(types.ResponseWriter).Integer(deletedCount).Bytes(), nil
 }
 
 func handleZREMRANGEBYLEX(params internal.HandlerFuncParams) ([]byte, error) {
@@ -1105,8 +1181,8 @@ func handleZREMRANGEBYLEX(params internal.HandlerFuncParams) ([]byte, error) {
 	}
 
 	key := keys.WriteKeys[0]
-	minimum := params.Command[2]
-	maximum := params.Command[3]
+	minimum := parseLexBound(params.Command[2])
+	maximum := parseLexBound(params.Command[3])
 
 	if !params.KeyExists(params.Context, key) {
 		return []byte(":0\r\n"), nil
@@ -1115,10 +1191,10 @@ func handleZREMRANGEBYLEX(params internal.HandlerFuncParams) ([]byte, error) {
 	if _, err = params.KeyLock(params.Context, key); err != nil {
 		return nil, err
 	}
-	defer params.KeyUnlock(params.Context, key)
 
 	set, ok := params.GetValue(params.Context, key).(*SortedSet)
 	if !ok {
+		params.KeyUnlock(params.Context, key)
 		return nil, fmt.Errorf("value at %s is not a sorted set", key)
 	}
 
@@ -1127,6 +1203,7 @@ func handleZREMRANGEBYLEX(params internal.HandlerFuncParams) ([]byte, error) {
 	// Check if all the members have the same score. If not, return 0
 	for i := 0; i < len(members)-1; i++ {
 		if members[i].Score != members[i+1].Score {
+			params.KeyUnlock(params.Context, key)
 			return []byte(":0\r\n"), nil
 		}
 	}
@@ -1135,14 +1212,22 @@ func handleZREMRANGEBYLEX(params internal.HandlerFuncParams) ([]byte, error) {
 
 	// All the members have the same score
 	for _, m := range members {
-		if slices.Contains([]int{1, 0}, internal.CompareLex(string(m.Value), minimum)) &&
-			slices.Contains([]int{-1, 0}, internal.CompareLex(string(m.Value), maximum)) {
+		if inLexRange(string(m.Value), minimum, maximum) {
 			set.Remove(m.Value)
 			deletedCount += 1
 		}
 	}
 
-	return []byte(fmt.Sprintf(":%d\r\n", deletedCount)), nil
+	if deletedCount == 0 {
+		params.KeyUnlock(params.Context, key)
+		return []byte(":0\r\n"), nil
+	}
+
+	if err = commitRemoval(params, key, set); err != nil {
+		return nil, err
+	}
+
+	return new(types.ResponseWriter).Integer(deletedCount).Bytes(), nil
 }
 
 func handleZRANGE(params internal.HandlerFuncParams) ([]byte, error) {
@@ -1270,19 +1355,124 @@ func handleZRANGE(params internal.HandlerFuncParams) ([]byte, error) {
 		}
 	}
 
-	res := fmt.Sprintf("*%d", len(resultMembers))
+	return writeMembers(new(types.ResponseWriter), resultMembers, withscores).Bytes(), nil
+}
 
-	for _, m := range resultMembers {
-		if withscores {
-			res += fmt.Sprintf("\r\n*2\r\n$%d\r\n%s\r\n+%s", len(m.Value), m.Value, strconv.FormatFloat(float64(m.Score), 'f', -1, 64))
-		} else {
-			res += fmt.Sprintf("\r\n*1\r\n$%d\r\n%s", len(m.Value), m.Value)
+// handleZRANGEBYSCORE handles ZRANGEBYSCORE and ZREVRANGEBYSCORE.
+// ZREVRANGEBYSCORE takes the maximum before the minimum, and returns the members from the highest score.
+func handleZRANGEBYSCORE(params internal.HandlerFuncParams) ([]byte, error) {
+	keys, err := zrangebyscoreKeyFunc(params.Command)
+	if err != nil {
+		return nil, err
+	}
+
+	key := keys.ReadKeys[0]
+	reverse := strings.EqualFold(params.Command[0], "zrevrangebyscore")
+
+	minArg, maxArg := params.Command[2], params.Command[3]
+	if reverse {
+		minArg, maxArg = maxArg, minArg
+	}
+	minimum, ok := parseScoreBound(minArg)
+	if !ok {
+		return nil, errors.New("min constraint must be a double")
+	}
+	maximum, ok := parseScoreBound(maxArg)
+	if !ok {
+		return nil, errors.New("max constraint must be a double")
+	}
+
+	withscores, offset, count, err := parseRangeByOptions(params.Command[4:], true)
+	if err != nil {
+		return nil, err
+	}
+
+	if !params.KeyExists(params.Context, key) {
+		return []byte("*0\r\n"), nil
+	}
+
+	if _, err = params.KeyRLock(params.Context, key); err != nil {
+		return nil, err
+	}
+	defer params.KeyRUnlock(params.Context, key)
+
+	set, ok := params.GetValue(params.Context, key).(*SortedSet)
+	if !ok {
+		return nil, fmt.Errorf("value at %s is not a sorted set", key)
+	}
+
+	members := set.GetAll()
+	// Members with the same score are ordered lexicographically.
+	slices.SortFunc(members, func(a, b MemberParam) int {
+		c := cmp.Compare(a.Score, b.Score)
+		if c == 0 {
+			c = internal.CompareLex(string(a.Value), string(b.Value))
 		}
+		if reverse {
+			return -c
+		}
+		return c
+	})
+	members = slices.DeleteFunc(members, func(m MemberParam) bool {
+		return !inScoreRange(m.Score, minimum, maximum)
+	})
+
+	return writeMembers(new(types.ResponseWriter), limitMembers(members, offset, count), withscores).Bytes(), nil
+}
+
+// handleZRANGEBYLEX handles ZRANGEBYLEX and ZREVRANGEBYLEX.
+// ZREVRANGEBYLEX takes the maximum before the minimum, and returns the members in reverse lexicographical order.
+// Like ZLEXCOUNT, an empty array is returned if the members don't all have the same score.
+func handleZRANGEBYLEX(params internal.HandlerFuncParams) ([]byte, error) {
+	keys, err := zrangebylexKeyFunc(params.Command)
+	if err != nil {
+		return nil, err
+	}
+
+	key := keys.ReadKeys[0]
+	reverse := strings.EqualFold(params.Command[0], "zrevrangebylex")
+
+	minimum, maximum := parseLexBound(params.Command[2]), parseLexBound(params.Command[3])
+	if reverse {
+		minimum, maximum = maximum, minimum
+	}
+
+	_, offset, count, err := parseRangeByOptions(params.Command[4:], false)
+	if err != nil {
+		return nil, err
+	}
+
+	if !params.KeyExists(params.Context, key) {
+		return []byte("*0\r\n"), nil
+	}
+
+	if _, err = params.KeyRLock(params.Context, key); err != nil {
+		return nil, err
+	}
+	defer params.KeyRUnlock(params.Context, key)
+
+	set, ok := params.GetValue(params.Context, key).(*SortedSet)
+	if !ok {
+		return nil, fmt.Errorf("value at %s is not a sorted set", key)
 	}
 
-	res += "\r\n"
+	members := set.GetAll()
+	for i := 0; i < len(members)-1; i++ {
+		if members[i].Score != members[i+1].Score {
+			return []byte("*0\r\n"), nil
+		}
+	}
+	slices.SortFunc(members, func(a, b MemberParam) int {
+		if reverse {
+			return internal.CompareLex(string(b.Value), string(a.Value))
+		}
+		return internal.CompareLex(string(a.Value), string(b.Value))
+	})
+	members = slices.DeleteFunc(members, func(m MemberParam) bool {
+		return !inLexRange(string(m.Value), minimum, maximum)
+	})
 
-	return []byte(res), nil
+	return writeMembers(new(types.ResponseWriter), limitMembers(members, offset, count), false).Bytes(), nil
 }
 
 func handleZRANGESTORE(params internal.HandlerFuncParams) ([]byte, error) {
@@ -1293,121 +1483,121 @@ func handleZRANGESTORE(params internal.HandlerFuncParams) ([]byte, error) {
 
 	destination := keys.WriteKeys[0]
 	source := keys.ReadKeys[0]
-	policy := "byscore"
-	scoreStart := math.Inf(-1)    // Lower bound if policy is "byscore"
-	scoreStop := math.Inf(1)      // Upper bound if policy is "byfloat"
-	lexStart := params.Command[3] // Lower bound if policy is "bylex"
-	lexStop := params.Command[4]  // Upper bound if policy is "bylex"
-	offset := 0
-	count := -1
 
-	reverse := slices.ContainsFunc(params.Command[5:], func(s string) bool {
-		return strings.EqualFold(s, "rev")
-	})
-
-	if slices.ContainsFunc(params.Command[5:], func(s string) bool {
-		return strings.EqualFold(s, "bylex")
-	}) {
-		policy = "bylex"
-	} else {
-		// policy is "byscore" make sure start and stop are valid float values
-		scoreStart, err = strconv.ParseFloat(params.Command[3], 64)
-		if err != nil {
-			return nil, err
-		}
-		scoreStop, err = strconv.ParseFloat(params.Command[4], 64)
-		if err != nil {
-			return nil, err
-		}
+	options, err := parseRangeStoreOptions(params.Command[5:])
+	if err != nil {
+		return nil, err
 	}
 
-	if slices.ContainsFunc(params.Command[5:], func(s string) bool {
-		return strings.EqualFold(s, "limit")
-	}) {
-		limitIdx := slices.IndexFunc(params.Command[5:], func(s string) bool {
-			return strings.EqualFold(s, "limit")
-		})
-		if limitIdx != -1 && limitIdx > len(params.Command[5:])-3 {
-			return nil, errors.New("limit should contain offset and count as integers")
+	var startRank, stopRank int
+	var minScore, maxScore scoreBound
+	var minLex, maxLex lexBound
+	startArg, stopArg := params.Command[3], params.Command[4]
+	if options.reverse && (options.byScore || options.byLex) {
+		// When reversing a score or lex range, start is the maximum and stop is the minimum.
+		startArg, stopArg = stopArg, startArg
+	}
+	switch {
+	case options.byScore:
+		var ok bool
+		if minScore, ok = parseScoreBound(startArg); !ok {
+			return nil, errors.New("min constraint must be a double")
 		}
-		offset, err = strconv.Atoi(params.Command[5:][limitIdx+1])
-		if err != nil {
-			return nil, errors.New("limit offset must be integer")
+		if maxScore, ok = parseScoreBound(stopArg); !ok {
+			return nil, errors.New("max constraint must be a double")
 		}
-		if offset < 0 {
-			return nil, errors.New("limit offset must be >= 0")
+	case options.byLex:
+		minLex, maxLex = parseLexBound(startArg), parseLexBound(stopArg)
+	default:
+		if startRank, err = strconv.Atoi(startArg); err != nil {
+			return nil, errors.New("start index must be an integer")
 		}
-		count, err = strconv.Atoi(params.Command[5:][limitIdx+2])
-		if err != nil {
-			return nil, errors.New("limit count must be integer")
+		if stopRank, err = strconv.Atoi(stopArg); err != nil {
+			return nil, errors.New("stop index must be an integer")
 		}
 	}
 
 	if !params.KeyExists(params.Context, source) {
-		return []byte("*0\r\n"), nil
+		return internal.DeleteEmptyDestination(params, destination)
 	}
 
-	if _, err = params.KeyRLock(params.Context, source); err != nil {
-		return nil, err
+	// The source is locked until the destination is written so that the stored range is consistent with the source.
+	// When the source is also the destination, the write lock is taken on the source instead.
+	if source == destination {
+		if _, err = params.KeyLock(params.Context, source); err != nil {
+			return nil, err
+		}
+	} else {
+		if _, err = params.KeyRLock(params.Context, source); err != nil {
+			return nil, err
+		}
+		defer params.KeyRUnlock(params.Context, source)
 	}
-	defer params.KeyRUnlock(params.Context, source)
 
 	set, ok := params.GetValue(params.Context, source).(*SortedSet)
 	if !ok {
+		if source == destination {
+			params.KeyUnlock(params.Context, source)
+		}
 		return nil, fmt.Errorf("value at %s is not a sorted set", source)
 	}
 
-	if offset > set.Cardinality() {
-		return []byte(":0\r\n"), nil
-	}
-	if count < 0 {
-		count = set.Cardinality() - offset
-	}
-
+	// Members with the same score are ordered lexicographically.
 	members := set.GetAll()
-	if strings.EqualFold(policy, "byscore") {
-		slices.SortFunc(members, func(a, b MemberParam) int {
-			// Do a score sort
-			if reverse {
-				return cmp.Compare(b.Score, a.Score)
-			}
-			return cmp.Compare(a.Score, b.Score)
+	slices.SortFunc(members, func(a, b MemberParam) int {
+		c := cmp.Compare(a.Score, b.Score)
+		if c == 0 {
+			c = internal.CompareLex(string(a.Value), string(b.Value))
+		}
+		if options.reverse {
+			return -c
+		}
+		return c
+	})
+
+	switch {
+	case options.byScore:
+		members = slices.DeleteFunc(members, func(m MemberParam) bool {
+			return !inScoreRange(m.Score, minScore, maxScore)
 		})
-	}
-	if strings.EqualFold(policy, "bylex") {
+		members = limitMembers(members, options.offset, options.count)
+	case options.byLex:
 		// If policy is BYLEX, all the elements must have the same score
-		for i := 0; i < len(members)-1; i++ {
-			if members[i].Score != members[i+1].Score {
-				return []byte(":0\r\n"), nil
-			}
+		if len(members) > 0 && members[0].Score != members[len(members)-1].Score {
+			members = nil
 		}
-		slices.SortFunc(members, func(a, b MemberParam) int {
-			if reverse {
-				return internal.CompareLex(string(b.Value), string(a.Value))
-			}
-			return internal.CompareLex(string(a.Value), string(b.Value))
+		members = slices.DeleteFunc(members, func(m MemberParam) bool {
+			return !inLexRange(string(m.Value), minLex, maxLex)
 		})
+		members = limitMembers(members, options.offset, options.count)
+	default:
+		if startRank < 0 {
+			startRank += len(members)
+		}
+		if stopRank < 0 {
+			stopRank += len(members)
+		}
+		startRank = max(startRank, 0)
+		stopRank = min(stopRank, len(members)-1)
+		if startRank > stopRank {
+			members = nil
+		} else {
+			members = members[startRank : stopRank+1]
+		}
 	}
 
-	var resultMembers []MemberParam
+	newSortedSet := NewSortedSet(members)
 
-	for i := offset; i <= count; i++ {
-		if i >= len(members) {
-			break
-		}
-		if strings.EqualFold(policy, "byscore") {
-			if members[i].Score >= Score(scoreStart) && members[i].Score <= Score(scoreStop) {
-				resultMembers = append(resultMembers, members[i])
-			}
-			continue
-		}
-		if slices.Contains([]int{1, 0}, internal.CompareLex(string(members[i].Value), lexStart)) &&
-			slices.Contains([]int{-1, 0}, internal.CompareLex(string(members[i].Value), lexStop)) {
-			resultMembers = append(resultMembers, members[i])
+	if source == destination {
+		if err = commitRemoval(params, destination, newSortedSet); err != nil {
+			return nil, err
 		}
+		return new(types.ResponseWriter).Integer(newSortedSet.Cardinality()).Bytes(), nil
 	}
 
-	newSortedSet := NewSortedSet(resultMembers)
+	if newSortedSet.Cardinality() == 0 {
+		return internal.DeleteEmptyDestination(params, destination)
+	}
 
 	if params.KeyExists(params.Context, destination) {
 		if _, err = params.KeyLock(params.Context, destination); err != nil {
@@ -1424,7 +1614,7 @@ func handleZRANGESTORE(params internal.HandlerFuncParams) ([]byte, error) {
 		return nil, err
 	}
 
-	return []byte(fmt.Sprintf(":%d\r\n", newSortedSet.Cardinality())), nil
+	return new(types.ResponseWriter).Integer(newSortedSet.Cardinality()).Bytes(), nil
 }
 
 func handleZUNION(params internal.HandlerFuncParams) ([]byte, error) {
@@ -1467,18 +1657,7 @@ func handleZUNION(params internal.HandlerFuncParams) ([]byte, error) {
 
 	union := Union(aggregate, setParams...)
 
-	res := fmt.Sprintf("*%d", union.Cardinality())
-	for _, m := range union.GetAll() {
-		if withscores {
-			res += fmt.Sprintf("\r\n*2\r\n$%d\r\n%s\r\n+%s", len(m.Value), m.Value, strconv.FormatFloat(float64(m.Score), 'f', -1, 64))
-		} else {
-			res += fmt.Sprintf("\r\n*1\r\n$%d\r\n%s", len(m.Value), m.Value)
-		}
-	}
-
-	res += "\r\n"
-
-	return []byte(res), nil
+	return writeMembers(new(types.ResponseWriter), union.GetAll(), withscores).Bytes(), nil
 }
 
 func handleZUNIONSTORE(params internal.HandlerFuncParams) ([]byte, error) {
@@ -1528,6 +1707,9 @@ func handleZUNIONSTORE(params internal.HandlerFuncParams) ([]byte, error) {
 	}
 
 	union := Union(aggregate, setParams...)
+	if union.Cardinality() == 0 {
+		return internal.DeleteEmptyDestination(params, destination)
+	}
 
 	if params.KeyExists(params.Context, destination) {
 		if _, err = params.KeyLock(params.Context, destination); err != nil {
@@ -1544,7 +1726,7 @@ func handleZUNIONSTORE(params internal.HandlerFuncParams) ([]byte, error) {
 		return nil, err
 	}
 
-	return []byte(fmt.Sprintf(":%d\r\n", union.Cardinality())), nil
+	return new(types.ResponseWriter).Integer(union.Cardinality()).Bytes(), nil
 }
 
 func Commands() []internal.Command {
@@ -1663,6 +1845,39 @@ Returns nil for members that do not exist in the set`,
 			KeyExtractionFunc: zmscoreKeyFunc,
 			HandlerFunc:       handleZMSCORE,
 		},
+		{
+			Command:    "bzmpop",
+			Module:     constants.SortedSetModule,
+			Categories: []string{constants.SortedSetCategory, constants.WriteCategory, constants.BlockingCategory, constants.SlowCategory},
+			Description: `(BZMPOP timeout key [key ...] <MIN | MAX> [COUNT count])
+Blocking version of ZMPOP. Pops 'count' members from the first non-empty sorted set and returns the key with the popped members.
+Blocks until a member is available or the timeout in seconds expires. A timeout of 0 blocks indefinitely.`,
+			Sync:              false,
+			KeyExtractionFunc: bzmpopKeyFunc,
+			HandlerFunc:       handleBZPOP,
+		},
+		{
+			Command:    "bzpopmax",
+			Module:     constants.SortedSetModule,
+			Categories: []string{constants.SortedSetCategory, constants.WriteCategory, constants.BlockingCategory, constants.SlowCategory},
+			Description: `(BZPOPMAX key [key ...] timeout)
+Removes and returns the member with the highest score from the first non-empty sorted set, along with its key and score.
+Blocks until a member is available or the timeout in seconds expires. A timeout of 0 blocks indefinitely.`,
+			Sync:              false,
+			KeyExtractionFunc: bzpopKeyFunc,
+			HandlerFunc:       handleBZPOP,
+		},
+		{
+			Command:    "bzpopmin",
+			Module:     constants.SortedSetModule,
+			Categories: []string{constants.SortedSetCategory, constants.WriteCategory, constants.BlockingCategory, constants.SlowCategory},
+			Description: `(BZPOPMIN key [key ...] timeout)
+Removes and returns the member with the lowest score from the first non-empty sorted set, along with its key and score.
+Blocks until a member is available or the timeout in seconds expires. A timeout of 0 blocks indefinitely.`,
+			Sync:              false,
+			KeyExtractionFunc: bzpopKeyFunc,
+			HandlerFunc:       handleBZPOP,
+		},
 		{
 			Command:    "zpopmax",
 			Module:     constants.SortedSetModule,
@@ -1736,10 +1951,22 @@ Returns the number of elements removed.`,
 			HandlerFunc:       handleZSCORE,
 		},
 		{
-			Command:           "zremrangebylex",
-			Module:            constants.SortedSetModule,
-			Categories:        []string{constants.SortedSetCategory, constants.WriteCategory, constants.SlowCategory},
-			Description:       `(ZREMRANGEBYLEX key min max) Removes the elements in the lexicographical range between min and max`,
+			Command:    "zscan",
+			Module:     constants.SortedSetModule,
+			Categories: []string{constants.SortedSetCategory, constants.ReadCategory, constants.SlowCategory},
+			Description: `(ZSCAN key cursor [MATCH pattern] [COUNT count])
+Incrementally iterates the members of a sorted set. Returns the cursor to pass to the next call and
+a batch of members with their scores. The iteration is complete when the returned cursor is 0.`,
+			Sync:              false,
+			KeyExtractionFunc: zscanKeyFunc,
+			HandlerFunc:       handleZSCAN,
+		},
+		{
+			Command:    "zremrangebylex",
+			Module:     constants.SortedSetModule,
+			Categories: []string{constants.SortedSetCategory, constants.WriteCategory, constants.SlowCategory},
+			Description: `(ZREMRANGEBYLEX key min max) Removes the elements in the lexicographical range between min and max.
+Prefix a boundary with "(" to exclude it. "-" and "+" are the unbounded minimum and maximum. The key is deleted when no elements are left.`,
 			Sync:              true,
 			KeyExtractionFunc: zremrangebylexKeyFunc,
 			HandlerFunc:       handleZREMRANGEBYLEX,
@@ -1749,16 +1976,18 @@ Returns the number of elements removed.`,
 			Module:     constants.SortedSetModule,
 			Categories: []string{constants.SortedSetCategory, constants.WriteCategory, constants.SlowCategory},
 			Description: `(ZREMRANGEBYRANK key start stop) Removes the elements in the rank range between start and stop.
-The elements are ordered from lowest score to highest score`,
+The elements are ordered from lowest score to highest score, and elements with the same score are ordered lexicographically.
+The key is deleted when no elements are left.`,
 			Sync:              true,
 			KeyExtractionFunc: zremrangebyrankKeyFunc,
 			HandlerFunc:       handleZREMRANGEBYRANK,
 		},
 		{
-			Command:           "zremrangebyscore",
-			Module:            constants.SortedSetModule,
-			Categories:        []string{constants.SortedSetCategory, constants.WriteCategory, constants.SlowCategory},
-			Description:       `(ZREMRANGEBYSCORE key min max) Removes the elements whose scores are in the range between min and max`,
+			Command:    "zremrangebyscore",
+			Module:     constants.SortedSetModule,
+			Categories: []string{constants.SortedSetCategory, constants.WriteCategory, constants.SlowCategory},
+			Description: `(ZREMRANGEBYSCORE key min max) Removes the elements whose scores are in the range between min and max.
+Prefix a boundary with "(" to exclude it. The key is deleted when no elements are left.`,
 			Sync:              true,
 			KeyExtractionFunc: zremrangebyscoreKeyFunc,
 			HandlerFunc:       handleZREMRANGEBYSCORE,
@@ -1784,12 +2013,56 @@ the same score. If the value held at key is not a sorted set, an error is return
 			KeyExtractionFunc: zrangeKeyCount,
 			HandlerFunc:       handleZRANGE,
 		},
+		{
+			Command:    "zrangebyscore",
+			Module:     constants.SortedSetModule,
+			Categories: []string{constants.SortedSetCategory, constants.ReadCategory, constants.SlowCategory},
+			Description: `(ZRANGEBYSCORE key min max [WITHSCORES] [LIMIT offset count]) Returns the members of the sorted set
+with a score between min and max, ordered from the lowest score. Prefix a boundary with ( to exclude it, and use -inf and +inf
+for unbounded ranges. LIMIT skips offset members of the range and returns at most count members, or all of them if count is negative.`,
+			Sync:              false,
+			KeyExtractionFunc: zrangebyscoreKeyFunc,
+			HandlerFunc:       handleZRANGEBYSCORE,
+		},
+		{
+			Command:    "zrevrangebyscore",
+			Module:     constants.SortedSetModule,
+			Categories: []string{constants.SortedSetCategory, constants.ReadCategory, constants.SlowCategory},
+			Description: `(ZREVRANGEBYSCORE key max min [WITHSCORES] [LIMIT offset count]) Returns the members of the sorted set
+with a score between max and min, ordered from the highest score.`,
+			Sync:              false,
+			KeyExtractionFunc: zrangebyscoreKeyFunc,
+			HandlerFunc:       handleZRANGEBYSCORE,
+		},
+		{
+			Command:    "zrangebylex",
+			Module:     constants.SortedSetModule,
+			Categories: []string{constants.SortedSetCategory, constants.ReadCategory, constants.SlowCategory},
+			Description: `(ZRANGEBYLEX key min max [LIMIT offset count]) Returns the members of the sorted set between min and max
+in lexicographical order. Prefix a boundary with [ to include it or ( to exclude it, and use - and + for unbounded ranges.
+All the members must have the same score, otherwise an empty array is returned.`,
+			Sync:              false,
+			KeyExtractionFunc: zrangebylexKeyFunc,
+			HandlerFunc:       handleZRANGEBYLEX,
+		},
+		{
+			Command:    "zrevrangebylex",
+			Module:     constants.SortedSetModule,
+			Categories: []string{constants.SortedSetCategory, constants.ReadCategory, constants.SlowCategory},
+			Description: `(ZREVRANGEBYLEX key max min [LIMIT offset count]) Returns the members of the sorted set between max and min
+in reverse lexicographical order.`,
+			Sync:              false,
+			KeyExtractionFunc: zrangebylexKeyFunc,
+			HandlerFunc:       handleZRANGEBYLEX,
+		},
 		{
 			Command:    "zrangestore",
 			Module:     constants.SortedSetModule,
 			Categories: []string{constants.SortedSetCategory, constants.WriteCategory, constants.SlowCategory},
 			Description: `ZRANGESTORE destination source start stop [BYSCORE | BYLEX] [REV] [LIMIT offset count]
-  [WITHSCORES] Retrieve the range of elements in the sorted set and store it in destination`,
+  [WITHSCORES] Retrieve the range of elements in the sorted set and store it in destination.
+The range is by rank unless BYSCORE or BYLEX is provided. With REV, ranks count from the highest score,
+and the score or lex range takes the maximum as start and the minimum as stop.`,
 			Sync:              true,
 			KeyExtractionFunc: zrangeStoreKeyFunc,
 			HandlerFunc:       handleZRANGESTORE,
@@ -1818,5 +2091,62 @@ The resulting union is stored at the destination key.`,
 			KeyExtractionFunc: zunionstoreKeyFunc,
 			HandlerFunc:       handleZUNIONSTORE,
 		},
+		{
+			Command:    "geoadd",
+			Module:     constants.SortedSetModule,
+			Categories: []string{constants.GeoCategory, constants.WriteCategory, constants.SlowCategory},
+			Description: `(GEOADD key [NX | XX] [CH] longitude latitude member [longitude latitude member...])
+Adds the locations to the sorted set at the key. The score of each member is the geohash of its location.
+"NX" only adds new members. "XX" only updates the locations of existing members.
+"CH" modifies the result to return the number of members added + changed, instead of only the members added.`,
+			Sync:              true,
+			KeyExtractionFunc: geoaddKeyFunc,
+			HandlerFunc:       handleGEOADD,
+		},
+		{
+			Command:    "geodist",
+			Module:     constants.SortedSetModule,
+			Categories: []string{constants.GeoCategory, constants.ReadCategory, constants.SlowCategory},
+			Description: `(GEODIST key member1 member2 [M | KM | FT | MI]) Returns the distance between the two members
+in the unit provided, which defaults to meters. Returns nil if one of the members does not exist.`,
+			Sync:              false,
+			KeyExtractionFunc: geodistKeyFunc,
+			HandlerFunc:       handleGEODIST,
+		},
+		{
+			Command:    "geopos",
+			Module:     constants.SortedSetModule,
+			Categories: []string{constants.GeoCategory, constants.ReadCategory, constants.SlowCategory},
+			Description: `(GEOPOS key [member...]) Returns the longitude and latitude of each member.
+A nil value is returned for each member that does not exist.`,
+			Sync:              false,
+			KeyExtractionFunc: geoposKeyFunc,
+			HandlerFunc:       handleGEOPOS,
+		},
+		{
+			Command:    "geosearch",
+			Module:     constants.SortedSetModule,
+			Categories: []string{constants.GeoCategory, constants.ReadCategory, constants.SlowCategory},
+			Description: `(GEOSEARCH key <FROMMEMBER member | FROMLONLAT longitude latitude>
+<BYRADIUS radius <M | KM | FT | MI> | BYBOX width height <M | KM | FT | MI>> [ASC | DESC] [COUNT count [ANY]]
+[WITHCOORD] [WITHDIST] [WITHHASH]) Returns the members within the circle or the box centered on the member
+or the location. "ASC" and "DESC" sort the members by their distance from the center.
+"COUNT" returns the closest count members, or the first count members found when "ANY" is provided.`,
+			Sync:              false,
+			KeyExtractionFunc: geosearchKeyFunc,
+			HandlerFunc:       handleGEOSEARCH,
+		},
+		{
+			Command:    "geosearchstore",
+			Module:     constants.SortedSetModule,
+			Categories: []string{constants.GeoCategory, constants.WriteCategory, constants.SlowCategory},
+			Description: `(GEOSEARCHSTORE destination source <FROMMEMBER member | FROMLONLAT longitude latitude>
+<BYRADIUS radius <M | KM | FT | MI> | BYBOX width height <M | KM | FT | MI>> [ASC | DESC] [COUNT count [ANY]]
+[STOREDIST]) Works like GEOSEARCH but stores the members in the sorted set at destination.
+"STOREDIST" stores the distances from the center as the scores instead of the geohashes.`,
+			Sync:              true,
+			KeyExtractionFunc: geosearchstoreKeyFunc,
+			HandlerFunc:       handleGEOSEARCHSTORE,
+		},
 	}
 }
@@ -0,0 +1,611 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sorted_set
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/echovault/echovault/internal"
+	"github.com/echovault/echovault/types"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// The geo commands store locations in a sorted set. The score of each member is the 52-bit geohash of its location,
+// which is the same encoding that Redis uses, so the sorted sets can also be read with the sorted set commands.
+const (
+	geoStep        = 26 // The number of bits used for each of the longitude and the latitude.
+	geoLongMin     = -180.0
+	geoLongMax     = 180.0
+	geoLatMin      = -85.05112878 // Latitudes are limited to the range of the Web Mercator projection.
+	geoLatMax      = 85.05112878
+	geoEarthRadius = 6372797.560856 // The earth's radius in meters, as used by Redis to compute distances.
+)
+
+// geoUnits maps each distance unit to its length in meters.
+var geoUnits = map[string]float64{
+	"m":  1,
+	"km": 1000,
+	"ft": 0.3048,
+	"mi": 1609.34,
+}
+
+// interleave spreads the bits of x to the even bits of the result and the bits of y to the odd bits.
+func interleave(x, y uint32) uint64 {
+	spread := func(v uint32) uint64 {
+		n := uint64(v)
+		n = (n | n<<16) & 0x0000FFFF0000FFFF
+		n = (n | n<<8) & 0x00FF00FF00FF00FF
+		n = (n | n<<4) & 0x0F0F0F0F0F0F0F0F
+		n = (n | n<<2) & 0x3333333333333333
+		n = (n | n<<1) & 0x5555555555555555
+		return n
+	}
+	return spread(x) | spread(y)<<1
+}
+
+// deinterleave is the inverse of interleave.
+func deinterleave(n uint64) (uint32, uint32) {
+	squash := func(n uint64) uint32 {
+		n &= 0x5555555555555555
+		n = (n | n>>1) & 0x3333333333333333
+		n = (n | n>>2) & 0x0F0F0F0F0F0F0F0F
+		n = (n | n>>4) & 0x00FF00FF00FF00FF
+		n = (n | n>>8) & 0x0000FFFF0000FFFF
+		n = (n | n>>16) & 0x00000000FFFFFFFF
+		return uint32(n)
+	}
+	return squash(n), squash(n >> 1)
+}
+
+// geohashEncode returns the geohash of the location.
+func geohashEncode(long, lat float64) uint64 {
+	latOffset := (lat - geoLatMin) / (geoLatMax - geoLatMin) * (1 << geoStep)
+	longOffset := (long - geoLongMin) / (geoLongMax - geoLongMin) * (1 << geoStep)
+	return interleave(uint32(latOffset), uint32(longOffset))
+}
+
+// geohashDecode returns the location at the center of the geohash cell.
+func geohashDecode(hash uint64) (float64, float64) {
+	latBits, longBits := deinterleave(hash)
+	latScale := geoLatMax - geoLatMin
+	longScale := geoLongMax - geoLongMin
+
+	latMin := geoLatMin + float64(latBits)/(1<<geoStep)*latScale
+	latMax := geoLatMin + float64(latBits+1)/(1<<geoStep)*latScale
+	longMin := geoLongMin + float64(longBits)/(1<<geoStep)*longScale
+	longMax := geoLongMin + float64(longBits+1)/(1<<geoStep)*longScale
+
+	long := min(max((longMin+longMax)/2, geoLongMin), geoLongMax)
+	lat := min(max((latMin+latMax)/2, geoLatMin), geoLatMax)
+	return long, lat
+}
+
+// geoDistance returns the distance in meters between the two locations, using the haversine formula.
+func geoDistance(long1, lat1, long2, lat2 float64) float64 {
+	lat1r, lat2r := lat1*math.Pi/180, lat2*math.Pi/180
+	u := math.Sin((lat2r - lat1r) / 2)
+	v := math.Sin((long2 - long1) * math.Pi / 180 / 2)
+	return 2 * geoEarthRadius * math.Asin(math.Sqrt(u*u+math.Cos(lat1r)*math.Cos(lat2r)*v*v))
+}
+
+// parseGeoCoordinates parses a longitude and latitude pair, and checks that the location can be encoded.
+func parseGeoCoordinates(longArg, latArg string) (float64, float64, error) {
+	long, err := strconv.ParseFloat(longArg, 64)
+	if err != nil {
+		return 0, 0, errors.New("value is not a valid float")
+	}
+	lat, err := strconv.ParseFloat(latArg, 64)
+	if err != nil {
+		return 0, 0, errors.New("value is not a valid float")
+	}
+	if long < geoLongMin || long > geoLongMax || lat < geoLatMin || lat > geoLatMax {
+		return 0, 0, fmt.Errorf("invalid longitude,latitude pair %s,%s", longArg, latArg)
+	}
+	return long, lat, nil
+}
+
+// parseGeoUnit returns the length in meters of the distance unit.
+func parseGeoUnit(unit string) (float64, error) {
+	meters, ok := geoUnits[strings.ToLower(unit)]
+	if !ok {
+		return 0, errors.New("unsupported unit provided. please use M, KM, FT, MI")
+	}
+	return meters, nil
+}
+
+// formatGeoFloat formats a coordinate or a distance with the given number of decimals, or -1 for the shortest
+// representation.
+func formatGeoFloat(f float64, precision int) string {
+	return strconv.FormatFloat(f, 'f', precision, 64)
+}
+
+// getGeoSet returns the sorted set at the key for reading. Returns nil if the key does not exist.
+// The caller must call the returned function to release the lock.
+func getGeoSet(ctx context.Context, params internal.HandlerFuncParams, key string) (*SortedSet, func(), error) {
+	if !params.KeyExists(ctx, key) {
+		return nil, func() {}, nil
+	}
+	if _, err := params.KeyRLock(ctx, key); err != nil {
+		return nil, nil, err
+	}
+	set, ok := params.GetValue(ctx, key).(*SortedSet)
+	if !ok {
+		params.KeyRUnlock(ctx, key)
+		return nil, nil, fmt.Errorf("value at %s is not a sorted set", key)
+	}
+	return set, func() { params.KeyRUnlock(ctx, key) }, nil
+}
+
+func handleGEOADD(params internal.HandlerFuncParams) ([]byte, error) {
+	keys, err := geoaddKeyFunc(params.Command)
+	if err != nil {
+		return nil, err
+	}
+
+	key := keys.WriteKeys[0]
+
+	// Parse the options, which come before the first longitude.
+	var updatePolicy interface{}
+	changed := false
+	i := 2
+	for ; i < len(params.Command); i++ {
+		option := strings.ToLower(params.Command[i])
+		if option == "nx" || option == "xx" {
+			if updatePolicy != nil && !strings.EqualFold(updatePolicy.(string), option) {
+				return nil, errors.New("XX and NX options at the same time are not compatible")
+			}
+			updatePolicy = option
+			continue
+		}
+		if option == "ch" {
+			changed = true
+			continue
+		}
+		break
+	}
+
+	if len(params.Command[i:]) == 0 || len(params.Command[i:])%3 != 0 {
+		return nil, errors.New("longitude/latitude/member triplets must be provided")
+	}
+
+	var members []MemberParam
+	for ; i < len(params.Command); i += 3 {
+		long, lat, err := parseGeoCoordinates(params.Command[i], params.Command[i+1])
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, MemberParam{
+			Value: Value(params.Command[i+2]),
+			Score: Score(geohashEncode(long, lat)),
+		})
+	}
+
+	if !params.KeyExists(params.Context, key) {
+		// XX only updates existing members, so nothing is added to a key that does not exist.
+		if updatePolicy == "xx" {
+			return []byte(":0\r\n"), nil
+		}
+		if _, err = params.CreateKeyAndLock(params.Context, key); err != nil {
+			return nil, err
+		}
+		defer params.KeyUnlock(params.Context, key)
+		set := NewSortedSet(members)
+		if err = params.SetValue(params.Context, key, set); err != nil {
+			return nil, err
+		}
+		return new(types.ResponseWriter).Integer(set.Cardinality()).Bytes(), nil
+	}
+
+	if _, err = params.KeyLock(params.Context, key); err != nil {
+		return nil, err
+	}
+	defer params.KeyUnlock(params.Context, key)
+
+	set, ok := params.GetValue(params.Context, key).(*SortedSet)
+	if !ok {
+		return nil, fmt.Errorf("value at %s is not a sorted set", key)
+	}
+
+	// Count the added members, and the updated members when CH is provided, before the set is updated.
+	count := 0
+	for _, m := range members {
+		switch {
+		case !set.Contains(m.Value):
+			if updatePolicy != "xx" {
+				count++
+			}
+		case changed && updatePolicy != "nx" && set.Get(m.Value).Score != m.Score:
+			count++
+		}
+	}
+
+	if _, err = set.AddOrUpdate(members, updatePolicy, nil, nil, nil); err != nil {
+		return nil, err
+	}
+	if err = params.SetValue(params.Context, key, set); err != nil {
+		return nil, err
+	}
+
+	return new(types.ResponseWriter).Integer(count).Bytes(), nil
+}
+
+func handleGEODIST(params internal.HandlerFuncParams) ([]byte, error) {
+	keys, err := geodistKeyFunc(params.Command)
+	if err != nil {
+		return nil, err
+	}
+
+	unit := 1.0
+	if len(params.Command) == 5 {
+		if unit, err = parseGeoUnit(params.Command[4]); err != nil {
+			return nil, err
+		}
+	}
+
+	set, unlock, err := getGeoSet(params.Context, params, keys.ReadKeys[0])
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if set == nil || !set.Contains(Value(params.Command[2])) || !set.Contains(Value(params.Command[3])) {
+		return []byte("$-1\r\n"), nil
+	}
+
+	long1, lat1 := geohashDecode(uint64(set.Get(Value(params.Command[2])).Score))
+	long2, lat2 := geohashDecode(uint64(set.Get(Value(params.Command[3])).Score))
+
+	return new(types.ResponseWriter).Bulk(formatGeoFloat(geoDistance(long1, lat1, long2, lat2)/unit, 4)).Bytes(), nil
+}
+
+func handleGEOPOS(params internal.HandlerFuncParams) ([]byte, error) {
+	keys, err := geoposKeyFunc(params.Command)
+	if err != nil {
+		return nil, err
+	}
+
+	set, unlock, err := getGeoSet(params.Context, params, keys.ReadKeys[0])
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	res := new(types.ResponseWriter).Array(len(params.Command[2:]))
+	for _, member := range params.Command[2:] {
+		if set == nil || !set.Contains(Value(member)) {
+			res.NullArray()
+			continue
+		}
+		long, lat := geohashDecode(uint64(set.Get(Value(member)).Score))
+		res.Array(2).Bulk(formatGeoFloat(long, -1)).Bulk(formatGeoFloat(lat, -1))
+	}
+
+	return res.Bytes(), nil
+}
+
+// geoSearchOptions are the options of GEOSEARCH and GEOSEARCHSTORE.
+type geoSearchOptions struct {
+	fromMember string
+	fromLonLat bool
+	long       float64
+	lat        float64
+	byRadius   bool
+	radius     float64 // The radius in meters.
+	byBox      bool
+	width      float64 // The width of the box in meters.
+	height     float64 // The height of the box in meters.
+	unit       float64 // The length of the unit in meters. Distances are returned in this unit.
+	order      string  // "asc", "desc" or "" for the order of the geohashes.
+	count      int     // The maximum number of results. 0 returns all the results.
+	any        bool    // Return the first count results found, instead of the count closest results.
+	withCoord  bool
+	withDist   bool
+	withHash   bool
+	storeDist  bool
+}
+
+// parseGeoSearchOptions parses the arguments of GEOSEARCH after the key, or of GEOSEARCHSTORE after the source.
+// STOREDIST is only accepted when store is true, and the WITH options are only accepted when store is false.
+func parseGeoSearchOptions(args []string, store bool) (geoSearchOptions, error) {
+	options := geoSearchOptions{}
+	fromMember := false
+
+	for i := 0; i < len(args); i++ {
+		switch strings.ToLower(args[i]) {
+		default:
+			return options, errors.New("syntax error")
+		case "frommember":
+			if i+1 >= len(args) {
+				return options, errors.New("syntax error")
+			}
+			fromMember = true
+			options.fromMember = args[i+1]
+			i++
+		case "fromlonlat":
+			if i+2 >= len(args) {
+				return options, errors.New("syntax error")
+			}
+			long, lat, err := parseGeoCoordinates(args[i+1], args[i+2])
+			if err != nil {
+				return options, err
+			}
+			options.fromLonLat, options.long, options.lat = true, long, lat
+			i += 2
+		case "byradius":
+			if i+2 >= len(args) {
+				return options, errors.New("syntax error")
+			}
+			radius, err := strconv.ParseFloat(args[i+1], 64)
+			if err != nil || radius < 0 {
+				return options, errors.New("radius must be a non-negative number")
+			}
+			if options.unit, err = parseGeoUnit(args[i+2]); err != nil {
+				return options, err
+			}
+			options.byRadius, options.radius = true, radius*options.unit
+			i += 2
+		case "bybox":
+			if i+3 >= len(args) {
+				return options, errors.New("syntax error")
+			}
+			width, err := strconv.ParseFloat(args[i+1], 64)
+			if err != nil || width < 0 {
+				return options, errors.New("width must be a non-negative number")
+			}
+			height, err := strconv.ParseFloat(args[i+2], 64)
+			if err != nil || height < 0 {
+				return options, errors.New("height must be a non-negative number")
+			}
+			if options.unit, err = parseGeoUnit(args[i+3]); err != nil {
+				return options, err
+			}
+			options.byBox, options.width, options.height = true, width*options.unit, height*options.unit
+			i += 3
+		case "asc", "desc":
+			options.order = strings.ToLower(args[i])
+		case "count":
+			if i+1 >= len(args) {
+				return options, errors.New("syntax error")
+			}
+			count, err := strconv.Atoi(args[i+1])
+			if err != nil || count <= 0 {
+				return options, errors.New("COUNT must be > 0")
+			}
+			options.count = count
+			i++
+			if i+1 < len(args) && strings.EqualFold(args[i+1], "any") {
+				options.any = true
+				i++
+			}
+		case "any":
+			return options, errors.New("the ANY argument requires COUNT argument")
+		case "withcoord", "withdist", "withhash":
+			if store {
+				return options, errors.New("syntax error")
+			}
+			options.withCoord = options.withCoord || strings.EqualFold(args[i], "withcoord")
+			options.withDist = options.withDist || strings.EqualFold(args[i], "withdist")
+			options.withHash = options.withHash || strings.EqualFold(args[i], "withhash")
+		case "storedist":
+			if !store {
+				return options, errors.New("syntax error")
+			}
+			options.storeDist = true
+		}
+	}
+
+	if fromMember == options.fromLonLat {
+		return options, errors.New("exactly one of FROMMEMBER or FROMLONLAT must be provided")
+	}
+	if options.byRadius == options.byBox {
+		return options, errors.New("exactly one of BYRADIUS or BYBOX must be provided")
+	}
+
+	return options, nil
+}
+
+// geoSearchResult is a member of the sorted set that matches a GEOSEARCH.
+type geoSearchResult struct {
+	member   MemberParam
+	long     float64
+	lat      float64
+	distance float64 // The distance from the center in meters.
+}
+
+// geoSearch returns the members of the set that are within the area of the search.
+func geoSearch(set *SortedSet, options geoSearchOptions) ([]geoSearchResult, error) {
+	if !options.fromLonLat {
+		if !set.Contains(Value(options.fromMember)) {
+			return nil, errors.New("could not decode requested zset member")
+		}
+		options.long, options.lat = geohashDecode(uint64(set.Get(Value(options.fromMember)).Score))
+	}
+
+	// Search the members in the order of their geohashes so that the results are deterministic.
+	members := set.GetAll()
+	slices.SortFunc(members, func(a, b MemberParam) int {
+		if a.Score == b.Score {
+			return cmp.Compare(a.Value, b.Value)
+		}
+		return cmp.Compare(a.Score, b.Score)
+	})
+
+	var results []geoSearchResult
+	for _, m := range members {
+		long, lat := geohashDecode(uint64(m.Score))
+		distance := geoDistance(options.long, options.lat, long, lat)
+
+		if options.byRadius && distance > options.radius {
+			continue
+		}
+		if options.byBox {
+			// The distance along the meridian and the distance along the parallel of the member.
+			if geoEarthRadius*math.Abs(lat-options.lat)*math.Pi/180 > options.height/2 ||
+				geoDistance(options.long, lat, long, lat) > options.width/2 {
+				continue
+			}
+		}
+
+		results = append(results, geoSearchResult{member: m, long: long, lat: lat, distance: distance})
+		if options.any && len(results) == options.count {
+			break
+		}
+	}
+
+	// Without ANY, COUNT returns the closest results.
+	order := options.order
+	if order == "" && options.count > 0 && !options.any {
+		order = "asc"
+	}
+	switch order {
+	case "asc":
+		slices.SortStableFunc(results, func(a, b geoSearchResult) int {
+			return cmp.Compare(a.distance, b.distance)
+		})
+	case "desc":
+		slices.SortStableFunc(results, func(a, b geoSearchResult) int {
+			return cmp.Compare(b.distance, a.distance)
+		})
+	}
+
+	if options.count > 0 && len(results) > options.count {
+		results = results[:options.count]
+	}
+
+	return results, nil
+}
+
+func handleGEOSEARCH(params internal.HandlerFuncParams) ([]byte, error) {
+	keys, err := geosearchKeyFunc(params.Command)
+	if err != nil {
+		return nil, err
+	}
+
+	options, err := parseGeoSearchOptions(params.Command[2:], false)
+	if err != nil {
+		return nil, err
+	}
+
+	set, unlock, err := getGeoSet(params.Context, params, keys.ReadKeys[0])
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if set == nil {
+		return []byte("*0\r\n"), nil
+	}
+
+	results, err := geoSearch(set, options)
+	if err != nil {
+		return nil, err
+	}
+
+	res := new(types.ResponseWriter).Array(len(results))
+	for _, result := range results {
+		if !options.withDist && !options.withHash && !options.withCoord {
+			res.Bulk(string(result.member.Value))
+			continue
+		}
+
+		length := 1
+		for _, with := range []bool{options.withDist, options.withHash, options.withCoord} {
+			if with {
+				length++
+			}
+		}
+		res.Array(length).Bulk(string(result.member.Value))
+		if options.withDist {
+			res.Bulk(formatGeoFloat(result.distance/options.unit, 4))
+		}
+		if options.withHash {
+			res.Integer(int(result.member.Score))
+		}
+		if options.withCoord {
+			res.Array(2).Bulk(formatGeoFloat(result.long, -1)).Bulk(formatGeoFloat(result.lat, -1))
+		}
+	}
+
+	return res.Bytes(), nil
+}
+
+func handleGEOSEARCHSTORE(params internal.HandlerFuncParams) ([]byte, error) {
+	keys, err := geosearchstoreKeyFunc(params.Command)
+	if err != nil {
+		return nil, err
+	}
+
+	destination := keys.WriteKeys[0]
+
+	options, err := parseGeoSearchOptions(params.Command[3:], true)
+	if err != nil {
+		return nil, err
+	}
+
+	// Search the source and release its lock before writing, as the destination can be the same key as the source.
+	members, err := func() ([]MemberParam, error) {
+		set, unlock, err := getGeoSet(params.Context, params, keys.ReadKeys[0])
+		if err != nil {
+			return nil, err
+		}
+		defer unlock()
+		if set == nil {
+			return nil, nil
+		}
+		results, err := geoSearch(set, options)
+		if err != nil {
+			return nil, err
+		}
+		members := make([]MemberParam, len(results))
+		for i, result := range results {
+			members[i] = result.member
+			if options.storeDist {
+				members[i].Score = Score(result.distance / options.unit)
+			}
+		}
+		return members, nil
+	}()
+	if err != nil {
+		return nil, err
+	}
+
+	// An empty result removes the destination.
+	if len(members) == 0 {
+		return internal.DeleteEmptyDestination(params, destination)
+	}
+
+	if params.KeyExists(params.Context, destination) {
+		if _, err = params.KeyLock(params.Context, destination); err != nil {
+			return nil, err
+		}
+	} else {
+		if _, err = params.CreateKeyAndLock(params.Context, destination); err != nil {
+			return nil, err
+		}
+	}
+	defer params.KeyUnlock(params.Context, destination)
+
+	set := NewSortedSet(members)
+	if err = params.SetValue(params.Context, destination, set); err != nil {
+		return nil, err
+	}
+
+	return new(types.ResponseWriter).Integer(set.Cardinality()).Bytes(), nil
+}
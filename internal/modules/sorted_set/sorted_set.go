@@ -21,7 +21,9 @@ import (
 	"math"
 	"math/rand"
 	"slices"
+	"strconv"
 	"strings"
+	"sync/atomic"
 )
 
 type Value string
@@ -41,29 +43,127 @@ type MemberParam struct {
 	Score Score
 }
 
+// Sorted sets use a compact encoding while they hold at most maxListpackEntries members,
+// and none of the members is longer than maxListpackValue bytes.
+// The limits are configured with zset-max-listpack-entries and zset-max-listpack-value.
+var (
+	maxListpackEntries atomic.Int64
+	maxListpackValue   atomic.Int64
+)
+
+func init() {
+	SetListpackLimits(128, 64)
+}
+
+// SetListpackLimits sets the limits of the compact encoding. They apply to all the sorted sets in the process.
+// Sorted sets that already use the full encoding keep it, and compact sorted sets are converted the next time they grow.
+func SetListpackLimits(entries int, value int) {
+	maxListpackEntries.Store(int64(entries))
+	maxListpackValue.Store(int64(value))
+}
+
+// SortedSet is a set of members with scores. Small sorted sets hold their members in a slice (the "listpack" encoding),
+// which uses a fraction of the memory of a map. A sorted set is converted to a map (the "skiplist" encoding)
+// once it exceeds the listpack limits, and is never converted back.
 type SortedSet struct {
-	members map[Value]MemberObject
+	members  map[Value]MemberObject // The members of a sorted set with the skiplist encoding. Nil for the listpack encoding.
+	listpack []MemberParam          // The members of a sorted set with the listpack encoding, in no particular order.
 }
 
 func NewSortedSet(members []MemberParam) *SortedSet {
-	s := &SortedSet{
-		members: make(map[Value]MemberObject),
-	}
+	s := &SortedSet{}
 	for _, m := range members {
-		s.members[m.Value] = MemberObject{
-			Value:  m.Value,
-			Score:  m.Score,
-			Exists: true,
-		}
+		s.put(m.Value, m.Score)
 	}
 	return s
 }
 
+// put adds the member to the sorted set, or updates its score if it's already a member.
+// A listpack sorted set is converted to a map if the member does not fit in the listpack.
+func (set *SortedSet) put(v Value, score Score) {
+	if set.members == nil {
+		if i := slices.IndexFunc(set.listpack, func(m MemberParam) bool { return m.Value == v }); i != -1 {
+			set.listpack[i].Score = score
+			return
+		}
+		if int64(len(set.listpack)) < maxListpackEntries.Load() && int64(len(v)) <= maxListpackValue.Load() {
+			set.listpack = append(set.listpack, MemberParam{Value: v, Score: score})
+			return
+		}
+		set.members = make(map[Value]MemberObject, len(set.listpack)+1)
+		for _, m := range set.listpack {
+			set.members[m.Value] = MemberObject{Value: m.Value, Score: m.Score, Exists: true}
+		}
+		set.listpack = nil
+	}
+	set.members[v] = MemberObject{Value: v, Score: score, Exists: true}
+}
+
+// TypeName returns the name of the sorted set type as reported by the TYPE command.
+func (set *SortedSet) TypeName() string {
+	return "zset"
+}
+
+// exportedMember is the exported form of a sorted set member.
+type exportedMember struct {
+	Member string `json:"member"`
+	Score  string `json:"score"`
+}
+
+// Export returns the members of the sorted set ordered by score, and by member for equal scores.
+// Scores are formatted as strings so that infinite scores can be exported.
+func (set *SortedSet) Export() interface{} {
+	members := set.GetAll()
+	slices.SortFunc(members, func(a, b MemberParam) int {
+		if c := cmp.Compare(a.Score, b.Score); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Value, b.Value)
+	})
+	res := make([]exportedMember, len(members))
+	for i, m := range members {
+		res[i] = exportedMember{Member: string(m.Value), Score: strconv.FormatFloat(float64(m.Score), 'f', -1, 64)}
+	}
+	return res
+}
+
+// Encoding returns the name of the encoding of the sorted set as reported by OBJECT ENCODING.
+func (set *SortedSet) Encoding() string {
+	if set.members == nil {
+		return "listpack"
+	}
+	return "skiplist"
+}
+
+// Clone returns a deep copy of the sorted set.
+func (set *SortedSet) Clone() interface{} {
+	if set.members == nil {
+		return &SortedSet{
+			listpack: slices.Clone(set.listpack),
+		}
+	}
+	members := make(map[Value]MemberObject, len(set.members))
+	for v, m := range set.members {
+		members[v] = m
+	}
+	return &SortedSet{
+		members: members,
+	}
+}
+
 func (set *SortedSet) Contains(m Value) bool {
-	return set.members[m].Exists
+	return set.Get(m).Exists
 }
 
 func (set *SortedSet) Get(v Value) MemberObject {
+	if set.members == nil {
+		for _, m := range set.listpack {
+			if m.Value == v {
+				return MemberObject{Value: m.Value, Score: m.Score, Exists: true}
+			}
+		}
+		return MemberObject{}
+	}
 	return set.members[v]
 }
 
@@ -104,6 +204,10 @@ func (set *SortedSet) GetRandom(count int) []MemberParam {
 }
 
 func (set *SortedSet) GetAll() []MemberParam {
+	if set.members == nil {
+		// Return a copy so that the caller can reorder the members without reordering the listpack.
+		return slices.Clone(set.listpack)
+	}
 	var res []MemberParam
 	for k, v := range set.members {
 		res = append(res, MemberParam{
@@ -115,7 +219,10 @@ func (set *SortedSet) GetAll() []MemberParam {
 }
 
 func (set *SortedSet) Cardinality() int {
-	return len(set.GetAll())
+	if set.members == nil {
+		return len(set.listpack)
+	}
+	return len(set.members)
 }
 
 func (set *SortedSet) AddOrUpdate(
@@ -150,23 +257,15 @@ func (set *SortedSet) AddOrUpdate(
 		for _, m := range members {
 			if !set.Contains(m.Value) {
 				// If the member is not contained, add it with the increment as its Score
-				set.members[m.Value] = MemberObject{
-					Value:  m.Value,
-					Score:  m.Score,
-					Exists: true,
-				}
+				set.put(m.Value, m.Score)
 				// Always add count because this is the addition of a new element
 				count += 1
 				return count, err
 			}
-			if slices.Contains([]Score{Score(math.Inf(-1)), Score(math.Inf(1))}, set.members[m.Value].Score) {
+			if slices.Contains([]Score{Score(math.Inf(-1)), Score(math.Inf(1))}, set.Get(m.Value).Score) {
 				return count, errors.New("cannot increment -inf or +inf")
 			}
-			set.members[m.Value] = MemberObject{
-				Value:  m.Value,
-				Score:  set.members[m.Value].Score + m.Score,
-				Exists: true,
-			}
+			set.put(m.Value, set.Get(m.Value).Score+m.Score)
 			if strings.EqualFold(ch, "ch") {
 				count += 1
 			}
@@ -178,11 +277,7 @@ func (set *SortedSet) AddOrUpdate(
 		if strings.EqualFold(policy, "xx") {
 			// Only update existing elements, do not add new elements
 			if set.Contains(m.Value) {
-				set.members[m.Value] = MemberObject{
-					Value:  m.Value,
-					Score:  compareScores(set.members[m.Value].Score, m.Score, comp),
-					Exists: true,
-				}
+				set.put(m.Value, compareScores(set.Get(m.Value).Score, m.Score, comp))
 				if strings.EqualFold(ch, "ch") {
 					count += 1
 				}
@@ -192,29 +287,33 @@ func (set *SortedSet) AddOrUpdate(
 		if strings.EqualFold(policy, "nx") {
 			// Only add new elements, do not update existing elements
 			if !set.Contains(m.Value) {
-				set.members[m.Value] = MemberObject{
-					Value:  m.Value,
-					Score:  m.Score,
-					Exists: true,
-				}
+				set.put(m.Value, m.Score)
 				count += 1
 			}
 			continue
 		}
 		// Policy not specified, just Set the elements and scores
-		if set.members[m.Value].Score != m.Score || !set.members[m.Value].Exists {
+		if set.Get(m.Value).Score != m.Score || !set.Get(m.Value).Exists {
 			count += 1
 		}
-		set.members[m.Value] = MemberObject{
-			Value:  m.Value,
-			Score:  compareScores(set.members[m.Value].Score, m.Score, comp),
-			Exists: true,
-		}
+		set.put(m.Value, compareScores(set.Get(m.Value).Score, m.Score, comp))
 	}
 	return count, nil
 }
 
 func (set *SortedSet) Remove(v Value) bool {
+	if set.members == nil {
+		i := slices.IndexFunc(set.listpack, func(m MemberParam) bool { return m.Value == v })
+		if i == -1 {
+			return false
+		}
+		// The order of the listpack is not significant, so the last member takes the place of the removed one.
+		last := len(set.listpack) - 1
+		set.listpack[i] = set.listpack[last]
+		set.listpack[last] = MemberParam{}
+		set.listpack = set.listpack[:last]
+		return true
+	}
 	if set.Contains(v) {
 		delete(set.members, v)
 		return true
@@ -223,7 +322,17 @@ func (set *SortedSet) Remove(v Value) bool {
 }
 
 func (set *SortedSet) Pop(count int, policy string) (*SortedSet, error) {
-	popped := NewSortedSet([]MemberParam{})
+	members, err := set.PopMembers(count, policy)
+	if err != nil {
+		return nil, err
+	}
+	return NewSortedSet(members), nil
+}
+
+// PopMembers removes up to count members with the lowest (MIN) or highest (MAX) scores.
+// The popped members are returned in the order that they were popped.
+// Members with the same score are popped in lexicographical order for MIN and reverse lexicographical order for MAX.
+func (set *SortedSet) PopMembers(count int, policy string) ([]MemberParam, error) {
 	if !slices.Contains([]string{"min", "max"}, strings.ToLower(policy)) {
 		return nil, errors.New("policy must be MIN or MAX")
 	}
@@ -231,30 +340,30 @@ func (set *SortedSet) Pop(count int, policy string) (*SortedSet, error) {
 		return nil, errors.New("count must be a positive integer")
 	}
 	if count == 0 {
-		return popped, nil
+		return []MemberParam{}, nil
 	}
 
 	members := set.GetAll()
 
 	slices.SortFunc(members, func(a, b MemberParam) int {
+		c := cmp.Compare(a.Score, b.Score)
+		if c == 0 {
+			c = internal.CompareLex(string(a.Value), string(b.Value))
+		}
 		if strings.EqualFold(policy, "min") {
-			return cmp.Compare(a.Score, b.Score)
+			return c
 		}
-		return cmp.Compare(b.Score, a.Score)
+		return -c
 	})
 
-	for i := 0; i < count; i++ {
-		if i >= len(members) {
-			break
-		}
-		set.Remove(members[i].Value)
-		_, err := popped.AddOrUpdate([]MemberParam{members[i]}, nil, nil, nil, nil)
-		if err != nil {
-			return nil, err
-		}
+	if count < len(members) {
+		members = members[:count]
+	}
+	for _, m := range members {
+		set.Remove(m.Value)
 	}
 
-	return popped, nil
+	return members, nil
 }
 
 func (set *SortedSet) Subtract(others []*SortedSet) *SortedSet {
@@ -282,7 +391,7 @@ func (set *SortedSet) Equals(other *SortedSet) bool {
 	if set.Cardinality() == 0 {
 		return true
 	}
-	for _, member := range set.members {
+	for _, member := range set.GetAll() {
 		if !other.Contains(member.Value) {
 			return false
 		}
@@ -16,6 +16,11 @@ package sorted_set
 
 import (
 	"errors"
+	"fmt"
+	"github.com/echovault/echovault/internal"
+	"github.com/echovault/echovault/internal/constants"
+	"github.com/echovault/echovault/types"
+	"math"
 	"slices"
 	"strconv"
 	"strings"
@@ -167,3 +172,233 @@ func compareScores(old Score, new Score, comp string) Score {
 		return old
 	}
 }
+
+// scoreBound is a score range boundary. A boundary prefixed with "(" excludes the score itself.
+type scoreBound struct {
+	score     Score
+	exclusive bool
+}
+
+// parseScoreBound parses a score boundary such as "1.5", "(1.5", "-inf" or "+inf".
+func parseScoreBound(s string) (scoreBound, bool) {
+	bound := scoreBound{}
+	if strings.HasPrefix(s, "(") {
+		bound.exclusive = true
+		s = s[1:]
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(f) {
+		return bound, false
+	}
+	bound.score = Score(f)
+	return bound, true
+}
+
+// inScoreRange returns true if the score lies between the minimum and maximum boundaries.
+func inScoreRange(score Score, minimum, maximum scoreBound) bool {
+	if score < minimum.score || (minimum.exclusive && score == minimum.score) {
+		return false
+	}
+	if score > maximum.score || (maximum.exclusive && score == maximum.score) {
+		return false
+	}
+	return true
+}
+
+// lexBound is a lexicographical range boundary.
+// "-" and "+" are the unbounded minimum and maximum. A boundary prefixed with "(" excludes the value itself.
+// A boundary prefixed with "[", or without a prefix, includes the value.
+type lexBound struct {
+	value     string
+	exclusive bool
+	infinity  int // -1 for "-", 1 for "+", 0 otherwise.
+}
+
+// parseLexBound parses a lexicographical boundary such as "[a", "(a", "a", "-" or "+".
+func parseLexBound(s string) lexBound {
+	switch {
+	case s == "-":
+		return lexBound{infinity: -1}
+	case s == "+":
+		return lexBound{infinity: 1}
+	case strings.HasPrefix(s, "("):
+		return lexBound{value: s[1:], exclusive: true}
+	case strings.HasPrefix(s, "["):
+		return lexBound{value: s[1:]}
+	default:
+		return lexBound{value: s}
+	}
+}
+
+// inLexRange returns true if the value lies between the minimum and maximum boundaries.
+func inLexRange(value string, minimum, maximum lexBound) bool {
+	if minimum.infinity == 1 || maximum.infinity == -1 {
+		return false
+	}
+	if minimum.infinity == 0 {
+		c := internal.CompareLex(value, minimum.value)
+		if c < 0 || (minimum.exclusive && c == 0) {
+			return false
+		}
+	}
+	if maximum.infinity == 0 {
+		c := internal.CompareLex(value, maximum.value)
+		if c > 0 || (maximum.exclusive && c == 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// commitRemoval is called after members are removed from the sorted set at the key, or after the set at the key
+// is replaced with a new set.
+// The modified set is stored at the key, or the key is deleted if no members are left.
+// The key must be locked prior to calling this function, and is unlocked by it.
+func commitRemoval(params internal.HandlerFuncParams, key string, set *SortedSet) error {
+	if set.Cardinality() > 0 {
+		defer params.KeyUnlock(params.Context, key)
+		return params.SetValue(params.Context, key, set)
+	}
+	// Delete the key without releasing the lock first, so that no other command sees the empty set.
+	params.DeleteLockedKey(params.Context, key)
+	return nil
+}
+
+// writeMembers writes the members as an array reply. Each member is written as a nested array holding the member,
+// followed by its score as a simple string if withscores is true.
+func writeMembers(res *types.ResponseWriter, members []MemberParam, withscores bool) *types.ResponseWriter {
+	res.Array(len(members))
+	for _, m := range members {
+		if withscores {
+			res.Array(2).Bulk(string(m.Value)).Simple(strconv.FormatFloat(float64(m.Score), 'f', -1, 64))
+		} else {
+			res.Array(1).Bulk(string(m.Value))
+		}
+	}
+	return res
+}
+
+// parseRangeByOptions parses the [WITHSCORES] [LIMIT offset count] options of ZRANGEBYSCORE and ZRANGEBYLEX.
+// WITHSCORES is only accepted if withscoresAllowed is true. The count is -1 if LIMIT is not provided.
+func parseRangeByOptions(args []string, withscoresAllowed bool) (withscores bool, offset int, count int, err error) {
+	count = -1
+	for i := 0; i < len(args); i++ {
+		switch {
+		case strings.EqualFold(args[i], "withscores") && withscoresAllowed:
+			withscores = true
+		case strings.EqualFold(args[i], "limit"):
+			if i+2 >= len(args) {
+				return false, 0, 0, errors.New("limit should contain offset and count as integers")
+			}
+			if offset, err = strconv.Atoi(args[i+1]); err != nil {
+				return false, 0, 0, errors.New("limit offset must be integer")
+			}
+			if count, err = strconv.Atoi(args[i+2]); err != nil {
+				return false, 0, 0, errors.New("limit count must be integer")
+			}
+			i += 2
+		default:
+			return false, 0, 0, fmt.Errorf("unknown option %s", args[i])
+		}
+	}
+	return withscores, offset, count, nil
+}
+
+// rangeStoreOptions holds the options of ZRANGESTORE. The range is by rank if neither byScore nor byLex is set.
+type rangeStoreOptions struct {
+	byScore bool
+	byLex   bool
+	reverse bool
+	offset  int
+	count   int // -1 if LIMIT is not provided.
+}
+
+// parseRangeStoreOptions parses the [BYSCORE | BYLEX] [REV] [LIMIT offset count] [WITHSCORES] options of ZRANGESTORE.
+// WITHSCORES is accepted for compatibility with ZRANGE, but has no effect as the scores are always stored.
+func parseRangeStoreOptions(args []string) (rangeStoreOptions, error) {
+	options := rangeStoreOptions{count: -1}
+	limit := false
+	for i := 0; i < len(args); i++ {
+		switch {
+		case strings.EqualFold(args[i], "byscore"):
+			options.byScore = true
+		case strings.EqualFold(args[i], "bylex"):
+			options.byLex = true
+		case strings.EqualFold(args[i], "rev"):
+			options.reverse = true
+		case strings.EqualFold(args[i], "withscores"):
+		case strings.EqualFold(args[i], "limit"):
+			if i+2 >= len(args) {
+				return options, errors.New("limit should contain offset and count as integers")
+			}
+			offset, err := strconv.Atoi(args[i+1])
+			if err != nil {
+				return options, errors.New("limit offset must be integer")
+			}
+			if offset < 0 {
+				return options, errors.New("limit offset must be >= 0")
+			}
+			count, err := strconv.Atoi(args[i+2])
+			if err != nil {
+				return options, errors.New("limit count must be integer")
+			}
+			options.offset, options.count, limit = offset, count, true
+			i += 2
+		default:
+			return options, fmt.Errorf("unknown option %s", args[i])
+		}
+	}
+	if options.byScore && options.byLex {
+		return options, errors.New("only one of byscore or bylex can be provided")
+	}
+	if limit && !options.byScore && !options.byLex {
+		return options, errors.New("limit is only supported with byscore or bylex")
+	}
+	return options, nil
+}
+
+// limitMembers skips offset members and returns at most count of the remaining members.
+// All the remaining members are returned if count is negative, and none if offset is negative.
+func limitMembers(members []MemberParam, offset int, count int) []MemberParam {
+	if offset < 0 || offset >= len(members) {
+		return nil
+	}
+	members = members[offset:]
+	if count >= 0 && count < len(members) {
+		members = members[:count]
+	}
+	return members
+}
+
+// parseZMPOPOptions parses the [MIN | MAX] [COUNT count] arguments of ZMPOP. The policy defaults to MIN.
+func parseZMPOPOptions(args []string) (int, string, error) {
+	policy := "min"
+	if len(args) > 0 && slices.Contains([]string{"min", "max"}, strings.ToLower(args[0])) {
+		policy = strings.ToLower(args[0])
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		return 1, policy, nil
+	}
+	return parseBZMPOPOptions(append([]string{policy}, args...))
+}
+
+// parseBZMPOPOptions parses the <MIN | MAX> [COUNT count] arguments of BZMPOP.
+func parseBZMPOPOptions(args []string) (int, string, error) {
+	policy := strings.ToLower(args[0])
+	switch len(args) {
+	case 1:
+		return 1, policy, nil
+	case 3:
+		if !strings.EqualFold(args[1], "count") {
+			return 0, "", fmt.Errorf("unknown option %s", args[1])
+		}
+		count, err := strconv.Atoi(args[2])
+		if err != nil || count <= 0 {
+			return 0, "", errors.New("count must be a positive integer")
+		}
+		return count, policy, nil
+	default:
+		return 0, "", errors.New(constants.WrongArgsResponse)
+	}
+}
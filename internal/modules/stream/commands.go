@@ -0,0 +1,1047 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/echovault/echovault/internal"
+	"github.com/echovault/echovault/internal/constants"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// xaddID is the ID argument of XADD. Either the whole ID or only the sequence number can be auto-generated.
+type xaddID struct {
+	id      ID
+	autoMs  bool
+	autoSeq bool
+}
+
+func parseXAddID(s string) (xaddID, error) {
+	if s == "*" {
+		return xaddID{autoMs: true, autoSeq: true}, nil
+	}
+	if ms, ok := strings.CutSuffix(s, "-*"); ok && !strings.Contains(ms, "-") {
+		id, err := ParseID(ms, 0)
+		if err != nil {
+			return xaddID{}, err
+		}
+		return xaddID{id: id, autoSeq: true}, nil
+	}
+	id, err := ParseID(s, 0)
+	if err != nil {
+		return xaddID{}, err
+	}
+	if id.Compare(minID) == 0 {
+		return xaddID{}, errors.New("the ID specified in XADD must be greater than 0-0")
+	}
+	return xaddID{id: id}, nil
+}
+
+func handleXADD(params internal.HandlerFuncParams) ([]byte, error) {
+	keys, err := xaddKeyFunc(params.Command)
+	if err != nil {
+		return nil, err
+	}
+
+	key := keys.WriteKeys[0]
+
+	// Parse the options that precede the ID.
+	noMkStream := false
+	maxLen := -1
+	i := 2
+options:
+	for ; i < len(params.Command); i++ {
+		switch strings.ToUpper(params.Command[i]) {
+		default:
+			break options
+		case "NOMKSTREAM":
+			noMkStream = true
+		case "MAXLEN":
+			// Trimming is always exact, so the "~" and "=" modifiers are accepted but ignored.
+			if i+1 < len(params.Command) && slices.Contains([]string{"~", "="}, params.Command[i+1]) {
+				i++
+			}
+			if i+1 >= len(params.Command) {
+				return nil, errors.New(constants.WrongArgsResponse)
+			}
+			i++
+			if maxLen, err = strconv.Atoi(params.Command[i]); err != nil || maxLen < 0 {
+				return nil, errors.New("MAXLEN must be a non-negative integer")
+			}
+		}
+	}
+
+	// The ID must be followed by at least one field-value pair.
+	args := params.Command[i:]
+	if len(args) < 3 || len(args)%2 != 1 {
+		return nil, errors.New(constants.WrongArgsResponse)
+	}
+
+	// Validate the ID before creating the stream so that an invalid ID does not create an empty stream.
+	xid, err := parseXAddID(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	if !params.KeyExists(params.Context, key) {
+		if noMkStream {
+			return []byte("$-1\r\n"), nil
+		}
+		if _, err = params.CreateKeyAndLock(params.Context, key); err != nil {
+			return nil, err
+		}
+		if err = params.SetValue(params.Context, key, NewStream()); err != nil {
+			params.KeyUnlock(params.Context, key)
+			return nil, err
+		}
+	} else {
+		if _, err = params.KeyLock(params.Context, key); err != nil {
+			return nil, err
+		}
+	}
+	defer params.KeyUnlock(params.Context, key)
+
+	stream, ok := params.GetValue(params.Context, key).(*Stream)
+	if !ok {
+		return nil, fmt.Errorf("value at key %s is not a stream", key)
+	}
+
+	id := xid.id
+	switch {
+	case xid.autoMs:
+		id, err = stream.NextID(params.GetClock().Now())
+	case xid.autoSeq:
+		id, err = stream.NextIDWithMs(xid.id.Ms)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err = stream.Add(id, slices.Clone(args[1:])); err != nil {
+		return nil, err
+	}
+	if maxLen >= 0 {
+		stream.Trim(maxLen)
+	}
+
+	// Set the value again so that the clients blocked on the stream are notified of the new entry.
+	if err = params.SetValue(params.Context, key, stream); err != nil {
+		return nil, err
+	}
+
+	res := id.String()
+	return []byte(fmt.Sprintf("$%d\r\n%s\r\n", len(res), res)), nil
+}
+
+func handleXLEN(params internal.HandlerFuncParams) ([]byte, error) {
+	keys, err := xlenKeyFunc(params.Command)
+	if err != nil {
+		return nil, err
+	}
+
+	key := keys.ReadKeys[0]
+
+	if !params.KeyExists(params.Context, key) {
+		return []byte(":0\r\n"), nil
+	}
+
+	if _, err = params.KeyRLock(params.Context, key); err != nil {
+		return nil, err
+	}
+	defer params.KeyRUnlock(params.Context, key)
+
+	stream, ok := params.GetValue(params.Context, key).(*Stream)
+	if !ok {
+		return nil, fmt.Errorf("value at key %s is not a stream", key)
+	}
+
+	return []byte(fmt.Sprintf(":%d\r\n", stream.Len())), nil
+}
+
+// parseRangeID parses a range boundary of XRANGE and XREVRANGE.
+// "-" and "+" are the smallest and largest possible IDs. A boundary prefixed with "(" is exclusive.
+// When the sequence number is omitted, the boundary covers all the entries in the millisecond.
+// Returns false if the exclusive boundary leaves no IDs to match.
+func parseRangeID(s string, isStart bool) (ID, bool, error) {
+	switch s {
+	case "-":
+		return minID, true, nil
+	case "+":
+		return maxID, true, nil
+	}
+
+	var defaultSeq uint64 = 0
+	if !isStart {
+		defaultSeq = math.MaxUint64
+	}
+
+	boundary, exclusive := strings.CutPrefix(s, "(")
+	id, err := ParseID(boundary, defaultSeq)
+	if err != nil || !exclusive {
+		return id, true, err
+	}
+
+	if isStart {
+		id, ok := id.next()
+		return id, ok, nil
+	}
+	id, ok := id.prev()
+	return id, ok, nil
+}
+
+// encodeEntries encodes the entries as an array of [ID, [field, value, ...]] arrays.
+// The fields of an entry that has been removed from the stream are encoded as a null array.
+func encodeEntries(entries []Entry) string {
+	res := fmt.Sprintf("*%d\r\n", len(entries))
+	for _, entry := range entries {
+		id := entry.ID.String()
+		if entry.Fields == nil {
+			res += fmt.Sprintf("*2\r\n$%d\r\n%s\r\n*-1\r\n", len(id), id)
+			continue
+		}
+		res += fmt.Sprintf("*2\r\n$%d\r\n%s\r\n*%d\r\n", len(id), id, len(entry.Fields))
+		for _, field := range entry.Fields {
+			res += fmt.Sprintf("$%d\r\n%s\r\n", len(field), field)
+		}
+	}
+	return res
+}
+
+func handleXRANGE(params internal.HandlerFuncParams) ([]byte, error) {
+	keys, err := xrangeKeyFunc(params.Command)
+	if err != nil {
+		return nil, err
+	}
+
+	key := keys.ReadKeys[0]
+	reverse := strings.EqualFold(params.Command[0], "xrevrange")
+
+	startArg, endArg := params.Command[2], params.Command[3]
+	if reverse {
+		startArg, endArg = endArg, startArg
+	}
+	start, startOk, err := parseRangeID(startArg, true)
+	if err != nil {
+		return nil, err
+	}
+	end, endOk, err := parseRangeID(endArg, false)
+	if err != nil {
+		return nil, err
+	}
+
+	count := -1
+	if len(params.Command) == 6 {
+		if !strings.EqualFold(params.Command[4], "count") {
+			return nil, fmt.Errorf("unknown option %s", params.Command[4])
+		}
+		if count, err = strconv.Atoi(params.Command[5]); err != nil || count < 0 {
+			return nil, errors.New("count must be a non-negative integer")
+		}
+	}
+
+	if !startOk || !endOk || count == 0 || !params.KeyExists(params.Context, key) {
+		return []byte("*0\r\n"), nil
+	}
+
+	if _, err = params.KeyRLock(params.Context, key); err != nil {
+		return nil, err
+	}
+	defer params.KeyRUnlock(params.Context, key)
+
+	stream, ok := params.GetValue(params.Context, key).(*Stream)
+	if !ok {
+		return nil, fmt.Errorf("value at key %s is not a stream", key)
+	}
+
+	return []byte(encodeEntries(stream.Range(start, end, count, reverse))), nil
+}
+
+func handleXREAD(params internal.HandlerFuncParams) ([]byte, error) {
+	keys, err := xreadKeyFunc(params.Command)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse the options that precede STREAMS.
+	count := -1
+	block := -1
+	for i := 1; !strings.EqualFold(params.Command[i], "streams"); i += 2 {
+		if i+1 >= len(params.Command) {
+			return nil, errors.New(constants.WrongArgsResponse)
+		}
+		switch strings.ToUpper(params.Command[i]) {
+		default:
+			return nil, fmt.Errorf("unknown option %s", params.Command[i])
+		case "COUNT":
+			if count, err = strconv.Atoi(params.Command[i+1]); err != nil || count < 0 {
+				return nil, errors.New("count must be a non-negative integer")
+			}
+		case "BLOCK":
+			if block, err = strconv.Atoi(params.Command[i+1]); err != nil || block < 0 {
+				return nil, errors.New("timeout must be a non-negative integer")
+			}
+		}
+	}
+
+	// A timeout of 0 blocks until an entry is available.
+	ctx := params.Context
+	if block > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(block)*time.Millisecond)
+		defer cancel()
+	}
+
+	// Start watching the keys before reading them so that an entry added between the read and the wait is not missed.
+	var watch <-chan struct{}
+	if block >= 0 {
+		watcher := params.WatchKeys(keys.ReadKeys...)
+		defer watcher.Close()
+		watch = watcher.C
+	}
+
+	ids, err := resolveReadIDs(ctx, params, keys.ReadKeys, params.Command[len(params.Command)-len(keys.ReadKeys):])
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		res, err := readStreams(ctx, params, keys.ReadKeys, ids, count)
+		if err != nil {
+			return nil, err
+		}
+		if res != nil {
+			return res, nil
+		}
+		if block < 0 {
+			return []byte("*-1\r\n"), nil
+		}
+
+		select {
+		case <-watch:
+			// One of the streams was modified, try again.
+		case <-ctx.Done():
+			if params.Context.Err() != nil {
+				return nil, context.Cause(params.Context)
+			}
+			// Return a null array when the timeout expires.
+			return []byte("*-1\r\n"), nil
+		}
+	}
+}
+
+// getStream returns the stream at the key, or nil if the key does not exist.
+// The stream must only be accessed while the key is read locked.
+func getStream(ctx context.Context, params internal.HandlerFuncParams, key string) (*Stream, error) {
+	if !params.KeyExists(ctx, key) {
+		return nil, nil
+	}
+	stream, ok := params.GetValue(ctx, key).(*Stream)
+	if !ok {
+		return nil, fmt.Errorf("value at key %s is not a stream", key)
+	}
+	return stream, nil
+}
+
+// resolveReadIDs parses the IDs of XREAD. "$" is resolved to the last ID of the stream when the command is called,
+// so that only the entries added after the command is called are returned.
+func resolveReadIDs(ctx context.Context, params internal.HandlerFuncParams, keys []string, args []string) ([]ID, error) {
+	ids := make([]ID, len(keys))
+	for i, key := range keys {
+		if args[i] != "$" {
+			id, err := ParseID(args[i], 0)
+			if err != nil {
+				return nil, err
+			}
+			ids[i] = id
+			continue
+		}
+
+		if _, err := params.KeyRLock(ctx, key); err != nil {
+			return nil, err
+		}
+		stream, err := getStream(ctx, params, key)
+		if stream != nil {
+			ids[i] = stream.LastID()
+		}
+		params.KeyRUnlock(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ids, nil
+}
+
+// readStreams returns the entries after the provided ID of each stream, grouped by stream.
+// Returns nil if none of the streams have new entries.
+func readStreams(ctx context.Context, params internal.HandlerFuncParams, keys []string, ids []ID, count int) ([]byte, error) {
+	res := ""
+	found := 0
+	for i, key := range keys {
+		if !params.KeyExists(ctx, key) {
+			continue
+		}
+
+		if _, err := params.KeyRLock(ctx, key); err != nil {
+			return nil, err
+		}
+		stream, err := getStream(ctx, params, key)
+		var entries []Entry
+		if stream != nil {
+			entries = stream.After(ids[i], count)
+		}
+		params.KeyRUnlock(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(entries) > 0 {
+			res += fmt.Sprintf("*2\r\n$%d\r\n%s\r\n%s", len(key), key, encodeEntries(entries))
+			found += 1
+		}
+	}
+	if found == 0 {
+		return nil, nil
+	}
+	return []byte(fmt.Sprintf("*%d\r\n%s", found, res)), nil
+}
+
+func handleXGROUPCreate(params internal.HandlerFuncParams) ([]byte, error) {
+	keys, err := xgroupCreateKeyFunc(params.Command)
+	if err != nil {
+		return nil, err
+	}
+
+	key := keys.WriteKeys[0]
+	groupName := params.Command[3]
+
+	mkStream := false
+	if len(params.Command) == 6 {
+		if !strings.EqualFold(params.Command[5], "mkstream") {
+			return nil, fmt.Errorf("unknown option %s", params.Command[5])
+		}
+		mkStream = true
+	}
+
+	// "$" is resolved to the last ID of the stream once the stream is locked.
+	var id ID
+	if params.Command[4] != "$" {
+		if id, err = ParseID(params.Command[4], 0); err != nil {
+			return nil, err
+		}
+	}
+
+	if !params.KeyExists(params.Context, key) {
+		if !mkStream {
+			return nil, fmt.Errorf("key %s does not exist, use MKSTREAM to create an empty stream", key)
+		}
+		if _, err = params.CreateKeyAndLock(params.Context, key); err != nil {
+			return nil, err
+		}
+		if err = params.SetValue(params.Context, key, NewStream()); err != nil {
+			params.KeyUnlock(params.Context, key)
+			return nil, err
+		}
+	} else {
+		if _, err = params.KeyLock(params.Context, key); err != nil {
+			return nil, err
+		}
+	}
+	defer params.KeyUnlock(params.Context, key)
+
+	stream, ok := params.GetValue(params.Context, key).(*Stream)
+	if !ok {
+		return nil, fmt.Errorf("value at key %s is not a stream", key)
+	}
+
+	if params.Command[4] == "$" {
+		id = stream.LastID()
+	}
+	if !stream.CreateGroup(groupName, id) {
+		return nil, fmt.Errorf("consumer group %s already exists", groupName)
+	}
+	if err = params.SetValue(params.Context, key, stream); err != nil {
+		return nil, err
+	}
+
+	return []byte(constants.OkResponse), nil
+}
+
+func handleXGROUPDestroy(params internal.HandlerFuncParams) ([]byte, error) {
+	keys, err := xgroupDestroyKeyFunc(params.Command)
+	if err != nil {
+		return nil, err
+	}
+
+	key := keys.WriteKeys[0]
+
+	if !params.KeyExists(params.Context, key) {
+		return nil, fmt.Errorf("key %s does not exist", key)
+	}
+
+	if _, err = params.KeyLock(params.Context, key); err != nil {
+		return nil, err
+	}
+	defer params.KeyUnlock(params.Context, key)
+
+	stream, ok := params.GetValue(params.Context, key).(*Stream)
+	if !ok {
+		return nil, fmt.Errorf("value at key %s is not a stream", key)
+	}
+
+	if !stream.DestroyGroup(params.Command[3]) {
+		return []byte(":0\r\n"), nil
+	}
+	if err = params.SetValue(params.Context, key, stream); err != nil {
+		return nil, err
+	}
+	return []byte(":1\r\n"), nil
+}
+
+// getGroup returns the stream at the key and the consumer group with the provided name.
+// The key must be locked before calling getGroup.
+func getGroup(ctx context.Context, params internal.HandlerFuncParams, key string, groupName string) (*Stream, *Group, error) {
+	stream, err := getStream(ctx, params, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if stream != nil {
+		if group, ok := stream.Group(groupName); ok {
+			return stream, group, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("no such key %s or consumer group %s", key, groupName)
+}
+
+func handleXREADGROUP(params internal.HandlerFuncParams) ([]byte, error) {
+	keys, err := xreadgroupKeyFunc(params.Command)
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.EqualFold(params.Command[1], "group") {
+		return nil, errors.New("missing GROUP option")
+	}
+	groupName, consumer := params.Command[2], params.Command[3]
+
+	// Parse the options that precede STREAMS.
+	count := -1
+	block := -1
+	noAck := false
+	for i := 4; !strings.EqualFold(params.Command[i], "streams"); i++ {
+		switch strings.ToUpper(params.Command[i]) {
+		default:
+			return nil, fmt.Errorf("unknown option %s", params.Command[i])
+		case "NOACK":
+			noAck = true
+		case "COUNT":
+			i++
+			if count, err = strconv.Atoi(params.Command[i]); err != nil || count < 0 {
+				return nil, errors.New("count must be a non-negative integer")
+			}
+		case "BLOCK":
+			i++
+			if block, err = strconv.Atoi(params.Command[i]); err != nil || block < 0 {
+				return nil, errors.New("timeout must be a non-negative integer")
+			}
+		}
+	}
+
+	// ">" reads the entries that have not been delivered to the group yet.
+	// Any other ID reads the consumer's pending entries after the ID, which never blocks.
+	args := params.Command[len(params.Command)-len(keys.WriteKeys):]
+	ids := make([]ID, len(args))
+	for i, arg := range args {
+		if arg == ">" {
+			continue
+		}
+		if ids[i], err = ParseID(arg, 0); err != nil {
+			return nil, err
+		}
+		block = -1
+	}
+
+	// A timeout of 0 blocks until an entry is available.
+	ctx := params.Context
+	if block > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(block)*time.Millisecond)
+		defer cancel()
+	}
+
+	// Start watching the keys before reading them so that an entry added between the read and the wait is not missed.
+	var watch <-chan struct{}
+	if block >= 0 {
+		watcher := params.WatchKeys(keys.WriteKeys...)
+		defer watcher.Close()
+		watch = watcher.C
+	}
+
+	for {
+		res := ""
+		found := 0
+		for i, key := range keys.WriteKeys {
+			entries, err := readGroup(ctx, params, key, groupName, consumer, args[i] == ">", ids[i], count, noAck)
+			if err != nil {
+				return nil, err
+			}
+			// The pending entries of a stream are always returned, even if there are none.
+			if len(entries) > 0 || args[i] != ">" {
+				res += fmt.Sprintf("*2\r\n$%d\r\n%s\r\n%s", len(key), key, encodeEntries(entries))
+				found += 1
+			}
+		}
+		if found > 0 {
+			return []byte(fmt.Sprintf("*%d\r\n%s", found, res)), nil
+		}
+		if block < 0 {
+			return []byte("*-1\r\n"), nil
+		}
+
+		select {
+		case <-watch:
+			// One of the streams was modified, try again.
+		case <-ctx.Done():
+			if params.Context.Err() != nil {
+				return nil, context.Cause(params.Context)
+			}
+			// Return a null array when the timeout expires.
+			return []byte("*-1\r\n"), nil
+		}
+	}
+}
+
+// readGroup reads the entries of the stream at the key for the consumer of the group.
+// When readNew is true, the entries that have not been delivered to the group are read.
+// Otherwise, the consumer's pending entries with IDs greater than the provided ID are read.
+func readGroup(ctx context.Context, params internal.HandlerFuncParams, key string, groupName string, consumer string,
+	readNew bool, id ID, count int, noAck bool) ([]Entry, error) {
+	// Wait for a state copy in progress to finish before modifying the store.
+	done := params.WaitForStateCopy()
+	defer done()
+
+	if _, err := params.KeyLock(ctx, key); err != nil {
+		return nil, err
+	}
+	defer params.KeyUnlock(ctx, key)
+
+	stream, group, err := getGroup(ctx, params, key, groupName)
+	if err != nil {
+		return nil, err
+	}
+
+	now := params.GetClock().Now()
+	var entries []Entry
+	if readNew {
+		entries = group.ReadNew(stream, consumer, count, noAck, now)
+	} else {
+		entries = group.ReadPending(stream, consumer, id, count, now)
+	}
+	// Reading registers the consumer in the group, so the stream is modified even when no entries are read.
+	if err = params.SetValue(ctx, key, stream); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func handleXACK(params internal.HandlerFuncParams) ([]byte, error) {
+	keys, err := xackKeyFunc(params.Command)
+	if err != nil {
+		return nil, err
+	}
+
+	key := keys.WriteKeys[0]
+
+	ids := make([]ID, len(params.Command[3:]))
+	for i, arg := range params.Command[3:] {
+		if ids[i], err = ParseID(arg, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	if !params.KeyExists(params.Context, key) {
+		return []byte(":0\r\n"), nil
+	}
+
+	if _, err = params.KeyLock(params.Context, key); err != nil {
+		return nil, err
+	}
+	defer params.KeyUnlock(params.Context, key)
+
+	stream, err := getStream(params.Context, params, key)
+	if err != nil {
+		return nil, err
+	}
+	group, ok := stream.Group(params.Command[2])
+	if !ok {
+		return []byte(":0\r\n"), nil
+	}
+
+	count := group.Ack(ids)
+	if count > 0 {
+		if err = params.SetValue(params.Context, key, stream); err != nil {
+			return nil, err
+		}
+	}
+
+	return []byte(fmt.Sprintf(":%d\r\n", count)), nil
+}
+
+func handleXPENDING(params internal.HandlerFuncParams) ([]byte, error) {
+	keys, err := xpendingKeyFunc(params.Command)
+	if err != nil {
+		return nil, err
+	}
+
+	key := keys.ReadKeys[0]
+	groupName := params.Command[2]
+
+	if _, err = params.KeyRLock(params.Context, key); err != nil {
+		return nil, err
+	}
+	defer params.KeyRUnlock(params.Context, key)
+
+	_, group, err := getGroup(params.Context, params, key, groupName)
+	if err != nil {
+		return nil, err
+	}
+
+	// The summary form returns the number of pending entries, the smallest and largest pending IDs,
+	// and the number of pending entries of each consumer.
+	if len(params.Command) == 3 {
+		pending := group.Pending(minID, maxID, "")
+		if len(pending) == 0 {
+			return []byte("*4\r\n:0\r\n$-1\r\n$-1\r\n*-1\r\n"), nil
+		}
+		first, last := pending[0].ID.String(), pending[len(pending)-1].ID.String()
+		res := fmt.Sprintf("*4\r\n:%d\r\n$%d\r\n%s\r\n$%d\r\n%s\r\n", len(pending), len(first), first, len(last), last)
+
+		var consumers []string
+		counts := make(map[string]int)
+		for _, entry := range pending {
+			if counts[entry.Consumer] == 0 {
+				consumers = append(consumers, entry.Consumer)
+			}
+			counts[entry.Consumer] += 1
+		}
+		slices.Sort(consumers)
+		res += fmt.Sprintf("*%d\r\n", len(consumers))
+		for _, consumer := range consumers {
+			count := strconv.Itoa(counts[consumer])
+			res += fmt.Sprintf("*2\r\n$%d\r\n%s\r\n$%d\r\n%s\r\n", len(consumer), consumer, len(count), count)
+		}
+		return []byte(res), nil
+	}
+
+	// The extended form is XPENDING key group [IDLE min-idle-time] start end count [consumer].
+	args := params.Command[3:]
+	var minIdle time.Duration
+	if strings.EqualFold(args[0], "idle") {
+		ms, err := strconv.Atoi(args[1])
+		if err != nil || ms < 0 {
+			return nil, errors.New("min-idle-time must be a non-negative integer")
+		}
+		minIdle = time.Duration(ms) * time.Millisecond
+		args = args[2:]
+	}
+	if len(args) != 3 && len(args) != 4 {
+		return nil, errors.New(constants.WrongArgsResponse)
+	}
+
+	start, startOk, err := parseRangeID(args[0], true)
+	if err != nil {
+		return nil, err
+	}
+	end, endOk, err := parseRangeID(args[1], false)
+	if err != nil {
+		return nil, err
+	}
+	count, err := strconv.Atoi(args[2])
+	if err != nil || count < 0 {
+		return nil, errors.New("count must be a non-negative integer")
+	}
+	consumer := ""
+	if len(args) == 4 {
+		consumer = args[3]
+	}
+
+	if !startOk || !endOk {
+		return []byte("*0\r\n"), nil
+	}
+
+	now := params.GetClock().Now()
+	res := ""
+	found := 0
+	for _, entry := range group.Pending(start, end, consumer) {
+		if found == count {
+			break
+		}
+		idle := now.Sub(entry.DeliveryTime)
+		if idle < minIdle {
+			continue
+		}
+		id := entry.ID.String()
+		res += fmt.Sprintf("*4\r\n$%d\r\n%s\r\n$%d\r\n%s\r\n:%d\r\n:%d\r\n",
+			len(id), id, len(entry.Consumer), entry.Consumer, idle.Milliseconds(), entry.DeliveryCount)
+		found += 1
+	}
+
+	return []byte(fmt.Sprintf("*%d\r\n%s", found, res)), nil
+}
+
+func handleXCLAIM(params internal.HandlerFuncParams) ([]byte, error) {
+	keys, err := xclaimKeyFunc(params.Command)
+	if err != nil {
+		return nil, err
+	}
+
+	key := keys.WriteKeys[0]
+	groupName, consumer := params.Command[2], params.Command[3]
+
+	minIdle, err := strconv.Atoi(params.Command[4])
+	if err != nil || minIdle < 0 {
+		return nil, errors.New("min-idle-time must be a non-negative integer")
+	}
+
+	now := params.GetClock().Now()
+	options := ClaimOptions{
+		MinIdleTime:  time.Duration(minIdle) * time.Millisecond,
+		Now:          now,
+		DeliveryTime: now,
+		RetryCount:   -1,
+	}
+
+	// The IDs are followed by the options.
+	var ids []ID
+	i := 5
+	for ; i < len(params.Command); i++ {
+		id, err := ParseID(params.Command[i], 0)
+		if err != nil {
+			break
+		}
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		return nil, errors.New("invalid stream ID specified as stream command argument")
+	}
+	for ; i < len(params.Command); i++ {
+		option := strings.ToUpper(params.Command[i])
+		switch option {
+		default:
+			return nil, fmt.Errorf("unknown option %s", params.Command[i])
+		case "FORCE":
+			options.Force = true
+			continue
+		case "JUSTID":
+			options.JustID = true
+			continue
+		case "IDLE", "TIME", "RETRYCOUNT":
+		}
+
+		if i++; i >= len(params.Command) {
+			return nil, errors.New(constants.WrongArgsResponse)
+		}
+		n, err := strconv.ParseInt(params.Command[i], 10, 64)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("%s must be a non-negative integer", option)
+		}
+		switch option {
+		case "IDLE":
+			options.DeliveryTime = now.Add(-time.Duration(n) * time.Millisecond)
+		case "TIME":
+			options.DeliveryTime = time.UnixMilli(n)
+		case "RETRYCOUNT":
+			options.RetryCount = int(n)
+		}
+	}
+
+	if _, err = params.KeyLock(params.Context, key); err != nil {
+		return nil, err
+	}
+	defer params.KeyUnlock(params.Context, key)
+
+	stream, group, err := getGroup(params.Context, params, key, groupName)
+	if err != nil {
+		return nil, err
+	}
+
+	claimed := group.Claim(stream, consumer, ids, options)
+	if err = params.SetValue(params.Context, key, stream); err != nil {
+		return nil, err
+	}
+
+	if options.JustID {
+		res := fmt.Sprintf("*%d\r\n", len(claimed))
+		for _, entry := range claimed {
+			id := entry.ID.String()
+			res += fmt.Sprintf("$%d\r\n%s\r\n", len(id), id)
+		}
+		return []byte(res), nil
+	}
+	return []byte(encodeEntries(claimed)), nil
+}
+
+func Commands() []internal.Command {
+	return []internal.Command{
+		{
+			Command:    "xadd",
+			Module:     constants.StreamModule,
+			Categories: []string{constants.StreamCategory, constants.WriteCategory, constants.FastCategory},
+			Description: `(XADD key [NOMKSTREAM] [MAXLEN [= | ~] threshold] <* | id> field value [field value ...])
+Appends an entry to the stream at the key and returns the ID of the entry. When the ID is *, it is generated
+from the current time. The stream is created if it does not exist, unless NOMKSTREAM is provided.
+When MAXLEN is provided, the oldest entries are removed so that the stream has at most threshold entries.`,
+			Sync:              true,
+			KeyExtractionFunc: xaddKeyFunc,
+			HandlerFunc:       handleXADD,
+		},
+		{
+			Command:           "xlen",
+			Module:            constants.StreamModule,
+			Categories:        []string{constants.StreamCategory, constants.ReadCategory, constants.FastCategory},
+			Description:       "(XLEN key) Returns the number of entries in the stream.",
+			Sync:              false,
+			KeyExtractionFunc: xlenKeyFunc,
+			HandlerFunc:       handleXLEN,
+		},
+		{
+			Command:    "xrange",
+			Module:     constants.StreamModule,
+			Categories: []string{constants.StreamCategory, constants.ReadCategory, constants.SlowCategory},
+			Description: `(XRANGE key start end [COUNT count])
+Returns the entries of the stream with IDs between start and end. "-" and "+" are the smallest and largest IDs.
+Prefix an ID with "(" to exclude it from the range.`,
+			Sync:              false,
+			KeyExtractionFunc: xrangeKeyFunc,
+			HandlerFunc:       handleXRANGE,
+		},
+		{
+			Command:    "xrevrange",
+			Module:     constants.StreamModule,
+			Categories: []string{constants.StreamCategory, constants.ReadCategory, constants.SlowCategory},
+			Description: `(XREVRANGE key end start [COUNT count])
+Returns the entries of the stream with IDs between end and start, starting from the largest ID.`,
+			Sync:              false,
+			KeyExtractionFunc: xrangeKeyFunc,
+			HandlerFunc:       handleXRANGE,
+		},
+		{
+			Command: "xread",
+			Module:  constants.StreamModule,
+			Categories: []string{
+				constants.StreamCategory, constants.ReadCategory, constants.BlockingCategory, constants.SlowCategory,
+			},
+			Description: `(XREAD [COUNT count] [BLOCK milliseconds] STREAMS key [key ...] id [id ...])
+Returns the entries with IDs greater than the provided ID from each of the streams. Use $ as the ID to only
+return entries added after the command is called. When BLOCK is provided, the command blocks until an entry
+is available or the timeout expires. A timeout of 0 blocks indefinitely.`,
+			Sync:              false,
+			KeyExtractionFunc: xreadKeyFunc,
+			HandlerFunc:       handleXREAD,
+		},
+		{
+			Command:     "xgroup",
+			Module:      constants.StreamModule,
+			Categories:  []string{},
+			Description: "Stream consumer group commands",
+			Sync:        false,
+			KeyExtractionFunc: func(cmd []string) (internal.KeyExtractionFuncResult, error) {
+				return internal.KeyExtractionFuncResult{
+					Channels:  make([]string, 0),
+					ReadKeys:  make([]string, 0),
+					WriteKeys: make([]string, 0),
+				}, nil
+			},
+			SubCommands: []internal.SubCommand{
+				{
+					Command:    "create",
+					Module:     constants.StreamModule,
+					Categories: []string{constants.StreamCategory, constants.WriteCategory, constants.SlowCategory},
+					Description: `(XGROUP CREATE key group <id | $> [MKSTREAM])
+Creates a consumer group that delivers the entries with IDs greater than the provided ID.
+Use $ to only deliver the entries added after the group is created.
+The stream is created if it does not exist and MKSTREAM is provided.`,
+					Sync:              true,
+					KeyExtractionFunc: xgroupCreateKeyFunc,
+					HandlerFunc:       handleXGROUPCreate,
+				},
+				{
+					Command:    "destroy",
+					Module:     constants.StreamModule,
+					Categories: []string{constants.StreamCategory, constants.WriteCategory, constants.SlowCategory},
+					Description: `(XGROUP DESTROY key group)
+Deletes the consumer group along with its pending entries. Returns 1 if the group was deleted and 0 otherwise.`,
+					Sync:              true,
+					KeyExtractionFunc: xgroupDestroyKeyFunc,
+					HandlerFunc:       handleXGROUPDestroy,
+				},
+			},
+		},
+		{
+			Command: "xreadgroup",
+			Module:  constants.StreamModule,
+			Categories: []string{
+				constants.StreamCategory, constants.WriteCategory, constants.BlockingCategory, constants.SlowCategory,
+			},
+			Description: `(XREADGROUP GROUP group consumer [COUNT count] [BLOCK milliseconds] [NOACK] STREAMS key [key ...] id [id ...])
+Reads the entries of the streams on behalf of the consumer of the group. Use > as the ID to read the entries
+that have not been delivered to any consumer of the group. The delivered entries are added to the pending
+entries list of the consumer, unless NOACK is provided. Any other ID returns the consumer's pending entries
+with IDs greater than the ID. When BLOCK is provided, the command blocks until an entry is available
+or the timeout expires.`,
+			Sync:              false,
+			KeyExtractionFunc: xreadgroupKeyFunc,
+			HandlerFunc:       handleXREADGROUP,
+		},
+		{
+			Command:    "xack",
+			Module:     constants.StreamModule,
+			Categories: []string{constants.StreamCategory, constants.WriteCategory, constants.FastCategory},
+			Description: `(XACK key group id [id ...])
+Removes the entries from the pending entries list of the group. Returns the number of entries acknowledged.`,
+			Sync:              true,
+			KeyExtractionFunc: xackKeyFunc,
+			HandlerFunc:       handleXACK,
+		},
+		{
+			Command:    "xpending",
+			Module:     constants.StreamModule,
+			Categories: []string{constants.StreamCategory, constants.ReadCategory, constants.SlowCategory},
+			Description: `(XPENDING key group [[IDLE min-idle-time] start end count [consumer]])
+Returns a summary of the pending entries of the group. When the range is provided, returns the ID, consumer,
+idle time in milliseconds and delivery count of each pending entry in the range.`,
+			Sync:              false,
+			KeyExtractionFunc: xpendingKeyFunc,
+			HandlerFunc:       handleXPENDING,
+		},
+		{
+			Command:    "xclaim",
+			Module:     constants.StreamModule,
+			Categories: []string{constants.StreamCategory, constants.WriteCategory, constants.FastCategory},
+			Description: `(XCLAIM key group consumer min-idle-time id [id ...] [IDLE ms] [TIME unix-time-milliseconds]
+[RETRYCOUNT count] [FORCE] [JUSTID])
+Transfers the ownership of the pending entries that have been idle for at least min-idle-time to the consumer.
+Returns the claimed entries, or only their IDs when JUSTID is provided.`,
+			Sync:              true,
+			KeyExtractionFunc: xclaimKeyFunc,
+			HandlerFunc:       handleXCLAIM,
+		},
+	}
+}
@@ -0,0 +1,232 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stream
+
+import (
+	"slices"
+	"sort"
+	"time"
+)
+
+// PendingEntry is an entry that has been delivered to a consumer of a group but has not been acknowledged yet.
+type PendingEntry struct {
+	ID            ID
+	Consumer      string
+	DeliveryTime  time.Time
+	DeliveryCount int
+}
+
+// Group is a consumer group of a stream. Each entry of the stream is delivered to only one consumer of the group.
+// The delivered entries are tracked in the pending entries list until the consumer acknowledges them.
+type Group struct {
+	lastDeliveredID ID
+	pending         map[ID]*PendingEntry
+	consumers       map[string]struct{}
+}
+
+func newGroup(lastDeliveredID ID) *Group {
+	return &Group{
+		lastDeliveredID: lastDeliveredID,
+		pending:         make(map[ID]*PendingEntry),
+		consumers:       make(map[string]struct{}),
+	}
+}
+
+func (group *Group) clone() *Group {
+	pending := make(map[ID]*PendingEntry, len(group.pending))
+	for id, entry := range group.pending {
+		e := *entry
+		pending[id] = &e
+	}
+	consumers := make(map[string]struct{}, len(group.consumers))
+	for consumer := range group.consumers {
+		consumers[consumer] = struct{}{}
+	}
+	return &Group{
+		lastDeliveredID: group.lastDeliveredID,
+		pending:         pending,
+		consumers:       consumers,
+	}
+}
+
+// CreateGroup creates a consumer group that delivers the entries with IDs greater than lastDeliveredID.
+// Returns false if the group already exists.
+func (stream *Stream) CreateGroup(name string, lastDeliveredID ID) bool {
+	if _, ok := stream.groups[name]; ok {
+		return false
+	}
+	stream.groups[name] = newGroup(lastDeliveredID)
+	return true
+}
+
+// DestroyGroup deletes the consumer group along with its pending entries. Returns false if the group does not exist.
+func (stream *Stream) DestroyGroup(name string) bool {
+	if _, ok := stream.groups[name]; !ok {
+		return false
+	}
+	delete(stream.groups, name)
+	return true
+}
+
+// Group returns the consumer group with the provided name.
+func (stream *Stream) Group(name string) (*Group, bool) {
+	group, ok := stream.groups[name]
+	return group, ok
+}
+
+// get returns the entry with the provided ID.
+func (stream *Stream) get(id ID) (Entry, bool) {
+	i := sort.Search(len(stream.entries), func(i int) bool {
+		return stream.entries[i].ID.Compare(id) >= 0
+	})
+	if i < len(stream.entries) && stream.entries[i].ID == id {
+		return stream.entries[i], true
+	}
+	return Entry{}, false
+}
+
+// ReadNew delivers the entries that have not been delivered to any consumer of the group yet.
+// Unless noAck is true, the delivered entries are added to the pending entries list of the consumer.
+// When count is greater than 0, at most count entries are delivered.
+func (group *Group) ReadNew(stream *Stream, consumer string, count int, noAck bool, now time.Time) []Entry {
+	group.consumers[consumer] = struct{}{}
+
+	entries := stream.After(group.lastDeliveredID, count)
+	for _, entry := range entries {
+		group.lastDeliveredID = entry.ID
+		if noAck {
+			continue
+		}
+		group.pending[entry.ID] = &PendingEntry{
+			ID:            entry.ID,
+			Consumer:      consumer,
+			DeliveryTime:  now,
+			DeliveryCount: 1,
+		}
+	}
+	return entries
+}
+
+// ReadPending delivers the consumer's pending entries with IDs greater than the provided ID again.
+// The Fields of entries that have been removed from the stream are nil.
+// When count is greater than 0, at most count entries are delivered.
+func (group *Group) ReadPending(stream *Stream, consumer string, after ID, count int, now time.Time) []Entry {
+	group.consumers[consumer] = struct{}{}
+
+	var entries []Entry
+	for _, pending := range group.Pending(after, maxID, consumer) {
+		if count > 0 && len(entries) == count {
+			break
+		}
+		pending.DeliveryTime = now
+		pending.DeliveryCount += 1
+		entry, ok := stream.get(pending.ID)
+		if !ok {
+			entry = Entry{ID: pending.ID, Fields: nil}
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// Ack removes the entries from the pending entries list. Returns the number of entries removed.
+func (group *Group) Ack(ids []ID) int {
+	count := 0
+	for _, id := range ids {
+		if _, ok := group.pending[id]; ok {
+			delete(group.pending, id)
+			count += 1
+		}
+	}
+	return count
+}
+
+// Pending returns the pending entries with IDs between start and end (both inclusive) in ascending order of ID.
+// When consumer is not empty, only the entries pending for the consumer are returned.
+func (group *Group) Pending(start ID, end ID, consumer string) []*PendingEntry {
+	var entries []*PendingEntry
+	for id, entry := range group.pending {
+		if id.Compare(start) < 0 || id.Compare(end) > 0 {
+			continue
+		}
+		if consumer != "" && entry.Consumer != consumer {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	slices.SortFunc(entries, func(a, b *PendingEntry) int {
+		return a.ID.Compare(b.ID)
+	})
+	return entries
+}
+
+// Consumers returns the names of the consumers of the group.
+func (group *Group) Consumers() []string {
+	consumers := make([]string, 0, len(group.consumers))
+	for consumer := range group.consumers {
+		consumers = append(consumers, consumer)
+	}
+	slices.Sort(consumers)
+	return consumers
+}
+
+// ClaimOptions modifies the effect of Claim.
+type ClaimOptions struct {
+	MinIdleTime  time.Duration // Only claim the entries that have been idle for at least this long.
+	Now          time.Time     // The time used to compute how long the entries have been idle.
+	DeliveryTime time.Time     // The new delivery time of the claimed entries.
+	RetryCount   int           // The new delivery count of the claimed entries. Ignored when negative.
+	Force        bool          // Claim the delivered entries that are not in the pending entries list.
+	JustID       bool          // Do not increment the delivery count.
+}
+
+// Claim transfers the ownership of the pending entries that have been idle for at least the minimum idle time
+// to the consumer. Entries that have been removed from the stream are removed from the pending entries list
+// instead of being claimed. Returns the claimed entries.
+func (group *Group) Claim(stream *Stream, consumer string, ids []ID, options ClaimOptions) []Entry {
+	group.consumers[consumer] = struct{}{}
+
+	var claimed []Entry
+	for _, id := range ids {
+		entry, exists := stream.get(id)
+		pending, isPending := group.pending[id]
+
+		if !isPending {
+			// Only the entries that exist in the stream can be forced into the pending entries list.
+			if !options.Force || !exists {
+				continue
+			}
+			pending = &PendingEntry{ID: id, DeliveryCount: 0}
+			group.pending[id] = pending
+		} else if options.Now.Sub(pending.DeliveryTime) < options.MinIdleTime {
+			continue
+		}
+
+		if !exists {
+			delete(group.pending, id)
+			continue
+		}
+
+		pending.Consumer = consumer
+		pending.DeliveryTime = options.DeliveryTime
+		if options.RetryCount >= 0 {
+			pending.DeliveryCount = options.RetryCount
+		} else if !options.JustID {
+			pending.DeliveryCount += 1
+		}
+		claimed = append(claimed, entry)
+	}
+	return claimed
+}
@@ -0,0 +1,164 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stream
+
+import (
+	"errors"
+	"github.com/echovault/echovault/internal"
+	"github.com/echovault/echovault/internal/constants"
+	"slices"
+	"strings"
+)
+
+func xaddKeyFunc(cmd []string) (internal.KeyExtractionFuncResult, error) {
+	if len(cmd) < 5 {
+		return internal.KeyExtractionFuncResult{}, errors.New(constants.WrongArgsResponse)
+	}
+	return internal.KeyExtractionFuncResult{
+		Channels:  make([]string, 0),
+		ReadKeys:  make([]string, 0),
+		WriteKeys: cmd[1:2],
+	}, nil
+}
+
+func xlenKeyFunc(cmd []string) (internal.KeyExtractionFuncResult, error) {
+	if len(cmd) != 2 {
+		return internal.KeyExtractionFuncResult{}, errors.New(constants.WrongArgsResponse)
+	}
+	return internal.KeyExtractionFuncResult{
+		Channels:  make([]string, 0),
+		ReadKeys:  cmd[1:2],
+		WriteKeys: make([]string, 0),
+	}, nil
+}
+
+func xrangeKeyFunc(cmd []string) (internal.KeyExtractionFuncResult, error) {
+	if len(cmd) != 4 && len(cmd) != 6 {
+		return internal.KeyExtractionFuncResult{}, errors.New(constants.WrongArgsResponse)
+	}
+	return internal.KeyExtractionFuncResult{
+		Channels:  make([]string, 0),
+		ReadKeys:  cmd[1:2],
+		WriteKeys: make([]string, 0),
+	}, nil
+}
+
+// streamKeys returns the keys that follow the STREAMS option of XREAD and XREADGROUP.
+// The search for the STREAMS option starts at the provided index so that the arguments before the options are skipped.
+func streamKeys(cmd []string, from int) ([]string, error) {
+	if len(cmd) <= from {
+		return nil, errors.New(constants.WrongArgsResponse)
+	}
+	streamsIdx := slices.IndexFunc(cmd[from:], func(arg string) bool {
+		return strings.EqualFold(arg, "streams")
+	})
+	if streamsIdx < 0 {
+		return nil, errors.New(constants.WrongArgsResponse)
+	}
+	streamsIdx += from
+	if len(cmd[streamsIdx+1:]) == 0 {
+		return nil, errors.New(constants.WrongArgsResponse)
+	}
+	// Each key must be followed by its ID after all the keys have been listed.
+	args := cmd[streamsIdx+1:]
+	if len(args)%2 != 0 {
+		return nil, errors.New("unbalanced list of streams: an ID or '$' must be specified for each key")
+	}
+	return args[:len(args)/2], nil
+}
+
+func xreadKeyFunc(cmd []string) (internal.KeyExtractionFuncResult, error) {
+	keys, err := streamKeys(cmd, 1)
+	if err != nil {
+		return internal.KeyExtractionFuncResult{}, err
+	}
+	return internal.KeyExtractionFuncResult{
+		Channels:  make([]string, 0),
+		ReadKeys:  keys,
+		WriteKeys: make([]string, 0),
+	}, nil
+}
+
+func xgroupCreateKeyFunc(cmd []string) (internal.KeyExtractionFuncResult, error) {
+	if len(cmd) < 5 || len(cmd) > 6 {
+		return internal.KeyExtractionFuncResult{}, errors.New(constants.WrongArgsResponse)
+	}
+	return internal.KeyExtractionFuncResult{
+		Channels:  make([]string, 0),
+		ReadKeys:  make([]string, 0),
+		WriteKeys: cmd[2:3],
+	}, nil
+}
+
+func xgroupDestroyKeyFunc(cmd []string) (internal.KeyExtractionFuncResult, error) {
+	if len(cmd) != 4 {
+		return internal.KeyExtractionFuncResult{}, errors.New(constants.WrongArgsResponse)
+	}
+	return internal.KeyExtractionFuncResult{
+		Channels:  make([]string, 0),
+		ReadKeys:  make([]string, 0),
+		WriteKeys: cmd[2:3],
+	}, nil
+}
+
+func xreadgroupKeyFunc(cmd []string) (internal.KeyExtractionFuncResult, error) {
+	// XREADGROUP GROUP group consumer STREAMS key id
+	if len(cmd) < 7 {
+		return internal.KeyExtractionFuncResult{}, errors.New(constants.WrongArgsResponse)
+	}
+	keys, err := streamKeys(cmd, 4)
+	if err != nil {
+		return internal.KeyExtractionFuncResult{}, err
+	}
+	// Reading from a group updates the group's pending entries list, so the keys are write keys.
+	return internal.KeyExtractionFuncResult{
+		Channels:  make([]string, 0),
+		ReadKeys:  make([]string, 0),
+		WriteKeys: keys,
+	}, nil
+}
+
+func xackKeyFunc(cmd []string) (internal.KeyExtractionFuncResult, error) {
+	if len(cmd) < 4 {
+		return internal.KeyExtractionFuncResult{}, errors.New(constants.WrongArgsResponse)
+	}
+	return internal.KeyExtractionFuncResult{
+		Channels:  make([]string, 0),
+		ReadKeys:  make([]string, 0),
+		WriteKeys: cmd[1:2],
+	}, nil
+}
+
+func xpendingKeyFunc(cmd []string) (internal.KeyExtractionFuncResult, error) {
+	if len(cmd) != 3 && (len(cmd) < 6 || len(cmd) > 9) {
+		return internal.KeyExtractionFuncResult{}, errors.New(constants.WrongArgsResponse)
+	}
+	return internal.KeyExtractionFuncResult{
+		Channels:  make([]string, 0),
+		ReadKeys:  cmd[1:2],
+		WriteKeys: make([]string, 0),
+	}, nil
+}
+
+func xclaimKeyFunc(cmd []string) (internal.KeyExtractionFuncResult, error) {
+	if len(cmd) < 6 {
+		return internal.KeyExtractionFuncResult{}, errors.New(constants.WrongArgsResponse)
+	}
+	return internal.KeyExtractionFuncResult{
+		Channels:  make([]string, 0),
+		ReadKeys:  make([]string, 0),
+		WriteKeys: cmd[1:2],
+	}, nil
+}
@@ -0,0 +1,256 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stream
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ID is the ID of a stream entry. It's made up of the unix time in milliseconds at which the entry was added
+// and a sequence number that distinguishes entries added within the same millisecond.
+type ID struct {
+	Ms  uint64
+	Seq uint64
+}
+
+var (
+	minID = ID{Ms: 0, Seq: 0}
+	maxID = ID{Ms: math.MaxUint64, Seq: math.MaxUint64}
+)
+
+func (id ID) String() string {
+	return fmt.Sprintf("%d-%d", id.Ms, id.Seq)
+}
+
+// Compare returns -1 if id is less than other, 0 if they're equal and 1 if id is greater than other.
+func (id ID) Compare(other ID) int {
+	switch {
+	case id.Ms < other.Ms:
+		return -1
+	case id.Ms > other.Ms:
+		return 1
+	case id.Seq < other.Seq:
+		return -1
+	case id.Seq > other.Seq:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// next returns the smallest ID that is greater than id.
+func (id ID) next() (ID, bool) {
+	if id.Seq < math.MaxUint64 {
+		return ID{Ms: id.Ms, Seq: id.Seq + 1}, true
+	}
+	if id.Ms < math.MaxUint64 {
+		return ID{Ms: id.Ms + 1, Seq: 0}, true
+	}
+	return id, false
+}
+
+// prev returns the largest ID that is less than id.
+func (id ID) prev() (ID, bool) {
+	if id.Seq > 0 {
+		return ID{Ms: id.Ms, Seq: id.Seq - 1}, true
+	}
+	if id.Ms > 0 {
+		return ID{Ms: id.Ms - 1, Seq: math.MaxUint64}, true
+	}
+	return id, false
+}
+
+// ParseID parses an ID in the "<ms>-<seq>" format.
+// If the sequence number is omitted, defaultSeq is used as the sequence number.
+func ParseID(s string, defaultSeq uint64) (ID, error) {
+	msPart, seqPart, hasSeq := strings.Cut(s, "-")
+	ms, err := strconv.ParseUint(msPart, 10, 64)
+	if err != nil {
+		return ID{}, errors.New("invalid stream ID specified as stream command argument")
+	}
+	if !hasSeq {
+		return ID{Ms: ms, Seq: defaultSeq}, nil
+	}
+	seq, err := strconv.ParseUint(seqPart, 10, 64)
+	if err != nil {
+		return ID{}, errors.New("invalid stream ID specified as stream command argument")
+	}
+	return ID{Ms: ms, Seq: seq}, nil
+}
+
+// Entry is a single entry in a stream. Fields holds the field-value pairs of the entry in the order they were added.
+type Entry struct {
+	ID     ID
+	Fields []string
+}
+
+// Stream is an append-only log of entries ordered by their IDs.
+type Stream struct {
+	entries []Entry
+	lastID  ID // The ID of the last entry ever added, which is kept even if the entry is trimmed.
+	groups  map[string]*Group
+}
+
+func NewStream() *Stream {
+	return &Stream{
+		entries: make([]Entry, 0),
+		lastID:  minID,
+		groups:  make(map[string]*Group),
+	}
+}
+
+// TypeName returns the name of the stream type as reported by the TYPE command.
+func (stream *Stream) TypeName() string {
+	return "stream"
+}
+
+// exportedEntry is the exported form of a stream entry.
+type exportedEntry struct {
+	ID     string   `json:"id"`
+	Fields []string `json:"fields"`
+}
+
+// Export returns the entries of the stream in ID order. Consumer groups are not exported.
+func (stream *Stream) Export() interface{} {
+	res := make([]exportedEntry, len(stream.entries))
+	for i, entry := range stream.entries {
+		res[i] = exportedEntry{ID: entry.ID.String(), Fields: slices.Clone(entry.Fields)}
+	}
+	return res
+}
+
+// Clone returns a deep copy of the stream.
+func (stream *Stream) Clone() interface{} {
+	entries := make([]Entry, len(stream.entries))
+	for i, entry := range stream.entries {
+		entries[i] = Entry{ID: entry.ID, Fields: slices.Clone(entry.Fields)}
+	}
+	groups := make(map[string]*Group, len(stream.groups))
+	for name, group := range stream.groups {
+		groups[name] = group.clone()
+	}
+	return &Stream{
+		entries: entries,
+		lastID:  stream.lastID,
+		groups:  groups,
+	}
+}
+
+// Len returns the number of entries in the stream.
+func (stream *Stream) Len() int {
+	return len(stream.entries)
+}
+
+// LastID returns the ID of the last entry added to the stream.
+func (stream *Stream) LastID() ID {
+	return stream.lastID
+}
+
+// NextID generates the ID for a new entry added at the provided time.
+// The ID is always greater than the last ID, even if the clock has moved backwards.
+func (stream *Stream) NextID(now time.Time) (ID, error) {
+	ms := uint64(now.UnixMilli())
+	if ms > stream.lastID.Ms {
+		return ID{Ms: ms, Seq: 0}, nil
+	}
+	return stream.NextIDWithMs(stream.lastID.Ms)
+}
+
+// NextIDWithMs generates the ID for a new entry with the provided milliseconds part.
+func (stream *Stream) NextIDWithMs(ms uint64) (ID, error) {
+	if ms > stream.lastID.Ms {
+		return ID{Ms: ms, Seq: 0}, nil
+	}
+	if ms == stream.lastID.Ms {
+		if id, ok := stream.lastID.next(); ok && id.Ms == ms {
+			return id, nil
+		}
+	}
+	return ID{}, errors.New("the ID specified in XADD is equal or smaller than the target stream top item")
+}
+
+// Add appends an entry to the stream. The ID must be greater than the last ID of the stream.
+func (stream *Stream) Add(id ID, fields []string) error {
+	if id.Compare(minID) == 0 {
+		return errors.New("the ID specified in XADD must be greater than 0-0")
+	}
+	if id.Compare(stream.lastID) <= 0 {
+		return errors.New("the ID specified in XADD is equal or smaller than the target stream top item")
+	}
+	stream.entries = append(stream.entries, Entry{ID: id, Fields: fields})
+	stream.lastID = id
+	return nil
+}
+
+// Trim removes the oldest entries until the stream has at most maxLen entries.
+// Returns the number of entries removed.
+func (stream *Stream) Trim(maxLen int) int {
+	if len(stream.entries) <= maxLen {
+		return 0
+	}
+	removed := len(stream.entries) - maxLen
+	// Reslice rather than copy the remaining entries, as XADD with MAXLEN trims the stream on every call.
+	// The removed entries are zeroed so that their fields can be freed, and the backing array is replaced
+	// the next time Add grows the slice.
+	clear(stream.entries[:removed])
+	stream.entries = stream.entries[removed:]
+	return removed
+}
+
+// Range returns the entries with IDs between start and end (both inclusive).
+// When count is greater than 0, at most count entries are returned.
+// When reverse is true, the entries are returned from end to start.
+func (stream *Stream) Range(start, end ID, count int, reverse bool) []Entry {
+	lo := sort.Search(len(stream.entries), func(i int) bool {
+		return stream.entries[i].ID.Compare(start) >= 0
+	})
+	hi := sort.Search(len(stream.entries), func(i int) bool {
+		return stream.entries[i].ID.Compare(end) > 0
+	})
+	if lo >= hi {
+		return []Entry{}
+	}
+
+	// Narrow the range to count entries before copying it, taking them from the end when reversed.
+	if count > 0 && hi-lo > count {
+		if reverse {
+			lo = hi - count
+		} else {
+			hi = lo + count
+		}
+	}
+	entries := slices.Clone(stream.entries[lo:hi])
+	if reverse {
+		slices.Reverse(entries)
+	}
+	return entries
+}
+
+// After returns the entries with IDs greater than id.
+// When count is greater than 0, at most count entries are returned.
+func (stream *Stream) After(id ID, count int) []Entry {
+	start, ok := id.next()
+	if !ok {
+		return []Entry{}
+	}
+	return stream.Range(start, maxID, count, false)
+}
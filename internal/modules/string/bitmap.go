@@ -0,0 +1,355 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package str
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/echovault/echovault/internal"
+	"math"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+// maxBitOffset is the largest bit offset accepted by SETBIT, which caps bitmaps at 512MB.
+const maxBitOffset = math.MaxUint32
+
+// bitmapBytes returns the bytes of a string value. Numeric values are converted back to their string form.
+func bitmapBytes(value interface{}) ([]byte, bool) {
+	switch v := value.(type) {
+	case string:
+		return []byte(v), true
+	case int:
+		return []byte(strconv.Itoa(v)), true
+	case float64:
+		return []byte(strconv.FormatFloat(v, 'f', -1, 64)), true
+	default:
+		return nil, false
+	}
+}
+
+// getBitmap returns the bytes of the string at the key. Returns nil if the key does not exist.
+func getBitmap(ctx context.Context, params internal.HandlerFuncParams, key string) ([]byte, error) {
+	if !params.KeyExists(ctx, key) {
+		return nil, nil
+	}
+	if _, err := params.KeyRLock(ctx, key); err != nil {
+		return nil, err
+	}
+	defer params.KeyRUnlock(ctx, key)
+
+	b, ok := bitmapBytes(params.GetValue(ctx, key))
+	if !ok {
+		return nil, fmt.Errorf("value at key %s is not a string", key)
+	}
+	return b, nil
+}
+
+// getBit returns the bit at the offset, counting from the most significant bit of the first byte.
+// Bits past the end of the bitmap are 0.
+func getBit(b []byte, offset int) int {
+	if offset/8 >= len(b) {
+		return 0
+	}
+	return int(b[offset/8]>>(7-offset%8)) & 1
+}
+
+// normaliseRange converts the start and end indices, which may be negative to count from the end,
+// into indices within [0, length). Returns false if the range is empty.
+func normaliseRange(start, end, length int) (int, int, bool) {
+	if start < 0 {
+		start = max(length+start, 0)
+	}
+	if end < 0 {
+		end = max(length+end, 0)
+	}
+	if end >= length {
+		end = length - 1
+	}
+	return start, end, length > 0 && start <= end
+}
+
+// parseBitRange parses the optional [start [end [BYTE | BIT]]] arguments of BITCOUNT and BITPOS.
+// The returned range is in bits. endGiven reports whether the end index was provided.
+func parseBitRange(args []string, length int) (start int, end int, endGiven bool, ok bool, err error) {
+	indices := make([]int, 0, 2)
+	for _, arg := range args[:min(len(args), 2)] {
+		i, err := strconv.Atoi(arg)
+		if err != nil {
+			return 0, 0, false, false, errors.New("value is not an integer or out of range")
+		}
+		indices = append(indices, i)
+	}
+
+	unit := "byte"
+	if len(args) == 3 {
+		unit = strings.ToLower(args[2])
+		if unit != "byte" && unit != "bit" {
+			return 0, 0, false, false, errors.New("syntax error")
+		}
+	}
+
+	start, end = 0, math.MaxInt
+	if len(indices) > 0 {
+		start = indices[0]
+	}
+	if len(indices) > 1 {
+		end, endGiven = indices[1], true
+	}
+
+	if unit == "bit" {
+		start, end, ok = normaliseRange(start, end, length*8)
+		return start, end, endGiven, ok, nil
+	}
+	start, end, ok = normaliseRange(start, end, length)
+	return start * 8, end*8 + 7, endGiven, ok, nil
+}
+
+func handleSetBit(params internal.HandlerFuncParams) ([]byte, error) {
+	keys, err := setBitKeyFunc(params.Command)
+	if err != nil {
+		return nil, err
+	}
+
+	key := keys.WriteKeys[0]
+
+	offset, err := strconv.Atoi(params.Command[2])
+	if err != nil || offset < 0 || offset > maxBitOffset {
+		return nil, errors.New("bit offset is not an integer or out of range")
+	}
+	if params.Command[3] != "0" && params.Command[3] != "1" {
+		return nil, errors.New("bit is not an integer or out of range")
+	}
+
+	if !params.KeyExists(params.Context, key) {
+		if _, err = params.CreateKeyAndLock(params.Context, key); err != nil {
+			return nil, err
+		}
+	} else {
+		if _, err = params.KeyLock(params.Context, key); err != nil {
+			return nil, err
+		}
+	}
+	defer params.KeyUnlock(params.Context, key)
+
+	var b []byte
+	if value := params.GetValue(params.Context, key); value != nil {
+		var ok bool
+		if b, ok = bitmapBytes(value); !ok {
+			return nil, fmt.Errorf("value at key %s is not a string", key)
+		}
+	}
+
+	// Grow the bitmap with zero bytes so that it contains the offset.
+	if n := offset/8 + 1; n > len(b) {
+		b = append(b, make([]byte, n-len(b))...)
+	}
+
+	old := getBit(b, offset)
+	mask := byte(1 << (7 - offset%8))
+	if params.Command[3] == "1" {
+		b[offset/8] |= mask
+	} else {
+		b[offset/8] &^= mask
+	}
+
+	if err = params.SetValue(params.Context, key, string(b)); err != nil {
+		return nil, err
+	}
+
+	return []byte(fmt.Sprintf(":%d\r\n", old)), nil
+}
+
+func handleGetBit(params internal.HandlerFuncParams) ([]byte, error) {
+	keys, err := getBitKeyFunc(params.Command)
+	if err != nil {
+		return nil, err
+	}
+
+	offset, err := strconv.Atoi(params.Command[2])
+	if err != nil || offset < 0 || offset > maxBitOffset {
+		return nil, errors.New("bit offset is not an integer or out of range")
+	}
+
+	b, err := getBitmap(params.Context, params, keys.ReadKeys[0])
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(fmt.Sprintf(":%d\r\n", getBit(b, offset))), nil
+}
+
+func handleBitCount(params internal.HandlerFuncParams) ([]byte, error) {
+	keys, err := bitCountKeyFunc(params.Command)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := getBitmap(params.Context, params, keys.ReadKeys[0])
+	if err != nil {
+		return nil, err
+	}
+
+	start, end, _, ok, err := parseBitRange(params.Command[2:], len(b))
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return []byte(":0\r\n"), nil
+	}
+
+	count := 0
+	// Count the bits up to the first byte boundary, then the whole bytes, then the bits after the last boundary.
+	for ; start <= end && start%8 != 0; start++ {
+		count += getBit(b, start)
+	}
+	for ; start+7 <= end; start += 8 {
+		count += bits.OnesCount8(b[start/8])
+	}
+	for ; start <= end; start++ {
+		count += getBit(b, start)
+	}
+
+	return []byte(fmt.Sprintf(":%d\r\n", count)), nil
+}
+
+func handleBitPos(params internal.HandlerFuncParams) ([]byte, error) {
+	keys, err := bitPosKeyFunc(params.Command)
+	if err != nil {
+		return nil, err
+	}
+
+	if params.Command[2] != "0" && params.Command[2] != "1" {
+		return nil, errors.New("the bit argument must be 1 or 0")
+	}
+	bit := int(params.Command[2][0] - '0')
+
+	key := keys.ReadKeys[0]
+	if !params.KeyExists(params.Context, key) {
+		// A key that does not exist is treated as an empty string of clear bits.
+		if bit == 0 {
+			return []byte(":0\r\n"), nil
+		}
+		return []byte(":-1\r\n"), nil
+	}
+
+	b, err := getBitmap(params.Context, params, key)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end, endGiven, ok, err := parseBitRange(params.Command[3:], len(b))
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return []byte(":-1\r\n"), nil
+	}
+
+	for i := start; i <= end; i++ {
+		if getBit(b, i) == bit {
+			return []byte(fmt.Sprintf(":%d\r\n", i)), nil
+		}
+	}
+
+	// When looking for a clear bit without an explicit end, the bits past the end of the string are clear.
+	if bit == 0 && !endGiven {
+		return []byte(fmt.Sprintf(":%d\r\n", len(b)*8)), nil
+	}
+	return []byte(":-1\r\n"), nil
+}
+
+func handleBitOp(params internal.HandlerFuncParams) ([]byte, error) {
+	keys, err := bitOpKeyFunc(params.Command)
+	if err != nil {
+		return nil, err
+	}
+
+	op := strings.ToUpper(params.Command[1])
+	switch op {
+	default:
+		return nil, fmt.Errorf("unknown operation %s", params.Command[1])
+	case "AND", "OR", "XOR":
+	case "NOT":
+		if len(keys.ReadKeys) != 1 {
+			return nil, errors.New("BITOP NOT must be called with a single source key")
+		}
+	}
+
+	// Keys that do not exist are treated as empty strings, which are padded with zero bytes.
+	sources := make([][]byte, len(keys.ReadKeys))
+	length := 0
+	for i, key := range keys.ReadKeys {
+		if sources[i], err = getBitmap(params.Context, params, key); err != nil {
+			return nil, err
+		}
+		length = max(length, len(sources[i]))
+	}
+
+	res := make([]byte, length)
+	for i := range res {
+		value := func(source []byte) byte {
+			if i < len(source) {
+				return source[i]
+			}
+			return 0
+		}
+		res[i] = value(sources[0])
+		for _, source := range sources[1:] {
+			switch op {
+			case "AND":
+				res[i] &= value(source)
+			case "OR":
+				res[i] |= value(source)
+			case "XOR":
+				res[i] ^= value(source)
+			}
+		}
+		if op == "NOT" {
+			res[i] = ^res[i]
+		}
+	}
+
+	destination := keys.WriteKeys[0]
+
+	// An empty result deletes the destination key.
+	if length == 0 {
+		if params.KeyExists(params.Context, destination) {
+			if err = params.DeleteKey(params.Context, destination); err != nil {
+				return nil, err
+			}
+		}
+		return []byte(":0\r\n"), nil
+	}
+
+	if !params.KeyExists(params.Context, destination) {
+		if _, err = params.CreateKeyAndLock(params.Context, destination); err != nil {
+			return nil, err
+		}
+	} else {
+		if _, err = params.KeyLock(params.Context, destination); err != nil {
+			return nil, err
+		}
+	}
+	defer params.KeyUnlock(params.Context, destination)
+
+	if err = params.SetValue(params.Context, destination, string(res)); err != nil {
+		return nil, err
+	}
+
+	return []byte(fmt.Sprintf(":%d\r\n", length)), nil
+}
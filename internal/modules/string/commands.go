@@ -19,6 +19,10 @@ import (
 	"fmt"
 	"github.com/echovault/echovault/internal"
 	"github.com/echovault/echovault/internal/constants"
+	"math"
+	"strconv"
+	"strings"
+	"time"
 )
 
 func handleSetRange(params internal.HandlerFuncParams) ([]byte, error) {
@@ -34,9 +38,27 @@ func handleSetRange(params internal.HandlerFuncParams) ([]byte, error) {
 		return nil, errors.New("offset must be an integer")
 	}
 
+	// The echovault protocol-compat level keeps the legacy behaviour of prepending and appending the string.
+	legacy := !internal.IsRedisCompat(params.GetConfigParameters()["protocol-compat"])
+	if !legacy && offset < 0 {
+		return nil, errors.New("offset is out of range")
+	}
+
 	newStr := params.Command[3]
 
+	// The string is padded up to the offset, so reject offsets that would grow it past the maximum size.
+	if !legacy && len(newStr) > 0 && offset > maxStringLength-len(newStr) {
+		return nil, errors.New("string exceeds maximum allowed size")
+	}
+
 	if !params.KeyExists(params.Context, key) {
+		// An empty value does not create the key.
+		if !legacy && len(newStr) == 0 {
+			return []byte(":0\r\n"), nil
+		}
+		if !legacy {
+			newStr = setRange("", offset, newStr)
+		}
 		if _, err = params.CreateKeyAndLock(params.Context, key); err != nil {
 			return nil, err
 		}
@@ -57,6 +79,18 @@ func handleSetRange(params internal.HandlerFuncParams) ([]byte, error) {
 		return nil, fmt.Errorf("value at key %s is not a string", key)
 	}
 
+	if !legacy {
+		// An empty value leaves the string unchanged.
+		if len(newStr) == 0 {
+			return []byte(fmt.Sprintf(":%d\r\n", len(str))), nil
+		}
+		newStr = setRange(str, offset, newStr)
+		if err = params.SetValue(params.Context, key, newStr); err != nil {
+			return nil, err
+		}
+		return []byte(fmt.Sprintf(":%d\r\n", len(newStr))), nil
+	}
+
 	// If the offset  >= length of the string, append the new string to the old one.
 	if offset >= len(str) {
 		newStr = str + newStr
@@ -96,6 +130,252 @@ func handleSetRange(params internal.HandlerFuncParams) ([]byte, error) {
 	return []byte(fmt.Sprintf(":%d\r\n", len(strRunes))), nil
 }
 
+// maxStringLength is the largest string in bytes that SETRANGE can create, which is 512MB.
+const maxStringLength = 512 * 1024 * 1024
+
+// setRange overwrites the bytes of str starting at offset with value.
+// If the offset is past the end of str, str is padded with zero bytes up to the offset.
+func setRange(str string, offset int, value string) string {
+	b := []byte(str)
+	if end := offset + len(value); end > len(b) {
+		b = append(b, make([]byte, end-len(b))...)
+	}
+	copy(b[offset:], value)
+	return string(b)
+}
+
+// handleSetIf replaces the value at the key with the new value only if the current value equals the expected value.
+// The previous value is always returned so the caller can tell whether the swap took place.
+func handleSetIf(params internal.HandlerFuncParams) ([]byte, error) {
+	keys, err := setIfKeyFunc(params.Command)
+	if err != nil {
+		return nil, err
+	}
+
+	key := keys.WriteKeys[0]
+	expected, newValue := params.Command[2], params.Command[3]
+
+	var expireAt time.Time
+	if len(params.Command) == 6 {
+		if !strings.EqualFold(params.Command[4], "ex") {
+			return nil, fmt.Errorf("unknown option %s", params.Command[4])
+		}
+		seconds, err := strconv.ParseInt(params.Command[5], 10, 64)
+		if err != nil || seconds <= 0 {
+			return nil, errors.New("ttl must be a positive integer")
+		}
+		expireAt = params.GetClock().Now().Add(time.Duration(seconds) * time.Second)
+	}
+
+	// A key that does not exist never matches the expected value.
+	if !params.KeyExists(params.Context, key) {
+		return []byte("$-1\r\n"), nil
+	}
+
+	if _, err = params.KeyLock(params.Context, key); err != nil {
+		return nil, err
+	}
+	defer params.KeyUnlock(params.Context, key)
+
+	// SET stores numeric strings as int or float64, so compare the string representation.
+	var current string
+	switch value := params.GetValue(params.Context, key).(type) {
+	case string, int, float64:
+		current = fmt.Sprintf("%v", value)
+	default:
+		return nil, fmt.Errorf("value at key %s is not a string", key)
+	}
+
+	if current == expected {
+		if err = params.SetValue(params.Context, key, internal.AdaptType(newValue)); err != nil {
+			return nil, err
+		}
+		if !expireAt.IsZero() {
+			params.SetExpiry(params.Context, key, expireAt, false)
+		}
+	}
+
+	return []byte(fmt.Sprintf("$%d\r\n%s\r\n", len(current), current)), nil
+}
+
+func handleAppend(params internal.HandlerFuncParams) ([]byte, error) {
+	keys, err := appendKeyFunc(params.Command)
+	if err != nil {
+		return nil, err
+	}
+
+	key := keys.WriteKeys[0]
+	suffix := params.Command[2]
+
+	if !params.KeyExists(params.Context, key) {
+		if _, err = params.CreateKeyAndLock(params.Context, key); err != nil {
+			return nil, err
+		}
+		defer params.KeyUnlock(params.Context, key)
+		if err = params.SetValue(params.Context, key, suffix); err != nil {
+			return nil, err
+		}
+		return []byte(fmt.Sprintf(":%d\r\n", len(suffix))), nil
+	}
+
+	if _, err = params.KeyLock(params.Context, key); err != nil {
+		return nil, err
+	}
+	defer params.KeyUnlock(params.Context, key)
+
+	str, ok := stringValue(params.GetValue(params.Context, key))
+	if !ok {
+		return nil, fmt.Errorf("value at key %s is not a string", key)
+	}
+
+	str += suffix
+	if err = params.SetValue(params.Context, key, str); err != nil {
+		return nil, err
+	}
+
+	return []byte(fmt.Sprintf(":%d\r\n", len(str))), nil
+}
+
+// handleIncrBy handles INCR, DECR, INCRBY and DECRBY.
+func handleIncrBy(params internal.HandlerFuncParams) ([]byte, error) {
+	command := strings.ToLower(params.Command[0])
+
+	var keys internal.KeyExtractionFuncResult
+	var err error
+	if command == "incr" || command == "decr" {
+		keys, err = incrKeyFunc(params.Command)
+	} else {
+		keys, err = incrByKeyFunc(params.Command)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := keys.WriteKeys[0]
+
+	increment := int64(1)
+	if len(params.Command) == 3 {
+		increment, err = strconv.ParseInt(params.Command[2], 10, 64)
+		if err != nil {
+			return nil, errors.New("increment must be an integer")
+		}
+	}
+	if command == "decr" || command == "decrby" {
+		if increment == math.MinInt64 {
+			return nil, errors.New("decrement would overflow")
+		}
+		increment = -increment
+	}
+
+	if !params.KeyExists(params.Context, key) {
+		if _, err = params.CreateKeyAndLock(params.Context, key); err != nil {
+			return nil, err
+		}
+	} else if _, err = params.KeyLock(params.Context, key); err != nil {
+		return nil, err
+	}
+	defer params.KeyUnlock(params.Context, key)
+
+	var current int64
+	if value := params.GetValue(params.Context, key); value != nil {
+		var ok bool
+		if current, ok = integerValue(value); !ok {
+			return nil, fmt.Errorf("value at key %s is not an integer or out of range", key)
+		}
+	}
+
+	if (increment > 0 && current > math.MaxInt64-increment) || (increment < 0 && current < math.MinInt64-increment) {
+		return nil, errors.New("increment or decrement would overflow")
+	}
+	current += increment
+
+	if err = params.SetValue(params.Context, key, int(current)); err != nil {
+		return nil, err
+	}
+
+	return []byte(fmt.Sprintf(":%d\r\n", current)), nil
+}
+
+func handleIncrByFloat(params internal.HandlerFuncParams) ([]byte, error) {
+	keys, err := incrByKeyFunc(params.Command)
+	if err != nil {
+		return nil, err
+	}
+
+	key := keys.WriteKeys[0]
+
+	increment, err := strconv.ParseFloat(params.Command[2], 64)
+	if err != nil || math.IsNaN(increment) || math.IsInf(increment, 0) {
+		return nil, errors.New("increment must be a float")
+	}
+
+	if !params.KeyExists(params.Context, key) {
+		if _, err = params.CreateKeyAndLock(params.Context, key); err != nil {
+			return nil, err
+		}
+	} else if _, err = params.KeyLock(params.Context, key); err != nil {
+		return nil, err
+	}
+	defer params.KeyUnlock(params.Context, key)
+
+	var current float64
+	switch value := params.GetValue(params.Context, key).(type) {
+	case nil:
+	case int:
+		current = float64(value)
+	case float64:
+		current = value
+	case string:
+		if current, err = strconv.ParseFloat(value, 64); err != nil || math.IsNaN(current) || math.IsInf(current, 0) {
+			return nil, fmt.Errorf("value at key %s is not a valid float", key)
+		}
+	default:
+		return nil, fmt.Errorf("value at key %s is not a valid float", key)
+	}
+
+	current += increment
+	if math.IsNaN(current) || math.IsInf(current, 0) {
+		return nil, errors.New("increment would produce NaN or Infinity")
+	}
+
+	// The result is stored as a string, so that an integral result can be incremented with INCR and INCRBY.
+	result := strconv.FormatFloat(current, 'f', -1, 64)
+	if err = params.SetValue(params.Context, key, result); err != nil {
+		return nil, err
+	}
+
+	return []byte(fmt.Sprintf("$%d\r\n%s\r\n", len(result), result)), nil
+}
+
+// stringValue returns the string representation of a string or numeric value.
+// Returns false if the value is neither.
+func stringValue(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case int:
+		return strconv.Itoa(v), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	default:
+		return "", false
+	}
+}
+
+// integerValue returns the value as a 64-bit integer if it's an integer or a string that holds one.
+// Returns false otherwise.
+func integerValue(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), true
+	case string:
+		i, err := strconv.ParseInt(v, 10, 64)
+		return i, err == nil
+	default:
+		return 0, false
+	}
+}
+
 func handleStrLen(params internal.HandlerFuncParams) ([]byte, error) {
 	keys, err := strLenKeyFunc(params.Command)
 	if err != nil {
@@ -132,7 +412,6 @@ func handleSubStr(params internal.HandlerFuncParams) ([]byte, error) {
 
 	start, startOk := internal.AdaptType(params.Command[2]).(int)
 	end, endOk := internal.AdaptType(params.Command[3]).(int)
-	reversed := false
 
 	if !startOk || !endOk {
 		return nil, errors.New("start and end indices must be integers")
@@ -152,6 +431,46 @@ func handleSubStr(params internal.HandlerFuncParams) ([]byte, error) {
 		return nil, fmt.Errorf("value at key %s is not a string", key)
 	}
 
+	var str string
+	if !internal.IsRedisCompat(params.GetConfigParameters()["protocol-compat"]) {
+		str = legacySubStr(value, start, end)
+	} else {
+		str = subStr(value, start, end)
+	}
+
+	return []byte(fmt.Sprintf("$%d\r\n%s\r\n", len(str), str)), nil
+}
+
+// subStr returns the substring between the start and end indices (both inclusive) following Redis GETRANGE.
+// Negative indices count from the end of the string and out of range indices are clamped to the string.
+// An empty string is returned when the range is inverted.
+func subStr(value string, start, end int) string {
+	if start < 0 {
+		start = len(value) + start
+	}
+	if end < 0 {
+		end = len(value) + end
+	}
+	if start < 0 {
+		start = 0
+	}
+	if end < 0 {
+		end = 0
+	}
+	if end >= len(value) {
+		end = len(value) - 1
+	}
+	if len(value) == 0 || start > end {
+		return ""
+	}
+	return value[start : end+1]
+}
+
+// legacySubStr returns the substring between the start and end indices.
+// When the range is inverted, the substring is reversed.
+func legacySubStr(value string, start, end int) string {
+	reversed := false
+
 	if start < 0 {
 		start = len(value) - internal.AbsInt(start)
 	}
@@ -182,7 +501,7 @@ func handleSubStr(params internal.HandlerFuncParams) ([]byte, error) {
 		str = res
 	}
 
-	return []byte(fmt.Sprintf("$%d\r\n%s\r\n", len(str), str)), nil
+	return str
 }
 
 func Commands() []internal.Command {
@@ -192,11 +511,81 @@ func Commands() []internal.Command {
 			Module:     constants.StringModule,
 			Categories: []string{constants.StringCategory, constants.WriteCategory, constants.SlowCategory},
 			Description: `(SETRANGE key offset value) 
-Overwrites part of a string value with another by offset. Creates the key if it doesn't exist.`,
+Overwrites part of a string value with another by offset. Creates the key if it doesn't exist.
+If the offset is past the end of the string, the string is padded with zero bytes up to the offset.`,
 			Sync:              true,
 			KeyExtractionFunc: setRangeKeyFunc,
 			HandlerFunc:       handleSetRange,
 		},
+		{
+			Command:    "setif",
+			Module:     constants.StringModule,
+			Categories: []string{constants.StringCategory, constants.WriteCategory, constants.FastCategory},
+			Description: `(SETIF key expected new [EX ttl])
+Atomically sets the key to the new value only if its current value equals the expected value,
+and optionally sets an expiry of ttl seconds when the value is replaced.
+Returns the previous value, or nil if the key does not exist.`,
+			Sync:              true,
+			KeyExtractionFunc: setIfKeyFunc,
+			HandlerFunc:       handleSetIf,
+		},
+		{
+			Command:           "append",
+			Module:            constants.StringModule,
+			Categories:        []string{constants.StringCategory, constants.WriteCategory, constants.FastCategory},
+			Description:       "(APPEND key value) Appends the value to the string at the key, creating the key if it doesn't exist.",
+			Sync:              true,
+			KeyExtractionFunc: appendKeyFunc,
+			HandlerFunc:       handleAppend,
+		},
+		{
+			Command:           "incr",
+			Module:            constants.StringModule,
+			Categories:        []string{constants.StringCategory, constants.WriteCategory, constants.FastCategory},
+			Description:       "(INCR key) Increments the integer at the key by 1. A key that doesn't exist is set to 0 first.",
+			Sync:              true,
+			KeyExtractionFunc: incrKeyFunc,
+			HandlerFunc:       handleIncrBy,
+		},
+		{
+			Command:           "decr",
+			Module:            constants.StringModule,
+			Categories:        []string{constants.StringCategory, constants.WriteCategory, constants.FastCategory},
+			Description:       "(DECR key) Decrements the integer at the key by 1. A key that doesn't exist is set to 0 first.",
+			Sync:              true,
+			KeyExtractionFunc: incrKeyFunc,
+			HandlerFunc:       handleIncrBy,
+		},
+		{
+			Command:    "incrby",
+			Module:     constants.StringModule,
+			Categories: []string{constants.StringCategory, constants.WriteCategory, constants.FastCategory},
+			Description: `(INCRBY key increment)
+Increments the integer at the key by the increment. A key that doesn't exist is set to 0 first.`,
+			Sync:              true,
+			KeyExtractionFunc: incrByKeyFunc,
+			HandlerFunc:       handleIncrBy,
+		},
+		{
+			Command:    "decrby",
+			Module:     constants.StringModule,
+			Categories: []string{constants.StringCategory, constants.WriteCategory, constants.FastCategory},
+			Description: `(DECRBY key decrement)
+Decrements the integer at the key by the decrement. A key that doesn't exist is set to 0 first.`,
+			Sync:              true,
+			KeyExtractionFunc: incrByKeyFunc,
+			HandlerFunc:       handleIncrBy,
+		},
+		{
+			Command:    "incrbyfloat",
+			Module:     constants.StringModule,
+			Categories: []string{constants.StringCategory, constants.WriteCategory, constants.FastCategory},
+			Description: `(INCRBYFLOAT key increment)
+Increments the number at the key by the floating point increment. A key that doesn't exist is set to 0 first.`,
+			Sync:              true,
+			KeyExtractionFunc: incrByKeyFunc,
+			HandlerFunc:       handleIncrByFloat,
+		},
 		{
 			Command:           "strlen",
 			Module:            constants.StringModule,
@@ -224,5 +613,56 @@ Overwrites part of a string value with another by offset. Creates the key if it
 			KeyExtractionFunc: subStrKeyFunc,
 			HandlerFunc:       handleSubStr,
 		},
+		{
+			Command:    "setbit",
+			Module:     constants.StringModule,
+			Categories: []string{constants.BitmapCategory, constants.WriteCategory, constants.SlowCategory},
+			Description: `(SETBIT key offset value) Sets or clears the bit at the offset of the string value and returns
+the original bit. The string is created if it does not exist, and padded with zero bytes so that it contains the offset.`,
+			Sync:              true,
+			KeyExtractionFunc: setBitKeyFunc,
+			HandlerFunc:       handleSetBit,
+		},
+		{
+			Command:    "getbit",
+			Module:     constants.StringModule,
+			Categories: []string{constants.BitmapCategory, constants.ReadCategory, constants.FastCategory},
+			Description: `(GETBIT key offset) Returns the bit at the offset of the string value.
+Offsets past the end of the string, and keys that do not exist, return 0.`,
+			Sync:              false,
+			KeyExtractionFunc: getBitKeyFunc,
+			HandlerFunc:       handleGetBit,
+		},
+		{
+			Command:    "bitcount",
+			Module:     constants.StringModule,
+			Categories: []string{constants.BitmapCategory, constants.ReadCategory, constants.SlowCategory},
+			Description: `(BITCOUNT key [start end [BYTE | BIT]]) Returns the number of set bits in the string value.
+The start and end indices are byte indices by default, or bit indices with BIT. Negative indices count from the end.`,
+			Sync:              false,
+			KeyExtractionFunc: bitCountKeyFunc,
+			HandlerFunc:       handleBitCount,
+		},
+		{
+			Command:    "bitpos",
+			Module:     constants.StringModule,
+			Categories: []string{constants.BitmapCategory, constants.ReadCategory, constants.SlowCategory},
+			Description: `(BITPOS key bit [start [end [BYTE | BIT]]]) Returns the position of the first bit set to 1 or 0
+in the string value, or -1 if there is none. The start and end indices are byte indices by default, or bit indices with BIT.`,
+			Sync:              false,
+			KeyExtractionFunc: bitPosKeyFunc,
+			HandlerFunc:       handleBitPos,
+		},
+		{
+			Command:    "bitop",
+			Module:     constants.StringModule,
+			Categories: []string{constants.BitmapCategory, constants.WriteCategory, constants.SlowCategory},
+			Description: `(BITOP <AND | OR | XOR | NOT> destkey key [key ...]) Performs the bitwise operation between the strings
+and stores the result in destkey. Shorter strings are padded with zero bytes. NOT takes a single key.
+Returns the length of the result.`,
+			Sync:              true,
+			KeyExtractionFunc: bitOpKeyFunc,
+			HandlerFunc:       handleBitOp,
+		},
 	}
 }
@@ -0,0 +1,188 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transaction
+
+import (
+	"errors"
+	"github.com/echovault/echovault/internal"
+	"github.com/echovault/echovault/internal/constants"
+)
+
+func getTransaction(params internal.HandlerFuncParams) (*Transaction, error) {
+	tx, ok := params.Context.Value(internal.ContextTransaction("Transaction")).(*Transaction)
+	if !ok {
+		return nil, errors.New("transactions are only supported on client connections")
+	}
+	return tx, nil
+}
+
+func noKeysKeyFunc(cmd []string) (internal.KeyExtractionFuncResult, error) {
+	if len(cmd) != 1 {
+		return internal.KeyExtractionFuncResult{}, errors.New(constants.WrongArgsResponse)
+	}
+	return internal.KeyExtractionFuncResult{
+		Channels:  make([]string, 0),
+		ReadKeys:  make([]string, 0),
+		WriteKeys: make([]string, 0),
+	}, nil
+}
+
+func watchKeyFunc(cmd []string) (internal.KeyExtractionFuncResult, error) {
+	if len(cmd) < 2 {
+		return internal.KeyExtractionFuncResult{}, errors.New(constants.WrongArgsResponse)
+	}
+	return internal.KeyExtractionFuncResult{
+		Channels:  make([]string, 0),
+		ReadKeys:  cmd[1:],
+		WriteKeys: make([]string, 0),
+	}, nil
+}
+
+func handleMulti(params internal.HandlerFuncParams) ([]byte, error) {
+	if _, err := noKeysKeyFunc(params.Command); err != nil {
+		return nil, err
+	}
+	tx, err := getTransaction(params)
+	if err != nil {
+		return nil, err
+	}
+	if tx.active {
+		return nil, errors.New("MULTI calls can not be nested")
+	}
+	tx.active = true
+	return []byte(constants.OkResponse), nil
+}
+
+func handleExec(params internal.HandlerFuncParams) ([]byte, error) {
+	if _, err := noKeysKeyFunc(params.Command); err != nil {
+		return nil, err
+	}
+	tx, err := getTransaction(params)
+	if err != nil {
+		return nil, err
+	}
+	if !tx.active {
+		return nil, errors.New("EXEC without MULTI")
+	}
+
+	aborted, watched, queue := tx.aborted, tx.watched, tx.queue
+	tx.reset()
+
+	if aborted {
+		return nil, errors.New("EXECABORT Transaction discarded because of previous errors")
+	}
+	return params.ExecuteTransaction(params.Context, params.Connection, watched, queue)
+}
+
+func handleDiscard(params internal.HandlerFuncParams) ([]byte, error) {
+	if _, err := noKeysKeyFunc(params.Command); err != nil {
+		return nil, err
+	}
+	tx, err := getTransaction(params)
+	if err != nil {
+		return nil, err
+	}
+	if !tx.active {
+		return nil, errors.New("DISCARD without MULTI")
+	}
+	tx.reset()
+	return []byte(constants.OkResponse), nil
+}
+
+func handleWatch(params internal.HandlerFuncParams) ([]byte, error) {
+	keys, err := watchKeyFunc(params.Command)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := getTransaction(params)
+	if err != nil {
+		return nil, err
+	}
+	if tx.active {
+		return nil, errors.New("WATCH inside MULTI is not allowed")
+	}
+	for _, key := range keys.ReadKeys {
+		// Delete the key if it has expired, so that its expiry is not mistaken for a change.
+		params.KeyExists(params.Context, key)
+		tx.Watch(key, params.GetKeyVersion(key))
+	}
+	return []byte(constants.OkResponse), nil
+}
+
+func handleUnwatch(params internal.HandlerFuncParams) ([]byte, error) {
+	if _, err := noKeysKeyFunc(params.Command); err != nil {
+		return nil, err
+	}
+	tx, err := getTransaction(params)
+	if err != nil {
+		return nil, err
+	}
+	tx.watched = make(map[string]uint64)
+	return []byte(constants.OkResponse), nil
+}
+
+func Commands() []internal.Command {
+	return []internal.Command{
+		{
+			Command:    "multi",
+			Module:     constants.TransactionModule,
+			Categories: []string{constants.TransactionCategory, constants.FastCategory},
+			Description: `(MULTI) Start a transaction. The commands that follow are queued and run together by EXEC.
+Transactions are not supported in cluster mode.`,
+			Sync:              false,
+			KeyExtractionFunc: noKeysKeyFunc,
+			HandlerFunc:       handleMulti,
+		},
+		{
+			Command:    "exec",
+			Module:     constants.TransactionModule,
+			Categories: []string{constants.TransactionCategory, constants.SlowCategory},
+			Description: `(EXEC) Run the commands queued since MULTI, with no other command running in between.
+Returns an array with the reply of each command. Returns a nil reply without running the commands
+if any of the keys watched with WATCH has changed.`,
+			Sync:              false,
+			KeyExtractionFunc: noKeysKeyFunc,
+			HandlerFunc:       handleExec,
+		},
+		{
+			Command:           "discard",
+			Module:            constants.TransactionModule,
+			Categories:        []string{constants.TransactionCategory, constants.FastCategory},
+			Description:       "(DISCARD) Discard the commands queued since MULTI and unwatch all the keys.",
+			Sync:              false,
+			KeyExtractionFunc: noKeysKeyFunc,
+			HandlerFunc:       handleDiscard,
+		},
+		{
+			Command:    "watch",
+			Module:     constants.TransactionModule,
+			Categories: []string{constants.TransactionCategory, constants.FastCategory},
+			Description: `(WATCH key [key ...]) Watch the keys, so that the next EXEC on this connection
+is aborted if any of the keys is modified, deleted or expires before the EXEC.`,
+			Sync:              false,
+			KeyExtractionFunc: watchKeyFunc,
+			HandlerFunc:       handleWatch,
+		},
+		{
+			Command:           "unwatch",
+			Module:            constants.TransactionModule,
+			Categories:        []string{constants.TransactionCategory, constants.FastCategory},
+			Description:       "(UNWATCH) Forget all the keys watched with WATCH.",
+			Sync:              false,
+			KeyExtractionFunc: noKeysKeyFunc,
+			HandlerFunc:       handleUnwatch,
+		},
+	}
+}
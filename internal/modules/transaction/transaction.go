@@ -0,0 +1,61 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transaction
+
+// Transaction holds the MULTI/EXEC state of a single client connection.
+// A connection handles one command at a time, so the state is not safe for concurrent use.
+type Transaction struct {
+	active  bool              // True after MULTI, until EXEC or DISCARD.
+	aborted bool              // True when a command could not be queued. EXEC then discards the transaction.
+	queue   [][]byte          // The commands queued after MULTI, in order.
+	watched map[string]uint64 // The keys watched with WATCH, and their versions when they were watched.
+}
+
+func NewTransaction() *Transaction {
+	return &Transaction{
+		watched: make(map[string]uint64),
+	}
+}
+
+// Active returns true if the connection is between MULTI and EXEC or DISCARD.
+func (tx *Transaction) Active() bool {
+	return tx.active
+}
+
+// Queue adds the command to the transaction.
+func (tx *Transaction) Queue(message []byte) {
+	tx.queue = append(tx.queue, message)
+}
+
+// Abort marks the transaction so that EXEC discards it instead of running the queued commands.
+func (tx *Transaction) Abort() {
+	tx.aborted = true
+}
+
+// Watch records the version of the key, so that EXEC can check whether the key has changed.
+// If the key is already watched, the version from the first WATCH is kept.
+func (tx *Transaction) Watch(key string, version uint64) {
+	if _, ok := tx.watched[key]; !ok {
+		tx.watched[key] = version
+	}
+}
+
+// reset clears the transaction and the watched keys.
+func (tx *Transaction) reset() {
+	tx.active = false
+	tx.aborted = false
+	tx.queue = nil
+	tx.watched = make(map[string]uint64)
+}
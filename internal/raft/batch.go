@@ -0,0 +1,167 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raft
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/echovault/echovault/internal"
+	"github.com/hashicorp/raft"
+	"time"
+)
+
+// proposalTimeout is the time allowed for a batch of proposals to be applied.
+const proposalTimeout = 500 * time.Millisecond
+
+// maxInflightBatches is the number of batches that can be waiting to be committed at the same time.
+// Raft replicates the batches in flight together, and new proposals queue up for the next batch once the limit is reached.
+const maxInflightBatches = 8
+
+// proposal is a request waiting to be applied as part of a batch.
+type proposal struct {
+	request internal.ApplyRequest
+	result  chan proposalResult
+}
+
+type proposalResult struct {
+	response internal.ApplyResponse
+	err      error
+}
+
+// Propose applies the request to the raft log and returns the response of the FSM.
+// Requests proposed concurrently are applied together in a single log entry, so that one consensus round trip
+// is shared by all the requests in the batch.
+func (r *Raft) Propose(ctx context.Context, request internal.ApplyRequest) (internal.ApplyResponse, error) {
+	p := proposal{request: request, result: make(chan proposalResult, 1)}
+
+	select {
+	case r.proposals <- p:
+	case <-ctx.Done():
+		return internal.ApplyResponse{}, context.Cause(ctx)
+	case <-r.stopped:
+		return internal.ApplyResponse{}, errors.New("raft layer is shut down")
+	}
+
+	res := <-p.result
+	return res.response, res.err
+}
+
+// batchProposals applies the queued proposals in batches until the context is cancelled.
+// Up to maxInflightBatches batches are applied at the same time, so that a batch doesn't wait for the previous
+// one to be committed before it's replicated. While all of them are in flight, new proposals queue up and are
+// applied together in the next batch.
+func (r *Raft) batchProposals(ctx context.Context) {
+	maxBatch := int(r.options.Config.RaftBatchSize)
+	if maxBatch < 1 {
+		maxBatch = 1
+	}
+	inflight := make(chan struct{}, maxInflightBatches)
+
+	for {
+		var batch []proposal
+		select {
+		case <-ctx.Done():
+			return
+		case p := <-r.proposals:
+			batch = append(batch, p)
+		}
+
+		// Wait for a batch in flight to finish before collecting the rest of this one.
+		select {
+		case <-ctx.Done():
+			batch[0].result <- proposalResult{err: errors.New("raft layer is shut down")}
+			return
+		case inflight <- struct{}{}:
+		}
+
+	drain:
+		for len(batch) < maxBatch {
+			select {
+			case p := <-r.proposals:
+				batch = append(batch, p)
+			default:
+				break drain
+			}
+		}
+
+		applyFuture, err := r.applyBatch(batch)
+		if err != nil {
+			<-inflight
+			continue
+		}
+		go func(batch []proposal) {
+			defer func() { <-inflight }()
+			r.respond(batch, applyFuture)
+		}(batch)
+	}
+}
+
+// applyBatch submits the proposals to the raft log in a single log entry.
+// A batch of 1 is submitted as a plain request. If the batch cannot be submitted, each proposal is sent the error.
+func (r *Raft) applyBatch(batch []proposal) (raft.ApplyFuture, error) {
+	request := batch[0].request
+	if len(batch) > 1 {
+		request = internal.ApplyRequest{Type: "batch", Batch: make([]internal.ApplyRequest, len(batch))}
+		for i, p := range batch {
+			request.Batch[i] = p.request
+		}
+	}
+
+	b, err := json.Marshal(request)
+	if err != nil {
+		err = fmt.Errorf("could not parse apply request: %+v", err)
+		for _, p := range batch {
+			p.result <- proposalResult{err: err}
+		}
+		return nil, err
+	}
+
+	return r.raft.Apply(b, proposalTimeout), nil
+}
+
+// respond waits for the batch to be applied and sends each proposal its own response.
+func (r *Raft) respond(batch []proposal, applyFuture raft.ApplyFuture) {
+	fail := func(err error) {
+		for _, p := range batch {
+			p.result <- proposalResult{err: err}
+		}
+	}
+
+	if err := applyFuture.Error(); err != nil {
+		fail(err)
+		return
+	}
+
+	if len(batch) == 1 {
+		r, ok := applyFuture.Response().(internal.ApplyResponse)
+		if !ok {
+			fail(fmt.Errorf("unprocessable entity %v", r))
+			return
+		}
+		batch[0].result <- proposalResult{response: r}
+		return
+	}
+
+	responses, ok := applyFuture.Response().([]internal.ApplyResponse)
+	if !ok || len(responses) != len(batch) {
+		fail(fmt.Errorf("unprocessable entity %v", applyFuture.Response()))
+		return
+	}
+	for i, p := range batch {
+		p.result <- proposalResult{response: responses[i]}
+	}
+}
@@ -17,6 +17,7 @@ package raft
 import (
 	"encoding/json"
 	"github.com/echovault/echovault/internal"
+	"github.com/echovault/echovault/internal/clock"
 	"github.com/echovault/echovault/internal/config"
 	"github.com/hashicorp/raft"
 	"strconv"
@@ -25,6 +26,7 @@ import (
 
 type SnapshotOpts struct {
 	config                config.Config
+	clock                 clock.Clock
 	data                  map[string]internal.KeyData
 	startSnapshot         func()
 	finishSnapshot        func()
@@ -52,7 +54,7 @@ func (s *Snapshot) Persist(sink raft.SnapshotSink) error {
 	}
 
 	snapshotObject := internal.SnapshotObject{
-		State:                      internal.FilterExpiredKeys(s.options.data),
+		State:                      internal.FilterExpiredKeys(s.options.clock.Now(), s.options.data),
 		LatestSnapshotMilliseconds: int64(msec),
 	}
 
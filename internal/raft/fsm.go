@@ -19,6 +19,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/echovault/echovault/internal"
+	"github.com/echovault/echovault/internal/clock"
 	"github.com/echovault/echovault/internal/config"
 	"github.com/hashicorp/raft"
 	"io"
@@ -30,6 +31,7 @@ import (
 
 type FSMOpts struct {
 	Config                config.Config
+	Clock                 clock.Clock
 	GetState              func() map[string]internal.KeyData
 	GetCommand            func(command string) (internal.Command, error)
 	CreateKeyAndLock      func(ctx context.Context, key string) (bool, error)
@@ -40,7 +42,11 @@ type FSMOpts struct {
 	StartSnapshot         func()
 	FinishSnapshot        func()
 	SetLatestSnapshotTime func(msec int64)
+	StartRestore          func()
+	FinishRestore         func()
+	SetRestoreProgress    func(loaded, total int)
 	GetHandlerFuncParams  func(ctx context.Context, cmd []string, conn *net.Conn) internal.HandlerFuncParams
+	SetAppliedIndex       func(index uint64) // Called with the index of each log entry once it has been applied.
 }
 
 type FSM struct {
@@ -55,6 +61,10 @@ func NewFSM(opts FSMOpts) raft.FSM {
 
 // Apply Implements raft.FSM interface
 func (fsm *FSM) Apply(log *raft.Log) interface{} {
+	if fsm.options.SetAppliedIndex != nil {
+		defer fsm.options.SetAppliedIndex(log.Index)
+	}
+
 	switch log.Type {
 	default:
 		// No-Op
@@ -68,73 +78,88 @@ func (fsm *FSM) Apply(log *raft.Log) interface{} {
 			}
 		}
 
-		ctx := context.WithValue(context.Background(), internal.ContextServerID("ServerID"), request.ServerID)
-		ctx = context.WithValue(ctx, internal.ContextConnID("ConnectionID"), request.ConnectionID)
+		// A batch returns the response of each request in the batch, in order.
+		if strings.EqualFold(request.Type, "batch") {
+			responses := make([]internal.ApplyResponse, len(request.Batch))
+			for i, r := range request.Batch {
+				responses[i] = fsm.applyRequest(r)
+			}
+			return responses
+		}
+
+		return fsm.applyRequest(request)
+	}
+
+	return nil
+}
+
+// applyRequest applies a single command or delete-key request to the store.
+func (fsm *FSM) applyRequest(request internal.ApplyRequest) internal.ApplyResponse {
+	ctx := context.WithValue(context.Background(), internal.ContextServerID("ServerID"), request.ServerID)
+	ctx = context.WithValue(ctx, internal.ContextConnID("ConnectionID"), request.ConnectionID)
 
-		switch strings.ToLower(request.Type) {
-		default:
+	switch strings.ToLower(request.Type) {
+	default:
+		return internal.ApplyResponse{
+			Error:    fmt.Errorf("unsupported raft command type %s", request.Type),
+			Response: nil,
+		}
+
+	case "delete-key":
+		if err := fsm.options.DeleteKey(ctx, request.Key); err != nil {
 			return internal.ApplyResponse{
-				Error:    fmt.Errorf("unsupported raft command type %s", request.Type),
+				Error:    err,
 				Response: nil,
 			}
+		}
+		return internal.ApplyResponse{
+			Error:    nil,
+			Response: []byte("OK"),
+		}
 
-		case "delete-key":
-			if err := fsm.options.DeleteKey(ctx, request.Key); err != nil {
-				return internal.ApplyResponse{
-					Error:    err,
-					Response: nil,
-				}
-			}
+	case "command":
+		// Handle command
+		command, err := fsm.options.GetCommand(request.CMD[0])
+		if err != nil {
 			return internal.ApplyResponse{
-				Error:    nil,
-				Response: []byte("OK"),
-			}
-
-		case "command":
-			// Handle command
-			command, err := fsm.options.GetCommand(request.CMD[0])
-			if err != nil {
-				return internal.ApplyResponse{
-					Error:    err,
-					Response: nil,
-				}
+				Error:    err,
+				Response: nil,
 			}
+		}
 
-			handler := command.HandlerFunc
+		handler := command.HandlerFunc
 
-			sc, err := internal.GetSubCommand(command, request.CMD)
-			if err != nil {
-				return internal.ApplyResponse{
-					Error:    err,
-					Response: nil,
-				}
-			}
-			subCommand, ok := sc.(internal.SubCommand)
-			if ok {
-				handler = subCommand.HandlerFunc
+		sc, err := internal.GetSubCommand(command, request.CMD)
+		if err != nil {
+			return internal.ApplyResponse{
+				Error:    err,
+				Response: nil,
 			}
+		}
+		subCommand, ok := sc.(internal.SubCommand)
+		if ok {
+			handler = subCommand.HandlerFunc
+		}
 
-			if res, err := handler(fsm.options.GetHandlerFuncParams(ctx, request.CMD, nil)); err != nil {
-				return internal.ApplyResponse{
-					Error:    err,
-					Response: nil,
-				}
-			} else {
-				return internal.ApplyResponse{
-					Error:    nil,
-					Response: res,
-				}
+		if res, err := internal.CallHandler(handler, fsm.options.GetHandlerFuncParams(ctx, request.CMD, nil)); err != nil {
+			return internal.ApplyResponse{
+				Error:    err,
+				Response: nil,
+			}
+		} else {
+			return internal.ApplyResponse{
+				Error:    nil,
+				Response: res,
 			}
 		}
 	}
-
-	return nil
 }
 
 // Snapshot implements raft.FSM interface
 func (fsm *FSM) Snapshot() (raft.FSMSnapshot, error) {
 	return NewFSMSnapshot(SnapshotOpts{
 		config:                fsm.options.Config,
+		clock:                 fsm.options.Clock,
 		startSnapshot:         fsm.options.StartSnapshot,
 		finishSnapshot:        fsm.options.FinishSnapshot,
 		setLatestSnapshotTime: fsm.options.SetLatestSnapshotTime,
@@ -161,9 +186,15 @@ func (fsm *FSM) Restore(snapshot io.ReadCloser) error {
 		return err
 	}
 
+	// Client commands are rejected while the state is replaced.
+	fsm.options.StartRestore()
+	defer fsm.options.FinishRestore()
+
 	// Set state
 	ctx := context.Background()
-	for k, v := range internal.FilterExpiredKeys(data.State) {
+	state := internal.FilterExpiredKeys(fsm.options.Clock.Now(), data.State)
+	loaded := 0
+	for k, v := range state {
 		if _, err = fsm.options.CreateKeyAndLock(ctx, k); err != nil {
 			log.Fatal(err)
 		}
@@ -172,6 +203,8 @@ func (fsm *FSM) Restore(snapshot io.ReadCloser) error {
 		}
 		fsm.options.SetExpiry(ctx, k, v.ExpireAt, false)
 		fsm.options.KeyUnlock(ctx, k)
+		loaded++
+		fsm.options.SetRestoreProgress(loaded, len(state))
 	}
 	// Set latest snapshot milliseconds
 	fsm.options.SetLatestSnapshotTime(data.LatestSnapshotMilliseconds)
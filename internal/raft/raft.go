@@ -19,12 +19,16 @@ import (
 	"errors"
 	"fmt"
 	"github.com/echovault/echovault/internal"
+	"github.com/echovault/echovault/internal/clock"
 	"github.com/echovault/echovault/internal/config"
 	"github.com/echovault/echovault/internal/memberlist"
 	"log"
 	"net"
 	"os"
 	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/echovault/echovault/types"
@@ -34,6 +38,7 @@ import (
 
 type Opts struct {
 	Config                config.Config
+	Clock                 clock.Clock
 	EchoVault             types.EchoVault
 	CreateKeyAndLock      func(ctx context.Context, key string) (bool, error)
 	SetValue              func(ctx context.Context, key string, value interface{}) error
@@ -45,17 +50,32 @@ type Opts struct {
 	StartSnapshot         func()
 	FinishSnapshot        func()
 	SetLatestSnapshotTime func(msec int64)
+	StartRestore          func()
+	FinishRestore         func()
+	SetRestoreProgress    func(loaded, total int)
 	GetHandlerFuncParams  func(ctx context.Context, cmd []string, conn *net.Conn) internal.HandlerFuncParams
 }
 
 type Raft struct {
-	options Opts
-	raft    *raft.Raft
+	options   Opts
+	raft      *raft.Raft
+	proposals chan proposal   // Requests waiting to be applied in the next batch.
+	stopped   <-chan struct{} // Closed when the batcher stops.
+
+	raftConfig  *raft.Config
+	logs        raft.LogStore
+	leaseExpiry atomic.Int64 // Unix epoch in nanoseconds when the leader lease for reads expires.
+
+	appliedIndex atomic.Uint64 // The index of the last log entry applied to the FSM.
+	appliedMutex sync.Mutex
+	appliedCh    chan struct{} // Closed and replaced each time the FSM applies a log entry.
 }
 
 func NewRaft(opts Opts) *Raft {
 	return &Raft{
-		options: opts,
+		options:   opts,
+		proposals: make(chan proposal),
+		appliedCh: make(chan struct{}),
 	}
 }
 
@@ -94,21 +114,15 @@ func (r *Raft) RaftInit(ctx context.Context) {
 		}
 	}
 
-	addr := fmt.Sprintf("%s:%d", conf.BindAddr, conf.RaftBindPort)
+	// The raft transport listens on the cluster address, which can be separate from the client address.
+	addr := conf.RaftAddr()
 
 	advertiseAddr, err := net.ResolveTCPAddr("tcp", addr)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	raftTransport, err := raft.NewTCPTransport(
-		addr,
-		advertiseAddr,
-		10,
-		500*time.Millisecond,
-		os.Stdout,
-	)
-
+	raftTransport, err := newTransport(conf, advertiseAddr, os.Stdout)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -118,6 +132,7 @@ func (r *Raft) RaftInit(ctx context.Context) {
 		raftConfig,
 		NewFSM(FSMOpts{
 			Config:                r.options.Config,
+			Clock:                 r.options.Clock,
 			GetState:              r.options.GetState,
 			GetCommand:            r.options.GetCommand,
 			CreateKeyAndLock:      r.options.CreateKeyAndLock,
@@ -128,7 +143,11 @@ func (r *Raft) RaftInit(ctx context.Context) {
 			StartSnapshot:         r.options.StartSnapshot,
 			FinishSnapshot:        r.options.FinishSnapshot,
 			SetLatestSnapshotTime: r.options.SetLatestSnapshotTime,
+			StartRestore:          r.options.StartRestore,
+			FinishRestore:         r.options.FinishRestore,
+			SetRestoreProgress:    r.options.SetRestoreProgress,
 			GetHandlerFuncParams:  r.options.GetHandlerFuncParams,
+			SetAppliedIndex:       r.setAppliedIndex,
 		}),
 		logStore,
 		stableStore,
@@ -154,6 +173,12 @@ func (r *Raft) RaftInit(ctx context.Context) {
 	}
 
 	r.raft = raftServer
+	r.raftConfig = raftConfig
+	r.logs = logStore
+
+	// Start batching the proposals to the raft log.
+	r.stopped = ctx.Done()
+	go r.batchProposals(ctx)
 }
 
 func (r *Raft) Apply(cmd []byte, timeout time.Duration) raft.ApplyFuture {
@@ -168,6 +193,12 @@ func (r *Raft) isRaftFollower() bool {
 	return r.raft.State() == raft.Follower
 }
 
+// LeaderID returns the server ID of the current leader, or an empty ID if there's no known leader.
+func (r *Raft) LeaderID() raft.ServerID {
+	_, id := r.raft.LeaderWithID()
+	return id
+}
+
 func (r *Raft) HasJoinedCluster() bool {
 	isFollower := r.isRaftFollower()
 
@@ -217,6 +248,16 @@ func (r *Raft) RemoveServer(meta memberlist.NodeMeta) error {
 	return nil
 }
 
+// ReplicationState returns the current raft term, the index of the latest log entry applied to the FSM,
+// and the number of servers in the cluster configuration.
+func (r *Raft) ReplicationState() (term uint64, appliedIndex uint64, servers int) {
+	term, _ = strconv.ParseUint(r.raft.Stats()["term"], 10, 64)
+	if future := r.raft.GetConfiguration(); future.Error() == nil {
+		servers = len(future.Configuration().Servers)
+	}
+	return term, r.raft.AppliedIndex(), servers
+}
+
 func (r *Raft) TakeSnapshot() error {
 	return r.raft.Snapshot().Error()
 }
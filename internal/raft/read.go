@@ -0,0 +1,108 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raft
+
+import (
+	"context"
+	"errors"
+	"github.com/hashicorp/raft"
+	"strconv"
+	"time"
+)
+
+// readIndexTimeout is the time allowed for the FSM to catch up with the log before a read is served.
+const readIndexTimeout = 500 * time.Millisecond
+
+// ConfirmRead blocks until it's safe to serve a linearizable read on this node.
+// Returns an error if this node is not the leader or leadership could not be confirmed.
+//
+// If useLease is true and the leader lease is valid, the read is confirmed immediately.
+// Otherwise, leadership is confirmed with a quorum of the cluster (ReadIndex) and the lease is renewed.
+func (r *Raft) ConfirmRead(ctx context.Context, useLease bool) error {
+	if !r.IsRaftLeader() {
+		return errors.New("not cluster leader, cannot serve read")
+	}
+
+	if useLease && r.options.Clock.Now().UnixNano() < r.leaseExpiry.Load() {
+		return nil
+	}
+
+	// The lease starts before leadership is confirmed, as no other node can be elected
+	// until the followers have missed heartbeats for the lease duration from this point.
+	start := r.options.Clock.Now()
+	stats := r.raft.Stats()
+	commitIndex, _ := strconv.ParseUint(stats["commit_index"], 10, 64)
+	snapshotIndex, _ := strconv.ParseUint(stats["last_snapshot_index"], 10, 64)
+	readIndex := r.lastCommandIndex(commitIndex)
+
+	if err := r.raft.VerifyLeader().Error(); err != nil {
+		return err
+	}
+	r.leaseExpiry.Store(start.Add(r.leaseDuration()).UnixNano())
+
+	// Wait for the FSM to apply all the committed entries up to the read index.
+	// Entries that are covered by the latest snapshot are already in the FSM's state.
+	timeout := r.options.Clock.After(readIndexTimeout)
+	for {
+		r.appliedMutex.Lock()
+		applied := r.appliedCh
+		r.appliedMutex.Unlock()
+
+		if max(r.appliedIndex.Load(), snapshotIndex) >= readIndex {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		case <-timeout:
+			return errors.New("timed out waiting for the state to catch up with the raft log")
+		case <-applied:
+		}
+	}
+}
+
+// lastCommandIndex returns the index of the last command entry at or before the provided index.
+// Other entries, such as the no-op a new leader appends to the log, are never passed to the FSM,
+// so the FSM's applied index doesn't reach them.
+func (r *Raft) lastCommandIndex(index uint64) uint64 {
+	for ; index > 0; index-- {
+		var entry raft.Log
+		// Entries that have been compacted into a snapshot are covered by the snapshot index.
+		if err := r.logs.GetLog(index, &entry); err != nil || entry.Type == raft.LogCommand {
+			return index
+		}
+	}
+	return 0
+}
+
+// setAppliedIndex records the index of the last log entry applied to the FSM and wakes the reads
+// that are waiting for it.
+func (r *Raft) setAppliedIndex(index uint64) {
+	r.appliedIndex.Store(index)
+
+	r.appliedMutex.Lock()
+	defer r.appliedMutex.Unlock()
+	close(r.appliedCh)
+	r.appliedCh = make(chan struct{})
+}
+
+// leaseDuration returns how long the leader can serve reads after confirming its leadership.
+// Followers only start an election after missing heartbeats for the heartbeat timeout, and the leader
+// steps down after failing to contact a quorum for the leader lease timeout. The lease is the shorter
+// of the two, less the maximum clock skew between nodes.
+func (r *Raft) leaseDuration() time.Duration {
+	return min(r.raftConfig.HeartbeatTimeout, r.raftConfig.LeaderLeaseTimeout) - r.options.Config.LeaseClockSkew
+}
@@ -0,0 +1,105 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raft
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"github.com/echovault/echovault/internal/config"
+	"io"
+	"net"
+	"os"
+	"time"
+
+	"github.com/hashicorp/raft"
+)
+
+// tlsStreamLayer is a raft.StreamLayer that encrypts the raft traffic between the nodes with TLS.
+// Both sides of each connection present the cluster certificate and verify the other side with the cluster CA,
+// so only the nodes with a certificate signed by the cluster CA can join the raft transport.
+type tlsStreamLayer struct {
+	net.Listener
+	advertise net.Addr
+	config    *tls.Config
+}
+
+func newTLSStreamLayer(conf config.Config, advertise net.Addr) (*tlsStreamLayer, error) {
+	certificate, err := tls.LoadX509KeyPair(conf.ClusterCertKeyPair[0], conf.ClusterCertKeyPair[1])
+	if err != nil {
+		return nil, fmt.Errorf("could not load cluster certificate: %w", err)
+	}
+
+	ca, err := os.ReadFile(conf.ClusterCA)
+	if err != nil {
+		return nil, fmt.Errorf("could not read cluster ca: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if ok := pool.AppendCertsFromPEM(ca); !ok {
+		return nil, errors.New("could not parse cluster ca")
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    pool,
+		RootCAs:      pool,
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	listener, err := tls.Listen("tcp", conf.RaftAddr(), tlsConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tlsStreamLayer{
+		Listener:  listener,
+		advertise: advertise,
+		config:    tlsConfig,
+	}, nil
+}
+
+// Dial implements raft.StreamLayer.
+func (layer *tlsStreamLayer) Dial(address raft.ServerAddress, timeout time.Duration) (net.Conn, error) {
+	host, _, err := net.SplitHostPort(string(address))
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := layer.config.Clone()
+	tlsConfig.ServerName = host
+	return tls.DialWithDialer(&net.Dialer{Timeout: timeout}, "tcp", string(address), tlsConfig)
+}
+
+// Addr implements net.Listener, returning the address advertised to the other nodes.
+func (layer *tlsStreamLayer) Addr() net.Addr {
+	if layer.advertise != nil {
+		return layer.advertise
+	}
+	return layer.Listener.Addr()
+}
+
+// newTransport creates the raft transport on the raft port. The transport uses TLS if cluster-tls is set,
+// otherwise it's a plain TCP transport.
+func newTransport(conf config.Config, advertise *net.TCPAddr, logOutput io.Writer) (raft.Transport, error) {
+	if !conf.ClusterTLS {
+		return raft.NewTCPTransport(conf.RaftAddr(), advertise, 10, 500*time.Millisecond, logOutput)
+	}
+	stream, err := newTLSStreamLayer(conf, advertise)
+	if err != nil {
+		return nil, err
+	}
+	return raft.NewNetworkTransport(stream, 10, 500*time.Millisecond, logOutput), nil
+}
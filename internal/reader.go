@@ -0,0 +1,188 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	// maxInlineSize is the longest line accepted for an inline command or a RESP header.
+	maxInlineSize = 64 * 1024
+	// maxMultiBulkLen is the largest number of elements accepted in a command array.
+	maxMultiBulkLen = 1024 * 1024
+	// maxBulkLen is the largest bulk string accepted in a command.
+	maxBulkLen = 512 * 1024 * 1024
+)
+
+// ErrProtocol is returned by MessageReader when the client sends data that is not valid RESP.
+// The connection cannot be recovered after a protocol error, as the reader no longer knows where
+// the next command starts.
+var ErrProtocol = errors.New("protocol error")
+
+// MessageReader reads client commands from a connection one at a time.
+// Commands are either RESP arrays of bulk strings or inline commands separated by spaces.
+// The reader keeps the unread bytes between calls, so several commands sent in a single write
+// (pipelining) are returned by consecutive calls to ReadMessage, and bulk strings larger than the
+// read buffer are read in full.
+type MessageReader struct {
+	reader *bufio.Reader
+}
+
+func NewMessageReader(r io.Reader) *MessageReader {
+	return &MessageReader{reader: bufio.NewReader(r)}
+}
+
+// Buffered returns the number of bytes that have been received but not yet read.
+// A non-zero value means the client has pipelined more commands.
+func (r *MessageReader) Buffered() int {
+	return r.reader.Buffered()
+}
+
+// ReadMessage returns the next command as a RESP array.
+// Array commands are returned exactly as they were received and inline commands are encoded as an array.
+// io.EOF is returned if the connection is closed between commands, and io.ErrUnexpectedEOF if it is
+// closed in the middle of one.
+func (r *MessageReader) ReadMessage() ([]byte, error) {
+	for {
+		b, err := r.reader.Peek(1)
+		if err != nil {
+			return nil, err
+		}
+
+		var message []byte
+		if b[0] == '*' {
+			message, err = r.readArray()
+		} else {
+			message, err = r.readInline()
+		}
+		if err != nil {
+			return nil, err
+		}
+		// Empty lines and empty arrays are skipped.
+		if message != nil {
+			return message, nil
+		}
+	}
+}
+
+func (r *MessageReader) readArray() ([]byte, error) {
+	line, err := r.readLine()
+	if err != nil {
+		return nil, err
+	}
+	length, err := strconv.Atoi(string(line[1:]))
+	if err != nil || length > maxMultiBulkLen {
+		return nil, fmt.Errorf("%w: invalid multibulk length", ErrProtocol)
+	}
+	if length <= 0 {
+		return nil, nil
+	}
+
+	message := make([]byte, 0, len(line)+2)
+	message = append(message, line...)
+	message = append(message, '\r', '\n')
+
+	for i := 0; i < length; i++ {
+		line, err = r.readLine()
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		if len(line) == 0 || line[0] != '$' {
+			if len(line) > 16 {
+				line = line[:16]
+			}
+			return nil, fmt.Errorf("%w: expected '$', got %q", ErrProtocol, line)
+		}
+		size, err := strconv.Atoi(string(line[1:]))
+		if err != nil || size < 0 || size > maxBulkLen {
+			return nil, fmt.Errorf("%w: invalid bulk length", ErrProtocol)
+		}
+
+		message = append(message, line...)
+		message = append(message, '\r', '\n')
+
+		// Read the bulk string along with its trailing CRLF. The buffer grows as data arrives
+		// so that a large length header cannot allocate memory on its own.
+		for remaining := size + 2; remaining > 0; {
+			n := min(remaining, maxInlineSize)
+			start := len(message)
+			message = append(message, make([]byte, n)...)
+			if _, err = io.ReadFull(r.reader, message[start:]); err != nil {
+				return nil, unexpectedEOF(err)
+			}
+			remaining -= n
+		}
+		if message[len(message)-2] != '\r' || message[len(message)-1] != '\n' {
+			return nil, fmt.Errorf("%w: bulk string is not terminated by CRLF", ErrProtocol)
+		}
+	}
+
+	return message, nil
+}
+
+func (r *MessageReader) readInline() ([]byte, error) {
+	line, err := r.readLine()
+	if err != nil {
+		return nil, err
+	}
+
+	args := strings.Fields(string(line))
+	if len(args) == 0 {
+		return nil, nil
+	}
+
+	return EncodeCommand(args), nil
+}
+
+// readLine reads up to the next LF and returns the line without the line terminator.
+func (r *MessageReader) readLine() ([]byte, error) {
+	var line []byte
+	for {
+		chunk, err := r.reader.ReadSlice('\n')
+		line = append(line, chunk...)
+		if len(line) > maxInlineSize {
+			return nil, fmt.Errorf("%w: too big inline request", ErrProtocol)
+		}
+		if err == nil {
+			break
+		}
+		if errors.Is(err, bufio.ErrBufferFull) {
+			continue
+		}
+		if errors.Is(err, io.EOF) && len(line) > 0 {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+
+	line = line[:len(line)-1]
+	if len(line) > 0 && line[len(line)-1] == '\r' {
+		line = line[:len(line)-1]
+	}
+	return line, nil
+}
+
+func unexpectedEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,113 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package replycache
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+)
+
+type entry struct {
+	command  string            // The encoded command that produced this reply.
+	versions map[string]uint64 // The version of each key read by the command when the reply was produced.
+	reply    []byte            // The pre-encoded RESP reply.
+}
+
+// Cache holds pre-encoded RESP replies for idempotent read commands.
+// Each reply is stored alongside the versions of the keys it was computed from.
+// A reply is only served while all of those keys still have the same version.
+type Cache struct {
+	mutex   sync.Mutex
+	size    int                      // Maximum number of replies held in the cache.
+	entries map[string]*list.Element // Map of encoded command to its element in the recency list.
+	recency *list.List               // Front of the list is the most recently used reply.
+	hits    atomic.Uint64
+	misses  atomic.Uint64
+}
+
+func NewCache(size int) *Cache {
+	return &Cache{
+		mutex:   sync.Mutex{},
+		size:    size,
+		entries: make(map[string]*list.Element),
+		recency: list.New(),
+	}
+}
+
+// Get returns the cached reply for the command if the versions of all the keys it read are unchanged.
+// getVersion is used to retrieve the current version of each key.
+func (cache *Cache) Get(command string, getVersion func(key string) uint64) ([]byte, bool) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	element, ok := cache.entries[command]
+	if !ok {
+		cache.misses.Add(1)
+		return nil, false
+	}
+
+	e := element.Value.(*entry)
+	for key, version := range e.versions {
+		if getVersion(key) != version {
+			// One of the keys has changed since the reply was cached, so the reply is stale.
+			cache.recency.Remove(element)
+			delete(cache.entries, command)
+			cache.misses.Add(1)
+			return nil, false
+		}
+	}
+
+	cache.recency.MoveToFront(element)
+	cache.hits.Add(1)
+	return e.reply, true
+}
+
+// Set stores the reply for the command along with the key versions it was computed from.
+// If the cache is full, the least recently used reply is dropped.
+func (cache *Cache) Set(command string, versions map[string]uint64, reply []byte) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	if cache.size <= 0 {
+		return
+	}
+
+	if element, ok := cache.entries[command]; ok {
+		element.Value = &entry{command: command, versions: versions, reply: reply}
+		cache.recency.MoveToFront(element)
+		return
+	}
+
+	for cache.recency.Len() >= cache.size {
+		oldest := cache.recency.Back()
+		cache.recency.Remove(oldest)
+		delete(cache.entries, oldest.Value.(*entry).command)
+	}
+
+	cache.entries[command] = cache.recency.PushFront(&entry{command: command, versions: versions, reply: reply})
+}
+
+// Len returns the number of replies currently held in the cache.
+func (cache *Cache) Len() int {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	return cache.recency.Len()
+}
+
+// Stats returns the number of cache hits and misses since the cache was created.
+func (cache *Cache) Stats() (hits uint64, misses uint64) {
+	return cache.hits.Load(), cache.misses.Load()
+}
@@ -0,0 +1,174 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package selftest
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+)
+
+const (
+	fsyncIterations  = 32               // The number of blocks written and synced to measure the fsync latency.
+	fsyncBlockSize   = 4096             // The size of each block written before a sync.
+	memoryBufferSize = 64 * 1024 * 1024 // The size of the buffer copied to measure the memory bandwidth.
+	memoryIterations = 8                // The number of times the buffer is copied.
+	lockOperations   = 1_000_000        // The total number of lock/unlock pairs carried out to measure lock throughput.
+)
+
+// Result holds the measurements of a self-test.
+//
+// Time is the time the self-test completed.
+//
+// FsyncAvg and FsyncMax are the average and the highest latency of writing a 4KB block to a file in the data
+// directory and syncing it to disk.
+//
+// MemoryBandwidth is the number of bytes per second copied between two 64MB buffers.
+//
+// LockThroughput is the number of mutex lock/unlock pairs per second with one goroutine per CPU contending for
+// the same mutex.
+type Result struct {
+	Time            time.Time
+	FsyncAvg        time.Duration
+	FsyncMax        time.Duration
+	MemoryBandwidth uint64
+	LockThroughput  uint64
+}
+
+// Tester runs self-tests and holds the result of the most recent one.
+type Tester struct {
+	running      sync.Mutex // Held while a self-test runs, so that concurrent runs don't skew each other's results.
+	mutex        sync.RWMutex
+	last         *Result
+	getDirectory func() string
+}
+
+// NewTester creates a Tester that measures the fsync latency in the directory returned by getDirectory.
+// If the directory is empty, the OS temporary directory is used.
+func NewTester(getDirectory func() string) *Tester {
+	return &Tester{getDirectory: getDirectory}
+}
+
+// Run measures the fsync latency, memory bandwidth and lock throughput of the host and records the result.
+func (tester *Tester) Run() (Result, error) {
+	if !tester.running.TryLock() {
+		return Result{}, errors.New("self-test already in progress")
+	}
+	defer tester.running.Unlock()
+
+	var result Result
+	var err error
+	if result.FsyncAvg, result.FsyncMax, err = measureFsync(tester.getDirectory()); err != nil {
+		return Result{}, fmt.Errorf("self-test fsync: %w", err)
+	}
+	result.MemoryBandwidth = measureMemoryBandwidth()
+	result.LockThroughput = measureLockThroughput()
+	result.Time = time.Now()
+
+	tester.mutex.Lock()
+	defer tester.mutex.Unlock()
+	tester.last = &result
+
+	return result, nil
+}
+
+// Last returns the result of the most recent self-test. The boolean is false if no self-test has completed.
+func (tester *Tester) Last() (Result, bool) {
+	tester.mutex.RLock()
+	defer tester.mutex.RUnlock()
+	if tester.last == nil {
+		return Result{}, false
+	}
+	return *tester.last, true
+}
+
+func measureFsync(directory string) (time.Duration, time.Duration, error) {
+	if directory == "" {
+		directory = os.TempDir()
+	}
+	if err := os.MkdirAll(directory, os.ModePerm); err != nil {
+		return 0, 0, err
+	}
+	f, err := os.CreateTemp(directory, "selftest-*")
+	if err != nil {
+		return 0, 0, err
+	}
+	defer func() {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+	}()
+
+	block := make([]byte, fsyncBlockSize)
+	var total, highest time.Duration
+	for i := 0; i < fsyncIterations; i++ {
+		start := time.Now()
+		if _, err = f.Write(block); err != nil {
+			return 0, 0, err
+		}
+		if err = f.Sync(); err != nil {
+			return 0, 0, err
+		}
+		elapsed := time.Since(start)
+		total += elapsed
+		highest = max(highest, elapsed)
+	}
+
+	return total / fsyncIterations, highest, nil
+}
+
+func measureMemoryBandwidth() uint64 {
+	src := make([]byte, memoryBufferSize)
+	dst := make([]byte, memoryBufferSize)
+	for i := range src {
+		src[i] = byte(i)
+	}
+
+	start := time.Now()
+	for i := 0; i < memoryIterations; i++ {
+		copy(dst, src)
+	}
+	elapsed := max(time.Since(start), time.Nanosecond)
+
+	return uint64(float64(memoryBufferSize*memoryIterations) / elapsed.Seconds())
+}
+
+func measureLockThroughput() uint64 {
+	workers := runtime.GOMAXPROCS(0)
+	operations := lockOperations / workers
+
+	var mutex sync.Mutex
+	var counter int
+	var wg sync.WaitGroup
+
+	start := time.Now()
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < operations; j++ {
+				mutex.Lock()
+				counter += 1
+				mutex.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	elapsed := max(time.Since(start), time.Nanosecond)
+
+	return uint64(float64(counter) / elapsed.Seconds())
+}
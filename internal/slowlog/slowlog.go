@@ -0,0 +1,150 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package slowlog
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// maxArgs is the number of arguments of a command that are kept in an entry.
+	maxArgs = 32
+	// maxArgLength is the number of bytes of each argument that are kept in an entry.
+	maxArgLength = 128
+)
+
+// Entry is a command recorded in the slow log.
+//
+// ID is a unique, increasing ID of the entry. IDs are not reused after the log is reset.
+//
+// Time is the time the command started executing.
+//
+// Duration is the time spent executing the command.
+//
+// Args are the command and its arguments. Commands with more than 32 arguments and arguments longer than 128 bytes
+// are truncated, and the number of omitted arguments or bytes is noted in the last argument.
+//
+// ClientAddr and ClientName are the address and name of the client that sent the command.
+// They're empty for commands executed through the embedded API.
+type Entry struct {
+	ID         uint64
+	Time       time.Time
+	Duration   time.Duration
+	Args       []string
+	ClientAddr string
+	ClientName string
+}
+
+// Log holds the most recent commands that took longer than the threshold to execute.
+// The threshold and the maximum number of entries are read on every command, so they can be changed at runtime.
+type Log struct {
+	mutex        sync.Mutex
+	nextID       uint64
+	entries      []Entry // Entries in the order they were recorded, oldest first.
+	getThreshold func() time.Duration
+	getMaxLen    func() int
+}
+
+// NewLog creates a slow log. Commands are recorded if they take at least as long as the duration returned by
+// getThreshold. A negative threshold disables the log and 0 records every command.
+// getMaxLen returns the maximum number of entries to keep. The oldest entries are dropped first.
+func NewLog(getThreshold func() time.Duration, getMaxLen func() int) *Log {
+	return &Log{
+		getThreshold: getThreshold,
+		getMaxLen:    getMaxLen,
+	}
+}
+
+// IsSlow returns true if a command that took d to execute would be recorded.
+func (slowLog *Log) IsSlow(d time.Duration) bool {
+	threshold := slowLog.getThreshold()
+	return threshold >= 0 && d >= threshold && slowLog.getMaxLen() > 0
+}
+
+// Record adds the command to the log if its duration reaches the threshold.
+func (slowLog *Log) Record(start time.Time, d time.Duration, args []string, clientAddr string, clientName string) {
+	if !slowLog.IsSlow(d) {
+		return
+	}
+	maxLen := slowLog.getMaxLen()
+
+	entry := Entry{
+		Time:       start,
+		Duration:   d,
+		Args:       truncateArgs(args),
+		ClientAddr: clientAddr,
+		ClientName: clientName,
+	}
+
+	slowLog.mutex.Lock()
+	defer slowLog.mutex.Unlock()
+
+	entry.ID = slowLog.nextID
+	slowLog.nextID += 1
+	slowLog.entries = append(slowLog.entries, entry)
+	if len(slowLog.entries) > maxLen {
+		slowLog.entries = append(slowLog.entries[:0:0], slowLog.entries[len(slowLog.entries)-maxLen:]...)
+	}
+}
+
+// Get returns up to count of the most recent entries, newest first. If count is negative, all entries are returned.
+func (slowLog *Log) Get(count int) []Entry {
+	slowLog.mutex.Lock()
+	defer slowLog.mutex.Unlock()
+
+	n := min(len(slowLog.entries), max(slowLog.getMaxLen(), 0))
+	if count >= 0 {
+		n = min(n, count)
+	}
+	entries := make([]Entry, 0, n)
+	for i := len(slowLog.entries) - 1; len(entries) < n; i-- {
+		entries = append(entries, slowLog.entries[i])
+	}
+	return entries
+}
+
+// Len returns the number of entries in the log.
+func (slowLog *Log) Len() int {
+	slowLog.mutex.Lock()
+	defer slowLog.mutex.Unlock()
+	return min(len(slowLog.entries), max(slowLog.getMaxLen(), 0))
+}
+
+// Reset removes all the entries from the log.
+func (slowLog *Log) Reset() {
+	slowLog.mutex.Lock()
+	defer slowLog.mutex.Unlock()
+	slowLog.entries = nil
+}
+
+func truncateArgs(args []string) []string {
+	n := len(args)
+	if n > maxArgs {
+		n = maxArgs - 1
+	}
+	truncated := make([]string, 0, min(len(args), maxArgs))
+	for _, arg := range args[:n] {
+		if len(arg) > maxArgLength {
+			arg = fmt.Sprintf("%s... (%d more bytes)", arg[:maxArgLength], len(arg)-maxArgLength)
+		}
+		truncated = append(truncated, arg)
+	}
+	if n < len(args) {
+		truncated = append(truncated, fmt.Sprintf("... (%d more arguments)", len(args)-n))
+	}
+	return truncated
+}
@@ -0,0 +1,140 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/echovault/echovault/internal"
+	"os"
+	"path"
+	"slices"
+	"time"
+)
+
+// KeyInfo describes a key held in a snapshot.
+type KeyInfo struct {
+	Key     string `json:"key"`
+	Type    string `json:"type"`
+	Size    int    `json:"size"`    // The size of the encoded value in bytes.
+	Length  int    `json:"length"`  // The number of elements in the value, or the length of a string value.
+	TTL     int64  `json:"ttl"`     // The remaining time to live in milliseconds, or -1 if the key does not expire.
+	Expired bool   `json:"expired"` // True if the key has expired and would be skipped when the snapshot is restored.
+}
+
+// Dump is the content of a snapshot as returned by Inspect.
+type Dump struct {
+	File                       string    `json:"file"`
+	LatestSnapshotMilliseconds int64     `json:"latestSnapshotMilliseconds"`
+	Keys                       []KeyInfo `json:"keys"`
+}
+
+// Inspect reads the snapshot at the provided path without restoring it, and returns its keys sorted by name.
+// The path is either a snapshot state file, or a data directory in which case the latest snapshot
+// in the manifest is read. now is used to work out the time to live of the keys.
+// The snapshot files are only read, so Inspect is safe to use on the data directory of a running server or on a backup.
+func Inspect(file string, now time.Time) (Dump, error) {
+	info, err := os.Stat(file)
+	if err != nil {
+		return Dump{}, err
+	}
+	if info.IsDir() {
+		if file, err = latestSnapshotFile(file); err != nil {
+			return Dump{}, err
+		}
+	}
+
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return Dump{}, err
+	}
+
+	// Decode the values as raw JSON, so that their encoded size can be reported.
+	var object struct {
+		State map[string]struct {
+			Value    json.RawMessage
+			ExpireAt time.Time
+		}
+		LatestSnapshotMilliseconds int64
+	}
+	if err = json.Unmarshal(b, &object); err != nil {
+		return Dump{}, fmt.Errorf("snapshot %s is corrupted: %+v", file, err)
+	}
+
+	dump := Dump{
+		File:                       file,
+		LatestSnapshotMilliseconds: object.LatestSnapshotMilliseconds,
+		Keys:                       make([]KeyInfo, 0, len(object.State)),
+	}
+	for encodedKey, data := range object.State {
+		key, err := internal.DecodeBinaryString(encodedKey)
+		if err != nil {
+			return Dump{}, fmt.Errorf("snapshot %s is corrupted: %+v", file, err)
+		}
+		keyInfo := KeyInfo{Key: key, Size: len(data.Value), TTL: -1}
+		keyInfo.Type, keyInfo.Length = describeValue(data.Value)
+		if data.ExpireAt != (time.Time{}) {
+			keyInfo.TTL = max(data.ExpireAt.Sub(now).Milliseconds(), 0)
+			keyInfo.Expired = !data.ExpireAt.After(now)
+		}
+		dump.Keys = append(dump.Keys, keyInfo)
+	}
+	slices.SortFunc(dump.Keys, func(a, b KeyInfo) int {
+		if a.Key < b.Key {
+			return -1
+		}
+		if a.Key > b.Key {
+			return 1
+		}
+		return 0
+	})
+
+	return dump, nil
+}
+
+// latestSnapshotFile returns the path of the latest snapshot in the manifest of the data directory.
+func latestSnapshotFile(directory string) (string, error) {
+	md, err := os.ReadFile(path.Join(directory, "snapshots", "manifest.bin"))
+	if err != nil {
+		return "", err
+	}
+	manifest := new(Manifest)
+	if err = json.Unmarshal(md, manifest); err != nil {
+		return "", fmt.Errorf("snapshot manifest is corrupted: %+v", err)
+	}
+	if manifest.LatestSnapshotMilliseconds == 0 {
+		return "", fmt.Errorf("no snapshot in %s", directory)
+	}
+	return path.Join(directory, "snapshots", fmt.Sprintf("%d", manifest.LatestSnapshotMilliseconds), "state.bin"), nil
+}
+
+// describeValue returns the type name and the length of an encoded value.
+// Strings and numbers are string values, objects are hashes and arrays are lists, as they're restored.
+func describeValue(value json.RawMessage) (string, int) {
+	var decoded interface{}
+	if err := json.Unmarshal(value, &decoded); err != nil {
+		return "unknown", 0
+	}
+	switch v := decoded.(type) {
+	default:
+		return "string", len(fmt.Sprintf("%v", v))
+	case string:
+		return "string", len(v)
+	case map[string]interface{}:
+		return "hash", len(v)
+	case []interface{}:
+		return "list", len(v)
+	}
+}
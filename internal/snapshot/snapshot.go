@@ -26,12 +26,16 @@ import (
 	"log"
 	"os"
 	"path"
+	"sync/atomic"
 	"time"
 )
 
 // This package contains the snapshot engine for standalone mode.
 // Snapshots in cluster mode will be handled using the raft package in the raft layer.
 
+// errNothingToSnapshot is returned by TakeSnapshot when the state has not changed since the latest snapshot.
+var errNothingToSnapshot = errors.New("nothing new to snapshot")
+
 type Manifest struct {
 	LatestSnapshotMilliseconds int64
 	LatestSnapshotHash         [16]byte
@@ -40,15 +44,17 @@ type Manifest struct {
 type Engine struct {
 	clock                     clock.Clock
 	changeCount               uint64
-	directory                 string
-	snapshotInterval          time.Duration
-	snapshotThreshold         uint64
+	getDirectoryFunc          func() string        // Returns the directory to store snapshots in.
+	getIntervalFunc           func() time.Duration // Returns the interval between checks for whether to take a snapshot.
+	getThresholdFunc          func() uint64        // Returns the number of changes that trigger a snapshot.
 	startSnapshotFunc         func()
 	finishSnapshotFunc        func()
 	getStateFunc              func() map[string]internal.KeyData
 	setLatestSnapshotTimeFunc func(msec int64)
 	getLatestSnapshotTimeFunc func() int64
 	setKeyDataFunc            func(key string, data internal.KeyData)
+	restoreProgressFunc       func(loaded, total int)
+	lastSnapshotFailed        atomic.Bool // True when the most recent snapshot attempt failed.
 }
 
 func WithClock(clock clock.Clock) func(engine *Engine) {
@@ -59,19 +65,43 @@ func WithClock(clock clock.Clock) func(engine *Engine) {
 
 func WithDirectory(directory string) func(engine *Engine) {
 	return func(engine *Engine) {
-		engine.directory = directory
+		engine.getDirectoryFunc = func() string { return directory }
+	}
+}
+
+// WithDirectoryFunc sets the function used to retrieve the snapshot directory.
+// The function is called every time a snapshot is taken or restored, so the directory can be updated at runtime.
+func WithDirectoryFunc(f func() string) func(engine *Engine) {
+	return func(engine *Engine) {
+		engine.getDirectoryFunc = f
 	}
 }
 
 func WithInterval(interval time.Duration) func(engine *Engine) {
 	return func(engine *Engine) {
-		engine.snapshotInterval = interval
+		engine.getIntervalFunc = func() time.Duration { return interval }
+	}
+}
+
+// WithIntervalFunc sets the function used to retrieve the snapshot interval.
+// The function is called before every interval, so the interval can be updated at runtime.
+func WithIntervalFunc(f func() time.Duration) func(engine *Engine) {
+	return func(engine *Engine) {
+		engine.getIntervalFunc = f
 	}
 }
 
 func WithThreshold(threshold uint64) func(engine *Engine) {
 	return func(engine *Engine) {
-		engine.snapshotThreshold = threshold
+		engine.getThresholdFunc = func() uint64 { return threshold }
+	}
+}
+
+// WithThresholdFunc sets the function used to retrieve the snapshot threshold.
+// The function is called at the end of every interval, so the threshold can be updated at runtime.
+func WithThresholdFunc(f func() uint64) func(engine *Engine) {
+	return func(engine *Engine) {
+		engine.getThresholdFunc = f
 	}
 }
 
@@ -111,13 +141,21 @@ func WithSetKeyDataFunc(f func(key string, data internal.KeyData)) func(engine *
 	}
 }
 
+// WithRestoreProgressFunc sets the function that's called with the number of keys restored so far
+// and the total number of keys in the snapshot.
+func WithRestoreProgressFunc(f func(loaded, total int)) func(engine *Engine) {
+	return func(engine *Engine) {
+		engine.restoreProgressFunc = f
+	}
+}
+
 func NewSnapshotEngine(options ...func(engine *Engine)) *Engine {
 	engine := &Engine{
 		clock:              clock.NewClock(),
 		changeCount:        0,
-		directory:          "",
-		snapshotInterval:   5 * time.Minute,
-		snapshotThreshold:  1000,
+		getDirectoryFunc:   func() string { return "" },
+		getIntervalFunc:    func() time.Duration { return 5 * time.Minute },
+		getThresholdFunc:   func() uint64 { return 1000 },
 		startSnapshotFunc:  func() {},
 		finishSnapshotFunc: func() {},
 		getStateFunc: func() map[string]internal.KeyData {
@@ -127,25 +165,30 @@ func NewSnapshotEngine(options ...func(engine *Engine)) *Engine {
 		getLatestSnapshotTimeFunc: func() int64 {
 			return 0
 		},
-		setKeyDataFunc: func(key string, data internal.KeyData) {},
+		setKeyDataFunc:      func(key string, data internal.KeyData) {},
+		restoreProgressFunc: func(loaded, total int) {},
 	}
 
 	for _, option := range options {
 		option(engine)
 	}
 
-	if engine.snapshotInterval != 0 {
-		go func() {
-			for {
-				<-engine.clock.After(engine.snapshotInterval)
-				if engine.changeCount == engine.snapshotThreshold {
-					if err := engine.TakeSnapshot(); err != nil {
-						log.Println(err)
-					}
+	go func() {
+		for {
+			interval := engine.getIntervalFunc()
+			if interval == 0 {
+				// Interval snapshots are disabled. Check again later in case the interval is updated.
+				<-engine.clock.After(1 * time.Second)
+				continue
+			}
+			<-engine.clock.After(interval)
+			if engine.changeCount == engine.getThresholdFunc() {
+				if err := engine.TakeSnapshot(); err != nil {
+					log.Println(err)
 				}
 			}
-		}()
-	}
+		}
+	}()
 
 	return engine
 }
@@ -154,10 +197,20 @@ func (engine *Engine) TakeSnapshot() error {
 	engine.startSnapshotFunc()
 	defer engine.finishSnapshotFunc()
 
+	err := engine.takeSnapshot()
+	engine.lastSnapshotFailed.Store(err != nil && !errors.Is(err, errNothingToSnapshot))
+	return err
+}
+
+func (engine *Engine) takeSnapshot() error {
 	// Extract current time
 	now := engine.clock.Now()
 	msec := now.UnixNano() / int64(time.Millisecond)
 
+	// Load the directory once so the whole snapshot is written to the same directory
+	// even if the directory is updated while the snapshot is in progress.
+	directory := engine.getDirectoryFunc()
+
 	// Update manifest file to indicate the latest snapshot.
 	// If manifest file does not exist, create it.
 	// Manifest object will contain the following information:
@@ -169,7 +222,7 @@ func (engine *Engine) TakeSnapshot() error {
 
 	var firstSnapshot bool // Tracks whether the snapshot being attempted is the first one
 
-	dirname := path.Join(engine.directory, "snapshots")
+	dirname := path.Join(directory, "snapshots")
 	if err := os.MkdirAll(dirname, os.ModePerm); err != nil {
 		log.Println(err)
 		return err
@@ -214,7 +267,7 @@ func (engine *Engine) TakeSnapshot() error {
 
 	// Get current state
 	snapshotObject := internal.SnapshotObject{
-		State:                      internal.FilterExpiredKeys(engine.getStateFunc()),
+		State:                      internal.FilterExpiredKeys(engine.clock.Now(), engine.getStateFunc()),
 		LatestSnapshotMilliseconds: engine.getLatestSnapshotTimeFunc(),
 	}
 	out, err := json.Marshal(snapshotObject)
@@ -225,7 +278,7 @@ func (engine *Engine) TakeSnapshot() error {
 
 	snapshotHash := md5.Sum(out)
 	if snapshotHash == manifest.LatestSnapshotHash {
-		return errors.New("nothing new to snapshot")
+		return errNothingToSnapshot
 	}
 
 	// Update the snapshotObject
@@ -267,7 +320,7 @@ func (engine *Engine) TakeSnapshot() error {
 	}
 
 	// Create snapshot directory
-	dirname = path.Join(engine.directory, "snapshots", fmt.Sprintf("%d", msec))
+	dirname = path.Join(directory, "snapshots", fmt.Sprintf("%d", msec))
 	if err := os.MkdirAll(dirname, os.ModePerm); err != nil {
 		return err
 	}
@@ -302,7 +355,7 @@ func (engine *Engine) TakeSnapshot() error {
 }
 
 func (engine *Engine) Restore() error {
-	mf, err := os.Open(path.Join(engine.directory, "snapshots", "manifest.bin"))
+	mf, err := os.Open(path.Join(engine.getDirectoryFunc(), "snapshots", "manifest.bin"))
 	if err != nil && errors.Is(err, fs.ErrNotExist) {
 		return errors.New("no snapshot manifest, skipping snapshot restore")
 	}
@@ -326,7 +379,7 @@ func (engine *Engine) Restore() error {
 	}
 
 	sf, err := os.Open(path.Join(
-		engine.directory,
+		engine.getDirectoryFunc(),
 		"snapshots",
 		fmt.Sprintf("%d", manifest.LatestSnapshotMilliseconds),
 		"state.bin"))
@@ -350,8 +403,12 @@ func (engine *Engine) Restore() error {
 
 	engine.setLatestSnapshotTimeFunc(snapshotObject.LatestSnapshotMilliseconds)
 
-	for key, data := range internal.FilterExpiredKeys(snapshotObject.State) {
+	state := internal.FilterExpiredKeys(engine.clock.Now(), snapshotObject.State)
+	loaded := 0
+	for key, data := range state {
 		engine.setKeyDataFunc(key, data)
+		loaded++
+		engine.restoreProgressFunc(loaded, len(state))
 	}
 
 	log.Println("successfully restored latest snapshot")
@@ -359,6 +416,47 @@ func (engine *Engine) Restore() error {
 	return nil
 }
 
+// Verify checks that the latest snapshot matches the hash recorded in the manifest, without restoring it.
+// Returns nil if there's no snapshot to verify.
+func (engine *Engine) Verify() error {
+	directory := engine.getDirectoryFunc()
+
+	md, err := os.ReadFile(path.Join(directory, "snapshots", "manifest.bin"))
+	if err != nil && errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	manifest := new(Manifest)
+	if err = json.Unmarshal(md, manifest); err != nil {
+		return fmt.Errorf("snapshot manifest is corrupted: %+v", err)
+	}
+	if manifest.LatestSnapshotMilliseconds == 0 {
+		return nil
+	}
+
+	sd, err := os.ReadFile(path.Join(
+		directory,
+		"snapshots",
+		fmt.Sprintf("%d", manifest.LatestSnapshotMilliseconds),
+		"state.bin"))
+	if err != nil {
+		return err
+	}
+	if md5.Sum(sd) != manifest.LatestSnapshotHash {
+		return fmt.Errorf("snapshot %d/state.bin does not match the manifest checksum", manifest.LatestSnapshotMilliseconds)
+	}
+
+	return nil
+}
+
+// LastSnapshotSucceeded returns false if the most recent snapshot attempt failed.
+func (engine *Engine) LastSnapshotSucceeded() bool {
+	return !engine.lastSnapshotFailed.Load()
+}
+
 func (engine *Engine) IncrementChangeCount() {
 	engine.changeCount += 1
 }
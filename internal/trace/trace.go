@@ -0,0 +1,203 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trace
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+)
+
+type contextKey string
+
+const (
+	commandKey     contextKey = "TracedCommand"
+	traceparentKey contextKey = "Traceparent"
+)
+
+// Entry is a line of the trace file.
+//
+// Seq is the position of the entry in the trace, starting at 1.
+//
+// Command is the executed command. It is empty for the mutations that are not caused by a command,
+// e.g. the deletion of expired keys.
+//
+// Reply is the RESP reply to the command, and Error is the error returned by the command.
+//
+// Modified and Deleted are the keys created or modified, and the keys deleted, by the command, in lexicographic order.
+//
+// Traceparent is the W3C traceparent of the caller's span, if the command was run with a context carrying one.
+type Entry struct {
+	Seq         uint64   `json:"seq"`
+	Command     []string `json:"command,omitempty"`
+	Reply       string   `json:"reply,omitempty"`
+	Error       string   `json:"error,omitempty"`
+	Modified    []string `json:"modified,omitempty"`
+	Deleted     []string `json:"deleted,omitempty"`
+	Traceparent string   `json:"traceparent,omitempty"`
+}
+
+// Command collects the keyspace mutations of a command while it runs.
+type Command struct {
+	mutex       sync.Mutex
+	modified    map[string]struct{}
+	deleted     map[string]struct{}
+	traceparent string
+}
+
+// Recorder appends an entry to the trace for every executed command, in the order the commands complete.
+// Each entry is written as a single line of JSON.
+type Recorder struct {
+	mutex   sync.Mutex
+	writer  io.WriteCloser
+	encoder *json.Encoder
+	seq     uint64
+}
+
+func NewRecorder(writer io.WriteCloser) *Recorder {
+	return &Recorder{
+		writer:  writer,
+		encoder: json.NewEncoder(writer),
+	}
+}
+
+// WithCommand returns a context that collects the keyspace mutations of the command run with it.
+func WithCommand(ctx context.Context) (context.Context, *Command) {
+	command := &Command{
+		modified:    make(map[string]struct{}),
+		deleted:     make(map[string]struct{}),
+		traceparent: Traceparent(ctx),
+	}
+	return context.WithValue(ctx, commandKey, command), command
+}
+
+// WithTraceparent returns a context that carries the W3C traceparent of the caller's span,
+// e.g. "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01".
+func WithTraceparent(ctx context.Context, traceparent string) (context.Context, error) {
+	if err := validateTraceparent(traceparent); err != nil {
+		return ctx, err
+	}
+	return context.WithValue(ctx, traceparentKey, traceparent), nil
+}
+
+// Traceparent returns the traceparent carried by the context, or an empty string if there is none.
+func Traceparent(ctx context.Context) string {
+	traceparent, _ := ctx.Value(traceparentKey).(string)
+	return traceparent
+}
+
+// validateTraceparent checks that the traceparent has the version-traceid-parentid-flags form of the W3C
+// Trace Context specification, with lowercase hex fields and non-zero trace and parent IDs.
+func validateTraceparent(traceparent string) error {
+	fields := strings.Split(traceparent, "-")
+	if len(fields) < 4 || len(fields[0]) != 2 || len(fields[1]) != 32 || len(fields[2]) != 16 || len(fields[3]) != 2 {
+		return errors.New("traceparent must be of the form version-traceid-parentid-flags")
+	}
+	// Only version 00 is fixed at 4 fields. Later versions may append fields.
+	if fields[0] == "ff" || (fields[0] == "00" && len(fields) != 4) {
+		return errors.New("invalid traceparent version")
+	}
+	for _, field := range fields[:4] {
+		if strings.ToLower(field) != field {
+			return errors.New("traceparent fields must be lowercase hex")
+		}
+		if _, err := hex.DecodeString(field); err != nil {
+			return errors.New("traceparent fields must be lowercase hex")
+		}
+	}
+	if strings.Trim(fields[1], "0") == "" || strings.Trim(fields[2], "0") == "" {
+		return errors.New("traceparent trace and parent IDs must not be all zeros")
+	}
+	return nil
+}
+
+// Record appends the entry of a completed command to the trace.
+func (recorder *Recorder) Record(cmd []string, reply []byte, err error, command *Command) {
+	entry := Entry{Command: cmd, Reply: string(reply), Traceparent: command.traceparent}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	command.mutex.Lock()
+	entry.Modified = sortedKeys(command.modified)
+	entry.Deleted = sortedKeys(command.deleted)
+	command.mutex.Unlock()
+	recorder.write(entry)
+}
+
+// Mutation records the modification or deletion of the key. If the context belongs to a traced command,
+// the key is added to the command's entry. Otherwise, the mutation is appended to the trace as its own entry.
+func (recorder *Recorder) Mutation(ctx context.Context, key string, deleted bool) {
+	if command, ok := ctx.Value(commandKey).(*Command); ok {
+		command.mutex.Lock()
+		defer command.mutex.Unlock()
+		// Only the final state of the key is recorded.
+		if deleted {
+			delete(command.modified, key)
+			command.deleted[key] = struct{}{}
+		} else {
+			delete(command.deleted, key)
+			command.modified[key] = struct{}{}
+		}
+		return
+	}
+
+	entry := Entry{Modified: []string{key}, Traceparent: Traceparent(ctx)}
+	if deleted {
+		entry = Entry{Deleted: []string{key}, Traceparent: Traceparent(ctx)}
+	}
+	recorder.write(entry)
+}
+
+// Close closes the trace file. No entries are recorded after the recorder is closed.
+func (recorder *Recorder) Close() error {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	if recorder.encoder == nil {
+		return nil
+	}
+	recorder.encoder = nil
+	return recorder.writer.Close()
+}
+
+func (recorder *Recorder) write(entry Entry) {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	if recorder.encoder == nil {
+		return
+	}
+	recorder.seq += 1
+	entry.Seq = recorder.seq
+	if err := recorder.encoder.Encode(entry); err != nil {
+		log.Printf("trace write error: %+v\n", err)
+	}
+}
+
+func sortedKeys(keys map[string]struct{}) []string {
+	if len(keys) == 0 {
+		return nil
+	}
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	slices.Sort(sorted)
+	return sorted
+}
@@ -16,7 +16,14 @@ package internal
 
 import (
 	"context"
+	"encoding/json"
 	"github.com/echovault/echovault/internal/clock"
+	"github.com/echovault/echovault/internal/keywait"
+	"github.com/echovault/echovault/internal/latency"
+	"github.com/echovault/echovault/internal/metrics"
+	"github.com/echovault/echovault/internal/selftest"
+	"github.com/echovault/echovault/internal/slowlog"
+	"github.com/echovault/echovault/types"
 	"net"
 	"time"
 )
@@ -26,15 +33,151 @@ type KeyData struct {
 	ExpireAt time.Time
 }
 
+// Cloner is implemented by data types that hold references to mutable data (e.g. sets and sorted sets).
+// Clone must return a deep copy of the value that shares no mutable data with the original.
+type Cloner interface {
+	Clone() interface{}
+}
+
+// TypeNamer is implemented by data types that are not plain strings, lists or hashes.
+// TypeName returns the name of the type as reported by the TYPE command (e.g. "set" or "zset").
+type TypeNamer interface {
+	TypeName() string
+}
+
+// EncodingNamer is implemented by data types with more than one internal representation (e.g. sets and sorted sets).
+// Encoding returns the name of the representation as reported by OBJECT ENCODING (e.g. "listpack").
+type EncodingNamer interface {
+	Encoding() string
+}
+
+// Exporter is implemented by data types that are not plain strings, lists or hashes.
+// Export returns a copy of the value made up of strings, slices and maps, so that it encodes to stable JSON.
+type Exporter interface {
+	Export() interface{}
+}
+
+// ExportRecord is the exported form of a key, as written by the EXPORT command.
+// TTL is the remaining time to live of the key in milliseconds, or -1 if the key has no expiry.
+type ExportRecord struct {
+	Key   string      `json:"key"`
+	Type  string      `json:"type"`
+	TTL   int64       `json:"ttl"`
+	Value interface{} `json:"value"`
+}
+
+// MemoryStats holds the memory usage and limit of the server, the size of the keyspace
+// and the results of keyspace compaction.
+type MemoryStats struct {
+	TotalAllocated     uint64 // The number of bytes currently allocated on the heap.
+	SystemMemory       uint64 // The number of bytes obtained from the OS.
+	MaxMemory          uint64 // The configured memory limit. 0 if there's no limit.
+	EvictionPolicy     string // The policy used to evict keys when the memory limit is reached.
+	KeysCount          int    // The number of keys currently in the store.
+	VolatileKeysCount  int    // The number of keys with an expiry time.
+	KeysPeak           int    // The highest number of keys in the store since the last compaction.
+	DefragRuns         uint64 // The number of keyspace compactions carried out.
+	DefragReleasedKeys uint64 // The total number of deleted key entries released by keyspace compactions.
+	DefragLastRun      int64  // Unix epoch in milliseconds of the last keyspace compaction. 0 if there was none.
+}
+
+// PersistenceInfo holds the status of the snapshot and AOF files.
+type PersistenceInfo struct {
+	LastSnapshotTime      int64  // Unix epoch in milliseconds of the latest snapshot. 0 if there was none.
+	LastSnapshotSucceeded bool   // False if the most recent snapshot attempt failed.
+	SnapshotIntegrityOK   bool   // False if the latest snapshot failed the checksum validation on startup.
+	LastRewriteSucceeded  bool   // False if the most recent AOF rewrite failed.
+	AOFIntegrityOK        bool   // False if the AOF log tail failed the validation on startup.
+	AOFQueueDepth         int64  // The number of write commands waiting to be written to the AOF log.
+	AOFRejectedWrites     uint64 // The number of write commands rejected because the AOF queue was full.
+	StallProtection       bool   // True while write commands are rejected because persistence has stalled.
+
+	Loading            bool    // True while a restore is in progress.
+	LoadingPercentage  float64 // The percentage of the keys and commands of the restore in progress that have been restored.
+	SnapshotInProgress bool    // True while a snapshot is being taken.
+	RewriteInProgress  bool    // True while the AOF log is being rewritten.
+	AOFCurrentSize     int64   // The number of bytes in the AOF preamble and log.
+	AOFBaseSize        int64   // The number of bytes in the AOF preamble, as of the last rewrite.
+	AOFLastSyncTime    int64   // Unix epoch in milliseconds of the last fsync of the AOF log. 0 if there was none.
+}
+
+// ServerInfo holds the general information about the server and its clients reported by INFO.
+type ServerInfo struct {
+	Version          string        // The version of the server.
+	Mode             string        // "standalone" or "cluster".
+	ServerID         string        // The configured server ID.
+	ProcessID        int           // The process ID of the server.
+	TCPPort          int           // The port of the TCP listener.
+	Uptime           time.Duration // The time since the server was created.
+	ConnectedClients int           // The number of clients connected to the TCP listeners.
+}
+
+// ReplicationInfo holds the replication ID and offset of the server.
+// In cluster mode, the offset is the raft applied index and the replication ID changes with the raft term.
+type ReplicationInfo struct {
+	Mode              string // "standalone" or "cluster".
+	Role              string // "master" in standalone mode or if this node is the raft leader, otherwise "slave".
+	MasterAddr        string // The client address of the raft leader if this node is a follower, empty if it's not known.
+	ConnectedReplicas int    // The number of followers if this node is the raft leader, otherwise 0.
+	ReplicationID     string // 40 character hexadecimal replication ID.
+	Offset            uint64 // The replication offset.
+	Term              uint64 // The current raft term. 0 in standalone mode.
+}
+
 type ContextServerID string
 type ContextConnID string
 
+// ContextListener is the context key of the name of the listener that accepted the connection.
+type ContextListener string
+
+// ContextTransaction is the context key of a connection's transaction state.
+type ContextTransaction string
+
+// ContextDisconnected is the context key of a context that's cancelled when the client of a connection disconnects.
+type ContextDisconnected string
+
 type ApplyRequest struct {
-	Type         string   `json:"Type"` // command | delete-key
+	Type         string   `json:"Type"` // command | delete-key | batch
 	ServerID     string   `json:"ServerID"`
 	ConnectionID string   `json:"ConnectionID"`
 	CMD          []string `json:"CMD"`
 	Key          string   `json:"Key"`
+	// The requests in a batch, applied in order. Only set when Type is "batch".
+	Batch []ApplyRequest `json:"Batch,omitempty"`
+}
+
+// MarshalJSON encodes the command and key with EncodeBinaryString so that binary arguments are preserved.
+func (request ApplyRequest) MarshalJSON() ([]byte, error) {
+	type applyRequest ApplyRequest
+	encoded := applyRequest(request)
+	encoded.Key = EncodeBinaryString(request.Key)
+	if request.CMD != nil {
+		encoded.CMD = make([]string, len(request.CMD))
+		for i, arg := range request.CMD {
+			encoded.CMD[i] = EncodeBinaryString(arg)
+		}
+	}
+	return json.Marshal(encoded)
+}
+
+// UnmarshalJSON reverses MarshalJSON.
+func (request *ApplyRequest) UnmarshalJSON(b []byte) error {
+	type applyRequest ApplyRequest
+	var decoded applyRequest
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		return err
+	}
+	var err error
+	if decoded.Key, err = DecodeBinaryString(decoded.Key); err != nil {
+		return err
+	}
+	for i, arg := range decoded.CMD {
+		if decoded.CMD[i], err = DecodeBinaryString(arg); err != nil {
+			return err
+		}
+	}
+	*request = ApplyRequest(decoded)
+	return nil
 }
 
 type ApplyResponse struct {
@@ -47,6 +190,43 @@ type SnapshotObject struct {
 	LatestSnapshotMilliseconds int64
 }
 
+// MarshalJSON encodes the snapshot state with EncodeState so that binary keys are preserved.
+func (snapshot SnapshotObject) MarshalJSON() ([]byte, error) {
+	state, err := EncodeState(snapshot.State)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(struct {
+		State                      json.RawMessage
+		LatestSnapshotMilliseconds int64
+	}{
+		State:                      state,
+		LatestSnapshotMilliseconds: snapshot.LatestSnapshotMilliseconds,
+	})
+}
+
+// UnmarshalJSON decodes the snapshot state in parallel with DecodeState.
+func (snapshot *SnapshotObject) UnmarshalJSON(b []byte) error {
+	var object struct {
+		State                      json.RawMessage
+		LatestSnapshotMilliseconds int64
+	}
+	if err := json.Unmarshal(b, &object); err != nil {
+		return err
+	}
+	snapshot.LatestSnapshotMilliseconds = object.LatestSnapshotMilliseconds
+	if len(object.State) == 0 || string(object.State) == "null" {
+		snapshot.State = make(map[string]KeyData)
+		return nil
+	}
+	state, err := DecodeState(object.State)
+	if err != nil {
+		return err
+	}
+	snapshot.State = state
+	return nil
+}
+
 type KeyExtractionFuncResult struct {
 	Channels  []string
 	ReadKeys  []string
@@ -59,6 +239,7 @@ type HandlerFuncParams struct {
 	Context               context.Context
 	Command               []string
 	Connection            *net.Conn
+	Replay                bool // True when the command is replayed from the AOF log on startup. Skip side effects such as notifications.
 	KeyLock               func(ctx context.Context, key string) (bool, error)
 	KeyUnlock             func(ctx context.Context, key string)
 	KeyRLock              func(ctx context.Context, key string) (bool, error)
@@ -66,18 +247,64 @@ type HandlerFuncParams struct {
 	KeyExists             func(ctx context.Context, key string) bool
 	CreateKeyAndLock      func(ctx context.Context, key string) (bool, error)
 	GetValue              func(ctx context.Context, key string) interface{}
-	SetValue              func(ctx context.Context, key string, value interface{}) error
+	SetValue              func(ctx context.Context, key string, value interface{}) error // Keeps the key's expiry time.
 	GetExpiry             func(ctx context.Context, key string) time.Time
 	SetExpiry             func(ctx context.Context, key string, expire time.Time, touch bool)
 	RemoveExpiry          func(ctx context.Context, key string)
 	DeleteKey             func(ctx context.Context, key string) error
+	DeleteLockedKey       func(ctx context.Context, key string) // Deletes a write-locked key and releases its lock.
+	ScanKeys              func(cursor uint64, count int, match func(key string) bool) ([]string, uint64)
 	GetClock              func() clock.Clock
+	GetLatencyRegistry    func() *latency.Registry
+	GetMetrics            func() *metrics.Registry
+	GetSlowLog            func() *slowlog.Log
+	GetSelfTester         func() *selftest.Tester
 	GetAllCommands        func() []Command
 	GetACL                func() interface{}
 	GetPubSub             func() interface{}
+	GetClientRegistry     func() interface{}
 	TakeSnapshot          func() error
 	RewriteAOF            func() error
 	GetLatestSnapshotTime func() int64
+	GetMemoryStats        func() MemoryStats
+	GetPersistenceInfo    func() PersistenceInfo
+	GetServerInfo         func() ServerInfo
+	GetReplicationInfo    func() ReplicationInfo
+	ChangeReplicationID   func()
+	GetConfigParameters   func() map[string]string
+	SetConfigParameter    func(parameter string, value string) error
+	WatchKeys             func(keys ...string) *keywait.Watcher
+	ForwardPublish        func(ctx context.Context, channel string, message string)
+	GetKeyVersion         func(key string) uint64
+	ExecuteTransaction    func(ctx context.Context, conn *net.Conn, watched map[string]uint64, queue [][]byte) ([]byte, error)
+	GetScripts            func() interface{}
+	ExecuteScript         func(ctx context.Context, conn *net.Conn, script string, keys []string, args []string) ([]byte, error)
+	// Returns a writer that streams the reply to the connection while it's written. Nil when the reply cannot be streamed.
+	NewReplyWriter func() *types.ResponseWriter
+	// Waits until no state copy is in progress and marks a state mutation in progress, returning the function that
+	// ends the mutation. Only set for blocking write commands, which do not wait for the state copy before they block.
+	BeginStateMutation func() func()
+}
+
+// WaitForStateCopy waits for a state copy in progress to finish before a blocking write command modifies the store,
+// like the non-blocking write commands do before they run. The returned function must be called once the modification
+// is complete. It returns immediately for the other commands, as they have already waited.
+func (params HandlerFuncParams) WaitForStateCopy() func() {
+	if params.BeginStateMutation == nil {
+		return func() {}
+	}
+	return params.BeginStateMutation()
+}
+
+// ReplyWriter returns the writer for the reply of a command that can return a very large reply, e.g. SMEMBERS.
+// When the command was sent on a connection, the reply may be written to the connection while it's being written,
+// so that it's not held in memory in full. The handler must return the writer's Bytes as the reply, and must only
+// call ReplyWriter once the command can no longer fail.
+func (params HandlerFuncParams) ReplyWriter() *types.ResponseWriter {
+	if params.NewReplyWriter == nil {
+		return new(types.ResponseWriter)
+	}
+	return params.NewReplyWriter()
 }
 
 type HandlerFunc func(params HandlerFuncParams) ([]byte, error)
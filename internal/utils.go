@@ -15,22 +15,29 @@
 package internal
 
 import (
-	"bufio"
 	"bytes"
 	"cmp"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/echovault/echovault/internal/constants"
+	"github.com/echovault/echovault/internal/glob"
+	"hash/fnv"
 	"io"
 	"log"
 	"math/big"
 	"net"
 	"reflect"
 	"runtime"
+	"runtime/debug"
 	"slices"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
 
 	"github.com/sethvargo/go-retry"
 	"github.com/tidwall/resp"
@@ -70,31 +77,6 @@ func Decode(raw []byte) ([]string, error) {
 	return res, nil
 }
 
-func ReadMessage(r io.Reader) ([]byte, error) {
-	reader := bufio.NewReader(r)
-
-	var res []byte
-
-	chunk := make([]byte, 8192)
-
-	for {
-		n, err := reader.Read(chunk)
-		if err != nil && errors.Is(err, io.EOF) {
-			break
-		}
-		if err != nil {
-			return nil, err
-		}
-		res = append(res, chunk...)
-		if n < len(chunk) {
-			break
-		}
-		clear(chunk)
-	}
-
-	return bytes.Trim(res, "\x00"), nil
-}
-
 func RetryBackoff(b retry.Backoff, maxRetries uint64, jitter, cappedDuration, maxDuration time.Duration) retry.Backoff {
 	backoff := b
 	if maxRetries > 0 {
@@ -149,6 +131,35 @@ func IsWriteCommand(command Command, subCommand SubCommand) bool {
 	return slices.Contains(append(command.Categories, subCommand.Categories...), constants.WriteCategory)
 }
 
+// CallHandler calls the command handler and recovers from any panic in the handler.
+// A panic is logged with the command name and stack trace, and returned as an "internal error" error
+// so that the server and the client connection stay alive. The command arguments are not logged
+// as they may contain sensitive values.
+func CallHandler(handler HandlerFunc, params HandlerFuncParams) (res []byte, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			name := ""
+			if len(params.Command) > 0 {
+				name = params.Command[0]
+			}
+			log.Printf("panic while handling command %s: %v\n%s", name, r, debug.Stack())
+			res, err = nil, errors.New("internal error")
+		}
+	}()
+	return handler(params)
+}
+
+// DeleteEmptyDestination is called by the *STORE commands when the computed result is empty.
+// Instead of storing an empty container, the destination key is deleted if it exists and 0 is returned.
+func DeleteEmptyDestination(params HandlerFuncParams, destination string) ([]byte, error) {
+	if params.KeyExists(params.Context, destination) {
+		if err := params.DeleteKey(params.Context, destination); err != nil {
+			return nil, err
+		}
+	}
+	return []byte(":0\r\n"), nil
+}
+
 func AbsInt(n int) int {
 	if n < 0 {
 		return -n
@@ -185,6 +196,26 @@ func ParseMemory(memory string) (uint64, error) {
 }
 
 // IsMaxMemoryExceeded checks whether we have exceeded the current maximum memory limit.
+// protocolCompat holds the protocol-compat level for the key extraction functions, which don't receive the
+// configuration. The level is shared by all the instances in the process, so the instance that was configured last takes effect.
+var protocolCompat atomic.Value
+
+// SetProtocolCompat sets the protocol-compat level that the key extraction functions parse commands with.
+func SetProtocolCompat(level string) {
+	protocolCompat.Store(level)
+}
+
+// ProtocolCompat returns the protocol-compat level set with SetProtocolCompat.
+func ProtocolCompat() string {
+	level, _ := protocolCompat.Load().(string)
+	return level
+}
+
+// IsRedisCompat reports whether the protocol-compat level follows Redis semantics.
+func IsRedisCompat(level string) bool {
+	return level == constants.ProtocolCompatRedis6 || level == constants.ProtocolCompatRedis7
+}
+
 func IsMaxMemoryExceeded(maxMemory uint64) bool {
 	if maxMemory == 0 {
 		return false
@@ -208,8 +239,8 @@ func IsMaxMemoryExceeded(maxMemory uint64) bool {
 	return memStats.HeapInuse >= maxMemory
 }
 
-// FilterExpiredKeys filters out keys that are already expired, so they are not persisted.
-func FilterExpiredKeys(state map[string]KeyData) map[string]KeyData {
+// FilterExpiredKeys filters out keys that are already expired at the provided time, so they are not persisted.
+func FilterExpiredKeys(now time.Time, state map[string]KeyData) map[string]KeyData {
 	var keysToDelete []string
 	for k, v := range state {
 		// Skip keys with no expiry time.
@@ -217,7 +248,7 @@ func FilterExpiredKeys(state map[string]KeyData) map[string]KeyData {
 			continue
 		}
 		// If the key is already expired, mark it for deletion.
-		if v.ExpireAt.Before(time.Now()) {
+		if v.ExpireAt.Before(now) {
 			keysToDelete = append(keysToDelete, k)
 		}
 	}
@@ -227,6 +258,286 @@ func FilterExpiredKeys(state map[string]KeyData) map[string]KeyData {
 	return state
 }
 
+// binaryStringPrefix marks a string that has been base64 encoded by EncodeBinaryString.
+const binaryStringPrefix = "\x00base64:"
+
+// EncodeBinaryString makes a string safe to store in JSON, which replaces invalid UTF-8 with U+FFFD.
+// Strings that are not valid UTF-8 are base64 encoded behind a prefix that starts with a null byte.
+// Strings that already start with the prefix are encoded as well, so that DecodeBinaryString is always exact.
+// Valid UTF-8 strings are returned unchanged, so existing snapshots and logs remain readable.
+func EncodeBinaryString(s string) string {
+	if utf8.ValidString(s) && !strings.HasPrefix(s, binaryStringPrefix) {
+		return s
+	}
+	return binaryStringPrefix + base64.StdEncoding.EncodeToString([]byte(s))
+}
+
+// DecodeBinaryString reverses EncodeBinaryString.
+func DecodeBinaryString(s string) (string, error) {
+	encoded, ok := strings.CutPrefix(s, binaryStringPrefix)
+	if !ok {
+		return s, nil
+	}
+	b, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("invalid binary string %q: %+v", s, err)
+	}
+	return string(b), nil
+}
+
+// EncodeState JSON encodes a store state. The keys are encoded with EncodeBinaryString so that keys
+// containing arbitrary bytes survive the round trip through DecodeState.
+func EncodeState(state map[string]KeyData) ([]byte, error) {
+	encoded := make(map[string]KeyData, len(state))
+	for key, data := range state {
+		encoded[EncodeBinaryString(key)] = data
+	}
+	return json.Marshal(encoded)
+}
+
+// DecodeState decodes a JSON encoded store state.
+// The keys are split into one shard per CPU by their hash, and the values of each shard are decoded in parallel
+// while the rest of the state is still being read.
+func DecodeState(b []byte) (map[string]KeyData, error) {
+	type entry struct {
+		key   string
+		value json.RawMessage
+	}
+
+	shards := make([]chan entry, runtime.GOMAXPROCS(0))
+	decoded := make([]map[string]KeyData, len(shards))
+	errs := make([]error, len(shards))
+	wg := sync.WaitGroup{}
+	for i := range shards {
+		shards[i] = make(chan entry, 64)
+		decoded[i] = make(map[string]KeyData)
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for e := range shards[i] {
+				// Keep draining the shard after an error so that the reader is never blocked.
+				if errs[i] != nil {
+					continue
+				}
+				data := KeyData{}
+				if err := json.Unmarshal(e.value, &data); err != nil {
+					errs[i] = fmt.Errorf("decode key %s: %+v", e.key, err)
+					continue
+				}
+				decoded[i][e.key] = data
+			}
+		}(i)
+	}
+
+	readErr := func() error {
+		defer func() {
+			for _, shard := range shards {
+				close(shard)
+			}
+		}()
+
+		decoder := json.NewDecoder(bytes.NewReader(b))
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		if token == nil {
+			return nil
+		}
+		if delim, ok := token.(json.Delim); !ok || delim != '{' {
+			return fmt.Errorf("expected state to be a JSON object, got %v", token)
+		}
+		for decoder.More() {
+			token, err = decoder.Token()
+			if err != nil {
+				return err
+			}
+			key, err := DecodeBinaryString(token.(string))
+			if err != nil {
+				return err
+			}
+			var value json.RawMessage
+			if err = decoder.Decode(&value); err != nil {
+				return err
+			}
+			h := fnv.New32a()
+			_, _ = h.Write([]byte(key))
+			shards[h.Sum32()%uint32(len(shards))] <- entry{key: key, value: value}
+		}
+		if _, err = decoder.Token(); err != nil {
+			return err
+		}
+		if _, err = decoder.Token(); err != io.EOF {
+			return errors.New("unexpected data after the state")
+		}
+		return nil
+	}()
+	wg.Wait()
+
+	if err := errors.Join(append([]error{readErr}, errs...)...); err != nil {
+		return nil, err
+	}
+
+	size := 0
+	for _, shard := range decoded {
+		size += len(shard)
+	}
+	state := make(map[string]KeyData, size)
+	for _, shard := range decoded {
+		for key, data := range shard {
+			state[key] = data
+		}
+	}
+	return state, nil
+}
+
+// CopyValue returns a deep copy of a value held in the store so that it can be read
+// without observing concurrent mutations to the original value.
+// Values that implement Cloner are copied using their Clone method.
+func CopyValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	default:
+		// Strings, integers and floats are immutable so they can be returned as is.
+		return v
+	case Cloner:
+		return v.Clone()
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(v))
+		for key, val := range v {
+			res[key] = CopyValue(val)
+		}
+		return res
+	case []interface{}:
+		res := make([]interface{}, len(v))
+		for i, val := range v {
+			res[i] = CopyValue(val)
+		}
+		return res
+	}
+}
+
+// TypeName returns the name of the type of a value held in the store, as reported by the TYPE command.
+func TypeName(value interface{}) string {
+	switch v := value.(type) {
+	default:
+		// Strings, integers and floats are all string values.
+		return "string"
+	case TypeNamer:
+		return v.TypeName()
+	case map[string]interface{}:
+		return "hash"
+	case []interface{}:
+		return "list"
+	}
+}
+
+// EncodingName returns the name of the internal representation of a value held in the store,
+// as reported by the OBJECT ENCODING command.
+func EncodingName(value interface{}) string {
+	switch v := value.(type) {
+	default:
+		return "raw"
+	case EncodingNamer:
+		return v.Encoding()
+	case TypeNamer:
+		// Data types with a single representation are reported by their type name, e.g. stream.
+		return v.TypeName()
+	case int:
+		return "int"
+	case float64:
+		return "embstr"
+	case string:
+		if len(v) <= 44 {
+			return "embstr"
+		}
+		return "raw"
+	case map[string]interface{}:
+		return "hashtable"
+	case []interface{}:
+		return "quicklist"
+	}
+}
+
+// ExportValue returns the exported form of a value held in the store, as written by the EXPORT command.
+// Strings, integers and floats are exported as strings, hashes as objects, lists as arrays,
+// and the other data types as the value returned by their Export method.
+func ExportValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	default:
+		return fmt.Sprintf("%v", v)
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case Exporter:
+		return v.Export()
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(v))
+		for field, val := range v {
+			res[field] = ExportValue(val)
+		}
+		return res
+	case []interface{}:
+		res := make([]interface{}, len(v))
+		for i, val := range v {
+			res[i] = ExportValue(val)
+		}
+		return res
+	}
+}
+
+// ScanOptions holds the cursor and options of the SCAN family of commands.
+type ScanOptions struct {
+	Cursor uint64
+	Match  *glob.Glob // Nil when no MATCH pattern was provided.
+	Count  int
+	Type   string // Only provided to SCAN. Empty when no TYPE was provided.
+}
+
+// ParseScanOptions parses the cursor and the MATCH, COUNT and TYPE options of the SCAN family of commands.
+// The args start at the cursor. TYPE is only accepted when allowType is true.
+func ParseScanOptions(args []string, allowType bool) (ScanOptions, error) {
+	cursor, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		return ScanOptions{}, errors.New("invalid cursor")
+	}
+	options := ScanOptions{Cursor: cursor, Count: 10}
+
+	for i := 1; i < len(args); i += 2 {
+		option := strings.ToLower(args[i])
+		if i+1 >= len(args) || !slices.Contains([]string{"match", "count", "type"}, option) ||
+			(option == "type" && !allowType) {
+			return ScanOptions{}, errors.New("syntax error")
+		}
+		switch option {
+		case "match":
+			options.Match = glob.Compile(args[i+1])
+		case "count":
+			count, err := strconv.Atoi(args[i+1])
+			if err != nil || count < 1 {
+				return ScanOptions{}, errors.New("count must be a positive integer")
+			}
+			options.Count = count
+		case "type":
+			options.Type = strings.ToLower(args[i+1])
+		}
+	}
+
+	return options, nil
+}
+
+// EncodeScanReply encodes the reply of the SCAN family of commands: the next cursor followed by the elements.
+func EncodeScanReply(cursor uint64, elems []string) []byte {
+	cursorStr := strconv.FormatUint(cursor, 10)
+	res := fmt.Sprintf("*2\r\n$%d\r\n%s\r\n*%d\r\n", len(cursorStr), cursorStr, len(elems))
+	for _, elem := range elems {
+		res += fmt.Sprintf("$%d\r\n%s\r\n", len(elem), elem)
+	}
+	return []byte(res)
+}
+
 // CompareLex returns -1 when s2 is lexicographically greater than s1,
 // 0 if they're equal and 1 if s2 is lexicographically less than s1.
 func CompareLex(s1 string, s2 string) int {
@@ -373,6 +684,28 @@ func ParseIntegerArrayResponse(b []byte) ([]int, error) {
 	return arr, nil
 }
 
+// ParseFloatArrayResponse parses an array of floats. Null elements are returned as 0,
+// and the returned bool slice reports which elements were not null.
+func ParseFloatArrayResponse(b []byte) ([]float64, []bool, error) {
+	r := resp.NewReader(bytes.NewReader(b))
+	v, _, err := r.ReadValue()
+	if err != nil {
+		return nil, nil, err
+	}
+	arr := make([]float64, len(v.Array()))
+	exists := make([]bool, len(v.Array()))
+	for i, e := range v.Array() {
+		if e.IsNull() {
+			continue
+		}
+		if arr[i], err = strconv.ParseFloat(e.String(), 64); err != nil {
+			return nil, nil, err
+		}
+		exists[i] = true
+	}
+	return arr, exists, nil
+}
+
 func ParseBooleanArrayResponse(b []byte) ([]bool, error) {
 	r := resp.NewReader(bytes.NewReader(b))
 	v, _, err := r.ReadValue()
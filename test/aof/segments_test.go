@@ -0,0 +1,193 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package aof
+
+import (
+	"fmt"
+	logstore "github.com/echovault/echovault/internal/aof/log"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// encodeCommand returns a command as it's queued to be written to the log.
+func encodeCommand(i int) []byte {
+	value := fmt.Sprintf("value%d", i)
+	return []byte(fmt.Sprintf("*3\r\n$3\r\nSET\r\n$3\r\nkey\r\n$%d\r\n%s\r\n", len(value), value))
+}
+
+// restoredCommand returns a command as it's read back from the log.
+func restoredCommand(i int) string {
+	return string(encodeCommand(i)) + "\r\n"
+}
+
+// newStore returns a store in the provided directory that records the commands it restores.
+func newStore(directory string, segmentSize uint64, restored *[]string) *logstore.AppendStore {
+	return logstore.NewAppendStore(
+		logstore.WithDirectory(directory),
+		logstore.WithStrategy("always"),
+		logstore.WithSegmentSize(segmentSize),
+		logstore.WithHandleCommandFunc(func(command []byte) {
+			*restored = append(*restored, string(command))
+		}),
+	)
+}
+
+// readManifest returns the segments listed in the manifest in the provided directory.
+func readManifest(t *testing.T, directory string) []string {
+	b, err := os.ReadFile(path.Join(directory, "aof", "manifest"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.Fields(string(b))
+}
+
+func Test_AOFSegments(t *testing.T) {
+	// Each segment holds two commands, so every second command starts a new segment.
+	segmentSize := uint64(len(encodeCommand(0))+2) * 2
+
+	t.Run("1. Start a new segment when the open segment is full and restore them in order", func(t *testing.T) {
+		directory := t.TempDir()
+		var restored []string
+		store := newStore(directory, segmentSize, &restored)
+		var want []string
+		for i := 0; i < 5; i++ {
+			if err := store.Write(encodeCommand(i)); err != nil {
+				t.Fatal(err)
+			}
+			want = append(want, restoredCommand(i))
+		}
+		if err := store.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		segments := readManifest(t, directory)
+		if expected := []string{"log.1.aof", "log.2.aof", "log.3.aof"}; !reflect.DeepEqual(segments, expected) {
+			t.Errorf("expected segments %v, got %v", expected, segments)
+		}
+
+		store = newStore(directory, segmentSize, &restored)
+		if err := store.Restore(); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(restored, want) {
+			t.Errorf("expected restored commands %q, got %q", want, restored)
+		}
+		if size := store.Size(); size != int64(len(want)*(len(encodeCommand(0))+2)) {
+			t.Errorf("expected size %d, got %d", len(want)*(len(encodeCommand(0))+2), size)
+		}
+	})
+
+	t.Run("2. Truncate drops all the segments", func(t *testing.T) {
+		directory := t.TempDir()
+		var restored []string
+		store := newStore(directory, segmentSize, &restored)
+		for i := 0; i < 5; i++ {
+			if err := store.Write(encodeCommand(i)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := store.Truncate(); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.Write(encodeCommand(5)); err != nil {
+			t.Fatal(err)
+		}
+
+		segments := readManifest(t, directory)
+		if expected := []string{"log.4.aof"}; !reflect.DeepEqual(segments, expected) {
+			t.Errorf("expected segments %v, got %v", expected, segments)
+		}
+		for _, segment := range []string{"log.1.aof", "log.2.aof", "log.3.aof"} {
+			if _, err := os.Stat(path.Join(directory, "aof", segment)); !os.IsNotExist(err) {
+				t.Errorf("expected segment %s to be deleted, got %v", segment, err)
+			}
+		}
+
+		if err := store.Restore(); err != nil {
+			t.Fatal(err)
+		}
+		if want := []string{restoredCommand(5)}; !reflect.DeepEqual(restored, want) {
+			t.Errorf("expected restored commands %q, got %q", want, restored)
+		}
+	})
+
+	t.Run("3. Adopt a log written to a single file as the first segment", func(t *testing.T) {
+		directory := t.TempDir()
+		var restored []string
+		store := newStore(directory, 0, &restored)
+		if err := store.Write(encodeCommand(0)); err != nil {
+			t.Fatal(err)
+		}
+		if err := store.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Stat(path.Join(directory, "aof", "manifest")); !os.IsNotExist(err) {
+			t.Errorf("expected no manifest when segments are disabled, got %v", err)
+		}
+
+		store = newStore(directory, segmentSize, &restored)
+		for i := 1; i < 3; i++ {
+			if err := store.Write(encodeCommand(i)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if segments := readManifest(t, directory); segments[0] != "log.aof" {
+			t.Errorf("expected log.aof to be the first segment, got %v", segments)
+		}
+		if err := store.Restore(); err != nil {
+			t.Fatal(err)
+		}
+		if want := []string{restoredCommand(0), restoredCommand(1), restoredCommand(2)}; !reflect.DeepEqual(restored, want) {
+			t.Errorf("expected restored commands %q, got %q", want, restored)
+		}
+	})
+
+	t.Run("4. Keep appending to a segmented log when segments are disabled", func(t *testing.T) {
+		directory := t.TempDir()
+		var restored []string
+		store := newStore(directory, segmentSize, &restored)
+		for i := 0; i < 3; i++ {
+			if err := store.Write(encodeCommand(i)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := store.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		store = newStore(directory, 0, &restored)
+		for i := 3; i < 6; i++ {
+			if err := store.Write(encodeCommand(i)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if expected := []string{"log.1.aof", "log.2.aof"}; !reflect.DeepEqual(readManifest(t, directory), expected) {
+			t.Errorf("expected segments %v, got %v", expected, readManifest(t, directory))
+		}
+		if err := store.Restore(); err != nil {
+			t.Fatal(err)
+		}
+		var want []string
+		for i := 0; i < 6; i++ {
+			want = append(want, restoredCommand(i))
+		}
+		if !reflect.DeepEqual(restored, want) {
+			t.Errorf("expected restored commands %q, got %q", want, restored)
+		}
+	})
+}
@@ -0,0 +1,393 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/echovault/echovault/echovault"
+	"github.com/echovault/echovault/internal"
+	"github.com/echovault/echovault/internal/cli"
+	"github.com/echovault/echovault/internal/config"
+	"github.com/echovault/echovault/internal/constants"
+	"github.com/tidwall/resp"
+	"net"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var bindAddr = "localhost"
+var port uint16 = 7489
+
+func init() {
+	mockServer, _ := echovault.NewEchoVault(
+		echovault.WithConfig(config.Config{
+			BindAddr:       bindAddr,
+			Port:           port,
+			DataDir:        "",
+			EvictionPolicy: constants.NoEviction,
+		}),
+	)
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		wg.Done()
+		mockServer.Start()
+	}()
+	wg.Wait()
+}
+
+// newClient connects to the test server, retrying while the server is starting.
+func newClient(t *testing.T, options ...func(client *cli.Client)) *cli.Client {
+	var err error
+	for i := 0; i < 50; i++ {
+		var client *cli.Client
+		if client, err = cli.NewClient(fmt.Sprintf("%s:%d", bindAddr, port), options...); err == nil {
+			t.Cleanup(func() {
+				_ = client.Close()
+			})
+			return client
+		}
+		<-time.After(20 * time.Millisecond)
+	}
+	t.Fatal(err)
+	return nil
+}
+
+func Test_SplitArgs(t *testing.T) {
+	tests := []struct {
+		name          string
+		line          string
+		expectedArgs  []string
+		expectedError error
+	}{
+		{
+			name:         "1. Split arguments on whitespace",
+			line:         "  SET   key \tvalue ",
+			expectedArgs: []string{"SET", "key", "value"},
+		},
+		{
+			name:         "2. Double-quoted arguments keep whitespace and support escape sequences",
+			line:         `SET key "hello world\n\x41\"" ""`,
+			expectedArgs: []string{"SET", "key", "hello world\nA\"", ""},
+		},
+		{
+			name:         "3. Single-quoted arguments only support escaped single quotes",
+			line:         `SET key 'it\'s \n'`,
+			expectedArgs: []string{"SET", "key", `it's \n`},
+		},
+		{
+			name:          "4. Return error when a quote is not closed",
+			line:          `SET key "value`,
+			expectedError: errors.New("unbalanced quotes in command line"),
+		},
+		{
+			name:          "5. Return error when a closing quote is not followed by a space",
+			line:          `SET key "value"x`,
+			expectedError: errors.New("closing quote must be followed by a space"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			args, err := cli.SplitArgs(test.line)
+			if test.expectedError != nil {
+				if err == nil || err.Error() != test.expectedError.Error() {
+					t.Errorf("expected error \"%s\", got \"%v\"", test.expectedError.Error(), err)
+				}
+				return
+			}
+			if err != nil {
+				t.Error(err)
+			}
+			if !slices.Equal(args, test.expectedArgs) {
+				t.Errorf("expected args %q, got %q", test.expectedArgs, args)
+			}
+		})
+	}
+}
+
+func Test_FormatReply(t *testing.T) {
+	nested := resp.ArrayValue([]resp.Value{
+		resp.StringValue("key"),
+		resp.ArrayValue([]resp.Value{resp.StringValue("field"), resp.IntegerValue(1)}),
+	})
+
+	tests := []struct {
+		name          string
+		value         resp.Value
+		expectedHuman string
+		expectedRaw   string
+	}{
+		{
+			name:          "1. Simple string",
+			value:         resp.SimpleStringValue("OK"),
+			expectedHuman: "OK",
+			expectedRaw:   "OK",
+		},
+		{
+			name:          "2. Bulk string",
+			value:         resp.StringValue("hello world"),
+			expectedHuman: `"hello world"`,
+			expectedRaw:   "hello world",
+		},
+		{
+			name:          "3. Integer",
+			value:         resp.IntegerValue(10),
+			expectedHuman: "(integer) 10",
+			expectedRaw:   "10",
+		},
+		{
+			name:          "4. Nil",
+			value:         resp.NullValue(),
+			expectedHuman: "(nil)",
+			expectedRaw:   "",
+		},
+		{
+			name:          "5. Error",
+			value:         resp.ErrorValue(errors.New("Error value is not an integer")),
+			expectedHuman: "(error) Error value is not an integer",
+			expectedRaw:   "Error value is not an integer",
+		},
+		{
+			name:          "6. Empty array",
+			value:         resp.ArrayValue([]resp.Value{}),
+			expectedHuman: "(empty array)",
+			expectedRaw:   "",
+		},
+		{
+			name:          "7. Nested array",
+			value:         nested,
+			expectedHuman: "1) \"key\"\n2) 1) \"field\"\n   2) (integer) 1",
+			expectedRaw:   "key\nfield\n1",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := cli.FormatReply(test.value, false); got != test.expectedHuman {
+				t.Errorf("expected human reply %q, got %q", test.expectedHuman, got)
+			}
+			if got := cli.FormatReply(test.value, true); got != test.expectedRaw {
+				t.Errorf("expected raw reply %q, got %q", test.expectedRaw, got)
+			}
+		})
+	}
+}
+
+func Test_History(t *testing.T) {
+	file := path.Join(t.TempDir(), "history")
+
+	history, err := cli.NewHistory(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"SET key value", "GET key", "GET key", "DEL key"} {
+		if err = history.Add(line); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The history is loaded from the file in the next session, without the consecutive duplicate.
+	history, err = cli.NewHistory(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedLines := []string{"SET key value", "GET key", "DEL key"}
+	if !slices.Equal(history.Lines(), expectedLines) {
+		t.Errorf("expected history %q, got %q", expectedLines, history.Lines())
+	}
+
+	for line, expected := range map[string]string{"!!": "DEL key", "!1": "SET key value", "GET key": "GET key"} {
+		got, err := history.Expand(line)
+		if err != nil {
+			t.Error(err)
+		}
+		if got != expected {
+			t.Errorf("expected %s to expand to \"%s\", got \"%s\"", line, expected, got)
+		}
+	}
+	if _, err = history.Expand("!4"); err == nil || err.Error() != "!4: event not found" {
+		t.Errorf("expected error \"!4: event not found\", got \"%v\"", err)
+	}
+}
+
+func Test_REPL(t *testing.T) {
+	history, _ := cli.NewHistory("")
+	repl := &cli.REPL{Client: newClient(t), History: history}
+
+	in := strings.NewReader("SET ReplKey1 \"hello world\"\nGET ReplKey1\n!!\nhistory\nLPOP ReplKey1\nquit\nGET ReplKey1\n")
+	out := bytes.NewBuffer(nil)
+	if err := repl.Run(in, out); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := strings.Join([]string{
+		"OK",
+		"hello world",
+		"hello world",
+		`    1  SET ReplKey1 "hello world"`,
+		"    2  GET ReplKey1",
+		"(error) Error LPOP command on non-list item",
+		"",
+	}, "\n")
+	if got := out.String(); got != expected {
+		t.Errorf("expected output %q, got %q", expected, got)
+	}
+}
+
+func Test_Pipe(t *testing.T) {
+	tests := []struct {
+		name           string
+		input          string
+		expectedResult cli.PipeResult
+		expectedOutput string
+		expectedValues map[string]string
+	}{
+		{
+			name: "1. Pipe RESP encoded commands",
+			input: string(internal.EncodeCommand([]string{"SET", "PipeKey1", "value 1"})) +
+				string(internal.EncodeCommand([]string{"SET", "PipeKey2", "value 2"})) +
+				string(internal.EncodeCommand([]string{"UNKNOWN", "PipeKey2"})),
+			expectedResult: cli.PipeResult{Replies: 3, Errors: 1},
+			expectedOutput: "Error command UNKNOWN not supported\n",
+			expectedValues: map[string]string{"PipeKey1": "value 1", "PipeKey2": "value 2"},
+		},
+		{
+			name:           "2. Pipe one command per line",
+			input:          "\nSET PipeKey3 \"value 3\"\n\nSET PipeKey4 value4",
+			expectedResult: cli.PipeResult{Replies: 2, Errors: 0},
+			expectedOutput: "",
+			expectedValues: map[string]string{"PipeKey3": "value 3", "PipeKey4": "value4"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := newClient(t)
+
+			out := bytes.NewBuffer(nil)
+			res, err := cli.Pipe(client, strings.NewReader(test.input), out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res != test.expectedResult {
+				t.Errorf("expected result %+v, got %+v", test.expectedResult, res)
+			}
+			if out.String() != test.expectedOutput {
+				t.Errorf("expected output %q, got %q", test.expectedOutput, out.String())
+			}
+			for key, expected := range test.expectedValues {
+				value, err := client.Do([]string{"GET", key})
+				if err != nil {
+					t.Error(err)
+				}
+				if value.String() != expected {
+					t.Errorf("expected value at key %s to be \"%s\", got \"%s\"", key, expected, value.String())
+				}
+			}
+		})
+	}
+}
+
+func Test_ClientRedirect(t *testing.T) {
+	// The follower rejects every command as it's not the leader, and reports the test server as the leader.
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = listener.Close()
+	}()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer func() {
+					_ = conn.Close()
+				}()
+				r := internal.NewMessageReader(conn)
+				for {
+					message, err := r.ReadMessage()
+					if err != nil {
+						return
+					}
+					cmd, err := internal.Decode(message)
+					if err != nil {
+						return
+					}
+					reply := "-Error not cluster leader, cannot carry out command\r\n"
+					if len(cmd) > 0 && strings.EqualFold(cmd[0], "INFO") {
+						info := fmt.Sprintf("# Replication\r\nrole:slave\r\nmaster_host:%s\r\nmaster_port:%d\r\n", bindAddr, port)
+						reply = fmt.Sprintf("$%d\r\n%s\r\n", len(info), info)
+					}
+					if _, err = conn.Write([]byte(reply)); err != nil {
+						return
+					}
+				}
+			}()
+		}
+	}()
+
+	// Make sure the test server is up.
+	newClient(t)
+
+	// Without redirects, the error from the follower is returned.
+	client, err := cli.NewClient(listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := client.Do([]string{"SET", "RedirectKey1", "value1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Type() != resp.Error || !strings.Contains(res.String(), "not cluster leader") {
+		t.Errorf("expected not cluster leader error, got %+v", res)
+	}
+	_ = client.Close()
+
+	// With redirects, the command is sent again to the leader.
+	out := bytes.NewBuffer(nil)
+	client, err = cli.NewClient(listener.Addr().String(), cli.WithRedirects(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = client.Close()
+	}()
+	res, err = client.Do([]string{"SET", "RedirectKey1", "value1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.String() != "OK" {
+		t.Errorf("expected OK response, got %+v", res)
+	}
+	expectedAddr := fmt.Sprintf("%s:%d", bindAddr, port)
+	if client.Addr() != expectedAddr {
+		t.Errorf("expected client to be connected to %s, got %s", expectedAddr, client.Addr())
+	}
+	if out.String() != fmt.Sprintf("-> Redirected to %s\n", expectedAddr) {
+		t.Errorf("expected redirect to be reported, got %q", out.String())
+	}
+}
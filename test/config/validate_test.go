@@ -0,0 +1,193 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"github.com/echovault/echovault/internal/config"
+	"github.com/echovault/echovault/internal/constants"
+	"path"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_Validate(t *testing.T) {
+	dataDir := t.TempDir()
+
+	tests := []struct {
+		name      string
+		modify    func(conf *config.Config)
+		wantError []string
+	}{
+		{
+			name:      "1. Default config is valid",
+			modify:    func(conf *config.Config) {},
+			wantError: nil,
+		},
+		{
+			name: "2. TLS without certificates is invalid",
+			modify: func(conf *config.Config) {
+				conf.TLS = true
+			},
+			wantError: []string{"tls and mtls require at least one cert-key-pair"},
+		},
+		{
+			name: "3. MTLS without client CAs is invalid",
+			modify: func(conf *config.Config) {
+				conf.MTLS = true
+			},
+			wantError: []string{
+				"tls and mtls require at least one cert-key-pair",
+				"mtls requires at least one client-ca",
+			},
+		},
+		{
+			name: "4. Unknown eviction policy is invalid",
+			modify: func(conf *config.Config) {
+				conf.EvictionPolicy = "allkeys-lrv"
+			},
+			wantError: []string{"eviction-policy must be one of", "got 'allkeys-lrv'"},
+		},
+		{
+			name: "5. Zero intervals are invalid",
+			modify: func(conf *config.Config) {
+				conf.SnapshotInterval = 0
+				conf.EvictionInterval = 0
+			},
+			wantError: []string{
+				"snapshot-interval must be greater than 0",
+				"eviction-interval must be greater than 0",
+			},
+		},
+		{
+			name: "6. Empty data directory is invalid",
+			modify: func(conf *config.Config) {
+				conf.DataDir = ""
+			},
+			wantError: []string{"data-dir is required"},
+		},
+		{
+			name: "7. Missing data directory is created",
+			modify: func(conf *config.Config) {
+				conf.DataDir = path.Join(dataDir, "nested", "data")
+			},
+			wantError: nil,
+		},
+		{
+			name: "8. Require pass without a password is invalid",
+			modify: func(conf *config.Config) {
+				conf.RequirePass = true
+			},
+			wantError: []string{"password cannot be empty if require-pass is set to true"},
+		},
+		{
+			name: "9. DNS peer discovery without a record name is invalid",
+			modify: func(conf *config.Config) {
+				conf.PeerDiscovery = "dns-srv"
+				conf.DiscoveryInterval = 0
+			},
+			wantError: []string{
+				"discovery-dns is required when peer-discovery is dns-srv",
+				"discovery-interval must be greater than 0",
+			},
+		},
+		{
+			name: "10. Cluster TLS without a certificate and CA is invalid",
+			modify: func(conf *config.Config) {
+				conf.ClusterTLS = true
+			},
+			wantError: []string{"cluster-tls requires a cluster-cert-key-pair and a cluster-ca"},
+		},
+		{
+			name: "11. Gossip key with an invalid length is invalid",
+			modify: func(conf *config.Config) {
+				conf.ClusterGossipKey = "c2hvcnQta2V5"
+			},
+			wantError: []string{"cluster-gossip-key must be a base64 encoded 16, 24 or 32 byte key"},
+		},
+		{
+			name: "12. Valid gossip key is valid",
+			modify: func(conf *config.Config) {
+				conf.ClusterGossipKey = "MDEyMzQ1Njc4OWFiY2RlZg=="
+			},
+			wantError: nil,
+		},
+		{
+			name: "13. Raft port shared with the client port is invalid",
+			modify: func(conf *config.Config) {
+				conf.RaftBindPort = conf.Port
+			},
+			wantError: []string{"raft-port and memberlist-port must be different from port"},
+		},
+		{
+			name: "14. Unknown AOF queue policy and empty AOF queue are invalid",
+			modify: func(conf *config.Config) {
+				conf.AOFQueuePolicy = "drop"
+				conf.AOFQueueLimit = 0
+			},
+			wantError: []string{
+				"aof-queue-policy must be one of 'block', 'error', got 'drop'",
+				"aof-queue-limit must be at least 1",
+			},
+		},
+		{
+			name: "15. Negative stall threshold is invalid",
+			modify: func(conf *config.Config) {
+				conf.StallThreshold = -time.Second
+			},
+			wantError: []string{"stall-threshold must not be negative"},
+		},
+		{
+			name: "16. Negative LFU decay time is invalid",
+			modify: func(conf *config.Config) {
+				conf.LFUDecayTime = -time.Minute
+			},
+			wantError: []string{"lfu-decay-time must not be negative"},
+		},
+		{
+			name: "17. Volatile idle policy is valid and a negative idle threshold is invalid",
+			modify: func(conf *config.Config) {
+				conf.EvictionPolicy = constants.VolatileIdle
+				conf.IdleThreshold = -time.Minute
+			},
+			wantError: []string{"idle-threshold must not be negative"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			conf := config.DefaultConfig()
+			conf.DataDir = dataDir
+			test.modify(&conf)
+
+			err := conf.Validate()
+			if len(test.wantError) == 0 {
+				if err != nil {
+					t.Errorf("expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Errorf("expected error containing %v, got nil", test.wantError)
+				return
+			}
+			for _, want := range test.wantError {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("expected error to contain \"%s\", got \"%s\"", want, err.Error())
+				}
+			}
+		})
+	}
+}
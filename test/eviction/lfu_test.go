@@ -0,0 +1,89 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eviction
+
+import (
+	"github.com/echovault/echovault/internal/clock"
+	"github.com/echovault/echovault/internal/eviction"
+	"testing"
+	"time"
+)
+
+func Test_CacheLFU(t *testing.T) {
+	t.Run("1. Evict the least frequently used key", func(t *testing.T) {
+		cache := eviction.NewCacheLFU(clock.NewManualClock(time.Now()))
+		for i := 0; i < 3; i++ {
+			cache.Update("hot")
+		}
+		cache.Update("cold")
+		if key := cache.PopLeastFrequent(); key != "cold" {
+			t.Errorf("expected cold to be evicted, got %s", key)
+		}
+		if key := cache.PopLeastFrequent(); key != "hot" {
+			t.Errorf("expected hot to be evicted, got %s", key)
+		}
+	})
+
+	t.Run("2. Evict a hot key once it has been idle for long enough", func(t *testing.T) {
+		manualClock := clock.NewManualClock(time.Now())
+		cache := eviction.NewCacheLFU(
+			manualClock,
+			eviction.WithDecayTimeFunc(func() time.Duration { return time.Minute }),
+		)
+		for i := 0; i < 10; i++ {
+			cache.Update("hot")
+		}
+		manualClock.Advance(20 * time.Minute)
+		cache.Update("new")
+		cache.Update("new")
+		if key := cache.PopLeastFrequent(); key != "hot" {
+			t.Errorf("expected idle hot key to be evicted, got %s", key)
+		}
+	})
+
+	t.Run("3. Keep a hot key when counters do not decay", func(t *testing.T) {
+		manualClock := clock.NewManualClock(time.Now())
+		cache := eviction.NewCacheLFU(manualClock)
+		for i := 0; i < 10; i++ {
+			cache.Update("hot")
+		}
+		manualClock.Advance(20 * time.Minute)
+		cache.Update("new")
+		cache.Update("new")
+		if key := cache.PopLeastFrequent(); key != "new" {
+			t.Errorf("expected new key to be evicted, got %s", key)
+		}
+	})
+
+	t.Run("4. Slow down the growth of the counter with the log factor", func(t *testing.T) {
+		var logFactor uint = 0
+		cache := eviction.NewCacheLFU(
+			clock.NewManualClock(time.Now()),
+			eviction.WithLogFactorFunc(func() uint { return logFactor }),
+		)
+		// With a log factor of 0, every access increments the counter.
+		for i := 0; i < 50; i++ {
+			cache.Update("linear")
+		}
+		// With a high log factor, the same number of accesses barely increments the counter.
+		logFactor = 1000
+		for i := 0; i < 50; i++ {
+			cache.Update("logarithmic")
+		}
+		if key := cache.PopLeastFrequent(); key != "logarithmic" {
+			t.Errorf("expected logarithmic to be evicted, got %s", key)
+		}
+	})
+}
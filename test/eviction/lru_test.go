@@ -0,0 +1,74 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eviction
+
+import (
+	"github.com/echovault/echovault/internal/clock"
+	"github.com/echovault/echovault/internal/eviction"
+	"testing"
+	"time"
+)
+
+func Test_CacheLRU(t *testing.T) {
+	t.Run("1. Evict the least recently used key", func(t *testing.T) {
+		manualClock := clock.NewManualClock(time.Now())
+		cache := eviction.NewCacheLRU(manualClock)
+		for _, key := range []string{"first", "second", "third"} {
+			cache.Update(key)
+			manualClock.Advance(time.Second)
+		}
+		cache.Update("first")
+		for _, want := range []string{"second", "third", "first"} {
+			if key := cache.PopLeastRecent(); key != want {
+				t.Errorf("expected %s to be evicted, got %s", want, key)
+			}
+		}
+		if cache.Len() != 0 {
+			t.Errorf("expected empty cache, got %d entries", cache.Len())
+		}
+	})
+
+	t.Run("2. Only pop the keys that have been idle for longer than the idle time", func(t *testing.T) {
+		manualClock := clock.NewManualClock(time.Now())
+		cache := eviction.NewCacheLRU(manualClock)
+		cache.Update("idle")
+		manualClock.Advance(20 * time.Minute)
+		cache.Update("active")
+		manualClock.Advance(15 * time.Minute)
+
+		if key, ok := cache.PopIdle(30 * time.Minute); !ok || key != "idle" {
+			t.Errorf("expected idle key, got %s, %v", key, ok)
+		}
+		if key, ok := cache.PopIdle(30 * time.Minute); ok {
+			t.Errorf("expected no idle key, got %s", key)
+		}
+		if cache.Len() != 1 {
+			t.Errorf("expected 1 entry, got %d", cache.Len())
+		}
+	})
+
+	t.Run("3. Do not duplicate a key that is updated again", func(t *testing.T) {
+		cache := eviction.NewCacheLRU(clock.NewManualClock(time.Now()))
+		cache.Update("key")
+		cache.Update("key")
+		if cache.Len() != 1 {
+			t.Errorf("expected 1 entry, got %d", cache.Len())
+		}
+		cache.Delete("key")
+		if cache.Len() != 0 {
+			t.Errorf("expected empty cache, got %d entries", cache.Len())
+		}
+	})
+}
@@ -0,0 +1,186 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package glob
+
+import (
+	"github.com/echovault/echovault/internal/glob"
+	"testing"
+)
+
+func Test_Match(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		matches []string
+		misses  []string
+	}{
+		{
+			name:    "1. Literal pattern only matches the exact string",
+			pattern: "channel",
+			matches: []string{"channel"},
+			misses:  []string{"", "channe", "channel1", "Channel"},
+		},
+		{
+			name:    "2. Empty pattern only matches the empty string",
+			pattern: "",
+			matches: []string{""},
+			misses:  []string{"a"},
+		},
+		{
+			name:    "3. Question mark matches exactly one character",
+			pattern: "h?llo",
+			matches: []string{"hello", "hallo", "hxllo", "hällo"},
+			misses:  []string{"hllo", "heello"},
+		},
+		{
+			name:    "4. Star matches any sequence of characters",
+			pattern: "h*llo",
+			matches: []string{"hllo", "hello", "heeeello"},
+			misses:  []string{"hell", "ahello"},
+		},
+		{
+			name:    "5. Star only pattern matches everything",
+			pattern: "**",
+			matches: []string{"", "a", "key:1:field"},
+		},
+		{
+			name:    "6. Multiple stars backtrack",
+			pattern: "*a*b*c",
+			matches: []string{"abc", "xaybzc", "aabbcc", "abcabc"},
+			misses:  []string{"acb", "abcd", "cba"},
+		},
+		{
+			name:    "7. Brackets match one of the characters",
+			pattern: "h[ae]llo",
+			matches: []string{"hello", "hallo"},
+			misses:  []string{"hillo", "hllo", "haello"},
+		},
+		{
+			name:    "8. Negated brackets match any character not in the brackets",
+			pattern: "h[^e]llo",
+			matches: []string{"hallo", "hbllo"},
+			misses:  []string{"hello", "hllo"},
+		},
+		{
+			name:    "9. Bracket ranges match characters in the range",
+			pattern: "key[0-9]",
+			matches: []string{"key0", "key5", "key9"},
+			misses:  []string{"keya", "key10", "key"},
+		},
+		{
+			name:    "10. Reversed bracket ranges are swapped",
+			pattern: "[z-a]",
+			matches: []string{"a", "m", "z"},
+			misses:  []string{"A", "0"},
+		},
+		{
+			name:    "11. Dash at the end of the brackets is literal",
+			pattern: "[a-]",
+			matches: []string{"a", "-"},
+			misses:  []string{"b"},
+		},
+		{
+			name:    "12. Escaped special characters match literally",
+			pattern: `\*\?\[x\]`,
+			matches: []string{"*?[x]"},
+			misses:  []string{"a?[x]", "*a[x]", "*?x"},
+		},
+		{
+			name:    "13. Escaped characters inside brackets match literally",
+			pattern: `[\]\^]`,
+			matches: []string{"]", "^"},
+			misses:  []string{`\`, "a"},
+		},
+		{
+			name:    "14. Trailing backslash matches a literal backslash",
+			pattern: `a\`,
+			matches: []string{`a\`},
+			misses:  []string{"a"},
+		},
+		{
+			name:    "15. Unterminated brackets match up to the end of the pattern",
+			pattern: "a[bc",
+			matches: []string{"ab", "ac"},
+			misses:  []string{"a", "abc"},
+		},
+		{
+			name:    "16. Bytes that are not valid UTF-8 only match the same bytes",
+			pattern: "key:\xff*",
+			matches: []string{"key:\xff", "key:\xff\x00\r\n"},
+			misses:  []string{"key:\xfe", "key:\ufffd", "key:"},
+		},
+		{
+			name:    "17. Question mark matches a single invalid byte",
+			pattern: "a?b",
+			matches: []string{"a\x80b", "a b", "a\nb"},
+			misses:  []string{"a\x80\x80b"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			g := glob.Compile(test.pattern)
+			if g.String() != test.pattern {
+				t.Errorf("expected pattern \"%s\", got \"%s\"", test.pattern, g.String())
+			}
+			for _, s := range test.matches {
+				if !g.Match(s) {
+					t.Errorf("expected pattern \"%s\" to match \"%s\"", test.pattern, s)
+				}
+			}
+			for _, s := range test.misses {
+				if glob.Match(test.pattern, s) {
+					t.Errorf("expected pattern \"%s\" not to match \"%s\"", test.pattern, s)
+				}
+			}
+		})
+	}
+}
+
+func Test_Cache(t *testing.T) {
+	cache := glob.NewCache(2)
+
+	a := cache.Compile("a*")
+	b := cache.Compile("b*")
+	if cache.Compile("a*") != a {
+		t.Error("expected cached pattern \"a*\" to be reused")
+	}
+
+	// "b*" is now the least recently used pattern, so it's dropped when "c*" is added.
+	c := cache.Compile("c*")
+	if cache.Len() != 2 {
+		t.Errorf("expected cache length 2, got %d", cache.Len())
+	}
+	if cache.Compile("a*") != a {
+		t.Error("expected pattern \"a*\" to remain cached")
+	}
+	if cache.Compile("c*") != c {
+		t.Error("expected pattern \"c*\" to remain cached")
+	}
+	if got := cache.Compile("b*"); got == b {
+		t.Error("expected pattern \"b*\" to have been evicted")
+	} else if !got.Match("bar") {
+		t.Error("expected recompiled pattern \"b*\" to match \"bar\"")
+	}
+
+	// A cache without capacity compiles every pattern without holding onto it.
+	empty := glob.NewCache(0)
+	if empty.Compile("a*") == empty.Compile("a*") {
+		t.Error("expected a cache of size 0 not to reuse patterns")
+	}
+	if empty.Len() != 0 {
+		t.Errorf("expected cache length 0, got %d", empty.Len())
+	}
+}
@@ -0,0 +1,207 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keyindex
+
+import (
+	"fmt"
+	"github.com/echovault/echovault/internal/keyindex"
+	"strings"
+	"testing"
+)
+
+// scanAll iterates the index to completion, calling between after each page.
+// Returns the number of times each key was returned.
+func scanAll(t *testing.T, index *keyindex.Index, count int, match func(key string) bool, between func(page int)) map[string]int {
+	seen := make(map[string]int)
+	cursor := uint64(0)
+	for page := 0; ; page++ {
+		if page > 100000 {
+			t.Fatal("scan did not complete")
+		}
+		var keys []string
+		keys, cursor = index.Scan(cursor, count, match)
+		for _, key := range keys {
+			seen[key] += 1
+		}
+		if cursor == 0 {
+			return seen
+		}
+		between(page)
+	}
+}
+
+func Test_Index(t *testing.T) {
+	t.Run("1. Add and remove keys", func(t *testing.T) {
+		index := keyindex.NewIndex()
+		for i := 0; i < 100; i++ {
+			index.Add(fmt.Sprintf("key%d", i))
+		}
+		// Adding a key that's already in the index is a no-op.
+		index.Add("key0")
+		if index.Len() != 100 {
+			t.Errorf("expected length 100, got %d", index.Len())
+		}
+		for i := 0; i < 50; i++ {
+			index.Remove(fmt.Sprintf("key%d", i))
+		}
+		// Removing a key that's not in the index is a no-op.
+		index.Remove("key0")
+		if index.Len() != 50 {
+			t.Errorf("expected length 50, got %d", index.Len())
+		}
+		seen := scanAll(t, index, 10, nil, func(int) {})
+		if len(seen) != 50 {
+			t.Errorf("expected 50 keys, got %d", len(seen))
+		}
+		for i := 0; i < 50; i++ {
+			if _, ok := seen[fmt.Sprintf("key%d", i)]; ok {
+				t.Errorf("expected removed key key%d not to be returned", i)
+			}
+		}
+	})
+
+	t.Run("2. Return keys that exist for the whole scan exactly once while the index grows", func(t *testing.T) {
+		index := keyindex.NewIndex()
+		for i := 0; i < 100; i++ {
+			index.Add(fmt.Sprintf("key%d", i))
+		}
+		// Add enough keys after each page to resize the index several times during the scan.
+		seen := scanAll(t, index, 10, nil, func(page int) {
+			for i := 0; i < 50; i++ {
+				index.Add(fmt.Sprintf("new%d:%d", page, i))
+			}
+		})
+		for i := 0; i < 100; i++ {
+			if n := seen[fmt.Sprintf("key%d", i)]; n != 1 {
+				t.Errorf("expected key%d to be returned once, got %d", i, n)
+			}
+		}
+	})
+
+	t.Run("3. Return keys that exist for the whole scan exactly once while the index shrinks", func(t *testing.T) {
+		index := keyindex.NewIndex()
+		for i := 0; i < 100; i++ {
+			index.Add(fmt.Sprintf("key%d", i))
+		}
+		for i := 0; i < 5000; i++ {
+			index.Add(fmt.Sprintf("removed%d", i))
+		}
+		removed := 0
+		seen := scanAll(t, index, 10, nil, func(int) {
+			for i := 0; i < 500 && removed < 5000; i++ {
+				index.Remove(fmt.Sprintf("removed%d", removed))
+				removed += 1
+			}
+		})
+		for i := 0; i < 100; i++ {
+			if n := seen[fmt.Sprintf("key%d", i)]; n != 1 {
+				t.Errorf("expected key%d to be returned once, got %d", i, n)
+			}
+		}
+	})
+
+	t.Run("4. Only return the keys that match", func(t *testing.T) {
+		index := keyindex.NewIndex()
+		for i := 0; i < 100; i++ {
+			index.Add(fmt.Sprintf("even%d", i*2))
+			index.Add(fmt.Sprintf("odd%d", i*2+1))
+		}
+		seen := scanAll(t, index, 10, func(key string) bool {
+			return strings.HasPrefix(key, "even")
+		}, func(int) {})
+		if len(seen) != 100 {
+			t.Errorf("expected 100 keys, got %d", len(seen))
+		}
+		for key := range seen {
+			if !strings.HasPrefix(key, "even") {
+				t.Errorf("expected only matching keys, got %s", key)
+			}
+		}
+	})
+}
+
+func Test_ScanMembers(t *testing.T) {
+	members := make([]string, 0, 1000)
+	for i := 0; i < 1000; i++ {
+		members = append(members, fmt.Sprintf("member%d", i))
+	}
+
+	tests := []struct {
+		name  string
+		count int
+	}{
+		{name: "1. Iterate the members one at a time", count: 1},
+		{name: "2. Iterate the members in pages", count: 7},
+		{name: "3. Iterate the members with the default count", count: 0},
+		{name: "4. Iterate the members in a single page", count: 1000},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			seen := make(map[string]int)
+			cursor := uint64(0)
+			limit := test.count
+			if limit <= 0 {
+				limit = 10
+			}
+			for {
+				page, next := keyindex.ScanMembers(members, cursor, test.count)
+				if len(page) > limit {
+					t.Errorf("expected at most %d members, got %d", limit, len(page))
+				}
+				for _, member := range page {
+					seen[member] += 1
+				}
+				if next == 0 {
+					break
+				}
+				if next <= cursor {
+					t.Fatalf("expected cursor to advance past %d, got %d", cursor, next)
+				}
+				cursor = next
+			}
+			if len(seen) != len(members) {
+				t.Errorf("expected %d members, got %d", len(members), len(seen))
+			}
+			for member, n := range seen {
+				if n != 1 {
+					t.Errorf("expected %s to be returned once, got %d", member, n)
+				}
+			}
+		})
+	}
+
+	t.Run("5. Return members that exist for the whole scan exactly once while the collection changes", func(t *testing.T) {
+		current := append([]string{}, members...)
+		seen := make(map[string]int)
+		cursor := uint64(0)
+		for i := 0; ; i++ {
+			page, next := keyindex.ScanMembers(current, cursor, 10)
+			for _, member := range page {
+				seen[member] += 1
+			}
+			if next == 0 {
+				break
+			}
+			cursor = next
+			current = append(current, fmt.Sprintf("added%d", i))
+		}
+		for _, member := range members {
+			if n := seen[member]; n != 1 {
+				t.Errorf("expected %s to be returned once, got %d", member, n)
+			}
+		}
+	})
+}
@@ -13,3 +13,209 @@
 // limitations under the License.
 
 package acl
+
+import (
+	"context"
+	"github.com/echovault/echovault/echovault"
+	"github.com/echovault/echovault/internal/config"
+	"os"
+	"path"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEchoVault_ACLSaveLoad(t *testing.T) {
+	tests := []struct {
+		name     string
+		fileName string
+	}{
+		{name: "1. Save and load JSON ACL config", fileName: "acl.json"},
+		{name: "2. Save and load YAML ACL config", fileName: "acl.yaml"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			aclConfig := path.Join(t.TempDir(), tt.fileName)
+			// Start with a config that is longer than the saved config to make sure the file is replaced.
+			padding := `[{"Username": "padding_user", "Enabled": true, "Passwords": [{"PasswordType": "plaintext", "PasswordValue": "` +
+				strings.Repeat("p", 1<<16) + `"}]}]`
+			if err := os.WriteFile(aclConfig, []byte(padding), 0640); err != nil {
+				t.Fatal(err)
+			}
+
+			server, err := echovault.NewEchoVault(
+				echovault.WithConfig(config.Config{DataDir: "", AclConfig: aclConfig}),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err = server.ACLDelUser("padding_user"); err != nil {
+				t.Fatal(err)
+			}
+			if _, err = server.ACLSetUser(echovault.User{
+				Username:          "saved_user",
+				Enabled:           true,
+				AddPlainPasswords: []string{"password"},
+				IncludeCategories: []string{"read"},
+				IncludeReadKeys:   []string{"saved:*"},
+			}); err != nil {
+				t.Fatal(err)
+			}
+			if _, err = server.ACLSave(); err != nil {
+				t.Fatal(err)
+			}
+
+			info, err := os.Stat(aclConfig)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Mode().Perm() != 0640 {
+				t.Errorf("expected file mode %v, got %v", os.FileMode(0640), info.Mode().Perm())
+			}
+			entries, err := os.ReadDir(path.Dir(aclConfig))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 1 {
+				t.Errorf("expected only the ACL config file in the directory, got %d entries", len(entries))
+			}
+
+			// A new server picks the saved user up on start.
+			restarted, err := echovault.NewEchoVault(
+				echovault.WithConfig(config.Config{DataDir: "", AclConfig: aclConfig}),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			users, err := restarted.ACLUsers()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Contains(users, "saved_user") || slices.Contains(users, "padding_user") {
+				t.Fatalf("expected saved_user without padding_user to be loaded, got %v", users)
+			}
+
+			// ACL LOAD REPLACE restores the saved rules of a user that has since been modified.
+			if _, err = restarted.ACLSetUser(echovault.User{Username: "saved_user", IncludeCategories: []string{"write"}}); err != nil {
+				t.Fatal(err)
+			}
+			if _, err = restarted.ACLLoad(echovault.ACLLoadOptions{Replace: true}); err != nil {
+				t.Fatal(err)
+			}
+			user, err := restarted.ACLGetUser("saved_user")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if slices.Contains(user["categories"], "+@write") {
+				t.Errorf("expected +@write to be replaced by the saved rules, got %v", user["categories"])
+			}
+			if !slices.Contains(user["categories"], "+@read") {
+				t.Errorf("expected +@read in the loaded rules, got %v", user["categories"])
+			}
+		})
+	}
+
+	t.Run("3. Save without an ACL config file", func(t *testing.T) {
+		server, err := echovault.NewEchoVault(echovault.WithConfig(config.Config{DataDir: ""}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = server.ACLSave(); err == nil || !strings.Contains(err.Error(), "acl config file not set") {
+			t.Errorf("expected error \"acl config file not set\", got %v", err)
+		}
+	})
+}
+
+func TestEchoVault_ACLAutoSave(t *testing.T) {
+	aclConfig := path.Join(t.TempDir(), "acl.json")
+
+	server, err := echovault.NewEchoVault(
+		echovault.WithConfig(config.Config{DataDir: "", AclConfig: aclConfig, AclAutoSave: true}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	waitFor := func(check func(string) bool) bool {
+		for i := 0; i < 100; i++ {
+			if b, err := os.ReadFile(aclConfig); err == nil && check(string(b)) {
+				return true
+			}
+			<-time.After(10 * time.Millisecond)
+		}
+		return false
+	}
+
+	if _, err = server.ACLSetUser(echovault.User{Username: "auto_user", Enabled: true}); err != nil {
+		t.Fatal(err)
+	}
+	if !waitFor(func(s string) bool { return strings.Contains(s, `"auto_user"`) }) {
+		t.Fatal("expected auto_user to be saved to the ACL config file")
+	}
+
+	if _, err = server.ACLDelUser("auto_user"); err != nil {
+		t.Fatal(err)
+	}
+	if !waitFor(func(s string) bool { return !strings.Contains(s, `"auto_user"`) }) {
+		t.Fatal("expected auto_user to be removed from the ACL config file")
+	}
+}
+
+func TestEchoVault_ACLListRoundTrip(t *testing.T) {
+	server, err := echovault.NewEchoVault(echovault.WithConfig(config.Config{DataDir: ""}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rules := []string{
+		"on", ">password1", "#" + strings.Repeat("a", 64), "+@all", "-@dangerous", "+acl|whoami", "-flushall",
+		"%RW~both:*", "%R~read:*", "%W~write:*", "+&news.*", "-&news.secret",
+	}
+	if _, err = server.ExecuteCommand(context.Background(), append([]string{"ACL", "SETUSER", "original"}, rules...)...); err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := server.ACLList()
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx := slices.IndexFunc(list, func(line string) bool {
+		return strings.HasPrefix(line, "original ")
+	})
+	if idx == -1 {
+		t.Fatalf("expected original user in ACL LIST, got %v", list)
+	}
+
+	// The rules listed by ACL LIST create an identical user when passed to ACL SETUSER.
+	listed := strings.Split(list[idx], " ")[1:]
+	if _, err = server.ExecuteCommand(context.Background(), append([]string{"ACL", "SETUSER", "copy"}, listed...)...); err != nil {
+		t.Fatal(err)
+	}
+	original, err := server.ACLGetUser("original")
+	if err != nil {
+		t.Fatal(err)
+	}
+	copied, err := server.ACLGetUser("copy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, section := range []string{"flags", "passwords", "categories", "commands", "keys", "channels"} {
+		want, got := slices.Clone(original[section]), slices.Clone(copied[section])
+		slices.Sort(want)
+		slices.Sort(got)
+		if len(want) == 0 || !reflect.DeepEqual(want, got) {
+			t.Errorf("expected %s %v, got %v", section, want, got)
+		}
+	}
+	if !slices.Contains(original["keys"], "%W~write:*") {
+		t.Errorf("expected %%W~write:* in keys, got %v", original["keys"])
+	}
+
+	// Embedded calls are not associated with a connection and run as the default user.
+	if reply, err := server.ExecuteCommand(context.Background(), "ACL", "WHOAMI"); err != nil || reply.String != "default" {
+		t.Errorf("expected ACL WHOAMI to return default, got %+v (%v)", reply, err)
+	}
+}
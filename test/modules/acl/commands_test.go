@@ -15,6 +15,7 @@
 package acl
 
 import (
+	"context"
 	"crypto/sha256"
 	"fmt"
 	"github.com/echovault/echovault/echovault"
@@ -28,6 +29,7 @@ import (
 	"strings"
 	"sync"
 	"testing"
+	"time"
 	"unsafe"
 )
 
@@ -300,6 +302,81 @@ func Test_HandleAuth(t *testing.T) {
 	}
 }
 
+func Test_HandleHelloAuth(t *testing.T) {
+	conn, err := net.Dial("tcp", fmt.Sprintf("%s:%d", bindAddr, port))
+	if err != nil {
+		t.Error(err)
+	}
+	defer func() {
+		if conn != nil {
+			_ = conn.Close()
+		}
+	}()
+	r := resp.NewConn(conn)
+
+	tests := []struct {
+		cmd     []string
+		wantRes string
+		wantErr string
+	}{
+		{ // 1. Reject HELLO without AUTH before the connection is authenticated
+			cmd:     []string{"HELLO", "2"},
+			wantErr: "Error user must be authenticated",
+		},
+		{ // 2. Fail to authenticate with the wrong password
+			cmd:     []string{"HELLO", "2", "AUTH", "with_password_user", "wrong_password"},
+			wantErr: "Error could not authenticate user",
+		},
+		{ // 3. Authenticate and set the client name with HELLO
+			cmd:     []string{"HELLO", "2", "AUTH", "with_password_user", "password2", "SETNAME", "hello-client"},
+			wantRes: "with_password_user",
+		},
+	}
+
+	for _, test := range tests {
+		cmd := make([]resp.Value, len(test.cmd))
+		for i, arg := range test.cmd {
+			cmd[i] = resp.StringValue(arg)
+		}
+		if err = r.WriteArray(cmd); err != nil {
+			t.Error(err)
+		}
+		rv, _, err := r.ReadValue()
+		if err != nil {
+			t.Error(err)
+		}
+		if test.wantErr != "" {
+			if rv.Error() == nil || rv.Error().Error() != test.wantErr {
+				t.Errorf("expected error response \"%s\", got \"%s\"", test.wantErr, rv.String())
+			}
+			continue
+		}
+		if rv.Type() == resp.Error {
+			t.Errorf("expected HELLO to succeed, got \"%s\"", rv.Error().Error())
+			continue
+		}
+		// The connection is now authenticated as the user and has the client name.
+		if err = r.WriteArray([]resp.Value{resp.StringValue("ACL"), resp.StringValue("WHOAMI")}); err != nil {
+			t.Error(err)
+		}
+		if rv, _, err = r.ReadValue(); err != nil {
+			t.Error(err)
+		}
+		if rv.String() != test.wantRes {
+			t.Errorf("expected whoami response to be \"%s\", got \"%s\"", test.wantRes, rv.String())
+		}
+		if err = r.WriteArray([]resp.Value{resp.StringValue("CLIENT"), resp.StringValue("GETNAME")}); err != nil {
+			t.Error(err)
+		}
+		if rv, _, err = r.ReadValue(); err != nil {
+			t.Error(err)
+		}
+		if rv.String() != "hello-client" {
+			t.Errorf("expected client name to be \"hello-client\", got \"%s\"", rv.String())
+		}
+	}
+}
+
 func Test_HandleCat(t *testing.T) {
 	conn, err := net.Dial("tcp", fmt.Sprintf("%s:%d", bindAddr, port))
 	if err != nil {
@@ -1111,6 +1188,11 @@ func Test_HandleGetUser(t *testing.T) {
 				resp.ArrayValue([]resp.Value{
 					resp.StringValue("on"),
 				}),
+				resp.StringValue("passwords"),
+				resp.ArrayValue([]resp.Value{
+					resp.StringValue(">get_user_password_1"),
+					resp.StringValue(fmt.Sprintf("#%s", generateSHA256Password("get_user_password_2"))),
+				}),
 				resp.StringValue("categories"),
 				resp.ArrayValue([]resp.Value{
 					resp.StringValue(fmt.Sprintf("+@%s", constants.WriteCategory)),
@@ -1181,7 +1263,7 @@ func Test_HandleGetUser(t *testing.T) {
 		}
 		resArr := v.Array()
 		for i := 0; i < len(resArr); i++ {
-			if slices.Contains([]string{"username", "flags", "categories", "commands", "keys", "channels"}, resArr[i].String()) {
+			if slices.Contains([]string{"username", "flags", "passwords", "categories", "commands", "keys", "channels"}, resArr[i].String()) {
 				// String item
 				if resArr[i].String() != test.wantRes[i].String() {
 					t.Errorf("expected response component %+v, got %+v", test.wantRes[i], resArr[i])
@@ -1447,9 +1529,9 @@ func Test_HandleList(t *testing.T) {
 				fmt.Sprintf("with_password_user on >password2 #%s +@all +all", generateSHA256Password("password3")),
 				"no_password_user on nopass >password4",
 				"disabled_user off >password5",
-				fmt.Sprintf(`list_user_1 on >list_user_password_1 #%s +@write +@read +@pubsub -@admin -@connection -@dangerous +acl|setuser +acl|getuser +acl|deluser -rewriteaof -save -acl|load -acl|save %s +&channel1 +&channel2 -&channel3 -&channel4`, generateSHA256Password("list_user_password_2"), "%RW~key1 %RW~key2 %R~key3 %R~key4"),
+				fmt.Sprintf(`list_user_1 on >list_user_password_1 #%s +@write +@read +@pubsub -@admin -@connection -@dangerous +acl|setuser +acl|getuser +acl|deluser -rewriteaof -save -acl|load -acl|save %s +&channel1 +&channel2 -&channel3 -&channel4`, generateSHA256Password("list_user_password_2"), "%RW~key1 %RW~key2 %R~key3 %R~key4 %W~key5 %W~key6"),
 				fmt.Sprintf(`list_user_2 on nopass nokeys +@write +@read +@pubsub -@admin -@connection -@dangerous +acl|setuser +acl|getuser +acl|deluser -rewriteaof -save -acl|load -acl|save +&channel1 +&channel2 -&channel3 -&channel4`),
-				fmt.Sprintf(`list_user_3 on >list_user_password_3 #%s +@write +@read +@pubsub -@admin -@connection -@dangerous +acl|setuser +acl|getuser +acl|deluser -rewriteaof -save -acl|load -acl|save %s +&channel1 +&channel2 -&channel3 -&channel4`, generateSHA256Password("list_user_password_4"), "%RW~key1 %RW~key2 %R~key3 %R~key4"),
+				fmt.Sprintf(`list_user_3 on >list_user_password_3 #%s +@write +@read +@pubsub -@admin -@connection -@dangerous +acl|setuser +acl|getuser +acl|deluser -rewriteaof -save -acl|load -acl|save %s +&channel1 +&channel2 -&channel3 -&channel4`, generateSHA256Password("list_user_password_4"), "%RW~key1 %RW~key2 %R~key3 %R~key4 %W~key5 %W~key6"),
 			},
 			wantErr: "",
 		},
@@ -1488,3 +1570,370 @@ func Test_HandleList(t *testing.T) {
 		}
 	}
 }
+
+func Test_PubSubChannelAuthorisation(t *testing.T) {
+	newsUser := acl.CreateUser("news_user")
+	newsUser.Passwords = []acl.Password{{PasswordType: acl.PasswordPlainText, PasswordValue: "password6"}}
+	newsUser.IncludedCategories = []string{"*"}
+	newsUser.IncludedCommands = []string{"*"}
+	newsUser.IncludedPubSubChannels = []string{"news.*", "n?ws"}
+	newsUser.ExcludedPubSubChannels = []string{"news.secret"}
+
+	allChannelsUser := acl.CreateUser("all_channels_user")
+	allChannelsUser.Passwords = []acl.Password{{PasswordType: acl.PasswordPlainText, PasswordValue: "password7"}}
+	allChannelsUser.IncludedCategories = []string{"*"}
+	allChannelsUser.IncludedCommands = []string{"*"}
+	allChannelsUser.IncludedPubSubChannels = []string{"*"}
+	allChannelsUser.ExcludedPubSubChannels = []string{"secret"}
+
+	getACL(mockServer).AddUsers([]*acl.User{newsUser, allChannelsUser})
+
+	tests := []struct {
+		name    string
+		user    []string
+		cmd     []string
+		wantErr string
+	}{
+		{
+			name: "1. Subscribe to a channel that matches an included pattern",
+			user: []string{"news_user", "password6"},
+			cmd:  []string{"SUBSCRIBE", "news.sport"},
+		},
+		{
+			name:    "2. Subscribe to an excluded channel",
+			user:    []string{"news_user", "password6"},
+			cmd:     []string{"SUBSCRIBE", "news.secret"},
+			wantErr: "Error not authorised to access channel &news.secret",
+		},
+		{
+			name: "3. Subscribe to a pattern that is the same as an included pattern",
+			user: []string{"news_user", "password6"},
+			cmd:  []string{"PSUBSCRIBE", "n?ws"},
+		},
+		{
+			name:    "4. Subscribe to an included pattern that matches an excluded channel",
+			user:    []string{"news_user", "password6"},
+			cmd:     []string{"PSUBSCRIBE", "news.*"},
+			wantErr: "Error not authorised to access channel &news.*",
+		},
+		{
+			name:    "5. Subscribe to a pattern that matches more channels than the included pattern",
+			user:    []string{"news_user", "password6"},
+			cmd:     []string{"PSUBSCRIBE", "n*ws"},
+			wantErr: "Error not authorised to access channel &n*ws",
+		},
+		{
+			name:    "6. Subscribe to a narrower pattern that is not an included pattern",
+			user:    []string{"news_user", "password6"},
+			cmd:     []string{"PSUBSCRIBE", "news.s*"},
+			wantErr: "Error not authorised to access channel &news.s*",
+		},
+		{
+			name: "7. Subscribe to a pattern when all channels are included",
+			user: []string{"all_channels_user", "password7"},
+			cmd:  []string{"PSUBSCRIBE", "news.*"},
+		},
+		{
+			name:    "8. Subscribe to a pattern that matches an excluded channel",
+			user:    []string{"all_channels_user", "password7"},
+			cmd:     []string{"PSUBSCRIBE", "*"},
+			wantErr: "Error not authorised to access channel &*",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			conn, err := net.Dial("tcp", fmt.Sprintf("%s:%d", bindAddr, port))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer func() {
+				_ = conn.Close()
+			}()
+			r := resp.NewConn(conn)
+
+			auth := []resp.Value{resp.StringValue("AUTH")}
+			for _, s := range test.user {
+				auth = append(auth, resp.StringValue(s))
+			}
+			if err = r.WriteArray(auth); err != nil {
+				t.Error(err)
+				return
+			}
+			if rv, _, err := r.ReadValue(); err != nil || rv.String() != "OK" {
+				t.Errorf("expected AUTH to return OK, got %+v, %v", rv, err)
+				return
+			}
+
+			cmd := make([]resp.Value, len(test.cmd))
+			for i, s := range test.cmd {
+				cmd[i] = resp.StringValue(s)
+			}
+			if err = r.WriteArray(cmd); err != nil {
+				t.Error(err)
+				return
+			}
+			rv, _, err := r.ReadValue()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if test.wantErr != "" {
+				if rv.Error() == nil || rv.Error().Error() != test.wantErr {
+					t.Errorf("expected error \"%s\", got %+v", test.wantErr, rv)
+				}
+				return
+			}
+			if rv.Error() != nil {
+				t.Errorf("expected no error, got \"%s\"", rv.Error().Error())
+			}
+		})
+	}
+}
+
+func Test_GlobRules(t *testing.T) {
+	do := func(r *resp.Conn, cmd ...string) (resp.Value, error) {
+		values := make([]resp.Value, len(cmd))
+		for i, s := range cmd {
+			values[i] = resp.StringValue(s)
+		}
+		if err := r.WriteArray(values); err != nil {
+			return resp.Value{}, err
+		}
+		rv, _, err := r.ReadValue()
+		return rv, err
+	}
+
+	adminConn, err := net.Dial("tcp", fmt.Sprintf("%s:%d", bindAddr, port))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer func() {
+		_ = adminConn.Close()
+	}()
+	admin := resp.NewConn(adminConn)
+	for _, cmd := range [][]string{
+		{"AUTH", "password1"},
+		{"ACL", "SETUSER", "glob_user", "on", ">password8", "allCategories", "+get*", "+set", "+mget", "+publish", "+sort", "+sort_ro", "-getbit",
+			"~user:*", "+&events.*"},
+	} {
+		if rv, err := do(admin, cmd...); err != nil || rv.String() != "OK" {
+			t.Errorf("expected %s to return OK, got %+v, %v", cmd[0], rv, err)
+			return
+		}
+	}
+
+	conn, err := net.Dial("tcp", fmt.Sprintf("%s:%d", bindAddr, port))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	r := resp.NewConn(conn)
+	if rv, err := do(r, "AUTH", "glob_user", "password8"); err != nil || rv.String() != "OK" {
+		t.Errorf("expected AUTH to return OK, got %+v, %v", rv, err)
+		return
+	}
+
+	tests := []struct {
+		name    string
+		cmd     []string
+		wantRes string
+		wantErr string
+	}{
+		{
+			name:    "1. Allow a command that matches a command glob and a key that matches a key glob",
+			cmd:     []string{"SET", "user:1", "value1"},
+			wantRes: "OK",
+		},
+		{
+			name:    "2. Allow another command that matches the command glob",
+			cmd:     []string{"GETRANGE", "user:1", "0", "4"},
+			wantRes: "value",
+		},
+		{
+			name:    "3. Reject an excluded command that matches the command glob",
+			cmd:     []string{"GETBIT", "user:1", "0"},
+			wantErr: "Error not authorised to run getbit command",
+		},
+		{
+			name:    "4. Reject a command that does not match any command rule",
+			cmd:     []string{"DEL", "user:1"},
+			wantErr: "Error not authorised to run del command",
+		},
+		{
+			name:    "5. Reject a key that does not match the key glob",
+			cmd:     []string{"SET", "account:1", "value1"},
+			wantErr: "Error not authorised to access the following keys [%W~account:1]",
+		},
+		{
+			name:    "6. Reject a command if any of its keys does not match the key glob",
+			cmd:     []string{"MGET", "user:1", "account:1"},
+			wantErr: "Error not authorised to access the following keys [%R~account:1]",
+		},
+		{
+			name:    "7. Allow a channel that matches the channel glob",
+			cmd:     []string{"PUBLISH", "events.login", "message"},
+			wantRes: "OK",
+		},
+		{
+			name:    "8. Reject a channel that does not match the channel glob",
+			cmd:     []string{"PUBLISH", "alerts.login", "message"},
+			wantErr: "Error not authorised to access channel &alerts.login",
+		},
+		{
+			name:    "9. Reject the GET option of SORT without access to all the keys",
+			cmd:     []string{"SORT", "user:list", "GET", "account:*"},
+			wantErr: "Error GET option of SORT denied due to insufficient ACL permissions",
+		},
+		{
+			name:    "10. Reject the BY option of SORT_RO without access to all the keys",
+			cmd:     []string{"SORT_RO", "user:list", "BY", "account:*"},
+			wantErr: "Error BY option of SORT denied due to insufficient ACL permissions",
+		},
+		{
+			name:    "11. Allow the BY option of SORT without a pattern",
+			cmd:     []string{"SORT", "user:list", "BY", "nosort", "STORE", "user:2"},
+			wantRes: "0",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rv, err := do(r, test.cmd...)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if test.wantErr != "" {
+				if rv.Error() == nil || rv.Error().Error() != test.wantErr {
+					t.Errorf("expected error \"%s\", got %+v", test.wantErr, rv)
+				}
+				return
+			}
+			if rv.Error() != nil {
+				t.Errorf("expected no error, got \"%s\"", rv.Error().Error())
+				return
+			}
+			if rv.String() != test.wantRes {
+				t.Errorf("expected response \"%s\", got \"%s\"", test.wantRes, rv.String())
+			}
+		})
+	}
+}
+
+func Test_UserLimits(t *testing.T) {
+	var port uint16 = 7502
+	mockServer, err := echovault.NewEchoVault(
+		echovault.WithConfig(config.Config{
+			BindAddr:       bindAddr,
+			Port:           port,
+			DataDir:        "",
+			EvictionPolicy: constants.NoEviction,
+			AuthRateLimit:  3,
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		wg.Done()
+		mockServer.Start()
+	}()
+	wg.Wait()
+	defer mockServer.ShutDown(context.Background())
+
+	if err = getACL(mockServer).SetUser([]string{"limited_user", "on", "nopass", "ratelimit:2", "maxconns:1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	dial := func() (net.Conn, *resp.Conn) {
+		// Retry until the server is listening.
+		var conn net.Conn
+		var err error
+		for i := 0; i < 100; i++ {
+			if conn, err = net.Dial("tcp", fmt.Sprintf("%s:%d", bindAddr, port)); err == nil {
+				break
+			}
+			<-time.After(10 * time.Millisecond)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = conn.Close() })
+		return conn, resp.NewConn(conn)
+	}
+	do := func(r *resp.Conn, cmd ...string) string {
+		args := make([]resp.Value, len(cmd))
+		for i, arg := range cmd {
+			args[i] = resp.StringValue(arg)
+		}
+		if err := r.WriteArray(args); err != nil {
+			t.Fatal(err)
+		}
+		v, _, err := r.ReadValue()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v.Type() == resp.Error {
+			return v.Error().Error()
+		}
+		return v.String()
+	}
+
+	// The user is limited to 2 commands per second.
+	netConn1, conn1 := dial()
+	if res := do(conn1, "AUTH", "limited_user", "password"); res != "OK" {
+		t.Fatalf("expected AUTH to return OK, got %s", res)
+	}
+	for i := 0; i < 2; i++ {
+		if res := do(conn1, "PING"); res != "PONG" {
+			t.Errorf("expected PING %d to return PONG, got %s", i+1, res)
+		}
+	}
+	if res := do(conn1, "PING"); res != "Error rate limit exceeded for user limited_user" {
+		t.Errorf("expected rate limit error, got %s", res)
+	}
+	<-time.After(600 * time.Millisecond)
+	if res := do(conn1, "PING"); res != "PONG" {
+		t.Errorf("expected PING to return PONG once the limit has refilled, got %s", res)
+	}
+
+	// Only one connection can be authenticated as the user at a time.
+	_, conn2 := dial()
+	if res := do(conn2, "AUTH", "limited_user", "password"); res != "Error too many connections for user limited_user" {
+		t.Errorf("expected max connections error, got %s", res)
+	}
+
+	// AUTH attempts from the same address are limited to 3 per second, so at most 2 attempts are left.
+	_, conn3 := dial()
+	if res := do(conn3, "AUTH", "wrong_user", "password"); res != "Error no user with username wrong_user" {
+		t.Errorf("expected unknown user error, got %s", res)
+	}
+	do(conn3, "AUTH", "wrong_user", "password")
+	if res := do(conn3, "AUTH", "wrong_user", "password"); res != "Error too many authentication attempts, try again later" {
+		t.Errorf("expected authentication rate limit error, got %s", res)
+	}
+
+	// Closing the first connection frees a connection for the user.
+	_ = netConn1.Close()
+	<-time.After(1100 * time.Millisecond)
+	if res := do(conn2, "AUTH", "limited_user", "password"); res != "OK" {
+		t.Errorf("expected AUTH to return OK after the first connection closed, got %s", res)
+	}
+
+	// The limits are listed with the user's rules.
+	user, err := mockServer.ACLGetUser("limited_user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Contains(user["flags"], "ratelimit:2") || !slices.Contains(user["flags"], "maxconns:1") {
+		t.Errorf("expected the limits in the user's flags, got %v", user["flags"])
+	}
+}
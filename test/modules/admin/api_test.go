@@ -16,15 +16,25 @@ package admin
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/echovault/echovault/echovault"
 	"github.com/echovault/echovault/internal/config"
 	"github.com/echovault/echovault/internal/constants"
 	"github.com/echovault/echovault/types"
-	"github.com/tidwall/resp"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"path"
+	"reflect"
 	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func createEchoVault() *echovault.EchoVault {
@@ -179,6 +189,39 @@ The value passed must be an integer.`,
 				},
 			},
 		},
+		{
+			name:    "3 Recover from a panic in the command handler",
+			wantErr: false,
+			args: args{
+				command: echovault.CommandOptions{
+					Command:     "CommandThree",
+					Module:      "test-module",
+					Description: `(CommandThree) Test command that panics.`,
+					Categories:  []string{},
+					Sync:        false,
+					KeyExtractionFunc: func(cmd []string) (types.CommandKeyExtractionFuncResult, error) {
+						return types.CommandKeyExtractionFuncResult{}, nil
+					},
+					HandlerFunc: func(params types.CommandHandlerFuncParams) ([]byte, error) {
+						panic("unexpected state")
+					},
+				},
+			},
+			scenarios: []scenarios{
+				{
+					name:    "1 Return internal error when the handler panics",
+					command: []string{"CommandThree"},
+					wantRes: 0,
+					wantErr: errors.New("internal error"),
+				},
+				{
+					name:    "2 Keep serving commands after the handler panics",
+					command: []string{"LPUSH", "panic-key1", "1", "2"},
+					wantRes: 2,
+					wantErr: nil,
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		server := createEchoVault()
@@ -187,17 +230,15 @@ The value passed must be an integer.`,
 				t.Errorf("AddCommand() error = %v, wantErr %v", err, tt.wantErr)
 			}
 			for _, scenario := range tt.scenarios {
-				b, err := server.ExecuteCommand(scenario.command...)
+				reply, err := server.ExecuteCommand(context.Background(), scenario.command...)
 				if scenario.wantErr != nil {
 					if scenario.wantErr.Error() != err.Error() {
 						t.Errorf("AddCommand() error = %v, wantErr %v", err, scenario.wantErr)
 					}
 					continue
 				}
-				r := resp.NewReader(bytes.NewReader(b))
-				v, _, _ := r.ReadValue()
-				if v.Integer() != scenario.wantRes {
-					t.Errorf("AddCommand() res = %v, wantRes %v", resp.BytesValue(b).Integer(), scenario.wantRes)
+				if reply.Integer != scenario.wantRes {
+					t.Errorf("AddCommand() res = %v, wantRes %v", reply.Integer, scenario.wantRes)
 				}
 			}
 		})
@@ -243,22 +284,131 @@ func TestEchoVault_ExecuteCommand(t *testing.T) {
 			if tt.args.presetValue != nil {
 				_, _ = server.LPush(tt.args.key, tt.args.presetValue...)
 			}
-			b, err := server.ExecuteCommand(tt.args.command...)
+			reply, err := server.ExecuteCommand(context.Background(), tt.args.command...)
 			if tt.wantErr != nil {
 				if err.Error() != tt.wantErr.Error() {
 					t.Errorf("ExecuteCommand() error = %v, wantErr %v", err, tt.wantErr)
 				}
 			}
-			r := resp.NewReader(bytes.NewReader(b))
-			v, _, _ := r.ReadValue()
-			if v.Integer() != tt.wantRes {
-				fmt.Println("RES: ", string(b))
-				t.Errorf("ExecuteCommand() response = %d, wantRes %d", v.Integer(), tt.wantRes)
+			if reply.Integer != tt.wantRes {
+				t.Errorf("ExecuteCommand() response = %d, wantRes %d", reply.Integer, tt.wantRes)
 			}
 		})
 	}
 }
 
+func TestEchoVault_ExecuteCommandReply(t *testing.T) {
+	server := createEchoVault()
+
+	if _, err := server.ExecuteCommand(context.Background(), "SET", "ReplyKey1", "value1"); err != nil {
+		t.Error(err)
+		return
+	}
+	if _, err := server.ExecuteCommand(context.Background(), "RPUSH", "ReplyKey2", "a", "b"); err != nil {
+		t.Error(err)
+		return
+	}
+
+	tests := []struct {
+		name      string
+		command   []string
+		wantType  echovault.ReplyType
+		wantValue interface{}
+	}{
+		{
+			name:      "1 Parse string reply",
+			command:   []string{"GET", "ReplyKey1"},
+			wantType:  echovault.ReplyString,
+			wantValue: "value1",
+		},
+		{
+			name:      "2 Parse integer reply",
+			command:   []string{"LLEN", "ReplyKey2"},
+			wantType:  echovault.ReplyInteger,
+			wantValue: 2,
+		},
+		{
+			name:      "3 Parse array reply",
+			command:   []string{"LRANGE", "ReplyKey2", "0", "-1"},
+			wantType:  echovault.ReplyArray,
+			wantValue: []string{"a", "b"},
+		},
+		{
+			name:      "4 Parse nil reply",
+			command:   []string{"GET", "ReplyKey3"},
+			wantType:  echovault.ReplyNil,
+			wantValue: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reply, err := server.ExecuteCommand(context.Background(), tt.command...)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if reply.Type != tt.wantType {
+				t.Errorf("ExecuteCommand() type = %s, want %s", reply.Type, tt.wantType)
+				return
+			}
+			var got interface{}
+			switch reply.Type {
+			case echovault.ReplyString:
+				got = reply.String
+			case echovault.ReplyInteger:
+				got = reply.Integer
+			case echovault.ReplyArray:
+				got = reply.Strings()
+			}
+			if !reflect.DeepEqual(got, tt.wantValue) {
+				t.Errorf("ExecuteCommand() value = %v, want %v", got, tt.wantValue)
+			}
+		})
+	}
+}
+
+func TestEchoVault_Router(t *testing.T) {
+	if _, err := echovault.NewRouter(); err == nil {
+		t.Error("expected error when creating a router without nodes")
+	}
+
+	// Standalone nodes are leaders with no followers, so every command is executed on the first node.
+	first, second := createEchoVault(), createEchoVault()
+	router, err := echovault.NewRouter(first, second)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if leader, err := router.Leader(); err != nil || leader != first {
+		t.Errorf("expected the first node to be the leader, got %v (%v)", leader, err)
+	}
+	if followers := router.Followers(); len(followers) != 0 {
+		t.Errorf("expected no followers, got %d", len(followers))
+	}
+
+	ctx := context.Background()
+	if _, err = router.ExecuteCommand(ctx, "SET", "RouterKey1", "value1"); err != nil {
+		t.Error(err)
+		return
+	}
+	reply, err := router.ExecuteCommand(ctx, "GET", "RouterKey1")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if reply.String != "value1" {
+		t.Errorf("expected value1, got %q", reply.String)
+	}
+	if value, _ := second.Get("RouterKey1"); value != "" {
+		t.Errorf("expected the key to only be set on the leader, got %q on the second node", value)
+	}
+
+	if _, err = router.ExecuteCommand(ctx, "UNKNOWNCOMMAND"); err == nil {
+		t.Error("expected error for an unknown command")
+	}
+}
+
 func TestEchoVault_RemoveCommand(t *testing.T) {
 	type args struct {
 		removeCommand  []string
@@ -298,7 +448,7 @@ func TestEchoVault_RemoveCommand(t *testing.T) {
 		server := createEchoVault()
 		t.Run(tt.name, func(t *testing.T) {
 			server.RemoveCommand(tt.args.removeCommand...)
-			_, err := server.ExecuteCommand(tt.args.executeCommand...)
+			_, err := server.ExecuteCommand(context.Background(), tt.args.executeCommand...)
 			if tt.wantErr != nil {
 				if err.Error() != tt.wantErr.Error() {
 					t.Errorf("RemoveCommand() error = %v, wantErr %v", err, tt.wantErr)
@@ -307,3 +457,1163 @@ func TestEchoVault_RemoveCommand(t *testing.T) {
 		})
 	}
 }
+
+func TestEchoVault_LatencyHistogram(t *testing.T) {
+	tests := []struct {
+		name      string
+		execute   [][]string
+		commands  []string
+		wantCalls map[string]int
+	}{
+		{
+			name: "1 Return the number of calls for each requested command",
+			execute: [][]string{
+				{"SET", "key1", "value1"},
+				{"SET", "key2", "value2"},
+				{"GET", "key1"},
+			},
+			commands:  []string{"SET", "GET"},
+			wantCalls: map[string]int{"set": 2, "get": 1},
+		},
+		{
+			name: "2 Record sub-commands as command|subcommand",
+			execute: [][]string{
+				{"COMMAND", "COUNT"},
+			},
+			commands:  []string{"command|count"},
+			wantCalls: map[string]int{"command|count": 1},
+		},
+		{
+			name: "3 Omit commands that have not been executed",
+			execute: [][]string{
+				{"SET", "key1", "value1"},
+			},
+			commands:  []string{"SET", "ZADD"},
+			wantCalls: map[string]int{"set": 1},
+		},
+	}
+	for _, tt := range tests {
+		server := createEchoVault()
+		t.Run(tt.name, func(t *testing.T) {
+			for _, cmd := range tt.execute {
+				if _, err := server.ExecuteCommand(context.Background(), cmd...); err != nil {
+					t.Error(err)
+				}
+			}
+			got, err := server.LatencyHistogram(tt.commands...)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if len(got) != len(tt.wantCalls) {
+				t.Errorf("LatencyHistogram() got %d histograms, want %d", len(got), len(tt.wantCalls))
+			}
+			for command, calls := range tt.wantCalls {
+				histogram, ok := got[command]
+				if !ok {
+					t.Errorf("LatencyHistogram() missing histogram for command %s", command)
+					continue
+				}
+				if histogram.Calls != calls {
+					t.Errorf("LatencyHistogram() command %s got calls %d, want %d", command, histogram.Calls, calls)
+				}
+				cumulative := 0
+				for _, count := range histogram.Buckets {
+					cumulative = max(cumulative, count)
+				}
+				if cumulative != calls {
+					t.Errorf("LatencyHistogram() command %s got bucket total %d, want %d", command, cumulative, calls)
+				}
+			}
+		})
+	}
+}
+
+func TestEchoVault_MemoryStats(t *testing.T) {
+	server, _ := echovault.NewEchoVault(
+		echovault.WithConfig(config.Config{
+			DataDir:         "",
+			DefragInterval:  10 * time.Millisecond,
+			DefragThreshold: 0.5,
+		}),
+	)
+
+	keys := make([]string, 100)
+	for i := 0; i < len(keys); i++ {
+		keys[i] = fmt.Sprintf("key%d", i)
+		if _, err := server.Set(keys[i], "value", echovault.SetOptions{}); err != nil {
+			t.Error(err)
+			return
+		}
+	}
+
+	stats, err := server.MemoryStats()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if stats["keys.count"] != 100 || stats["keys.peak"] != 100 {
+		t.Errorf("expected keys.count and keys.peak to be 100, got %d and %d", stats["keys.count"], stats["keys.peak"])
+	}
+
+	// Delete most of the keys so that the number of keys drops below the threshold.
+	if _, err = server.Del(keys[:90]...); err != nil {
+		t.Error(err)
+		return
+	}
+
+	// Wait for the background compaction to run.
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if stats, err = server.MemoryStats(); err != nil {
+			t.Error(err)
+			return
+		}
+		if stats["defrag.runs"] > 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if stats["defrag.runs"] != 1 {
+		t.Errorf("expected defrag.runs to be 1, got %d", stats["defrag.runs"])
+	}
+	if stats["keys.count"] != 10 || stats["keys.peak"] != 10 {
+		t.Errorf("expected keys.count and keys.peak to be 10, got %d and %d", stats["keys.count"], stats["keys.peak"])
+	}
+	if stats["defrag.released.keys"] != 90 {
+		t.Errorf("expected defrag.released.keys to be 90, got %d", stats["defrag.released.keys"])
+	}
+	if stats["defrag.last.run"] == 0 {
+		t.Errorf("expected defrag.last.run to be set")
+	}
+}
+
+func TestEchoVault_MemoryStatsCompactionWhileRunningCommands(t *testing.T) {
+	// Run with -race, as the compaction replaces the maps that the commands read and write.
+	server, _ := echovault.NewEchoVault(
+		echovault.WithConfig(config.Config{
+			DataDir:         "",
+			DefragInterval:  time.Millisecond,
+			DefragThreshold: 0.5,
+		}),
+	)
+
+	stop := make(chan struct{})
+	errs := make(chan error, 8)
+	wg := sync.WaitGroup{}
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			keys := make([]string, 100)
+			for j := 0; j < len(keys); j++ {
+				keys[j] = fmt.Sprintf("compaction%d:%d", i, j)
+			}
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				// Create the keys and delete most of them so that the keyspace is compacted again.
+				for _, key := range keys {
+					if _, err := server.Set(key, "value", echovault.SetOptions{}); err != nil {
+						errs <- err
+						return
+					}
+				}
+				if _, err := server.Del(keys[10:]...); err != nil {
+					errs <- err
+					return
+				}
+				for _, key := range keys[:10] {
+					value, err := server.Get(key)
+					if err != nil {
+						errs <- err
+						return
+					}
+					if value != "value" {
+						errs <- fmt.Errorf("expected value at %s, got %q", key, value)
+						return
+					}
+				}
+			}
+		}(i)
+	}
+
+	// Let the commands run until the keyspace has been compacted several times.
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		stats, err := server.MemoryStats()
+		if err != nil {
+			t.Error(err)
+			break
+		}
+		if stats["defrag.runs"] >= 5 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	close(stop)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+	stats, err := server.MemoryStats()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if stats["defrag.runs"] < 5 {
+		t.Errorf("expected defrag.runs to be at least 5, got %d", stats["defrag.runs"])
+	}
+}
+
+func TestEchoVault_ConfigSet(t *testing.T) {
+	server := createEchoVault()
+
+	tests := []struct {
+		name      string
+		parameter string
+		value     string
+		wantValue string
+		wantErr   error
+	}{
+		{
+			name:      "1. Update snapshot-threshold",
+			parameter: "snapshot-threshold",
+			value:     "50",
+			wantValue: "50",
+			wantErr:   nil,
+		},
+		{
+			name:      "2. Update snapshot-interval",
+			parameter: "snapshot-interval",
+			value:     "1m30s",
+			wantValue: "1m30s",
+			wantErr:   nil,
+		},
+		{
+			name:      "3. Update data-dir",
+			parameter: "data-dir",
+			value:     "/tmp/echovault",
+			wantValue: "/tmp/echovault",
+			wantErr:   nil,
+		},
+		{
+			name:      "4. Return error when snapshot-threshold is not an integer",
+			parameter: "snapshot-threshold",
+			value:     "fifty",
+			wantErr:   errors.New("snapshot-threshold must be a positive integer, got fifty"),
+		},
+		{
+			name:      "5. Return error when parameter cannot be updated at runtime",
+			parameter: "port",
+			value:     "7480",
+			wantErr:   errors.New("unsupported config parameter port"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := server.ConfigSet(tt.parameter, tt.value)
+			if tt.wantErr != nil {
+				if err == nil || err.Error() != tt.wantErr.Error() {
+					t.Errorf("ConfigSet() error = %v, wantErr %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			got, err := server.ConfigGet(tt.parameter)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if got[tt.parameter] != tt.wantValue {
+				t.Errorf("ConfigGet() got %s = %s, want %s", tt.parameter, got[tt.parameter], tt.wantValue)
+			}
+		})
+	}
+}
+
+func TestEchoVault_Info(t *testing.T) {
+	tests := []struct {
+		name   string
+		files  map[string]string // Files to create in the data directory before starting the server.
+		expect map[string]string
+	}{
+		{
+			name:  "1. Report ok when there are no persisted files",
+			files: map[string]string{},
+			expect: map[string]string{
+				"rdb_last_save_status":       "ok",
+				"rdb_integrity_check_status": "ok",
+				"aof_last_bgrewrite_status":  "ok",
+				"aof_integrity_check_status": "ok",
+				"aof_queue_depth":            "0",
+				"aof_rejected_writes":        "0",
+				"write_stall_protection":     "0",
+				"loading":                    "0",
+				"rdb_bgsave_in_progress":     "0",
+				"aof_rewrite_in_progress":    "0",
+				"aof_current_size":           "0",
+				"aof_base_size":              "0",
+				"aof_last_fsync_time":        "0",
+			},
+		},
+		{
+			name: "2. Report err when the snapshot does not match the manifest checksum",
+			files: map[string]string{
+				path.Join("snapshots", "manifest.bin"):      `{"LatestSnapshotMilliseconds":1000,"LatestSnapshotHash":[0,0,0,0,0,0,0,0,0,0,0,0,0,0,0,0]}`,
+				path.Join("snapshots", "1000", "state.bin"): `{"State":{},"LatestSnapshotMilliseconds":1000}`,
+				path.Join("aof", "log.aof"):                 "*1\r\n$4\r\nPING\r\n\r\n",
+			},
+			expect: map[string]string{
+				"rdb_integrity_check_status": "err",
+				"aof_integrity_check_status": "ok",
+			},
+		},
+		{
+			name: "3. Report err when the last command in the AOF log is incomplete",
+			files: map[string]string{
+				path.Join("aof", "log.aof"): "*1\r\n$4\r\nPING\r\n\r\n*1\r\n$4\r\nPI",
+			},
+			expect: map[string]string{
+				"rdb_integrity_check_status": "ok",
+				"aof_integrity_check_status": "err",
+			},
+		},
+		{
+			name: "4. Report the size of the AOF log",
+			files: map[string]string{
+				path.Join("aof", "log.aof"): "*1\r\n$4\r\nPING\r\n\r\n",
+			},
+			expect: map[string]string{
+				"aof_current_size": "16",
+				"aof_base_size":    "0",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dataDir := t.TempDir()
+			for name, content := range tt.files {
+				if err := os.MkdirAll(path.Dir(path.Join(dataDir, name)), os.ModePerm); err != nil {
+					t.Error(err)
+					return
+				}
+				if err := os.WriteFile(path.Join(dataDir, name), []byte(content), os.ModePerm); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+
+			server, err := echovault.NewEchoVault(
+				echovault.WithConfig(config.Config{
+					DataDir:         dataDir,
+					AOFSyncStrategy: "no",
+				}),
+			)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+
+			info, err := server.Info("persistence")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			for field, want := range tt.expect {
+				if got := info["persistence"][field]; got != want {
+					t.Errorf("Info() field %s got %q, want %q", field, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestEchoVault_CommandStats(t *testing.T) {
+	server := createEchoVault()
+
+	for i := 0; i < 2; i++ {
+		if _, err := server.Set("CommandStatsKey1", "value", echovault.SetOptions{}); err != nil {
+			t.Error(err)
+			return
+		}
+	}
+	// SADD on a string fails with a wrong type error.
+	if _, err := server.SAdd("CommandStatsKey1", "member"); err == nil {
+		t.Error("expected SADD on a string to fail")
+		return
+	}
+
+	info, err := server.Info()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if _, ok := info["commandstats"]; ok {
+		t.Errorf("expected commandstats to not be a default section")
+	}
+
+	info, err = server.Info("commandstats")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	stats := info["commandstats"]
+	for field, want := range map[string][]string{
+		"cmdstat_set":  {"calls=2,", "failed_calls=0"},
+		"cmdstat_sadd": {"calls=1,", "failed_calls=1"},
+	} {
+		for _, w := range want {
+			if !strings.Contains(stats[field], w) {
+				t.Errorf("expected %s to contain %q, got %q", field, w, stats[field])
+			}
+		}
+	}
+
+	// After a reset, only the commands executed since are reported.
+	if ok, err := server.ConfigResetStat(); err != nil || ok != "OK" {
+		t.Errorf("expected OK, got %q (%v)", ok, err)
+		return
+	}
+	info, err = server.Info("all")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if _, ok := info["commandstats"]["cmdstat_set"]; ok {
+		t.Errorf("expected cmdstat_set to be reset, got %q", info["commandstats"]["cmdstat_set"])
+	}
+	if !strings.Contains(info["commandstats"]["cmdstat_config|resetstat"], "calls=1,") {
+		t.Errorf("expected CONFIG RESETSTAT to be recorded after the reset, got %v", info["commandstats"])
+	}
+}
+
+func TestEchoVault_InfoSections(t *testing.T) {
+	server := createEchoVault()
+
+	if _, err := server.Set("InfoSectionsKey1", "value", echovault.SetOptions{}); err != nil {
+		t.Error(err)
+		return
+	}
+	if _, err := server.Set("InfoSectionsKey2", "value", echovault.SetOptions{EX: 100}); err != nil {
+		t.Error(err)
+		return
+	}
+	for _, key := range []string{"InfoSectionsKey1", "InfoSectionsKey2", "InfoSectionsKey3"} {
+		if _, err := server.Get(key); err != nil {
+			t.Error(err)
+			return
+		}
+	}
+
+	info, err := server.Info()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for _, section := range []string{"server", "clients", "memory", "persistence", "stats", "replication", "keyspace"} {
+		if _, ok := info[section]; !ok {
+			t.Errorf("expected %s to be a default section, got %v", section, info)
+		}
+	}
+
+	for section, fields := range map[string]map[string]string{
+		"server":   {"server_mode": "standalone"},
+		"clients":  {"connected_clients": "0"},
+		"memory":   {"maxmemory": "0"},
+		"stats":    {"total_commands_processed": "6", "keyspace_hits": "2", "keyspace_misses": "1", "total_accept_errors": "0"},
+		"keyspace": {"db0": "keys=2,expires=1"},
+	} {
+		for field, want := range fields {
+			if got := info[section][field]; got != want {
+				t.Errorf("expected %s field %s to be %q, got %q", section, field, want, got)
+			}
+		}
+	}
+	if used, err := strconv.Atoi(info["memory"]["used_memory"]); err != nil || used <= 0 {
+		t.Errorf("expected used_memory to be a positive integer, got %q", info["memory"]["used_memory"])
+	}
+
+	// CONFIG RESETSTAT sets the stats back to 0, after which only the INFO command itself has been processed.
+	// Each command is counted before it runs, so INFO counts itself.
+	if _, err = server.ConfigResetStat(); err != nil {
+		t.Error(err)
+		return
+	}
+	if info, err = server.Info("stats"); err != nil {
+		t.Error(err)
+		return
+	}
+	for field, want := range map[string]string{"total_commands_processed": "1", "keyspace_hits": "0", "keyspace_misses": "0"} {
+		if got := info["stats"][field]; got != want {
+			t.Errorf("expected stats field %s to be %q after reset, got %q", field, want, got)
+		}
+	}
+}
+
+func TestEchoVault_SlowLog(t *testing.T) {
+	server, err := echovault.NewEchoVault(
+		echovault.WithConfig(config.Config{
+			DataDir:          "",
+			SlowlogThreshold: 0, // Record every command.
+			SlowlogMaxLen:    3,
+		}),
+	)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	longValue := strings.Repeat("a", 200)
+	for _, key := range []string{"SlowLogKey1", "SlowLogKey2", "SlowLogKey3"} {
+		if _, err = server.Set(key, longValue, echovault.SetOptions{}); err != nil {
+			t.Error(err)
+			return
+		}
+	}
+	if _, err = server.Get("SlowLogKey1"); err != nil {
+		t.Error(err)
+		return
+	}
+
+	// Only the 3 most recent commands are kept.
+	if n, err := server.SlowLogLen(); err != nil || n != 3 {
+		t.Errorf("expected slow log length 3, got %d (%v)", n, err)
+		return
+	}
+
+	entries, err := server.SlowLogGet(-1)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(entries) != 3 {
+		t.Errorf("expected 3 entries, got %d", len(entries))
+		return
+	}
+	// The entries are returned newest first, and the SLOWLOG LEN command is the newest.
+	wantArgs := [][]string{
+		{"SLOWLOG", "LEN"},
+		{"GET", "SlowLogKey1"},
+		{"SET", "SlowLogKey3", longValue[:128] + "... (72 more bytes)"},
+	}
+	for i, entry := range entries {
+		if !reflect.DeepEqual(entry.Args, wantArgs[i]) {
+			t.Errorf("expected entry %d to have args %v, got %v", i, wantArgs[i], entry.Args)
+		}
+		if i > 0 && entry.ID >= entries[i-1].ID {
+			t.Errorf("expected entry IDs to be decreasing, got %d after %d", entry.ID, entries[i-1].ID)
+		}
+	}
+
+	if entries, err = server.SlowLogGet(1); err != nil || len(entries) != 1 {
+		t.Errorf("expected 1 entry, got %d (%v)", len(entries), err)
+	}
+
+	// The RESET command itself is recorded after the log is reset.
+	if ok, err := server.SlowLogReset(); err != nil || ok != "OK" {
+		t.Errorf("expected OK, got %q (%v)", ok, err)
+		return
+	}
+	if n, err := server.SlowLogLen(); err != nil || n != 1 {
+		t.Errorf("expected slow log length 1 after reset, got %d (%v)", n, err)
+	}
+
+	// A negative threshold disables the slow log.
+	if _, err = server.ConfigSet("slowlog-log-slower-than", "-1ms"); err != nil {
+		t.Error(err)
+		return
+	}
+	if _, err = server.SlowLogReset(); err != nil {
+		t.Error(err)
+		return
+	}
+	if n, err := server.SlowLogLen(); err != nil || n != 0 {
+		t.Errorf("expected empty slow log when disabled, got %d (%v)", n, err)
+	}
+}
+
+func TestEchoVault_DebugSelfTest(t *testing.T) {
+	server, err := echovault.NewEchoVault(
+		echovault.WithConfig(config.Config{
+			DataDir: t.TempDir(),
+		}),
+	)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	info, err := server.Info("selftest")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if got := info["selftest"]["selftest_last_run"]; got != "0" {
+		t.Errorf("expected selftest_last_run to be 0 before the first self-test, got %q", got)
+	}
+
+	result, err := server.DebugSelfTest()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for _, field := range []string{"fsync.avg.usec", "fsync.max.usec", "memory.bandwidth.bytes.per.sec", "lock.ops.per.sec"} {
+		if _, ok := result[field]; !ok {
+			t.Errorf("expected self-test result to have field %s, got %v", field, result)
+		}
+	}
+	if result["memory.bandwidth.bytes.per.sec"] <= 0 || result["lock.ops.per.sec"] <= 0 {
+		t.Errorf("expected positive memory bandwidth and lock throughput, got %v", result)
+	}
+	if result["fsync.max.usec"] < result["fsync.avg.usec"] {
+		t.Errorf("expected max fsync latency to be at least the average, got %v", result)
+	}
+
+	if info, err = server.Info("selftest"); err != nil {
+		t.Error(err)
+		return
+	}
+	if got := info["selftest"]["selftest_last_run"]; got == "0" {
+		t.Errorf("expected selftest_last_run to be set after the self-test")
+	}
+	if got, want := info["selftest"]["selftest_lock_ops_per_sec"], strconv.Itoa(result["lock.ops.per.sec"]); got != want {
+		t.Errorf("expected selftest_lock_ops_per_sec to be %s, got %s", want, got)
+	}
+}
+
+func TestEchoVault_AOFReplay(t *testing.T) {
+	dataDir := t.TempDir()
+	if err := os.MkdirAll(path.Join(dataDir, "aof"), os.ModePerm); err != nil {
+		t.Error(err)
+		return
+	}
+	if err := os.WriteFile(path.Join(dataDir, "aof", "log.aof"),
+		[]byte("*3\r\n$3\r\nSET\r\n$4\r\nkey1\r\n$6\r\nvalue1\r\n\r\n"), os.ModePerm); err != nil {
+		t.Error(err)
+		return
+	}
+
+	server, err := echovault.NewEchoVault(
+		echovault.WithConfig(config.Config{
+			DataDir:         dataDir,
+			RestoreAOF:      true,
+			AOFSyncStrategy: "no",
+			EvictionPolicy:  constants.NoEviction,
+		}),
+	)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	// The replayed command should be applied to the store.
+	value, err := server.Get("key1")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if value != "value1" {
+		t.Errorf("expected value \"value1\" at key1, got \"%s\"", value)
+	}
+
+	// The replayed command should not be recorded again in the metrics.
+	histograms, err := server.LatencyHistogram("SET")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if _, ok := histograms["set"]; ok {
+		t.Errorf("expected replayed SET command to not be recorded in the latency histogram")
+	}
+}
+
+func TestEchoVault_HealthProbes(t *testing.T) {
+	server, err := echovault.NewEchoVault(
+		echovault.WithConfig(config.Config{
+			BindAddr:   "localhost",
+			HealthPort: 7497,
+			DataDir:    "",
+		}),
+	)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer server.ShutDown(context.Background())
+
+	for _, probe := range []string{"/healthz", "/livez", "/readyz"} {
+		t.Run(probe, func(t *testing.T) {
+			res, err := http.Get(fmt.Sprintf("http://localhost:7497%s", probe))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer func() {
+				_ = res.Body.Close()
+			}()
+			body, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if res.StatusCode != http.StatusOK {
+				t.Errorf("GET %s got status %d, want %d: %s", probe, res.StatusCode, http.StatusOK, string(body))
+			}
+			if string(body) != "ok\n" {
+				t.Errorf("GET %s got body %q, want %q", probe, string(body), "ok\n")
+			}
+		})
+	}
+}
+
+func TestEchoVault_InitFile(t *testing.T) {
+	dataDir := path.Join(t.TempDir(), "data")
+	initFile := path.Join(t.TempDir(), "init.txt")
+
+	// Inline and RESP commands are mixed, and the failing command does not stop the following ones.
+	commands := "SET InitKey1 value1\n" +
+		"\n" +
+		"*3\r\n$3\r\nSET\r\n$8\r\nInitKey2\r\n$11\r\nvalue two 2\r\n" +
+		"UNKNOWNCOMMAND arg\n" +
+		"SADD InitKey3 member1 member2\n"
+	if err := os.WriteFile(initFile, []byte(commands), 0644); err != nil {
+		t.Error(err)
+		return
+	}
+
+	conf := config.Config{
+		DataDir:         dataDir,
+		InitFile:        initFile,
+		EvictionPolicy:  constants.NoEviction,
+		AOFSyncStrategy: "always",
+	}
+
+	server, err := echovault.NewEchoVault(echovault.WithConfig(conf))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	for key, want := range map[string]string{"InitKey1": "value1", "InitKey2": "value two 2"} {
+		got, err := server.Get(key)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if got != want {
+			t.Errorf("expected %s to be %q, got %q", key, want, got)
+		}
+	}
+	if card, err := server.SCard("InitKey3"); err != nil || card != 2 {
+		t.Errorf("expected InitKey3 to have 2 members, got %d (%v)", card, err)
+	}
+	server.ShutDown(context.Background())
+
+	// The data directory is no longer empty, so the init file is not executed again on the next startup.
+	if err = os.WriteFile(initFile, []byte("SET InitKey4 value4\n"), 0644); err != nil {
+		t.Error(err)
+		return
+	}
+	server, err = echovault.NewEchoVault(echovault.WithConfig(conf))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer server.ShutDown(context.Background())
+	if got, err := server.Get("InitKey4"); err != nil || got != "" {
+		t.Errorf("expected InitKey4 to not be set, got %q (%v)", got, err)
+	}
+}
+
+func TestEchoVault_DebugChangeReplicationID(t *testing.T) {
+	server := createEchoVault()
+
+	info, err := server.Info("replication")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	replication := info["replication"]
+	if replication["role"] != "master" {
+		t.Errorf("expected role to be master, got %q", replication["role"])
+	}
+	if len(replication["master_replid"]) != 40 {
+		t.Errorf("expected master_replid to be 40 characters, got %q", replication["master_replid"])
+	}
+
+	// The replication offset increases with each write command.
+	if _, err = server.Set("key1", "value1", echovault.SetOptions{}); err != nil {
+		t.Error(err)
+		return
+	}
+	if info, err = server.Info("replication"); err != nil {
+		t.Error(err)
+		return
+	}
+	if offset, _ := strconv.Atoi(info["replication"]["master_repl_offset"]); offset <= 0 {
+		t.Errorf("expected master_repl_offset to be greater than 0, got %q", info["replication"]["master_repl_offset"])
+	}
+	if info["replication"]["master_replid"] != replication["master_replid"] {
+		t.Errorf("expected master_replid to be unchanged, got %q, want %q",
+			info["replication"]["master_replid"], replication["master_replid"])
+	}
+
+	ok, err := server.DebugChangeReplicationID()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if ok != "OK" {
+		t.Errorf("expected response OK, got %q", ok)
+	}
+	if info, err = server.Info("replication"); err != nil {
+		t.Error(err)
+		return
+	}
+	if info["replication"]["master_replid"] == replication["master_replid"] {
+		t.Errorf("expected master_replid to change from %q", replication["master_replid"])
+	}
+}
+
+func TestEchoVault_Trace(t *testing.T) {
+	traceFile := path.Join(t.TempDir(), "trace.jsonl")
+	server, err := echovault.NewEchoVault(
+		echovault.WithConfig(config.Config{
+			DataDir:        "",
+			EvictionPolicy: constants.NoEviction,
+			TraceFile:      traceFile,
+		}),
+	)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	if _, err = server.Set("key1", "value1", echovault.SetOptions{}); err != nil {
+		t.Error(err)
+		return
+	}
+	if _, err = server.LPush("list1", "a", "b"); err != nil {
+		t.Error(err)
+		return
+	}
+	if _, err = server.Rename("key1", "key2"); err != nil {
+		t.Error(err)
+		return
+	}
+	if _, err = server.Get("key2"); err != nil {
+		t.Error(err)
+		return
+	}
+	if _, err = server.Rename("key3", "key4"); err == nil {
+		t.Error("expected RENAME of a missing key to return an error")
+		return
+	}
+	if _, err = server.Del("key2", "list1"); err != nil {
+		t.Error(err)
+		return
+	}
+	server.ShutDown(context.Background())
+
+	expected := []string{
+		`{"seq":1,"command":["SET","key1","value1"],"reply":"+OK\r\n","modified":["key1"]}`,
+		`{"seq":2,"command":["LPUSH","list1","a","b"],"reply":":2\r\n","modified":["list1"]}`,
+		`{"seq":3,"command":["RENAME","key1","key2"],"reply":"+OK\r\n","modified":["key2"],"deleted":["key1"]}`,
+		`{"seq":4,"command":["GET","key2"],"reply":"+value1\r\n"}`,
+		`{"seq":5,"command":["RENAME","key3","key4"],"error":"no such key"}`,
+		`{"seq":6,"command":["DEL","key2","list1"],"reply":":2\r\n","deleted":["key2","list1"]}`,
+	}
+
+	b, err := os.ReadFile(traceFile)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if len(lines) != len(expected) {
+		t.Errorf("expected %d trace entries, got %d:\n%s", len(expected), len(lines), string(b))
+		return
+	}
+	for i, line := range lines {
+		if line != expected[i] {
+			t.Errorf("expected trace entry %d to be %s, got %s", i+1, expected[i], line)
+		}
+	}
+}
+
+func TestEchoVault_TraceTraceparent(t *testing.T) {
+	traceFile := path.Join(t.TempDir(), "trace.jsonl")
+	server, err := echovault.NewEchoVault(
+		echovault.WithConfig(config.Config{
+			DataDir:        "",
+			EvictionPolicy: constants.NoEviction,
+			TraceFile:      traceFile,
+		}),
+	)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	traceparent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	ctx, err := echovault.ContextWithTraceparent(context.Background(), traceparent)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if got := echovault.TraceparentFromContext(ctx); got != traceparent {
+		t.Errorf("expected traceparent %s, got %s", traceparent, got)
+	}
+	if _, err = server.ExecuteCommand(ctx, "SET", "key1", "value1"); err != nil {
+		t.Error(err)
+		return
+	}
+	if _, err = server.ExecuteCommand(context.Background(), "GET", "key1"); err != nil {
+		t.Error(err)
+		return
+	}
+	server.ShutDown(context.Background())
+
+	for _, invalid := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	} {
+		if _, err = echovault.ContextWithTraceparent(context.Background(), invalid); err == nil {
+			t.Errorf("expected traceparent %q to be rejected", invalid)
+		}
+	}
+
+	expected := []string{
+		`{"seq":1,"command":["SET","key1","value1"],"reply":"+OK\r\n","modified":["key1"],"traceparent":"` + traceparent + `"}`,
+		`{"seq":2,"command":["GET","key1"],"reply":"+value1\r\n"}`,
+	}
+
+	b, err := os.ReadFile(traceFile)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if len(lines) != len(expected) {
+		t.Errorf("expected %d trace entries, got %d:\n%s", len(expected), len(lines), string(b))
+		return
+	}
+	for i, line := range lines {
+		if line != expected[i] {
+			t.Errorf("expected trace entry %d to be %s, got %s", i+1, expected[i], line)
+		}
+	}
+}
+
+func TestEchoVault_Export(t *testing.T) {
+	server := createEchoVault()
+
+	if _, err := server.Set("ExportString", "value1", echovault.SetOptions{}); err != nil {
+		t.Error(err)
+		return
+	}
+	if _, err := server.Set("ExportVolatile", "value2", echovault.SetOptions{EX: 100}); err != nil {
+		t.Error(err)
+		return
+	}
+	if _, err := server.HSet("ExportHash", map[string]string{"field1": "value1", "field2": "2"}); err != nil {
+		t.Error(err)
+		return
+	}
+	if _, err := server.RPush("ExportList", "a", "b", "c"); err != nil {
+		t.Error(err)
+		return
+	}
+	if _, err := server.SAdd("ExportSet", "c", "a", "b"); err != nil {
+		t.Error(err)
+		return
+	}
+	if _, err := server.ZAdd("ExportZSet", map[string]float64{"b": 2, "a": 2, "c": 1.5}, echovault.ZAddOptions{}); err != nil {
+		t.Error(err)
+		return
+	}
+	if _, err := server.XAdd("ExportStream", "1-1", []string{"field1", "value1"}, echovault.XAddOptions{}); err != nil {
+		t.Error(err)
+		return
+	}
+	if _, err := server.Set("OtherKey", "value3", echovault.SetOptions{}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	var buf bytes.Buffer
+	count, err := server.Export(&buf, echovault.ExportOptions{Match: "Export*"})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if count != 7 {
+		t.Errorf("expected 7 exported keys, got %d", count)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	expected := []string{
+		`{"key":"ExportHash","type":"hash","ttl":-1,"value":{"field1":"value1","field2":"2"}}`,
+		`{"key":"ExportList","type":"list","ttl":-1,"value":["a","b","c"]}`,
+		`{"key":"ExportSet","type":"set","ttl":-1,"value":["a","b","c"]}`,
+		`{"key":"ExportStream","type":"stream","ttl":-1,"value":[{"id":"1-1","fields":["field1","value1"]}]}`,
+		`{"key":"ExportString","type":"string","ttl":-1,"value":"value1"}`,
+		"",
+		`{"key":"ExportZSet","type":"zset","ttl":-1,"value":[{"member":"c","score":"1.5"},{"member":"a","score":"2"},{"member":"b","score":"2"}]}`,
+	}
+	if len(lines) != len(expected) {
+		t.Errorf("expected %d lines, got %d:\n%s", len(expected), len(lines), buf.String())
+		return
+	}
+	for i, line := range lines {
+		if expected[i] == "" {
+			// The TTL of the volatile key depends on the time taken to run the test.
+			var record echovault.ExportRecord
+			if err = json.Unmarshal([]byte(line), &record); err != nil {
+				t.Error(err)
+				continue
+			}
+			if record.Key != "ExportVolatile" || record.Value != "value2" || record.TTL <= 0 || record.TTL > 100000 {
+				t.Errorf("expected ExportVolatile record with a TTL of up to 100000ms, got %s", line)
+			}
+			continue
+		}
+		if line != expected[i] {
+			t.Errorf("expected line %d to be %s, got %s", i+1, expected[i], line)
+		}
+	}
+}
+
+func TestEchoVault_Import(t *testing.T) {
+	source := createEchoVault()
+
+	if _, err := source.Set("tenant1:string", "value1", echovault.SetOptions{}); err != nil {
+		t.Error(err)
+		return
+	}
+	if _, err := source.Set("tenant1:volatile", "value2", echovault.SetOptions{EX: 100}); err != nil {
+		t.Error(err)
+		return
+	}
+	if _, err := source.HSet("tenant1:hash", map[string]string{"field1": "value1", "field2": "2"}); err != nil {
+		t.Error(err)
+		return
+	}
+	if _, err := source.RPush("tenant1:list", "a", "b", "3"); err != nil {
+		t.Error(err)
+		return
+	}
+	if _, err := source.SAdd("tenant1:set", "c", "a", "b"); err != nil {
+		t.Error(err)
+		return
+	}
+	if _, err := source.ZAdd("tenant1:zset", map[string]float64{"b": 2, "a": math.Inf(1), "c": 1.5}, echovault.ZAddOptions{}); err != nil {
+		t.Error(err)
+		return
+	}
+	if _, err := source.XAdd("tenant1:stream", "1-1", []string{"field1", "value1"}, echovault.XAddOptions{}); err != nil {
+		t.Error(err)
+		return
+	}
+	if _, err := source.XAdd("tenant1:stream", "2-0", []string{"field2", "value2"}, echovault.XAddOptions{}); err != nil {
+		t.Error(err)
+		return
+	}
+	if _, err := source.Set("tenant2:string", "value3", echovault.SetOptions{}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	var full bytes.Buffer
+	if _, err := source.Export(&full, echovault.ExportOptions{}); err != nil {
+		t.Error(err)
+		return
+	}
+	var tenant1 bytes.Buffer
+	if _, err := source.Export(&tenant1, echovault.ExportOptions{Match: "tenant1:*"}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	// Restoring the full export with MATCH only restores the keys of the tenant.
+	target := createEchoVault()
+	if _, err := target.Set("tenant1:string", "old", echovault.SetOptions{EX: 100}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	if _, err := target.Import(bytes.NewReader(full.Bytes()), echovault.ImportOptions{Match: "tenant1:*"}); err == nil ||
+		!strings.Contains(err.Error(), "key tenant1:string already exists") {
+		t.Errorf("expected error \"key tenant1:string already exists\", got %v", err)
+		return
+	}
+	if value, _ := target.Get("tenant1:hash"); value != "" {
+		t.Errorf("expected a failed import not to write any key, got tenant1:hash %q", value)
+	}
+
+	count, err := target.Import(bytes.NewReader(full.Bytes()), echovault.ImportOptions{Match: "tenant1:*", Replace: true})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if count != 7 {
+		t.Errorf("expected 7 imported keys, got %d", count)
+	}
+	if ttl, err := target.TTL("tenant1:string"); err != nil || ttl != -1 {
+		t.Errorf("expected the replaced key to have no expiry, got %d (%v)", ttl, err)
+	}
+
+	var restored bytes.Buffer
+	if _, err = target.Export(&restored, echovault.ExportOptions{}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	// The volatile key's TTL depends on the time taken to run the test, so compare it separately.
+	withoutTTL := func(b []byte) []echovault.ExportRecord {
+		var records []echovault.ExportRecord
+		for _, line := range strings.Split(strings.TrimSpace(string(b)), "\n") {
+			var record echovault.ExportRecord
+			if err := json.Unmarshal([]byte(line), &record); err != nil {
+				t.Error(err)
+				return nil
+			}
+			if record.TTL > 0 {
+				if record.TTL > 100000 {
+					t.Errorf("expected %s to have a TTL of up to 100000ms, got %d", record.Key, record.TTL)
+				}
+				record.TTL = 1
+			}
+			records = append(records, record)
+		}
+		return records
+	}
+	if want, got := withoutTTL(tenant1.Bytes()), withoutTTL(restored.Bytes()); !reflect.DeepEqual(want, got) {
+		t.Errorf("expected restored keys %v, got %v", want, got)
+	}
+
+	// The restored stream keeps its last ID.
+	if _, err = target.XAdd("tenant1:stream", "1-5", []string{"field", "value"}, echovault.XAddOptions{}); err == nil {
+		t.Error("expected XADD with an ID smaller than the last restored ID to fail")
+	}
+
+	if _, err = target.Import(strings.NewReader(`{"key":"bad","type":"zset","ttl":-1,"value":[{"member":"a","score":"x"}]}`),
+		echovault.ImportOptions{}); err == nil || !strings.Contains(err.Error(), "invalid score x for key bad") {
+		t.Errorf("expected error \"invalid score x for key bad\", got %v", err)
+	}
+}
@@ -17,6 +17,7 @@ package admin
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"github.com/echovault/echovault/echovault"
 	"github.com/echovault/echovault/internal"
@@ -24,9 +25,12 @@ import (
 	"github.com/echovault/echovault/internal/constants"
 	"github.com/tidwall/resp"
 	"net"
+	"os"
+	"path"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 	"unsafe"
 )
 
@@ -95,3 +99,310 @@ func Test_CommandsHandler(t *testing.T) {
 		fmt.Println(element)
 	}
 }
+
+func Test_ListenerCommands(t *testing.T) {
+	socket := path.Join(t.TempDir(), "echovault.sock")
+	server, err := echovault.NewEchoVault(
+		echovault.WithConfig(config.Config{
+			BindAddr:          "localhost",
+			Port:              7501,
+			UnixSocket:        socket,
+			UnixSocketPerm:    "700",
+			DataDir:           "",
+			EvictionPolicy:    constants.NoEviction,
+			TCPDenyCommands:   []string{"@admin"},
+			UnixAllowCommands: []string{"config", "ping"},
+		}),
+	)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	go server.Start()
+	defer server.ShutDown(context.Background())
+
+	tests := []struct {
+		name     string
+		network  string
+		address  string
+		command  []string
+		expected string
+	}{
+		{
+			name:     "1. Allow a command that is not denied on the TCP listener",
+			network:  "tcp",
+			address:  "localhost:7501",
+			command:  []string{"PING"},
+			expected: "PONG",
+		},
+		{
+			name:     "2. Reject a sub-command of a denied category on the TCP listener",
+			network:  "tcp",
+			address:  "localhost:7501",
+			command:  []string{"CONFIG", "GET", "data-dir"},
+			expected: "Error CONFIG|GET is not allowed on the tcp listener",
+		},
+		{
+			name:     "3. Reject a command of a denied category on the TCP listener",
+			network:  "tcp",
+			address:  "localhost:7501",
+			command:  []string{"LASTSAVE"},
+			expected: "Error LASTSAVE is not allowed on the tcp listener",
+		},
+		{
+			name:     "4. Allow the sub-command of an allowed command on the unix listener",
+			network:  "unix",
+			address:  socket,
+			command:  []string{"CONFIG", "GET", "protocol-compat"},
+			expected: "[protocol-compat echovault]",
+		},
+		{
+			name:     "5. Allow an allowed command on the unix listener",
+			network:  "unix",
+			address:  socket,
+			command:  []string{"PING"},
+			expected: "PONG",
+		},
+		{
+			name:     "6. Reject a command that is not allowed on the unix listener",
+			network:  "unix",
+			address:  socket,
+			command:  []string{"SET", "key", "value"},
+			expected: "Error SET is not allowed on the unix listener",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var conn net.Conn
+			for i := 0; i < 20; i++ {
+				if conn, err = net.Dial(test.network, test.address); err == nil {
+					break
+				}
+				<-time.After(50 * time.Millisecond)
+			}
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer func() {
+				_ = conn.Close()
+			}()
+
+			client := resp.NewConn(conn)
+			command := make([]resp.Value, len(test.command))
+			for i, c := range test.command {
+				command[i] = resp.StringValue(c)
+			}
+			if err = client.WriteArray(command); err != nil {
+				t.Error(err)
+				return
+			}
+			res, _, err := client.ReadValue()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if res.String() != test.expected {
+				t.Errorf("expected response \"%s\", got \"%s\"", test.expected, res.String())
+			}
+		})
+	}
+
+	info, err := os.Stat(socket)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if info.Mode().Perm() != 0700 {
+		t.Errorf("expected unix socket permissions %o, got %o", 0700, info.Mode().Perm())
+	}
+}
+
+func Test_ContextShutDown(t *testing.T) {
+	socket := path.Join(t.TempDir(), "echovault.sock")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	server, err := echovault.NewEchoVault(
+		echovault.WithContext(ctx),
+		echovault.WithConfig(config.Config{
+			BindAddr:       "localhost",
+			Port:           7510,
+			UnixSocket:     socket,
+			DataDir:        "",
+			EvictionPolicy: constants.NoEviction,
+		}),
+	)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		server.Start()
+		close(stopped)
+	}()
+
+	conns := make(map[string]net.Conn)
+	for _, address := range [][2]string{{"tcp", "localhost:7510"}, {"unix", socket}} {
+		var conn net.Conn
+		for i := 0; i < 20; i++ {
+			if conn, err = net.Dial(address[0], address[1]); err == nil {
+				break
+			}
+			<-time.After(50 * time.Millisecond)
+		}
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer func() {
+			_ = conn.Close()
+		}()
+		client := resp.NewConn(conn)
+		if err = client.WriteArray([]resp.Value{resp.StringValue("PING")}); err != nil {
+			t.Error(err)
+			return
+		}
+		if res, _, err := client.ReadValue(); err != nil || res.String() != "PONG" {
+			t.Errorf("expected PONG on the %s listener, got \"%s\" (%v)", address[0], res.String(), err)
+			return
+		}
+		conns[address[0]] = conn
+	}
+
+	// Cancelling the context stops the listeners and closes the connections.
+	cancel()
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		t.Error("expected Start to return when the context is cancelled")
+		return
+	}
+	for network, conn := range conns {
+		_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		if _, err = conn.Read(make([]byte, 1)); err == nil || errors.Is(err, os.ErrDeadlineExceeded) {
+			t.Errorf("expected the %s connection to be closed, got %v", network, err)
+		}
+	}
+	if _, err = net.Dial("tcp", "localhost:7510"); err == nil {
+		t.Error("expected the TCP listener to be closed")
+	}
+	if _, err = os.Stat(socket); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected the unix socket to be removed, got %v", err)
+	}
+
+	shutDownCtx, cancelShutDown := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancelShutDown()
+	if err = server.ShutDown(shutDownCtx); err != nil {
+		t.Errorf("expected ShutDown to return after the context is cancelled, got %v", err)
+	}
+}
+
+func Test_ShutDownBeforeStart(t *testing.T) {
+	server, err := echovault.NewEchoVault(
+		echovault.WithConfig(config.Config{
+			BindAddr:       "localhost",
+			Port:           7512,
+			DataDir:        "",
+			EvictionPolicy: constants.NoEviction,
+		}),
+	)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	shutDownCtx, cancelShutDown := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancelShutDown()
+	if err = server.ShutDown(shutDownCtx); err != nil {
+		t.Errorf("expected ShutDown to return, got %v", err)
+	}
+
+	// A server that has been shut down does not start accepting connections.
+	stopped := make(chan struct{})
+	go func() {
+		server.Start()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		t.Error("expected Start to return after ShutDown")
+		return
+	}
+	if _, err = net.Dial("tcp", "localhost:7512"); err == nil {
+		t.Error("expected the TCP listener to be closed")
+	}
+}
+
+func Test_BlockingCommandCancelledOnDisconnect(t *testing.T) {
+	server, err := echovault.NewEchoVault(
+		echovault.WithConfig(config.Config{
+			BindAddr:       "localhost",
+			Port:           7511,
+			DataDir:        "",
+			EvictionPolicy: constants.NoEviction,
+		}),
+	)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	go server.Start()
+	defer server.ShutDown(context.Background())
+
+	do := func(conn net.Conn, cmd ...string) (resp.Value, error) {
+		values := make([]resp.Value, len(cmd))
+		for i, s := range cmd {
+			values[i] = resp.StringValue(s)
+		}
+		client := resp.NewConn(conn)
+		if err := client.WriteArray(values); err != nil {
+			return resp.Value{}, err
+		}
+		rv, _, err := client.ReadValue()
+		return rv, err
+	}
+
+	var blocked net.Conn
+	for i := 0; i < 20; i++ {
+		if blocked, err = net.Dial("tcp", "localhost:7511"); err == nil {
+			break
+		}
+		<-time.After(50 * time.Millisecond)
+	}
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if err = resp.NewConn(blocked).WriteArray([]resp.Value{
+		resp.StringValue("BLPOP"), resp.StringValue("BlockedKey"), resp.StringValue("0"),
+	}); err != nil {
+		t.Error(err)
+		return
+	}
+	// Give the server time to start blocking before the client disconnects.
+	<-time.After(100 * time.Millisecond)
+	_ = blocked.Close()
+	<-time.After(100 * time.Millisecond)
+
+	conn, err := net.Dial("tcp", "localhost:7511")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	if rv, err := do(conn, "RPUSH", "BlockedKey", "value"); err != nil || rv.Integer() != 1 {
+		t.Errorf("expected RPUSH to return 1, got %+v (%v)", rv, err)
+		return
+	}
+	<-time.After(100 * time.Millisecond)
+	// The element must not be popped by the blocking command of the client that disconnected.
+	if rv, err := do(conn, "LLEN", "BlockedKey"); err != nil || rv.Integer() != 1 {
+		t.Errorf("expected the element to remain in the list, got length %+v (%v)", rv, err)
+	}
+}
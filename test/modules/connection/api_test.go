@@ -13,3 +13,111 @@
 // limitations under the License.
 
 package connection
+
+import (
+	"context"
+	"errors"
+	"github.com/echovault/echovault/echovault"
+	"github.com/echovault/echovault/internal"
+	"github.com/echovault/echovault/internal/constants"
+	"github.com/echovault/echovault/internal/modules/connection"
+	"reflect"
+	"testing"
+)
+
+func TestEchoVault_ConnectionMetadata(t *testing.T) {
+	registry := getUnexportedField(
+		reflect.ValueOf(mockServer).Elem().FieldByName("clientRegistry")).(*connection.ClientRegistry)
+
+	ctx := context.WithValue(context.Background(), internal.ContextConnID("ConnectionID"), "test-metadata")
+	registry.RegisterClient(ctx, nil)
+	defer registry.UnregisterClient(ctx)
+
+	tests := []struct {
+		name        string
+		ctx         context.Context
+		key         string
+		value       interface{}
+		wantValue   interface{}
+		wantOk      bool
+		expectedErr error
+	}{
+		{
+			name:        "1. Attach metadata to the connection",
+			ctx:         ctx,
+			key:         "tenant",
+			value:       "tenant-1",
+			wantValue:   "tenant-1",
+			wantOk:      true,
+			expectedErr: nil,
+		},
+		{
+			name:        "2. Overwrite existing metadata",
+			ctx:         ctx,
+			key:         "tenant",
+			value:       "tenant-2",
+			wantValue:   "tenant-2",
+			wantOk:      true,
+			expectedErr: nil,
+		},
+		{
+			name:        "3. Remove metadata when the value is nil",
+			ctx:         ctx,
+			key:         "tenant",
+			value:       nil,
+			wantValue:   nil,
+			wantOk:      false,
+			expectedErr: nil,
+		},
+		{
+			name:        "4. Return error when the client is not registered",
+			ctx:         context.Background(),
+			key:         "tenant",
+			value:       "tenant-1",
+			wantValue:   nil,
+			wantOk:      false,
+			expectedErr: errors.New("client not found"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := mockServer.SetConnectionMetadata(tt.ctx, tt.key, tt.value)
+			if tt.expectedErr != nil {
+				if err == nil || err.Error() != tt.expectedErr.Error() {
+					t.Errorf("SetConnectionMetadata() error = %v, wantErr %v", err, tt.expectedErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("SetConnectionMetadata() error = %v", err)
+				return
+			}
+			got, ok := mockServer.GetConnectionMetadata(tt.ctx, tt.key)
+			if ok != tt.wantOk || got != tt.wantValue {
+				t.Errorf("GetConnectionMetadata() = (%v, %v), want (%v, %v)", got, ok, tt.wantValue, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestEchoVault_Hello(t *testing.T) {
+	got, err := mockServer.Hello()
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	want := echovault.HelloResponse{
+		Server:  constants.ServerName,
+		Version: constants.Version,
+		Proto:   2,
+		Mode:    "standalone",
+		Role:    "master",
+		Modules: []string{
+			"acl", "admin", "connection", "generic", "hash", "list", "pubsub", "scripting", "set", "sortedset", "stream", "string", "transaction",
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Hello() got = %+v, want %+v", got, want)
+	}
+}
@@ -15,18 +15,24 @@
 package connection
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"github.com/echovault/echovault/echovault"
 	"github.com/echovault/echovault/internal"
 	"github.com/echovault/echovault/internal/config"
 	"github.com/echovault/echovault/internal/constants"
+	"github.com/echovault/echovault/internal/modules/connection"
 	"github.com/tidwall/resp"
+	"io"
 	"net"
 	"reflect"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 	"unsafe"
 )
 
@@ -73,6 +79,13 @@ func getHandlerFuncParams(ctx context.Context, cmd []string, conn *net.Conn) int
 		Context:    ctx,
 		Command:    cmd,
 		Connection: conn,
+		GetClientRegistry: getUnexportedField(
+			reflect.ValueOf(mockServer).Elem().FieldByName("getClientRegistry")).(func() interface{}),
+		GetAllCommands: getUnexportedField(
+			reflect.ValueOf(mockServer).Elem().FieldByName("getCommands")).(func() []internal.Command),
+		GetReplicationInfo: func() internal.ReplicationInfo {
+			return internal.ReplicationInfo{Mode: "cluster", Role: "slave"}
+		},
 	}
 }
 
@@ -122,3 +135,705 @@ func Test_HandlePing(t *testing.T) {
 		}
 	}
 }
+
+func Test_HandleHello(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name        string
+		command     []string
+		expected    map[string]string
+		expectedErr error
+	}{
+		{
+			name:    "1. Return the server metadata",
+			command: []string{"HELLO"},
+			expected: map[string]string{
+				"server":  constants.ServerName,
+				"version": constants.Version,
+				"proto":   "2",
+				"mode":    "cluster",
+				"role":    "replica",
+				"modules": "acl admin connection generic hash list pubsub scripting set sortedset stream string transaction",
+			},
+			expectedErr: nil,
+		},
+		{
+			name:    "2. Accept protocol version 2",
+			command: []string{"HELLO", "2"},
+			expected: map[string]string{
+				"server":  constants.ServerName,
+				"version": constants.Version,
+				"proto":   "2",
+				"mode":    "cluster",
+				"role":    "replica",
+				"modules": "acl admin connection generic hash list pubsub scripting set sortedset stream string transaction",
+			},
+			expectedErr: nil,
+		},
+		{
+			name:        "3. Return error when the protocol version is not supported",
+			command:     []string{"HELLO", "4"},
+			expectedErr: errors.New("unsupported protocol version 4"),
+		},
+		{
+			name:        "4. Return error when the protocol version is not an integer",
+			command:     []string{"HELLO", "abc"},
+			expectedErr: errors.New("protocol version is not an integer or out of range"),
+		},
+		{
+			name:        "5. Return error when the AUTH option is missing the password",
+			command:     []string{"HELLO", "2", "AUTH", "user"},
+			expectedErr: errors.New("syntax error in HELLO option 'AUTH'"),
+		},
+		{
+			name:        "6. Return error when the option is unknown",
+			command:     []string{"HELLO", "2", "NAME", "client"},
+			expectedErr: errors.New("syntax error in HELLO option 'NAME'"),
+		},
+		{
+			name:        "7. Return error when the SETNAME client name has a space",
+			command:     []string{"HELLO", "2", "SETNAME", "bad name"},
+			expectedErr: errors.New("client names cannot contain spaces, newlines or special characters"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res, err := getHandler("HELLO")(getHandlerFuncParams(ctx, test.command, nil))
+			if test.expectedErr != nil {
+				if err == nil || err.Error() != test.expectedErr.Error() {
+					t.Errorf("expected error %s, got: %v", test.expectedErr.Error(), err)
+				}
+				return
+			}
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			v, _, err := resp.NewReader(bytes.NewBuffer(res)).ReadValue()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			got := make(map[string]string)
+			for i := 0; i+1 < len(v.Array()); i += 2 {
+				value := v.Array()[i+1]
+				if value.Type() == resp.Array {
+					var modules []string
+					for _, module := range value.Array() {
+						modules = append(modules, module.String())
+					}
+					got[v.Array()[i].String()] = strings.Join(modules, " ")
+					continue
+				}
+				got[v.Array()[i].String()] = value.String()
+			}
+			if !reflect.DeepEqual(got, test.expected) {
+				t.Errorf("expected %+v, got: %+v", test.expected, got)
+			}
+		})
+	}
+}
+
+func Test_HandleHelloProtocol3(t *testing.T) {
+	registry := getUnexportedField(
+		reflect.ValueOf(mockServer).Elem().FieldByName("clientRegistry")).(*connection.ClientRegistry)
+
+	ctx := context.WithValue(context.Background(), internal.ContextConnID("ConnectionID"), "test-hello-3")
+	registry.RegisterClient(ctx, nil)
+	defer registry.UnregisterClient(ctx)
+
+	res, err := getHandler("HELLO")(getHandlerFuncParams(ctx, []string{"HELLO", "3"}, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// RESP3 clients receive the metadata as a map.
+	if !strings.HasPrefix(string(res), "%6\r\n") || !strings.Contains(string(res), "$5\r\nproto\r\n:3\r\n") {
+		t.Errorf("expected RESP3 map with proto 3, got: %q", string(res))
+	}
+	if proto := registry.GetProtocol(ctx); proto != 3 {
+		t.Errorf("expected protocol 3, got: %d", proto)
+	}
+
+	// The protocol version is kept by HELLO without a protocol version.
+	res, err = getHandler("HELLO")(getHandlerFuncParams(ctx, []string{"HELLO"}, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(res), "$5\r\nproto\r\n:3\r\n") {
+		t.Errorf("expected proto 3, got: %q", string(res))
+	}
+
+	// Unregistered clients can't switch to RESP3.
+	if _, err = getHandler("HELLO")(getHandlerFuncParams(context.Background(), []string{"HELLO", "3"}, nil)); err == nil {
+		t.Error("expected error for unregistered client")
+	}
+}
+
+func Test_HandleClientNoTouch(t *testing.T) {
+	registry := getUnexportedField(
+		reflect.ValueOf(mockServer).Elem().FieldByName("clientRegistry")).(*connection.ClientRegistry)
+
+	ctx := context.WithValue(context.Background(), internal.ContextConnID("ConnectionID"), "test-no-touch")
+	registry.RegisterClient(ctx, nil)
+	defer registry.UnregisterClient(ctx)
+
+	tests := []struct {
+		name        string
+		ctx         context.Context
+		command     []string
+		wantNoTouch bool
+		expectedErr error
+	}{
+		{
+			name:        "1. Turn on NO-TOUCH for the client",
+			ctx:         ctx,
+			command:     []string{"CLIENT", "NO-TOUCH", "ON"},
+			wantNoTouch: true,
+			expectedErr: nil,
+		},
+		{
+			name:        "2. Turn off NO-TOUCH for the client",
+			ctx:         ctx,
+			command:     []string{"CLIENT", "NO-TOUCH", "OFF"},
+			wantNoTouch: false,
+			expectedErr: nil,
+		},
+		{
+			name:        "3. Return error when option is not ON or OFF",
+			ctx:         ctx,
+			command:     []string{"CLIENT", "NO-TOUCH", "MAYBE"},
+			wantNoTouch: false,
+			expectedErr: errors.New("unknown option MAYBE"),
+		},
+		{
+			name:        "4. Return error when the client is not registered",
+			ctx:         context.Background(),
+			command:     []string{"CLIENT", "NO-TOUCH", "ON"},
+			wantNoTouch: false,
+			expectedErr: errors.New("client not found"),
+		},
+		{
+			name:        "5. Command too short",
+			ctx:         ctx,
+			command:     []string{"CLIENT", "NO-TOUCH"},
+			wantNoTouch: false,
+			expectedErr: errors.New(constants.WrongArgsResponse),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res, err := getHandler("CLIENT", "NO-TOUCH")(getHandlerFuncParams(test.ctx, test.command, nil))
+			if test.expectedErr != nil {
+				if err == nil || err.Error() != test.expectedErr.Error() {
+					t.Errorf("expected error %v, got: %v", test.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if string(res) != constants.OkResponse {
+				t.Errorf("expected response %q, got: %q", constants.OkResponse, string(res))
+			}
+			if got := registry.IsNoTouch(test.ctx); got != test.wantNoTouch {
+				t.Errorf("expected no-touch %v, got: %v", test.wantNoTouch, got)
+			}
+		})
+	}
+}
+
+func Test_HandleClientNoEvict(t *testing.T) {
+	registry := getUnexportedField(
+		reflect.ValueOf(mockServer).Elem().FieldByName("clientRegistry")).(*connection.ClientRegistry)
+
+	ctx := context.WithValue(context.Background(), internal.ContextConnID("ConnectionID"), "test-no-evict")
+	registry.RegisterClient(ctx, nil)
+	defer registry.UnregisterClient(ctx)
+
+	tests := []struct {
+		name        string
+		ctx         context.Context
+		command     []string
+		wantNoEvict bool
+		expectedErr error
+	}{
+		{
+			name:        "1. Turn on NO-EVICT for the client",
+			ctx:         ctx,
+			command:     []string{"CLIENT", "NO-EVICT", "ON"},
+			wantNoEvict: true,
+			expectedErr: nil,
+		},
+		{
+			name:        "2. Turn off NO-EVICT for the client",
+			ctx:         ctx,
+			command:     []string{"CLIENT", "NO-EVICT", "OFF"},
+			wantNoEvict: false,
+			expectedErr: nil,
+		},
+		{
+			name:        "3. Return error when option is not ON or OFF",
+			ctx:         ctx,
+			command:     []string{"CLIENT", "NO-EVICT", "MAYBE"},
+			wantNoEvict: false,
+			expectedErr: errors.New("unknown option MAYBE"),
+		},
+		{
+			name:        "4. Return error when the client is not registered",
+			ctx:         context.Background(),
+			command:     []string{"CLIENT", "NO-EVICT", "ON"},
+			wantNoEvict: false,
+			expectedErr: errors.New("client not found"),
+		},
+		{
+			name:        "5. Command too short",
+			ctx:         ctx,
+			command:     []string{"CLIENT", "NO-EVICT"},
+			wantNoEvict: false,
+			expectedErr: errors.New(constants.WrongArgsResponse),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res, err := getHandler("CLIENT", "NO-EVICT")(getHandlerFuncParams(test.ctx, test.command, nil))
+			if test.expectedErr != nil {
+				if err == nil || err.Error() != test.expectedErr.Error() {
+					t.Errorf("expected error %v, got: %v", test.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if string(res) != constants.OkResponse {
+				t.Errorf("expected response %q, got: %q", constants.OkResponse, string(res))
+			}
+			if got := registry.IsNoEvict(test.ctx); got != test.wantNoEvict {
+				t.Errorf("expected no-evict %v, got: %v", test.wantNoEvict, got)
+			}
+		})
+	}
+}
+
+// readRESP3 reads the next RESP3 value from the reader and returns it in a readable form:
+// aggregates are returned as their elements in square brackets, prefixed with ">" for push messages.
+func readRESP3(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	switch line[0] {
+	case '$':
+		n, _ := strconv.Atoi(line[1:])
+		if n < 0 {
+			return "nil", nil
+		}
+		b := make([]byte, n+2)
+		if _, err = io.ReadFull(r, b); err != nil {
+			return "", err
+		}
+		return string(b[:n]), nil
+	case '*', '%', '>':
+		n, _ := strconv.Atoi(line[1:])
+		if line[0] == '%' {
+			n *= 2
+		}
+		elems := make([]string, n)
+		for i := range elems {
+			if elems[i], err = readRESP3(r); err != nil {
+				return "", err
+			}
+		}
+		prefix := ""
+		if line[0] == '>' {
+			prefix = ">"
+		}
+		return fmt.Sprintf("%s[%s]", prefix, strings.Join(elems, " ")), nil
+	default:
+		return line, nil
+	}
+}
+
+func Test_ClientTracking(t *testing.T) {
+	server, _ := echovault.NewEchoVault(
+		echovault.WithConfig(config.Config{
+			BindAddr:       "localhost",
+			Port:           7500,
+			DataDir:        "",
+			EvictionPolicy: constants.NoEviction,
+		}),
+	)
+	go server.Start()
+
+	dial := func() (net.Conn, *bufio.Reader) {
+		var conn net.Conn
+		var err error
+		for i := 0; i < 10; i++ {
+			if conn, err = net.Dial("tcp", "localhost:7500"); err == nil {
+				break
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() {
+			_ = conn.Close()
+		})
+		return conn, bufio.NewReader(conn)
+	}
+	do := func(conn net.Conn, r *bufio.Reader, cmd ...string) string {
+		if _, err := conn.Write(internal.EncodeCommand(cmd)); err != nil {
+			t.Fatal(err)
+		}
+		_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		res, err := readRESP3(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+
+	client, clientReader := dial()
+	writer, writerReader := dial()
+
+	if res := do(client, clientReader, "CLIENT", "TRACKING", "ON"); !strings.Contains(res, "requires protocol version 3") {
+		t.Errorf("expected protocol error, got: %s", res)
+	}
+	if res := do(client, clientReader, "HELLO", "3"); !strings.Contains(res, "proto :3") {
+		t.Errorf("expected HELLO map with proto 3, got: %s", res)
+	}
+	if res := do(client, clientReader, "CLIENT", "TRACKING", "ON"); res != "+OK" {
+		t.Errorf("expected +OK, got: %s", res)
+	}
+
+	t.Run("1. Invalidate a key when it's written", func(t *testing.T) {
+		do(client, clientReader, "GET", "TrackingKey1")
+		do(writer, writerReader, "SET", "TrackingKey1", "value1")
+		if res, _ := readRESP3(clientReader); res != ">[invalidate [TrackingKey1]]" {
+			t.Errorf("expected invalidation message, got: %s", res)
+		}
+		// The key is no longer tracked until it's read again.
+		do(writer, writerReader, "SET", "TrackingKey1", "value2")
+		if res := do(client, clientReader, "PING"); res != "+PONG" {
+			t.Errorf("expected +PONG, got: %s", res)
+		}
+	})
+
+	t.Run("2. Invalidate a key when it's deleted", func(t *testing.T) {
+		do(writer, writerReader, "HSET", "TrackingKey2", "field", "value")
+		do(client, clientReader, "HGETALL", "TrackingKey2")
+		do(writer, writerReader, "DEL", "TrackingKey2")
+		if res, _ := readRESP3(clientReader); res != ">[invalidate [TrackingKey2]]" {
+			t.Errorf("expected invalidation message, got: %s", res)
+		}
+	})
+
+	t.Run("3. Invalidate a key written by the tracking client", func(t *testing.T) {
+		do(client, clientReader, "GET", "TrackingKey3")
+		// The invalidation message is sent before the reply.
+		if res := do(client, clientReader, "SET", "TrackingKey3", "value"); res != ">[invalidate [TrackingKey3]]" {
+			t.Errorf("expected invalidation message, got: %s", res)
+		}
+		if res, _ := readRESP3(clientReader); res != "+OK" {
+			t.Errorf("expected +OK, got: %s", res)
+		}
+	})
+
+	t.Run("4. Stop sending invalidation messages when tracking is turned off", func(t *testing.T) {
+		do(client, clientReader, "GET", "TrackingKey4")
+		if res := do(client, clientReader, "HELLO", "2"); !strings.Contains(res, "turn client tracking off") {
+			t.Errorf("expected error, got: %s", res)
+		}
+		do(client, clientReader, "CLIENT", "TRACKING", "OFF")
+		do(writer, writerReader, "SET", "TrackingKey4", "value")
+		if res := do(client, clientReader, "PING"); res != "+PONG" {
+			t.Errorf("expected +PONG, got: %s", res)
+		}
+	})
+}
+
+func Test_Pipelining(t *testing.T) {
+	server, _ := echovault.NewEchoVault(
+		echovault.WithConfig(config.Config{
+			BindAddr:       "localhost",
+			Port:           7502,
+			DataDir:        "",
+			EvictionPolicy: constants.NoEviction,
+		}),
+	)
+	go server.Start()
+
+	var conn net.Conn
+	var err error
+	for i := 0; i < 10; i++ {
+		if conn, err = net.Dial("tcp", "localhost:7502"); err == nil {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	binaryValue := "binary\x00value\x00"
+	largeValue := strings.Repeat("a", 100*1024)
+
+	// All the commands are sent in a single write, mixing RESP arrays and inline commands.
+	var message []byte
+	message = append(message, internal.EncodeCommand([]string{"SET", "PipelineKey1", binaryValue})...)
+	message = append(message, internal.EncodeCommand([]string{"SET", "PipelineKey2", largeValue})...)
+	message = append(message, "PING\r\n\r\n"...)
+	message = append(message, "GET PipelineKey1\r\n"...)
+	message = append(message, internal.EncodeCommand([]string{"GET", "PipelineKey2"})...)
+	if _, err = conn.Write(message); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"OK", "OK", "PONG", binaryValue, largeValue}
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	r := resp.NewReader(conn)
+	for i, want := range expected {
+		v, _, err := r.ReadValue()
+		if err != nil {
+			t.Fatalf("reply %d: %v", i, err)
+		}
+		if v.String() != want {
+			t.Errorf("reply %d: expected %q, got %q", i, want[:min(len(want), 32)], v.String()[:min(len(v.String()), 32)])
+		}
+	}
+
+	// A malformed command is answered with a protocol error and the connection is closed.
+	if _, err = conn.Write([]byte("*1\r\n+PING\r\n")); err != nil {
+		t.Fatal(err)
+	}
+	v, _, err := r.ReadValue()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(v.String(), "protocol error") {
+		t.Errorf("expected protocol error, got: %s", v.String())
+	}
+	if _, _, err = r.ReadValue(); !errors.Is(err, io.EOF) {
+		t.Errorf("expected connection to be closed, got: %v", err)
+	}
+}
+
+func Test_ClientCommands(t *testing.T) {
+	server, _ := echovault.NewEchoVault(
+		echovault.WithConfig(config.Config{
+			BindAddr:       "localhost",
+			Port:           7503,
+			DataDir:        "",
+			EvictionPolicy: constants.NoEviction,
+		}),
+	)
+	go server.Start()
+
+	dial := func() *resp.Conn {
+		var conn net.Conn
+		var err error
+		for i := 0; i < 10; i++ {
+			if conn, err = net.Dial("tcp", "localhost:7503"); err == nil {
+				break
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() {
+			_ = conn.Close()
+		})
+		return resp.NewConn(conn)
+	}
+	do := func(conn *resp.Conn, cmd ...string) resp.Value {
+		command := make([]resp.Value, len(cmd))
+		for i, c := range cmd {
+			command[i] = resp.StringValue(c)
+		}
+		if err := conn.WriteArray(command); err != nil {
+			t.Fatal(err)
+		}
+		v, _, err := conn.ReadValue()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+
+	first, second := dial(), dial()
+
+	t.Run("1. Set and get the client name", func(t *testing.T) {
+		if res := do(first, "CLIENT", "GETNAME"); !res.IsNull() {
+			t.Errorf("expected nil name, got %q", res.String())
+		}
+		if res := do(first, "CLIENT", "SETNAME", "bad name"); res.Type() != resp.Error {
+			t.Errorf("expected error for a name with a space, got %q", res.String())
+		}
+		if res := do(first, "CLIENT", "SETNAME", "first-client"); res.String() != "OK" {
+			t.Errorf("expected OK, got %q", res.String())
+		}
+		if res := do(first, "CLIENT", "GETNAME"); res.String() != "first-client" {
+			t.Errorf("expected first-client, got %q", res.String())
+		}
+	})
+
+	var firstID string
+	t.Run("2. List the connected clients", func(t *testing.T) {
+		do(first, "PING")
+		lines := strings.Split(strings.TrimSpace(do(second, "CLIENT", "LIST").String()), "\n")
+		if len(lines) != 2 {
+			t.Fatalf("expected 2 clients, got %d: %v", len(lines), lines)
+		}
+		for _, want := range []string{"name=first-client", "cmd=ping", "user=default", "resp=2"} {
+			if !strings.Contains(lines[0], want) {
+				t.Errorf("expected %q to contain %q", lines[0], want)
+			}
+		}
+		if !strings.Contains(lines[1], "cmd=client|list") {
+			t.Errorf("expected %q to contain the CLIENT LIST command", lines[1])
+		}
+		firstID = strings.TrimPrefix(strings.Fields(lines[0])[0], "id=")
+
+		filtered := strings.TrimSpace(do(second, "CLIENT", "LIST", "ID", firstID).String())
+		if strings.Count(filtered, "\n") != 0 || !strings.HasPrefix(filtered, "id="+firstID+" ") {
+			t.Errorf("expected only the first client, got %q", filtered)
+		}
+	})
+
+	t.Run("3. Report the client flags in CLIENT INFO and CLIENT LIST", func(t *testing.T) {
+		if info := do(first, "CLIENT", "INFO").String(); !strings.Contains(info, "id="+firstID+" ") ||
+			!strings.Contains(info, " flags=N ") {
+			t.Errorf("expected the first client with no flags, got %q", info)
+		}
+		if res := do(first, "CLIENT", "NO-EVICT", "ON"); res.String() != "OK" {
+			t.Errorf("expected OK, got %q", res.String())
+		}
+		if info := do(first, "CLIENT", "INFO").String(); !strings.Contains(info, " flags=e ") {
+			t.Errorf("expected the no-evict flag, got %q", info)
+		}
+		do(first, "CLIENT", "NO-TOUCH", "ON")
+		if line := do(second, "CLIENT", "LIST", "ID", firstID).String(); !strings.Contains(line, " flags=eT ") {
+			t.Errorf("expected the no-evict and no-touch flags, got %q", line)
+		}
+		do(first, "CLIENT", "NO-EVICT", "OFF")
+		do(first, "CLIENT", "NO-TOUCH", "OFF")
+		if info := do(first, "CLIENT", "INFO").String(); !strings.Contains(info, " flags=N ") {
+			t.Errorf("expected no flags, got %q", info)
+		}
+	})
+
+	t.Run("4. Pause write commands", func(t *testing.T) {
+		if res := do(second, "CLIENT", "PAUSE", "200", "WRITE"); res.String() != "OK" {
+			t.Errorf("expected OK, got %q", res.String())
+		}
+		start := time.Now()
+		do(first, "GET", "ClientPauseKey")
+		if elapsed := time.Since(start); elapsed >= 200*time.Millisecond {
+			t.Errorf("expected read to not be paused, took %s", elapsed)
+		}
+		do(first, "SET", "ClientPauseKey", "value")
+		if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+			t.Errorf("expected write to be paused, took %s", elapsed)
+		}
+	})
+
+	t.Run("5. Kill clients by user and by ID", func(t *testing.T) {
+		// The calling client is skipped by default.
+		if res := do(second, "CLIENT", "KILL", "USER", "default"); res.Integer() != 1 {
+			t.Errorf("expected 1 client to be killed, got %d", res.Integer())
+		}
+		if _, _, err := first.ReadValue(); !errors.Is(err, io.EOF) {
+			t.Errorf("expected the first connection to be closed, got: %v", err)
+		}
+		if res := do(second, "CLIENT", "KILL", "ID", firstID); res.Integer() != 0 {
+			t.Errorf("expected no clients to be killed, got %d", res.Integer())
+		}
+		if res := do(second, "CLIENT", "KILL", "127.0.0.1:1"); res.Type() != resp.Error {
+			t.Errorf("expected error for an unknown address, got %q", res.String())
+		}
+	})
+}
+
+func Test_Monitor(t *testing.T) {
+	server, _ := echovault.NewEchoVault(
+		echovault.WithConfig(config.Config{
+			BindAddr:       "localhost",
+			Port:           7504,
+			DataDir:        "",
+			EvictionPolicy: constants.NoEviction,
+		}),
+	)
+	go server.Start()
+
+	dial := func() (net.Conn, *bufio.Reader) {
+		var conn net.Conn
+		var err error
+		for i := 0; i < 10; i++ {
+			if conn, err = net.Dial("tcp", "localhost:7504"); err == nil {
+				break
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() {
+			_ = conn.Close()
+		})
+		return conn, bufio.NewReader(conn)
+	}
+	do := func(conn net.Conn, r *bufio.Reader, cmd ...string) string {
+		if _, err := conn.Write(internal.EncodeCommand(cmd)); err != nil {
+			t.Fatal(err)
+		}
+		_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		res, err := readRESP3(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+
+	monitor, monitorReader := dial()
+	client, clientReader := dial()
+
+	if res := do(monitor, monitorReader, "MONITOR"); res != "+OK" {
+		t.Fatalf("expected +OK, got: %s", res)
+	}
+
+	t.Run("1. Stream commands with the client address and quoted arguments", func(t *testing.T) {
+		do(client, clientReader, "SET", "MonitorKey1", "hello \"world\"\n")
+		res, err := readRESP3(monitorReader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := fmt.Sprintf(`[0 %s] "SET" "MonitorKey1" "hello \"world\"\n"`, client.LocalAddr().String())
+		if !strings.HasPrefix(res, "+") || !strings.HasSuffix(res, want) {
+			t.Errorf("expected feed line ending with %s, got: %s", want, res)
+		}
+	})
+
+	t.Run("2. Do not stream admin commands and redact credentials", func(t *testing.T) {
+		do(client, clientReader, "CLIENT", "LIST")
+		do(client, clientReader, "AUTH", "password")
+		res, err := readRESP3(monitorReader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasSuffix(res, `"AUTH" "(redacted)"`) {
+			t.Errorf("expected redacted AUTH, got: %s", res)
+		}
+	})
+}
@@ -16,15 +16,21 @@ package generic
 
 import (
 	"context"
+	"fmt"
 	"github.com/echovault/echovault/echovault"
 	"github.com/echovault/echovault/internal"
 	"github.com/echovault/echovault/internal/clock"
 	"github.com/echovault/echovault/internal/config"
+	"github.com/echovault/echovault/internal/constants"
+	"github.com/echovault/echovault/internal/eviction"
 	"reflect"
 	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
+	"unsafe"
 )
 
 func createEchoVault() *echovault.EchoVault {
@@ -96,6 +102,30 @@ func TestEchoVault_DEL(t *testing.T) {
 	}
 }
 
+func TestEchoVault_DeleteMany(t *testing.T) {
+	server := createEchoVault()
+
+	// More keys than fit in a single batch, with a repeated key and a missing key.
+	var keys []string
+	for i := 0; i < 2500; i++ {
+		key := fmt.Sprintf("DeleteManyKey%d", i)
+		presetKeyData(server, context.Background(), key, internal.KeyData{Value: "value", ExpireAt: time.Time{}})
+		keys = append(keys, key)
+	}
+	keys = append(keys, "DeleteManyKey0", "DeleteManyMissingKey")
+
+	got, err := server.DeleteMany(keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != 2500 {
+		t.Errorf("DeleteMany() got = %v, want %v", got, 2500)
+	}
+	if count, _ := server.Exists(keys...); count != 0 {
+		t.Errorf("DeleteMany() expected all the keys to be deleted, %d remain", count)
+	}
+}
+
 func TestEchoVault_EXPIRE(t *testing.T) {
 	mockClock := clock.NewClock()
 
@@ -571,6 +601,227 @@ func TestEchoVault_GET(t *testing.T) {
 	}
 }
 
+func TestEchoVault_GetTyped(t *testing.T) {
+	server := createEchoVault()
+
+	type document struct {
+		Name string `json:"name"`
+	}
+
+	tests := []struct {
+		name        string
+		presetValue interface{}
+		key         string
+		get         func(key string) (interface{}, bool, error)
+		want        interface{}
+		wantOk      bool
+		wantErr     bool
+	}{
+		{
+			name:        "1. GetInt returns the integer at the key",
+			presetValue: 10,
+			key:         "GetTypedKey1",
+			get:         func(key string) (interface{}, bool, error) { return server.GetInt(key) },
+			want:        10,
+			wantOk:      true,
+			wantErr:     false,
+		},
+		{
+			name:        "2. GetInt returns error when the value is not an integer",
+			presetValue: "value",
+			key:         "GetTypedKey2",
+			get:         func(key string) (interface{}, bool, error) { return server.GetInt(key) },
+			want:        0,
+			wantOk:      true,
+			wantErr:     true,
+		},
+		{
+			name:        "3. GetFloat converts an integer to a float",
+			presetValue: 10,
+			key:         "GetTypedKey3",
+			get:         func(key string) (interface{}, bool, error) { return server.GetFloat(key) },
+			want:        float64(10),
+			wantOk:      true,
+			wantErr:     false,
+		},
+		{
+			name:        "4. GetBytes returns the string at the key as bytes",
+			presetValue: "value",
+			key:         "GetTypedKey4",
+			get:         func(key string) (interface{}, bool, error) { return server.GetBytes(key) },
+			want:        []byte("value"),
+			wantOk:      true,
+			wantErr:     false,
+		},
+		{
+			name:        "5. GetBytes returns error when the value is not a string",
+			presetValue: []string{"value"},
+			key:         "GetTypedKey5",
+			get:         func(key string) (interface{}, bool, error) { return server.GetBytes(key) },
+			want:        []byte(nil),
+			wantOk:      true,
+			wantErr:     true,
+		},
+		{
+			name:        "6. GetJSON decodes the JSON at the key",
+			presetValue: `{"name":"value"}`,
+			key:         "GetTypedKey6",
+			get: func(key string) (interface{}, bool, error) {
+				var doc document
+				ok, err := server.GetJSON(key, &doc)
+				return doc, ok, err
+			},
+			want:    document{Name: "value"},
+			wantOk:  true,
+			wantErr: false,
+		},
+		{
+			name:        "7. Return false when the key does not exist",
+			presetValue: nil,
+			key:         "GetTypedKey7",
+			get:         func(key string) (interface{}, bool, error) { return server.GetInt(key) },
+			want:        0,
+			wantOk:      false,
+			wantErr:     false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.presetValue != nil {
+				err := presetValue(server, context.Background(), tt.key, tt.presetValue)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+			}
+			got, ok, err := tt.get(tt.key)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("get() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if ok != tt.wantOk {
+				t.Errorf("get() ok = %v, want %v", ok, tt.wantOk)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("get() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEchoVault_CommandTimeout(t *testing.T) {
+	server, _ := echovault.NewEchoVault(
+		echovault.WithConfig(config.Config{
+			DataDir: "",
+		}),
+		echovault.WithCommandTimeout(50*time.Millisecond),
+	)
+
+	// Hold the write lock on the key so that embedded reads cannot acquire it.
+	if _, err := server.CreateKeyAndLock(context.Background(), "CommandTimeoutKey1"); err != nil {
+		t.Error(err)
+		return
+	}
+	if err := server.SetValue(context.Background(), "CommandTimeoutKey1", "value1"); err != nil {
+		t.Error(err)
+		return
+	}
+	defer server.KeyUnlock(context.Background(), "CommandTimeoutKey1")
+
+	tests := []struct {
+		name    string
+		call    func() error
+		wantErr string
+	}{
+		{
+			name: "1. Get returns the timeout error when the key lock is held",
+			call: func() error {
+				_, err := server.Get("CommandTimeoutKey1")
+				return err
+			},
+			wantErr: "command timed out after 50ms",
+		},
+		{
+			name: "2. GetBytes returns the timeout error when the key lock is held",
+			call: func() error {
+				_, _, err := server.GetBytes("CommandTimeoutKey1")
+				return err
+			},
+			wantErr: "command timed out after 50ms",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call()
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("expected error \"%s\", got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestEchoVault_ManualClock(t *testing.T) {
+	manualClock := clock.NewManualClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	server, _ := echovault.NewEchoVault(
+		echovault.WithConfig(config.Config{
+			DataDir: "",
+		}),
+		echovault.WithClock(manualClock),
+	)
+
+	if _, err := server.Set("ManualClockKey1", "value1", echovault.SetOptions{EX: 10}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	tests := []struct {
+		name    string
+		advance time.Duration
+		wantTTL int
+		want    string
+	}{
+		{
+			name:    "1. Key is not expired before the clock is advanced",
+			advance: 0,
+			wantTTL: 10,
+			want:    "value1",
+		},
+		{
+			name:    "2. Key is not expired before the TTL has elapsed",
+			advance: 5 * time.Second,
+			wantTTL: 5,
+			want:    "value1",
+		},
+		{
+			name:    "3. Key is expired once the TTL has elapsed",
+			advance: 6 * time.Second,
+			wantTTL: -2,
+			want:    "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manualClock.Advance(tt.advance)
+			ttl, err := server.TTL("ManualClockKey1")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if ttl != tt.wantTTL {
+				t.Errorf("TTL() got = %v, want %v", ttl, tt.wantTTL)
+			}
+			got, err := server.Get("ManualClockKey1")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("GET() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestEchoVault_MGET(t *testing.T) {
 	server := createEchoVault()
 
@@ -660,7 +911,7 @@ func TestEchoVault_SET(t *testing.T) {
 			wantErr:      false,
 		},
 		{
-			name: "Throw error when value already exists with NX flag passed",
+			name: "Return empty string when value already exists with NX flag passed",
 			presetValues: map[string]internal.KeyData{
 				"key3": {
 					Value:    "preset-value3",
@@ -671,7 +922,7 @@ func TestEchoVault_SET(t *testing.T) {
 			value:   "value3",
 			options: echovault.SetOptions{NX: true},
 			want:    "",
-			wantErr: true,
+			wantErr: false,
 		},
 		{
 			name: "Set new key value when key exists with XX flag passed",
@@ -688,13 +939,13 @@ func TestEchoVault_SET(t *testing.T) {
 			wantErr: false,
 		},
 		{
-			name:         "Return error when setting non-existent key with XX flag",
+			name:         "Return empty string when setting non-existent key with XX flag",
 			presetValues: nil,
 			key:          "key5",
 			value:        "value5",
 			options:      echovault.SetOptions{XX: true},
 			want:         "",
-			wantErr:      true,
+			wantErr:      false,
 		},
 		{
 			name:         "Set expiry time on the key to 100 seconds from now",
@@ -775,6 +1026,158 @@ func TestEchoVault_SET(t *testing.T) {
 	}
 }
 
+func TestEchoVault_SetKeepTTL(t *testing.T) {
+	server := createEchoVault()
+
+	if _, err := server.Set("KeepTTLKey1", "value1", echovault.SetOptions{EX: 100}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	// KEEPTTL keeps the expiry time of the key.
+	if _, err := server.Set("KeepTTLKey1", "value2", echovault.SetOptions{KEEPTTL: true}); err != nil {
+		t.Error(err)
+		return
+	}
+	if ttl, err := server.TTL("KeepTTLKey1"); err != nil || ttl != 100 {
+		t.Errorf("TTL() got = %v, %v, want 100", ttl, err)
+	}
+
+	// Otherwise, setting the key removes its expiry time.
+	if _, err := server.Set("KeepTTLKey1", "value3", echovault.SetOptions{}); err != nil {
+		t.Error(err)
+		return
+	}
+	if ttl, err := server.TTL("KeepTTLKey1"); err != nil || ttl != -1 {
+		t.Errorf("TTL() got = %v, %v, want -1", ttl, err)
+	}
+}
+
+func TestEchoVault_SetEx_PSetEx(t *testing.T) {
+	server := createEchoVault()
+
+	if got, err := server.SetEx("SetExKey1", "value1", 100); err != nil || got != "OK" {
+		t.Errorf("SETEX() got = %v, %v, want OK", got, err)
+	}
+	if ttl, err := server.TTL("SetExKey1"); err != nil || ttl != 100 {
+		t.Errorf("TTL() got = %v, %v, want 100", ttl, err)
+	}
+
+	if got, err := server.PSetEx("SetExKey2", "value2", 5000); err != nil || got != "OK" {
+		t.Errorf("PSETEX() got = %v, %v, want OK", got, err)
+	}
+	if ttl, err := server.PTTL("SetExKey2"); err != nil || ttl != 5000 {
+		t.Errorf("PTTL() got = %v, %v, want 5000", ttl, err)
+	}
+
+	if _, err := server.SetEx("SetExKey3", "value3", 0); err == nil {
+		t.Error("expected SETEX() to return an error when the expiry time is not positive")
+	}
+}
+
+func TestEchoVault_GetEx(t *testing.T) {
+	server := createEchoVault()
+
+	if _, err := server.Set("GetExKey1", "value1", echovault.SetOptions{}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	if got, err := server.GetEx("GetExKey1", echovault.GetExOptions{EX: 100}); err != nil || got != "value1" {
+		t.Errorf("GETEX() got = %v, %v, want value1", got, err)
+	}
+	if ttl, err := server.TTL("GetExKey1"); err != nil || ttl != 100 {
+		t.Errorf("TTL() got = %v, %v, want 100", ttl, err)
+	}
+
+	if got, err := server.GetEx("GetExKey1", echovault.GetExOptions{PERSIST: true}); err != nil || got != "value1" {
+		t.Errorf("GETEX() got = %v, %v, want value1", got, err)
+	}
+	if ttl, err := server.TTL("GetExKey1"); err != nil || ttl != -1 {
+		t.Errorf("TTL() got = %v, %v, want -1", ttl, err)
+	}
+
+	if got, err := server.GetEx("GetExKey2", echovault.GetExOptions{}); err != nil || got != "" {
+		t.Errorf("GETEX() got = %v, %v, want empty string", got, err)
+	}
+}
+
+func TestEchoVault_GetDel(t *testing.T) {
+	server := createEchoVault()
+
+	if _, err := server.Set("GetDelKey1", "value1", echovault.SetOptions{}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	if got, err := server.GetDel("GetDelKey1"); err != nil || got != "value1" {
+		t.Errorf("GETDEL() got = %v, %v, want value1", got, err)
+	}
+	if got, err := server.GetDel("GetDelKey1"); err != nil || got != "" {
+		t.Errorf("GETDEL() got = %v, %v, want empty string", got, err)
+	}
+}
+
+func TestEchoVault_BinaryKeys(t *testing.T) {
+	server := createEchoVault()
+
+	keys := []string{"binary:\xff\xfe", "binary:line\r\nbreak", "binary:null\x00byte", "binary:with space"}
+
+	t.Run("1. Keys may contain arbitrary bytes", func(t *testing.T) {
+		for i, key := range keys {
+			if _, err := server.Set(key, strconv.Itoa(i), echovault.SetOptions{}); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+		for i, key := range keys {
+			value, err := server.Get(key)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if value != strconv.Itoa(i) {
+				t.Errorf("GET(%q) got = %v, want %v", key, value, i)
+			}
+		}
+		got, err := server.Keys("binary:*")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		slices.Sort(got)
+		want := slices.Clone(keys)
+		slices.Sort(want)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("KEYS() got = %q, want %q", got, want)
+		}
+		// Invalid bytes only match the same bytes.
+		if got, err = server.Keys("binary:\xfe*"); err != nil || len(got) != 0 {
+			t.Errorf("KEYS() got = %q, %v, want no keys", got, err)
+		}
+	})
+
+	t.Run("2. Keys longer than max-key-length are rejected", func(t *testing.T) {
+		if _, err := server.ConfigSet("max-key-length", "8"); err != nil {
+			t.Error(err)
+			return
+		}
+		defer func() {
+			_, _ = server.ConfigSet("max-key-length", "0")
+		}()
+		if _, err := server.Set("12345678", "value", echovault.SetOptions{}); err != nil {
+			t.Errorf("expected key of max-key-length to be accepted, got %v", err)
+		}
+		_, err := server.Set("123456789", "value", echovault.SetOptions{})
+		if err == nil || err.Error() != "key length 9 exceeds max-key-length of 8 bytes" {
+			t.Errorf("expected max-key-length error, got %v", err)
+		}
+		if _, err = server.Get("123456789"); err == nil {
+			t.Error("expected max-key-length error for read command, got nil")
+		}
+	})
+}
+
 func TestEchoVault_MSET(t *testing.T) {
 	server := createEchoVault()
 
@@ -790,6 +1193,12 @@ func TestEchoVault_MSET(t *testing.T) {
 			want:    "OK",
 			wantErr: false,
 		},
+		{
+			name:    "Return error when no keys are provided",
+			kvPairs: map[string]string{},
+			want:    "",
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -805,6 +1214,50 @@ func TestEchoVault_MSET(t *testing.T) {
 	}
 }
 
+func TestEchoVault_MSETNX(t *testing.T) {
+	server := createEchoVault()
+
+	tests := []struct {
+		name         string
+		presetValues map[string]interface{}
+		kvPairs      map[string]string
+		want         bool
+		wantErr      bool
+	}{
+		{
+			name:    "Set multiple keys when none of them exist",
+			kvPairs: map[string]string{"key1": "value1", "key2": "10"},
+			want:    true,
+			wantErr: false,
+		},
+		{
+			name:         "Set none of the keys when one of them exists",
+			presetValues: map[string]interface{}{"key4": "value4"},
+			kvPairs:      map[string]string{"key3": "value3", "key4": "value4"},
+			want:         false,
+			wantErr:      false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.presetValues {
+				if err := presetValue(server, context.Background(), k, v); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+			got, err := server.MSetNX(tt.kvPairs)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("MSETNX() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("MSETNX() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestEchoVault_PERSIST(t *testing.T) {
 	mockClock := clock.NewClock()
 
@@ -932,3 +1385,868 @@ func TestEchoVault_TTL(t *testing.T) {
 		})
 	}
 }
+
+func TestEchoVault_OBJECTREFCOUNT(t *testing.T) {
+	server := createEchoVault()
+
+	if err := presetValue(server, context.Background(), "key1", "value1"); err != nil {
+		t.Error(err)
+		return
+	}
+
+	tests := []struct {
+		name    string
+		key     string
+		want    int
+		wantErr bool
+	}{
+		{
+			name:    "Return 1 for an existing key",
+			key:     "key1",
+			want:    1,
+			wantErr: false,
+		},
+		{
+			name:    "Return 0 when the key does not exist",
+			key:     "key2",
+			want:    0,
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := server.ObjectRefCount(tt.key)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ObjectRefCount() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ObjectRefCount() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEchoVault_EXISTS(t *testing.T) {
+	server := createEchoVault()
+
+	presetKeyData(server, context.Background(), "key1", internal.KeyData{Value: "value1", ExpireAt: time.Time{}})
+	presetKeyData(server, context.Background(), "key2", internal.KeyData{Value: "value2", ExpireAt: time.Time{}})
+
+	got, err := server.Exists("key1", "key2", "key2", "key3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != 3 {
+		t.Errorf("EXISTS() got = %v, want %v", got, 3)
+	}
+}
+
+func TestEchoVault_TYPE(t *testing.T) {
+	server := createEchoVault()
+
+	tests := []struct {
+		name        string
+		presetValue interface{}
+		key         string
+		want        string
+	}{
+		{name: "Return string for string values", presetValue: "value1", key: "key1", want: "string"},
+		{name: "Return list for list values", presetValue: []interface{}{"value1"}, key: "key2", want: "list"},
+		{name: "Return hash for hash values", presetValue: map[string]interface{}{"field1": "value1"}, key: "key3", want: "hash"},
+		{name: "Return none when the key does not exist", presetValue: nil, key: "key4", want: "none"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.presetValue != nil {
+				presetKeyData(server, context.Background(), tt.key, internal.KeyData{Value: tt.presetValue, ExpireAt: time.Time{}})
+			}
+			got, err := server.Type(tt.key)
+			if err != nil {
+				t.Errorf("TYPE() error = %v", err)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("TYPE() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEchoVault_Types(t *testing.T) {
+	server := createEchoVault()
+
+	presetKeyData(server, context.Background(), "TypesKey1", internal.KeyData{Value: "value1", ExpireAt: time.Time{}})
+	presetKeyData(server, context.Background(), "TypesKey2", internal.KeyData{Value: []interface{}{"value1"}, ExpireAt: time.Time{}})
+
+	got, err := server.Types("TypesKey1", "TypesKey2", "TypesKey3", "TypesKey1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"string", "list", "none", "string"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Types() got = %v, want %v", got, want)
+	}
+
+	got, err = server.Types("TypesKey2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, []string{"list"}) {
+		t.Errorf("Types() got = %v, want %v", got, []string{"list"})
+	}
+}
+
+func TestEchoVault_RENAME(t *testing.T) {
+	server := createEchoVault()
+
+	presetKeyData(server, context.Background(), "key1", internal.KeyData{Value: "value1", ExpireAt: time.Time{}})
+	presetKeyData(server, context.Background(), "key3", internal.KeyData{Value: "value3", ExpireAt: time.Time{}})
+
+	if _, err := server.Rename("key1", "key2"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := server.Get("key2"); got != "value1" {
+		t.Errorf("RENAME() value at new key = %v, want %v", got, "value1")
+	}
+	if got, _ := server.Exists("key1"); got != 0 {
+		t.Errorf("RENAME() expected old key to be removed")
+	}
+
+	renamed, err := server.RenameNX("key2", "key3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if renamed {
+		t.Errorf("RENAMENX() got = %v, want %v", renamed, false)
+	}
+
+	if _, err = server.Rename("key4", "key5"); err == nil || err.Error() != "no such key" {
+		t.Errorf("RENAME() error = %v, want \"no such key\"", err)
+	}
+}
+
+func TestEchoVault_COPY(t *testing.T) {
+	server := createEchoVault()
+
+	presetKeyData(server, context.Background(), "key1", internal.KeyData{Value: "value1", ExpireAt: time.Time{}})
+	presetKeyData(server, context.Background(), "key2", internal.KeyData{Value: "value2", ExpireAt: time.Time{}})
+
+	tests := []struct {
+		name    string
+		source  string
+		dest    string
+		options echovault.CopyOptions
+		want    bool
+		wantVal string
+	}{
+		{name: "Copy to a new key", source: "key1", dest: "key3", want: true, wantVal: "value1"},
+		{name: "Do not overwrite an existing key", source: "key1", dest: "key2", want: false, wantVal: "value2"},
+		{name: "Overwrite an existing key with Replace", source: "key1", dest: "key2", options: echovault.CopyOptions{Replace: true}, want: true, wantVal: "value1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := server.Copy(tt.source, tt.dest, tt.options)
+			if err != nil {
+				t.Errorf("COPY() error = %v", err)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("COPY() got = %v, want %v", got, tt.want)
+			}
+			if val, _ := server.Get(tt.dest); val != tt.wantVal {
+				t.Errorf("COPY() value at destination = %v, want %v", val, tt.wantVal)
+			}
+		})
+	}
+}
+
+func TestEchoVault_SORT(t *testing.T) {
+	server := createEchoVault()
+
+	if _, err := server.RPush("SortKey1", "b", "c", "a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := server.MSet(map[string]string{
+		"SortWeight_a": "3", "SortWeight_b": "1", "SortWeight_c": "2",
+		"SortObject_a": "A", "SortObject_b": "B",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := server.HSet("SortHash_c", map[string]string{"name": "C"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := server.SAdd("SortKey2", "3", "10", "2"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		key     string
+		options echovault.SortOptions
+		want    []string
+	}{
+		{name: "Sort a set numerically", key: "SortKey2", want: []string{"2", "3", "10"}},
+		{name: "Sort a set lexicographically", key: "SortKey2", options: echovault.SortOptions{Alpha: true}, want: []string{"10", "2", "3"}},
+		{name: "Sort a list lexicographically with a limit", key: "SortKey1", options: echovault.SortOptions{Alpha: true, Desc: true, Offset: 1, Count: 2}, want: []string{"b", "a"}},
+		{name: "Sort by the weight keys", key: "SortKey1", options: echovault.SortOptions{By: "SortWeight_*"}, want: []string{"b", "c", "a"}},
+		{
+			name:    "Get the object keys and hash fields",
+			key:     "SortKey1",
+			options: echovault.SortOptions{By: "SortWeight_*", Get: []string{"#", "SortObject_*", "SortHash_*->name"}},
+			want:    []string{"b", "B", "", "c", "", "C", "a", "A", ""},
+		},
+		{name: "Skip sorting", key: "SortKey1", options: echovault.SortOptions{By: "nosort"}, want: []string{"b", "c", "a"}},
+		{name: "Sort a key that does not exist", key: "SortKey3", want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := server.Sort(tt.key, tt.options)
+			if err != nil {
+				t.Errorf("SORT() error = %v", err)
+				return
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("SORT() got = %v, want %v", got, tt.want)
+			}
+			if got, err = server.SortRO(tt.key, tt.options); err != nil || !slices.Equal(got, tt.want) {
+				t.Errorf("SORT_RO() got = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+
+	t.Run("Store the sorted elements", func(t *testing.T) {
+		count, err := server.SortStore("SortKey1", "SortKey4", echovault.SortOptions{By: "SortWeight_*", Desc: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if count != 3 {
+			t.Errorf("SORT STORE got = %d, want 3", count)
+		}
+		if got, _ := server.LRange("SortKey4", 0, -1); !slices.Equal(got, []string{"a", "c", "b"}) {
+			t.Errorf("SORT STORE stored %v, want [a c b]", got)
+		}
+	})
+
+	t.Run("Return error when the elements are not numbers", func(t *testing.T) {
+		if _, err := server.Sort("SortKey1", echovault.SortOptions{}); err == nil {
+			t.Error("SORT() expected an error when sorting strings numerically")
+		}
+	})
+}
+
+func TestEchoVault_SORTReplyCache(t *testing.T) {
+	server, err := echovault.NewEchoVault(
+		echovault.WithConfig(config.Config{
+			DataDir:        "",
+			ReplyCacheSize: 100,
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = server.RPush("SortCacheKey", "a", "b"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = server.MSet(map[string]string{"SortCacheWeight_a": "1", "SortCacheWeight_b": "2"}); err != nil {
+		t.Fatal(err)
+	}
+	options := echovault.SortOptions{By: "SortCacheWeight_*"}
+	if got, err := server.SortRO("SortCacheKey", options); err != nil || !slices.Equal(got, []string{"a", "b"}) {
+		t.Fatalf("SORT_RO() got = %v, %v, want [a b]", got, err)
+	}
+
+	// Changing a weight key does not change the version of the sorted key, so the reply must not be cached.
+	if _, err = server.Set("SortCacheWeight_a", "3", echovault.SetOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := server.SortRO("SortCacheKey", options); err != nil || !slices.Equal(got, []string{"b", "a"}) {
+		t.Errorf("SORT_RO() got = %v, %v, want [b a]", got, err)
+	}
+}
+
+func TestEchoVault_ReplyCache(t *testing.T) {
+	manualClock := clock.NewManualClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	server, err := echovault.NewEchoVault(
+		echovault.WithConfig(config.Config{
+			DataDir:          "",
+			ReplyCacheSize:   100,
+			EvictionPolicy:   constants.VolatileIdle,
+			EvictionInterval: time.Hour,
+			IdleThreshold:    time.Hour,
+		}),
+		echovault.WithClock(manualClock),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = server.Set("ReplyCacheKey1", "value1", echovault.SetOptions{EX: 100000}); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := server.Get("ReplyCacheKey1"); err != nil || got != "value1" {
+		t.Fatalf("GET() got = %v, %v, want value1", got, err)
+	}
+
+	t.Run("1. A cached reply is invalidated when its key is modified", func(t *testing.T) {
+		if _, err = server.Set("ReplyCacheKey1", "value2", echovault.SetOptions{KEEPTTL: true}); err != nil {
+			t.Fatal(err)
+		}
+		if got, err := server.Get("ReplyCacheKey1"); err != nil || got != "value2" {
+			t.Errorf("GET() got = %v, %v, want value2", got, err)
+		}
+	})
+
+	t.Run("2. A reply served from the cache updates the access time of its key", func(t *testing.T) {
+		manualClock.Advance(30 * time.Minute)
+		if got, err := server.Get("ReplyCacheKey1"); err != nil || got != "value2" {
+			t.Errorf("GET() got = %v, %v, want value2", got, err)
+		}
+		lruCache := reflect.ValueOf(server).Elem().FieldByName("lruCache").FieldByName("cache")
+		cache := (*eviction.CacheLRU)(unsafe.Pointer(lruCache.UnsafeAddr()))
+		if key, idle := cache.PopIdle(20 * time.Minute); idle {
+			t.Errorf("expected key %s to have been accessed by the cached GET", key)
+		}
+	})
+}
+
+func TestEchoVault_WritesPreserveTTL(t *testing.T) {
+	server := createEchoVault()
+	ctx := context.Background()
+
+	// Each test creates the keys with the preset commands, gives every key an expiry time,
+	// then checks that the command does not change the expiry time of the keys it modifies.
+	tests := []struct {
+		name    string
+		preset  [][]string
+		command []string
+		keys    []string
+	}{
+		{
+			name:    "SETRANGE",
+			preset:  [][]string{{"SET", "TTLKey1", "value1"}},
+			command: []string{"SETRANGE", "TTLKey1", "2", "xx"},
+			keys:    []string{"TTLKey1"},
+		},
+		{
+			name:    "SETBIT",
+			preset:  [][]string{{"SETBIT", "TTLKey2", "1", "1"}},
+			command: []string{"SETBIT", "TTLKey2", "20", "1"},
+			keys:    []string{"TTLKey2"},
+		},
+		{
+			name:    "HSET",
+			preset:  [][]string{{"HSET", "TTLKey3", "field1", "value1"}},
+			command: []string{"HSET", "TTLKey3", "field2", "value2"},
+			keys:    []string{"TTLKey3"},
+		},
+		{
+			name:    "HSETNX",
+			preset:  [][]string{{"HSET", "TTLKey4", "field1", "value1"}},
+			command: []string{"HSETNX", "TTLKey4", "field2", "value2"},
+			keys:    []string{"TTLKey4"},
+		},
+		{
+			name:    "HINCRBY",
+			preset:  [][]string{{"HSET", "TTLKey5", "field1", "1"}},
+			command: []string{"HINCRBY", "TTLKey5", "field1", "10"},
+			keys:    []string{"TTLKey5"},
+		},
+		{
+			name:    "HINCRBYFLOAT",
+			preset:  [][]string{{"HSET", "TTLKey6", "field1", "1.5"}},
+			command: []string{"HINCRBYFLOAT", "TTLKey6", "field1", "1.5"},
+			keys:    []string{"TTLKey6"},
+		},
+		{
+			name:    "HDEL",
+			preset:  [][]string{{"HSET", "TTLKey7", "field1", "value1", "field2", "value2"}},
+			command: []string{"HDEL", "TTLKey7", "field1"},
+			keys:    []string{"TTLKey7"},
+		},
+		{
+			name:    "LPUSH",
+			preset:  [][]string{{"LPUSH", "TTLKey8", "value1"}},
+			command: []string{"LPUSH", "TTLKey8", "value2"},
+			keys:    []string{"TTLKey8"},
+		},
+		{
+			name:    "RPUSHX",
+			preset:  [][]string{{"RPUSH", "TTLKey9", "value1"}},
+			command: []string{"RPUSHX", "TTLKey9", "value2"},
+			keys:    []string{"TTLKey9"},
+		},
+		{
+			name:    "LSET",
+			preset:  [][]string{{"RPUSH", "TTLKey10", "value1", "value2"}},
+			command: []string{"LSET", "TTLKey10", "0", "value3"},
+			keys:    []string{"TTLKey10"},
+		},
+		{
+			name:    "LTRIM",
+			preset:  [][]string{{"RPUSH", "TTLKey11", "value1", "value2", "value3"}},
+			command: []string{"LTRIM", "TTLKey11", "0", "1"},
+			keys:    []string{"TTLKey11"},
+		},
+		{
+			name:    "LREM",
+			preset:  [][]string{{"RPUSH", "TTLKey12", "value1", "value2", "value1"}},
+			command: []string{"LREM", "TTLKey12", "1", "value1"},
+			keys:    []string{"TTLKey12"},
+		},
+		{
+			name:    "LINSERT",
+			preset:  [][]string{{"RPUSH", "TTLKey13", "value1", "value2"}},
+			command: []string{"LINSERT", "TTLKey13", "BEFORE", "value2", "value3"},
+			keys:    []string{"TTLKey13"},
+		},
+		{
+			name:    "LPOP",
+			preset:  [][]string{{"RPUSH", "TTLKey14", "value1", "value2"}},
+			command: []string{"LPOP", "TTLKey14"},
+			keys:    []string{"TTLKey14"},
+		},
+		{
+			name:    "LMOVE",
+			preset:  [][]string{{"RPUSH", "TTLKey15", "value1", "value2"}, {"RPUSH", "TTLKey16", "value3"}},
+			command: []string{"LMOVE", "TTLKey15", "TTLKey16", "LEFT", "RIGHT"},
+			keys:    []string{"TTLKey15", "TTLKey16"},
+		},
+		{
+			name:    "SADD",
+			preset:  [][]string{{"SADD", "TTLKey17", "member1"}},
+			command: []string{"SADD", "TTLKey17", "member2"},
+			keys:    []string{"TTLKey17"},
+		},
+		{
+			name:    "SREM",
+			preset:  [][]string{{"SADD", "TTLKey18", "member1", "member2"}},
+			command: []string{"SREM", "TTLKey18", "member1"},
+			keys:    []string{"TTLKey18"},
+		},
+		{
+			name:    "SPOP",
+			preset:  [][]string{{"SADD", "TTLKey19", "member1", "member2"}},
+			command: []string{"SPOP", "TTLKey19"},
+			keys:    []string{"TTLKey19"},
+		},
+		{
+			name:    "SMOVE",
+			preset:  [][]string{{"SADD", "TTLKey20", "member1", "member2"}, {"SADD", "TTLKey21", "member3"}},
+			command: []string{"SMOVE", "TTLKey20", "TTLKey21", "member1"},
+			keys:    []string{"TTLKey20", "TTLKey21"},
+		},
+		{
+			name:    "ZADD",
+			preset:  [][]string{{"ZADD", "TTLKey22", "1", "member1"}},
+			command: []string{"ZADD", "TTLKey22", "2", "member2"},
+			keys:    []string{"TTLKey22"},
+		},
+		{
+			name:    "ZINCRBY",
+			preset:  [][]string{{"ZADD", "TTLKey23", "1", "member1"}},
+			command: []string{"ZINCRBY", "TTLKey23", "5", "member1"},
+			keys:    []string{"TTLKey23"},
+		},
+		{
+			name:    "ZREM",
+			preset:  [][]string{{"ZADD", "TTLKey24", "1", "member1", "2", "member2"}},
+			command: []string{"ZREM", "TTLKey24", "member1"},
+			keys:    []string{"TTLKey24"},
+		},
+		{
+			name:    "ZPOPMIN",
+			preset:  [][]string{{"ZADD", "TTLKey25", "1", "member1", "2", "member2"}},
+			command: []string{"ZPOPMIN", "TTLKey25"},
+			keys:    []string{"TTLKey25"},
+		},
+		{
+			name:    "ZREMRANGEBYSCORE",
+			preset:  [][]string{{"ZADD", "TTLKey26", "1", "member1", "2", "member2"}},
+			command: []string{"ZREMRANGEBYSCORE", "TTLKey26", "1", "1"},
+			keys:    []string{"TTLKey26"},
+		},
+		{
+			name:    "ZREMRANGEBYRANK",
+			preset:  [][]string{{"ZADD", "TTLKey27", "1", "member1", "2", "member2"}},
+			command: []string{"ZREMRANGEBYRANK", "TTLKey27", "0", "0"},
+			keys:    []string{"TTLKey27"},
+		},
+		{
+			name:    "GEOADD",
+			preset:  [][]string{{"GEOADD", "TTLKey28", "13.361389", "38.115556", "Palermo"}},
+			command: []string{"GEOADD", "TTLKey28", "15.087269", "37.502669", "Catania"},
+			keys:    []string{"TTLKey28"},
+		},
+		{
+			name:    "XADD",
+			preset:  [][]string{{"XADD", "TTLKey29", "1-1", "field1", "value1"}},
+			command: []string{"XADD", "TTLKey29", "1-2", "field2", "value2"},
+			keys:    []string{"TTLKey29"},
+		},
+		{
+			name:    "XGROUP CREATE",
+			preset:  [][]string{{"XADD", "TTLKey30", "1-1", "field1", "value1"}},
+			command: []string{"XGROUP", "CREATE", "TTLKey30", "group1", "0"},
+			keys:    []string{"TTLKey30"},
+		},
+		{
+			name: "XREADGROUP",
+			preset: [][]string{
+				{"XADD", "TTLKey31", "1-1", "field1", "value1"},
+				{"XGROUP", "CREATE", "TTLKey31", "group1", "0"},
+			},
+			command: []string{"XREADGROUP", "GROUP", "group1", "consumer1", "STREAMS", "TTLKey31", ">"},
+			keys:    []string{"TTLKey31"},
+		},
+		{
+			name:    "RPOP",
+			preset:  [][]string{{"RPUSH", "TTLKey32", "value1", "value2"}},
+			command: []string{"RPOP", "TTLKey32"},
+			keys:    []string{"TTLKey32"},
+		},
+		{
+			name:    "LPUSHX",
+			preset:  [][]string{{"RPUSH", "TTLKey33", "value1"}},
+			command: []string{"LPUSHX", "TTLKey33", "value2"},
+			keys:    []string{"TTLKey33"},
+		},
+		{
+			name:    "RPUSH",
+			preset:  [][]string{{"RPUSH", "TTLKey34", "value1"}},
+			command: []string{"RPUSH", "TTLKey34", "value2"},
+			keys:    []string{"TTLKey34"},
+		},
+		{
+			name:    "BLPOP",
+			preset:  [][]string{{"RPUSH", "TTLKey35", "value1", "value2"}},
+			command: []string{"BLPOP", "TTLKey35", "0"},
+			keys:    []string{"TTLKey35"},
+		},
+		{
+			name:    "ZPOPMAX",
+			preset:  [][]string{{"ZADD", "TTLKey36", "1", "member1", "2", "member2"}},
+			command: []string{"ZPOPMAX", "TTLKey36"},
+			keys:    []string{"TTLKey36"},
+		},
+		{
+			name:    "ZMPOP",
+			preset:  [][]string{{"ZADD", "TTLKey37", "1", "member1", "2", "member2"}},
+			command: []string{"ZMPOP", "TTLKey37", "MIN"},
+			keys:    []string{"TTLKey37"},
+		},
+		{
+			name:    "ZREMRANGEBYLEX",
+			preset:  [][]string{{"ZADD", "TTLKey38", "0", "a", "0", "b"}},
+			command: []string{"ZREMRANGEBYLEX", "TTLKey38", "[a", "[a"},
+			keys:    []string{"TTLKey38"},
+		},
+		{
+			name: "XACK",
+			preset: [][]string{
+				{"XADD", "TTLKey39", "1-1", "field1", "value1"},
+				{"XGROUP", "CREATE", "TTLKey39", "group1", "0"},
+				{"XREADGROUP", "GROUP", "group1", "consumer1", "STREAMS", "TTLKey39", ">"},
+			},
+			command: []string{"XACK", "TTLKey39", "group1", "1-1"},
+			keys:    []string{"TTLKey39"},
+		},
+		{
+			name: "XCLAIM",
+			preset: [][]string{
+				{"XADD", "TTLKey40", "1-1", "field1", "value1"},
+				{"XGROUP", "CREATE", "TTLKey40", "group1", "0"},
+				{"XREADGROUP", "GROUP", "group1", "consumer1", "STREAMS", "TTLKey40", ">"},
+			},
+			command: []string{"XCLAIM", "TTLKey40", "group1", "consumer2", "0", "1-1"},
+			keys:    []string{"TTLKey40"},
+		},
+		{
+			name: "XGROUP DESTROY",
+			preset: [][]string{
+				{"XADD", "TTLKey41", "1-1", "field1", "value1"},
+				{"XGROUP", "CREATE", "TTLKey41", "group1", "0"},
+			},
+			command: []string{"XGROUP", "DESTROY", "TTLKey41", "group1"},
+			keys:    []string{"TTLKey41"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, cmd := range tt.preset {
+				if _, err := server.ExecuteCommand(ctx, cmd...); err != nil {
+					t.Fatalf("preset %v error = %v", cmd, err)
+				}
+			}
+
+			expireAt := make(map[string]int, len(tt.keys))
+			for i, key := range tt.keys {
+				// Give each key a different expiry time to make sure they're not swapped by the command.
+				if _, err := server.PExpire(key, 100000+i*1000, echovault.PExpireOptions{}); err != nil {
+					t.Fatal(err)
+				}
+				pexpireTime, err := server.PExpireTime(key)
+				if err != nil {
+					t.Fatal(err)
+				}
+				expireAt[key] = pexpireTime
+			}
+
+			if _, err := server.ExecuteCommand(ctx, tt.command...); err != nil {
+				t.Fatalf("%s error = %v", tt.name, err)
+			}
+
+			for key, want := range expireAt {
+				got, err := server.PExpireTime(key)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got != want {
+					t.Errorf("%s expiry time of key %s = %v, want %v", tt.name, key, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestEchoVault_OnExpire(t *testing.T) {
+	server := createEchoVault()
+	mockClock := clock.NewClock()
+
+	expired := make(chan echovault.ExpiredKey, 10)
+	server.OnExpire("tag1", func(key echovault.ExpiredKey) {
+		expired <- key
+	})
+
+	presetKeyData(server, context.Background(), "key1", internal.KeyData{
+		Value:    map[string]interface{}{"user": "user1"},
+		ExpireAt: mockClock.Now().Add(-10 * time.Second),
+	})
+	presetKeyData(server, context.Background(), "key2", internal.KeyData{
+		Value:    "value2",
+		ExpireAt: mockClock.Now().Add(1000 * time.Second),
+	})
+
+	// Reading the expired key deletes it and reports its value.
+	if got, _ := server.Exists("key1"); got != 0 {
+		t.Errorf("EXISTS() got = %v, want %v", got, 0)
+	}
+	select {
+	case key := <-expired:
+		want := echovault.ExpiredKey{
+			Key:      "key1",
+			Value:    map[string]interface{}{"user": "user1"},
+			ExpireAt: mockClock.Now().Add(-10 * time.Second),
+		}
+		if !reflect.DeepEqual(key, want) {
+			t.Errorf("OnExpire() got = %+v, want %+v", key, want)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("OnExpire() callback was not called for the expired key")
+	}
+
+	// Keys that are deleted before they expire are not reported.
+	if _, err := server.Del("key2"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Callbacks that are removed are no longer called.
+	server.RemoveExpireCallback("tag1")
+	presetKeyData(server, context.Background(), "key3", internal.KeyData{
+		Value:    "value3",
+		ExpireAt: mockClock.Now().Add(-10 * time.Second),
+	})
+	if got, _ := server.Exists("key3"); got != 0 {
+		t.Errorf("EXISTS() got = %v, want %v", got, 0)
+	}
+
+	select {
+	case key := <-expired:
+		t.Errorf("OnExpire() expected no more callbacks, got %+v", key)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestEchoVault_KEYS(t *testing.T) {
+	server := createEchoVault()
+
+	for _, key := range []string{"key1", "key2", "key10", "other1"} {
+		presetKeyData(server, context.Background(), key, internal.KeyData{Value: "value", ExpireAt: time.Time{}})
+	}
+
+	tests := []struct {
+		name    string
+		pattern string
+		want    []string
+	}{
+		{name: "Return all the keys", pattern: "*", want: []string{"key1", "key10", "key2", "other1"}},
+		{name: "Return the keys that match the pattern", pattern: "key?", want: []string{"key1", "key2"}},
+		{name: "Return no keys when none match", pattern: "none*", want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := server.Keys(tt.pattern)
+			if err != nil {
+				t.Errorf("KEYS() error = %v", err)
+				return
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("KEYS() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEchoVault_SCAN(t *testing.T) {
+	server := createEchoVault()
+
+	var want []string
+	for i := 0; i < 30; i++ {
+		key := fmt.Sprintf("key%d", i)
+		presetKeyData(server, context.Background(), key, internal.KeyData{Value: "value", ExpireAt: time.Time{}})
+		want = append(want, key)
+	}
+	presetKeyData(server, context.Background(), "list1", internal.KeyData{Value: []interface{}{"value"}, ExpireAt: time.Time{}})
+	slices.Sort(want)
+
+	var got []string
+	var cursor uint64
+	for {
+		next, keys, err := server.Scan(cursor, echovault.ScanOptions{Match: "key*", Count: 4, Type: "string"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, keys...)
+		if cursor = next; cursor == 0 {
+			break
+		}
+	}
+	slices.Sort(got)
+	if !slices.Equal(got, want) {
+		t.Errorf("SCAN() got = %v, want %v", got, want)
+	}
+}
+
+func TestEchoVault_GetWithVersion_SetIfVersion(t *testing.T) {
+	server := createEchoVault()
+
+	// A missing key has version 0, so version 0 only sets a key that does not exist.
+	value, version, err := server.GetWithVersion("VersionKey1")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if value != "" || version != 0 {
+		t.Errorf("GetWithVersion() got = (%q, %d), want (\"\", 0)", value, version)
+	}
+	if ok, err := server.SetIfVersion("VersionKey1", "value1", 0); err != nil || !ok {
+		t.Errorf("SetIfVersion() got = (%v, %v), want (true, nil)", ok, err)
+		return
+	}
+	if ok, err := server.SetIfVersion("VersionKey1", "value2", 0); err != nil || ok {
+		t.Errorf("SetIfVersion() got = (%v, %v), want (false, nil)", ok, err)
+		return
+	}
+
+	value, version, err = server.GetWithVersion("VersionKey1")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if value != "value1" || version == 0 {
+		t.Errorf("GetWithVersion() got = (%q, %d), want (\"value1\", > 0)", value, version)
+	}
+	objectVersion, err := server.ObjectVersion("VersionKey1")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if objectVersion != version {
+		t.Errorf("ObjectVersion() got = %d, want %d", objectVersion, version)
+	}
+
+	// Reading the key does not change its version.
+	if _, err = server.Get("VersionKey1"); err != nil {
+		t.Error(err)
+		return
+	}
+	// A write in between the read and the conditional set makes the conditional set fail.
+	if _, err = server.Set("VersionKey1", "value3", echovault.SetOptions{}); err != nil {
+		t.Error(err)
+		return
+	}
+	if ok, err := server.SetIfVersion("VersionKey1", "value4", version); err != nil || ok {
+		t.Errorf("SetIfVersion() got = (%v, %v), want (false, nil)", ok, err)
+		return
+	}
+
+	value, version, err = server.GetWithVersion("VersionKey1")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if value != "value3" {
+		t.Errorf("GetWithVersion() got value = %q, want \"value3\"", value)
+	}
+	if ok, err := server.SetIfVersion("VersionKey1", "value5", version); err != nil || !ok {
+		t.Errorf("SetIfVersion() got = (%v, %v), want (true, nil)", ok, err)
+		return
+	}
+	if value, _ = server.Get("VersionKey1"); value != "value5" {
+		t.Errorf("GET() got = %q, want \"value5\"", value)
+	}
+
+	// Deleting the key resets its version.
+	if _, err = server.Del("VersionKey1"); err != nil {
+		t.Error(err)
+		return
+	}
+	if objectVersion, err = server.ObjectVersion("VersionKey1"); err != nil || objectVersion != 0 {
+		t.Errorf("ObjectVersion() got = (%d, %v), want (0, nil)", objectVersion, err)
+	}
+}
+
+func TestEchoVault_SetIfVersionConcurrent(t *testing.T) {
+	server := createEchoVault()
+
+	// Each goroutine increments the counter with a compare-and-set loop, so no increment is lost.
+	workers, increments := 10, 20
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < increments; j++ {
+				for {
+					value, version, err := server.GetWithVersion("VersionCounterKey")
+					if err != nil {
+						t.Error(err)
+						return
+					}
+					counter, _ := strconv.Atoi(value)
+					ok, err := server.SetIfVersion("VersionCounterKey", strconv.Itoa(counter+1), version)
+					if err != nil {
+						t.Error(err)
+						return
+					}
+					if ok {
+						break
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	value, err := server.Get("VersionCounterKey")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if value != strconv.Itoa(workers*increments) {
+		t.Errorf("GET() got = %s, want %d", value, workers*increments)
+	}
+}
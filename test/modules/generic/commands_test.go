@@ -24,10 +24,15 @@ import (
 	"github.com/echovault/echovault/internal/clock"
 	"github.com/echovault/echovault/internal/config"
 	"github.com/echovault/echovault/internal/constants"
+	"github.com/echovault/echovault/internal/keyindex"
+	"github.com/echovault/echovault/internal/modules/set"
+	"github.com/echovault/echovault/internal/modules/sorted_set"
 	"github.com/tidwall/resp"
 	"net"
 	"reflect"
+	"slices"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 	"unsafe"
@@ -83,6 +88,8 @@ func getHandler(commands ...string) internal.HandlerFunc {
 func getHandlerFuncParams(ctx context.Context, cmd []string, conn *net.Conn) internal.HandlerFuncParams {
 	getClock :=
 		getUnexportedField(reflect.ValueOf(mockServer).Elem().FieldByName("getClock")).(func() clock.Clock)
+	keyIndex :=
+		getUnexportedField(reflect.ValueOf(mockServer).Elem().FieldByName("keyIndex")).(*keyindex.Index)
 	return internal.HandlerFuncParams{
 		Context:          ctx,
 		Command:          cmd,
@@ -97,7 +104,10 @@ func getHandlerFuncParams(ctx context.Context, cmd []string, conn *net.Conn) int
 		SetValue:         mockServer.SetValue,
 		GetExpiry:        mockServer.GetExpiry,
 		SetExpiry:        mockServer.SetExpiry,
+		RemoveExpiry:     mockServer.RemoveExpiry,
 		DeleteKey:        mockServer.DeleteKey,
+		DeleteLockedKey:  mockServer.DeleteLockedKey,
+		ScanKeys:         keyIndex.Scan,
 		GetClock:         getClock,
 	}
 }
@@ -149,7 +159,7 @@ func Test_HandleSET(t *testing.T) {
 			expectedErr:      nil,
 		},
 		{
-			name:    "5. Throw error when value already exists with NX flag passed",
+			name:    "5. Return nil when value already exists with NX flag passed",
 			command: []string{"SET", "SetKey5", "value5", "NX"},
 			presetValues: map[string]KeyData{
 				"SetKey5": {
@@ -160,7 +170,7 @@ func Test_HandleSET(t *testing.T) {
 			expectedResponse: nil,
 			expectedValue:    "preset-value5",
 			expectedExpiry:   time.Time{},
-			expectedErr:      errors.New("key SetKey5 already exists"),
+			expectedErr:      nil,
 		},
 		{
 			name:    "6. Set new key value when key exists with XX flag passed",
@@ -177,13 +187,13 @@ func Test_HandleSET(t *testing.T) {
 			expectedErr:      nil,
 		},
 		{
-			name:             "7. Return error when setting non-existent key with XX flag",
+			name:             "7. Return nil when setting non-existent key with XX flag",
 			command:          []string{"SET", "SetKey7", "value7", "XX"},
 			presetValues:     nil,
 			expectedResponse: nil,
 			expectedValue:    nil,
 			expectedExpiry:   time.Time{},
-			expectedErr:      errors.New("key SetKey7 does not exist"),
+			expectedErr:      nil,
 		},
 		{
 			name:             "8. Return error when NX flag is provided after XX flag",
@@ -407,6 +417,75 @@ func Test_HandleSET(t *testing.T) {
 			expectedValue:    nil,
 			expectedErr:      errors.New(constants.WrongArgsResponse),
 		},
+		{
+			name:    "31. Remove the previous expiry time when KEEPTTL is not passed",
+			command: []string{"SET", "SetKey31", "value31"},
+			presetValues: map[string]KeyData{
+				"SetKey31": {
+					Value:    "preset-value31",
+					ExpireAt: mockClock.Now().Add(100 * time.Second),
+				},
+			},
+			expectedResponse: "OK",
+			expectedValue:    "value31",
+			expectedExpiry:   time.Time{},
+			expectedErr:      nil,
+		},
+		{
+			name:    "32. Keep the previous expiry time when KEEPTTL is passed",
+			command: []string{"SET", "SetKey32", "value32", "KEEPTTL"},
+			presetValues: map[string]KeyData{
+				"SetKey32": {
+					Value:    "preset-value32",
+					ExpireAt: mockClock.Now().Add(100 * time.Second),
+				},
+			},
+			expectedResponse: "OK",
+			expectedValue:    "value32",
+			expectedExpiry:   mockClock.Now().Add(100 * time.Second),
+			expectedErr:      nil,
+		},
+		{
+			name:             "33. Return error when KEEPTTL is passed after an expiry time",
+			command:          []string{"SET", "SetKey33", "value33", "EX", "10", "KEEPTTL"},
+			presetValues:     nil,
+			expectedResponse: nil,
+			expectedValue:    nil,
+			expectedExpiry:   time.Time{},
+			expectedErr:      errors.New("cannot specify KEEPTTL when expiry time is already set"),
+		},
+		{
+			name:             "34. Return error when an expiry time is passed after KEEPTTL",
+			command:          []string{"SET", "SetKey34", "value34", "KEEPTTL", "PX", "10"},
+			presetValues:     nil,
+			expectedResponse: nil,
+			expectedValue:    nil,
+			expectedExpiry:   time.Time{},
+			expectedErr:      errors.New("cannot specify PX when KEEPTTL is already specified"),
+		},
+		{
+			name:    "35. Return the current value without setting the key when NX and GET are passed",
+			command: []string{"SET", "SetKey35", "value35", "NX", "GET"},
+			presetValues: map[string]KeyData{
+				"SetKey35": {
+					Value:    "preset-value35",
+					ExpireAt: time.Time{},
+				},
+			},
+			expectedResponse: "preset-value35",
+			expectedValue:    "preset-value35",
+			expectedExpiry:   time.Time{},
+			expectedErr:      nil,
+		},
+		{
+			name:             "36. Return error when the expiry time is not positive",
+			command:          []string{"SET", "SetKey36", "value36", "EX", "0"},
+			presetValues:     nil,
+			expectedResponse: nil,
+			expectedValue:    nil,
+			expectedExpiry:   time.Time{},
+			expectedErr:      errors.New("seconds value should be a positive integer"),
+		},
 	}
 
 	for i, test := range tests {
@@ -470,12 +549,14 @@ func Test_HandleSET(t *testing.T) {
 			var value interface{}
 			var expireAt time.Time
 
-			if _, err = mockServer.KeyLock(ctx, key); err != nil {
-				t.Error(err)
+			if mockServer.KeyExists(ctx, key) {
+				if _, err = mockServer.KeyLock(ctx, key); err != nil {
+					t.Error(err)
+				}
+				value = mockServer.GetValue(ctx, key)
+				expireAt = mockServer.GetExpiry(ctx, key)
+				mockServer.KeyUnlock(ctx, key)
 			}
-			value = mockServer.GetValue(ctx, key)
-			expireAt = mockServer.GetExpiry(ctx, key)
-			mockServer.KeyUnlock(ctx, key)
 
 			if value != test.expectedValue {
 				t.Errorf("expected value %+v, got %+v", test.expectedValue, value)
@@ -487,6 +568,88 @@ func Test_HandleSET(t *testing.T) {
 	}
 }
 
+func Test_HandleSETEX(t *testing.T) {
+	tests := []struct {
+		name           string
+		command        []string
+		expectedValue  interface{}
+		expectedExpiry time.Time
+		expectedErr    error
+	}{
+		{
+			name:           "1. Set the value and expire the key after the number of seconds",
+			command:        []string{"SETEX", "SetExKey1", "100", "value1"},
+			expectedValue:  "value1",
+			expectedExpiry: mockClock.Now().Add(100 * time.Second),
+			expectedErr:    nil,
+		},
+		{
+			name:           "2. Set the value and expire the key after the number of milliseconds",
+			command:        []string{"PSETEX", "SetExKey2", "4096", "value2"},
+			expectedValue:  "value2",
+			expectedExpiry: mockClock.Now().Add(4096 * time.Millisecond),
+			expectedErr:    nil,
+		},
+		{
+			name:        "3. Return error when the expiry time is not an integer",
+			command:     []string{"SETEX", "SetExKey3", "seconds", "value3"},
+			expectedErr: errors.New("seconds value should be an integer"),
+		},
+		{
+			name:        "4. Return error when the expiry time is not positive",
+			command:     []string{"PSETEX", "SetExKey4", "-10", "value4"},
+			expectedErr: errors.New("milliseconds value should be a positive integer"),
+		},
+		{
+			name:        "5. Command too short",
+			command:     []string{"SETEX", "SetExKey5", "100"},
+			expectedErr: errors.New(constants.WrongArgsResponse),
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), "test_name", fmt.Sprintf("SETEX, %d", i+1))
+
+			handler := getHandler(test.command[0])
+			if handler == nil {
+				t.Errorf("no handler found for command %s", test.command[0])
+				return
+			}
+
+			res, err := handler(getHandlerFuncParams(ctx, test.command, nil))
+			if test.expectedErr != nil {
+				if err == nil || test.expectedErr.Error() != err.Error() {
+					t.Errorf("expected error \"%s\", got \"%v\"", test.expectedErr.Error(), err)
+				}
+				return
+			}
+			if err != nil {
+				t.Error(err)
+			}
+			if string(res) != "+OK\r\n" {
+				t.Errorf("expected response OK, got %q", string(res))
+			}
+
+			key := test.command[1]
+			if _, err = mockServer.KeyRLock(ctx, key); err != nil {
+				t.Error(err)
+				return
+			}
+			value := mockServer.GetValue(ctx, key)
+			expireAt := mockServer.GetExpiry(ctx, key)
+			mockServer.KeyRUnlock(ctx, key)
+
+			if value != test.expectedValue {
+				t.Errorf("expected value %+v, got %+v", test.expectedValue, value)
+			}
+			if !test.expectedExpiry.Equal(expireAt) {
+				t.Errorf("expected expiry time %v, got %v", test.expectedExpiry, expireAt)
+			}
+		})
+	}
+}
+
 func Test_HandleMSET(t *testing.T) {
 	tests := []struct {
 		name             string
@@ -509,6 +672,20 @@ func Test_HandleMSET(t *testing.T) {
 			expectedValues:   make(map[string]interface{}),
 			expectedErr:      errors.New("each key must be paired with a value"),
 		},
+		{
+			name:             "3. Set the last value given for a repeated key",
+			command:          []string{"MSET", "MsetKey4", "value1", "MsetKey5", "value2", "MsetKey4", "value3"},
+			expectedResponse: "OK",
+			expectedValues:   map[string]interface{}{"MsetKey4": "value3", "MsetKey5": "value2"},
+			expectedErr:      nil,
+		},
+		{
+			name:             "4. Command too short",
+			command:          []string{"MSET"},
+			expectedResponse: "",
+			expectedValues:   make(map[string]interface{}),
+			expectedErr:      errors.New(constants.WrongArgsResponse),
+		},
 	}
 
 	for i, test := range tests {
@@ -577,6 +754,101 @@ func Test_HandleMSET(t *testing.T) {
 	}
 }
 
+func Test_HandleMSETNX(t *testing.T) {
+	tests := []struct {
+		name             string
+		presetValues     map[string]string
+		command          []string
+		expectedResponse int
+		expectedValues   map[string]interface{}
+		expectedErr      error
+	}{
+		{
+			name:             "1. Set multiple keys when none of them exist",
+			command:          []string{"MSETNX", "MsetNXKey1", "value1", "MsetNXKey2", "10"},
+			expectedResponse: 1,
+			expectedValues:   map[string]interface{}{"MsetNXKey1": "value1", "MsetNXKey2": 10},
+			expectedErr:      nil,
+		},
+		{
+			name:             "2. Set none of the keys when one of them exists",
+			presetValues:     map[string]string{"MsetNXKey4": "existing"},
+			command:          []string{"MSETNX", "MsetNXKey3", "value3", "MsetNXKey4", "value4"},
+			expectedResponse: 0,
+			expectedValues:   map[string]interface{}{"MsetNXKey3": nil, "MsetNXKey4": "existing"},
+			expectedErr:      nil,
+		},
+		{
+			name:        "3. Return error when keys and values are not even",
+			command:     []string{"MSETNX", "MsetNXKey5", "value5", "MsetNXKey6"},
+			expectedErr: errors.New("each key must be paired with a value"),
+		},
+		{
+			name:        "4. Command too short",
+			command:     []string{"MSETNX"},
+			expectedErr: errors.New(constants.WrongArgsResponse),
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), "test_name", fmt.Sprintf("MSETNX, %d", i))
+
+			for key, value := range test.presetValues {
+				if _, err := mockServer.CreateKeyAndLock(ctx, key); err != nil {
+					t.Error(err)
+				}
+				if err := mockServer.SetValue(ctx, key, value); err != nil {
+					t.Error(err)
+				}
+				mockServer.KeyUnlock(ctx, key)
+			}
+
+			handler := getHandler(test.command[0])
+			if handler == nil {
+				t.Errorf("no handler found for command %s", test.command[0])
+				return
+			}
+
+			res, err := handler(getHandlerFuncParams(ctx, test.command, nil))
+			if test.expectedErr != nil {
+				if err == nil || err.Error() != test.expectedErr.Error() {
+					t.Errorf("expected error %v, got %v", test.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			rd := resp.NewReader(bytes.NewBuffer(res))
+			rv, _, err := rd.ReadValue()
+			if err != nil {
+				t.Error(err)
+			}
+			if rv.Integer() != test.expectedResponse {
+				t.Errorf("expected response %d, got %d", test.expectedResponse, rv.Integer())
+			}
+			for key, expectedValue := range test.expectedValues {
+				if expectedValue == nil {
+					if mockServer.KeyExists(ctx, key) {
+						t.Errorf("expected key %s not to exist", key)
+					}
+					continue
+				}
+				if _, err = mockServer.KeyRLock(ctx, key); err != nil {
+					t.Error(err)
+					continue
+				}
+				if value := mockServer.GetValue(ctx, key); value != expectedValue {
+					t.Errorf("expected value %v for key %s, got %v", expectedValue, key, value)
+				}
+				mockServer.KeyRUnlock(ctx, key)
+			}
+		})
+	}
+}
+
 func Test_HandleGET(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -675,56 +947,94 @@ func Test_HandleGET(t *testing.T) {
 	}
 }
 
-func Test_HandleMGET(t *testing.T) {
+func Test_HandleGETEX(t *testing.T) {
 	tests := []struct {
-		name          string
-		presetKeys    []string
-		presetValues  []string
-		command       []string
-		expected      []interface{}
-		expectedError error
+		name             string
+		command          []string
+		presetValues     map[string]KeyData
+		expectedResponse interface{}
+		expectedExpiry   time.Time
+		expectedErr      error
 	}{
 		{
-			name:          "1. MGET multiple existing values",
-			presetKeys:    []string{"MgetKey1", "MgetKey2", "MgetKey3", "MgetKey4"},
-			presetValues:  []string{"value1", "value2", "value3", "value4"},
-			command:       []string{"MGET", "MgetKey1", "MgetKey4", "MgetKey2", "MgetKey3", "MgetKey1"},
-			expected:      []interface{}{"value1", "value4", "value2", "value3", "value1"},
-			expectedError: nil,
+			name:    "1. Get the value without changing the expiry time",
+			command: []string{"GETEX", "GetExKey1"},
+			presetValues: map[string]KeyData{
+				"GetExKey1": {Value: "value1", ExpireAt: mockClock.Now().Add(100 * time.Second)},
+			},
+			expectedResponse: "value1",
+			expectedExpiry:   mockClock.Now().Add(100 * time.Second),
 		},
 		{
-			name:          "2. MGET multiple values with nil values spliced in",
-			presetKeys:    []string{"MgetKey5", "MgetKey6", "MgetKey7"},
-			presetValues:  []string{"value5", "value6", "value7"},
-			command:       []string{"MGET", "MgetKey5", "MgetKey6", "non-existent", "non-existent", "MgetKey7", "non-existent"},
-			expected:      []interface{}{"value5", "value6", nil, nil, "value7", nil},
-			expectedError: nil,
+			name:    "2. Get the value and set the expiry time in seconds",
+			command: []string{"GETEX", "GetExKey2", "EX", "10"},
+			presetValues: map[string]KeyData{
+				"GetExKey2": {Value: "value2", ExpireAt: time.Time{}},
+			},
+			expectedResponse: "value2",
+			expectedExpiry:   mockClock.Now().Add(10 * time.Second),
 		},
 		{
-			name:          "3. Return error when MGET is invoked with no keys",
-			presetKeys:    []string{"MgetKey5"},
-			presetValues:  []string{"value5"},
-			command:       []string{"MGET"},
-			expected:      nil,
-			expectedError: errors.New(constants.WrongArgsResponse),
+			name:    "3. Get the value and set the exact expiry time in unix milliseconds",
+			command: []string{"GETEX", "GetExKey3", "PXAT", fmt.Sprintf("%d", mockClock.Now().Add(4096*time.Millisecond).UnixMilli())},
+			presetValues: map[string]KeyData{
+				"GetExKey3": {Value: "value3", ExpireAt: time.Time{}},
+			},
+			expectedResponse: "value3",
+			expectedExpiry:   mockClock.Now().Add(4096 * time.Millisecond),
+		},
+		{
+			name:    "4. Get the value and remove the expiry time with PERSIST",
+			command: []string{"GETEX", "GetExKey4", "PERSIST"},
+			presetValues: map[string]KeyData{
+				"GetExKey4": {Value: "value4", ExpireAt: mockClock.Now().Add(100 * time.Second)},
+			},
+			expectedResponse: "value4",
+			expectedExpiry:   time.Time{},
+		},
+		{
+			name:             "5. Return nil when the key does not exist",
+			command:          []string{"GETEX", "GetExKey5", "EX", "10"},
+			presetValues:     nil,
+			expectedResponse: nil,
+		},
+		{
+			name:        "6. Return error when more than one expiry option is passed",
+			command:     []string{"GETEX", "GetExKey6", "PERSIST", "EX"},
+			expectedErr: errors.New("cannot specify EX when expiry option is already specified"),
+		},
+		{
+			name:        "7. Return error when the expiry time is not an integer",
+			command:     []string{"GETEX", "GetExKey7", "PX", "milliseconds"},
+			expectedErr: errors.New("milliseconds value should be an integer"),
+		},
+		{
+			name:        "8. Return error when an unknown option is passed",
+			command:     []string{"GETEX", "GetExKey8", "KEEPTTL"},
+			expectedErr: errors.New("unknown option KEEPTTL for getex command"),
+		},
+		{
+			name:        "9. Command too short",
+			command:     []string{"GETEX"},
+			expectedErr: errors.New(constants.WrongArgsResponse),
 		},
 	}
 
 	for i, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			ctx := context.WithValue(context.Background(), "test_name", fmt.Sprintf("MGET, %d", i))
-			// Set up the values
-			for i, key := range test.presetKeys {
-				_, err := mockServer.CreateKeyAndLock(ctx, key)
-				if err != nil {
+			ctx := context.WithValue(context.Background(), "test_name", fmt.Sprintf("GETEX, %d", i+1))
+
+			for k, v := range test.presetValues {
+				if _, err := mockServer.CreateKeyAndLock(ctx, k); err != nil {
 					t.Error(err)
 				}
-				if err = mockServer.SetValue(ctx, key, test.presetValues[i]); err != nil {
+				if err := mockServer.SetValue(ctx, k, v.Value); err != nil {
 					t.Error(err)
 				}
-				mockServer.KeyUnlock(ctx, key)
+				mockServer.SetExpiry(ctx, k, v.ExpireAt, false)
+				mockServer.KeyUnlock(ctx, k)
 			}
-			// Test the command and its results
+
 			handler := getHandler(test.command[0])
 			if handler == nil {
 				t.Errorf("no handler found for command %s", test.command[0])
@@ -732,32 +1042,184 @@ func Test_HandleMGET(t *testing.T) {
 			}
 
 			res, err := handler(getHandlerFuncParams(ctx, test.command, nil))
-			if test.expectedError != nil {
-				// If we expect and error, branch out and check error
-				if err.Error() != test.expectedError.Error() {
-					t.Errorf("expected error %+v, got: %+v", test.expectedError, err)
+			if test.expectedErr != nil {
+				if err == nil || test.expectedErr.Error() != err.Error() {
+					t.Errorf("expected error \"%s\", got \"%v\"", test.expectedErr.Error(), err)
 				}
 				return
 			}
 			if err != nil {
 				t.Error(err)
 			}
-			rr := resp.NewReader(bytes.NewBuffer(res))
-			rv, _, err := rr.ReadValue()
+
+			rd := resp.NewReader(bytes.NewReader(res))
+			rv, _, err := rd.ReadValue()
 			if err != nil {
 				t.Error(err)
 			}
-			if rv.Type().String() != "Array" {
-				t.Errorf("expected type Array, got: %s", rv.Type().String())
+			if test.expectedResponse == nil {
+				if !rv.IsNull() {
+					t.Errorf("expected nil response, got %+v", rv)
+				}
+				return
 			}
-			for i, value := range rv.Array() {
+			if rv.String() != test.expectedResponse {
+				t.Errorf("expected response \"%s\", got \"%s\"", test.expectedResponse, rv.String())
+			}
+
+			key := test.command[1]
+			if _, err = mockServer.KeyRLock(ctx, key); err != nil {
+				t.Error(err)
+				return
+			}
+			expireAt := mockServer.GetExpiry(ctx, key)
+			mockServer.KeyRUnlock(ctx, key)
+			if !test.expectedExpiry.Equal(expireAt) {
+				t.Errorf("expected expiry time %v, got %v", test.expectedExpiry, expireAt)
+			}
+		})
+	}
+}
+
+func Test_HandleGETDEL(t *testing.T) {
+	ctx := context.WithValue(context.Background(), "test_name", "GETDEL")
+
+	if _, err := mockServer.CreateKeyAndLock(ctx, "GetDelKey1"); err != nil {
+		t.Error(err)
+	}
+	if err := mockServer.SetValue(ctx, "GetDelKey1", "value1"); err != nil {
+		t.Error(err)
+	}
+	mockServer.KeyUnlock(ctx, "GetDelKey1")
+
+	handler := getHandler("GETDEL")
+	if handler == nil {
+		t.Error("no handler found for command GETDEL")
+		return
+	}
+
+	// Return the value and delete the key.
+	res, err := handler(getHandlerFuncParams(ctx, []string{"GETDEL", "GetDelKey1"}, nil))
+	if err != nil {
+		t.Error(err)
+	}
+	if !bytes.Equal(res, []byte("+value1\r\n")) {
+		t.Errorf("expected %q, got %q", "+value1\r\n", string(res))
+	}
+	if mockServer.KeyExists(ctx, "GetDelKey1") {
+		t.Error("expected GetDelKey1 to be deleted")
+	}
+
+	// Return nil once the key has been deleted.
+	res, err = handler(getHandlerFuncParams(ctx, []string{"GETDEL", "GetDelKey1"}, nil))
+	if err != nil {
+		t.Error(err)
+	}
+	if !bytes.Equal(res, []byte("$-1\r\n")) {
+		t.Errorf("expected %q, got %q", "$-1\r\n", string(res))
+	}
+
+	if _, err = handler(getHandlerFuncParams(ctx, []string{"GETDEL", "GetDelKey1", "GetDelKey2"}, nil)); err == nil ||
+		err.Error() != constants.WrongArgsResponse {
+		t.Errorf("expected error \"%s\", got \"%v\"", constants.WrongArgsResponse, err)
+	}
+}
+
+func Test_HandleMGET(t *testing.T) {
+	tests := []struct {
+		name          string
+		presetKeys    []string
+		presetValues  []string
+		command       []string
+		expected      []interface{}
+		expectedError error
+	}{
+		{
+			name:          "1. MGET multiple existing values",
+			presetKeys:    []string{"MgetKey1", "MgetKey2", "MgetKey3", "MgetKey4"},
+			presetValues:  []string{"value1", "value2", "value3", "value4"},
+			command:       []string{"MGET", "MgetKey1", "MgetKey4", "MgetKey2", "MgetKey3", "MgetKey1"},
+			expected:      []interface{}{"value1", "value4", "value2", "value3", "value1"},
+			expectedError: nil,
+		},
+		{
+			name:          "2. MGET multiple values with nil values spliced in",
+			presetKeys:    []string{"MgetKey5", "MgetKey6", "MgetKey7"},
+			presetValues:  []string{"value5", "value6", "value7"},
+			command:       []string{"MGET", "MgetKey5", "MgetKey6", "non-existent", "non-existent", "MgetKey7", "non-existent"},
+			expected:      []interface{}{"value5", "value6", nil, nil, "value7", nil},
+			expectedError: nil,
+		},
+		{
+			name:          "3. Return error when MGET is invoked with no keys",
+			presetKeys:    []string{"MgetKey5"},
+			presetValues:  []string{"value5"},
+			command:       []string{"MGET"},
+			expected:      nil,
+			expectedError: errors.New(constants.WrongArgsResponse),
+		},
+		{
+			name:          "4. Return empty strings as values rather than nil",
+			presetKeys:    []string{"MgetKey8", "MgetKey9"},
+			presetValues:  []string{"", "value9"},
+			command:       []string{"MGET", "MgetKey9", "MgetKey8", "MgetKey9"},
+			expected:      []interface{}{"value9", "", "value9"},
+			expectedError: nil,
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), "test_name", fmt.Sprintf("MGET, %d", i))
+			// Set up the values
+			for i, key := range test.presetKeys {
+				_, err := mockServer.CreateKeyAndLock(ctx, key)
+				if err != nil {
+					t.Error(err)
+				}
+				if err = mockServer.SetValue(ctx, key, test.presetValues[i]); err != nil {
+					t.Error(err)
+				}
+				mockServer.KeyUnlock(ctx, key)
+			}
+			// Test the command and its results
+			handler := getHandler(test.command[0])
+			if handler == nil {
+				t.Errorf("no handler found for command %s", test.command[0])
+				return
+			}
+
+			res, err := handler(getHandlerFuncParams(ctx, test.command, nil))
+			if test.expectedError != nil {
+				// If we expect and error, branch out and check error
+				if err.Error() != test.expectedError.Error() {
+					t.Errorf("expected error %+v, got: %+v", test.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Error(err)
+			}
+			rr := resp.NewReader(bytes.NewBuffer(res))
+			rv, _, err := rr.ReadValue()
+			if err != nil {
+				t.Error(err)
+			}
+			if rv.Type().String() != "Array" {
+				t.Errorf("expected type Array, got: %s", rv.Type().String())
+			}
+			if len(rv.Array()) != len(test.expected) {
+				t.Errorf("expected %d values, got %d", len(test.expected), len(rv.Array()))
+				return
+			}
+			for i, value := range rv.Array() {
 				if test.expected[i] == nil {
 					if !value.IsNull() {
 						t.Errorf("expected nil value, got %+v", value)
 					}
 					continue
 				}
-				if value.String() != test.expected[i] {
+				if value.IsNull() || value.String() != test.expected[i] {
 					t.Errorf("expected value %s, got: %s", test.expected[i], value.String())
 				}
 			}
@@ -794,7 +1256,20 @@ func Test_HandleDEL(t *testing.T) {
 			expectedErr: nil,
 		},
 		{
-			name:             "2. Return error when DEL is called with no keys",
+			name:    "2. Count a key that is repeated in the command once",
+			command: []string{"DEL", "DelKey6", "DelKey6", "DelKey7", "DelKey7"},
+			presetValues: map[string]KeyData{
+				"DelKey6": {Value: "value6", ExpireAt: time.Time{}},
+			},
+			expectedResponse: 1,
+			expectToExist: map[string]bool{
+				"DelKey6": false,
+				"DelKey7": false,
+			},
+			expectedErr: nil,
+		},
+		{
+			name:             "3. Return error when DEL is called with no keys",
 			command:          []string{"DEL"},
 			presetValues:     nil,
 			expectedResponse: 0,
@@ -1384,12 +1859,44 @@ func Test_HandleEXPIRE(t *testing.T) {
 			expectedError:    errors.New(constants.WrongArgsResponse),
 		},
 		{
-			name:             "16. Command too long",
+			name:             "16. Return error when NX is combined with GT",
 			command:          []string{"EXPIRE", "ExpireKey16", "10", "NX", "GT"},
 			presetValues:     nil,
 			expectedResponse: 0,
 			expectedValues:   nil,
-			expectedError:    errors.New(constants.WrongArgsResponse),
+			expectedError:    errors.New("NX and XX, GT or LT options at the same time are not compatible"),
+		},
+		{
+			name:             "17. Return error when GT is combined with LT",
+			command:          []string{"EXPIRE", "ExpireKey17", "10", "GT", "LT"},
+			presetValues:     nil,
+			expectedResponse: 0,
+			expectedValues:   nil,
+			expectedError:    errors.New("GT and LT options at the same time are not compatible"),
+		},
+		{
+			name:    "18. Set expiry time when XX is combined with GT and the new expiry time is greater",
+			command: []string{"EXPIRE", "ExpireKey18", "1000", "XX", "GT"},
+			presetValues: map[string]KeyData{
+				"ExpireKey18": {Value: "value18", ExpireAt: mockClock.Now().Add(30 * time.Second)},
+			},
+			expectedResponse: 1,
+			expectedValues: map[string]KeyData{
+				"ExpireKey18": {Value: "value18", ExpireAt: mockClock.Now().Add(1000 * time.Second)},
+			},
+			expectedError: nil,
+		},
+		{
+			name:    "19. Return 0 when XX is combined with LT and the key has no expiry time",
+			command: []string{"EXPIRE", "ExpireKey19", "1000", "XX", "LT"},
+			presetValues: map[string]KeyData{
+				"ExpireKey19": {Value: "value19", ExpireAt: time.Time{}},
+			},
+			expectedResponse: 0,
+			expectedValues: map[string]KeyData{
+				"ExpireKey19": {Value: "value19", ExpireAt: time.Time{}},
+			},
+			expectedError: nil,
 		},
 	}
 
@@ -1460,6 +1967,29 @@ func Test_HandleEXPIRE(t *testing.T) {
 			}
 		})
 	}
+
+	t.Run("20. Delete the key when the expiry time is in the past", func(t *testing.T) {
+		ctx := context.WithValue(context.Background(), "test_name", "EXPIRE, past")
+		key := "ExpireKey20"
+		if _, err := mockServer.CreateKeyAndLock(ctx, key); err != nil {
+			t.Fatal(err)
+		}
+		if err := mockServer.SetValue(ctx, key, "value20"); err != nil {
+			t.Fatal(err)
+		}
+		mockServer.KeyUnlock(ctx, key)
+
+		res, err := getHandler("EXPIRE")(getHandlerFuncParams(ctx, []string{"EXPIRE", key, "-10"}, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(res) != ":1\r\n" {
+			t.Errorf("expected response :1, got %q", res)
+		}
+		if mockServer.KeyExists(ctx, key) {
+			t.Errorf("expected key %s to be deleted", key)
+		}
+	})
 }
 
 func Test_HandleEXPIREAT(t *testing.T) {
@@ -1666,12 +2196,44 @@ func Test_HandleEXPIREAT(t *testing.T) {
 			expectedError:    errors.New(constants.WrongArgsResponse),
 		},
 		{
-			name:             "16. Command too long",
+			name:             "16. Return error when NX is combined with GT",
 			command:          []string{"EXPIREAT", "ExpireAtKey16", "10", "NX", "GT"},
 			presetValues:     nil,
 			expectedResponse: 0,
 			expectedValues:   nil,
-			expectedError:    errors.New(constants.WrongArgsResponse),
+			expectedError:    errors.New("NX and XX, GT or LT options at the same time are not compatible"),
+		},
+		{
+			name:             "17. Return error when GT is combined with LT",
+			command:          []string{"EXPIREAT", "ExpireAtKey17", "10", "GT", "LT"},
+			presetValues:     nil,
+			expectedResponse: 0,
+			expectedValues:   nil,
+			expectedError:    errors.New("GT and LT options at the same time are not compatible"),
+		},
+		{
+			name:    "18. Set expiry time when XX is combined with GT and the new expiry time is greater",
+			command: []string{"EXPIREAT", "ExpireAtKey18", fmt.Sprintf("%d", mockClock.Now().Add(1000*time.Second).Unix()), "XX", "GT"},
+			presetValues: map[string]KeyData{
+				"ExpireAtKey18": {Value: "value18", ExpireAt: mockClock.Now().Add(30 * time.Second)},
+			},
+			expectedResponse: 1,
+			expectedValues: map[string]KeyData{
+				"ExpireAtKey18": {Value: "value18", ExpireAt: time.Unix(mockClock.Now().Add(1000*time.Second).Unix(), 0)},
+			},
+			expectedError: nil,
+		},
+		{
+			name:    "19. Return 0 when XX is combined with LT and the key has no expiry time",
+			command: []string{"EXPIREAT", "ExpireAtKey19", fmt.Sprintf("%d", mockClock.Now().Add(1000*time.Second).Unix()), "XX", "LT"},
+			presetValues: map[string]KeyData{
+				"ExpireAtKey19": {Value: "value19", ExpireAt: time.Time{}},
+			},
+			expectedResponse: 0,
+			expectedValues: map[string]KeyData{
+				"ExpireAtKey19": {Value: "value19", ExpireAt: time.Time{}},
+			},
+			expectedError: nil,
 		},
 	}
 
@@ -1742,4 +2304,1302 @@ func Test_HandleEXPIREAT(t *testing.T) {
 			}
 		})
 	}
+
+	t.Run("20. Delete the key when the expiry time is in the past", func(t *testing.T) {
+		ctx := context.WithValue(context.Background(), "test_name", "EXPIREAT, past")
+		key := "ExpireAtKey20"
+		if _, err := mockServer.CreateKeyAndLock(ctx, key); err != nil {
+			t.Fatal(err)
+		}
+		if err := mockServer.SetValue(ctx, key, "value20"); err != nil {
+			t.Fatal(err)
+		}
+		mockServer.KeyUnlock(ctx, key)
+
+		res, err := getHandler("EXPIREAT")(getHandlerFuncParams(ctx, []string{"EXPIREAT", key, fmt.Sprintf("%d", mockClock.Now().Add(-10*time.Second).Unix())}, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(res) != ":1\r\n" {
+			t.Errorf("expected response :1, got %q", res)
+		}
+		if mockServer.KeyExists(ctx, key) {
+			t.Errorf("expected key %s to be deleted", key)
+		}
+	})
+}
+
+func Test_HandleOBJECTENCODING(t *testing.T) {
+	// Use small listpack limits so that the conversions are easy to trigger.
+	set.SetListpackLimits(3, 8)
+	sorted_set.SetListpackLimits(3, 8)
+	defer set.SetListpackLimits(128, 64)
+	defer sorted_set.SetListpackLimits(128, 64)
+
+	grownSet := set.NewSet([]string{"a", "b", "c"})
+	grownSet.Add([]string{"d"})
+	grownSortedSet := sorted_set.NewSortedSet([]sorted_set.MemberParam{{Value: "a", Score: 1}})
+	if _, err := grownSortedSet.AddOrUpdate(
+		[]sorted_set.MemberParam{{Value: "long-member", Score: 2}}, nil, nil, nil, nil,
+	); err != nil {
+		t.Error(err)
+	}
+
+	tests := []struct {
+		name             string
+		command          []string
+		presetValues     map[string]KeyData
+		expectedResponse string
+		expectedNil      bool
+		expectedError    error
+	}{
+		{
+			name:    "1. Return listpack for a small set",
+			command: []string{"OBJECT", "ENCODING", "ObjectEncodingKey1"},
+			presetValues: map[string]KeyData{
+				"ObjectEncodingKey1": {Value: set.NewSet([]string{"a", "b", "c"}), ExpireAt: time.Time{}},
+			},
+			expectedResponse: "listpack",
+		},
+		{
+			name:    "2. Return hashtable for a set that has grown past the entries limit",
+			command: []string{"OBJECT", "ENCODING", "ObjectEncodingKey2"},
+			presetValues: map[string]KeyData{
+				"ObjectEncodingKey2": {Value: grownSet, ExpireAt: time.Time{}},
+			},
+			expectedResponse: "hashtable",
+		},
+		{
+			name:    "3. Return hashtable for a set with a member longer than the value limit",
+			command: []string{"OBJECT", "ENCODING", "ObjectEncodingKey3"},
+			presetValues: map[string]KeyData{
+				"ObjectEncodingKey3": {Value: set.NewSet([]string{"long-member"}), ExpireAt: time.Time{}},
+			},
+			expectedResponse: "hashtable",
+		},
+		{
+			name:    "4. Return listpack for a small sorted set",
+			command: []string{"OBJECT", "ENCODING", "ObjectEncodingKey4"},
+			presetValues: map[string]KeyData{
+				"ObjectEncodingKey4": {
+					Value:    sorted_set.NewSortedSet([]sorted_set.MemberParam{{Value: "a", Score: 1}}),
+					ExpireAt: time.Time{},
+				},
+			},
+			expectedResponse: "listpack",
+		},
+		{
+			name:    "5. Return skiplist for a sorted set that has grown past the value limit",
+			command: []string{"OBJECT", "ENCODING", "ObjectEncodingKey5"},
+			presetValues: map[string]KeyData{
+				"ObjectEncodingKey5": {Value: grownSortedSet, ExpireAt: time.Time{}},
+			},
+			expectedResponse: "skiplist",
+		},
+		{
+			name:    "6. Return int for an integer value",
+			command: []string{"OBJECT", "ENCODING", "ObjectEncodingKey6"},
+			presetValues: map[string]KeyData{
+				"ObjectEncodingKey6": {Value: 10, ExpireAt: time.Time{}},
+			},
+			expectedResponse: "int",
+		},
+		{
+			name:          "7. Return nil when the key does not exist",
+			command:       []string{"OBJECT", "ENCODING", "ObjectEncodingKey7"},
+			presetValues:  nil,
+			expectedNil:   true,
+			expectedError: nil,
+		},
+		{
+			name:          "8. Command too short",
+			command:       []string{"OBJECT", "ENCODING"},
+			presetValues:  nil,
+			expectedError: errors.New(constants.WrongArgsResponse),
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), "test_name", fmt.Sprintf("OBJECT ENCODING, %d", i))
+
+			if test.presetValues != nil {
+				for k, v := range test.presetValues {
+					if _, err := mockServer.CreateKeyAndLock(ctx, k); err != nil {
+						t.Error(err)
+					}
+					if err := mockServer.SetValue(ctx, k, v.Value); err != nil {
+						t.Error(err)
+					}
+					mockServer.KeyUnlock(ctx, k)
+				}
+			}
+
+			handler := getHandler(test.command[0], test.command[1])
+			if handler == nil {
+				t.Errorf("no handler found for command %s %s", test.command[0], test.command[1])
+				return
+			}
+
+			res, err := handler(getHandlerFuncParams(ctx, test.command, nil))
+
+			if test.expectedError != nil {
+				if err == nil || test.expectedError.Error() != err.Error() {
+					t.Errorf("expected error \"%s\", got \"%v\"", test.expectedError.Error(), err)
+				}
+				return
+			}
+			if err != nil {
+				t.Error(err)
+			}
+
+			rd := resp.NewReader(bytes.NewReader(res))
+			rv, _, err := rd.ReadValue()
+			if err != nil {
+				t.Error(err)
+			}
+			if test.expectedNil {
+				if !rv.IsNull() {
+					t.Errorf("expected nil response, got %+v", rv)
+				}
+				return
+			}
+			if rv.String() != test.expectedResponse {
+				t.Errorf("expected response %s, got %s", test.expectedResponse, rv.String())
+			}
+		})
+	}
+
+	// The members are kept when a set is converted to the hashtable encoding.
+	for _, member := range []string{"a", "b", "c", "d"} {
+		if !grownSet.Contains(member) {
+			t.Errorf("expected converted set to contain %s", member)
+		}
+	}
+	if grownSet.Cardinality() != 4 {
+		t.Errorf("expected converted set cardinality 4, got %d", grownSet.Cardinality())
+	}
+	if grownSortedSet.Get("a").Score != 1 || grownSortedSet.Get("long-member").Score != 2 {
+		t.Errorf("expected converted sorted set to keep its scores, got %+v", grownSortedSet.GetAll())
+	}
+}
+
+func Test_HandleOBJECTREFCOUNT(t *testing.T) {
+	tests := []struct {
+		name             string
+		command          []string
+		presetValues     map[string]KeyData
+		expectedResponse int
+		expectedNil      bool
+		expectedError    error
+	}{
+		{
+			name:    "1. Return 1 for an existing key",
+			command: []string{"OBJECT", "REFCOUNT", "ObjectRefCountKey1"},
+			presetValues: map[string]KeyData{
+				"ObjectRefCountKey1": {Value: "value1", ExpireAt: time.Time{}},
+			},
+			expectedResponse: 1,
+			expectedError:    nil,
+		},
+		{
+			name:          "2. Return nil when the key does not exist",
+			command:       []string{"OBJECT", "REFCOUNT", "ObjectRefCountKey2"},
+			presetValues:  nil,
+			expectedNil:   true,
+			expectedError: nil,
+		},
+		{
+			name:          "3. Command too short",
+			command:       []string{"OBJECT", "REFCOUNT"},
+			presetValues:  nil,
+			expectedError: errors.New(constants.WrongArgsResponse),
+		},
+		{
+			name:          "4. Command too long",
+			command:       []string{"OBJECT", "REFCOUNT", "ObjectRefCountKey3", "ObjectRefCountKey4"},
+			presetValues:  nil,
+			expectedError: errors.New(constants.WrongArgsResponse),
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), "test_name", fmt.Sprintf("OBJECT REFCOUNT, %d", i))
+
+			if test.presetValues != nil {
+				for k, v := range test.presetValues {
+					if _, err := mockServer.CreateKeyAndLock(ctx, k); err != nil {
+						t.Error(err)
+					}
+					if err := mockServer.SetValue(ctx, k, v.Value); err != nil {
+						t.Error(err)
+					}
+					mockServer.KeyUnlock(ctx, k)
+				}
+			}
+
+			handler := getHandler(test.command[0], test.command[1])
+			if handler == nil {
+				t.Errorf("no handler found for command %s %s", test.command[0], test.command[1])
+				return
+			}
+
+			res, err := handler(getHandlerFuncParams(ctx, test.command, nil))
+
+			if test.expectedError != nil {
+				if err == nil || test.expectedError.Error() != err.Error() {
+					t.Errorf("expected error \"%s\", got \"%v\"", test.expectedError.Error(), err)
+				}
+				return
+			}
+			if err != nil {
+				t.Error(err)
+			}
+
+			rd := resp.NewReader(bytes.NewReader(res))
+			rv, _, err := rd.ReadValue()
+			if err != nil {
+				t.Error(err)
+			}
+			if test.expectedNil {
+				if !rv.IsNull() {
+					t.Errorf("expected nil response, got %+v", rv)
+				}
+				return
+			}
+			if rv.Integer() != test.expectedResponse {
+				t.Errorf("expected response %d, got %d", test.expectedResponse, rv.Integer())
+			}
+		})
+	}
+}
+
+func Test_HandleEXISTS(t *testing.T) {
+	tests := []struct {
+		name             string
+		command          []string
+		presetValues     map[string]KeyData
+		expectedResponse int
+		expectedError    error
+	}{
+		{
+			name:    "1. Count the keys that exist",
+			command: []string{"EXISTS", "ExistsKey1", "ExistsKey2", "ExistsKey3"},
+			presetValues: map[string]KeyData{
+				"ExistsKey1": {Value: "value1", ExpireAt: time.Time{}},
+				"ExistsKey2": {Value: "value2", ExpireAt: time.Time{}},
+			},
+			expectedResponse: 2,
+			expectedError:    nil,
+		},
+		{
+			name:    "2. Count a repeated key once for each occurrence",
+			command: []string{"EXISTS", "ExistsKey4", "ExistsKey4", "ExistsKey4"},
+			presetValues: map[string]KeyData{
+				"ExistsKey4": {Value: "value4", ExpireAt: time.Time{}},
+			},
+			expectedResponse: 3,
+			expectedError:    nil,
+		},
+		{
+			name:    "3. Do not count expired keys",
+			command: []string{"EXISTS", "ExistsKey5"},
+			presetValues: map[string]KeyData{
+				"ExistsKey5": {Value: "value5", ExpireAt: mockClock.Now().Add(-10 * time.Second)},
+			},
+			expectedResponse: 0,
+			expectedError:    nil,
+		},
+		{
+			name:          "4. Command too short",
+			command:       []string{"EXISTS"},
+			presetValues:  nil,
+			expectedError: errors.New(constants.WrongArgsResponse),
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), "test_name", fmt.Sprintf("EXISTS, %d", i))
+
+			if test.presetValues != nil {
+				for k, v := range test.presetValues {
+					if _, err := mockServer.CreateKeyAndLock(ctx, k); err != nil {
+						t.Error(err)
+					}
+					if err := mockServer.SetValue(ctx, k, v.Value); err != nil {
+						t.Error(err)
+					}
+					mockServer.SetExpiry(ctx, k, v.ExpireAt, false)
+					mockServer.KeyUnlock(ctx, k)
+				}
+			}
+
+			handler := getHandler(test.command[0])
+			if handler == nil {
+				t.Errorf("no handler found for command %s", test.command[0])
+				return
+			}
+
+			res, err := handler(getHandlerFuncParams(ctx, test.command, nil))
+			if test.expectedError != nil {
+				if err == nil || test.expectedError.Error() != err.Error() {
+					t.Errorf("expected error \"%s\", got \"%v\"", test.expectedError.Error(), err)
+				}
+				return
+			}
+			if err != nil {
+				t.Error(err)
+			}
+
+			rd := resp.NewReader(bytes.NewReader(res))
+			rv, _, err := rd.ReadValue()
+			if err != nil {
+				t.Error(err)
+			}
+			if rv.Integer() != test.expectedResponse {
+				t.Errorf("expected response %d, got %d", test.expectedResponse, rv.Integer())
+			}
+		})
+	}
+}
+
+func Test_HandleTYPE(t *testing.T) {
+	tests := []struct {
+		name             string
+		command          []string
+		presetValues     map[string]KeyData
+		expectedResponse string
+		expectedError    error
+	}{
+		{
+			name:    "1. Return string for strings, integers and floats",
+			command: []string{"TYPE", "TypeKey1"},
+			presetValues: map[string]KeyData{
+				"TypeKey1": {Value: 10, ExpireAt: time.Time{}},
+			},
+			expectedResponse: "string",
+			expectedError:    nil,
+		},
+		{
+			name:    "2. Return list for lists",
+			command: []string{"TYPE", "TypeKey2"},
+			presetValues: map[string]KeyData{
+				"TypeKey2": {Value: []interface{}{"value1", "value2"}, ExpireAt: time.Time{}},
+			},
+			expectedResponse: "list",
+			expectedError:    nil,
+		},
+		{
+			name:    "3. Return hash for hashes",
+			command: []string{"TYPE", "TypeKey3"},
+			presetValues: map[string]KeyData{
+				"TypeKey3": {Value: map[string]interface{}{"field1": "value1"}, ExpireAt: time.Time{}},
+			},
+			expectedResponse: "hash",
+			expectedError:    nil,
+		},
+		{
+			name:    "4. Return set for sets",
+			command: []string{"TYPE", "TypeKey4"},
+			presetValues: map[string]KeyData{
+				"TypeKey4": {Value: set.NewSet([]string{"member1"}), ExpireAt: time.Time{}},
+			},
+			expectedResponse: "set",
+			expectedError:    nil,
+		},
+		{
+			name:    "5. Return zset for sorted sets",
+			command: []string{"TYPE", "TypeKey5"},
+			presetValues: map[string]KeyData{
+				"TypeKey5": {Value: sorted_set.NewSortedSet([]sorted_set.MemberParam{{Value: "member1", Score: 1}}), ExpireAt: time.Time{}},
+			},
+			expectedResponse: "zset",
+			expectedError:    nil,
+		},
+		{
+			name:             "6. Return none when the key does not exist",
+			command:          []string{"TYPE", "TypeKey6"},
+			presetValues:     nil,
+			expectedResponse: "none",
+			expectedError:    nil,
+		},
+		{
+			name:          "7. Command too short",
+			command:       []string{"TYPE"},
+			presetValues:  nil,
+			expectedError: errors.New(constants.WrongArgsResponse),
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), "test_name", fmt.Sprintf("TYPE, %d", i))
+
+			if test.presetValues != nil {
+				for k, v := range test.presetValues {
+					if _, err := mockServer.CreateKeyAndLock(ctx, k); err != nil {
+						t.Error(err)
+					}
+					if err := mockServer.SetValue(ctx, k, v.Value); err != nil {
+						t.Error(err)
+					}
+					mockServer.KeyUnlock(ctx, k)
+				}
+			}
+
+			handler := getHandler(test.command[0])
+			if handler == nil {
+				t.Errorf("no handler found for command %s", test.command[0])
+				return
+			}
+
+			res, err := handler(getHandlerFuncParams(ctx, test.command, nil))
+			if test.expectedError != nil {
+				if err == nil || test.expectedError.Error() != err.Error() {
+					t.Errorf("expected error \"%s\", got \"%v\"", test.expectedError.Error(), err)
+				}
+				return
+			}
+			if err != nil {
+				t.Error(err)
+			}
+
+			rd := resp.NewReader(bytes.NewReader(res))
+			rv, _, err := rd.ReadValue()
+			if err != nil {
+				t.Error(err)
+			}
+			if rv.String() != test.expectedResponse {
+				t.Errorf("expected response \"%s\", got \"%s\"", test.expectedResponse, rv.String())
+			}
+		})
+	}
+}
+
+func Test_HandleRENAME(t *testing.T) {
+	tests := []struct {
+		name             string
+		command          []string
+		presetValues     map[string]KeyData
+		expectedResponse string
+		expectedValues   map[string]KeyData
+		expectToExist    map[string]bool
+		expectedError    error
+	}{
+		{
+			name:    "1. Rename the key and keep its expiry time",
+			command: []string{"RENAME", "RenameKey1", "RenameKey2"},
+			presetValues: map[string]KeyData{
+				"RenameKey1": {Value: "value1", ExpireAt: mockClock.Now().Add(100 * time.Second)},
+			},
+			expectedResponse: "OK",
+			expectedValues: map[string]KeyData{
+				"RenameKey2": {Value: "value1", ExpireAt: mockClock.Now().Add(100 * time.Second)},
+			},
+			expectToExist: map[string]bool{"RenameKey1": false},
+			expectedError: nil,
+		},
+		{
+			name:    "2. Overwrite the destination and its expiry time",
+			command: []string{"RENAME", "RenameKey3", "RenameKey4"},
+			presetValues: map[string]KeyData{
+				"RenameKey3": {Value: []interface{}{"value1", "value2"}, ExpireAt: time.Time{}},
+				"RenameKey4": {Value: "value4", ExpireAt: mockClock.Now().Add(100 * time.Second)},
+			},
+			expectedResponse: "OK",
+			expectedValues: map[string]KeyData{
+				"RenameKey4": {Value: []interface{}{"value1", "value2"}, ExpireAt: time.Time{}},
+			},
+			expectToExist: map[string]bool{"RenameKey3": false},
+			expectedError: nil,
+		},
+		{
+			name:    "3. RENAMENX renames the key when the destination does not exist",
+			command: []string{"RENAMENX", "RenameKey5", "RenameKey6"},
+			presetValues: map[string]KeyData{
+				"RenameKey5": {Value: "value5", ExpireAt: time.Time{}},
+			},
+			expectedResponse: "1",
+			expectedValues: map[string]KeyData{
+				"RenameKey6": {Value: "value5", ExpireAt: time.Time{}},
+			},
+			expectToExist: map[string]bool{"RenameKey5": false},
+			expectedError: nil,
+		},
+		{
+			name:    "4. RENAMENX does not overwrite the destination",
+			command: []string{"RENAMENX", "RenameKey7", "RenameKey8"},
+			presetValues: map[string]KeyData{
+				"RenameKey7": {Value: "value7", ExpireAt: time.Time{}},
+				"RenameKey8": {Value: "value8", ExpireAt: time.Time{}},
+			},
+			expectedResponse: "0",
+			expectedValues: map[string]KeyData{
+				"RenameKey7": {Value: "value7", ExpireAt: time.Time{}},
+				"RenameKey8": {Value: "value8", ExpireAt: time.Time{}},
+			},
+			expectedError: nil,
+		},
+		{
+			name:    "5. Renaming a key to itself keeps the key",
+			command: []string{"RENAME", "RenameKey9", "RenameKey9"},
+			presetValues: map[string]KeyData{
+				"RenameKey9": {Value: "value9", ExpireAt: time.Time{}},
+			},
+			expectedResponse: "OK",
+			expectedValues: map[string]KeyData{
+				"RenameKey9": {Value: "value9", ExpireAt: time.Time{}},
+			},
+			expectedError: nil,
+		},
+		{
+			name:          "6. Return error when the key does not exist",
+			command:       []string{"RENAME", "RenameKey10", "RenameKey11"},
+			presetValues:  nil,
+			expectToExist: map[string]bool{"RenameKey11": false},
+			expectedError: errors.New("no such key"),
+		},
+		{
+			name:          "7. Command too short",
+			command:       []string{"RENAME", "RenameKey12"},
+			presetValues:  nil,
+			expectedError: errors.New(constants.WrongArgsResponse),
+		},
+		{
+			name:    "8. Rename the key to a destination that sorts before it",
+			command: []string{"RENAME", "RenameKey14", "RenameKey13"},
+			presetValues: map[string]KeyData{
+				"RenameKey13": {Value: "value13", ExpireAt: time.Time{}},
+				"RenameKey14": {Value: "value14", ExpireAt: mockClock.Now().Add(100 * time.Second)},
+			},
+			expectedResponse: "OK",
+			expectedValues: map[string]KeyData{
+				"RenameKey13": {Value: "value14", ExpireAt: mockClock.Now().Add(100 * time.Second)},
+			},
+			expectToExist: map[string]bool{"RenameKey14": false},
+			expectedError: nil,
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), "test_name", fmt.Sprintf("RENAME, %d", i))
+
+			if test.presetValues != nil {
+				for k, v := range test.presetValues {
+					if _, err := mockServer.CreateKeyAndLock(ctx, k); err != nil {
+						t.Error(err)
+					}
+					if err := mockServer.SetValue(ctx, k, v.Value); err != nil {
+						t.Error(err)
+					}
+					mockServer.SetExpiry(ctx, k, v.ExpireAt, false)
+					mockServer.KeyUnlock(ctx, k)
+				}
+			}
+
+			handler := getHandler(test.command[0])
+			if handler == nil {
+				t.Errorf("no handler found for command %s", test.command[0])
+				return
+			}
+
+			res, err := handler(getHandlerFuncParams(ctx, test.command, nil))
+			if test.expectedError != nil {
+				if err == nil || test.expectedError.Error() != err.Error() {
+					t.Errorf("expected error \"%s\", got \"%v\"", test.expectedError.Error(), err)
+				}
+			} else {
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				rd := resp.NewReader(bytes.NewReader(res))
+				rv, _, err := rd.ReadValue()
+				if err != nil {
+					t.Error(err)
+				}
+				if rv.String() != test.expectedResponse {
+					t.Errorf("expected response \"%s\", got \"%s\"", test.expectedResponse, rv.String())
+				}
+			}
+
+			for k, expected := range test.expectedValues {
+				if _, err = mockServer.KeyRLock(ctx, k); err != nil {
+					t.Error(err)
+					continue
+				}
+				if value := mockServer.GetValue(ctx, k); !reflect.DeepEqual(value, expected.Value) {
+					t.Errorf("expected value at key %s to be %+v, got %+v", k, expected.Value, value)
+				}
+				if expireAt := mockServer.GetExpiry(ctx, k); expireAt != expected.ExpireAt {
+					t.Errorf("expected expiry time at key %s to be %v, got %v", k, expected.ExpireAt, expireAt)
+				}
+				mockServer.KeyRUnlock(ctx, k)
+			}
+
+			for k, expected := range test.expectToExist {
+				if exists := mockServer.KeyExists(ctx, k); exists != expected {
+					t.Errorf("expected exists status of key %s to be %+v, got %+v", k, expected, exists)
+				}
+			}
+		})
+	}
+}
+
+func Test_HandleCOPY(t *testing.T) {
+	tests := []struct {
+		name             string
+		command          []string
+		presetValues     map[string]KeyData
+		expectedResponse int
+		expectedValues   map[string]KeyData
+		expectedError    error
+	}{
+		{
+			name:    "1. Copy the value and expiry time to a new key",
+			command: []string{"COPY", "CopyKey1", "CopyKey2"},
+			presetValues: map[string]KeyData{
+				"CopyKey1": {Value: map[string]interface{}{"field1": "value1"}, ExpireAt: mockClock.Now().Add(100 * time.Second)},
+			},
+			expectedResponse: 1,
+			expectedValues: map[string]KeyData{
+				"CopyKey1": {Value: map[string]interface{}{"field1": "value1"}, ExpireAt: mockClock.Now().Add(100 * time.Second)},
+				"CopyKey2": {Value: map[string]interface{}{"field1": "value1"}, ExpireAt: mockClock.Now().Add(100 * time.Second)},
+			},
+			expectedError: nil,
+		},
+		{
+			name:    "2. Do not overwrite the destination without REPLACE",
+			command: []string{"COPY", "CopyKey3", "CopyKey4"},
+			presetValues: map[string]KeyData{
+				"CopyKey3": {Value: "value3", ExpireAt: time.Time{}},
+				"CopyKey4": {Value: "value4", ExpireAt: time.Time{}},
+			},
+			expectedResponse: 0,
+			expectedValues: map[string]KeyData{
+				"CopyKey4": {Value: "value4", ExpireAt: time.Time{}},
+			},
+			expectedError: nil,
+		},
+		{
+			name:    "3. Overwrite the destination with REPLACE",
+			command: []string{"COPY", "CopyKey5", "CopyKey6", "REPLACE"},
+			presetValues: map[string]KeyData{
+				"CopyKey5": {Value: "value5", ExpireAt: time.Time{}},
+				"CopyKey6": {Value: "value6", ExpireAt: mockClock.Now().Add(100 * time.Second)},
+			},
+			expectedResponse: 1,
+			expectedValues: map[string]KeyData{
+				"CopyKey6": {Value: "value5", ExpireAt: time.Time{}},
+			},
+			expectedError: nil,
+		},
+		{
+			name:             "4. Return 0 when the source does not exist",
+			command:          []string{"COPY", "CopyKey7", "CopyKey8"},
+			presetValues:     nil,
+			expectedResponse: 0,
+			expectedError:    nil,
+		},
+		{
+			name:    "5. Return error when the source and destination are the same",
+			command: []string{"COPY", "CopyKey9", "CopyKey9"},
+			presetValues: map[string]KeyData{
+				"CopyKey9": {Value: "value9", ExpireAt: time.Time{}},
+			},
+			expectedError: errors.New("source and destination objects are the same"),
+		},
+		{
+			name:          "6. Return error when the option is unknown",
+			command:       []string{"COPY", "CopyKey10", "CopyKey11", "DB"},
+			presetValues:  nil,
+			expectedError: errors.New("unknown option DB"),
+		},
+		{
+			name:          "7. Command too short",
+			command:       []string{"COPY", "CopyKey12"},
+			presetValues:  nil,
+			expectedError: errors.New(constants.WrongArgsResponse),
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), "test_name", fmt.Sprintf("COPY, %d", i))
+
+			if test.presetValues != nil {
+				for k, v := range test.presetValues {
+					if _, err := mockServer.CreateKeyAndLock(ctx, k); err != nil {
+						t.Error(err)
+					}
+					if err := mockServer.SetValue(ctx, k, v.Value); err != nil {
+						t.Error(err)
+					}
+					mockServer.SetExpiry(ctx, k, v.ExpireAt, false)
+					mockServer.KeyUnlock(ctx, k)
+				}
+			}
+
+			handler := getHandler(test.command[0])
+			if handler == nil {
+				t.Errorf("no handler found for command %s", test.command[0])
+				return
+			}
+
+			res, err := handler(getHandlerFuncParams(ctx, test.command, nil))
+			if test.expectedError != nil {
+				if err == nil || test.expectedError.Error() != err.Error() {
+					t.Errorf("expected error \"%s\", got \"%v\"", test.expectedError.Error(), err)
+				}
+				return
+			}
+			if err != nil {
+				t.Error(err)
+			}
+
+			rd := resp.NewReader(bytes.NewReader(res))
+			rv, _, err := rd.ReadValue()
+			if err != nil {
+				t.Error(err)
+			}
+			if rv.Integer() != test.expectedResponse {
+				t.Errorf("expected response %d, got %d", test.expectedResponse, rv.Integer())
+			}
+
+			for k, expected := range test.expectedValues {
+				if _, err = mockServer.KeyRLock(ctx, k); err != nil {
+					t.Error(err)
+					continue
+				}
+				if value := mockServer.GetValue(ctx, k); !reflect.DeepEqual(value, expected.Value) {
+					t.Errorf("expected value at key %s to be %+v, got %+v", k, expected.Value, value)
+				}
+				if expireAt := mockServer.GetExpiry(ctx, k); expireAt != expected.ExpireAt {
+					t.Errorf("expected expiry time at key %s to be %v, got %v", k, expected.ExpireAt, expireAt)
+				}
+				mockServer.KeyRUnlock(ctx, k)
+			}
+
+			// The copy must not share mutable data with the source.
+			if test.command[1] == "CopyKey1" {
+				if _, err = mockServer.KeyLock(ctx, "CopyKey2"); err != nil {
+					t.Fatal(err)
+				}
+				mockServer.GetValue(ctx, "CopyKey2").(map[string]interface{})["field2"] = "value2"
+				mockServer.KeyUnlock(ctx, "CopyKey2")
+				if _, err = mockServer.KeyRLock(ctx, "CopyKey1"); err != nil {
+					t.Fatal(err)
+				}
+				defer mockServer.KeyRUnlock(ctx, "CopyKey1")
+				if _, ok := mockServer.GetValue(ctx, "CopyKey1").(map[string]interface{})["field2"]; ok {
+					t.Error("expected the copy not to share the hash with the source")
+				}
+			}
+		})
+	}
+}
+
+func Test_HandleSORT(t *testing.T) {
+	tests := []struct {
+		name             string
+		command          []string
+		presetValues     map[string]interface{}
+		expectedResponse []interface{} // A nil element is a nil reply.
+		expectedCount    int           // The reply of SORT with STORE.
+		expectedValues   map[string]interface{}
+		expectedError    error
+	}{
+		{
+			name:             "1. Sort a list numerically",
+			command:          []string{"SORT", "SortKey1"},
+			presetValues:     map[string]interface{}{"SortKey1": []interface{}{10, 2.5, "-1", 3}},
+			expectedResponse: []interface{}{"-1", "2.5", "3", "10"},
+		},
+		{
+			name:    "2. Sort a set lexicographically in descending order",
+			command: []string{"SORT", "SortKey2", "ALPHA", "DESC"},
+			presetValues: map[string]interface{}{
+				"SortKey2": set.NewSet([]string{"banana", "apple", "cherry"}),
+			},
+			expectedResponse: []interface{}{"cherry", "banana", "apple"},
+		},
+		{
+			name:    "3. Sort by the weight keys and get the object keys",
+			command: []string{"SORT", "SortKey3", "BY", "SortWeight3_*", "GET", "SortObject3_*", "GET", "#"},
+			presetValues: map[string]interface{}{
+				"SortKey3":      []interface{}{"a", "b", "c"},
+				"SortWeight3_a": 3,
+				"SortWeight3_b": 1,
+				"SortObject3_a": "A",
+				"SortObject3_b": "B",
+			},
+			// c has no weight so its score is 0, and it has no object.
+			expectedResponse: []interface{}{nil, "c", "B", "b", "A", "a"},
+		},
+		{
+			name:    "4. Sort by the field of the weight hashes",
+			command: []string{"SORT", "SortKey4", "BY", "SortWeight4_*->weight", "DESC"},
+			presetValues: map[string]interface{}{
+				"SortKey4":      []interface{}{"a", "b", "c"},
+				"SortWeight4_a": map[string]interface{}{"weight": 2},
+				"SortWeight4_b": map[string]interface{}{"weight": 1.5},
+				"SortWeight4_c": map[string]interface{}{"weight": "7"},
+			},
+			expectedResponse: []interface{}{"c", "a", "b"},
+		},
+		{
+			name:    "5. Skip sorting a sorted set and limit the result",
+			command: []string{"SORT", "SortKey5", "BY", "nosort", "LIMIT", "1", "2"},
+			presetValues: map[string]interface{}{
+				"SortKey5": sorted_set.NewSortedSet([]sorted_set.MemberParam{
+					{Value: "a", Score: 3}, {Value: "b", Score: 1}, {Value: "c", Score: 2}, {Value: "d", Score: 4},
+				}),
+			},
+			expectedResponse: []interface{}{"c", "a"},
+		},
+		{
+			name:    "6. Skip sorting a sorted set in descending order",
+			command: []string{"SORT_RO", "SortKey6", "BY", "nosort", "DESC", "LIMIT", "0", "-1"},
+			presetValues: map[string]interface{}{
+				"SortKey6": sorted_set.NewSortedSet([]sorted_set.MemberParam{
+					{Value: "a", Score: 3}, {Value: "b", Score: 1}, {Value: "c", Score: 2},
+				}),
+			},
+			expectedResponse: []interface{}{"a", "c", "b"},
+		},
+		{
+			name:    "7. Store the sorted list at the destination",
+			command: []string{"SORT", "SortKey7", "STORE", "SortKey8"},
+			presetValues: map[string]interface{}{
+				"SortKey7": []interface{}{3, 1, 2},
+				"SortKey8": "value",
+			},
+			expectedCount:  3,
+			expectedValues: map[string]interface{}{"SortKey8": []interface{}{1, 2, 3}},
+		},
+		{
+			name:           "8. Delete the destination when the result is empty",
+			command:        []string{"SORT", "SortKey9", "STORE", "SortKey10"},
+			presetValues:   map[string]interface{}{"SortKey10": []interface{}{"value"}},
+			expectedCount:  0,
+			expectedValues: map[string]interface{}{"SortKey10": nil},
+		},
+		{
+			name:          "9. Return error when the elements are not numbers",
+			command:       []string{"SORT", "SortKey11"},
+			presetValues:  map[string]interface{}{"SortKey11": []interface{}{"a", 1}},
+			expectedError: errors.New("one or more scores can't be converted into double"),
+		},
+		{
+			name:          "10. Return error when the value is not a list, set or sorted set",
+			command:       []string{"SORT", "SortKey12"},
+			presetValues:  map[string]interface{}{"SortKey12": map[string]interface{}{"field": "value"}},
+			expectedError: errors.New("value at SortKey12 is not a list, set or sorted set"),
+		},
+		{
+			name:          "11. Return error when SORT_RO has STORE",
+			command:       []string{"SORT_RO", "SortKey13", "STORE", "SortKey14"},
+			expectedError: errors.New("syntax error"),
+		},
+		{
+			name:          "12. Return error when the limit is not an integer",
+			command:       []string{"SORT", "SortKey15", "LIMIT", "0", "all"},
+			expectedError: errors.New("limit offset and count must be integers"),
+		},
+		{
+			name:          "13. Command too short",
+			command:       []string{"SORT"},
+			expectedError: errors.New(constants.WrongArgsResponse),
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), "test_name", fmt.Sprintf("SORT, %d", i))
+
+			for k, v := range test.presetValues {
+				if _, err := mockServer.CreateKeyAndLock(ctx, k); err != nil {
+					t.Error(err)
+				}
+				if err := mockServer.SetValue(ctx, k, v); err != nil {
+					t.Error(err)
+				}
+				mockServer.KeyUnlock(ctx, k)
+			}
+
+			handler := getHandler(test.command[0])
+			if handler == nil {
+				t.Errorf("no handler found for command %s", test.command[0])
+				return
+			}
+
+			res, err := handler(getHandlerFuncParams(ctx, test.command, nil))
+			if test.expectedError != nil {
+				if err == nil || test.expectedError.Error() != err.Error() {
+					t.Errorf("expected error \"%s\", got \"%v\"", test.expectedError.Error(), err)
+				}
+				return
+			}
+			if err != nil {
+				t.Error(err)
+			}
+
+			rd := resp.NewReader(bytes.NewReader(res))
+			rv, _, err := rd.ReadValue()
+			if err != nil {
+				t.Error(err)
+			}
+
+			if test.expectedValues != nil {
+				if rv.Integer() != test.expectedCount {
+					t.Errorf("expected response %d, got %d", test.expectedCount, rv.Integer())
+				}
+				for k, expected := range test.expectedValues {
+					if expected == nil {
+						if mockServer.KeyExists(ctx, k) {
+							t.Errorf("expected key %s to be deleted", k)
+						}
+						continue
+					}
+					if _, err = mockServer.KeyRLock(ctx, k); err != nil {
+						t.Error(err)
+						continue
+					}
+					if value := mockServer.GetValue(ctx, k); !reflect.DeepEqual(value, expected) {
+						t.Errorf("expected value at key %s to be %+v, got %+v", k, expected, value)
+					}
+					mockServer.KeyRUnlock(ctx, k)
+				}
+				return
+			}
+
+			if len(rv.Array()) != len(test.expectedResponse) {
+				t.Errorf("expected response of length %d, got %d", len(test.expectedResponse), len(rv.Array()))
+				return
+			}
+			for j, expected := range test.expectedResponse {
+				element := rv.Array()[j]
+				if expected == nil {
+					if !element.IsNull() {
+						t.Errorf("expected element %d to be nil, got \"%s\"", j, element.String())
+					}
+					continue
+				}
+				if element.IsNull() || element.String() != expected {
+					t.Errorf("expected element %d to be \"%s\", got \"%s\"", j, expected, element.String())
+				}
+			}
+		})
+	}
+}
+
+func Test_HandleKEYS(t *testing.T) {
+	ctx := context.WithValue(context.Background(), "test_name", "KEYS")
+
+	presetValues := map[string]KeyData{
+		"KeysKey1":   {Value: "value1", ExpireAt: time.Time{}},
+		"KeysKey2":   {Value: "value2", ExpireAt: time.Time{}},
+		"KeysKey10":  {Value: "value10", ExpireAt: time.Time{}},
+		"KeysOther1": {Value: "value1", ExpireAt: time.Time{}},
+		"KeysKey3":   {Value: "value3", ExpireAt: mockClock.Now().Add(-10 * time.Second)},
+	}
+	for k, v := range presetValues {
+		if _, err := mockServer.CreateKeyAndLock(ctx, k); err != nil {
+			t.Fatal(err)
+		}
+		if err := mockServer.SetValue(ctx, k, v.Value); err != nil {
+			t.Fatal(err)
+		}
+		mockServer.SetExpiry(ctx, k, v.ExpireAt, false)
+		mockServer.KeyUnlock(ctx, k)
+	}
+
+	tests := []struct {
+		name          string
+		command       []string
+		expectedKeys  []string
+		expectedError error
+	}{
+		{
+			name:         "1. Return the keys that match the pattern and skip the expired keys",
+			command:      []string{"KEYS", "KeysKey*"},
+			expectedKeys: []string{"KeysKey1", "KeysKey10", "KeysKey2"},
+		},
+		{
+			name:         "2. Match a single character",
+			command:      []string{"KEYS", "KeysKey?"},
+			expectedKeys: []string{"KeysKey1", "KeysKey2"},
+		},
+		{
+			name:         "3. Return an empty array when no keys match",
+			command:      []string{"KEYS", "KeysNone*"},
+			expectedKeys: []string{},
+		},
+		{
+			name:          "4. Command too short",
+			command:       []string{"KEYS"},
+			expectedError: errors.New(constants.WrongArgsResponse),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res, err := getHandler("KEYS")(getHandlerFuncParams(ctx, test.command, nil))
+			if test.expectedError != nil {
+				if err == nil || test.expectedError.Error() != err.Error() {
+					t.Errorf("expected error \"%s\", got \"%v\"", test.expectedError.Error(), err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rv, _, err := resp.NewReader(bytes.NewReader(res)).ReadValue()
+			if err != nil {
+				t.Fatal(err)
+			}
+			keys := make([]string, len(rv.Array()))
+			for i, v := range rv.Array() {
+				keys[i] = v.String()
+			}
+			slices.Sort(keys)
+			if !slices.Equal(keys, test.expectedKeys) {
+				t.Errorf("expected keys %v, got %v", test.expectedKeys, keys)
+			}
+		})
+	}
+}
+
+func Test_HandleSCAN(t *testing.T) {
+	ctx := context.WithValue(context.Background(), "test_name", "SCAN")
+
+	var expectedStrings []string
+	for i := 0; i < 50; i++ {
+		key := fmt.Sprintf("ScanKey%d", i)
+		var value interface{} = fmt.Sprintf("value%d", i)
+		if i%5 == 0 {
+			// Every fifth key holds a list.
+			value = []interface{}{"value1"}
+		} else {
+			expectedStrings = append(expectedStrings, key)
+		}
+		if _, err := mockServer.CreateKeyAndLock(ctx, key); err != nil {
+			t.Fatal(err)
+		}
+		if err := mockServer.SetValue(ctx, key, value); err != nil {
+			t.Fatal(err)
+		}
+		mockServer.KeyUnlock(ctx, key)
+	}
+	slices.Sort(expectedStrings)
+
+	// scan iterates the keyspace with the options until the returned cursor is 0.
+	scan := func(options ...string) ([]string, error) {
+		var keys []string
+		cursor := "0"
+		for i := 0; ; i++ {
+			if i > 1000 {
+				return nil, errors.New("scan did not complete")
+			}
+			res, err := getHandler("SCAN")(getHandlerFuncParams(ctx, append([]string{"SCAN", cursor}, options...), nil))
+			if err != nil {
+				return nil, err
+			}
+			rv, _, err := resp.NewReader(bytes.NewReader(res)).ReadValue()
+			if err != nil {
+				return nil, err
+			}
+			for _, v := range rv.Array()[1].Array() {
+				keys = append(keys, v.String())
+			}
+			cursor = rv.Array()[0].String()
+			if cursor == "0" {
+				break
+			}
+		}
+		slices.Sort(keys)
+		return keys, nil
+	}
+
+	t.Run("1. Iterate all the matching keys exactly once", func(t *testing.T) {
+		keys, err := scan("MATCH", "ScanKey*", "COUNT", "7")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(keys) != 50 || len(slices.Compact(slices.Clone(keys))) != 50 {
+			t.Errorf("expected 50 distinct keys, got %d keys: %v", len(keys), keys)
+		}
+	})
+
+	t.Run("2. Only return the keys of the type", func(t *testing.T) {
+		keys, err := scan("MATCH", "ScanKey*", "TYPE", "string", "COUNT", "100")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(keys, expectedStrings) {
+			t.Errorf("expected keys %v, got %v", expectedStrings, keys)
+		}
+	})
+
+	t.Run("3. Return every key visited in a single call when the count is large enough", func(t *testing.T) {
+		res, err := getHandler("SCAN")(getHandlerFuncParams(ctx, []string{"SCAN", "0", "COUNT", "100000", "MATCH", "ScanKey1?"}, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		rv, _, err := resp.NewReader(bytes.NewReader(res)).ReadValue()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rv.Array()[0].String() != "0" {
+			t.Errorf("expected cursor 0, got %s", rv.Array()[0].String())
+		}
+		if len(rv.Array()[1].Array()) != 10 {
+			t.Errorf("expected 10 keys, got %d", len(rv.Array()[1].Array()))
+		}
+	})
+
+	errorTests := []struct {
+		name          string
+		command       []string
+		expectedError error
+	}{
+		{
+			name:          "4. Return error when the cursor is not an unsigned integer",
+			command:       []string{"SCAN", "-1"},
+			expectedError: errors.New("invalid cursor"),
+		},
+		{
+			name:          "5. Return error when the count is not a positive integer",
+			command:       []string{"SCAN", "0", "COUNT", "0"},
+			expectedError: errors.New("count must be a positive integer"),
+		},
+		{
+			name:          "6. Return error when an option is missing its value",
+			command:       []string{"SCAN", "0", "MATCH"},
+			expectedError: errors.New("syntax error"),
+		},
+		{
+			name:          "7. Command too short",
+			command:       []string{"SCAN"},
+			expectedError: errors.New(constants.WrongArgsResponse),
+		},
+	}
+	for _, test := range errorTests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := getHandler("SCAN")(getHandlerFuncParams(ctx, test.command, nil))
+			if err == nil || test.expectedError.Error() != err.Error() {
+				t.Errorf("expected error \"%s\", got \"%v\"", test.expectedError.Error(), err)
+			}
+		})
+	}
+}
+
+func Test_KeyLocks(t *testing.T) {
+	t.Run("1. Goroutines waiting on a deleted key find that it no longer exists", func(t *testing.T) {
+		ctx := context.WithValue(context.Background(), "test_name", "KEY LOCKS, 1")
+		key := "KeyLocksKey1"
+		if _, err := mockServer.CreateKeyAndLock(ctx, key); err != nil {
+			t.Fatal(err)
+		}
+
+		errs := make(chan error)
+		for i := 0; i < 3; i++ {
+			go func() {
+				_, err := mockServer.KeyLock(ctx, key)
+				errs <- err
+			}()
+		}
+		// Give the goroutines time to start waiting on the lock before it's deleted.
+		<-time.After(20 * time.Millisecond)
+		mockServer.DeleteLockedKey(ctx, key)
+
+		for i := 0; i < 3; i++ {
+			if err := <-errs; err == nil || err.Error() != fmt.Sprintf("key %s not found", key) {
+				t.Errorf("expected error \"key %s not found\", got %v", key, err)
+			}
+		}
+	})
+
+	t.Run("2. Deleting a key releases its lock so that the key can be created again", func(t *testing.T) {
+		ctx := context.WithValue(context.Background(), "test_name", "KEY LOCKS, 2")
+		key := "KeyLocksKey2"
+		if _, err := mockServer.CreateKeyAndLock(ctx, key); err != nil {
+			t.Fatal(err)
+		}
+		if err := mockServer.SetValue(ctx, key, "value"); err != nil {
+			t.Fatal(err)
+		}
+		mockServer.KeyUnlock(ctx, key)
+
+		if _, err := getHandler("DEL")(getHandlerFuncParams(ctx, []string{"DEL", key}, nil)); err != nil {
+			t.Fatal(err)
+		}
+		keyLocks := getUnexportedField(reflect.ValueOf(mockServer).Elem().FieldByName("keyLocks")).(map[string]*sync.RWMutex)
+		if _, ok := keyLocks[key]; ok {
+			t.Errorf("expected the lock of key %s to be removed", key)
+		}
+
+		timeout, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		if _, err := mockServer.CreateKeyAndLock(timeout, key); err != nil {
+			t.Fatalf("expected key %s to be created and locked, got %v", key, err)
+		}
+		mockServer.DeleteLockedKey(ctx, key)
+	})
+
+	t.Run("3. Maintenance removes the locks of keys that no longer exist unless they're held", func(t *testing.T) {
+		manualClock := clock.NewManualClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+		server, err := echovault.NewEchoVault(
+			echovault.WithConfig(config.Config{
+				DataDir:        "",
+				EvictionPolicy: constants.NoEviction,
+				DefragInterval: time.Second,
+			}),
+			echovault.WithClock(manualClock),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Leave behind the locks of keys that are not in the store.
+		keyLocks := getUnexportedField(reflect.ValueOf(server).Elem().FieldByName("keyLocks")).(map[string]*sync.RWMutex)
+		keyCreationLock := getUnexportedField(reflect.ValueOf(server).Elem().FieldByName("keyCreationLock")).(*sync.Mutex)
+		held := &sync.RWMutex{}
+		held.Lock()
+		keyCreationLock.Lock()
+		keyLocks["KeyLocksOrphan"] = &sync.RWMutex{}
+		keyLocks["KeyLocksHeld"] = held
+		keyCreationLock.Unlock()
+		if _, err = server.Set("KeyLocksKey3", "value", echovault.SetOptions{}); err != nil {
+			t.Fatal(err)
+		}
+
+		// Advance the clock until the maintenance goroutine has removed the lock that isn't held.
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			manualClock.Advance(time.Second)
+			keyCreationLock.Lock()
+			_, orphan := keyLocks["KeyLocksOrphan"]
+			_, heldLock := keyLocks["KeyLocksHeld"]
+			_, existing := keyLocks["KeyLocksKey3"]
+			keyCreationLock.Unlock()
+			if !orphan {
+				if !heldLock {
+					t.Error("expected the held lock not to be removed")
+				}
+				if !existing {
+					t.Error("expected the lock of an existing key not to be removed")
+				}
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatal("expected the lock of a key that doesn't exist to be removed")
+			}
+			<-time.After(10 * time.Millisecond)
+		}
+	})
 }
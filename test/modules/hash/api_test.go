@@ -16,6 +16,7 @@ package hash
 
 import (
 	"context"
+	"fmt"
 	"github.com/echovault/echovault/echovault"
 	"github.com/echovault/echovault/internal/config"
 	"reflect"
@@ -792,3 +793,43 @@ func TestEchoVault_HVALS(t *testing.T) {
 		})
 	}
 }
+
+func TestEchoVault_HSCAN(t *testing.T) {
+	server := createEchoVault()
+
+	want := make(map[string]string)
+	hash := make(map[string]interface{})
+	for i := 0; i < 25; i++ {
+		want[fmt.Sprintf("field%d", i)] = fmt.Sprintf("value%d", i)
+		hash[fmt.Sprintf("field%d", i)] = fmt.Sprintf("value%d", i)
+	}
+	if err := presetValue(server, context.Background(), "key1", hash); err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(map[string]string)
+	var cursor uint64
+	for {
+		next, fields, err := server.HScan("key1", cursor, echovault.HScanOptions{Count: 3})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for field, value := range fields {
+			got[field] = value
+		}
+		if cursor = next; cursor == 0 {
+			break
+		}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("HSCAN() got = %v, want %v", got, want)
+	}
+
+	_, fields, err := server.HScan("key1", 0, echovault.HScanOptions{Match: "field1?", Count: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fields) != 10 {
+		t.Errorf("HSCAN() with MATCH got %d fields, want %d", len(fields), 10)
+	}
+}
@@ -1533,3 +1533,171 @@ func Test_HandleHDEL(t *testing.T) {
 		})
 	}
 }
+
+func Test_HandleHSCAN(t *testing.T) {
+	ctx := context.WithValue(context.Background(), "test_name", "HSCAN")
+
+	key := "HScanKey1"
+	if _, err := mockServer.CreateKeyAndLock(ctx, key); err != nil {
+		t.Fatal(err)
+	}
+	hash := make(map[string]interface{})
+	for i := 0; i < 20; i++ {
+		hash[fmt.Sprintf("field%d", i)] = fmt.Sprintf("value%d", i)
+	}
+	if err := mockServer.SetValue(ctx, key, hash); err != nil {
+		t.Fatal(err)
+	}
+	mockServer.KeyUnlock(ctx, key)
+
+	wrongTypeKey := "HScanKey2"
+	if _, err := mockServer.CreateKeyAndLock(ctx, wrongTypeKey); err != nil {
+		t.Fatal(err)
+	}
+	if err := mockServer.SetValue(ctx, wrongTypeKey, "default value"); err != nil {
+		t.Fatal(err)
+	}
+	mockServer.KeyUnlock(ctx, wrongTypeKey)
+
+	// scan iterates the hash with the options until the returned cursor is 0.
+	scan := func(options ...string) ([]string, error) {
+		var elems []string
+		cursor := "0"
+		for i := 0; ; i++ {
+			if i > 1000 {
+				return nil, errors.New("scan did not complete")
+			}
+			command := append([]string{"HSCAN", key, cursor}, options...)
+			res, err := getHandler("HSCAN")(getHandlerFuncParams(ctx, command, nil))
+			if err != nil {
+				return nil, err
+			}
+			rv, _, err := resp.NewReader(bytes.NewReader(res)).ReadValue()
+			if err != nil {
+				return nil, err
+			}
+			for _, v := range rv.Array()[1].Array() {
+				elems = append(elems, v.String())
+			}
+			cursor = rv.Array()[0].String()
+			if cursor == "0" {
+				break
+			}
+		}
+		return elems, nil
+	}
+
+	t.Run("1. Iterate all the fields exactly once", func(t *testing.T) {
+		elems, err := scan("COUNT", "3")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(elems) != 40 {
+			t.Fatalf("expected 20 fields, got %d elements: %v", len(elems)/2, elems)
+		}
+		got := make(map[string]string)
+		for i := 0; i < len(elems); i += 2 {
+			if _, ok := got[elems[i]]; ok {
+				t.Errorf("field %s returned more than once", elems[i])
+			}
+			got[elems[i]] = elems[i+1]
+		}
+		for i := 0; i < 20; i++ {
+			if got[fmt.Sprintf("field%d", i)] != fmt.Sprintf("value%d", i) {
+				t.Errorf("expected field%d to be %s, got %s", i, fmt.Sprintf("value%d", i), got[fmt.Sprintf("field%d", i)])
+			}
+		}
+	})
+
+	t.Run("2. Only return the fields that match the pattern", func(t *testing.T) {
+		elems, err := scan("MATCH", "field1*", "COUNT", "4")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var matched []string
+		for i := 0; i < len(elems); i += 2 {
+			matched = append(matched, elems[i])
+		}
+		slices.Sort(matched)
+		expected := []string{
+			"field1", "field10", "field11", "field12", "field13",
+			"field14", "field15", "field16", "field17", "field18", "field19",
+		}
+		if !slices.Equal(matched, expected) {
+			t.Errorf("expected fields %v, got %v", expected, matched)
+		}
+	})
+
+	t.Run("3. Return an empty scan for a non-existent key", func(t *testing.T) {
+		res, err := getHandler("HSCAN")(getHandlerFuncParams(ctx, []string{"HSCAN", "HScanKey3", "0"}, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(res, []byte("*2\r\n$1\r\n0\r\n*0\r\n")) {
+			t.Errorf("expected empty scan response, got %q", res)
+		}
+	})
+
+	errorTests := []struct {
+		name          string
+		command       []string
+		expectedError error
+	}{
+		{
+			name:          "4. Return error when the value is not a hash",
+			command:       []string{"HSCAN", wrongTypeKey, "0"},
+			expectedError: errors.New("value at HScanKey2 is not a hash"),
+		},
+		{
+			name:          "5. Return error when the cursor is not an unsigned integer",
+			command:       []string{"HSCAN", key, "cursor"},
+			expectedError: errors.New("invalid cursor"),
+		},
+		{
+			name:          "6. Return error when TYPE option is passed",
+			command:       []string{"HSCAN", key, "0", "TYPE", "string"},
+			expectedError: errors.New("syntax error"),
+		},
+		{
+			name:          "7. Command too short",
+			command:       []string{"HSCAN", key},
+			expectedError: errors.New(constants.WrongArgsResponse),
+		},
+	}
+	for _, test := range errorTests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := getHandler("HSCAN")(getHandlerFuncParams(ctx, test.command, nil))
+			if err == nil || test.expectedError.Error() != err.Error() {
+				t.Errorf("expected error \"%s\", got \"%v\"", test.expectedError.Error(), err)
+			}
+		})
+	}
+}
+
+func Benchmark_HandleHGETALL(b *testing.B) {
+	ctx := context.WithValue(context.Background(), "test_name", "HGETALL benchmark")
+	key := "HgetallBenchmarkKey"
+
+	hash := make(map[string]interface{}, 100000)
+	for i := 0; i < 100000; i++ {
+		hash[fmt.Sprintf("field%d", i)] = fmt.Sprintf("value%d", i)
+	}
+	if _, err := mockServer.CreateKeyAndLock(ctx, key); err != nil {
+		b.Fatal(err)
+	}
+	if err := mockServer.SetValue(ctx, key, hash); err != nil {
+		b.Fatal(err)
+	}
+	mockServer.KeyUnlock(ctx, key)
+
+	handler := getHandler("HGETALL")
+	command := []string{"HGETALL", key}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := handler(getHandlerFuncParams(ctx, command, nil)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"github.com/echovault/echovault/internal/config"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func createEchoVault() *echovault.EchoVault {
@@ -212,7 +213,7 @@ func TestEchoVault_LMOVE(t *testing.T) {
 			destination: "destination1",
 			whereFrom:   "LEFT",
 			whereTo:     "LEFT",
-			want:        "OK",
+			want:        "one",
 			wantErr:     false,
 		},
 		{
@@ -226,7 +227,7 @@ func TestEchoVault_LMOVE(t *testing.T) {
 			destination: "destination2",
 			whereFrom:   "LEFT",
 			whereTo:     "RIGHT",
-			want:        "OK",
+			want:        "one",
 			wantErr:     false,
 		},
 		{
@@ -240,7 +241,7 @@ func TestEchoVault_LMOVE(t *testing.T) {
 			destination: "destination3",
 			whereFrom:   "RIGHT",
 			whereTo:     "LEFT",
-			want:        "OK",
+			want:        "three",
 			wantErr:     false,
 		},
 		{
@@ -254,11 +255,11 @@ func TestEchoVault_LMOVE(t *testing.T) {
 			destination: "destination4",
 			whereFrom:   "RIGHT",
 			whereTo:     "RIGHT",
-			want:        "OK",
+			want:        "three",
 			wantErr:     false,
 		},
 		{
-			name:   "Throw error when the right list is non-existent",
+			name:   "Create the right list when it is non-existent",
 			preset: true,
 			presetValue: map[string]interface{}{
 				"source5": []interface{}{"one", "two", "three"},
@@ -267,8 +268,8 @@ func TestEchoVault_LMOVE(t *testing.T) {
 			destination: "destination5",
 			whereFrom:   "LEFT",
 			whereTo:     "LEFT",
-			want:        "",
-			wantErr:     true,
+			want:        "one",
+			wantErr:     false,
 		},
 		{
 			name:   "Throw error when right list in not a list",
@@ -357,6 +358,78 @@ func TestEchoVault_LMOVE(t *testing.T) {
 	}
 }
 
+func TestEchoVault_LMPOP(t *testing.T) {
+	server := createEchoVault()
+
+	tests := []struct {
+		name         string
+		presetValues map[string]interface{}
+		keys         []string
+		options      echovault.LMPopOptions
+		wantKey      string
+		want         []string
+		wantErr      bool
+	}{
+		{
+			name:         "Pop one element from the left by default",
+			presetValues: map[string]interface{}{"key1": []interface{}{"one", "two"}},
+			keys:         []string{"key0", "key1"},
+			options:      echovault.LMPopOptions{},
+			wantKey:      "key1",
+			want:         []string{"one"},
+			wantErr:      false,
+		},
+		{
+			name:         "Pop multiple elements from the right",
+			presetValues: map[string]interface{}{"key2": []interface{}{"one", "two", "three"}},
+			keys:         []string{"key2"},
+			options:      echovault.LMPopOptions{Right: true, Count: 2},
+			wantKey:      "key2",
+			want:         []string{"three", "two"},
+			wantErr:      false,
+		},
+		{
+			name:         "Return an empty key when all the lists are empty",
+			presetValues: map[string]interface{}{"key3": []interface{}{}},
+			keys:         []string{"key3", "key4"},
+			options:      echovault.LMPopOptions{},
+			wantKey:      "",
+			want:         nil,
+			wantErr:      false,
+		},
+		{
+			name:         "Return error when a key is not a list",
+			presetValues: map[string]interface{}{"key5": "Default value"},
+			keys:         []string{"key5"},
+			options:      echovault.LMPopOptions{},
+			wantKey:      "",
+			want:         nil,
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.presetValues {
+				if err := presetValue(server, context.Background(), k, v); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+			gotKey, got, err := server.LMPop(tt.keys, tt.options)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LMPOP() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if gotKey != tt.wantKey {
+				t.Errorf("LMPOP() gotKey = %v, want %v", gotKey, tt.wantKey)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LMPOP() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestEchoVault_POP(t *testing.T) {
 	server := createEchoVault()
 
@@ -676,6 +749,162 @@ func TestEchoVault_LRANGE(t *testing.T) {
 	}
 }
 
+func TestEchoVault_LINSERT(t *testing.T) {
+	server := createEchoVault()
+
+	tests := []struct {
+		name        string
+		preset      bool
+		presetValue interface{}
+		key         string
+		position    string
+		pivot       string
+		element     string
+		want        int
+		wantErr     bool
+	}{
+		{
+			name:        "Insert the element before the pivot",
+			preset:      true,
+			presetValue: []interface{}{"1", "2", "3"},
+			key:         "key1",
+			position:    "BEFORE",
+			pivot:       "2",
+			element:     "4",
+			want:        4,
+			wantErr:     false,
+		},
+		{
+			name:        "Return -1 when the pivot does not exist",
+			preset:      true,
+			presetValue: []interface{}{"1", "2", "3"},
+			key:         "key2",
+			position:    "AFTER",
+			pivot:       "5",
+			element:     "4",
+			want:        -1,
+			wantErr:     false,
+		},
+		{
+			name:        "Throw error on non-list item",
+			preset:      true,
+			presetValue: "Default value",
+			key:         "key3",
+			position:    "BEFORE",
+			pivot:       "1",
+			element:     "4",
+			want:        0,
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		if tt.preset {
+			err := presetValue(server, context.Background(), tt.key, tt.presetValue)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+		}
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := server.LInsert(tt.key, tt.position, tt.pivot, tt.element)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LINSERT() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("LINSERT() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEchoVault_BLPOP(t *testing.T) {
+	server := createEchoVault()
+
+	t.Run("Pop immediately from a non-empty list", func(t *testing.T) {
+		if err := presetValue(server, context.Background(), "key1", []interface{}{"1", "2"}); err != nil {
+			t.Error(err)
+			return
+		}
+		got, err := server.BRPop(1, "key0", "key1")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if want := []string{"key1", "2"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("BRPOP() got = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("Block until an element is pushed", func(t *testing.T) {
+		go func() {
+			<-time.After(50 * time.Millisecond)
+			if _, err := server.LPush("key2", "1"); err != nil {
+				t.Error(err)
+			}
+		}()
+		got, err := server.BLPop(5, "key2")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if want := []string{"key2", "1"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("BLPOP() got = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("Return an empty slice when the timeout expires", func(t *testing.T) {
+		got, err := server.BLPop(0.1, "key3")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if len(got) != 0 {
+			t.Errorf("BLPOP() got = %v, want empty slice", got)
+		}
+	})
+}
+
+func TestEchoVault_BLMOVE(t *testing.T) {
+	server := createEchoVault()
+
+	t.Run("Block until an element is pushed to the source", func(t *testing.T) {
+		go func() {
+			<-time.After(50 * time.Millisecond)
+			if _, err := server.RPush("queue", "job1", "job2"); err != nil {
+				t.Error(err)
+			}
+		}()
+		got, err := server.BLMove("queue", "processing", "LEFT", "RIGHT", 5)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if got != "job1" {
+			t.Errorf("BLMOVE() got = %v, want %v", got, "job1")
+		}
+		processing, err := server.LRange("processing", 0, -1)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if want := []string{"job1"}; !reflect.DeepEqual(processing, want) {
+			t.Errorf("LRANGE() got = %v, want %v", processing, want)
+		}
+	})
+
+	t.Run("Return an empty string when the timeout expires", func(t *testing.T) {
+		got, err := server.BLMove("empty", "processing", "LEFT", "RIGHT", 0.1)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if got != "" {
+			t.Errorf("BLMOVE() got = %v, want empty string", got)
+		}
+	})
+}
+
 func TestEchoVault_LREM(t *testing.T) {
 	server := createEchoVault()
 
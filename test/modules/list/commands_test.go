@@ -23,11 +23,13 @@ import (
 	"github.com/echovault/echovault/internal"
 	"github.com/echovault/echovault/internal/config"
 	"github.com/echovault/echovault/internal/constants"
+	"github.com/echovault/echovault/internal/keywait"
 	"github.com/tidwall/resp"
 	"net"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 	"unsafe"
 )
 
@@ -82,6 +84,12 @@ func getHandlerFuncParams(ctx context.Context, cmd []string, conn *net.Conn) int
 		KeyRUnlock:       mockServer.KeyRUnlock,
 		GetValue:         mockServer.GetValue,
 		SetValue:         mockServer.SetValue,
+		DeleteLockedKey:  mockServer.DeleteLockedKey,
+		GetConfigParameters: func() map[string]string {
+			return map[string]string{"protocol-compat": constants.ProtocolCompatEchoVault}
+		},
+		WatchKeys: getUnexportedField(
+			reflect.ValueOf(mockServer).Elem().FieldByName("keyWaiters")).(*keywait.Registry).Watch,
 	}
 }
 
@@ -688,6 +696,142 @@ func Test_HandleLSET(t *testing.T) {
 	}
 }
 
+func Test_HandleLINSERT(t *testing.T) {
+	tests := []struct {
+		name             string
+		preset           bool
+		key              string
+		presetValue      interface{}
+		command          []string
+		expectedResponse int
+		expectedValue    []interface{}
+		expectedError    error
+	}{
+		{
+			name:             "1. Insert element before the pivot",
+			preset:           true,
+			key:              "LinsertKey1",
+			presetValue:      []interface{}{"value1", "value2", "value3"},
+			command:          []string{"LINSERT", "LinsertKey1", "BEFORE", "value2", "new-value"},
+			expectedResponse: 4,
+			expectedValue:    []interface{}{"value1", "new-value", "value2", "value3"},
+			expectedError:    nil,
+		},
+		{
+			name:             "2. Insert element after the pivot",
+			preset:           true,
+			key:              "LinsertKey2",
+			presetValue:      []interface{}{"value1", "value2", "value3"},
+			command:          []string{"LINSERT", "LinsertKey2", "after", "value3", "new-value"},
+			expectedResponse: 4,
+			expectedValue:    []interface{}{"value1", "value2", "value3", "new-value"},
+			expectedError:    nil,
+		},
+		{
+			name:             "3. Return -1 when the pivot is not found",
+			preset:           true,
+			key:              "LinsertKey3",
+			presetValue:      []interface{}{"value1", "value2", "value3"},
+			command:          []string{"LINSERT", "LinsertKey3", "BEFORE", "value4", "new-value"},
+			expectedResponse: -1,
+			expectedValue:    []interface{}{"value1", "value2", "value3"},
+			expectedError:    nil,
+		},
+		{
+			name:             "4. Return 0 when the key does not exist",
+			preset:           false,
+			key:              "LinsertKey4",
+			presetValue:      nil,
+			command:          []string{"LINSERT", "LinsertKey4", "BEFORE", "value1", "new-value"},
+			expectedResponse: 0,
+			expectedValue:    nil,
+			expectedError:    nil,
+		},
+		{
+			name:             "5. Return error when the position is not BEFORE or AFTER",
+			preset:           true,
+			key:              "LinsertKey5",
+			presetValue:      []interface{}{"value1"},
+			command:          []string{"LINSERT", "LinsertKey5", "MIDDLE", "value1", "new-value"},
+			expectedResponse: 0,
+			expectedValue:    nil,
+			expectedError:    errors.New("position must be BEFORE or AFTER"),
+		},
+		{
+			name:             "6. Return error when the key is not a list",
+			preset:           true,
+			key:              "LinsertKey6",
+			presetValue:      "Default value",
+			command:          []string{"LINSERT", "LinsertKey6", "BEFORE", "value1", "new-value"},
+			expectedResponse: 0,
+			expectedValue:    nil,
+			expectedError:    errors.New("LINSERT command on non-list item"),
+		},
+		{
+			name:             "7. Command too short",
+			preset:           false,
+			key:              "LinsertKey7",
+			presetValue:      nil,
+			command:          []string{"LINSERT", "LinsertKey7", "BEFORE", "value1"},
+			expectedResponse: 0,
+			expectedValue:    nil,
+			expectedError:    errors.New(constants.WrongArgsResponse),
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), "test_name", fmt.Sprintf("LINSERT, %d", i))
+
+			if test.preset {
+				if _, err := mockServer.CreateKeyAndLock(ctx, test.key); err != nil {
+					t.Error(err)
+				}
+				if err := mockServer.SetValue(ctx, test.key, test.presetValue); err != nil {
+					t.Error(err)
+				}
+				mockServer.KeyUnlock(ctx, test.key)
+			}
+
+			handler := getHandler(test.command[0])
+			if handler == nil {
+				t.Errorf("no handler found for command %s", test.command[0])
+				return
+			}
+
+			res, err := handler(getHandlerFuncParams(ctx, test.command, nil))
+			if test.expectedError != nil {
+				if err == nil || err.Error() != test.expectedError.Error() {
+					t.Errorf("expected error \"%s\", got \"%v\"", test.expectedError.Error(), err)
+				}
+				return
+			}
+			rd := resp.NewReader(bytes.NewBuffer(res))
+			rv, _, err := rd.ReadValue()
+			if err != nil {
+				t.Error(err)
+			}
+			if rv.Integer() != test.expectedResponse {
+				t.Errorf("expected response %d, got %d", test.expectedResponse, rv.Integer())
+			}
+			if !test.preset {
+				return
+			}
+			if _, err = mockServer.KeyRLock(ctx, test.key); err != nil {
+				t.Error(err)
+			}
+			defer mockServer.KeyRUnlock(ctx, test.key)
+			l, ok := mockServer.GetValue(ctx, test.key).([]interface{})
+			if !ok {
+				t.Error("expected value to be list, got another type")
+			}
+			if !reflect.DeepEqual(l, test.expectedValue) {
+				t.Errorf("expected list %+v, got %+v", test.expectedValue, l)
+			}
+		})
+	}
+}
+
 func Test_HandleLTRIM(t *testing.T) {
 	tests := []struct {
 		name             string
@@ -1025,7 +1169,7 @@ func Test_HandleLMOVE(t *testing.T) {
 				"destination1": []interface{}{"one", "two", "three"},
 			},
 			command:          []string{"LMOVE", "source1", "destination1", "LEFT", "LEFT"},
-			expectedResponse: "OK",
+			expectedResponse: "one",
 			expectedValue: map[string]interface{}{
 				"source1":      []interface{}{"two", "three"},
 				"destination1": []interface{}{"one", "one", "two", "three"},
@@ -1040,7 +1184,7 @@ func Test_HandleLMOVE(t *testing.T) {
 				"destination2": []interface{}{"one", "two", "three"},
 			},
 			command:          []string{"LMOVE", "source2", "destination2", "LEFT", "RIGHT"},
-			expectedResponse: "OK",
+			expectedResponse: "one",
 			expectedValue: map[string]interface{}{
 				"source2":      []interface{}{"two", "three"},
 				"destination2": []interface{}{"one", "two", "three", "one"},
@@ -1055,7 +1199,7 @@ func Test_HandleLMOVE(t *testing.T) {
 				"destination3": []interface{}{"one", "two", "three"},
 			},
 			command:          []string{"LMOVE", "source3", "destination3", "RIGHT", "LEFT"},
-			expectedResponse: "OK",
+			expectedResponse: "three",
 			expectedValue: map[string]interface{}{
 				"source3":      []interface{}{"one", "two"},
 				"destination3": []interface{}{"three", "one", "two", "three"},
@@ -1070,7 +1214,7 @@ func Test_HandleLMOVE(t *testing.T) {
 				"destination4": []interface{}{"one", "two", "three"},
 			},
 			command:          []string{"LMOVE", "source4", "destination4", "RIGHT", "RIGHT"},
-			expectedResponse: "OK",
+			expectedResponse: "three",
 			expectedValue: map[string]interface{}{
 				"source4":      []interface{}{"one", "two"},
 				"destination4": []interface{}{"one", "two", "three", "three"},
@@ -1078,17 +1222,18 @@ func Test_HandleLMOVE(t *testing.T) {
 			expectedError: nil,
 		},
 		{
-			name:   "5. Throw error when the right list is non-existent",
+			name:   "5. Create the right list when it is non-existent",
 			preset: true,
 			presetValue: map[string]interface{}{
 				"source5": []interface{}{"one", "two", "three"},
 			},
 			command:          []string{"LMOVE", "source5", "destination5", "LEFT", "LEFT"},
-			expectedResponse: nil,
+			expectedResponse: "one",
 			expectedValue: map[string]interface{}{
-				"source5": []interface{}{"one", "two", "three"},
+				"source5":      []interface{}{"two", "three"},
+				"destination5": []interface{}{"one"},
 			},
-			expectedError: errors.New("both source and destination must be lists"),
+			expectedError: nil,
 		},
 		{
 			name:   "6. Throw error when right list in not a list",
@@ -1169,6 +1314,34 @@ func Test_HandleLMOVE(t *testing.T) {
 			expectedValue:    map[string]interface{}{},
 			expectedError:    errors.New("wherefrom and whereto arguments must be either LEFT or RIGHT"),
 		},
+		{
+			name:   "13. Rotate the list when source and destination are the same",
+			preset: true,
+			presetValue: map[string]interface{}{
+				"source13": []interface{}{"one", "two", "three"},
+			},
+			command:          []string{"LMOVE", "source13", "source13", "LEFT", "RIGHT"},
+			expectedResponse: "one",
+			expectedValue: map[string]interface{}{
+				"source13": []interface{}{"two", "three", "one"},
+			},
+			expectedError: nil,
+		},
+		{
+			name:   "14. Return nil when the left list is empty",
+			preset: true,
+			presetValue: map[string]interface{}{
+				"source14":      []interface{}{},
+				"destination14": []interface{}{"one"},
+			},
+			command:          []string{"LMOVE", "source14", "destination14", "LEFT", "LEFT"},
+			expectedResponse: "",
+			expectedValue: map[string]interface{}{
+				"source14":      []interface{}{},
+				"destination14": []interface{}{"one"},
+			},
+			expectedError: nil,
+		},
 	}
 
 	for i, test := range tests {
@@ -1234,6 +1407,453 @@ func Test_HandleLMOVE(t *testing.T) {
 	}
 }
 
+func Test_HandleLMPOP(t *testing.T) {
+	tests := []struct {
+		name             string
+		presetValues     map[string]interface{}
+		protocolCompat   string
+		command          []string
+		expectedResponse []string // The key followed by the popped elements.
+		expectedValues   map[string][]interface{}
+		expectedError    error
+	}{
+		{
+			name:             "1. Pop one element from the left of the first non-empty list",
+			presetValues:     map[string]interface{}{"LmpopKey2": []interface{}{}, "LmpopKey3": []interface{}{"one", "two", "three"}},
+			command:          []string{"LMPOP", "LmpopKey1", "LmpopKey2", "LmpopKey3", "LEFT"},
+			expectedResponse: []string{"LmpopKey3", "one"},
+			expectedValues:   map[string][]interface{}{"LmpopKey2": {}, "LmpopKey3": {"two", "three"}},
+			expectedError:    nil,
+		},
+		{
+			name:             "2. Pop multiple elements from the right",
+			presetValues:     map[string]interface{}{"LmpopKey4": []interface{}{"one", "two", "three"}},
+			command:          []string{"LMPOP", "LmpopKey4", "RIGHT", "COUNT", "2"},
+			expectedResponse: []string{"LmpopKey4", "three", "two"},
+			expectedValues:   map[string][]interface{}{"LmpopKey4": {"one"}},
+			expectedError:    nil,
+		},
+		{
+			name:             "3. Pop all the elements when count is larger than the list",
+			presetValues:     map[string]interface{}{"LmpopKey5": []interface{}{"one", "two"}},
+			command:          []string{"LMPOP", "LmpopKey5", "LEFT", "COUNT", "10"},
+			expectedResponse: []string{"LmpopKey5", "one", "two"},
+			expectedValues:   map[string][]interface{}{"LmpopKey5": {}},
+			expectedError:    nil,
+		},
+		{
+			name:             "4. Return null when all the lists are empty",
+			presetValues:     map[string]interface{}{"LmpopKey6": []interface{}{}},
+			command:          []string{"LMPOP", "LmpopKey6", "LmpopKey7", "LEFT"},
+			expectedResponse: nil,
+			expectedValues:   map[string][]interface{}{"LmpopKey6": {}},
+			expectedError:    nil,
+		},
+		{
+			name:          "5. Return error when a key is not a list",
+			presetValues:  map[string]interface{}{"LmpopKey8": "Default value"},
+			command:       []string{"LMPOP", "LmpopKey8", "LEFT"},
+			expectedError: errors.New("LMPOP command on non-list item"),
+		},
+		{
+			name:          "6. Return error when count is not a positive integer",
+			command:       []string{"LMPOP", "LmpopKey9", "LEFT", "COUNT", "0"},
+			expectedError: errors.New("count must be a positive integer"),
+		},
+		{
+			name:          "7. Return error when the option is unknown",
+			command:       []string{"LMPOP", "LmpopKey10", "LEFT", "LIMIT", "2"},
+			expectedError: errors.New("unknown option LIMIT"),
+		},
+		{
+			name:          "8. Return error when LEFT or RIGHT is missing",
+			command:       []string{"LMPOP", "LmpopKey11", "LmpopKey12"},
+			expectedError: errors.New(constants.WrongArgsResponse),
+		},
+		{
+			name:             "9. Take numkeys before the keys in redis-7 protocol-compat",
+			presetValues:     map[string]interface{}{"LmpopKey13": []interface{}{}, "LmpopKey14": []interface{}{"one", "two"}},
+			protocolCompat:   constants.ProtocolCompatRedis7,
+			command:          []string{"LMPOP", "2", "LmpopKey13", "LmpopKey14", "RIGHT", "COUNT", "2"},
+			expectedResponse: []string{"LmpopKey14", "two", "one"},
+			expectedValues:   map[string][]interface{}{"LmpopKey13": {}, "LmpopKey14": {}},
+			expectedError:    nil,
+		},
+		{
+			name:           "10. Return error when numkeys is missing in redis-7 protocol-compat",
+			protocolCompat: constants.ProtocolCompatRedis7,
+			command:        []string{"LMPOP", "LmpopKey15", "LmpopKey16", "LEFT"},
+			expectedError:  errors.New("numkeys should be greater than 0"),
+		},
+		{
+			name:           "11. Return error when numkeys is larger than the number of keys in redis-7 protocol-compat",
+			protocolCompat: constants.ProtocolCompatRedis7,
+			command:        []string{"LMPOP", "3", "LmpopKey17", "LmpopKey18", "LEFT"},
+			expectedError:  errors.New("number of keys can't be greater than number of args"),
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), "test_name", fmt.Sprintf("LMPOP, %d", i))
+
+			for key, value := range test.presetValues {
+				if _, err := mockServer.CreateKeyAndLock(ctx, key); err != nil {
+					t.Error(err)
+				}
+				if err := mockServer.SetValue(ctx, key, value); err != nil {
+					t.Error(err)
+				}
+				mockServer.KeyUnlock(ctx, key)
+			}
+
+			handler := getHandler(test.command[0])
+			if handler == nil {
+				t.Errorf("no handler found for command %s", test.command[0])
+				return
+			}
+
+			params := getHandlerFuncParams(ctx, test.command, nil)
+			if test.protocolCompat != "" {
+				params.GetConfigParameters = func() map[string]string {
+					return map[string]string{"protocol-compat": test.protocolCompat}
+				}
+			}
+
+			res, err := handler(params)
+			if test.expectedError != nil {
+				if err == nil || err.Error() != test.expectedError.Error() {
+					t.Errorf("expected error \"%s\", got \"%v\"", test.expectedError.Error(), err)
+				}
+				return
+			}
+			if err != nil {
+				t.Error(err)
+				return
+			}
+
+			rd := resp.NewReader(bytes.NewBuffer(res))
+			rv, _, err := rd.ReadValue()
+			if err != nil {
+				t.Error(err)
+			}
+			if test.expectedResponse == nil {
+				if !rv.IsNull() {
+					t.Errorf("expected null response, got %+v", rv)
+				}
+			} else {
+				got := []string{rv.Array()[0].String()}
+				for _, v := range rv.Array()[1].Array() {
+					got = append(got, v.String())
+				}
+				if !reflect.DeepEqual(got, test.expectedResponse) {
+					t.Errorf("expected response %+v, got %+v", test.expectedResponse, got)
+				}
+			}
+
+			for key, expectedValue := range test.expectedValues {
+				if _, err = mockServer.KeyRLock(ctx, key); err != nil {
+					t.Error(err)
+				}
+				list, ok := mockServer.GetValue(ctx, key).([]interface{})
+				if !ok {
+					t.Error("expected value to be list, got another type")
+				}
+				if !reflect.DeepEqual(list, expectedValue) {
+					t.Errorf("expected list at key %s to be %+v, got %+v", key, expectedValue, list)
+				}
+				mockServer.KeyRUnlock(ctx, key)
+			}
+		})
+	}
+}
+
+func Test_HandleBlockingPop(t *testing.T) {
+	tests := []struct {
+		name             string
+		presetValues     map[string]interface{}
+		pushAfter        map[string][]interface{} // Lists to set after the command has started blocking.
+		command          []string
+		expectedResponse []string
+		expectedValues   map[string][]interface{}
+		expectedError    error
+	}{
+		{
+			name:             "1. BLPOP pops from the first non-empty list without blocking",
+			presetValues:     map[string]interface{}{"BlockingPopKey2": []interface{}{"value1", "value2"}},
+			command:          []string{"BLPOP", "BlockingPopKey1", "BlockingPopKey2", "1"},
+			expectedResponse: []string{"BlockingPopKey2", "value1"},
+			expectedValues:   map[string][]interface{}{"BlockingPopKey2": {"value2"}},
+			expectedError:    nil,
+		},
+		{
+			name:             "2. BRPOP pops the last element of the list",
+			presetValues:     map[string]interface{}{"BlockingPopKey3": []interface{}{"value1", "value2"}},
+			command:          []string{"BRPOP", "BlockingPopKey3", "1"},
+			expectedResponse: []string{"BlockingPopKey3", "value2"},
+			expectedValues:   map[string][]interface{}{"BlockingPopKey3": {"value1"}},
+			expectedError:    nil,
+		},
+		{
+			name:             "3. Return null when the timeout expires",
+			presetValues:     map[string]interface{}{"BlockingPopKey4": []interface{}{}},
+			command:          []string{"BLPOP", "BlockingPopKey4", "0.1"},
+			expectedResponse: nil,
+			expectedValues:   map[string][]interface{}{"BlockingPopKey4": {}},
+			expectedError:    nil,
+		},
+		{
+			name:             "4. Wake up and pop when an element is pushed while blocking",
+			presetValues:     map[string]interface{}{"BlockingPopKey5": []interface{}{}},
+			pushAfter:        map[string][]interface{}{"BlockingPopKey5": {"value1", "value2"}},
+			command:          []string{"BLPOP", "BlockingPopKey5", "5"},
+			expectedResponse: []string{"BlockingPopKey5", "value1"},
+			expectedValues:   map[string][]interface{}{"BlockingPopKey5": {"value2"}},
+			expectedError:    nil,
+		},
+		{
+			name:             "5. Return error when the key is not a list",
+			presetValues:     map[string]interface{}{"BlockingPopKey6": "Default value"},
+			command:          []string{"BLPOP", "BlockingPopKey6", "1"},
+			expectedResponse: nil,
+			expectedValues:   nil,
+			expectedError:    errors.New("BLPOP command on non-list item"),
+		},
+		{
+			name:             "6. Return error when the timeout is negative",
+			command:          []string{"BLPOP", "BlockingPopKey7", "-1"},
+			expectedResponse: nil,
+			expectedValues:   nil,
+			expectedError:    errors.New("timeout must be a non-negative number"),
+		},
+		{
+			name:             "7. Command too short",
+			command:          []string{"BLPOP", "BlockingPopKey8"},
+			expectedResponse: nil,
+			expectedValues:   nil,
+			expectedError:    errors.New(constants.WrongArgsResponse),
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), "test_name", fmt.Sprintf("BLOCKING POP, %d", i))
+
+			for key, value := range test.presetValues {
+				if _, err := mockServer.CreateKeyAndLock(ctx, key); err != nil {
+					t.Error(err)
+				}
+				if err := mockServer.SetValue(ctx, key, value); err != nil {
+					t.Error(err)
+				}
+				mockServer.KeyUnlock(ctx, key)
+			}
+
+			// Copy the lists so that the goroutine does not read the next test case after this one completes.
+			pushAfter := test.pushAfter
+			go func() {
+				<-time.After(50 * time.Millisecond)
+				for key, value := range pushAfter {
+					if _, err := mockServer.KeyLock(ctx, key); err != nil {
+						t.Error(err)
+					}
+					if err := mockServer.SetValue(ctx, key, value); err != nil {
+						t.Error(err)
+					}
+					mockServer.KeyUnlock(ctx, key)
+				}
+			}()
+
+			handler := getHandler(test.command[0])
+			if handler == nil {
+				t.Errorf("no handler found for command %s", test.command[0])
+				return
+			}
+
+			res, err := handler(getHandlerFuncParams(ctx, test.command, nil))
+			if test.expectedError != nil {
+				if err == nil || err.Error() != test.expectedError.Error() {
+					t.Errorf("expected error \"%s\", got \"%v\"", test.expectedError.Error(), err)
+				}
+				return
+			}
+			if err != nil {
+				t.Error(err)
+				return
+			}
+
+			rd := resp.NewReader(bytes.NewBuffer(res))
+			rv, _, err := rd.ReadValue()
+			if err != nil {
+				t.Error(err)
+			}
+			if test.expectedResponse == nil {
+				if !rv.IsNull() {
+					t.Errorf("expected null response, got %+v", rv)
+				}
+			} else {
+				var got []string
+				for _, v := range rv.Array() {
+					got = append(got, v.String())
+				}
+				if !reflect.DeepEqual(got, test.expectedResponse) {
+					t.Errorf("expected response %+v, got %+v", test.expectedResponse, got)
+				}
+			}
+
+			for key, expectedValue := range test.expectedValues {
+				if _, err = mockServer.KeyRLock(ctx, key); err != nil {
+					t.Error(err)
+				}
+				list, ok := mockServer.GetValue(ctx, key).([]interface{})
+				if !ok {
+					t.Error("expected value to be list, got another type")
+				}
+				if !reflect.DeepEqual(list, expectedValue) {
+					t.Errorf("expected list at key %s to be %+v, got %+v", key, expectedValue, list)
+				}
+				mockServer.KeyRUnlock(ctx, key)
+			}
+		})
+	}
+}
+
+func Test_HandleBLMOVE(t *testing.T) {
+	tests := []struct {
+		name             string
+		presetValues     map[string]interface{}
+		pushAfter        map[string][]interface{} // Lists to set after the command has started blocking.
+		command          []string
+		expectedResponse interface{}
+		expectedValues   map[string][]interface{}
+		expectedError    error
+	}{
+		{
+			name:             "1. Move an element without blocking when the source is not empty",
+			presetValues:     map[string]interface{}{"BlmoveKey1": []interface{}{"value1", "value2"}},
+			command:          []string{"BLMOVE", "BlmoveKey1", "BlmoveKey2", "LEFT", "RIGHT", "1"},
+			expectedResponse: "value1",
+			expectedValues:   map[string][]interface{}{"BlmoveKey1": {"value2"}, "BlmoveKey2": {"value1"}},
+			expectedError:    nil,
+		},
+		{
+			name:             "2. Return null when the timeout expires",
+			presetValues:     map[string]interface{}{"BlmoveKey3": []interface{}{}},
+			command:          []string{"BLMOVE", "BlmoveKey3", "BlmoveKey4", "LEFT", "LEFT", "0.1"},
+			expectedResponse: nil,
+			expectedValues:   map[string][]interface{}{"BlmoveKey3": {}},
+			expectedError:    nil,
+		},
+		{
+			name:             "3. Wake up and move when an element is pushed while blocking",
+			presetValues:     map[string]interface{}{"BlmoveKey5": []interface{}{}, "BlmoveKey6": []interface{}{"value3"}},
+			pushAfter:        map[string][]interface{}{"BlmoveKey5": {"value1", "value2"}},
+			command:          []string{"BLMOVE", "BlmoveKey5", "BlmoveKey6", "RIGHT", "LEFT", "5"},
+			expectedResponse: "value2",
+			expectedValues:   map[string][]interface{}{"BlmoveKey5": {"value1"}, "BlmoveKey6": {"value2", "value3"}},
+			expectedError:    nil,
+		},
+		{
+			name:             "4. Return error when the destination is not a list",
+			presetValues:     map[string]interface{}{"BlmoveKey7": []interface{}{"value1"}, "BlmoveKey8": "Default value"},
+			command:          []string{"BLMOVE", "BlmoveKey7", "BlmoveKey8", "LEFT", "LEFT", "1"},
+			expectedResponse: nil,
+			expectedValues:   nil,
+			expectedError:    errors.New("both source and destination must be lists"),
+		},
+		{
+			name:             "5. Return error when the timeout is negative",
+			command:          []string{"BLMOVE", "BlmoveKey9", "BlmoveKey10", "LEFT", "LEFT", "-1"},
+			expectedResponse: nil,
+			expectedValues:   nil,
+			expectedError:    errors.New("timeout must be a non-negative number"),
+		},
+		{
+			name:             "6. Command too short",
+			command:          []string{"BLMOVE", "BlmoveKey11", "BlmoveKey12", "LEFT", "LEFT"},
+			expectedResponse: nil,
+			expectedValues:   nil,
+			expectedError:    errors.New(constants.WrongArgsResponse),
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), "test_name", fmt.Sprintf("BLMOVE, %d", i))
+
+			for key, value := range test.presetValues {
+				if _, err := mockServer.CreateKeyAndLock(ctx, key); err != nil {
+					t.Error(err)
+				}
+				if err := mockServer.SetValue(ctx, key, value); err != nil {
+					t.Error(err)
+				}
+				mockServer.KeyUnlock(ctx, key)
+			}
+
+			// Copy the lists so that the goroutine does not read the next test case after this one completes.
+			pushAfter := test.pushAfter
+			go func() {
+				<-time.After(50 * time.Millisecond)
+				for key, value := range pushAfter {
+					if _, err := mockServer.KeyLock(ctx, key); err != nil {
+						t.Error(err)
+					}
+					if err := mockServer.SetValue(ctx, key, value); err != nil {
+						t.Error(err)
+					}
+					mockServer.KeyUnlock(ctx, key)
+				}
+			}()
+
+			handler := getHandler(test.command[0])
+			if handler == nil {
+				t.Errorf("no handler found for command %s", test.command[0])
+				return
+			}
+
+			res, err := handler(getHandlerFuncParams(ctx, test.command, nil))
+			if test.expectedError != nil {
+				if err == nil || err.Error() != test.expectedError.Error() {
+					t.Errorf("expected error \"%s\", got \"%v\"", test.expectedError.Error(), err)
+				}
+				return
+			}
+			if err != nil {
+				t.Error(err)
+				return
+			}
+
+			rd := resp.NewReader(bytes.NewBuffer(res))
+			rv, _, err := rd.ReadValue()
+			if err != nil {
+				t.Error(err)
+			}
+			if test.expectedResponse == nil {
+				if !rv.IsNull() {
+					t.Errorf("expected null response, got %+v", rv)
+				}
+			} else if rv.String() != test.expectedResponse {
+				t.Errorf("expected response %+v, got %s", test.expectedResponse, rv.String())
+			}
+
+			for key, expectedValue := range test.expectedValues {
+				if _, err = mockServer.KeyRLock(ctx, key); err != nil {
+					t.Error(err)
+				}
+				list, ok := mockServer.GetValue(ctx, key).([]interface{})
+				if !ok {
+					t.Error("expected value to be list, got another type")
+				}
+				if !reflect.DeepEqual(list, expectedValue) {
+					t.Errorf("expected list at key %s to be %+v, got %+v", key, expectedValue, list)
+				}
+				mockServer.KeyRUnlock(ctx, key)
+			}
+		})
+	}
+}
+
 func Test_HandleLPUSH(t *testing.T) {
 	tests := []struct {
 		name             string
@@ -1597,3 +2217,80 @@ func Test_HandlePOP(t *testing.T) {
 		})
 	}
 }
+
+func Test_HandleProtocolCompat(t *testing.T) {
+	tests := []struct {
+		name             string
+		protocolCompat   string
+		command          []string
+		expectedResponse string
+		expectedError    error
+	}{
+		{
+			name:             "1. LPOP on a non-existent key returns an error by default",
+			protocolCompat:   constants.ProtocolCompatEchoVault,
+			command:          []string{"LPOP", "ProtocolCompatKey1"},
+			expectedResponse: "",
+			expectedError:    errors.New("LPOP command on non-list item"),
+		},
+		{
+			name:             "2. LPOP on a non-existent key returns nil in redis-6 protocol-compat",
+			protocolCompat:   constants.ProtocolCompatRedis6,
+			command:          []string{"LPOP", "ProtocolCompatKey2"},
+			expectedResponse: "$-1\r\n",
+			expectedError:    nil,
+		},
+		{
+			name:             "3. RPOP on a non-existent key returns nil in redis-7 protocol-compat",
+			protocolCompat:   constants.ProtocolCompatRedis7,
+			command:          []string{"RPOP", "ProtocolCompatKey3"},
+			expectedResponse: "$-1\r\n",
+			expectedError:    nil,
+		},
+		{
+			name:             "4. LRANGE on a non-existent key returns an error by default",
+			protocolCompat:   constants.ProtocolCompatEchoVault,
+			command:          []string{"LRANGE", "ProtocolCompatKey4", "0", "-1"},
+			expectedResponse: "",
+			expectedError:    errors.New("LRANGE command on non-list item"),
+		},
+		{
+			name:             "5. LRANGE on a non-existent key returns an empty array in redis-7 protocol-compat",
+			protocolCompat:   constants.ProtocolCompatRedis7,
+			command:          []string{"LRANGE", "ProtocolCompatKey5", "0", "-1"},
+			expectedResponse: "*0\r\n",
+			expectedError:    nil,
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), "test_name", fmt.Sprintf("PROTOCOL-COMPAT, %d", i))
+
+			handler := getHandler(test.command[0])
+			if handler == nil {
+				t.Errorf("no handler found for command %s", test.command[0])
+				return
+			}
+
+			params := getHandlerFuncParams(ctx, test.command, nil)
+			params.GetConfigParameters = func() map[string]string {
+				return map[string]string{"protocol-compat": test.protocolCompat}
+			}
+
+			res, err := handler(params)
+			if test.expectedError != nil {
+				if err == nil || err.Error() != test.expectedError.Error() {
+					t.Errorf("expected error \"%s\", got \"%v\"", test.expectedError.Error(), err)
+				}
+				return
+			}
+			if err != nil {
+				t.Error(err)
+			}
+			if string(res) != test.expectedResponse {
+				t.Errorf("expected response %q, got %q", test.expectedResponse, string(res))
+			}
+		})
+	}
+}
@@ -17,6 +17,7 @@ package pubsub
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"github.com/echovault/echovault/echovault"
 	"github.com/echovault/echovault/internal"
@@ -819,16 +820,16 @@ func Test_HandleNumSub(t *testing.T) {
 		}{
 			{ // 1. Get all subscriptions on existing channels
 				cmd:              append([]string{"PUBSUB", "NUMSUB"}, channels...),
-				expectedResponse: [][]string{{"channel_1", "3"}, {"channel_2", "3"}, {"channel_3", "3"}},
+				expectedResponse: [][]string{{"channel_1", "3", "0"}, {"channel_2", "3", "0"}, {"channel_3", "3", "0"}},
 			},
 			{ // 2. Get all the subscriptions of on existing channels and a few non-existent ones
 				cmd: append([]string{"PUBSUB", "NUMSUB", "non_existent_channel_1", "non_existent_channel_2"}, channels...),
 				expectedResponse: [][]string{
-					{"non_existent_channel_1", "0"},
-					{"non_existent_channel_2", "0"},
-					{"channel_1", "3"},
-					{"channel_2", "3"},
-					{"channel_3", "3"},
+					{"non_existent_channel_1", "0", "0"},
+					{"non_existent_channel_2", "0", "0"},
+					{"channel_1", "3", "0"},
+					{"channel_2", "3", "0"},
+					{"channel_3", "3", "0"},
 				},
 			},
 			{ // 3. Get an empty array when channels are not provided in the command
@@ -858,11 +859,13 @@ func Test_HandleNumSub(t *testing.T) {
 
 			for _, item := range arr {
 				itemArr := item.Array()
-				if len(itemArr) != 2 {
-					t.Errorf("expected each response item to be of length 2, got %d", len(itemArr))
+				if len(itemArr) != 3 {
+					t.Errorf("expected each response item to be of length 3, got %d", len(itemArr))
+					continue
 				}
 				if !slices.ContainsFunc(test.expectedResponse, func(expected []string) bool {
-					return expected[0] == itemArr[0].String() && expected[1] == itemArr[1].String()
+					return expected[0] == itemArr[0].String() && expected[1] == itemArr[1].String() &&
+						expected[2] == itemArr[2].String()
 				}) {
 					t.Errorf("could not find entry with channel \"%s\", with %d subscribers in expected response",
 						itemArr[0].String(), itemArr[1].Integer())
@@ -870,6 +873,29 @@ func Test_HandleNumSub(t *testing.T) {
 			}
 		}
 
+		// Publishing to a channel with 3 subscribers counts as 3 deliveries.
+		for i := 0; i < 2; i++ {
+			if _, err := getHandler("PUBLISH")(getHandlerFuncParams(ctx, []string{"PUBLISH", "channel_1", "message"}, nil, mockServer)); err != nil {
+				t.Error(err)
+			}
+			for _, c := range connections {
+				if _, _, err := c.r.ReadValue(); err != nil {
+					t.Error(err)
+				}
+			}
+		}
+		res, err := getHandler("PUBSUB", "NUMSUB")(getHandlerFuncParams(ctx, []string{"PUBSUB", "NUMSUB", "channel_1"}, nil, mockServer))
+		if err != nil {
+			t.Error(err)
+		}
+		rv, _, err := resp.NewReader(bytes.NewReader(res)).ReadValue()
+		if err != nil {
+			t.Error(err)
+		}
+		if delivered := rv.Array()[0].Array()[2].Integer(); delivered != 6 {
+			t.Errorf("expected 6 deliveries on channel_1, got %d", delivered)
+		}
+
 		done <- struct{}{}
 	}()
 
@@ -879,3 +905,184 @@ func Test_HandleNumSub(t *testing.T) {
 	case <-done:
 	}
 }
+
+func Test_HandleSubscribeReplay(t *testing.T) {
+	ctx := context.WithValue(context.Background(), "test_name", "SUBSCRIBE REPLAY")
+	channel := "replay_channel"
+
+	// readMessages reads the next messages sent to the connection and verifies them.
+	readMessages := func(c *net.Conn, r *resp.Conn, expected ...[]string) {
+		for _, e := range expected {
+			if err := (*c).SetReadDeadline(time.Now().Add(200 * time.Millisecond)); err != nil {
+				t.Fatal(err)
+			}
+			rv, _, err := r.ReadValue()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make([]string, len(rv.Array()))
+			for i, v := range rv.Array() {
+				got[i] = v.String()
+			}
+			if !slices.Equal(got, e) {
+				t.Errorf("expected event %v, got %v", e, got)
+			}
+		}
+	}
+
+	// subscribe subscribes a new connection with the command and returns the connection's reader.
+	subscribe := func(cmd []string) (*net.Conn, *resp.Conn) {
+		w, r := net.Pipe()
+		go func() {
+			if _, err := getHandler("SUBSCRIBE")(getHandlerFuncParams(ctx, cmd, &w, mockServer)); err != nil {
+				t.Error(err)
+			}
+		}()
+		return &r, resp.NewConn(r)
+	}
+
+	publish := func(message string) {
+		if _, err := getHandler("PUBLISH")(getHandlerFuncParams(ctx, []string{"PUBLISH", channel, message}, nil, mockServer)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	message := func(m string) []string {
+		return []string{"message", channel, m}
+	}
+
+	if _, err := getHandler("PUBSUB", "RETAIN")(getHandlerFuncParams(ctx, []string{"PUBSUB", "RETAIN", channel, "3"}, nil, mockServer)); err != nil {
+		t.Fatal(err)
+	}
+
+	// The first subscriber reads each published message, which guarantees that the message has been retained.
+	c1, r1 := subscribe([]string{"SUBSCRIBE", channel})
+	readMessages(c1, r1, []string{"subscribe", channel, "1"})
+	for _, m := range []string{"m1", "m2", "m3", "m4", "m5"} {
+		publish(m)
+		readMessages(c1, r1, message(m))
+	}
+
+	// Replay the last 2 messages, then receive the new messages.
+	c2, r2 := subscribe([]string{"SUBSCRIBE", "REPLAY", "2", channel})
+	readMessages(c2, r2, []string{"subscribe", channel, "1"}, message("m4"), message("m5"))
+	publish("m6")
+	readMessages(c1, r1, message("m6"))
+	readMessages(c2, r2, message("m6"))
+
+	// Only the last 3 messages are retained.
+	c3, r3 := subscribe([]string{"SUBSCRIBE", "REPLAY", "10", channel})
+	readMessages(c3, r3, []string{"subscribe", channel, "1"}, message("m4"), message("m5"), message("m6"))
+
+	// Disabling retention discards the retained messages.
+	if _, err := getHandler("PUBSUB", "RETAIN")(getHandlerFuncParams(ctx, []string{"PUBSUB", "RETAIN", channel, "0"}, nil, mockServer)); err != nil {
+		t.Fatal(err)
+	}
+	c4, r4 := subscribe([]string{"SUBSCRIBE", "REPLAY", "10", channel})
+	readMessages(c4, r4, []string{"subscribe", channel, "1"})
+	publish("m7")
+	readMessages(c4, r4, message("m7"))
+
+	errorTests := []struct {
+		name          string
+		cmd           []string
+		expectedError error
+	}{
+		{
+			name:          "Return error when the replay count is negative",
+			cmd:           []string{"SUBSCRIBE", "REPLAY", "-1", channel},
+			expectedError: errors.New("replay count must be a non-negative integer"),
+		},
+		{
+			name:          "Return error when the retain count is not an integer",
+			cmd:           []string{"PUBSUB", "RETAIN", channel, "count"},
+			expectedError: errors.New("count must be a non-negative integer"),
+		},
+		{
+			name:          "PUBSUB RETAIN command too short",
+			cmd:           []string{"PUBSUB", "RETAIN", channel},
+			expectedError: errors.New(constants.WrongArgsResponse),
+		},
+	}
+	for _, test := range errorTests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := getHandler(test.cmd[0])
+			if strings.EqualFold(test.cmd[0], "pubsub") {
+				handler = getHandler(test.cmd[0], test.cmd[1])
+			}
+			_, err := handler(getHandlerFuncParams(ctx, test.cmd, nil, mockServer))
+			if err == nil || err.Error() != test.expectedError.Error() {
+				t.Errorf("expected error \"%s\", got \"%v\"", test.expectedError.Error(), err)
+			}
+		})
+	}
+}
+
+func Test_PublishForwardedInCluster(t *testing.T) {
+	newNode := func(id string, port uint16, bootstrap bool, joinAddr string) *echovault.EchoVault {
+		server, err := echovault.NewEchoVault(
+			echovault.WithConfig(config.Config{
+				ServerID:           id,
+				BindAddr:           "127.0.0.1",
+				Port:               port,
+				RaftBindPort:       port + 1,
+				MemberListBindPort: port + 2,
+				InMemory:           true,
+				BootstrapCluster:   bootstrap,
+				JoinAddr:           joinAddr,
+				EvictionPolicy:     constants.NoEviction,
+			}),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return server
+	}
+	publisher := newNode("pubsub_node_1", 7520, true, "")
+	subscriber := newNode("pubsub_node_2", 7523, false, "127.0.0.1:7522")
+
+	channel := "cluster_channel"
+	received := make(chan []string, 16)
+	read := subscriber.Subscribe("cluster_subscriber", channel)
+	go func() {
+		for {
+			received <- read()
+		}
+	}()
+
+	// receive waits for the next message whose content satisfies match, skipping the other events.
+	receive := func(timeout time.Duration, match func(message string) bool) bool {
+		deadline := time.After(timeout)
+		for {
+			select {
+			case event := <-received:
+				if len(event) == 3 && event[0] == "message" && match(event[2]) {
+					return true
+				}
+			case <-deadline:
+				return false
+			}
+		}
+	}
+
+	// Publish until the nodes have found each other and the subscriber receives a message.
+	joined := false
+	for i := 0; i < 100 && !joined; i++ {
+		if _, err := publisher.Publish(channel, "ping"); err != nil {
+			t.Fatal(err)
+		}
+		joined = receive(100*time.Millisecond, func(message string) bool { return message == "ping" })
+	}
+	if !joined {
+		t.Fatal("expected the subscriber on the other node to receive the published messages")
+	}
+
+	// The message is larger than a UDP packet, so it cannot be gossiped.
+	large := strings.Repeat("a", 256*1024)
+	if _, err := publisher.Publish(channel, large); err != nil {
+		t.Fatal(err)
+	}
+	if !receive(5*time.Second, func(message string) bool { return message == large }) {
+		t.Error("expected the subscriber on the other node to receive the large message")
+	}
+}
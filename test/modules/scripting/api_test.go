@@ -0,0 +1,152 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scripting
+
+import (
+	"github.com/echovault/echovault/echovault"
+	"github.com/echovault/echovault/internal/config"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func createEchoVault() *echovault.EchoVault {
+	ev, _ := echovault.NewEchoVault(
+		echovault.WithConfig(config.Config{
+			DataDir: "",
+		}),
+	)
+	return ev
+}
+
+func TestEchoVault_EVAL(t *testing.T) {
+	server := createEchoVault()
+
+	tests := []struct {
+		name    string
+		script  string
+		keys    []string
+		args    []string
+		want    interface{}
+		wantErr bool
+	}{
+		{
+			name:   "1. Return an integer",
+			script: "return 1 + 1",
+			want:   2,
+		},
+		{
+			name:   "2. Return nil",
+			script: "return nil",
+			want:   nil,
+		},
+		{
+			name:   "3. Return a nested array",
+			script: "return {KEYS[1], {ARGV[1], 1}}",
+			keys:   []string{"key1"},
+			args:   []string{"arg1"},
+			want:   []interface{}{"key1", []interface{}{"arg1", 1}},
+		},
+		{
+			name:   "4. Run commands",
+			script: "redis.call('SET', KEYS[1], ARGV[1]); return redis.call('GET', KEYS[1])",
+			keys:   []string{"key2"},
+			args:   []string{"value2"},
+			want:   "value2",
+		},
+		{
+			name:    "5. Return error raised by the script",
+			script:  "error('failed')",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := server.Eval(tt.script, tt.keys, tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("EVAL() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("EVAL() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEchoVault_EVALSHA(t *testing.T) {
+	server := createEchoVault()
+
+	sha, err := server.ScriptLoad("return ARGV[1]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := server.EvalSha(sha, nil, []string{"value"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "value" {
+		t.Errorf("EVALSHA() got = %v, want %v", got, "value")
+	}
+
+	exists, err := server.ScriptExists(sha, "unknown")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(exists, []bool{true, false}) {
+		t.Errorf("SCRIPT EXISTS got = %v, want %v", exists, []bool{true, false})
+	}
+
+	if _, err = server.ScriptFlush(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = server.EvalSha(sha, nil, []string{"value"}); err == nil {
+		t.Error("EVALSHA() expected error after SCRIPT FLUSH")
+	}
+}
+
+func TestEchoVault_EVALTimeLimit(t *testing.T) {
+	server, _ := echovault.NewEchoVault(
+		echovault.WithConfig(config.Config{
+			DataDir:      "",
+			LuaTimeLimit: 100 * time.Millisecond,
+		}),
+	)
+
+	start := time.Now()
+	_, err := server.Eval("redis.call('SET', KEYS[1], 'value'); while true do end", []string{"key1"}, nil)
+	if err == nil || !strings.Contains(err.Error(), "time limit") {
+		t.Errorf("EVAL() expected time limit error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("EVAL() expected the script to be stopped after the time limit, ran for %s", elapsed)
+	}
+
+	// The commands run by the script before it was stopped are kept.
+	value, err := server.Get("key1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != "value" {
+		t.Errorf("GET() got = %v, want %v", value, "value")
+	}
+
+	// The server runs other scripts after a script is stopped.
+	got, err := server.Eval("return ARGV[1]", nil, []string{"value"})
+	if err != nil || got != "value" {
+		t.Errorf("EVAL() got = %v, %v, want %v", got, err, "value")
+	}
+}
@@ -0,0 +1,304 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scripting
+
+import (
+	"fmt"
+	"github.com/echovault/echovault/echovault"
+	"github.com/echovault/echovault/internal/config"
+	"github.com/echovault/echovault/internal/constants"
+	"github.com/tidwall/resp"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+)
+
+var mockServer *echovault.EchoVault
+var bindAddr = "localhost"
+var port uint16 = 7499
+
+func init() {
+	mockServer, _ = echovault.NewEchoVault(
+		echovault.WithConfig(config.Config{
+			BindAddr:       bindAddr,
+			Port:           port,
+			DataDir:        "",
+			EvictionPolicy: constants.NoEviction,
+		}),
+	)
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		wg.Done()
+		mockServer.Start()
+	}()
+	wg.Wait()
+}
+
+type client struct {
+	t *testing.T
+	r *resp.Conn
+}
+
+func newClient(t *testing.T) *client {
+	conn, err := net.Dial("tcp", fmt.Sprintf("%s:%d", bindAddr, port))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+	return &client{t: t, r: resp.NewConn(conn)}
+}
+
+// do sends the command and returns the reply.
+func (c *client) do(args ...string) resp.Value {
+	cmd := make([]resp.Value, len(args))
+	for i, arg := range args {
+		cmd[i] = resp.StringValue(arg)
+	}
+	if err := c.r.WriteArray(cmd); err != nil {
+		c.t.Fatal(err)
+	}
+	v, _, err := c.r.ReadValue()
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	return v
+}
+
+func Test_HandleEVAL(t *testing.T) {
+	tests := []struct {
+		name          string
+		command       []string
+		expected      string
+		expectedError string
+	}{
+		{
+			name:     "1. Return a string",
+			command:  []string{"EVAL", "return 'hello'", "0"},
+			expected: "hello",
+		},
+		{
+			name:     "2. Return an integer, truncating numbers",
+			command:  []string{"EVAL", "return 3.7", "0"},
+			expected: "3",
+		},
+		{
+			name:     "3. Return an array of the keys and arguments",
+			command:  []string{"EVAL", "return {KEYS[1], KEYS[2], ARGV[1]}", "2", "EvalKey1", "EvalKey2", "arg1"},
+			expected: "[EvalKey1 EvalKey2 arg1]",
+		},
+		{
+			name:     "4. Run commands with redis.call",
+			command:  []string{"EVAL", "redis.call('SET', KEYS[1], ARGV[1]); return redis.call('GET', KEYS[1])", "1", "EvalKey3", "value3"},
+			expected: "value3",
+		},
+		{
+			name:     "5. Convert a null reply to false",
+			command:  []string{"EVAL", "return redis.call('GET', KEYS[1]) == false", "1", "EvalKey4"},
+			expected: "1",
+		},
+		{
+			name:     "6. Return the reply of a command",
+			command:  []string{"EVAL", "return redis.call('SET', KEYS[1], 'value')", "1", "EvalKey5"},
+			expected: "OK",
+		},
+		{
+			name:          "7. Raise the error of a command called with redis.call",
+			command:       []string{"EVAL", "return redis.call('LPUSH', KEYS[1], 'element')", "1", "EvalKey5"},
+			expectedError: "LPUSH command on non-list item",
+		},
+		{
+			name:     "8. Return the error of a command called with redis.pcall",
+			command:  []string{"EVAL", "local r = redis.pcall('LPUSH', KEYS[1], 'element'); return type(r.err)", "1", "EvalKey5"},
+			expected: "string",
+		},
+		{
+			name:          "9. Return an error reply",
+			command:       []string{"EVAL", "return redis.error_reply('custom error')", "0"},
+			expectedError: "custom error",
+		},
+		{
+			name:          "10. Return a script runtime error",
+			command:       []string{"EVAL", "return nil + 1", "0"},
+			expectedError: "error running script",
+		},
+		{
+			name:          "11. Return a script syntax error",
+			command:       []string{"EVAL", "return (", "0"},
+			expectedError: "error running script",
+		},
+		{
+			name:          "12. Don't allow blocking commands in scripts",
+			command:       []string{"EVAL", "return redis.call('BLPOP', KEYS[1], 0)", "1", "EvalKey6"},
+			expectedError: "BLPOP is not allowed from scripts",
+		},
+		{
+			name:          "13. Don't allow EVAL in scripts",
+			command:       []string{"EVAL", "return redis.call('EVAL', 'return 1', 0)", "0"},
+			expectedError: "EVAL is not allowed from scripts",
+		},
+		{
+			name:          "14. Return error when numkeys is greater than the number of arguments",
+			command:       []string{"EVAL", "return 1", "2", "EvalKey7"},
+			expectedError: "number of keys can't be greater than number of args",
+		},
+		{
+			name:          "15. Return error when numkeys is negative",
+			command:       []string{"EVAL", "return 1", "-1"},
+			expectedError: "number of keys can't be negative",
+		},
+		{
+			name:          "16. Command too short",
+			command:       []string{"EVAL", "return 1"},
+			expectedError: constants.WrongArgsResponse,
+		},
+		{
+			name:          "17. Don't allow access to the file system",
+			command:       []string{"EVAL", "return io.open('/etc/hosts')", "0"},
+			expectedError: "error running script",
+		},
+	}
+
+	c := newClient(t)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res := c.do(test.command...)
+			if test.expectedError != "" {
+				if res.Type() != resp.Error || !strings.Contains(res.Error().Error(), test.expectedError) {
+					t.Errorf("expected error containing \"%s\", got \"%s\"", test.expectedError, res.String())
+				}
+				return
+			}
+			if res.Type() == resp.Error {
+				t.Errorf("expected reply \"%s\", got error \"%s\"", test.expected, res.Error())
+				return
+			}
+			got := res.String()
+			if res.Type() == resp.Array {
+				elems := make([]string, len(res.Array()))
+				for i, elem := range res.Array() {
+					elems[i] = elem.String()
+				}
+				got = fmt.Sprintf("[%s]", strings.Join(elems, " "))
+			}
+			if got != test.expected {
+				t.Errorf("expected reply \"%s\", got \"%s\"", test.expected, got)
+			}
+		})
+	}
+}
+
+func Test_HandleEVALSHA(t *testing.T) {
+	c := newClient(t)
+	script := "return redis.call('SET', KEYS[1], ARGV[1])"
+
+	sha := c.do("SCRIPT", "LOAD", script).String()
+	if len(sha) != 40 {
+		t.Fatalf("expected a 40 character SHA1 digest, got \"%s\"", sha)
+	}
+
+	if res := c.do("EVALSHA", sha, "1", "EvalShaKey1", "value1"); res.String() != "OK" {
+		t.Errorf("expected reply \"OK\", got \"%s\"", res.String())
+	}
+	if res := c.do("GET", "EvalShaKey1"); res.String() != "value1" {
+		t.Errorf("expected reply \"value1\", got \"%s\"", res.String())
+	}
+	// The digest is case-insensitive.
+	if res := c.do("EVALSHA", strings.ToUpper(sha), "1", "EvalShaKey1", "value2"); res.String() != "OK" {
+		t.Errorf("expected reply \"OK\", got \"%s\"", res.String())
+	}
+
+	// EVAL caches the script too.
+	c.do("EVAL", "return 'cached'", "0")
+	exists := c.do("SCRIPT", "EXISTS", sha, "0000000000000000000000000000000000000000", sha1hex(t, c, "return 'cached'"))
+	if len(exists.Array()) != 3 || exists.Array()[0].Integer() != 1 || exists.Array()[1].Integer() != 0 ||
+		exists.Array()[2].Integer() != 1 {
+		t.Errorf("expected [1 0 1], got %s", exists.String())
+	}
+
+	if res := c.do("SCRIPT", "FLUSH"); res.String() != "OK" {
+		t.Errorf("expected reply \"OK\", got \"%s\"", res.String())
+	}
+	res := c.do("EVALSHA", sha, "1", "EvalShaKey1", "value3")
+	if res.Type() != resp.Error || !strings.Contains(res.Error().Error(), "NOSCRIPT") {
+		t.Errorf("expected NOSCRIPT error, got \"%s\"", res.String())
+	}
+
+	res = c.do("SCRIPT", "FLUSH", "LATER")
+	if res.Type() != resp.Error || !strings.Contains(res.Error().Error(), "unknown flush mode LATER") {
+		t.Errorf("expected unknown flush mode error, got \"%s\"", res.String())
+	}
+}
+
+// sha1hex returns the digest of the script, as computed by redis.sha1hex.
+func sha1hex(t *testing.T, c *client, script string) string {
+	res := c.do("EVAL", "return redis.sha1hex(ARGV[1])", "0", script)
+	if res.Type() == resp.Error {
+		t.Fatal(res.Error())
+	}
+	return res.String()
+}
+
+func Test_EVALAtomicity(t *testing.T) {
+	writer := newClient(t)
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				writer.do("SET", "EvalAtomicKey1", "writer")
+			}
+		}
+	}()
+
+	c := newClient(t)
+	script := `
+redis.call('SET', KEYS[1], 'script')
+for i = 1, 100 do
+	if redis.call('GET', KEYS[1]) ~= 'script' then
+		return 0
+	end
+end
+return 1`
+	for i := 0; i < 20; i++ {
+		if res := c.do("EVAL", script, "1", "EvalAtomicKey1"); res.Integer() != 1 {
+			t.Errorf("expected the script to run with no other command in between, got %s", res.String())
+			break
+		}
+	}
+	close(done)
+	wg.Wait()
+}
+
+func Test_EVALInTransaction(t *testing.T) {
+	c := newClient(t)
+	c.do("MULTI")
+	if res := c.do("EVAL", "return redis.call('SET', KEYS[1], ARGV[1])", "1", "EvalTxKey1", "value1"); res.String() != "QUEUED" {
+		t.Fatalf("expected reply \"QUEUED\", got \"%s\"", res.String())
+	}
+	c.do("GET", "EvalTxKey1")
+	res := c.do("EXEC")
+	if len(res.Array()) != 2 || res.Array()[0].String() != "OK" || res.Array()[1].String() != "value1" {
+		t.Errorf("expected [OK value1], got %s", res.String())
+	}
+}
@@ -16,6 +16,7 @@ package set
 
 import (
 	"context"
+	"fmt"
 	"github.com/echovault/echovault/echovault"
 	"github.com/echovault/echovault/internal/config"
 	"github.com/echovault/echovault/internal/modules/set"
@@ -743,6 +744,82 @@ func TestEchoVault_SMEMBERS(t *testing.T) {
 	}
 }
 
+func TestEchoVault_SMembersIter(t *testing.T) {
+	server := createEchoVault()
+
+	tests := []struct {
+		name        string
+		presetValue interface{}
+		key         string
+		stopAfter   int
+		want        []string
+		wantCount   int
+		wantErr     bool
+	}{
+		{
+			name:        "Visit all the members of the set",
+			key:         "key1",
+			presetValue: set.NewSet([]string{"one", "two", "three", "four", "five"}),
+			want:        []string{"one", "two", "three", "four", "five"},
+			wantCount:   5,
+			wantErr:     false,
+		},
+		{
+			name:        "Stop the iteration when the callback returns false",
+			key:         "key2",
+			presetValue: set.NewSet([]string{"one", "two", "three", "four", "five"}),
+			stopAfter:   2,
+			want:        []string{"one", "two", "three", "four", "five"},
+			wantCount:   2,
+			wantErr:     false,
+		},
+		{
+			name:        "If the key does not exist, visit no members",
+			key:         "key3",
+			presetValue: nil,
+			want:        []string{},
+			wantCount:   0,
+			wantErr:     false,
+		},
+		{
+			name:        "Throw error when the provided key is not a set",
+			key:         "key4",
+			presetValue: "Default value",
+			want:        []string{},
+			wantCount:   0,
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.presetValue != nil {
+				err := presetValue(server, context.Background(), tt.key, tt.presetValue)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+			}
+			var got []string
+			err := server.SMembersIter(tt.key, func(member string) bool {
+				got = append(got, member)
+				return tt.stopAfter == 0 || len(got) < tt.stopAfter
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SMembersIter() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if len(got) != tt.wantCount {
+				t.Errorf("SMembersIter() visited %d members, want %d", len(got), tt.wantCount)
+			}
+			for _, g := range got {
+				if !slices.Contains(tt.want, g) {
+					t.Errorf("SMembersIter() got = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
 func TestEchoVault_SMISMEMBER(t *testing.T) {
 	server := createEchoVault()
 
@@ -1232,3 +1309,33 @@ func TestEchoVault_SUNIONSTORE(t *testing.T) {
 		})
 	}
 }
+
+func TestEchoVault_SSCAN(t *testing.T) {
+	server := createEchoVault()
+
+	var want []string
+	for i := 0; i < 25; i++ {
+		want = append(want, fmt.Sprintf("member%d", i))
+	}
+	if err := presetValue(server, context.Background(), "key1", set.NewSet(want)); err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(want)
+
+	var got []string
+	var cursor uint64
+	for {
+		next, members, err := server.SScan("key1", cursor, echovault.SScanOptions{Count: 3})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, members...)
+		if cursor = next; cursor == 0 {
+			break
+		}
+	}
+	slices.Sort(got)
+	if !slices.Equal(got, want) {
+		t.Errorf("SSCAN() got = %v, want %v", got, want)
+	}
+}
@@ -84,6 +84,10 @@ func getHandlerFuncParams(ctx context.Context, cmd []string, conn *net.Conn) int
 		KeyRUnlock:       mockServer.KeyRUnlock,
 		GetValue:         mockServer.GetValue,
 		SetValue:         mockServer.SetValue,
+		DeleteKey:        mockServer.DeleteKey,
+		GetConfigParameters: func() map[string]string {
+			return map[string]string{"protocol-compat": constants.ProtocolCompatEchoVault}
+		},
 	}
 }
 
@@ -699,6 +703,7 @@ func Test_HandleSINTERCARD(t *testing.T) {
 		name             string
 		preset           bool
 		presetValues     map[string]interface{}
+		protocolCompat   string
 		command          []string
 		expectedResponse int
 		expectedError    error
@@ -780,6 +785,46 @@ func Test_HandleSINTERCARD(t *testing.T) {
 			expectedResponse: 0,
 			expectedError:    errors.New(constants.WrongArgsResponse),
 		},
+		{
+			name:   "8. Get the intersect cardinality with numkeys and a limit in redis-7 protocol-compat",
+			preset: true,
+			presetValues: map[string]interface{}{
+				"SinterCardKey17": set.NewSet([]string{"one", "two", "three", "four", "five"}),
+				"limit":           set.NewSet([]string{"two", "three", "four", "six"}),
+			},
+			protocolCompat:   constants.ProtocolCompatRedis7,
+			command:          []string{"SINTERCARD", "2", "SinterCardKey17", "limit", "LIMIT", "2"},
+			expectedResponse: 2,
+			expectedError:    nil,
+		},
+		{
+			name:   "9. Get the intersect cardinality with numkeys in redis-7 protocol-compat",
+			preset: true,
+			presetValues: map[string]interface{}{
+				"SinterCardKey18": set.NewSet([]string{"one", "two", "three"}),
+				"SinterCardKey19": set.NewSet([]string{"two", "three", "four"}),
+			},
+			protocolCompat:   constants.ProtocolCompatRedis7,
+			command:          []string{"SINTERCARD", "2", "SinterCardKey18", "SinterCardKey19"},
+			expectedResponse: 2,
+			expectedError:    nil,
+		},
+		{
+			name:             "10. Throw error when numkeys is missing in redis-7 protocol-compat",
+			preset:           false,
+			protocolCompat:   constants.ProtocolCompatRedis7,
+			command:          []string{"SINTERCARD", "SinterCardKey20", "SinterCardKey21"},
+			expectedResponse: 0,
+			expectedError:    errors.New("numkeys should be greater than 0"),
+		},
+		{
+			name:             "11. Throw error when numkeys is greater than the number of keys in redis-7 protocol-compat",
+			preset:           false,
+			protocolCompat:   constants.ProtocolCompatRedis7,
+			command:          []string{"SINTERCARD", "3", "SinterCardKey20", "SinterCardKey21"},
+			expectedResponse: 0,
+			expectedError:    errors.New("number of keys can't be greater than number of args"),
+		},
 	}
 
 	for i, test := range tests {
@@ -804,7 +849,14 @@ func Test_HandleSINTERCARD(t *testing.T) {
 				return
 			}
 
-			res, err := handler(getHandlerFuncParams(ctx, test.command, nil))
+			params := getHandlerFuncParams(ctx, test.command, nil)
+			if test.protocolCompat != "" {
+				params.GetConfigParameters = func() map[string]string {
+					return map[string]string{"protocol-compat": test.protocolCompat}
+				}
+			}
+
+			res, err := handler(params)
 
 			if test.expectedError != nil {
 				if err.Error() != test.expectedError.Error() {
@@ -907,9 +959,23 @@ func Test_HandleSINTERSTORE(t *testing.T) {
 			expectedError:    nil,
 		},
 		{
-			name:             "6. Command too short",
+			name:   "6. Delete the destination key when the intersection is empty.",
+			preset: true,
+			presetValues: map[string]interface{}{
+				"SinterStoreKey14":        set.NewSet([]string{"one", "two"}),
+				"SinterStoreKey15":        set.NewSet([]string{"three", "four"}),
+				"SinterStoreDestination6": set.NewSet([]string{"one"}),
+			},
+			destination:      "SinterStoreDestination6",
+			command:          []string{"SINTERSTORE", "SinterStoreDestination6", "SinterStoreKey14", "SinterStoreKey15"},
+			expectedValue:    nil,
+			expectedResponse: 0,
+			expectedError:    nil,
+		},
+		{
+			name:             "7. Command too short",
 			preset:           false,
-			command:          []string{"SINTERSTORE", "SinterStoreDestination6"},
+			command:          []string{"SINTERSTORE", "SinterStoreDestination7"},
 			expectedResponse: 0,
 			expectedError:    errors.New(constants.WrongArgsResponse),
 		},
@@ -954,6 +1020,9 @@ func Test_HandleSINTERSTORE(t *testing.T) {
 			if rv.Integer() != test.expectedResponse {
 				t.Errorf("expected response integer %d, got %d", test.expectedResponse, rv.Integer())
 			}
+			if test.expectedValue == nil && mockServer.KeyExists(ctx, test.destination) {
+				t.Errorf("expected key %s to be deleted", test.destination)
+			}
 			if test.expectedValue != nil {
 				if _, err = mockServer.KeyRLock(ctx, test.destination); err != nil {
 					t.Error(err)
@@ -1946,7 +2015,19 @@ func Test_HandleSUNIONSTORE(t *testing.T) {
 			expectedError:    errors.New("value at key SunionStoreKey7 is not a set"),
 		},
 		{
-			name:             "5. Command too short",
+			name:   "5. Delete the destination key when none of the keys exist.",
+			preset: true,
+			presetValues: map[string]interface{}{
+				"SunionStoreDestination5": set.NewSet([]string{"one"}),
+			},
+			destination:      "SunionStoreDestination5",
+			command:          []string{"SUNIONSTORE", "SunionStoreDestination5", "non-existent1", "non-existent2"},
+			expectedValue:    nil,
+			expectedResponse: 0,
+			expectedError:    nil,
+		},
+		{
+			name:             "6. Command too short",
 			preset:           false,
 			command:          []string{"SUNIONSTORE", "SunionStoreDestination6"},
 			expectedResponse: 0,
@@ -1994,6 +2075,9 @@ func Test_HandleSUNIONSTORE(t *testing.T) {
 			if rv.Integer() != test.expectedResponse {
 				t.Errorf("expected response integer %d, got %d", test.expectedResponse, rv.Integer())
 			}
+			if test.expectedValue == nil && mockServer.KeyExists(ctx, test.destination) {
+				t.Errorf("expected key %s to be deleted", test.destination)
+			}
 			if test.expectedValue != nil {
 				if _, err = mockServer.KeyRLock(ctx, test.destination); err != nil {
 					t.Error(err)
@@ -2012,3 +2096,183 @@ func Test_HandleSUNIONSTORE(t *testing.T) {
 		})
 	}
 }
+
+func Test_SetClone(t *testing.T) {
+	original := set.NewSet([]string{"one", "two", "three"})
+	clone, ok := original.Clone().(*set.Set)
+	if !ok {
+		t.Error("expected clone to be a set")
+		return
+	}
+
+	// Mutating the original set must not affect the clone.
+	original.Add([]string{"four"})
+	original.Remove([]string{"one"})
+
+	if clone.Cardinality() != 3 {
+		t.Errorf("expected clone cardinality to be 3, got %d", clone.Cardinality())
+	}
+	for _, elem := range []string{"one", "two", "three"} {
+		if !clone.Contains(elem) {
+			t.Errorf("expected clone to contain element %s", elem)
+		}
+	}
+	if clone.Contains("four") {
+		t.Error("expected clone not to contain element four")
+	}
+}
+
+func Test_HandleSSCAN(t *testing.T) {
+	ctx := context.WithValue(context.Background(), "test_name", "SSCAN")
+
+	key := "SScanKey1"
+	if _, err := mockServer.CreateKeyAndLock(ctx, key); err != nil {
+		t.Fatal(err)
+	}
+	members := make([]string, 20)
+	for i := range members {
+		members[i] = fmt.Sprintf("member%d", i)
+	}
+	if err := mockServer.SetValue(ctx, key, set.NewSet(members)); err != nil {
+		t.Fatal(err)
+	}
+	mockServer.KeyUnlock(ctx, key)
+
+	wrongTypeKey := "SScanKey2"
+	if _, err := mockServer.CreateKeyAndLock(ctx, wrongTypeKey); err != nil {
+		t.Fatal(err)
+	}
+	if err := mockServer.SetValue(ctx, wrongTypeKey, "default value"); err != nil {
+		t.Fatal(err)
+	}
+	mockServer.KeyUnlock(ctx, wrongTypeKey)
+
+	// scan iterates the set with the options until the returned cursor is 0.
+	scan := func(options ...string) ([]string, error) {
+		var elems []string
+		cursor := "0"
+		for i := 0; ; i++ {
+			if i > 1000 {
+				return nil, errors.New("scan did not complete")
+			}
+			command := append([]string{"SSCAN", key, cursor}, options...)
+			res, err := getHandler("SSCAN")(getHandlerFuncParams(ctx, command, nil))
+			if err != nil {
+				return nil, err
+			}
+			rv, _, err := resp.NewReader(bytes.NewReader(res)).ReadValue()
+			if err != nil {
+				return nil, err
+			}
+			for _, v := range rv.Array()[1].Array() {
+				elems = append(elems, v.String())
+			}
+			cursor = rv.Array()[0].String()
+			if cursor == "0" {
+				break
+			}
+		}
+		return elems, nil
+	}
+
+	t.Run("1. Iterate all the members exactly once", func(t *testing.T) {
+		elems, err := scan("COUNT", "3")
+		if err != nil {
+			t.Fatal(err)
+		}
+		sorted := slices.Clone(elems)
+		slices.Sort(sorted)
+		if len(elems) != 20 || len(slices.Compact(sorted)) != 20 {
+			t.Errorf("expected 20 distinct members, got %v", elems)
+		}
+	})
+
+	t.Run("2. Only return the members that match the pattern", func(t *testing.T) {
+		elems, err := scan("MATCH", "member1*", "COUNT", "4")
+		if err != nil {
+			t.Fatal(err)
+		}
+		matched := slices.Clone(elems)
+		slices.Sort(matched)
+		expected := []string{
+			"member1", "member10", "member11", "member12", "member13",
+			"member14", "member15", "member16", "member17", "member18", "member19",
+		}
+		if !slices.Equal(matched, expected) {
+			t.Errorf("expected members %v, got %v", expected, matched)
+		}
+	})
+
+	t.Run("3. Return an empty scan for a non-existent key", func(t *testing.T) {
+		res, err := getHandler("SSCAN")(getHandlerFuncParams(ctx, []string{"SSCAN", "SScanKey3", "0"}, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(res, []byte("*2\r\n$1\r\n0\r\n*0\r\n")) {
+			t.Errorf("expected empty scan response, got %q", res)
+		}
+	})
+
+	errorTests := []struct {
+		name          string
+		command       []string
+		expectedError error
+	}{
+		{
+			name:          "4. Return error when the value is not a set",
+			command:       []string{"SSCAN", wrongTypeKey, "0"},
+			expectedError: errors.New("value at key SScanKey2 is not a set"),
+		},
+		{
+			name:          "5. Return error when the cursor is not an unsigned integer",
+			command:       []string{"SSCAN", key, "cursor"},
+			expectedError: errors.New("invalid cursor"),
+		},
+		{
+			name:          "6. Return error when TYPE option is passed",
+			command:       []string{"SSCAN", key, "0", "TYPE", "string"},
+			expectedError: errors.New("syntax error"),
+		},
+		{
+			name:          "7. Command too short",
+			command:       []string{"SSCAN", key},
+			expectedError: errors.New(constants.WrongArgsResponse),
+		},
+	}
+	for _, test := range errorTests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := getHandler("SSCAN")(getHandlerFuncParams(ctx, test.command, nil))
+			if err == nil || test.expectedError.Error() != err.Error() {
+				t.Errorf("expected error \"%s\", got \"%v\"", test.expectedError.Error(), err)
+			}
+		})
+	}
+}
+
+func Benchmark_HandleSMEMBERS(b *testing.B) {
+	ctx := context.WithValue(context.Background(), "test_name", "SMEMBERS benchmark")
+	key := "SmembersBenchmarkKey"
+
+	members := make([]string, 100000)
+	for i := range members {
+		members[i] = fmt.Sprintf("member%d", i)
+	}
+	if _, err := mockServer.CreateKeyAndLock(ctx, key); err != nil {
+		b.Fatal(err)
+	}
+	if err := mockServer.SetValue(ctx, key, set.NewSet(members)); err != nil {
+		b.Fatal(err)
+	}
+	mockServer.KeyUnlock(ctx, key)
+
+	handler := getHandler("SMEMBERS")
+	command := []string{"SMEMBERS", key}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := handler(getHandlerFuncParams(ctx, command, nil)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
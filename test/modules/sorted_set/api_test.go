@@ -16,6 +16,7 @@ package sorted_set
 
 import (
 	"context"
+	"fmt"
 	"github.com/echovault/echovault/echovault"
 	"github.com/echovault/echovault/internal"
 	"github.com/echovault/echovault/internal/config"
@@ -24,6 +25,7 @@ import (
 	"reflect"
 	"strconv"
 	"testing"
+	"time"
 )
 
 func createEchoVault() *echovault.EchoVault {
@@ -1755,12 +1757,12 @@ func TestEchoVault_ZMSCORE(t *testing.T) {
 			wantErr: false,
 		},
 		{
-			name:        "If key does not exist, return empty array",
+			name:        "If key does not exist, return nil for every member",
 			preset:      false,
 			presetValue: nil,
 			key:         "key2",
 			members:     []string{"one", "two", "three", "four"},
-			want:        []interface{}{},
+			want:        []interface{}{nil, nil, nil, nil},
 			wantErr:     false,
 		},
 		{
@@ -1807,6 +1809,50 @@ func TestEchoVault_ZMSCORE(t *testing.T) {
 	}
 }
 
+func TestEchoVault_ZMScoreFloat(t *testing.T) {
+	server := createEchoVault()
+
+	if err := presetValue(server, context.Background(), "ZMScoreFloatKey", ss.NewSortedSet([]ss.MemberParam{
+		{Value: "one", Score: 1.1}, {Value: "inf", Score: ss.Score(math.Inf(1))}, {Value: "ninf", Score: ss.Score(math.Inf(-1))},
+	})); err != nil {
+		t.Error(err)
+		return
+	}
+
+	scores, exists, err := server.ZMScoreFloat("ZMScoreFloatKey", "one", "none", "inf", "ninf")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	wantScores := []float64{1.1, 0, math.Inf(1), math.Inf(-1)}
+	wantExists := []bool{true, false, true, true}
+	if !reflect.DeepEqual(scores, wantScores) || !reflect.DeepEqual(exists, wantExists) {
+		t.Errorf("ZMScoreFloat() got %v %v, want %v %v", scores, exists, wantScores, wantExists)
+	}
+
+	score, ok, err := server.ZScoreFloat("ZMScoreFloatKey", "inf")
+	if err != nil || !ok || !math.IsInf(score, 1) {
+		t.Errorf("ZScoreFloat() got %v %v %v, want +Inf true <nil>", score, ok, err)
+	}
+	score, ok, err = server.ZScoreFloat("ZMScoreFloatKey", "none")
+	if err != nil || ok || score != 0 {
+		t.Errorf("ZScoreFloat() got %v %v %v, want 0 false <nil>", score, ok, err)
+	}
+
+	scores, exists, err = server.ZMScoreFloat("ZMScoreFloatMissing", "one", "two")
+	if err != nil || !reflect.DeepEqual(scores, []float64{0, 0}) || !reflect.DeepEqual(exists, []bool{false, false}) {
+		t.Errorf("ZMScoreFloat() on missing key got %v %v %v, want [0 0] [false false] <nil>", scores, exists, err)
+	}
+
+	if err = presetValue(server, context.Background(), "ZMScoreFloatString", "value"); err != nil {
+		t.Error(err)
+		return
+	}
+	if _, _, err = server.ZMScoreFloat("ZMScoreFloatString", "one"); err == nil {
+		t.Error("ZMScoreFloat() expected error for a key that is not a sorted set")
+	}
+}
+
 func TestEchoVault_ZPOP(t *testing.T) {
 	server := createEchoVault()
 
@@ -1913,6 +1959,77 @@ func TestEchoVault_ZPOP(t *testing.T) {
 	}
 }
 
+func TestEchoVault_BZPOP(t *testing.T) {
+	server := createEchoVault()
+
+	t.Run("Pop immediately from a non-empty sorted set", func(t *testing.T) {
+		err := presetValue(server, context.Background(), "BZPopKey1", ss.NewSortedSet([]ss.MemberParam{
+			{Value: "one", Score: 1}, {Value: "two", Score: 2},
+		}))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		got, err := server.BZPopMax(1, "BZPopKey0", "BZPopKey1")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if want := []string{"BZPopKey1", "two", "2"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("BZPOPMAX() got = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("Block until a member is added", func(t *testing.T) {
+		go func() {
+			<-time.After(50 * time.Millisecond)
+			if _, err := server.ZAdd("BZPopKey2", map[string]float64{"one": 1, "two": 2}, echovault.ZAddOptions{}); err != nil {
+				t.Error(err)
+			}
+		}()
+		got, err := server.BZPopMin(5, "BZPopKey2")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if want := []string{"BZPopKey2", "one", "1"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("BZPOPMIN() got = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("Pop count members in order with BZMPop", func(t *testing.T) {
+		err := presetValue(server, context.Background(), "BZPopKey3", ss.NewSortedSet([]ss.MemberParam{
+			{Value: "one", Score: 1}, {Value: "two", Score: 2}, {Value: "three", Score: 3},
+		}))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		key, got, err := server.BZMPop(1, []string{"BZPopKey3"}, echovault.ZMPopOptions{Min: true, Count: 2})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if key != "BZPopKey3" {
+			t.Errorf("BZMPOP() key = %v, want %v", key, "BZPopKey3")
+		}
+		if want := [][]string{{"one", "1"}, {"two", "2"}}; !reflect.DeepEqual(got, want) {
+			t.Errorf("BZMPOP() got = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("Return an empty slice when the timeout expires", func(t *testing.T) {
+		got, err := server.BZPopMin(0.1, "BZPopKey4")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if len(got) != 0 {
+			t.Errorf("BZPOPMIN() got = %v, want empty slice", got)
+		}
+	})
+}
+
 func TestEchoVault_ZRANDMEMBER(t *testing.T) {
 	server := createEchoVault()
 
@@ -2150,6 +2267,169 @@ func TestEchoVault_ZRANGE(t *testing.T) {
 	}
 }
 
+func TestEchoVault_ZRANGEBYSCORE(t *testing.T) {
+	server := createEchoVault()
+
+	set := func() *ss.SortedSet {
+		return ss.NewSortedSet([]ss.MemberParam{
+			{Value: "one", Score: 1}, {Value: "two", Score: 2},
+			{Value: "three", Score: 3}, {Value: "four", Score: 4},
+			{Value: "five", Score: 5}, {Value: "six", Score: 6},
+		})
+	}
+
+	tests := []struct {
+		name        string
+		presetValue interface{}
+		key         string
+		reverse     bool
+		start       string
+		stop        string
+		options     echovault.ZRangeByScoreOptions
+		want        [][]string
+		wantErr     bool
+	}{
+		{
+			name:        "Get members within score range",
+			presetValue: set(),
+			key:         "ZRangeByScoreKey1",
+			start:       "2",
+			stop:        "(5",
+			want:        [][]string{{"two"}, {"three"}, {"four"}},
+		},
+		{
+			name:        "Get members with scores, offset and count",
+			presetValue: set(),
+			key:         "ZRangeByScoreKey2",
+			start:       "-inf",
+			stop:        "+inf",
+			options:     echovault.ZRangeByScoreOptions{WithScores: true, Offset: 1, Count: 2},
+			want:        [][]string{{"two", "2"}, {"three", "3"}},
+		},
+		{
+			name:        "Get members in descending order with ZRevRangeByScore",
+			presetValue: set(),
+			key:         "ZRangeByScoreKey3",
+			reverse:     true,
+			start:       "5",
+			stop:        "2",
+			want:        [][]string{{"five"}, {"four"}, {"three"}, {"two"}},
+		},
+		{
+			name:        "Throw error when the key does not hold a sorted set",
+			presetValue: "Default value",
+			key:         "ZRangeByScoreKey4",
+			start:       "1",
+			stop:        "5",
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := presetValue(server, context.Background(), tt.key, tt.presetValue); err != nil {
+				t.Error(err)
+				return
+			}
+			var got [][]string
+			var err error
+			if tt.reverse {
+				got, err = server.ZRevRangeByScore(tt.key, tt.start, tt.stop, tt.options)
+			} else {
+				got, err = server.ZRangeByScore(tt.key, tt.start, tt.stop, tt.options)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ZRANGEBYSCORE() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ZRANGEBYSCORE() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEchoVault_ZRANGEBYLEX(t *testing.T) {
+	server := createEchoVault()
+
+	set := func() *ss.SortedSet {
+		return ss.NewSortedSet([]ss.MemberParam{
+			{Value: "a", Score: 1}, {Value: "b", Score: 1},
+			{Value: "c", Score: 1}, {Value: "d", Score: 1},
+			{Value: "e", Score: 1}, {Value: "f", Score: 1},
+		})
+	}
+
+	tests := []struct {
+		name        string
+		presetValue interface{}
+		key         string
+		reverse     bool
+		start       string
+		stop        string
+		options     echovault.ZRangeByLexOptions
+		want        []string
+	}{
+		{
+			name:        "Get members within lex range",
+			presetValue: set(),
+			key:         "ZRangeByLexKey1",
+			start:       "[b",
+			stop:        "(e",
+			want:        []string{"b", "c", "d"},
+		},
+		{
+			name:        "Get members with offset and count",
+			presetValue: set(),
+			key:         "ZRangeByLexKey2",
+			start:       "-",
+			stop:        "+",
+			options:     echovault.ZRangeByLexOptions{Offset: 2, Count: 2},
+			want:        []string{"c", "d"},
+		},
+		{
+			name:        "Get members in reverse order with ZRevRangeByLex",
+			presetValue: set(),
+			key:         "ZRangeByLexKey3",
+			reverse:     true,
+			start:       "+",
+			stop:        "[d",
+			want:        []string{"f", "e", "d"},
+		},
+		{
+			name: "Return empty result when the members have different scores",
+			presetValue: ss.NewSortedSet([]ss.MemberParam{
+				{Value: "a", Score: 1}, {Value: "b", Score: 2},
+			}),
+			key:   "ZRangeByLexKey4",
+			start: "-",
+			stop:  "+",
+			want:  []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := presetValue(server, context.Background(), tt.key, tt.presetValue); err != nil {
+				t.Error(err)
+				return
+			}
+			var got []string
+			var err error
+			if tt.reverse {
+				got, err = server.ZRevRangeByLex(tt.key, tt.start, tt.stop, tt.options)
+			} else {
+				got, err = server.ZRangeByLex(tt.key, tt.start, tt.stop, tt.options)
+			}
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ZRANGEBYLEX() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestEchoVault_ZRANGESTORE(t *testing.T) {
 	server := createEchoVault()
 
@@ -2205,7 +2485,7 @@ func TestEchoVault_ZRANGESTORE(t *testing.T) {
 		},
 		{
 			// Get elements within score range with offset and limit.
-			// Offset and limit are in where we start and stop counting in the original sorted set (NOT THE RESULT).
+			// Offset and limit are applied to the members within the range.
 			name:   "Get elements within score range with offset and limit",
 			preset: true,
 			presetValues: map[string]interface{}{
@@ -2264,7 +2544,7 @@ func TestEchoVault_ZRANGESTORE(t *testing.T) {
 		},
 		{
 			// Get elements within lex range with offset and limit.
-			// Offset and limit are in where we start and stop counting in the original sorted set (NOT THE RESULT).
+			// Offset and limit are applied to the members within the range.
 			name:   "Get elements within lex range with offset and limit",
 			preset: true,
 			presetValues: map[string]interface{}{
@@ -2280,13 +2560,12 @@ func TestEchoVault_ZRANGESTORE(t *testing.T) {
 			start:       "a",
 			stop:        "h",
 			options:     echovault.ZRangeStoreOptions{WithScores: true, ByLex: true, Offset: 2, Count: 4},
-			want:        3,
+			want:        4,
 			wantErr:     false,
 		},
 		{
 			// Get elements within lex range with offset and limit + reverse the results.
-			// Offset and limit are in where we start and stop counting in the original sorted set (NOT THE RESULT).
-			// REV reverses the original set before getting the range.
+			// With Rev, start is the maximum value and stop is the minimum value.
 			name:   "Get elements within lex range with offset and limit + reverse the results",
 			preset: true,
 			presetValues: map[string]interface{}{
@@ -2299,10 +2578,10 @@ func TestEchoVault_ZRANGESTORE(t *testing.T) {
 			},
 			destination: "destination7",
 			source:      "key7",
-			start:       "a",
-			stop:        "h",
-			options:     echovault.ZRangeStoreOptions{WithScores: true, ByLex: true, Offset: 2, Count: 4},
-			want:        3,
+			start:       "h",
+			stop:        "a",
+			options:     echovault.ZRangeStoreOptions{ByLex: true, Rev: true, Offset: 2, Count: 4},
+			want:        4,
 			wantErr:     false,
 		},
 		{
@@ -2324,6 +2603,23 @@ func TestEchoVault_ZRANGESTORE(t *testing.T) {
 			want:        0,
 			wantErr:     false,
 		},
+		{
+			name:   "Get elements within rank range in reverse order",
+			preset: true,
+			presetValues: map[string]interface{}{
+				"key10": ss.NewSortedSet([]ss.MemberParam{
+					{Value: "one", Score: 1}, {Value: "two", Score: 2},
+					{Value: "three", Score: 3}, {Value: "four", Score: 4},
+				}),
+			},
+			destination: "destination10",
+			source:      "key10",
+			start:       "0",
+			stop:        "2",
+			options:     echovault.ZRangeStoreOptions{Rev: true},
+			want:        3,
+			wantErr:     false,
+		},
 		{
 			name:   "Throw error when the key does not hold a sorted set",
 			preset: true,
@@ -2598,6 +2894,143 @@ func TestEchoVault_ZREMRANGEBYSCORE(t *testing.T) {
 	}
 }
 
+func TestEchoVault_ZREMRANGEBYRANK(t *testing.T) {
+	server := createEchoVault()
+
+	tests := []struct {
+		name        string
+		preset      bool
+		presetValue interface{}
+		key         string
+		start       int
+		stop        int
+		want        int
+		wantErr     bool
+	}{
+		{
+			name:   "Successfully remove multiple elements within the provided rank range",
+			preset: true,
+			presetValue: ss.NewSortedSet([]ss.MemberParam{
+				{Value: "one", Score: 1}, {Value: "two", Score: 2},
+				{Value: "three", Score: 3}, {Value: "four", Score: 4},
+				{Value: "five", Score: 5},
+			}),
+			key:     "key1",
+			start:   1,
+			stop:    -2,
+			want:    3,
+			wantErr: false,
+		},
+		{
+			name:    "If key does not exist, return 0",
+			preset:  false,
+			key:     "key2",
+			start:   0,
+			stop:    2,
+			want:    0,
+			wantErr: false,
+		},
+		{
+			name:   "Return error when the indices are out of bounds",
+			preset: true,
+			presetValue: ss.NewSortedSet([]ss.MemberParam{
+				{Value: "one", Score: 1}, {Value: "two", Score: 2},
+			}),
+			key:     "key3",
+			start:   0,
+			stop:    5,
+			want:    0,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.preset {
+				err := presetValue(server, context.Background(), tt.key, tt.presetValue)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+			}
+			got, err := server.ZRemRangeByRank(tt.key, tt.start, tt.stop)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ZREMRANGEBYRANK() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ZREMRANGEBYRANK() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEchoVault_ZREMRANGEBYLEX(t *testing.T) {
+	server := createEchoVault()
+
+	tests := []struct {
+		name        string
+		preset      bool
+		presetValue interface{}
+		key         string
+		min         string
+		max         string
+		want        int
+		wantErr     bool
+	}{
+		{
+			name:   "Successfully remove the elements within the provided lex range",
+			preset: true,
+			presetValue: ss.NewSortedSet([]ss.MemberParam{
+				{Value: "a", Score: 1}, {Value: "b", Score: 1},
+				{Value: "c", Score: 1}, {Value: "d", Score: 1},
+			}),
+			key:     "key1",
+			min:     "[b",
+			max:     "+",
+			want:    3,
+			wantErr: false,
+		},
+		{
+			name:    "If key does not exist, return 0",
+			preset:  false,
+			key:     "key2",
+			min:     "-",
+			max:     "+",
+			want:    0,
+			wantErr: false,
+		},
+		{
+			name:        "Return error key is not a sorted set",
+			preset:      true,
+			presetValue: "Default value",
+			key:         "key3",
+			min:         "-",
+			max:         "+",
+			want:        0,
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.preset {
+				err := presetValue(server, context.Background(), tt.key, tt.presetValue)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+			}
+			got, err := server.ZRemRangeByLex(tt.key, tt.min, tt.max)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ZREMRANGEBYLEX() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ZREMRANGEBYLEX() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestEchoVault_ZSCORE(t *testing.T) {
 	server := createEchoVault()
 
@@ -3321,3 +3754,125 @@ func TestEchoVault_ZUNIONSTORE(t *testing.T) {
 		})
 	}
 }
+
+func TestEchoVault_GEO(t *testing.T) {
+	server := createEchoVault()
+
+	locations := []echovault.GeoLocation{
+		{Longitude: 13.361389, Latitude: 38.115556, Member: "Palermo"},
+		{Longitude: 15.087269, Latitude: 37.502669, Member: "Catania"},
+		{Longitude: 12.496366, Latitude: 41.902782, Member: "Rome"},
+	}
+
+	added, err := server.GeoAdd("GeoKey1", echovault.GeoAddOptions{}, locations...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if added != 3 {
+		t.Errorf("GeoAdd() got = %v, want %v", added, 3)
+	}
+
+	dist, err := server.GeoDist("GeoKey1", "Palermo", "Catania", "km")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dist != 166.2742 {
+		t.Errorf("GeoDist() got = %v, want %v", dist, 166.2742)
+	}
+	dist, err = server.GeoDist("GeoKey1", "Palermo", "Syracuse", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dist != nil {
+		t.Errorf("GeoDist() got = %v, want nil", dist)
+	}
+
+	positions, err := server.GeoPos("GeoKey1", "Catania", "Syracuse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(positions) != 2 || positions[0] == nil || positions[1] != nil {
+		t.Fatalf("GeoPos() got = %+v, want the position of Catania and nil", positions)
+	}
+	if math.Abs(positions[0].Longitude-15.087269) > 1e-5 || math.Abs(positions[0].Latitude-37.502669) > 1e-5 {
+		t.Errorf("GeoPos() got = %+v, want 15.087269,37.502669", positions[0])
+	}
+
+	results, err := server.GeoSearch("GeoKey1", echovault.GeoSearchOptions{
+		FromMember: "Palermo",
+		Radius:     200,
+		Unit:       "km",
+		Order:      "ASC",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []echovault.GeoSearchResult{
+		{Member: "Palermo", Distance: 0, Hash: 3479099956230698},
+		{Member: "Catania", Distance: 166.2742, Hash: 3479447370796909},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("GeoSearch() got = %+v, want %+v", results, want)
+	}
+	for i := range want {
+		if results[i].Member != want[i].Member || results[i].Distance != want[i].Distance || results[i].Hash != want[i].Hash {
+			t.Errorf("GeoSearch() got = %+v, want %+v", results[i], want[i])
+		}
+	}
+
+	stored, err := server.GeoSearchStore("GeoKey2", "GeoKey1", echovault.GeoSearchOptions{
+		FromLonLat: true,
+		Longitude:  12.5,
+		Latitude:   41.9,
+		ByBox:      true,
+		Width:      100,
+		Height:     100,
+		Unit:       "km",
+	}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored != 1 {
+		t.Errorf("GeoSearchStore() got = %v, want %v", stored, 1)
+	}
+
+	if err = presetValue(server, context.Background(), "GeoKey3", "Default value"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = server.GeoAdd("GeoKey3", echovault.GeoAddOptions{}, locations...); err == nil {
+		t.Error("GeoAdd() expected error when the key is not a sorted set")
+	}
+}
+
+func TestEchoVault_ZSCAN(t *testing.T) {
+	server := createEchoVault()
+
+	want := make(map[string]float64)
+	members := make([]ss.MemberParam, 25)
+	for i := range members {
+		member := fmt.Sprintf("member%d", i)
+		want[member] = float64(i) + 0.5
+		members[i] = ss.MemberParam{Value: ss.Value(member), Score: ss.Score(want[member])}
+	}
+	if err := presetValue(server, context.Background(), "key1", ss.NewSortedSet(members)); err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(map[string]float64)
+	var cursor uint64
+	for {
+		next, scores, err := server.ZScan("key1", cursor, echovault.ZScanOptions{Count: 3})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for member, score := range scores {
+			got[member] = score
+		}
+		if cursor = next; cursor == 0 {
+			break
+		}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ZSCAN() got = %v, want %v", got, want)
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/echovault/echovault/internal"
 	"github.com/echovault/echovault/internal/config"
 	"github.com/echovault/echovault/internal/constants"
+	"github.com/echovault/echovault/internal/keywait"
 	"github.com/echovault/echovault/internal/modules/sorted_set"
 	"github.com/tidwall/resp"
 	"math"
@@ -32,6 +33,7 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 	"unsafe"
 )
 
@@ -86,6 +88,10 @@ func getHandlerFuncParams(ctx context.Context, cmd []string, conn *net.Conn) int
 		KeyRUnlock:       mockServer.KeyRUnlock,
 		GetValue:         mockServer.GetValue,
 		SetValue:         mockServer.SetValue,
+		DeleteKey:        mockServer.DeleteKey,
+		DeleteLockedKey:  mockServer.DeleteLockedKey,
+		WatchKeys: getUnexportedField(
+			reflect.ValueOf(mockServer).Elem().FieldByName("keyWaiters")).(*keywait.Registry).Watch,
 	}
 }
 
@@ -1081,9 +1087,30 @@ func Test_HandleZDIFFSTORE(t *testing.T) {
 			expectedError:    nil,
 		},
 		{
-			name:             "6. Command too short",
+			name:   "6. Delete the destination key when the difference is empty",
+			preset: true,
+			presetValues: map[string]interface{}{
+				"ZdiffStoreKey14": sorted_set.NewSortedSet([]sorted_set.MemberParam{
+					{Value: "one", Score: 1}, {Value: "two", Score: 2},
+				}),
+				"ZdiffStoreKey15": sorted_set.NewSortedSet([]sorted_set.MemberParam{
+					{Value: "one", Score: 1}, {Value: "two", Score: 2},
+					{Value: "three", Score: 3},
+				}),
+				"ZdiffStoreDestinationKey6": sorted_set.NewSortedSet([]sorted_set.MemberParam{
+					{Value: "one", Score: 1},
+				}),
+			},
+			destination:      "ZdiffStoreDestinationKey6",
+			command:          []string{"ZDIFFSTORE", "ZdiffStoreDestinationKey6", "ZdiffStoreKey14", "ZdiffStoreKey15"},
+			expectedValue:    nil,
+			expectedResponse: 0,
+			expectedError:    nil,
+		},
+		{
+			name:             "7. Command too short",
 			preset:           false,
-			command:          []string{"ZDIFFSTORE", "ZdiffStoreDestinationKey6"},
+			command:          []string{"ZDIFFSTORE", "ZdiffStoreDestinationKey7"},
 			expectedResponse: 0,
 			expectedError:    errors.New(constants.WrongArgsResponse),
 		},
@@ -1130,6 +1157,9 @@ func Test_HandleZDIFFSTORE(t *testing.T) {
 			if rv.Integer() != test.expectedResponse {
 				t.Errorf("expected response integer %d, got %d", test.expectedResponse, rv.Integer())
 			}
+			if test.expectedValue == nil && mockServer.KeyExists(ctx, test.destination) {
+				t.Errorf("expected key %s to be deleted", test.destination)
+			}
 			if test.expectedValue != nil {
 				if _, err = mockServer.KeyRLock(ctx, test.destination); err != nil {
 					t.Error(err)
@@ -1569,6 +1599,33 @@ func Test_HandleZMPOP(t *testing.T) {
 			command:       []string{"ZMPOP"},
 			expectedError: errors.New(constants.WrongArgsResponse),
 		},
+		{
+			name:   "10. Pop min elements when only COUNT is provided",
+			preset: true,
+			presetValues: map[string]interface{}{
+				"ZmpopKey12": sorted_set.NewSortedSet([]sorted_set.MemberParam{
+					{Value: "one", Score: 1}, {Value: "two", Score: 2}, {Value: "three", Score: 3},
+				}),
+			},
+			command: []string{"ZMPOP", "ZmpopKey12", "COUNT", "2"},
+			expectedValues: map[string]*sorted_set.SortedSet{
+				"ZmpopKey12": sorted_set.NewSortedSet([]sorted_set.MemberParam{{Value: "three", Score: 3}}),
+			},
+			expectedResponse: [][]string{{"one", "1"}, {"two", "2"}},
+			expectedError:    nil,
+		},
+		{
+			name:          "11. Return error when the option is unknown",
+			preset:        false,
+			command:       []string{"ZMPOP", "ZmpopKey13", "MAX", "LIMIT", "2"},
+			expectedError: errors.New("unknown option LIMIT"),
+		},
+		{
+			name:          "12. Return error when COUNT has no value",
+			preset:        false,
+			command:       []string{"ZMPOP", "ZmpopKey14", "MIN", "COUNT"},
+			expectedError: errors.New(constants.WrongArgsResponse),
+		},
 	}
 
 	for i, test := range tests {
@@ -1832,6 +1889,198 @@ func Test_HandleZPOP(t *testing.T) {
 	}
 }
 
+func Test_HandleBZPOP(t *testing.T) {
+	tests := []struct {
+		name             string
+		presetValues     map[string]interface{}
+		addAfter         map[string][]string // Members to ZADD after the command has started blocking.
+		command          []string
+		expectedResponse []string
+		expectedValues   map[string]*sorted_set.SortedSet
+		expectedError    error
+	}{
+		{
+			name: "1. BZPOPMIN pops from the first non-empty sorted set without blocking",
+			presetValues: map[string]interface{}{
+				"BZPopKey2": sorted_set.NewSortedSet([]sorted_set.MemberParam{
+					{Value: "one", Score: 1}, {Value: "two", Score: 2},
+				}),
+			},
+			command:          []string{"BZPOPMIN", "BZPopKey1", "BZPopKey2", "1"},
+			expectedResponse: []string{"BZPopKey2", "one", "1"},
+			expectedValues: map[string]*sorted_set.SortedSet{
+				"BZPopKey2": sorted_set.NewSortedSet([]sorted_set.MemberParam{{Value: "two", Score: 2}}),
+			},
+			expectedError: nil,
+		},
+		{
+			name: "2. BZPOPMAX pops the member with the highest score",
+			presetValues: map[string]interface{}{
+				"BZPopKey3": sorted_set.NewSortedSet([]sorted_set.MemberParam{
+					{Value: "one", Score: 1}, {Value: "two", Score: 2},
+				}),
+			},
+			command:          []string{"BZPOPMAX", "BZPopKey3", "1"},
+			expectedResponse: []string{"BZPopKey3", "two", "2"},
+			expectedValues: map[string]*sorted_set.SortedSet{
+				"BZPopKey3": sorted_set.NewSortedSet([]sorted_set.MemberParam{{Value: "one", Score: 1}}),
+			},
+			expectedError: nil,
+		},
+		{
+			name:             "3. Return null when the timeout expires",
+			command:          []string{"BZPOPMIN", "BZPopKey4", "0.1"},
+			expectedResponse: nil,
+			expectedError:    nil,
+		},
+		{
+			name: "4. Wake up and pop when a member is added while blocking",
+			presetValues: map[string]interface{}{
+				"BZPopKey5": sorted_set.NewSortedSet([]sorted_set.MemberParam{}),
+			},
+			addAfter:         map[string][]string{"BZPopKey5": {"5", "five", "3", "three"}},
+			command:          []string{"BZPOPMIN", "BZPopKey5", "5"},
+			expectedResponse: []string{"BZPopKey5", "three", "3"},
+			expectedValues: map[string]*sorted_set.SortedSet{
+				"BZPopKey5": sorted_set.NewSortedSet([]sorted_set.MemberParam{{Value: "five", Score: 5}}),
+			},
+			expectedError: nil,
+		},
+		{
+			name: "5. BZMPOP pops count members in order and returns the key",
+			presetValues: map[string]interface{}{
+				"BZPopKey7": sorted_set.NewSortedSet([]sorted_set.MemberParam{
+					{Value: "one", Score: 1}, {Value: "two", Score: 2}, {Value: "three", Score: 3},
+				}),
+			},
+			command:          []string{"BZMPOP", "1", "BZPopKey6", "BZPopKey7", "MAX", "COUNT", "2"},
+			expectedResponse: []string{"BZPopKey7", "three", "3", "two", "2"},
+			expectedValues: map[string]*sorted_set.SortedSet{
+				"BZPopKey7": sorted_set.NewSortedSet([]sorted_set.MemberParam{{Value: "one", Score: 1}}),
+			},
+			expectedError: nil,
+		},
+		{
+			name:             "6. Return error when the key is not a sorted set",
+			presetValues:     map[string]interface{}{"BZPopKey8": "Default value"},
+			command:          []string{"BZPOPMIN", "BZPopKey8", "1"},
+			expectedResponse: nil,
+			expectedError:    errors.New("value at key BZPopKey8 is not a sorted set"),
+		},
+		{
+			name:             "7. Return error when the timeout is negative",
+			command:          []string{"BZPOPMAX", "BZPopKey9", "-1"},
+			expectedResponse: nil,
+			expectedError:    errors.New("timeout must be a non-negative number"),
+		},
+		{
+			name:             "8. Return error when the BZMPOP count is not a positive integer",
+			command:          []string{"BZMPOP", "1", "BZPopKey10", "MIN", "COUNT", "0"},
+			expectedResponse: nil,
+			expectedError:    errors.New("count must be a positive integer"),
+		},
+		{
+			name:             "9. Return error when BZMPOP has no MIN or MAX",
+			command:          []string{"BZMPOP", "1", "BZPopKey11", "BZPopKey12"},
+			expectedResponse: nil,
+			expectedError:    errors.New(constants.WrongArgsResponse),
+		},
+		{
+			name:             "10. Command too short",
+			command:          []string{"BZPOPMIN", "BZPopKey13"},
+			expectedResponse: nil,
+			expectedError:    errors.New(constants.WrongArgsResponse),
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), "test_name", fmt.Sprintf("BZPOP, %d", i))
+
+			for key, value := range test.presetValues {
+				if _, err := mockServer.CreateKeyAndLock(ctx, key); err != nil {
+					t.Error(err)
+				}
+				if err := mockServer.SetValue(ctx, key, value); err != nil {
+					t.Error(err)
+				}
+				mockServer.KeyUnlock(ctx, key)
+			}
+
+			// Copy the members so that the goroutine does not read the next test case after this one completes.
+			addAfter := test.addAfter
+			go func() {
+				<-time.After(50 * time.Millisecond)
+				for key, members := range addAfter {
+					command := append([]string{"ZADD", key}, members...)
+					if _, err := getHandler("ZADD")(getHandlerFuncParams(ctx, command, nil)); err != nil {
+						t.Error(err)
+					}
+				}
+			}()
+
+			handler := getHandler(test.command[0])
+			if handler == nil {
+				t.Errorf("no handler found for command %s", test.command[0])
+				return
+			}
+
+			res, err := handler(getHandlerFuncParams(ctx, test.command, nil))
+			if test.expectedError != nil {
+				if err == nil || err.Error() != test.expectedError.Error() {
+					t.Errorf("expected error \"%s\", got \"%v\"", test.expectedError.Error(), err)
+				}
+				return
+			}
+			if err != nil {
+				t.Error(err)
+				return
+			}
+
+			rd := resp.NewReader(bytes.NewBuffer(res))
+			rv, _, err := rd.ReadValue()
+			if err != nil {
+				t.Error(err)
+			}
+			if test.expectedResponse == nil {
+				if !rv.IsNull() {
+					t.Errorf("expected null response, got %+v", rv)
+				}
+			} else {
+				// Flatten the BZMPOP members so that both reply shapes can be compared in the same way.
+				var got []string
+				for _, v := range rv.Array() {
+					if v.Type() != resp.Array {
+						got = append(got, v.String())
+						continue
+					}
+					for _, member := range v.Array() {
+						for _, field := range member.Array() {
+							got = append(got, field.String())
+						}
+					}
+				}
+				if !reflect.DeepEqual(got, test.expectedResponse) {
+					t.Errorf("expected response %+v, got %+v", test.expectedResponse, got)
+				}
+			}
+
+			for key, expectedValue := range test.expectedValues {
+				if _, err = mockServer.KeyRLock(ctx, key); err != nil {
+					t.Error(err)
+				}
+				set, ok := mockServer.GetValue(ctx, key).(*sorted_set.SortedSet)
+				if !ok {
+					t.Error("expected value to be sorted set, got another type")
+				} else if !set.Equals(expectedValue) {
+					t.Errorf("expected sorted set at key %s to be %+v, got %+v", key, expectedValue.GetAll(), set.GetAll())
+				}
+				mockServer.KeyRUnlock(ctx, key)
+			}
+		})
+	}
+}
+
 func Test_HandleZMSCORE(t *testing.T) {
 	tests := []struct {
 		name             string
@@ -1858,11 +2107,11 @@ func Test_HandleZMSCORE(t *testing.T) {
 			expectedError:    nil,
 		},
 		{
-			name:             "2. If key does not exist, return empty array",
+			name:             "2. If key does not exist, return nil for every member",
 			preset:           false,
 			presetValues:     nil,
 			command:          []string{"ZMSCORE", "ZmScoreKey2", "one", "two", "three", "four"},
-			expectedResponse: []interface{}{},
+			expectedResponse: []interface{}{nil, nil, nil, nil},
 			expectedError:    nil,
 		},
 		{
@@ -2493,6 +2742,7 @@ func Test_HandleZREMRANGEBYSCORE(t *testing.T) {
 		presetValues     map[string]interface{}
 		command          []string
 		expectedValues   map[string]*sorted_set.SortedSet
+		deletedKeys      []string
 		expectedResponse int
 		expectedError    error
 	}{
@@ -2548,6 +2798,44 @@ func Test_HandleZREMRANGEBYSCORE(t *testing.T) {
 			command:       []string{"ZREMRANGEBYSCORE", "ZremRangeByScoreKey5", "4", "5", "8"},
 			expectedError: errors.New(constants.WrongArgsResponse),
 		},
+		{
+			name:   "6. Exclude the boundaries prefixed with (",
+			preset: true,
+			presetValues: map[string]interface{}{
+				"ZremRangeByScoreKey6": sorted_set.NewSortedSet([]sorted_set.MemberParam{
+					{Value: "one", Score: 1}, {Value: "two", Score: 2},
+					{Value: "three", Score: 3}, {Value: "four", Score: 4},
+					{Value: "five", Score: 5},
+				}),
+			},
+			command: []string{"ZREMRANGEBYSCORE", "ZremRangeByScoreKey6", "(2", "(5"},
+			expectedValues: map[string]*sorted_set.SortedSet{
+				"ZremRangeByScoreKey6": sorted_set.NewSortedSet([]sorted_set.MemberParam{
+					{Value: "one", Score: 1}, {Value: "two", Score: 2}, {Value: "five", Score: 5},
+				}),
+			},
+			expectedResponse: 2,
+			expectedError:    nil,
+		},
+		{
+			name:   "7. Delete the key when all the elements are removed",
+			preset: true,
+			presetValues: map[string]interface{}{
+				"ZremRangeByScoreKey7": sorted_set.NewSortedSet([]sorted_set.MemberParam{
+					{Value: "one", Score: 1}, {Value: "two", Score: 2},
+				}),
+			},
+			command:          []string{"ZREMRANGEBYSCORE", "ZremRangeByScoreKey7", "-inf", "+inf"},
+			deletedKeys:      []string{"ZremRangeByScoreKey7"},
+			expectedResponse: 2,
+			expectedError:    nil,
+		},
+		{
+			name:          "8. Return error when min is not a valid double",
+			preset:        false,
+			command:       []string{"ZREMRANGEBYSCORE", "ZremRangeByScoreKey8", "(min", "10"},
+			expectedError: errors.New("min constraint must be a double"),
+		},
 	}
 
 	for i, test := range tests {
@@ -2606,6 +2894,11 @@ func Test_HandleZREMRANGEBYSCORE(t *testing.T) {
 					}
 				}
 			}
+			for _, key := range test.deletedKeys {
+				if mockServer.KeyExists(ctx, key) {
+					t.Errorf("expected key \"%s\" to be deleted", key)
+				}
+			}
 		})
 	}
 }
@@ -2729,6 +3022,25 @@ func Test_HandleZREMRANGEBYRANK(t *testing.T) {
 			command:       []string{"ZREMRANGEBYRANK", "ZremRangeByRankKey7", "4", "5", "8"},
 			expectedError: errors.New(constants.WrongArgsResponse),
 		},
+		{
+			name:   "8. Rank members with the same score lexicographically",
+			preset: true,
+			presetValues: map[string]interface{}{
+				"ZremRangeByRankKey8": sorted_set.NewSortedSet([]sorted_set.MemberParam{
+					{Value: "d", Score: 1}, {Value: "b", Score: 1},
+					{Value: "a", Score: 1}, {Value: "c", Score: 1},
+					{Value: "e", Score: 2},
+				}),
+			},
+			command: []string{"ZREMRANGEBYRANK", "ZremRangeByRankKey8", "0", "1"},
+			expectedValues: map[string]*sorted_set.SortedSet{
+				"ZremRangeByRankKey8": sorted_set.NewSortedSet([]sorted_set.MemberParam{
+					{Value: "c", Score: 1}, {Value: "d", Score: 1}, {Value: "e", Score: 2},
+				}),
+			},
+			expectedResponse: 2,
+			expectedError:    nil,
+		},
 	}
 
 	for i, test := range tests {
@@ -2879,6 +3191,43 @@ func Test_HandleZREMRANGEBYLEX(t *testing.T) {
 			command:       []string{"ZREMRANGEBYLEX", "ZremRangeByLexKey5", "a", "b", "c"},
 			expectedError: errors.New(constants.WrongArgsResponse),
 		},
+		{
+			name:   "6. Exclude the boundaries prefixed with ( and include the boundaries prefixed with [",
+			preset: true,
+			presetValues: map[string]interface{}{
+				"ZremRangeByLexKey6": sorted_set.NewSortedSet([]sorted_set.MemberParam{
+					{Value: "a", Score: 1}, {Value: "b", Score: 1},
+					{Value: "c", Score: 1}, {Value: "d", Score: 1},
+					{Value: "e", Score: 1},
+				}),
+			},
+			command: []string{"ZREMRANGEBYLEX", "ZremRangeByLexKey6", "(a", "[d"},
+			expectedValues: map[string]*sorted_set.SortedSet{
+				"ZremRangeByLexKey6": sorted_set.NewSortedSet([]sorted_set.MemberParam{
+					{Value: "a", Score: 1}, {Value: "e", Score: 1},
+				}),
+			},
+			expectedResponse: 3,
+			expectedError:    nil,
+		},
+		{
+			name:   "7. Remove from the start of the set when min is -",
+			preset: true,
+			presetValues: map[string]interface{}{
+				"ZremRangeByLexKey7": sorted_set.NewSortedSet([]sorted_set.MemberParam{
+					{Value: "a", Score: 1}, {Value: "b", Score: 1},
+					{Value: "c", Score: 1}, {Value: "d", Score: 1},
+				}),
+			},
+			command: []string{"ZREMRANGEBYLEX", "ZremRangeByLexKey7", "-", "(c"},
+			expectedValues: map[string]*sorted_set.SortedSet{
+				"ZremRangeByLexKey7": sorted_set.NewSortedSet([]sorted_set.MemberParam{
+					{Value: "c", Score: 1}, {Value: "d", Score: 1},
+				}),
+			},
+			expectedResponse: 2,
+			expectedError:    nil,
+		},
 	}
 
 	for i, test := range tests {
@@ -3220,66 +3569,337 @@ func Test_HandleZRANGE(t *testing.T) {
 	}
 }
 
-func Test_HandleZRANGESTORE(t *testing.T) {
+func Test_HandleZRANGEBYSCORE(t *testing.T) {
 	tests := []struct {
 		name             string
 		preset           bool
 		presetValues     map[string]interface{}
-		destination      string
 		command          []string
-		expectedValue    *sorted_set.SortedSet
-		expectedResponse int
+		expectedResponse [][]string
 		expectedError    error
 	}{
 		{
-			name:   "1. Get elements withing score range without score.",
+			name:   "1. Get members within score range in ascending order.",
 			preset: true,
 			presetValues: map[string]interface{}{
-				"ZrangeStoreKey1": sorted_set.NewSortedSet([]sorted_set.MemberParam{
+				"ZrangebyscoreKey1": sorted_set.NewSortedSet([]sorted_set.MemberParam{
 					{Value: "one", Score: 1}, {Value: "two", Score: 2},
 					{Value: "three", Score: 3}, {Value: "four", Score: 4},
 					{Value: "five", Score: 5}, {Value: "six", Score: 6},
-					{Value: "seven", Score: 7}, {Value: "eight", Score: 8},
 				}),
 			},
-			destination:      "ZrangeStoreDestinationKey1",
-			command:          []string{"ZRANGESTORE", "ZrangeStoreDestinationKey1", "ZrangeStoreKey1", "3", "7", "BYSCORE"},
-			expectedResponse: 5,
-			expectedValue: sorted_set.NewSortedSet([]sorted_set.MemberParam{
-				{Value: "three", Score: 3}, {Value: "four", Score: 4}, {Value: "five", Score: 5},
-				{Value: "six", Score: 6}, {Value: "seven", Score: 7},
-			}),
-			expectedError: nil,
+			command:          []string{"ZRANGEBYSCORE", "ZrangebyscoreKey1", "2", "(5"},
+			expectedResponse: [][]string{{"two"}, {"three"}, {"four"}},
+			expectedError:    nil,
 		},
 		{
-			name:   "2. Get elements within score range with score.",
+			name:   "2. Get members with scores and limit.",
 			preset: true,
 			presetValues: map[string]interface{}{
-				"ZrangeStoreKey2": sorted_set.NewSortedSet([]sorted_set.MemberParam{
+				"ZrangebyscoreKey2": sorted_set.NewSortedSet([]sorted_set.MemberParam{
 					{Value: "one", Score: 1}, {Value: "two", Score: 2},
 					{Value: "three", Score: 3}, {Value: "four", Score: 4},
 					{Value: "five", Score: 5}, {Value: "six", Score: 6},
-					{Value: "seven", Score: 7}, {Value: "eight", Score: 8},
 				}),
 			},
-			destination:      "ZrangeStoreDestinationKey2",
-			command:          []string{"ZRANGESTORE", "ZrangeStoreDestinationKey2", "ZrangeStoreKey2", "3", "7", "BYSCORE", "WITHSCORES"},
-			expectedResponse: 5,
-			expectedValue: sorted_set.NewSortedSet([]sorted_set.MemberParam{
-				{Value: "three", Score: 3}, {Value: "four", Score: 4}, {Value: "five", Score: 5},
-				{Value: "six", Score: 6}, {Value: "seven", Score: 7},
-			}),
-			expectedError: nil,
+			command:          []string{"ZRANGEBYSCORE", "ZrangebyscoreKey2", "-inf", "+inf", "WITHSCORES", "LIMIT", "1", "3"},
+			expectedResponse: [][]string{{"two", "2"}, {"three", "3"}, {"four", "4"}},
+			expectedError:    nil,
 		},
 		{
-			// 3. Get elements within score range with offset and limit.
-			// Offset and limit are in where we start and stop counting in the original sorted set (NOT THE RESULT).
-			name:   "3. Get elements within score range with offset and limit.",
+			name:   "3. Members with the same score are ordered lexicographically.",
 			preset: true,
 			presetValues: map[string]interface{}{
-				"ZrangeStoreKey3": sorted_set.NewSortedSet([]sorted_set.MemberParam{
-					{Value: "one", Score: 1}, {Value: "two", Score: 2},
-					{Value: "three", Score: 3}, {Value: "four", Score: 4},
+				"ZrangebyscoreKey3": sorted_set.NewSortedSet([]sorted_set.MemberParam{
+					{Value: "c", Score: 1}, {Value: "a", Score: 1},
+					{Value: "b", Score: 1}, {Value: "d", Score: 2},
+				}),
+			},
+			command:          []string{"ZRANGEBYSCORE", "ZrangebyscoreKey3", "1", "1"},
+			expectedResponse: [][]string{{"a"}, {"b"}, {"c"}},
+			expectedError:    nil,
+		},
+		{
+			name:   "4. ZREVRANGEBYSCORE takes max before min and returns descending order.",
+			preset: true,
+			presetValues: map[string]interface{}{
+				"ZrangebyscoreKey4": sorted_set.NewSortedSet([]sorted_set.MemberParam{
+					{Value: "one", Score: 1}, {Value: "two", Score: 2},
+					{Value: "three", Score: 3}, {Value: "four", Score: 4},
+					{Value: "five", Score: 5}, {Value: "six", Score: 6},
+				}),
+			},
+			command:          []string{"ZREVRANGEBYSCORE", "ZrangebyscoreKey4", "5", "2", "LIMIT", "1", "-1"},
+			expectedResponse: [][]string{{"four"}, {"three"}, {"two"}},
+			expectedError:    nil,
+		},
+		{
+			name:             "5. Return empty array when the key does not exist.",
+			preset:           false,
+			command:          []string{"ZRANGEBYSCORE", "ZrangebyscoreKey5", "-inf", "+inf"},
+			expectedResponse: [][]string{},
+			expectedError:    nil,
+		},
+		{
+			name:             "6. Return error when min is not a double.",
+			preset:           false,
+			command:          []string{"ZRANGEBYSCORE", "ZrangebyscoreKey6", "a", "5"},
+			expectedResponse: nil,
+			expectedError:    errors.New("min constraint must be a double"),
+		},
+		{
+			name:             "7. Return error when limit offset is not an integer.",
+			preset:           false,
+			command:          []string{"ZRANGEBYSCORE", "ZrangebyscoreKey7", "1", "5", "LIMIT", "a", "2"},
+			expectedResponse: nil,
+			expectedError:    errors.New("limit offset must be integer"),
+		},
+		{
+			name:   "8. Return error when the value is not a sorted set.",
+			preset: true,
+			presetValues: map[string]interface{}{
+				"ZrangebyscoreKey8": "Default value",
+			},
+			command:          []string{"ZRANGEBYSCORE", "ZrangebyscoreKey8", "1", "5"},
+			expectedResponse: nil,
+			expectedError:    errors.New("value at ZrangebyscoreKey8 is not a sorted set"),
+		},
+		{
+			name:             "9. Command too short",
+			preset:           false,
+			command:          []string{"ZRANGEBYSCORE", "ZrangebyscoreKey9", "1"},
+			expectedResponse: nil,
+			expectedError:    errors.New(constants.WrongArgsResponse),
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), "test_name", fmt.Sprintf("ZRANGEBYSCORE, %d", i))
+
+			if test.preset {
+				for key, value := range test.presetValues {
+					if _, err := mockServer.CreateKeyAndLock(ctx, key); err != nil {
+						t.Error(err)
+					}
+					if err := mockServer.SetValue(ctx, key, value); err != nil {
+						t.Error(err)
+					}
+					mockServer.KeyUnlock(ctx, key)
+				}
+			}
+
+			handler := getHandler(test.command[0])
+			if handler == nil {
+				t.Errorf("no handler found for command %s", test.command[0])
+				return
+			}
+
+			res, err := handler(getHandlerFuncParams(ctx, test.command, nil))
+			if test.expectedError != nil {
+				if err == nil || err.Error() != test.expectedError.Error() {
+					t.Errorf("expected error \"%s\", got \"%v\"", test.expectedError.Error(), err)
+				}
+				return
+			}
+			if err != nil {
+				t.Error(err)
+			}
+			members, err := internal.ParseNestedStringArrayResponse(res)
+			if err != nil {
+				t.Error(err)
+			}
+			// Unlike ZRANGE, the order of the members is part of the expected response.
+			if !reflect.DeepEqual(members, test.expectedResponse) {
+				t.Errorf("expected response %+v, got %+v", test.expectedResponse, members)
+			}
+		})
+	}
+}
+
+func Test_HandleZRANGEBYLEX(t *testing.T) {
+	tests := []struct {
+		name             string
+		preset           bool
+		presetValues     map[string]interface{}
+		command          []string
+		expectedResponse [][]string
+		expectedError    error
+	}{
+		{
+			name:   "1. Get members within lex range.",
+			preset: true,
+			presetValues: map[string]interface{}{
+				"ZrangebylexKey1": sorted_set.NewSortedSet([]sorted_set.MemberParam{
+					{Value: "a", Score: 1}, {Value: "b", Score: 1},
+					{Value: "c", Score: 1}, {Value: "d", Score: 1},
+					{Value: "e", Score: 1}, {Value: "f", Score: 1},
+				}),
+			},
+			command:          []string{"ZRANGEBYLEX", "ZrangebylexKey1", "[b", "(e"},
+			expectedResponse: [][]string{{"b"}, {"c"}, {"d"}},
+			expectedError:    nil,
+		},
+		{
+			name:   "2. Get members within unbounded lex range with limit.",
+			preset: true,
+			presetValues: map[string]interface{}{
+				"ZrangebylexKey2": sorted_set.NewSortedSet([]sorted_set.MemberParam{
+					{Value: "a", Score: 1}, {Value: "b", Score: 1},
+					{Value: "c", Score: 1}, {Value: "d", Score: 1},
+					{Value: "e", Score: 1}, {Value: "f", Score: 1},
+				}),
+			},
+			command:          []string{"ZRANGEBYLEX", "ZrangebylexKey2", "-", "+", "LIMIT", "2", "2"},
+			expectedResponse: [][]string{{"c"}, {"d"}},
+			expectedError:    nil,
+		},
+		{
+			name:   "3. ZREVRANGEBYLEX takes max before min and returns reverse order.",
+			preset: true,
+			presetValues: map[string]interface{}{
+				"ZrangebylexKey3": sorted_set.NewSortedSet([]sorted_set.MemberParam{
+					{Value: "a", Score: 1}, {Value: "b", Score: 1},
+					{Value: "c", Score: 1}, {Value: "d", Score: 1},
+					{Value: "e", Score: 1}, {Value: "f", Score: 1},
+				}),
+			},
+			command:          []string{"ZREVRANGEBYLEX", "ZrangebylexKey3", "[e", "(b"},
+			expectedResponse: [][]string{{"e"}, {"d"}, {"c"}},
+			expectedError:    nil,
+		},
+		{
+			name:   "4. Return empty array when members have different scores.",
+			preset: true,
+			presetValues: map[string]interface{}{
+				"ZrangebylexKey4": sorted_set.NewSortedSet([]sorted_set.MemberParam{
+					{Value: "a", Score: 1}, {Value: "b", Score: 2},
+				}),
+			},
+			command:          []string{"ZRANGEBYLEX", "ZrangebylexKey4", "-", "+"},
+			expectedResponse: [][]string{},
+			expectedError:    nil,
+		},
+		{
+			name:             "5. Return error when WITHSCORES is provided.",
+			preset:           false,
+			command:          []string{"ZRANGEBYLEX", "ZrangebylexKey5", "-", "+", "WITHSCORES", "0", "1"},
+			expectedResponse: nil,
+			expectedError:    errors.New("unknown option WITHSCORES"),
+		},
+		{
+			name:             "6. Command too long",
+			preset:           false,
+			command:          []string{"ZRANGEBYLEX", "ZrangebylexKey6", "-", "+", "LIMIT", "0"},
+			expectedResponse: nil,
+			expectedError:    errors.New(constants.WrongArgsResponse),
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), "test_name", fmt.Sprintf("ZRANGEBYLEX, %d", i))
+
+			if test.preset {
+				for key, value := range test.presetValues {
+					if _, err := mockServer.CreateKeyAndLock(ctx, key); err != nil {
+						t.Error(err)
+					}
+					if err := mockServer.SetValue(ctx, key, value); err != nil {
+						t.Error(err)
+					}
+					mockServer.KeyUnlock(ctx, key)
+				}
+			}
+
+			handler := getHandler(test.command[0])
+			if handler == nil {
+				t.Errorf("no handler found for command %s", test.command[0])
+				return
+			}
+
+			res, err := handler(getHandlerFuncParams(ctx, test.command, nil))
+			if test.expectedError != nil {
+				if err == nil || err.Error() != test.expectedError.Error() {
+					t.Errorf("expected error \"%s\", got \"%v\"", test.expectedError.Error(), err)
+				}
+				return
+			}
+			if err != nil {
+				t.Error(err)
+			}
+			members, err := internal.ParseNestedStringArrayResponse(res)
+			if err != nil {
+				t.Error(err)
+			}
+			// Unlike ZRANGE, the order of the members is part of the expected response.
+			if !reflect.DeepEqual(members, test.expectedResponse) {
+				t.Errorf("expected response %+v, got %+v", test.expectedResponse, members)
+			}
+		})
+	}
+}
+
+func Test_HandleZRANGESTORE(t *testing.T) {
+	tests := []struct {
+		name             string
+		preset           bool
+		presetValues     map[string]interface{}
+		destination      string
+		command          []string
+		expectedValue    *sorted_set.SortedSet
+		expectedResponse int
+		expectedError    error
+	}{
+		{
+			name:   "1. Get elements withing score range without score.",
+			preset: true,
+			presetValues: map[string]interface{}{
+				"ZrangeStoreKey1": sorted_set.NewSortedSet([]sorted_set.MemberParam{
+					{Value: "one", Score: 1}, {Value: "two", Score: 2},
+					{Value: "three", Score: 3}, {Value: "four", Score: 4},
+					{Value: "five", Score: 5}, {Value: "six", Score: 6},
+					{Value: "seven", Score: 7}, {Value: "eight", Score: 8},
+				}),
+			},
+			destination:      "ZrangeStoreDestinationKey1",
+			command:          []string{"ZRANGESTORE", "ZrangeStoreDestinationKey1", "ZrangeStoreKey1", "3", "7", "BYSCORE"},
+			expectedResponse: 5,
+			expectedValue: sorted_set.NewSortedSet([]sorted_set.MemberParam{
+				{Value: "three", Score: 3}, {Value: "four", Score: 4}, {Value: "five", Score: 5},
+				{Value: "six", Score: 6}, {Value: "seven", Score: 7},
+			}),
+			expectedError: nil,
+		},
+		{
+			name:   "2. Get elements within score range with score.",
+			preset: true,
+			presetValues: map[string]interface{}{
+				"ZrangeStoreKey2": sorted_set.NewSortedSet([]sorted_set.MemberParam{
+					{Value: "one", Score: 1}, {Value: "two", Score: 2},
+					{Value: "three", Score: 3}, {Value: "four", Score: 4},
+					{Value: "five", Score: 5}, {Value: "six", Score: 6},
+					{Value: "seven", Score: 7}, {Value: "eight", Score: 8},
+				}),
+			},
+			destination:      "ZrangeStoreDestinationKey2",
+			command:          []string{"ZRANGESTORE", "ZrangeStoreDestinationKey2", "ZrangeStoreKey2", "3", "7", "BYSCORE", "WITHSCORES"},
+			expectedResponse: 5,
+			expectedValue: sorted_set.NewSortedSet([]sorted_set.MemberParam{
+				{Value: "three", Score: 3}, {Value: "four", Score: 4}, {Value: "five", Score: 5},
+				{Value: "six", Score: 6}, {Value: "seven", Score: 7},
+			}),
+			expectedError: nil,
+		},
+		{
+			// 3. Get elements within score range with offset and limit.
+			// Offset and limit are applied to the members within the range.
+			name:   "3. Get elements within score range with offset and limit.",
+			preset: true,
+			presetValues: map[string]interface{}{
+				"ZrangeStoreKey3": sorted_set.NewSortedSet([]sorted_set.MemberParam{
+					{Value: "one", Score: 1}, {Value: "two", Score: 2},
+					{Value: "three", Score: 3}, {Value: "four", Score: 4},
 					{Value: "five", Score: 5}, {Value: "six", Score: 6},
 					{Value: "seven", Score: 7}, {Value: "eight", Score: 8},
 				}),
@@ -3288,14 +3908,14 @@ func Test_HandleZRANGESTORE(t *testing.T) {
 			command:          []string{"ZRANGESTORE", "ZrangeStoreDestinationKey3", "ZrangeStoreKey3", "3", "7", "BYSCORE", "WITHSCORES", "LIMIT", "2", "4"},
 			expectedResponse: 3,
 			expectedValue: sorted_set.NewSortedSet([]sorted_set.MemberParam{
-				{Value: "three", Score: 3}, {Value: "four", Score: 4}, {Value: "five", Score: 5},
+				{Value: "five", Score: 5}, {Value: "six", Score: 6}, {Value: "seven", Score: 7},
 			}),
 			expectedError: nil,
 		},
 		{
 			// 4. Get elements within score range with offset and limit + reverse the results.
-			// Offset and limit are in where we start and stop counting in the original sorted set (NOT THE RESULT).
-			// REV reverses the original set before getting the range.
+			// With REV, start is the maximum score and stop is the minimum score.
+			// Offset and limit are applied to the members within the range in reverse order.
 			name:   "4. Get elements within score range with offset and limit + reverse the results.",
 			preset: true,
 			presetValues: map[string]interface{}{
@@ -3307,10 +3927,10 @@ func Test_HandleZRANGESTORE(t *testing.T) {
 				}),
 			},
 			destination:      "ZrangeStoreDestinationKey4",
-			command:          []string{"ZRANGESTORE", "ZrangeStoreDestinationKey4", "ZrangeStoreKey4", "3", "7", "BYSCORE", "WITHSCORES", "LIMIT", "2", "4", "REV"},
+			command:          []string{"ZRANGESTORE", "ZrangeStoreDestinationKey4", "ZrangeStoreKey4", "7", "3", "BYSCORE", "WITHSCORES", "LIMIT", "2", "4", "REV"},
 			expectedResponse: 3,
 			expectedValue: sorted_set.NewSortedSet([]sorted_set.MemberParam{
-				{Value: "six", Score: 6}, {Value: "five", Score: 5}, {Value: "four", Score: 4},
+				{Value: "five", Score: 5}, {Value: "four", Score: 4}, {Value: "three", Score: 3},
 			}),
 			expectedError: nil,
 		},
@@ -3356,7 +3976,7 @@ func Test_HandleZRANGESTORE(t *testing.T) {
 		},
 		{
 			// 7. Get elements within lex range with offset and limit.
-			// Offset and limit are in where we start and stop counting in the original sorted set (NOT THE RESULT).
+			// Offset and limit are applied to the members within the range.
 			name:   "7. Get elements within lex range with offset and limit.",
 			preset: true,
 			presetValues: map[string]interface{}{
@@ -3369,16 +3989,16 @@ func Test_HandleZRANGESTORE(t *testing.T) {
 			},
 			destination:      "ZrangeStoreDestinationKey7",
 			command:          []string{"ZRANGESTORE", "ZrangeStoreDestinationKey7", "ZrangeStoreKey7", "a", "h", "BYLEX", "WITHSCORES", "LIMIT", "2", "4"},
-			expectedResponse: 3,
+			expectedResponse: 4,
 			expectedValue: sorted_set.NewSortedSet([]sorted_set.MemberParam{
-				{Value: "c", Score: 1}, {Value: "d", Score: 1}, {Value: "e", Score: 1},
+				{Value: "c", Score: 1}, {Value: "d", Score: 1}, {Value: "e", Score: 1}, {Value: "f", Score: 1},
 			}),
 			expectedError: nil,
 		},
 		{
 			// 8. Get elements within lex range with offset and limit + reverse the results.
-			// Offset and limit are in where we start and stop counting in the original sorted set (NOT THE RESULT).
-			// REV reverses the original set before getting the range.
+			// With REV, start is the maximum value and stop is the minimum value.
+			// Offset and limit are applied to the members within the range in reverse order.
 			name:   "8. Get elements within lex range with offset and limit + reverse the results.",
 			preset: true,
 			presetValues: map[string]interface{}{
@@ -3390,10 +4010,10 @@ func Test_HandleZRANGESTORE(t *testing.T) {
 				}),
 			},
 			destination:      "ZrangeStoreDestinationKey8",
-			command:          []string{"ZRANGESTORE", "ZrangeStoreDestinationKey8", "ZrangeStoreKey8", "a", "h", "BYLEX", "WITHSCORES", "LIMIT", "2", "4", "REV"},
-			expectedResponse: 3,
+			command:          []string{"ZRANGESTORE", "ZrangeStoreDestinationKey8", "ZrangeStoreKey8", "h", "a", "BYLEX", "WITHSCORES", "LIMIT", "2", "4", "REV"},
+			expectedResponse: 4,
 			expectedValue: sorted_set.NewSortedSet([]sorted_set.MemberParam{
-				{Value: "f", Score: 1}, {Value: "e", Score: 1}, {Value: "d", Score: 1},
+				{Value: "f", Score: 1}, {Value: "e", Score: 1}, {Value: "d", Score: 1}, {Value: "c", Score: 1},
 			}),
 			expectedError: nil,
 		},
@@ -3472,17 +4092,129 @@ func Test_HandleZRANGESTORE(t *testing.T) {
 			expectedResponse: 0,
 			expectedError:    errors.New(constants.WrongArgsResponse),
 		},
-	}
-
-	for i, test := range tests {
-		t.Run(test.name, func(t *testing.T) {
-			ctx := context.WithValue(context.Background(), "test_name", fmt.Sprintf("ZRANGESTORE, %d", i))
-
-			if test.preset {
-				for key, value := range test.presetValues {
-					if _, err := mockServer.CreateKeyAndLock(ctx, key); err != nil {
-						t.Error(err)
-					}
+		{
+			name:   "17. Get elements within rank range.",
+			preset: true,
+			presetValues: map[string]interface{}{
+				"ZrangeStoreKey17": sorted_set.NewSortedSet([]sorted_set.MemberParam{
+					{Value: "one", Score: 1}, {Value: "two", Score: 2},
+					{Value: "three", Score: 3}, {Value: "four", Score: 4},
+					{Value: "five", Score: 5}, {Value: "six", Score: 6},
+					{Value: "seven", Score: 7}, {Value: "eight", Score: 8},
+				}),
+			},
+			destination:      "ZrangeStoreDestinationKey17",
+			command:          []string{"ZRANGESTORE", "ZrangeStoreDestinationKey17", "ZrangeStoreKey17", "1", "3"},
+			expectedResponse: 3,
+			expectedValue: sorted_set.NewSortedSet([]sorted_set.MemberParam{
+				{Value: "two", Score: 2}, {Value: "three", Score: 3}, {Value: "four", Score: 4},
+			}),
+			expectedError: nil,
+		},
+		{
+			name:   "18. Get elements within negative rank range in reverse order.",
+			preset: true,
+			presetValues: map[string]interface{}{
+				"ZrangeStoreKey18": sorted_set.NewSortedSet([]sorted_set.MemberParam{
+					{Value: "one", Score: 1}, {Value: "two", Score: 2},
+					{Value: "three", Score: 3}, {Value: "four", Score: 4},
+					{Value: "five", Score: 5}, {Value: "six", Score: 6},
+					{Value: "seven", Score: 7}, {Value: "eight", Score: 8},
+				}),
+			},
+			destination:      "ZrangeStoreDestinationKey18",
+			command:          []string{"ZRANGESTORE", "ZrangeStoreDestinationKey18", "ZrangeStoreKey18", "0", "-7", "REV"},
+			expectedResponse: 2,
+			expectedValue: sorted_set.NewSortedSet([]sorted_set.MemberParam{
+				{Value: "eight", Score: 8}, {Value: "seven", Score: 7},
+			}),
+			expectedError: nil,
+		},
+		{
+			name:   "19. Get elements within exclusive score range.",
+			preset: true,
+			presetValues: map[string]interface{}{
+				"ZrangeStoreKey19": sorted_set.NewSortedSet([]sorted_set.MemberParam{
+					{Value: "one", Score: 1}, {Value: "two", Score: 2},
+					{Value: "three", Score: 3}, {Value: "four", Score: 4},
+					{Value: "five", Score: 5}, {Value: "six", Score: 6},
+					{Value: "seven", Score: 7}, {Value: "eight", Score: 8},
+				}),
+			},
+			destination:      "ZrangeStoreDestinationKey19",
+			command:          []string{"ZRANGESTORE", "ZrangeStoreDestinationKey19", "ZrangeStoreKey19", "(3", "+inf", "BYSCORE", "LIMIT", "0", "2"},
+			expectedResponse: 2,
+			expectedValue: sorted_set.NewSortedSet([]sorted_set.MemberParam{
+				{Value: "four", Score: 4}, {Value: "five", Score: 5},
+			}),
+			expectedError: nil,
+		},
+		{
+			name:   "20. Store the range at the source key.",
+			preset: true,
+			presetValues: map[string]interface{}{
+				"ZrangeStoreKey20": sorted_set.NewSortedSet([]sorted_set.MemberParam{
+					{Value: "one", Score: 1}, {Value: "two", Score: 2},
+					{Value: "three", Score: 3}, {Value: "four", Score: 4},
+					{Value: "five", Score: 5}, {Value: "six", Score: 6},
+					{Value: "seven", Score: 7}, {Value: "eight", Score: 8},
+				}),
+			},
+			destination:      "ZrangeStoreKey20",
+			command:          []string{"ZRANGESTORE", "ZrangeStoreKey20", "ZrangeStoreKey20", "-2", "-1"},
+			expectedResponse: 2,
+			expectedValue: sorted_set.NewSortedSet([]sorted_set.MemberParam{
+				{Value: "seven", Score: 7}, {Value: "eight", Score: 8},
+			}),
+			expectedError: nil,
+		},
+		{
+			name:   "21. Delete the destination when the range is empty.",
+			preset: true,
+			presetValues: map[string]interface{}{
+				"ZrangeStoreKey21": sorted_set.NewSortedSet([]sorted_set.MemberParam{
+					{Value: "one", Score: 1}, {Value: "two", Score: 2},
+					{Value: "three", Score: 3}, {Value: "four", Score: 4},
+					{Value: "five", Score: 5}, {Value: "six", Score: 6},
+					{Value: "seven", Score: 7}, {Value: "eight", Score: 8},
+				}),
+				"ZrangeStoreDestinationKey21": sorted_set.NewSortedSet([]sorted_set.MemberParam{
+					{Value: "one", Score: 1},
+				}),
+			},
+			destination:      "ZrangeStoreDestinationKey21",
+			command:          []string{"ZRANGESTORE", "ZrangeStoreDestinationKey21", "ZrangeStoreKey21", "10", "20", "BYSCORE"},
+			expectedResponse: 0,
+			expectedValue:    nil,
+			expectedError:    nil,
+		},
+		{
+			name:             "22. Throw error when limit is used with a rank range",
+			preset:           false,
+			presetValues:     nil,
+			command:          []string{"ZRANGESTORE", "ZrangeStoreDestinationKey22", "ZrangeStoreKey22", "0", "-1", "LIMIT", "0", "2"},
+			expectedResponse: 0,
+			expectedError:    errors.New("limit is only supported with byscore or bylex"),
+		},
+		{
+			name:             "23. Throw error when rank is not an integer",
+			preset:           false,
+			presetValues:     nil,
+			command:          []string{"ZRANGESTORE", "ZrangeStoreDestinationKey23", "ZrangeStoreKey23", "a", "-1"},
+			expectedResponse: 0,
+			expectedError:    errors.New("start index must be an integer"),
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), "test_name", fmt.Sprintf("ZRANGESTORE, %d", i))
+
+			if test.preset {
+				for key, value := range test.presetValues {
+					if _, err := mockServer.CreateKeyAndLock(ctx, key); err != nil {
+						t.Error(err)
+					}
 					if err := mockServer.SetValue(ctx, key, value); err != nil {
 						t.Error(err)
 					}
@@ -3515,6 +4247,9 @@ func Test_HandleZRANGESTORE(t *testing.T) {
 			if rv.Integer() != test.expectedResponse {
 				t.Errorf("expected response integer %d, got %d", test.expectedResponse, rv.Integer())
 			}
+			if test.expectedValue == nil && test.destination != "" && mockServer.KeyExists(ctx, test.destination) {
+				t.Errorf("expected key %s to be deleted", test.destination)
+			}
 			if test.expectedValue != nil {
 				if _, err = mockServer.KeyRLock(ctx, test.destination); err != nil {
 					t.Error(err)
@@ -5021,3 +5756,567 @@ func Test_HandleZUNIONSTORE(t *testing.T) {
 		})
 	}
 }
+
+// presetGeo adds Palermo, Catania and Rome to the sorted set at the key with GEOADD.
+func presetGeo(ctx context.Context, key string) error {
+	_, err := getHandler("GEOADD")(getHandlerFuncParams(ctx, []string{
+		"GEOADD", key,
+		"13.361389", "38.115556", "Palermo",
+		"15.087269", "37.502669", "Catania",
+		"12.496366", "41.902782", "Rome",
+	}, nil))
+	return err
+}
+
+func Test_HandleGEOADD(t *testing.T) {
+	tests := []struct {
+		name             string
+		preset           bool
+		key              string
+		command          []string
+		expectedResponse int
+		expectedScores   map[string]float64
+		expectedError    error
+	}{
+		{
+			name:             "1. Add the locations to a new sorted set with their geohashes as scores",
+			key:              "GeoAddKey1",
+			command:          []string{"GEOADD", "GeoAddKey1", "13.361389", "38.115556", "Palermo", "15.087269", "37.502669", "Catania"},
+			expectedResponse: 2,
+			expectedScores:   map[string]float64{"Palermo": 3479099956230698, "Catania": 3479447370796909},
+		},
+		{
+			name:             "2. Only count the added members by default",
+			preset:           true,
+			key:              "GeoAddKey2",
+			command:          []string{"GEOADD", "GeoAddKey2", "13.361389", "38.115556", "Palermo", "15.087269", "37.502669", "Syracuse"},
+			expectedResponse: 1,
+			expectedScores:   map[string]float64{"Palermo": 3479099956230698},
+		},
+		{
+			name:             "3. Count the added and changed members with CH",
+			preset:           true,
+			key:              "GeoAddKey3",
+			command:          []string{"GEOADD", "GeoAddKey3", "CH", "15.087269", "37.502669", "Palermo", "15.087269", "37.502669", "Syracuse"},
+			expectedResponse: 2,
+			expectedScores:   map[string]float64{"Palermo": 3479447370796909, "Syracuse": 3479447370796909},
+		},
+		{
+			name:             "4. Do not update existing members with NX",
+			preset:           true,
+			key:              "GeoAddKey4",
+			command:          []string{"GEOADD", "GeoAddKey4", "NX", "CH", "15.087269", "37.502669", "Palermo"},
+			expectedResponse: 0,
+			expectedScores:   map[string]float64{"Palermo": 3479099956230698},
+		},
+		{
+			name:             "5. Do not add new members with XX",
+			preset:           true,
+			key:              "GeoAddKey5",
+			command:          []string{"GEOADD", "GeoAddKey5", "XX", "CH", "15.087269", "37.502669", "Palermo", "15.087269", "37.502669", "Syracuse"},
+			expectedResponse: 1,
+			expectedScores:   map[string]float64{"Palermo": 3479447370796909},
+		},
+		{
+			name:          "6. Return error when the latitude is out of range",
+			key:           "GeoAddKey6",
+			command:       []string{"GEOADD", "GeoAddKey6", "13.361389", "86", "Palermo"},
+			expectedError: errors.New("invalid longitude,latitude pair 13.361389,86"),
+		},
+		{
+			name:          "7. Return error when the members are not longitude/latitude/member triplets",
+			key:           "GeoAddKey7",
+			command:       []string{"GEOADD", "GeoAddKey7", "13.361389", "38.115556", "Palermo", "15.087269"},
+			expectedError: errors.New("longitude/latitude/member triplets must be provided"),
+		},
+		{
+			name:          "8. Command too short",
+			key:           "GeoAddKey8",
+			command:       []string{"GEOADD", "GeoAddKey8", "13.361389", "38.115556"},
+			expectedError: errors.New(constants.WrongArgsResponse),
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), "test_name", fmt.Sprintf("GEOADD, %d", i))
+
+			if test.preset {
+				if err := presetGeo(ctx, test.key); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			res, err := getHandler("GEOADD")(getHandlerFuncParams(ctx, test.command, nil))
+			if test.expectedError != nil {
+				if err == nil || err.Error() != test.expectedError.Error() {
+					t.Errorf("expected error \"%s\", got \"%v\"", test.expectedError.Error(), err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			rv, _, err := resp.NewReader(bytes.NewReader(res)).ReadValue()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rv.Integer() != test.expectedResponse {
+				t.Errorf("expected response %d, got %d", test.expectedResponse, rv.Integer())
+			}
+
+			if _, err = mockServer.KeyRLock(ctx, test.key); err != nil {
+				t.Fatal(err)
+			}
+			defer mockServer.KeyRUnlock(ctx, test.key)
+			set, ok := mockServer.GetValue(ctx, test.key).(*sorted_set.SortedSet)
+			if !ok {
+				t.Fatalf("expected value at key %s to be a sorted set", test.key)
+			}
+			for member, score := range test.expectedScores {
+				if got := set.Get(sorted_set.Value(member)); !got.Exists || float64(got.Score) != score {
+					t.Errorf("expected member %s to have score %f, got %+v", member, score, got)
+				}
+			}
+			if test.command[2] == "XX" && set.Contains("Syracuse") {
+				t.Error("expected XX not to add new members")
+			}
+		})
+	}
+}
+
+func Test_HandleGEODIST(t *testing.T) {
+	ctx := context.WithValue(context.Background(), "test_name", "GEODIST")
+	if err := presetGeo(ctx, "GeoDistKey1"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name             string
+		command          []string
+		expectedResponse string
+		expectedNil      bool
+		expectedError    error
+	}{
+		{
+			name:             "1. Return the distance in meters by default",
+			command:          []string{"GEODIST", "GeoDistKey1", "Palermo", "Catania"},
+			expectedResponse: "166274.1516",
+		},
+		{
+			name:             "2. Return the distance in kilometers",
+			command:          []string{"GEODIST", "GeoDistKey1", "Palermo", "Catania", "KM"},
+			expectedResponse: "166.2742",
+		},
+		{
+			name:             "3. Return the distance in miles",
+			command:          []string{"GEODIST", "GeoDistKey1", "Palermo", "Catania", "mi"},
+			expectedResponse: "103.3182",
+		},
+		{
+			name:        "4. Return nil when a member does not exist",
+			command:     []string{"GEODIST", "GeoDistKey1", "Palermo", "Syracuse"},
+			expectedNil: true,
+		},
+		{
+			name:        "5. Return nil when the key does not exist",
+			command:     []string{"GEODIST", "GeoDistKey2", "Palermo", "Catania"},
+			expectedNil: true,
+		},
+		{
+			name:          "6. Return error when the unit is not supported",
+			command:       []string{"GEODIST", "GeoDistKey1", "Palermo", "Catania", "yd"},
+			expectedError: errors.New("unsupported unit provided. please use M, KM, FT, MI"),
+		},
+		{
+			name:          "7. Command too short",
+			command:       []string{"GEODIST", "GeoDistKey1", "Palermo"},
+			expectedError: errors.New(constants.WrongArgsResponse),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res, err := getHandler("GEODIST")(getHandlerFuncParams(ctx, test.command, nil))
+			if test.expectedError != nil {
+				if err == nil || err.Error() != test.expectedError.Error() {
+					t.Errorf("expected error \"%s\", got \"%v\"", test.expectedError.Error(), err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			rv, _, err := resp.NewReader(bytes.NewReader(res)).ReadValue()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.expectedNil {
+				if !rv.IsNull() {
+					t.Errorf("expected nil response, got %+v", rv)
+				}
+				return
+			}
+			if rv.String() != test.expectedResponse {
+				t.Errorf("expected response \"%s\", got \"%s\"", test.expectedResponse, rv.String())
+			}
+		})
+	}
+}
+
+func Test_HandleGEOPOS(t *testing.T) {
+	ctx := context.WithValue(context.Background(), "test_name", "GEOPOS")
+	if err := presetGeo(ctx, "GeoPosKey1"); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := getHandler("GEOPOS")(getHandlerFuncParams(ctx, []string{"GEOPOS", "GeoPosKey1", "Palermo", "Syracuse"}, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rv, _, err := resp.NewReader(bytes.NewReader(res)).ReadValue()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rv.Array()) != 2 {
+		t.Fatalf("expected 2 positions, got %d", len(rv.Array()))
+	}
+	// The position is the center of the geohash cell, so it's within a few centimeters of the location that was added.
+	position := rv.Array()[0].Array()
+	if len(position) != 2 || math.Abs(position[0].Float()-13.361389) > 1e-5 || math.Abs(position[1].Float()-38.115556) > 1e-5 {
+		t.Errorf("expected the position of Palermo to be 13.361389,38.115556, got %+v", position)
+	}
+	if !rv.Array()[1].IsNull() {
+		t.Errorf("expected nil position for a member that does not exist, got %+v", rv.Array()[1])
+	}
+
+	// All the positions are nil when the key does not exist.
+	res, err = getHandler("GEOPOS")(getHandlerFuncParams(ctx, []string{"GEOPOS", "GeoPosKey2", "Palermo"}, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(res) != "*1\r\n*-1\r\n" {
+		t.Errorf("expected response %q, got %q", "*1\r\n*-1\r\n", string(res))
+	}
+}
+
+func Test_HandleGEOSEARCH(t *testing.T) {
+	ctx := context.WithValue(context.Background(), "test_name", "GEOSEARCH")
+	if err := presetGeo(ctx, "GeoSearchKey1"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name             string
+		command          []string
+		expectedResponse [][]string
+		expectedError    error
+	}{
+		{
+			name:             "1. Return the members within the radius of a location, closest first",
+			command:          []string{"GEOSEARCH", "GeoSearchKey1", "FROMLONLAT", "15", "37", "BYRADIUS", "200", "km", "ASC"},
+			expectedResponse: [][]string{{"Catania"}, {"Palermo"}},
+		},
+		{
+			name:             "2. Return the members within the radius of a member, furthest first, with distances",
+			command:          []string{"GEOSEARCH", "GeoSearchKey1", "FROMMEMBER", "Palermo", "BYRADIUS", "500", "km", "DESC", "WITHDIST"},
+			expectedResponse: [][]string{{"Rome", "427.6295"}, {"Catania", "166.2742"}, {"Palermo", "0.0000"}},
+		},
+		{
+			name:             "3. Return the closest members with COUNT",
+			command:          []string{"GEOSEARCH", "GeoSearchKey1", "FROMMEMBER", "Rome", "BYRADIUS", "1000", "km", "COUNT", "2", "WITHHASH"},
+			expectedResponse: [][]string{{"Rome", "3480343273965391"}, {"Palermo", "3479099956230698"}},
+		},
+		{
+			name:             "4. Return the members within the box",
+			command:          []string{"GEOSEARCH", "GeoSearchKey1", "FROMLONLAT", "14", "38", "BYBOX", "400", "200", "km", "ASC"},
+			expectedResponse: [][]string{{"Palermo"}, {"Catania"}},
+		},
+		{
+			name:             "5. Return an empty array when the key does not exist",
+			command:          []string{"GEOSEARCH", "GeoSearchKey2", "FROMLONLAT", "15", "37", "BYRADIUS", "200", "km"},
+			expectedResponse: [][]string{},
+		},
+		{
+			name:          "6. Return error when the member does not exist",
+			command:       []string{"GEOSEARCH", "GeoSearchKey1", "FROMMEMBER", "Syracuse", "BYRADIUS", "200", "km"},
+			expectedError: errors.New("could not decode requested zset member"),
+		},
+		{
+			name:          "7. Return error when both FROMMEMBER and FROMLONLAT are provided",
+			command:       []string{"GEOSEARCH", "GeoSearchKey1", "FROMMEMBER", "Palermo", "FROMLONLAT", "15", "37", "BYRADIUS", "200", "km"},
+			expectedError: errors.New("exactly one of FROMMEMBER or FROMLONLAT must be provided"),
+		},
+		{
+			name:          "8. Return error when neither BYRADIUS nor BYBOX is provided",
+			command:       []string{"GEOSEARCH", "GeoSearchKey1", "FROMMEMBER", "Palermo", "ASC", "COUNT", "1"},
+			expectedError: errors.New("exactly one of BYRADIUS or BYBOX must be provided"),
+		},
+		{
+			name:          "9. Return error when ANY is provided without COUNT",
+			command:       []string{"GEOSEARCH", "GeoSearchKey1", "FROMMEMBER", "Palermo", "BYRADIUS", "200", "km", "ANY"},
+			expectedError: errors.New("the ANY argument requires COUNT argument"),
+		},
+		{
+			name:          "10. Command too short",
+			command:       []string{"GEOSEARCH", "GeoSearchKey1", "FROMMEMBER", "Palermo", "BYRADIUS", "200"},
+			expectedError: errors.New(constants.WrongArgsResponse),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res, err := getHandler("GEOSEARCH")(getHandlerFuncParams(ctx, test.command, nil))
+			if test.expectedError != nil {
+				if err == nil || err.Error() != test.expectedError.Error() {
+					t.Errorf("expected error \"%s\", got \"%v\"", test.expectedError.Error(), err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			rv, _, err := resp.NewReader(bytes.NewReader(res)).ReadValue()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(rv.Array()) != len(test.expectedResponse) {
+				t.Fatalf("expected %d results, got %d", len(test.expectedResponse), len(rv.Array()))
+			}
+			for i, expected := range test.expectedResponse {
+				got := []string{rv.Array()[i].String()}
+				if rv.Array()[i].Type() == resp.Array {
+					got = []string{}
+					for _, field := range rv.Array()[i].Array() {
+						got = append(got, field.String())
+					}
+				}
+				if !slices.Equal(got, expected) {
+					t.Errorf("expected result %d to be %v, got %v", i, expected, got)
+				}
+			}
+		})
+	}
+}
+
+func Test_HandleGEOSEARCHSTORE(t *testing.T) {
+	ctx := context.WithValue(context.Background(), "test_name", "GEOSEARCHSTORE")
+	if err := presetGeo(ctx, "GeoSearchStoreSource"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name             string
+		command          []string
+		expectedResponse int
+		expectedScores   map[string]float64
+		expectedError    error
+	}{
+		{
+			name:             "1. Store the matching members with their geohashes",
+			command:          []string{"GEOSEARCHSTORE", "GeoSearchStoreKey1", "GeoSearchStoreSource", "FROMLONLAT", "15", "37", "BYRADIUS", "200", "km"},
+			expectedResponse: 2,
+			expectedScores:   map[string]float64{"Palermo": 3479099956230698, "Catania": 3479447370796909},
+		},
+		{
+			name:             "2. Store the matching members with their distances",
+			command:          []string{"GEOSEARCHSTORE", "GeoSearchStoreKey2", "GeoSearchStoreSource", "FROMMEMBER", "Palermo", "BYRADIUS", "200", "km", "STOREDIST"},
+			expectedResponse: 2,
+			expectedScores:   map[string]float64{"Palermo": 0, "Catania": 166.27415156960038},
+		},
+		{
+			name:             "3. Store the results in the source key",
+			command:          []string{"GEOSEARCHSTORE", "GeoSearchStoreSource", "GeoSearchStoreSource", "FROMMEMBER", "Rome", "BYRADIUS", "10", "km"},
+			expectedResponse: 1,
+			expectedScores:   map[string]float64{"Rome": 3480343273965391},
+		},
+		{
+			name:          "4. Return error when a WITH option is provided",
+			command:       []string{"GEOSEARCHSTORE", "GeoSearchStoreKey4", "GeoSearchStoreSource", "FROMLONLAT", "15", "37", "BYRADIUS", "200", "km", "WITHDIST"},
+			expectedError: errors.New("syntax error"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res, err := getHandler("GEOSEARCHSTORE")(getHandlerFuncParams(ctx, test.command, nil))
+			if test.expectedError != nil {
+				if err == nil || err.Error() != test.expectedError.Error() {
+					t.Errorf("expected error \"%s\", got \"%v\"", test.expectedError.Error(), err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			rv, _, err := resp.NewReader(bytes.NewReader(res)).ReadValue()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rv.Integer() != test.expectedResponse {
+				t.Errorf("expected response %d, got %d", test.expectedResponse, rv.Integer())
+			}
+
+			destination := test.command[1]
+			if _, err = mockServer.KeyRLock(ctx, destination); err != nil {
+				t.Fatal(err)
+			}
+			defer mockServer.KeyRUnlock(ctx, destination)
+			set, ok := mockServer.GetValue(ctx, destination).(*sorted_set.SortedSet)
+			if !ok {
+				t.Fatalf("expected value at key %s to be a sorted set", destination)
+			}
+			if set.Cardinality() != len(test.expectedScores) {
+				t.Errorf("expected %d members, got %d", len(test.expectedScores), set.Cardinality())
+			}
+			for member, score := range test.expectedScores {
+				if got := set.Get(sorted_set.Value(member)); !got.Exists || math.Abs(float64(got.Score)-score) > 1e-9 {
+					t.Errorf("expected member %s to have score %f, got %+v", member, score, got)
+				}
+			}
+		})
+	}
+}
+
+func Test_HandleZSCAN(t *testing.T) {
+	ctx := context.WithValue(context.Background(), "test_name", "ZSCAN")
+
+	key := "ZScanKey1"
+	if _, err := mockServer.CreateKeyAndLock(ctx, key); err != nil {
+		t.Fatal(err)
+	}
+	members := make([]sorted_set.MemberParam, 20)
+	for i := range members {
+		members[i] = sorted_set.MemberParam{
+			Value: sorted_set.Value(fmt.Sprintf("member%d", i)),
+			Score: sorted_set.Score(i) + 0.5,
+		}
+	}
+	if err := mockServer.SetValue(ctx, key, sorted_set.NewSortedSet(members)); err != nil {
+		t.Fatal(err)
+	}
+	mockServer.KeyUnlock(ctx, key)
+
+	wrongTypeKey := "ZScanKey2"
+	if _, err := mockServer.CreateKeyAndLock(ctx, wrongTypeKey); err != nil {
+		t.Fatal(err)
+	}
+	if err := mockServer.SetValue(ctx, wrongTypeKey, "default value"); err != nil {
+		t.Fatal(err)
+	}
+	mockServer.KeyUnlock(ctx, wrongTypeKey)
+
+	// scan iterates the sorted set with the options until the returned cursor is 0.
+	scan := func(options ...string) ([]string, error) {
+		var elems []string
+		cursor := "0"
+		for i := 0; ; i++ {
+			if i > 1000 {
+				return nil, errors.New("scan did not complete")
+			}
+			command := append([]string{"ZSCAN", key, cursor}, options...)
+			res, err := getHandler("ZSCAN")(getHandlerFuncParams(ctx, command, nil))
+			if err != nil {
+				return nil, err
+			}
+			rv, _, err := resp.NewReader(bytes.NewReader(res)).ReadValue()
+			if err != nil {
+				return nil, err
+			}
+			for _, v := range rv.Array()[1].Array() {
+				elems = append(elems, v.String())
+			}
+			cursor = rv.Array()[0].String()
+			if cursor == "0" {
+				break
+			}
+		}
+		return elems, nil
+	}
+
+	t.Run("1. Iterate all the members exactly once", func(t *testing.T) {
+		elems, err := scan("COUNT", "3")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(elems) != 40 {
+			t.Fatalf("expected 20 members, got %d elements: %v", len(elems)/2, elems)
+		}
+		got := make(map[string]string)
+		for i := 0; i < len(elems); i += 2 {
+			if _, ok := got[elems[i]]; ok {
+				t.Errorf("member %s returned more than once", elems[i])
+			}
+			got[elems[i]] = elems[i+1]
+		}
+		for i := 0; i < 20; i++ {
+			if got[fmt.Sprintf("member%d", i)] != fmt.Sprintf("%d.5", i) {
+				t.Errorf("expected member%d to be %s, got %s", i, fmt.Sprintf("%d.5", i), got[fmt.Sprintf("member%d", i)])
+			}
+		}
+	})
+
+	t.Run("2. Only return the members that match the pattern", func(t *testing.T) {
+		elems, err := scan("MATCH", "member1*", "COUNT", "4")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var matched []string
+		for i := 0; i < len(elems); i += 2 {
+			matched = append(matched, elems[i])
+		}
+		slices.Sort(matched)
+		expected := []string{
+			"member1", "member10", "member11", "member12", "member13",
+			"member14", "member15", "member16", "member17", "member18", "member19",
+		}
+		if !slices.Equal(matched, expected) {
+			t.Errorf("expected members %v, got %v", expected, matched)
+		}
+	})
+
+	t.Run("3. Return an empty scan for a non-existent key", func(t *testing.T) {
+		res, err := getHandler("ZSCAN")(getHandlerFuncParams(ctx, []string{"ZSCAN", "ZScanKey3", "0"}, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(res, []byte("*2\r\n$1\r\n0\r\n*0\r\n")) {
+			t.Errorf("expected empty scan response, got %q", res)
+		}
+	})
+
+	errorTests := []struct {
+		name          string
+		command       []string
+		expectedError error
+	}{
+		{
+			name:          "4. Return error when the value is not a sorted set",
+			command:       []string{"ZSCAN", wrongTypeKey, "0"},
+			expectedError: errors.New("value at ZScanKey2 is not a sorted set"),
+		},
+		{
+			name:          "5. Return error when the cursor is not an unsigned integer",
+			command:       []string{"ZSCAN", key, "cursor"},
+			expectedError: errors.New("invalid cursor"),
+		},
+		{
+			name:          "6. Return error when TYPE option is passed",
+			command:       []string{"ZSCAN", key, "0", "TYPE", "string"},
+			expectedError: errors.New("syntax error"),
+		},
+		{
+			name:          "7. Command too short",
+			command:       []string{"ZSCAN", key},
+			expectedError: errors.New(constants.WrongArgsResponse),
+		},
+	}
+	for _, test := range errorTests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := getHandler("ZSCAN")(getHandlerFuncParams(ctx, test.command, nil))
+			if err == nil || test.expectedError.Error() != err.Error() {
+				t.Errorf("expected error \"%s\", got \"%v\"", test.expectedError.Error(), err)
+			}
+		})
+	}
+}
@@ -0,0 +1,332 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stream
+
+import (
+	"github.com/echovault/echovault/echovault"
+	"github.com/echovault/echovault/internal/clock"
+	"github.com/echovault/echovault/internal/config"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func createEchoVault() *echovault.EchoVault {
+	ev, _ := echovault.NewEchoVault(
+		echovault.WithClock(clock.NewManualClock(time.UnixMilli(1000))),
+		echovault.WithConfig(config.Config{
+			DataDir: "",
+		}),
+	)
+	return ev
+}
+
+func TestEchoVault_XADD(t *testing.T) {
+	server := createEchoVault()
+
+	tests := []struct {
+		name    string
+		key     string
+		id      string
+		fields  []string
+		options echovault.XAddOptions
+		want    string
+		wantLen int
+		wantErr bool
+	}{
+		{
+			name:    "Add an entry with an auto-generated ID",
+			key:     "key1",
+			id:      "*",
+			fields:  []string{"field1", "value1"},
+			want:    "1000-0",
+			wantLen: 1,
+			wantErr: false,
+		},
+		{
+			name:    "Auto-generated IDs are greater than the last ID",
+			key:     "key1",
+			id:      "*",
+			fields:  []string{"field2", "value2"},
+			want:    "1000-1",
+			wantLen: 2,
+			wantErr: false,
+		},
+		{
+			name:    "Cap the stream with MaxLen",
+			key:     "key1",
+			id:      "2000-0",
+			fields:  []string{"field3", "value3"},
+			options: echovault.XAddOptions{MaxLen: 2},
+			want:    "2000-0",
+			wantLen: 2,
+			wantErr: false,
+		},
+		{
+			name:    "Do not create the stream with NoMkStream",
+			key:     "key2",
+			id:      "*",
+			fields:  []string{"field1", "value1"},
+			options: echovault.XAddOptions{NoMkStream: true},
+			want:    "",
+			wantLen: 0,
+			wantErr: false,
+		},
+		{
+			name:    "Return error when the ID is smaller than the last ID",
+			key:     "key1",
+			id:      "1-1",
+			fields:  []string{"field1", "value1"},
+			want:    "",
+			wantLen: 2,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := server.XAdd(tt.key, tt.id, tt.fields, tt.options)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("XADD() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("XADD() got = %v, want %v", got, tt.want)
+			}
+			length, err := server.XLen(tt.key)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if length != tt.wantLen {
+				t.Errorf("XLEN() got = %v, want %v", length, tt.wantLen)
+			}
+		})
+	}
+}
+
+func TestEchoVault_XRANGE(t *testing.T) {
+	server := createEchoVault()
+
+	for _, id := range []string{"1-1", "1-2", "2-1"} {
+		if _, err := server.XAdd("key1", id, []string{"field", id}, echovault.XAddOptions{}); err != nil {
+			t.Error(err)
+			return
+		}
+	}
+
+	entries := []echovault.StreamEntry{
+		{ID: "1-1", Fields: []string{"field", "1-1"}},
+		{ID: "1-2", Fields: []string{"field", "1-2"}},
+		{ID: "2-1", Fields: []string{"field", "2-1"}},
+	}
+
+	tests := []struct {
+		name      string
+		rangeFunc func(key string, from string, to string, count uint) ([]echovault.StreamEntry, error)
+		key       string
+		from      string
+		to        string
+		count     uint
+		want      []echovault.StreamEntry
+		wantErr   bool
+	}{
+		{
+			name:      "Return all the entries",
+			rangeFunc: server.XRange,
+			key:       "key1",
+			from:      "-",
+			to:        "+",
+			want:      entries,
+			wantErr:   false,
+		},
+		{
+			name:      "Return the entries within the millisecond",
+			rangeFunc: server.XRange,
+			key:       "key1",
+			from:      "1",
+			to:        "1",
+			want:      entries[:2],
+			wantErr:   false,
+		},
+		{
+			name:      "Return the entries in reverse order with count",
+			rangeFunc: server.XRevRange,
+			key:       "key1",
+			from:      "+",
+			to:        "-",
+			count:     2,
+			want:      []echovault.StreamEntry{entries[2], entries[1]},
+			wantErr:   false,
+		},
+		{
+			name:      "Return an empty slice when the key does not exist",
+			rangeFunc: server.XRange,
+			key:       "key2",
+			from:      "-",
+			to:        "+",
+			want:      []echovault.StreamEntry{},
+			wantErr:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.rangeFunc(tt.key, tt.from, tt.to, tt.count)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("XRANGE() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("XRANGE() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEchoVault_XREAD(t *testing.T) {
+	server := createEchoVault()
+
+	if _, err := server.XAdd("key1", "1-1", []string{"field", "value"}, echovault.XAddOptions{}); err != nil {
+		t.Error(err)
+		return
+	}
+
+	t.Run("Read the entries after the ID", func(t *testing.T) {
+		got, err := server.XRead(map[string]string{"key1": "0", "key2": "0"}, echovault.XReadOptions{})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		want := map[string][]echovault.StreamEntry{"key1": {{ID: "1-1", Fields: []string{"field", "value"}}}}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("XREAD() got = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("Block until an entry is added", func(t *testing.T) {
+		go func() {
+			<-time.After(50 * time.Millisecond)
+			if _, err := server.XAdd("key1", "2-1", []string{"field", "value"}, echovault.XAddOptions{}); err != nil {
+				t.Error(err)
+			}
+		}()
+		got, err := server.XRead(map[string]string{"key1": "$"}, echovault.XReadOptions{Block: true, Timeout: 5 * time.Second})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		want := map[string][]echovault.StreamEntry{"key1": {{ID: "2-1", Fields: []string{"field", "value"}}}}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("XREAD() got = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("Return an empty map when the timeout expires", func(t *testing.T) {
+		got, err := server.XRead(map[string]string{"key1": "$"}, echovault.XReadOptions{Block: true, Timeout: 100 * time.Millisecond})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if len(got) != 0 {
+			t.Errorf("XREAD() got = %v, want empty map", got)
+		}
+	})
+}
+
+func TestEchoVault_XREADGROUP(t *testing.T) {
+	server := createEchoVault()
+
+	for _, id := range []string{"1-1", "1-2"} {
+		if _, err := server.XAdd("key1", id, []string{"field", id}, echovault.XAddOptions{}); err != nil {
+			t.Error(err)
+			return
+		}
+	}
+
+	t.Run("Create a consumer group", func(t *testing.T) {
+		if _, err := server.XGroupCreate("key1", "group", "0", false); err != nil {
+			t.Error(err)
+			return
+		}
+		if _, err := server.XGroupCreate("key1", "group", "0", false); err == nil {
+			t.Error("expected error when the group already exists")
+		}
+	})
+
+	t.Run("Read new entries and track them as pending", func(t *testing.T) {
+		got, err := server.XReadGroup("group", "alice", map[string]string{"key1": ">"}, echovault.XReadGroupOptions{})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		want := map[string][]echovault.StreamEntry{"key1": {
+			{ID: "1-1", Fields: []string{"field", "1-1"}},
+			{ID: "1-2", Fields: []string{"field", "1-2"}},
+		}}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("XREADGROUP() got = %v, want %v", got, want)
+		}
+
+		summary, err := server.XPending("key1", "group")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		wantSummary := echovault.StreamPendingSummary{Count: 2, MinID: "1-1", MaxID: "1-2", Consumers: map[string]int{"alice": 2}}
+		if !reflect.DeepEqual(summary, wantSummary) {
+			t.Errorf("XPENDING() got = %v, want %v", summary, wantSummary)
+		}
+	})
+
+	t.Run("Claim and acknowledge pending entries", func(t *testing.T) {
+		claimed, err := server.XClaim("key1", "group", "bob", 0, []string{"1-2"}, echovault.XClaimOptions{JustID: true})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if !reflect.DeepEqual(claimed, []echovault.StreamEntry{{ID: "1-2"}}) {
+			t.Errorf("XCLAIM() got = %v", claimed)
+		}
+
+		pending, err := server.XPendingRange("key1", "group", "-", "+", 10, echovault.XPendingRangeOptions{Consumer: "bob"})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		wantPending := []echovault.StreamPendingEntry{{ID: "1-2", Consumer: "bob", Idle: 0, DeliveryCount: 1}}
+		if !reflect.DeepEqual(pending, wantPending) {
+			t.Errorf("XPENDING() got = %v, want %v", pending, wantPending)
+		}
+
+		acked, err := server.XAck("key1", "group", "1-1", "1-2")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if acked != 2 {
+			t.Errorf("XACK() got = %v, want 2", acked)
+		}
+	})
+
+	t.Run("Destroy the consumer group", func(t *testing.T) {
+		ok, err := server.XGroupDestroy("key1", "group")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if !ok {
+			t.Error("XGROUP DESTROY got = false, want true")
+		}
+	})
+}
@@ -0,0 +1,1154 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stream
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/echovault/echovault/echovault"
+	"github.com/echovault/echovault/internal"
+	"github.com/echovault/echovault/internal/clock"
+	"github.com/echovault/echovault/internal/config"
+	"github.com/echovault/echovault/internal/constants"
+	"github.com/echovault/echovault/internal/keywait"
+	"github.com/echovault/echovault/internal/modules/stream"
+	"github.com/tidwall/resp"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"unsafe"
+)
+
+var mockClock = clock.NewManualClock(time.UnixMilli(1000))
+var mockServer *echovault.EchoVault
+
+func init() {
+	mockServer, _ = echovault.NewEchoVault(
+		echovault.WithClock(mockClock),
+		echovault.WithConfig(config.Config{
+			DataDir:        "",
+			EvictionPolicy: constants.NoEviction,
+		}),
+	)
+}
+
+func getUnexportedField(field reflect.Value) interface{} {
+	return reflect.NewAt(field.Type(), unsafe.Pointer(field.UnsafeAddr())).Elem().Interface()
+}
+
+func getHandler(commands ...string) internal.HandlerFunc {
+	if len(commands) == 0 {
+		return nil
+	}
+	getCommands :=
+		getUnexportedField(reflect.ValueOf(mockServer).Elem().FieldByName("getCommands")).(func() []internal.Command)
+	for _, c := range getCommands() {
+		if strings.EqualFold(commands[0], c.Command) && len(commands) == 1 {
+			// Get command handler
+			return c.HandlerFunc
+		}
+		if strings.EqualFold(commands[0], c.Command) {
+			// Get sub-command handler
+			for _, sc := range c.SubCommands {
+				if strings.EqualFold(commands[1], sc.Command) {
+					return sc.HandlerFunc
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func getHandlerFuncParams(ctx context.Context, cmd []string, conn *net.Conn) internal.HandlerFuncParams {
+	return internal.HandlerFuncParams{
+		Context:          ctx,
+		Command:          cmd,
+		Connection:       conn,
+		KeyExists:        mockServer.KeyExists,
+		CreateKeyAndLock: mockServer.CreateKeyAndLock,
+		KeyLock:          mockServer.KeyLock,
+		KeyRLock:         mockServer.KeyRLock,
+		KeyUnlock:        mockServer.KeyUnlock,
+		KeyRUnlock:       mockServer.KeyRUnlock,
+		GetValue:         mockServer.GetValue,
+		SetValue:         mockServer.SetValue,
+		GetClock: func() clock.Clock {
+			return mockClock
+		},
+		WatchKeys: getUnexportedField(
+			reflect.ValueOf(mockServer).Elem().FieldByName("keyWaiters")).(*keywait.Registry).Watch,
+	}
+}
+
+// presetStream creates a stream at the key with an entry for each of the IDs.
+func presetStream(ctx context.Context, key string, ids ...string) error {
+	if _, err := mockServer.CreateKeyAndLock(ctx, key); err != nil {
+		return err
+	}
+	defer mockServer.KeyUnlock(ctx, key)
+	s := stream.NewStream()
+	for _, id := range ids {
+		parsed, err := stream.ParseID(id, 0)
+		if err != nil {
+			return err
+		}
+		if err = s.Add(parsed, []string{"field", id}); err != nil {
+			return err
+		}
+	}
+	return mockServer.SetValue(ctx, key, s)
+}
+
+// readEntries decodes an array of stream entries into an array of "<id> <field> <value> ..." strings.
+func readEntries(v resp.Value) []string {
+	res := make([]string, 0)
+	for _, entry := range v.Array() {
+		fields := []string{entry.Array()[0].String()}
+		for _, field := range entry.Array()[1].Array() {
+			fields = append(fields, field.String())
+		}
+		res = append(res, strings.Join(fields, " "))
+	}
+	return res
+}
+
+func Test_HandleXADD(t *testing.T) {
+	tests := []struct {
+		name             string
+		presetValue      interface{}
+		presetIDs        []string
+		key              string
+		command          []string
+		expectedResponse string // Empty string for a nil response.
+		expectedEntries  []string
+		expectedError    error
+	}{
+		{
+			name:             "1. Create a new stream with an auto-generated ID",
+			key:              "XAddKey1",
+			command:          []string{"XADD", "XAddKey1", "*", "field1", "value1"},
+			expectedResponse: "1000-0",
+			expectedEntries:  []string{"1000-0 field1 value1"},
+			expectedError:    nil,
+		},
+		{
+			name:             "2. Increment the sequence number when the clock is behind the last ID",
+			presetIDs:        []string{"5000-3"},
+			key:              "XAddKey2",
+			command:          []string{"XADD", "XAddKey2", "*", "field1", "value1"},
+			expectedResponse: "5000-4",
+			expectedEntries:  []string{"5000-3 field 5000-3", "5000-4 field1 value1"},
+			expectedError:    nil,
+		},
+		{
+			name:             "3. Add an entry with an explicit ID",
+			presetIDs:        []string{"1-1"},
+			key:              "XAddKey3",
+			command:          []string{"XADD", "XAddKey3", "2-5", "field1", "value1", "field2", "value2"},
+			expectedResponse: "2-5",
+			expectedEntries:  []string{"1-1 field 1-1", "2-5 field1 value1 field2 value2"},
+			expectedError:    nil,
+		},
+		{
+			name:             "4. Generate the sequence number of a partial ID",
+			presetIDs:        []string{"7-2"},
+			key:              "XAddKey4",
+			command:          []string{"XADD", "XAddKey4", "7-*", "field1", "value1"},
+			expectedResponse: "7-3",
+			expectedEntries:  []string{"7-2 field 7-2", "7-3 field1 value1"},
+			expectedError:    nil,
+		},
+		{
+			name:             "5. Trim the oldest entries with MAXLEN",
+			presetIDs:        []string{"1-1", "1-2", "1-3"},
+			key:              "XAddKey5",
+			command:          []string{"XADD", "XAddKey5", "MAXLEN", "~", "2", "2-1", "field1", "value1"},
+			expectedResponse: "2-1",
+			expectedEntries:  []string{"1-3 field 1-3", "2-1 field1 value1"},
+			expectedError:    nil,
+		},
+		{
+			name:             "6. Do not create the stream with NOMKSTREAM",
+			key:              "XAddKey6",
+			command:          []string{"XADD", "XAddKey6", "NOMKSTREAM", "*", "field1", "value1"},
+			expectedResponse: "",
+			expectedEntries:  nil,
+			expectedError:    nil,
+		},
+		{
+			name:          "7. Return error when the ID is not greater than the last ID",
+			presetIDs:     []string{"5-5"},
+			key:           "XAddKey7",
+			command:       []string{"XADD", "XAddKey7", "5-5", "field1", "value1"},
+			expectedError: errors.New("the ID specified in XADD is equal or smaller than the target stream top item"),
+		},
+		{
+			name:          "8. Return error when the ID is 0-0",
+			key:           "XAddKey8",
+			command:       []string{"XADD", "XAddKey8", "0-0", "field1", "value1"},
+			expectedError: errors.New("the ID specified in XADD must be greater than 0-0"),
+		},
+		{
+			name:          "9. Return error when the ID is invalid",
+			key:           "XAddKey9",
+			command:       []string{"XADD", "XAddKey9", "abc", "field1", "value1"},
+			expectedError: errors.New("invalid stream ID specified as stream command argument"),
+		},
+		{
+			name:          "10. Return error when the value is not a stream",
+			presetValue:   "Default value",
+			key:           "XAddKey10",
+			command:       []string{"XADD", "XAddKey10", "*", "field1", "value1"},
+			expectedError: errors.New("value at key XAddKey10 is not a stream"),
+		},
+		{
+			name:          "11. Return error when a field does not have a value",
+			key:           "XAddKey11",
+			command:       []string{"XADD", "XAddKey11", "*", "field1", "value1", "field2"},
+			expectedError: errors.New(constants.WrongArgsResponse),
+		},
+		{
+			name:          "12. Command too short",
+			key:           "XAddKey12",
+			command:       []string{"XADD", "XAddKey12", "*", "field1"},
+			expectedError: errors.New(constants.WrongArgsResponse),
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), "test_name", fmt.Sprintf("XADD, %d", i))
+
+			if test.presetIDs != nil {
+				if err := presetStream(ctx, test.key, test.presetIDs...); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+			if test.presetValue != nil {
+				if _, err := mockServer.CreateKeyAndLock(ctx, test.key); err != nil {
+					t.Error(err)
+					return
+				}
+				if err := mockServer.SetValue(ctx, test.key, test.presetValue); err != nil {
+					t.Error(err)
+					return
+				}
+				mockServer.KeyUnlock(ctx, test.key)
+			}
+
+			handler := getHandler(test.command[0])
+			if handler == nil {
+				t.Errorf("no handler found for command %s", test.command[0])
+				return
+			}
+
+			res, err := handler(getHandlerFuncParams(ctx, test.command, nil))
+			if test.expectedError != nil {
+				if err == nil || err.Error() != test.expectedError.Error() {
+					t.Errorf("expected error \"%s\", got \"%v\"", test.expectedError.Error(), err)
+				}
+				return
+			}
+			if err != nil {
+				t.Error(err)
+				return
+			}
+
+			rd := resp.NewReader(bytes.NewReader(res))
+			rv, _, err := rd.ReadValue()
+			if err != nil {
+				t.Error(err)
+			}
+			if test.expectedResponse == "" {
+				if !rv.IsNull() {
+					t.Errorf("expected nil response, got %s", rv.String())
+				}
+			} else if rv.String() != test.expectedResponse {
+				t.Errorf("expected response %s, got %s", test.expectedResponse, rv.String())
+			}
+
+			if test.expectedEntries == nil {
+				if mockServer.KeyExists(ctx, test.key) {
+					t.Errorf("expected key %s not to exist", test.key)
+				}
+				return
+			}
+			res, err = getHandler("XRANGE")(getHandlerFuncParams(ctx, []string{"XRANGE", test.key, "-", "+"}, nil))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			rv, _, _ = resp.NewReader(bytes.NewReader(res)).ReadValue()
+			if entries := readEntries(rv); !reflect.DeepEqual(entries, test.expectedEntries) {
+				t.Errorf("expected entries %+v, got %+v", test.expectedEntries, entries)
+			}
+		})
+	}
+}
+
+func Test_HandleXLEN(t *testing.T) {
+	tests := []struct {
+		name             string
+		presetIDs        []string
+		command          []string
+		expectedResponse int
+		expectedError    error
+	}{
+		{
+			name:             "1. Return the number of entries in the stream",
+			presetIDs:        []string{"1-1", "1-2", "2-1"},
+			command:          []string{"XLEN", "XLenKey1"},
+			expectedResponse: 3,
+			expectedError:    nil,
+		},
+		{
+			name:             "2. Return 0 when the key does not exist",
+			command:          []string{"XLEN", "XLenKey2"},
+			expectedResponse: 0,
+			expectedError:    nil,
+		},
+		{
+			name:          "3. Command too long",
+			command:       []string{"XLEN", "XLenKey3", "XLenKey4"},
+			expectedError: errors.New(constants.WrongArgsResponse),
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), "test_name", fmt.Sprintf("XLEN, %d", i))
+
+			if test.presetIDs != nil {
+				if err := presetStream(ctx, test.command[1], test.presetIDs...); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+
+			res, err := getHandler(test.command[0])(getHandlerFuncParams(ctx, test.command, nil))
+			if test.expectedError != nil {
+				if err == nil || err.Error() != test.expectedError.Error() {
+					t.Errorf("expected error \"%s\", got \"%v\"", test.expectedError.Error(), err)
+				}
+				return
+			}
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			rv, _, _ := resp.NewReader(bytes.NewReader(res)).ReadValue()
+			if rv.Integer() != test.expectedResponse {
+				t.Errorf("expected response %d, got %d", test.expectedResponse, rv.Integer())
+			}
+		})
+	}
+}
+
+func Test_HandleXRANGE(t *testing.T) {
+	// All the test cases read from the same stream.
+	if err := presetStream(context.Background(), "XRangeKey", "1-1", "1-2", "2-1", "3-1", "3-2"); err != nil {
+		t.Error(err)
+		return
+	}
+
+	tests := []struct {
+		name             string
+		command          []string
+		expectedResponse []string
+		expectedError    error
+	}{
+		{
+			name:             "1. Return all the entries",
+			command:          []string{"XRANGE", "XRangeKey", "-", "+"},
+			expectedResponse: []string{"1-1 field 1-1", "1-2 field 1-2", "2-1 field 2-1", "3-1 field 3-1", "3-2 field 3-2"},
+			expectedError:    nil,
+		},
+		{
+			name:             "2. Incomplete IDs cover the whole millisecond",
+			command:          []string{"XRANGE", "XRangeKey", "1", "2"},
+			expectedResponse: []string{"1-1 field 1-1", "1-2 field 1-2", "2-1 field 2-1"},
+			expectedError:    nil,
+		},
+		{
+			name:             "3. Exclusive ranges with COUNT",
+			command:          []string{"XRANGE", "XRangeKey", "(1-1", "+", "COUNT", "2"},
+			expectedResponse: []string{"1-2 field 1-2", "2-1 field 2-1"},
+			expectedError:    nil,
+		},
+		{
+			name:             "4. XREVRANGE returns the entries in reverse order",
+			command:          []string{"XREVRANGE", "XRangeKey", "+", "(2-1", "COUNT", "5"},
+			expectedResponse: []string{"3-2 field 3-2", "3-1 field 3-1"},
+			expectedError:    nil,
+		},
+		{
+			name:             "5. XREVRANGE with COUNT returns the newest entries",
+			command:          []string{"XREVRANGE", "XRangeKey", "+", "-", "COUNT", "2"},
+			expectedResponse: []string{"3-2 field 3-2", "3-1 field 3-1"},
+			expectedError:    nil,
+		},
+		{
+			name:             "6. Return an empty array when the range is inverted",
+			command:          []string{"XRANGE", "XRangeKey", "3", "1"},
+			expectedResponse: []string{},
+			expectedError:    nil,
+		},
+		{
+			name:             "7. Return an empty array when the key does not exist",
+			command:          []string{"XRANGE", "XRangeKey2", "-", "+"},
+			expectedResponse: []string{},
+			expectedError:    nil,
+		},
+		{
+			name:          "8. Return error when the ID is invalid",
+			command:       []string{"XRANGE", "XRangeKey", "abc", "+"},
+			expectedError: errors.New("invalid stream ID specified as stream command argument"),
+		},
+		{
+			name:          "9. Return error when COUNT is not an integer",
+			command:       []string{"XRANGE", "XRangeKey", "-", "+", "COUNT", "abc"},
+			expectedError: errors.New("count must be a non-negative integer"),
+		},
+		{
+			name:          "10. Command too short",
+			command:       []string{"XRANGE", "XRangeKey", "-"},
+			expectedError: errors.New(constants.WrongArgsResponse),
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), "test_name", fmt.Sprintf("XRANGE, %d", i))
+
+			res, err := getHandler(test.command[0])(getHandlerFuncParams(ctx, test.command, nil))
+			if test.expectedError != nil {
+				if err == nil || err.Error() != test.expectedError.Error() {
+					t.Errorf("expected error \"%s\", got \"%v\"", test.expectedError.Error(), err)
+				}
+				return
+			}
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			rv, _, _ := resp.NewReader(bytes.NewReader(res)).ReadValue()
+			if entries := readEntries(rv); !reflect.DeepEqual(entries, test.expectedResponse) {
+				t.Errorf("expected response %+v, got %+v", test.expectedResponse, entries)
+			}
+		})
+	}
+}
+
+func Test_HandleXREAD(t *testing.T) {
+	tests := []struct {
+		name             string
+		presetIDs        map[string][]string
+		addAfter         []string // XADD command to run after the command has started blocking.
+		command          []string
+		expectedResponse map[string][]string // Nil for a nil response.
+		expectedError    error
+	}{
+		{
+			name: "1. Read the entries after the IDs from multiple streams",
+			presetIDs: map[string][]string{
+				"XReadKey1": {"1-1", "1-2"},
+				"XReadKey2": {"2-1"},
+				"XReadKey3": {"3-1"},
+			},
+			command: []string{"XREAD", "STREAMS", "XReadKey1", "XReadKey2", "XReadKey3", "1-1", "0", "3-1"},
+			expectedResponse: map[string][]string{
+				"XReadKey1": {"1-2 field 1-2"},
+				"XReadKey2": {"2-1 field 2-1"},
+			},
+			expectedError: nil,
+		},
+		{
+			name:             "2. Limit the entries read from each stream with COUNT",
+			presetIDs:        map[string][]string{"XReadKey4": {"1-1", "1-2", "1-3"}},
+			command:          []string{"XREAD", "COUNT", "2", "STREAMS", "XReadKey4", "0-0"},
+			expectedResponse: map[string][]string{"XReadKey4": {"1-1 field 1-1", "1-2 field 1-2"}},
+			expectedError:    nil,
+		},
+		{
+			name:             "3. Return nil when there are no new entries",
+			presetIDs:        map[string][]string{"XReadKey5": {"1-1"}},
+			command:          []string{"XREAD", "STREAMS", "XReadKey5", "XReadKey6", "$", "0"},
+			expectedResponse: nil,
+			expectedError:    nil,
+		},
+		{
+			name:             "4. Return nil when the block timeout expires",
+			presetIDs:        map[string][]string{"XReadKey7": {"1-1"}},
+			command:          []string{"XREAD", "BLOCK", "100", "STREAMS", "XReadKey7", "$"},
+			expectedResponse: nil,
+			expectedError:    nil,
+		},
+		{
+			name:             "5. Wake up when an entry is added while blocking",
+			presetIDs:        map[string][]string{"XReadKey8": {"1-1"}},
+			addAfter:         []string{"XADD", "XReadKey8", "2-1", "field", "2-1"},
+			command:          []string{"XREAD", "BLOCK", "5000", "STREAMS", "XReadKey8", "$"},
+			expectedResponse: map[string][]string{"XReadKey8": {"2-1 field 2-1"}},
+			expectedError:    nil,
+		},
+		{
+			name:          "6. Return error when the number of keys and IDs do not match",
+			command:       []string{"XREAD", "STREAMS", "XReadKey9", "XReadKey10", "0"},
+			expectedError: errors.New("unbalanced list of streams: an ID or '$' must be specified for each key"),
+		},
+		{
+			name:          "7. Return error when the block timeout is invalid",
+			command:       []string{"XREAD", "BLOCK", "-1", "STREAMS", "XReadKey11", "0"},
+			expectedError: errors.New("timeout must be a non-negative integer"),
+		},
+		{
+			name:          "8. Command too short",
+			command:       []string{"XREAD", "STREAMS"},
+			expectedError: errors.New(constants.WrongArgsResponse),
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), "test_name", fmt.Sprintf("XREAD, %d", i))
+
+			for key, ids := range test.presetIDs {
+				if err := presetStream(ctx, key, ids...); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+
+			if test.addAfter != nil {
+				go func() {
+					<-time.After(50 * time.Millisecond)
+					if _, err := getHandler("XADD")(getHandlerFuncParams(ctx, test.addAfter, nil)); err != nil {
+						t.Error(err)
+					}
+				}()
+			}
+
+			res, err := getHandler(test.command[0])(getHandlerFuncParams(ctx, test.command, nil))
+			if test.expectedError != nil {
+				if err == nil || err.Error() != test.expectedError.Error() {
+					t.Errorf("expected error \"%s\", got \"%v\"", test.expectedError.Error(), err)
+				}
+				return
+			}
+			if err != nil {
+				t.Error(err)
+				return
+			}
+
+			rv, _, _ := resp.NewReader(bytes.NewReader(res)).ReadValue()
+			if test.expectedResponse == nil {
+				if !rv.IsNull() {
+					t.Errorf("expected nil response, got %+v", rv)
+				}
+				return
+			}
+			got := make(map[string][]string)
+			for _, s := range rv.Array() {
+				got[s.Array()[0].String()] = readEntries(s.Array()[1])
+			}
+			if !reflect.DeepEqual(got, test.expectedResponse) {
+				t.Errorf("expected response %+v, got %+v", test.expectedResponse, got)
+			}
+		})
+	}
+}
+
+// presetGroup creates a stream at the key with an entry for each of the IDs, and a consumer group
+// that delivers all the entries of the stream.
+func presetGroup(ctx context.Context, key string, group string, ids ...string) error {
+	if err := presetStream(ctx, key, ids...); err != nil {
+		return err
+	}
+	if _, err := mockServer.KeyLock(ctx, key); err != nil {
+		return err
+	}
+	defer mockServer.KeyUnlock(ctx, key)
+	mockServer.GetValue(ctx, key).(*stream.Stream).CreateGroup(group, stream.ID{})
+	return nil
+}
+
+// runCommands runs each of the commands with its handler, including sub-command handlers.
+func runCommands(ctx context.Context, commands ...[]string) error {
+	for _, command := range commands {
+		handler := getHandler(command[0])
+		if handler == nil {
+			handler = getHandler(command[0], command[1])
+		}
+		if _, err := handler(getHandlerFuncParams(ctx, command, nil)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func Test_HandleXGROUP(t *testing.T) {
+	if err := presetGroup(context.Background(), "XGroupKey1", "existing", "1-1"); err != nil {
+		t.Error(err)
+		return
+	}
+
+	tests := []struct {
+		name             string
+		command          []string
+		expectedResponse string
+		expectedError    error
+	}{
+		{
+			name:             "1. Create a group on an existing stream",
+			command:          []string{"XGROUP", "CREATE", "XGroupKey1", "group1", "$"},
+			expectedResponse: "+OK\r\n",
+			expectedError:    nil,
+		},
+		{
+			name:          "2. Return error when the group already exists",
+			command:       []string{"XGROUP", "CREATE", "XGroupKey1", "existing", "0"},
+			expectedError: errors.New("consumer group existing already exists"),
+		},
+		{
+			name:             "3. Create the stream with MKSTREAM",
+			command:          []string{"XGROUP", "CREATE", "XGroupKey2", "group1", "0", "MKSTREAM"},
+			expectedResponse: "+OK\r\n",
+			expectedError:    nil,
+		},
+		{
+			name:          "4. Return error when the key does not exist without MKSTREAM",
+			command:       []string{"XGROUP", "CREATE", "XGroupKey3", "group1", "0"},
+			expectedError: errors.New("key XGroupKey3 does not exist, use MKSTREAM to create an empty stream"),
+		},
+		{
+			name:          "5. Return error when the ID is invalid",
+			command:       []string{"XGROUP", "CREATE", "XGroupKey1", "group2", "abc"},
+			expectedError: errors.New("invalid stream ID specified as stream command argument"),
+		},
+		{
+			name:             "6. Destroy an existing group",
+			command:          []string{"XGROUP", "DESTROY", "XGroupKey1", "existing"},
+			expectedResponse: ":1\r\n",
+			expectedError:    nil,
+		},
+		{
+			name:             "7. Return 0 when destroying a group that does not exist",
+			command:          []string{"XGROUP", "DESTROY", "XGroupKey1", "group3"},
+			expectedResponse: ":0\r\n",
+			expectedError:    nil,
+		},
+		{
+			name:          "8. Command too short",
+			command:       []string{"XGROUP", "CREATE", "XGroupKey1", "group1"},
+			expectedError: errors.New(constants.WrongArgsResponse),
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), "test_name", fmt.Sprintf("XGROUP, %d", i))
+
+			res, err := getHandler(test.command[0], test.command[1])(getHandlerFuncParams(ctx, test.command, nil))
+			if test.expectedError != nil {
+				if err == nil || err.Error() != test.expectedError.Error() {
+					t.Errorf("expected error \"%s\", got \"%v\"", test.expectedError.Error(), err)
+				}
+				return
+			}
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if string(res) != test.expectedResponse {
+				t.Errorf("expected response %q, got %q", test.expectedResponse, string(res))
+			}
+		})
+	}
+}
+
+func Test_HandleXREADGROUP(t *testing.T) {
+	tests := []struct {
+		name             string
+		key              string
+		presetIDs        []string
+		setup            [][]string // Commands to run before the command.
+		addAfter         []string   // XADD command to run after the command has started blocking.
+		command          []string
+		expectedResponse []string // Nil for a nil response.
+		expectedError    error
+	}{
+		{
+			name:             "1. Read the entries that have not been delivered with COUNT",
+			key:              "XReadGroupKey1",
+			presetIDs:        []string{"1-1", "1-2", "1-3"},
+			command:          []string{"XREADGROUP", "GROUP", "group", "alice", "COUNT", "2", "STREAMS", "XReadGroupKey1", ">"},
+			expectedResponse: []string{"1-1 field 1-1", "1-2 field 1-2"},
+			expectedError:    nil,
+		},
+		{
+			name:      "2. Entries are only delivered to one consumer of the group",
+			key:       "XReadGroupKey2",
+			presetIDs: []string{"1-1", "1-2"},
+			setup: [][]string{
+				{"XREADGROUP", "GROUP", "group", "alice", "COUNT", "1", "STREAMS", "XReadGroupKey2", ">"},
+			},
+			command:          []string{"XREADGROUP", "GROUP", "group", "bob", "STREAMS", "XReadGroupKey2", ">"},
+			expectedResponse: []string{"1-2 field 1-2"},
+			expectedError:    nil,
+		},
+		{
+			name:      "3. Return nil when there are no new entries",
+			key:       "XReadGroupKey3",
+			presetIDs: []string{"1-1"},
+			setup: [][]string{
+				{"XREADGROUP", "GROUP", "group", "alice", "STREAMS", "XReadGroupKey3", ">"},
+			},
+			command:          []string{"XREADGROUP", "GROUP", "group", "alice", "STREAMS", "XReadGroupKey3", ">"},
+			expectedResponse: nil,
+			expectedError:    nil,
+		},
+		{
+			name:      "4. Read the consumer's pending entries with an explicit ID",
+			key:       "XReadGroupKey4",
+			presetIDs: []string{"1-1", "1-2", "1-3"},
+			setup: [][]string{
+				{"XREADGROUP", "GROUP", "group", "alice", "COUNT", "2", "STREAMS", "XReadGroupKey4", ">"},
+				{"XREADGROUP", "GROUP", "group", "bob", "STREAMS", "XReadGroupKey4", ">"},
+			},
+			command:          []string{"XREADGROUP", "GROUP", "group", "alice", "STREAMS", "XReadGroupKey4", "0"},
+			expectedResponse: []string{"1-1 field 1-1", "1-2 field 1-2"},
+			expectedError:    nil,
+		},
+		{
+			name:      "5. NOACK does not add the entries to the pending entries list",
+			key:       "XReadGroupKey5",
+			presetIDs: []string{"1-1"},
+			setup: [][]string{
+				{"XREADGROUP", "GROUP", "group", "alice", "NOACK", "STREAMS", "XReadGroupKey5", ">"},
+			},
+			command:          []string{"XREADGROUP", "GROUP", "group", "alice", "STREAMS", "XReadGroupKey5", "0"},
+			expectedResponse: []string{},
+			expectedError:    nil,
+		},
+		{
+			name:             "6. Return nil when the block timeout expires",
+			key:              "XReadGroupKey6",
+			presetIDs:        []string{},
+			command:          []string{"XREADGROUP", "GROUP", "group", "alice", "BLOCK", "100", "STREAMS", "XReadGroupKey6", ">"},
+			expectedResponse: nil,
+			expectedError:    nil,
+		},
+		{
+			name:             "7. Wake up when an entry is added while blocking",
+			key:              "XReadGroupKey7",
+			presetIDs:        []string{},
+			addAfter:         []string{"XADD", "XReadGroupKey7", "2-1", "field", "2-1"},
+			command:          []string{"XREADGROUP", "GROUP", "group", "alice", "BLOCK", "5000", "STREAMS", "XReadGroupKey7", ">"},
+			expectedResponse: []string{"2-1 field 2-1"},
+			expectedError:    nil,
+		},
+		{
+			name:          "8. Return error when the group does not exist",
+			key:           "XReadGroupKey8",
+			presetIDs:     []string{"1-1"},
+			command:       []string{"XREADGROUP", "GROUP", "missing", "alice", "STREAMS", "XReadGroupKey8", ">"},
+			expectedError: errors.New("no such key XReadGroupKey8 or consumer group missing"),
+		},
+		{
+			name:          "9. Command too short",
+			command:       []string{"XREADGROUP", "GROUP", "group", "alice", "STREAMS", "XReadGroupKey9"},
+			expectedError: errors.New(constants.WrongArgsResponse),
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), "test_name", fmt.Sprintf("XREADGROUP, %d", i))
+
+			if test.presetIDs != nil {
+				if err := presetGroup(ctx, test.key, "group", test.presetIDs...); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+			if err := runCommands(ctx, test.setup...); err != nil {
+				t.Error(err)
+				return
+			}
+
+			if test.addAfter != nil {
+				go func() {
+					<-time.After(50 * time.Millisecond)
+					if _, err := getHandler("XADD")(getHandlerFuncParams(ctx, test.addAfter, nil)); err != nil {
+						t.Error(err)
+					}
+				}()
+			}
+
+			res, err := getHandler(test.command[0])(getHandlerFuncParams(ctx, test.command, nil))
+			if test.expectedError != nil {
+				if err == nil || err.Error() != test.expectedError.Error() {
+					t.Errorf("expected error \"%s\", got \"%v\"", test.expectedError.Error(), err)
+				}
+				return
+			}
+			if err != nil {
+				t.Error(err)
+				return
+			}
+
+			rv, _, _ := resp.NewReader(bytes.NewReader(res)).ReadValue()
+			if test.expectedResponse == nil {
+				if !rv.IsNull() {
+					t.Errorf("expected nil response, got %+v", rv)
+				}
+				return
+			}
+			if len(rv.Array()) != 1 || rv.Array()[0].Array()[0].String() != test.key {
+				t.Errorf("expected response for key %s, got %+v", test.key, rv)
+				return
+			}
+			if entries := readEntries(rv.Array()[0].Array()[1]); !reflect.DeepEqual(entries, test.expectedResponse) {
+				t.Errorf("expected response %+v, got %+v", test.expectedResponse, entries)
+			}
+		})
+	}
+}
+
+func Test_HandleXACK(t *testing.T) {
+	tests := []struct {
+		name             string
+		key              string
+		presetIDs        []string
+		setup            [][]string
+		command          []string
+		expectedResponse int
+		expectedPending  int // The number of entries pending after the command.
+		expectedError    error
+	}{
+		{
+			name:      "1. Acknowledge pending entries",
+			key:       "XAckKey1",
+			presetIDs: []string{"1-1", "1-2", "1-3"},
+			setup: [][]string{
+				{"XREADGROUP", "GROUP", "group", "alice", "STREAMS", "XAckKey1", ">"},
+			},
+			command:          []string{"XACK", "XAckKey1", "group", "1-1", "1-3", "2-1"},
+			expectedResponse: 2,
+			expectedPending:  1,
+			expectedError:    nil,
+		},
+		{
+			name:             "2. Return 0 when the group does not exist",
+			key:              "XAckKey2",
+			presetIDs:        []string{"1-1"},
+			command:          []string{"XACK", "XAckKey2", "missing", "1-1"},
+			expectedResponse: 0,
+			expectedPending:  0,
+			expectedError:    nil,
+		},
+		{
+			name:          "3. Return error when the ID is invalid",
+			key:           "XAckKey3",
+			presetIDs:     []string{"1-1"},
+			command:       []string{"XACK", "XAckKey3", "group", "abc"},
+			expectedError: errors.New("invalid stream ID specified as stream command argument"),
+		},
+		{
+			name:          "4. Command too short",
+			command:       []string{"XACK", "XAckKey4", "group"},
+			expectedError: errors.New(constants.WrongArgsResponse),
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), "test_name", fmt.Sprintf("XACK, %d", i))
+
+			if test.presetIDs != nil {
+				if err := presetGroup(ctx, test.key, "group", test.presetIDs...); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+			if err := runCommands(ctx, test.setup...); err != nil {
+				t.Error(err)
+				return
+			}
+
+			res, err := getHandler(test.command[0])(getHandlerFuncParams(ctx, test.command, nil))
+			if test.expectedError != nil {
+				if err == nil || err.Error() != test.expectedError.Error() {
+					t.Errorf("expected error \"%s\", got \"%v\"", test.expectedError.Error(), err)
+				}
+				return
+			}
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			rv, _, _ := resp.NewReader(bytes.NewReader(res)).ReadValue()
+			if rv.Integer() != test.expectedResponse {
+				t.Errorf("expected response %d, got %d", test.expectedResponse, rv.Integer())
+			}
+
+			if _, err = mockServer.KeyRLock(ctx, test.key); err != nil {
+				t.Error(err)
+				return
+			}
+			defer mockServer.KeyRUnlock(ctx, test.key)
+			group, _ := mockServer.GetValue(ctx, test.key).(*stream.Stream).Group("group")
+			if pending := len(group.Pending(stream.ID{}, stream.ID{Ms: 10}, "")); pending != test.expectedPending {
+				t.Errorf("expected %d pending entries, got %d", test.expectedPending, pending)
+			}
+		})
+	}
+}
+
+func Test_HandleXPENDING(t *testing.T) {
+	tests := []struct {
+		name             string
+		key              string
+		presetIDs        []string
+		setup            [][]string
+		command          []string
+		expectedResponse []string // Each element is an array of the response joined with spaces.
+		expectedError    error
+	}{
+		{
+			name:      "1. Return the summary of the pending entries",
+			key:       "XPendingKey1",
+			presetIDs: []string{"1-1", "1-2", "1-3"},
+			setup: [][]string{
+				{"XREADGROUP", "GROUP", "group", "bob", "COUNT", "1", "STREAMS", "XPendingKey1", ">"},
+				{"XREADGROUP", "GROUP", "group", "alice", "STREAMS", "XPendingKey1", ">"},
+			},
+			command:          []string{"XPENDING", "XPendingKey1", "group"},
+			expectedResponse: []string{"3", "1-1", "1-3", "alice 2", "bob 1"},
+			expectedError:    nil,
+		},
+		{
+			name:             "2. Return an empty summary when there are no pending entries",
+			key:              "XPendingKey2",
+			presetIDs:        []string{"1-1"},
+			command:          []string{"XPENDING", "XPendingKey2", "group"},
+			expectedResponse: []string{"0", "", ""},
+			expectedError:    nil,
+		},
+		{
+			name:      "3. Return the pending entries of a consumer in the range",
+			key:       "XPendingKey3",
+			presetIDs: []string{"1-1", "1-2", "1-3"},
+			setup: [][]string{
+				{"XREADGROUP", "GROUP", "group", "bob", "COUNT", "1", "STREAMS", "XPendingKey3", ">"},
+				{"XREADGROUP", "GROUP", "group", "alice", "STREAMS", "XPendingKey3", ">"},
+				{"XREADGROUP", "GROUP", "group", "alice", "STREAMS", "XPendingKey3", "0"},
+			},
+			command:          []string{"XPENDING", "XPendingKey3", "group", "-", "+", "1", "alice"},
+			expectedResponse: []string{"1-2 alice 0 2"},
+			expectedError:    nil,
+		},
+		{
+			name:      "4. Only return the entries that have been idle for at least IDLE milliseconds",
+			key:       "XPendingKey4",
+			presetIDs: []string{"1-1", "1-2"},
+			setup: [][]string{
+				{"XREADGROUP", "GROUP", "group", "alice", "STREAMS", "XPendingKey4", ">"},
+				{"XCLAIM", "XPendingKey4", "group", "bob", "0", "1-2", "IDLE", "5000"},
+			},
+			command:          []string{"XPENDING", "XPendingKey4", "group", "IDLE", "1000", "-", "+", "10"},
+			expectedResponse: []string{"1-2 bob 5000 2"},
+			expectedError:    nil,
+		},
+		{
+			name:          "5. Return error when the group does not exist",
+			key:           "XPendingKey5",
+			presetIDs:     []string{"1-1"},
+			command:       []string{"XPENDING", "XPendingKey5", "missing"},
+			expectedError: errors.New("no such key XPendingKey5 or consumer group missing"),
+		},
+		{
+			name:          "6. Command too short",
+			command:       []string{"XPENDING", "XPendingKey6"},
+			expectedError: errors.New(constants.WrongArgsResponse),
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), "test_name", fmt.Sprintf("XPENDING, %d", i))
+
+			if test.presetIDs != nil {
+				if err := presetGroup(ctx, test.key, "group", test.presetIDs...); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+			if err := runCommands(ctx, test.setup...); err != nil {
+				t.Error(err)
+				return
+			}
+
+			res, err := getHandler(test.command[0])(getHandlerFuncParams(ctx, test.command, nil))
+			if test.expectedError != nil {
+				if err == nil || err.Error() != test.expectedError.Error() {
+					t.Errorf("expected error \"%s\", got \"%v\"", test.expectedError.Error(), err)
+				}
+				return
+			}
+			if err != nil {
+				t.Error(err)
+				return
+			}
+
+			rv, _, _ := resp.NewReader(bytes.NewReader(res)).ReadValue()
+			got := make([]string, 0)
+			for _, v := range rv.Array() {
+				if v.Type() != resp.Array {
+					got = append(got, v.String())
+					continue
+				}
+				if v.IsNull() {
+					continue
+				}
+				// The consumers of the summary are nested one level deeper than the extended entries.
+				if len(v.Array()) > 0 && v.Array()[0].Type() == resp.Array {
+					for _, c := range v.Array() {
+						got = append(got, fmt.Sprintf("%s %s", c.Array()[0].String(), c.Array()[1].String()))
+					}
+					continue
+				}
+				fields := make([]string, 0)
+				for _, f := range v.Array() {
+					fields = append(fields, f.String())
+				}
+				got = append(got, strings.Join(fields, " "))
+			}
+			if !reflect.DeepEqual(got, test.expectedResponse) {
+				t.Errorf("expected response %+v, got %+v", test.expectedResponse, got)
+			}
+		})
+	}
+}
+
+func Test_HandleXCLAIM(t *testing.T) {
+	tests := []struct {
+		name             string
+		key              string
+		presetIDs        []string
+		setup            [][]string
+		command          []string
+		expectedResponse []string
+		expectedError    error
+	}{
+		{
+			name:      "1. Claim the entries that have been idle long enough",
+			key:       "XClaimKey1",
+			presetIDs: []string{"1-1", "1-2"},
+			setup: [][]string{
+				{"XREADGROUP", "GROUP", "group", "alice", "STREAMS", "XClaimKey1", ">"},
+				{"XCLAIM", "XClaimKey1", "group", "alice", "0", "1-1", "IDLE", "5000", "JUSTID"},
+			},
+			command:          []string{"XCLAIM", "XClaimKey1", "group", "bob", "1000", "1-1", "1-2"},
+			expectedResponse: []string{"1-1 field 1-1"},
+			expectedError:    nil,
+		},
+		{
+			name:      "2. Return only the IDs with JUSTID",
+			key:       "XClaimKey2",
+			presetIDs: []string{"1-1", "1-2"},
+			setup: [][]string{
+				{"XREADGROUP", "GROUP", "group", "alice", "STREAMS", "XClaimKey2", ">"},
+			},
+			command:          []string{"XCLAIM", "XClaimKey2", "group", "bob", "0", "1-1", "1-2", "JUSTID"},
+			expectedResponse: []string{"1-1", "1-2"},
+			expectedError:    nil,
+		},
+		{
+			name:             "3. FORCE claims the entries that are not pending",
+			key:              "XClaimKey3",
+			presetIDs:        []string{"1-1"},
+			command:          []string{"XCLAIM", "XClaimKey3", "group", "bob", "0", "1-1", "2-1", "FORCE"},
+			expectedResponse: []string{"1-1 field 1-1"},
+			expectedError:    nil,
+		},
+		{
+			name:          "4. Return error when the option is unknown",
+			key:           "XClaimKey4",
+			presetIDs:     []string{"1-1"},
+			command:       []string{"XCLAIM", "XClaimKey4", "group", "bob", "0", "1-1", "UNKNOWN"},
+			expectedError: errors.New("unknown option UNKNOWN"),
+		},
+		{
+			name:          "5. Return error when the group does not exist",
+			key:           "XClaimKey5",
+			presetIDs:     []string{"1-1"},
+			command:       []string{"XCLAIM", "XClaimKey5", "missing", "bob", "0", "1-1"},
+			expectedError: errors.New("no such key XClaimKey5 or consumer group missing"),
+		},
+		{
+			name:          "6. Command too short",
+			command:       []string{"XCLAIM", "XClaimKey6", "group", "bob", "0"},
+			expectedError: errors.New(constants.WrongArgsResponse),
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), "test_name", fmt.Sprintf("XCLAIM, %d", i))
+
+			if test.presetIDs != nil {
+				if err := presetGroup(ctx, test.key, "group", test.presetIDs...); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+			if err := runCommands(ctx, test.setup...); err != nil {
+				t.Error(err)
+				return
+			}
+
+			res, err := getHandler(test.command[0])(getHandlerFuncParams(ctx, test.command, nil))
+			if test.expectedError != nil {
+				if err == nil || err.Error() != test.expectedError.Error() {
+					t.Errorf("expected error \"%s\", got \"%v\"", test.expectedError.Error(), err)
+				}
+				return
+			}
+			if err != nil {
+				t.Error(err)
+				return
+			}
+
+			rv, _, _ := resp.NewReader(bytes.NewReader(res)).ReadValue()
+			got := make([]string, 0)
+			if strings.EqualFold(test.command[len(test.command)-1], "justid") {
+				for _, id := range rv.Array() {
+					got = append(got, id.String())
+				}
+			} else {
+				got = readEntries(rv)
+			}
+			if !reflect.DeepEqual(got, test.expectedResponse) {
+				t.Errorf("expected response %+v, got %+v", test.expectedResponse, got)
+			}
+		})
+	}
+}
@@ -18,6 +18,8 @@ import (
 	"context"
 	"github.com/echovault/echovault/echovault"
 	"github.com/echovault/echovault/internal/config"
+	"github.com/echovault/echovault/internal/constants"
+	"math"
 	"testing"
 )
 
@@ -42,10 +44,17 @@ func presetValue(server *echovault.EchoVault, ctx context.Context, key string, v
 }
 
 func TestEchoVault_SUBSTR(t *testing.T) {
-	server := createEchoVault()
+	server, _ := echovault.NewEchoVault(
+		echovault.WithConfig(config.Config{
+			DataDir:        "",
+			ProtocolCompat: constants.ProtocolCompatRedis7,
+		}),
+	)
+	legacyServer := createEchoVault()
 
 	tests := []struct {
 		name        string
+		legacy      bool
 		presetValue interface{}
 		substrFunc  func(key string, start int, end int) (string, error)
 		key         string
@@ -108,9 +117,10 @@ func TestEchoVault_SUBSTR(t *testing.T) {
 		{
 			// Return reverse substring with end index smaller than start index.
 			// When end index is smaller than start index, the 2 indices are reversed.
-			name:        "Return reverse substring with end index smaller than start index",
+			name:        "Return reverse substring with end index smaller than start index in legacy mode",
+			legacy:      true,
 			key:         "key6",
-			substrFunc:  server.SubStr,
+			substrFunc:  legacyServer.SubStr,
 			presetValue: "Test String Six",
 			start:       4,
 			end:         0,
@@ -170,19 +180,42 @@ func TestEchoVault_SUBSTR(t *testing.T) {
 		{
 			// Return reverse substring with end index smaller than start index.
 			// When end index is smaller than start index, the 2 indices are reversed.
-			name:        "Return reverse substring with end index smaller than start index",
+			name:        "Return reverse substring with end index smaller than start index in legacy mode",
+			legacy:      true,
 			key:         "key12",
-			substrFunc:  server.GetRange,
+			substrFunc:  legacyServer.GetRange,
 			presetValue: "Test String Six",
 			start:       4,
 			end:         0,
 			want:        "tseT",
 		},
+		{
+			name:        "Return empty string with end index smaller than start index",
+			key:         "key13",
+			substrFunc:  server.GetRange,
+			presetValue: "Test String Seven",
+			start:       4,
+			end:         0,
+			want:        "",
+		},
+		{
+			name:        "Return the substring with negative start and end indices",
+			key:         "key14",
+			substrFunc:  server.GetRange,
+			presetValue: "Test String Eight",
+			start:       -5,
+			end:         -1,
+			want:        "Eight",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			if tt.presetValue != nil {
-				err := presetValue(server, context.Background(), tt.key, tt.presetValue)
+				target := server
+				if tt.legacy {
+					target = legacyServer
+				}
+				err := presetValue(target, context.Background(), tt.key, tt.presetValue)
 				if err != nil {
 					t.Error(err)
 					return
@@ -201,10 +234,17 @@ func TestEchoVault_SUBSTR(t *testing.T) {
 }
 
 func TestEchoVault_SETRANGE(t *testing.T) {
-	server := createEchoVault()
+	server, _ := echovault.NewEchoVault(
+		echovault.WithConfig(config.Config{
+			DataDir:        "",
+			ProtocolCompat: constants.ProtocolCompatRedis7,
+		}),
+	)
+	legacyServer := createEchoVault()
 
 	tests := []struct {
 		name        string
+		legacy      bool
 		presetValue interface{}
 		key         string
 		offset      int
@@ -213,7 +253,8 @@ func TestEchoVault_SETRANGE(t *testing.T) {
 		wantErr     bool
 	}{
 		{
-			name:        "Test that SETRANGE on non-existent string creates new string",
+			name:        "Test that SETRANGE on non-existent string creates new string in legacy mode",
+			legacy:      true,
 			key:         "key1",
 			presetValue: "",
 			offset:      10,
@@ -231,7 +272,8 @@ func TestEchoVault_SETRANGE(t *testing.T) {
 			wantErr:     false,
 		},
 		{
-			name:        "SETRANGE with negative offset prepends the string",
+			name:        "SETRANGE with negative offset prepends the string in legacy mode",
+			legacy:      true,
 			key:         "key3",
 			presetValue: "This is a preset value",
 			offset:      -10,
@@ -249,7 +291,8 @@ func TestEchoVault_SETRANGE(t *testing.T) {
 			wantErr:     false,
 		},
 		{
-			name:        "SETRANGE with offset longer than original lengths appends the string",
+			name:        "SETRANGE with offset longer than original lengths appends the string in legacy mode",
+			legacy:      true,
 			key:         "key5",
 			presetValue: "This is a preset value",
 			offset:      100,
@@ -266,17 +309,39 @@ func TestEchoVault_SETRANGE(t *testing.T) {
 			want:        len("This is a preset valu replaced"),
 			wantErr:     false,
 		},
+		{
+			name:        "SETRANGE with offset longer than original length pads the string with zero bytes",
+			key:         "key7",
+			presetValue: "This is a preset value",
+			offset:      100,
+			new:         " Appended",
+			want:        100 + len(" Appended"),
+			wantErr:     false,
+		},
+		{
+			name:        "SETRANGE with negative offset returns error",
+			key:         "key8",
+			presetValue: "This is a preset value",
+			offset:      -10,
+			new:         "Prepended ",
+			want:        0,
+			wantErr:     true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			target := server
+			if tt.legacy {
+				target = legacyServer
+			}
 			if tt.presetValue != nil {
-				err := presetValue(server, context.Background(), tt.key, tt.presetValue)
+				err := presetValue(target, context.Background(), tt.key, tt.presetValue)
 				if err != nil {
 					t.Error(err)
 					return
 				}
 			}
-			got, err := server.SetRange(tt.key, tt.offset, tt.new)
+			got, err := target.SetRange(tt.key, tt.offset, tt.new)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("SETRANGE() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -288,6 +353,95 @@ func TestEchoVault_SETRANGE(t *testing.T) {
 	}
 }
 
+func TestEchoVault_SETIF(t *testing.T) {
+	server := createEchoVault()
+
+	tests := []struct {
+		name        string
+		presetValue interface{}
+		key         string
+		expected    string
+		new         string
+		want        string
+		wantValue   string
+		wantErr     bool
+	}{
+		{
+			name:        "Replace the value when it matches the expected value",
+			presetValue: "old",
+			key:         "SetIfKey1",
+			expected:    "old",
+			new:         "new",
+			want:        "old",
+			wantValue:   "new",
+		},
+		{
+			name:        "Keep the value when it does not match the expected value",
+			presetValue: "current",
+			key:         "SetIfKey2",
+			expected:    "old",
+			new:         "new",
+			want:        "current",
+			wantValue:   "current",
+		},
+		{
+			name:        "Compare against numeric values set by SET",
+			presetValue: 10,
+			key:         "SetIfKey3",
+			expected:    "10",
+			new:         "11",
+			want:        "10",
+			wantValue:   "11",
+		},
+		{
+			name:      "Do not create the key when it does not exist",
+			key:       "SetIfKey4",
+			expected:  "old",
+			new:       "new",
+			want:      "",
+			wantValue: "",
+		},
+		{
+			name:        "Return error when the value is not a string",
+			presetValue: []string{"a"},
+			key:         "SetIfKey5",
+			expected:    "old",
+			new:         "new",
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.presetValue != nil {
+				err := presetValue(server, context.Background(), tt.key, tt.presetValue)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+			}
+			got, err := server.SetIf(tt.key, tt.expected, tt.new, 0)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SETIF() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if got != tt.want {
+				t.Errorf("SETIF() got = %v, want %v", got, tt.want)
+			}
+			value, err := server.Get(tt.key)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if value != tt.wantValue {
+				t.Errorf("SETIF() value = %v, want %v", value, tt.wantValue)
+			}
+		})
+	}
+}
+
 func TestEchoVault_STRLEN(t *testing.T) {
 	server := createEchoVault()
 
@@ -333,3 +487,291 @@ func TestEchoVault_STRLEN(t *testing.T) {
 		})
 	}
 }
+
+func TestEchoVault_APPEND(t *testing.T) {
+	server := createEchoVault()
+
+	tests := []struct {
+		name        string
+		presetValue interface{}
+		key         string
+		value       string
+		want        int
+		wantErr     bool
+	}{
+		{
+			name:        "Append to an existing string",
+			key:         "key1",
+			presetValue: "Hello",
+			value:       " World",
+			want:        len("Hello World"),
+			wantErr:     false,
+		},
+		{
+			name:    "Create the string when the key does not exist",
+			key:     "key2",
+			value:   "Hello",
+			want:    len("Hello"),
+			wantErr: false,
+		},
+		{
+			name:        "Return error when the value is not a string",
+			key:         "key3",
+			presetValue: []string{"a"},
+			value:       "b",
+			want:        0,
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.presetValue != nil {
+				err := presetValue(server, context.Background(), tt.key, tt.presetValue)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+			}
+			got, err := server.Append(tt.key, tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("APPEND() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("APPEND() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEchoVault_INCR(t *testing.T) {
+	server := createEchoVault()
+
+	tests := []struct {
+		name        string
+		presetValue interface{}
+		key         string
+		incr        func(key string) (int, error)
+		want        int
+		wantErr     bool
+	}{
+		{
+			name:        "Incr an existing integer",
+			key:         "key1",
+			presetValue: 10,
+			incr:        server.Incr,
+			want:        11,
+			wantErr:     false,
+		},
+		{
+			name:    "Decr a key that does not exist",
+			key:     "key2",
+			incr:    server.Decr,
+			want:    -1,
+			wantErr: false,
+		},
+		{
+			name:        "IncrBy a string that holds an integer",
+			key:         "key3",
+			presetValue: "5",
+			incr: func(key string) (int, error) {
+				return server.IncrBy(key, 10)
+			},
+			want:    15,
+			wantErr: false,
+		},
+		{
+			name:        "DecrBy an existing integer",
+			key:         "key4",
+			presetValue: 5,
+			incr: func(key string) (int, error) {
+				return server.DecrBy(key, 10)
+			},
+			want:    -5,
+			wantErr: false,
+		},
+		{
+			name:        "Return error when the value is not an integer",
+			key:         "key5",
+			presetValue: "abc",
+			incr:        server.Incr,
+			want:        0,
+			wantErr:     true,
+		},
+		{
+			name:        "Return error when the increment overflows",
+			key:         "key6",
+			presetValue: math.MaxInt64,
+			incr:        server.Incr,
+			want:        0,
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.presetValue != nil {
+				err := presetValue(server, context.Background(), tt.key, tt.presetValue)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+			}
+			got, err := tt.incr(tt.key)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("INCR() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("INCR() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEchoVault_INCRBYFLOAT(t *testing.T) {
+	server := createEchoVault()
+
+	tests := []struct {
+		name        string
+		presetValue interface{}
+		key         string
+		increment   float64
+		want        float64
+		wantErr     bool
+	}{
+		{
+			name:        "Increment an existing float",
+			key:         "key1",
+			presetValue: 10.5,
+			increment:   0.1,
+			want:        10.6,
+			wantErr:     false,
+		},
+		{
+			name:        "Increment an existing integer",
+			key:         "key2",
+			presetValue: 3,
+			increment:   1.5,
+			want:        4.5,
+			wantErr:     false,
+		},
+		{
+			name:      "Increment a key that does not exist",
+			key:       "key3",
+			increment: -2.25,
+			want:      -2.25,
+			wantErr:   false,
+		},
+		{
+			name:        "Return error when the value is not a float",
+			key:         "key4",
+			presetValue: "abc",
+			increment:   1,
+			want:        0,
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.presetValue != nil {
+				err := presetValue(server, context.Background(), tt.key, tt.presetValue)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+			}
+			got, err := server.IncrByFloat(tt.key, tt.increment)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("INCRBYFLOAT() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("INCRBYFLOAT() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEchoVault_Bitmap(t *testing.T) {
+	server := createEchoVault()
+
+	// Set the bits of the flags bitmap one at a time.
+	for _, offset := range []uint{1, 2, 9} {
+		if _, err := server.SetBit("flags", offset, 1); err != nil {
+			t.Error(err)
+			return
+		}
+	}
+
+	tests := []struct {
+		name    string
+		call    func() (int, error)
+		want    int
+		wantErr bool
+	}{
+		{
+			name:    "GetBit returns a set bit",
+			call:    func() (int, error) { return server.GetBit("flags", 9) },
+			want:    1,
+			wantErr: false,
+		},
+		{
+			name:    "SetBit returns the original bit",
+			call:    func() (int, error) { return server.SetBit("flags", 2, 1) },
+			want:    1,
+			wantErr: false,
+		},
+		{
+			name:    "BitCount counts the set bits",
+			call:    func() (int, error) { return server.BitCount("flags", echovault.BitCountOptions{}) },
+			want:    3,
+			wantErr: false,
+		},
+		{
+			name: "BitCount counts the set bits in a bit range",
+			call: func() (int, error) {
+				return server.BitCount("flags", echovault.BitCountOptions{WithRange: true, Start: 2, End: 9, Bit: true})
+			},
+			want:    2,
+			wantErr: false,
+		},
+		{
+			name: "BitPos returns the first set bit after the start byte",
+			call: func() (int, error) {
+				return server.BitPos("flags", 1, echovault.BitPosOptions{WithStart: true, Start: 1})
+			},
+			want:    9,
+			wantErr: false,
+		},
+		{
+			name:    "BitOp returns the length of the result",
+			call:    func() (int, error) { return server.BitOp("NOT", "inverted", "flags") },
+			want:    2,
+			wantErr: false,
+		},
+		{
+			name:    "BitCount counts the bits of the result",
+			call:    func() (int, error) { return server.BitCount("inverted", echovault.BitCountOptions{}) },
+			want:    13,
+			wantErr: false,
+		},
+		{
+			name:    "SetBit returns error when the bit is invalid",
+			call:    func() (int, error) { return server.SetBit("flags", 1, 2) },
+			want:    0,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.call()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -21,14 +21,17 @@ import (
 	"fmt"
 	"github.com/echovault/echovault/echovault"
 	"github.com/echovault/echovault/internal"
+	"github.com/echovault/echovault/internal/clock"
 	"github.com/echovault/echovault/internal/config"
 	"github.com/echovault/echovault/internal/constants"
 	"github.com/tidwall/resp"
+	"math"
 	"net"
 	"reflect"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 	"unsafe"
 )
 
@@ -83,12 +86,14 @@ func getHandlerFuncParams(ctx context.Context, cmd []string, conn *net.Conn) int
 		KeyRUnlock:       mockServer.KeyRUnlock,
 		GetValue:         mockServer.GetValue,
 		SetValue:         mockServer.SetValue,
+		DeleteKey:        mockServer.DeleteKey,
 	}
 }
 
 func Test_HandleSetRange(t *testing.T) {
 	tests := []struct {
 		name             string
+		legacy           bool
 		preset           bool
 		key              string
 		presetValue      string
@@ -98,7 +103,8 @@ func Test_HandleSetRange(t *testing.T) {
 		expectedError    error
 	}{
 		{
-			name:             "Test that SETRANGE on non-existent string creates new string",
+			name:             "Test that SETRANGE on non-existent string creates new string in legacy mode",
+			legacy:           true,
 			preset:           false,
 			key:              "SetRangeKey1",
 			presetValue:      "",
@@ -118,7 +124,8 @@ func Test_HandleSetRange(t *testing.T) {
 			expectedError:    nil,
 		},
 		{
-			name:             "SETRANGE with negative offset prepends the string",
+			name:             "SETRANGE with negative offset prepends the string in legacy mode",
+			legacy:           true,
 			preset:           true,
 			key:              "SetRangeKey3",
 			presetValue:      "This is a preset value",
@@ -138,7 +145,8 @@ func Test_HandleSetRange(t *testing.T) {
 			expectedError:    nil,
 		},
 		{
-			name:             "SETRANGE with offset longer than original lengths appends the string",
+			name:             "SETRANGE with offset longer than original lengths appends the string in legacy mode",
+			legacy:           true,
 			preset:           true,
 			key:              "SetRangeKey5",
 			presetValue:      "This is a preset value",
@@ -157,6 +165,43 @@ func Test_HandleSetRange(t *testing.T) {
 			expectedResponse: len("This is a preset valu replaced"),
 			expectedError:    nil,
 		},
+		{
+			name:             "SETRANGE on non-existent string pads the new string with zero bytes",
+			preset:           false,
+			key:              "SetRangeKey7",
+			command:          []string{"SETRANGE", "SetRangeKey7", "5", "value"},
+			expectedValue:    "\x00\x00\x00\x00\x00value",
+			expectedResponse: 10,
+			expectedError:    nil,
+		},
+		{
+			name:             "SETRANGE with offset longer than original length pads the string with zero bytes",
+			preset:           true,
+			key:              "SetRangeKey8",
+			presetValue:      "value",
+			command:          []string{"SETRANGE", "SetRangeKey8", "7", "value"},
+			expectedValue:    "value\x00\x00value",
+			expectedResponse: 12,
+			expectedError:    nil,
+		},
+		{
+			name:             "SETRANGE with negative offset returns error",
+			preset:           true,
+			key:              "SetRangeKey9",
+			presetValue:      "value",
+			command:          []string{"SETRANGE", "SetRangeKey9", "-1", "value"},
+			expectedResponse: 0,
+			expectedError:    errors.New("offset is out of range"),
+		},
+		{
+			name:             "SETRANGE returns error when the string would exceed 512MB",
+			preset:           true,
+			key:              "SetRangeKey10",
+			presetValue:      "value",
+			command:          []string{"SETRANGE", "SetRangeKey10", "536870910", "abc"},
+			expectedResponse: 0,
+			expectedError:    errors.New("string exceeds maximum allowed size"),
+		},
 		{
 			name:             " Offset not integer",
 			preset:           false,
@@ -210,7 +255,15 @@ func Test_HandleSetRange(t *testing.T) {
 				return
 			}
 
-			res, err := handler(getHandlerFuncParams(ctx, test.command, nil))
+			params := getHandlerFuncParams(ctx, test.command, nil)
+			params.GetConfigParameters = func() map[string]string {
+				if test.legacy {
+					return map[string]string{"protocol-compat": constants.ProtocolCompatEchoVault}
+				}
+				return map[string]string{"protocol-compat": constants.ProtocolCompatRedis7}
+			}
+
+			res, err := handler(params)
 
 			if test.expectedError != nil {
 				if err.Error() != test.expectedError.Error() {
@@ -246,6 +299,154 @@ func Test_HandleSetRange(t *testing.T) {
 	}
 }
 
+func Test_HandleSetIf(t *testing.T) {
+	mockClock := clock.NewClock()
+
+	tests := []struct {
+		name             string
+		preset           bool
+		key              string
+		presetValue      interface{}
+		command          []string
+		expectedValue    interface{}
+		expectedExpiry   time.Time
+		expectedResponse interface{}
+		expectedError    error
+	}{
+		{
+			name:             "Replace the value when it matches the expected value",
+			preset:           true,
+			key:              "SetIfKey1",
+			presetValue:      "old",
+			command:          []string{"SETIF", "SetIfKey1", "old", "new"},
+			expectedValue:    "new",
+			expectedResponse: "old",
+		},
+		{
+			name:             "Keep the value when it does not match the expected value",
+			preset:           true,
+			key:              "SetIfKey2",
+			presetValue:      "current",
+			command:          []string{"SETIF", "SetIfKey2", "old", "new"},
+			expectedValue:    "current",
+			expectedResponse: "current",
+		},
+		{
+			name:             "Set the expiry when the value is replaced",
+			preset:           true,
+			key:              "SetIfKey3",
+			presetValue:      "old",
+			command:          []string{"SETIF", "SetIfKey3", "old", "new", "EX", "100"},
+			expectedValue:    "new",
+			expectedExpiry:   mockClock.Now().Add(100 * time.Second),
+			expectedResponse: "old",
+		},
+		{
+			name:             "Return nil and do not create the key when it does not exist",
+			preset:           false,
+			key:              "SetIfKey4",
+			command:          []string{"SETIF", "SetIfKey4", "old", "new"},
+			expectedValue:    nil,
+			expectedResponse: nil,
+		},
+		{
+			name:          "Return error when the value is not a string",
+			preset:        true,
+			key:           "SetIfKey5",
+			presetValue:   []string{"a"},
+			command:       []string{"SETIF", "SetIfKey5", "old", "new"},
+			expectedError: errors.New("value at key SetIfKey5 is not a string"),
+		},
+		{
+			name:          "Return error when the ttl is not a positive integer",
+			preset:        false,
+			key:           "SetIfKey6",
+			command:       []string{"SETIF", "SetIfKey6", "old", "new", "EX", "0"},
+			expectedError: errors.New("ttl must be a positive integer"),
+		},
+		{
+			name:          "Return error when the option is unknown",
+			preset:        false,
+			key:           "SetIfKey7",
+			command:       []string{"SETIF", "SetIfKey7", "old", "new", "PX", "100"},
+			expectedError: errors.New("unknown option PX"),
+		},
+		{
+			name:          "Command too short",
+			preset:        false,
+			key:           "SetIfKey8",
+			command:       []string{"SETIF", "SetIfKey8", "old"},
+			expectedError: errors.New(constants.WrongArgsResponse),
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), "test_name", fmt.Sprintf("SETIF, %d", i))
+
+			if test.preset {
+				if _, err := mockServer.CreateKeyAndLock(ctx, test.key); err != nil {
+					t.Error(err)
+				}
+				if err := mockServer.SetValue(ctx, test.key, test.presetValue); err != nil {
+					t.Error(err)
+				}
+				mockServer.KeyUnlock(ctx, test.key)
+			}
+
+			handler := getHandler(test.command[0])
+			if handler == nil {
+				t.Errorf("no handler found for command %s", test.command[0])
+				return
+			}
+
+			params := getHandlerFuncParams(ctx, test.command, nil)
+			params.GetClock = func() clock.Clock { return mockClock }
+			params.SetExpiry = mockServer.SetExpiry
+
+			res, err := handler(params)
+			if test.expectedError != nil {
+				if err == nil || err.Error() != test.expectedError.Error() {
+					t.Errorf("expected error \"%s\", got \"%v\"", test.expectedError.Error(), err)
+				}
+				return
+			}
+			if err != nil {
+				t.Error(err)
+			}
+			rd := resp.NewReader(bytes.NewBuffer(res))
+			rv, _, err := rd.ReadValue()
+			if err != nil {
+				t.Error(err)
+			}
+			if test.expectedResponse == nil {
+				if !rv.IsNull() {
+					t.Errorf("expected nil response, got %+v", rv)
+				}
+			} else if rv.Type() != resp.BulkString || rv.String() != test.expectedResponse {
+				t.Errorf("expected bulk string response \"%v\", got %s \"%s\"", test.expectedResponse, rv.Type(), rv.String())
+			}
+
+			if test.expectedValue == nil {
+				if mockServer.KeyExists(ctx, test.key) {
+					t.Errorf("expected key %s to not exist", test.key)
+				}
+				return
+			}
+			if _, err = mockServer.KeyRLock(ctx, test.key); err != nil {
+				t.Error(err)
+			}
+			defer mockServer.KeyRUnlock(ctx, test.key)
+			if value := mockServer.GetValue(ctx, test.key); value != test.expectedValue {
+				t.Errorf("expected value \"%v\", got \"%v\"", test.expectedValue, value)
+			}
+			if expiry := mockServer.GetExpiry(ctx, test.key); expiry != test.expectedExpiry {
+				t.Errorf("expected expiry %v, got %v", test.expectedExpiry, expiry)
+			}
+		})
+	}
+}
+
 func Test_HandleStrLen(t *testing.T) {
 	tests := []struct {
 		name             string
@@ -335,107 +536,236 @@ func Test_HandleStrLen(t *testing.T) {
 	}
 }
 
-func Test_HandleSubStr(t *testing.T) {
+func Test_HandleAppend(t *testing.T) {
 	tests := []struct {
 		name             string
-		preset           bool
 		key              string
-		presetValue      string
+		presetValue      interface{}
 		command          []string
-		expectedResponse string
+		expectedResponse int
+		expectedValue    interface{}
 		expectedError    error
 	}{
 		{
-			name:             "Return substring within the range of the string",
-			preset:           true,
-			key:              "SubStrKey1",
-			presetValue:      "Test String One",
-			command:          []string{"SUBSTR", "SubStrKey1", "5", "10"},
-			expectedResponse: "String",
+			name:             "1. Append to an existing string",
+			key:              "AppendKey1",
+			presetValue:      "Hello",
+			command:          []string{"APPEND", "AppendKey1", " World"},
+			expectedResponse: len("Hello World"),
+			expectedValue:    "Hello World",
 			expectedError:    nil,
 		},
 		{
-			name:             "Return substring at the end of the string with exact end index",
-			preset:           true,
-			key:              "SubStrKey2",
-			presetValue:      "Test String Two",
-			command:          []string{"SUBSTR", "SubStrKey2", "12", "14"},
-			expectedResponse: "Two",
+			name:             "2. Create the string when the key does not exist",
+			key:              "AppendKey2",
+			presetValue:      nil,
+			command:          []string{"APPEND", "AppendKey2", "Hello"},
+			expectedResponse: len("Hello"),
+			expectedValue:    "Hello",
 			expectedError:    nil,
 		},
 		{
-			name:             "Return substring at the end of the string with end index greater than length",
-			preset:           true,
-			key:              "SubStrKey3",
-			presetValue:      "Test String Three",
-			command:          []string{"SUBSTR", "SubStrKey3", "12", "75"},
-			expectedResponse: "Three",
+			name:             "3. Append to an integer",
+			key:              "AppendKey3",
+			presetValue:      10,
+			command:          []string{"APPEND", "AppendKey3", "5"},
+			expectedResponse: 3,
+			expectedValue:    "105",
 			expectedError:    nil,
 		},
 		{
-			name:             "Return the substring at the start of the string with 0 start index",
-			preset:           true,
-			key:              "SubStrKey4",
-			presetValue:      "Test String Four",
-			command:          []string{"SUBSTR", "SubStrKey4", "0", "3"},
-			expectedResponse: "Test",
+			name:             "4. Append to a float",
+			key:              "AppendKey4",
+			presetValue:      1.5,
+			command:          []string{"APPEND", "AppendKey4", "0"},
+			expectedResponse: 4,
+			expectedValue:    "1.50",
 			expectedError:    nil,
 		},
 		{
-			// Return the substring with negative start index.
-			// Substring should begin abs(start) from the end of the string when start is negative.
-			name:             "Return the substring with negative start index",
-			preset:           true,
-			key:              "SubStrKey5",
-			presetValue:      "Test String Five",
-			command:          []string{"SUBSTR", "SubStrKey5", "-11", "10"},
-			expectedResponse: "String",
+			name:          "5. Return error when the value is not a string",
+			key:           "AppendKey5",
+			presetValue:   []string{"a", "b"},
+			command:       []string{"APPEND", "AppendKey5", "c"},
+			expectedError: errors.New("value at key AppendKey5 is not a string"),
+		},
+		{
+			name:          "6. Command too short",
+			command:       []string{"APPEND", "AppendKey6"},
+			expectedError: errors.New(constants.WrongArgsResponse),
+		},
+		{
+			name:          "7. Command too long",
+			command:       []string{"APPEND", "AppendKey7", "a", "b"},
+			expectedError: errors.New(constants.WrongArgsResponse),
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), "test_name", fmt.Sprintf("APPEND, %d", i))
+
+			if test.presetValue != nil {
+				if _, err := mockServer.CreateKeyAndLock(ctx, test.key); err != nil {
+					t.Error(err)
+				}
+				if err := mockServer.SetValue(ctx, test.key, test.presetValue); err != nil {
+					t.Error(err)
+				}
+				mockServer.KeyUnlock(ctx, test.key)
+			}
+
+			handler := getHandler(test.command[0])
+			if handler == nil {
+				t.Errorf("no handler found for command %s", test.command[0])
+				return
+			}
+
+			res, err := handler(getHandlerFuncParams(ctx, test.command, nil))
+
+			if test.expectedError != nil {
+				if err == nil || err.Error() != test.expectedError.Error() {
+					t.Errorf("expected error \"%v\", got \"%v\"", test.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			rd := resp.NewReader(bytes.NewBuffer(res))
+			rv, _, err := rd.ReadValue()
+			if err != nil {
+				t.Error(err)
+			}
+			if rv.Integer() != test.expectedResponse {
+				t.Errorf("expected response %d, got %d", test.expectedResponse, rv.Integer())
+			}
+			if _, err = mockServer.KeyRLock(ctx, test.key); err != nil {
+				t.Error(err)
+				return
+			}
+			value := mockServer.GetValue(ctx, test.key)
+			mockServer.KeyRUnlock(ctx, test.key)
+			if value != test.expectedValue {
+				t.Errorf("expected value %v, got %v", test.expectedValue, value)
+			}
+		})
+	}
+}
+
+func Test_HandleIncrBy(t *testing.T) {
+	tests := []struct {
+		name             string
+		key              string
+		presetValue      interface{}
+		command          []string
+		expectedResponse int
+		expectedValue    interface{}
+		expectedError    error
+	}{
+		{
+			name:             "1. INCR an existing integer",
+			key:              "IncrKey1",
+			presetValue:      10,
+			command:          []string{"INCR", "IncrKey1"},
+			expectedResponse: 11,
+			expectedValue:    11,
 			expectedError:    nil,
 		},
 		{
-			// Return reverse substring with end index smaller than start index.
-			// When end index is smaller than start index, the 2 indices are reversed.
-			name:             "Return reverse substring with end index smaller than start index",
-			preset:           true,
-			key:              "SubStrKey6",
-			presetValue:      "Test String Six",
-			command:          []string{"SUBSTR", "SubStrKey6", "4", "0"},
-			expectedResponse: "tseT",
+			name:             "2. INCR a key that does not exist",
+			key:              "IncrKey2",
+			presetValue:      nil,
+			command:          []string{"INCR", "IncrKey2"},
+			expectedResponse: 1,
+			expectedValue:    1,
 			expectedError:    nil,
 		},
 		{
-			name:          "Command too short",
-			command:       []string{"SUBSTR", "key", "10"},
-			expectedError: errors.New(constants.WrongArgsResponse),
+			name:             "3. DECR a string that holds an integer",
+			key:              "IncrKey3",
+			presetValue:      "10",
+			command:          []string{"DECR", "IncrKey3"},
+			expectedResponse: 9,
+			expectedValue:    9,
+			expectedError:    nil,
 		},
 		{
-			name:          "Command too long",
-			command:       []string{"SUBSTR", "key", "10", "15", "20"},
-			expectedError: errors.New(constants.WrongArgsResponse),
+			name:             "4. INCRBY a negative increment",
+			key:              "IncrKey4",
+			presetValue:      5,
+			command:          []string{"INCRBY", "IncrKey4", "-10"},
+			expectedResponse: -5,
+			expectedValue:    -5,
+			expectedError:    nil,
 		},
 		{
-			name:          "Start index is not an integer",
-			command:       []string{"SUBSTR", "key", "start", "10"},
-			expectedError: errors.New("start and end indices must be integers"),
+			name:             "5. DECRBY a key that does not exist",
+			key:              "IncrKey5",
+			presetValue:      nil,
+			command:          []string{"DECRBY", "IncrKey5", "7"},
+			expectedResponse: -7,
+			expectedValue:    -7,
+			expectedError:    nil,
 		},
 		{
-			name:          "End index is not an integer",
-			command:       []string{"SUBSTR", "key", "0", "end"},
-			expectedError: errors.New("start and end indices must be integers"),
+			name:          "6. Return error when the value is not an integer",
+			key:           "IncrKey6",
+			presetValue:   "abc",
+			command:       []string{"INCR", "IncrKey6"},
+			expectedError: errors.New("value at key IncrKey6 is not an integer or out of range"),
 		},
 		{
-			name:          "Non-existent key",
-			command:       []string{"SUBSTR", "non-existent-key", "0", "10"},
-			expectedError: errors.New("key non-existent-key does not exist"),
+			name:          "7. Return error when the value is a float",
+			key:           "IncrKey7",
+			presetValue:   1.5,
+			command:       []string{"INCRBY", "IncrKey7", "1"},
+			expectedError: errors.New("value at key IncrKey7 is not an integer or out of range"),
+		},
+		{
+			name:          "8. Return error when the increment is not an integer",
+			key:           "IncrKey8",
+			command:       []string{"INCRBY", "IncrKey8", "1.5"},
+			expectedError: errors.New("increment must be an integer"),
+		},
+		{
+			name:          "9. Return error when the increment overflows",
+			key:           "IncrKey9",
+			presetValue:   strconv.FormatInt(math.MaxInt64, 10),
+			command:       []string{"INCR", "IncrKey9"},
+			expectedError: errors.New("increment or decrement would overflow"),
+		},
+		{
+			name:          "10. Return error when the decrement overflows",
+			key:           "IncrKey10",
+			presetValue:   strconv.FormatInt(math.MinInt64, 10),
+			command:       []string{"DECRBY", "IncrKey10", "1"},
+			expectedError: errors.New("increment or decrement would overflow"),
+		},
+		{
+			name:          "11. Return error when the decrement cannot be negated",
+			key:           "IncrKey11",
+			command:       []string{"DECRBY", "IncrKey11", strconv.FormatInt(math.MinInt64, 10)},
+			expectedError: errors.New("decrement would overflow"),
+		},
+		{
+			name:          "12. INCR command too long",
+			command:       []string{"INCR", "IncrKey12", "1"},
+			expectedError: errors.New(constants.WrongArgsResponse),
+		},
+		{
+			name:          "13. INCRBY command too short",
+			command:       []string{"INCRBY", "IncrKey13"},
+			expectedError: errors.New(constants.WrongArgsResponse),
 		},
 	}
 
 	for i, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), "test_name", fmt.Sprintf("INCRBY, %d", i))
 
-			ctx := context.WithValue(context.Background(), "test_name", fmt.Sprintf("SUBSTR, %d", i))
-
-			if test.preset {
+			if test.presetValue != nil {
 				if _, err := mockServer.CreateKeyAndLock(ctx, test.key); err != nil {
 					t.Error(err)
 				}
@@ -454,18 +784,785 @@ func Test_HandleSubStr(t *testing.T) {
 			res, err := handler(getHandlerFuncParams(ctx, test.command, nil))
 
 			if test.expectedError != nil {
-				if err.Error() != test.expectedError.Error() {
-					t.Errorf("expected error \"%s\", got \"%s\"", test.expectedError.Error(), err.Error())
+				if err == nil || err.Error() != test.expectedError.Error() {
+					t.Errorf("expected error \"%v\", got \"%v\"", test.expectedError, err)
 				}
 				return
 			}
+			if err != nil {
+				t.Error(err)
+				return
+			}
 			rd := resp.NewReader(bytes.NewBuffer(res))
 			rv, _, err := rd.ReadValue()
 			if err != nil {
 				t.Error(err)
 			}
-			if rv.String() != test.expectedResponse {
-				t.Errorf("expected response \"%s\", got \"%s\"", test.expectedResponse, rv.String())
+			if rv.Integer() != test.expectedResponse {
+				t.Errorf("expected response %d, got %d", test.expectedResponse, rv.Integer())
+			}
+			if _, err = mockServer.KeyRLock(ctx, test.key); err != nil {
+				t.Error(err)
+				return
+			}
+			value := mockServer.GetValue(ctx, test.key)
+			mockServer.KeyRUnlock(ctx, test.key)
+			if value != test.expectedValue {
+				t.Errorf("expected value %v, got %v", test.expectedValue, value)
+			}
+		})
+	}
+}
+
+func Test_HandleIncrByFloat(t *testing.T) {
+	tests := []struct {
+		name             string
+		key              string
+		presetValue      interface{}
+		command          []string
+		expectedResponse string
+		expectedValue    interface{}
+		expectedError    error
+	}{
+		{
+			name:             "1. Increment an existing float",
+			key:              "IncrByFloatKey1",
+			presetValue:      10.5,
+			command:          []string{"INCRBYFLOAT", "IncrByFloatKey1", "0.1"},
+			expectedResponse: "10.6",
+			expectedValue:    "10.6",
+			expectedError:    nil,
+		},
+		{
+			name:             "2. Increment an existing integer",
+			key:              "IncrByFloatKey2",
+			presetValue:      3,
+			command:          []string{"INCRBYFLOAT", "IncrByFloatKey2", "1.5"},
+			expectedResponse: "4.5",
+			expectedValue:    "4.5",
+			expectedError:    nil,
+		},
+		{
+			name:             "3. Increment a key that does not exist",
+			key:              "IncrByFloatKey3",
+			presetValue:      nil,
+			command:          []string{"INCRBYFLOAT", "IncrByFloatKey3", "-2.25"},
+			expectedResponse: "-2.25",
+			expectedValue:    "-2.25",
+			expectedError:    nil,
+		},
+		{
+			name:             "4. Increment a string that holds a float",
+			key:              "IncrByFloatKey4",
+			presetValue:      "5.0e3",
+			command:          []string{"INCRBYFLOAT", "IncrByFloatKey4", "200"},
+			expectedResponse: "5200",
+			expectedValue:    "5200",
+			expectedError:    nil,
+		},
+		{
+			name:          "5. Return error when the value is not a float",
+			key:           "IncrByFloatKey5",
+			presetValue:   "abc",
+			command:       []string{"INCRBYFLOAT", "IncrByFloatKey5", "1"},
+			expectedError: errors.New("value at key IncrByFloatKey5 is not a valid float"),
+		},
+		{
+			name:          "6. Return error when the increment is not a float",
+			key:           "IncrByFloatKey6",
+			command:       []string{"INCRBYFLOAT", "IncrByFloatKey6", "abc"},
+			expectedError: errors.New("increment must be a float"),
+		},
+		{
+			name:          "7. Return error when the increment is infinite",
+			key:           "IncrByFloatKey7",
+			command:       []string{"INCRBYFLOAT", "IncrByFloatKey7", "inf"},
+			expectedError: errors.New("increment must be a float"),
+		},
+		{
+			name:          "8. Return error when the result is infinite",
+			key:           "IncrByFloatKey8",
+			presetValue:   math.MaxFloat64,
+			command:       []string{"INCRBYFLOAT", "IncrByFloatKey8", strconv.FormatFloat(math.MaxFloat64, 'f', -1, 64)},
+			expectedError: errors.New("increment would produce NaN or Infinity"),
+		},
+		{
+			name:          "9. Command too short",
+			command:       []string{"INCRBYFLOAT", "IncrByFloatKey9"},
+			expectedError: errors.New(constants.WrongArgsResponse),
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), "test_name", fmt.Sprintf("INCRBYFLOAT, %d", i))
+
+			if test.presetValue != nil {
+				if _, err := mockServer.CreateKeyAndLock(ctx, test.key); err != nil {
+					t.Error(err)
+				}
+				if err := mockServer.SetValue(ctx, test.key, test.presetValue); err != nil {
+					t.Error(err)
+				}
+				mockServer.KeyUnlock(ctx, test.key)
+			}
+
+			handler := getHandler(test.command[0])
+			if handler == nil {
+				t.Errorf("no handler found for command %s", test.command[0])
+				return
+			}
+
+			res, err := handler(getHandlerFuncParams(ctx, test.command, nil))
+
+			if test.expectedError != nil {
+				if err == nil || err.Error() != test.expectedError.Error() {
+					t.Errorf("expected error \"%v\", got \"%v\"", test.expectedError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			rd := resp.NewReader(bytes.NewBuffer(res))
+			rv, _, err := rd.ReadValue()
+			if err != nil {
+				t.Error(err)
+			}
+			if rv.Type() != resp.BulkString || rv.String() != test.expectedResponse {
+				t.Errorf("expected bulk string response \"%s\", got %s \"%s\"", test.expectedResponse, rv.Type(), rv.String())
+			}
+			if _, err = mockServer.KeyRLock(ctx, test.key); err != nil {
+				t.Error(err)
+				return
+			}
+			value := mockServer.GetValue(ctx, test.key)
+			mockServer.KeyRUnlock(ctx, test.key)
+			if value != test.expectedValue {
+				t.Errorf("expected value %v, got %v", test.expectedValue, value)
+			}
+		})
+	}
+
+	t.Run("10. Increment an integral result with INCR", func(t *testing.T) {
+		ctx := context.WithValue(context.Background(), "test_name", "INCRBYFLOAT, 10")
+		for _, command := range [][]string{
+			{"INCRBYFLOAT", "IncrByFloatKey10", "1.5"},
+			{"INCRBYFLOAT", "IncrByFloatKey10", "0.5"},
+		} {
+			if _, err := getHandler(command[0])(getHandlerFuncParams(ctx, command, nil)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		res, err := getHandler("INCR")(getHandlerFuncParams(ctx, []string{"INCR", "IncrByFloatKey10"}, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(res) != ":3\r\n" {
+			t.Errorf("expected response :3, got %q", string(res))
+		}
+	})
+}
+
+func Test_HandleSubStr(t *testing.T) {
+	tests := []struct {
+		name             string
+		legacy           bool
+		preset           bool
+		key              string
+		presetValue      string
+		command          []string
+		expectedResponse string
+		expectedError    error
+	}{
+		{
+			name:             "Return substring within the range of the string",
+			preset:           true,
+			key:              "SubStrKey1",
+			presetValue:      "Test String One",
+			command:          []string{"SUBSTR", "SubStrKey1", "5", "10"},
+			expectedResponse: "String",
+			expectedError:    nil,
+		},
+		{
+			name:             "Return substring at the end of the string with exact end index",
+			preset:           true,
+			key:              "SubStrKey2",
+			presetValue:      "Test String Two",
+			command:          []string{"SUBSTR", "SubStrKey2", "12", "14"},
+			expectedResponse: "Two",
+			expectedError:    nil,
+		},
+		{
+			name:             "Return substring at the end of the string with end index greater than length",
+			preset:           true,
+			key:              "SubStrKey3",
+			presetValue:      "Test String Three",
+			command:          []string{"SUBSTR", "SubStrKey3", "12", "75"},
+			expectedResponse: "Three",
+			expectedError:    nil,
+		},
+		{
+			name:             "Return the substring at the start of the string with 0 start index",
+			preset:           true,
+			key:              "SubStrKey4",
+			presetValue:      "Test String Four",
+			command:          []string{"SUBSTR", "SubStrKey4", "0", "3"},
+			expectedResponse: "Test",
+			expectedError:    nil,
+		},
+		{
+			// Return the substring with negative start index.
+			// Substring should begin abs(start) from the end of the string when start is negative.
+			name:             "Return the substring with negative start index",
+			preset:           true,
+			key:              "SubStrKey5",
+			presetValue:      "Test String Five",
+			command:          []string{"SUBSTR", "SubStrKey5", "-11", "10"},
+			expectedResponse: "String",
+			expectedError:    nil,
+		},
+		{
+			// Return reverse substring with end index smaller than start index.
+			// When end index is smaller than start index, the 2 indices are reversed.
+			name:             "Return reverse substring with end index smaller than start index in legacy mode",
+			legacy:           true,
+			preset:           true,
+			key:              "SubStrKey6",
+			presetValue:      "Test String Six",
+			command:          []string{"SUBSTR", "SubStrKey6", "4", "0"},
+			expectedResponse: "tseT",
+			expectedError:    nil,
+		},
+		{
+			name:             "Return empty string with end index smaller than start index",
+			preset:           true,
+			key:              "SubStrKey7",
+			presetValue:      "Test String Seven",
+			command:          []string{"SUBSTR", "SubStrKey7", "4", "0"},
+			expectedResponse: "",
+			expectedError:    nil,
+		},
+		{
+			name:             "Return the substring with negative start and end indices",
+			preset:           true,
+			key:              "SubStrKey8",
+			presetValue:      "Test String Eight",
+			command:          []string{"SUBSTR", "SubStrKey8", "-5", "-1"},
+			expectedResponse: "Eight",
+			expectedError:    nil,
+		},
+		{
+			name:             "Clamp negative start index that is out of range to the start of the string",
+			preset:           true,
+			key:              "SubStrKey9",
+			presetValue:      "Test String Nine",
+			command:          []string{"SUBSTR", "SubStrKey9", "-100", "3"},
+			expectedResponse: "Test",
+			expectedError:    nil,
+		},
+		{
+			name:             "Return empty string when start index is past the end of the string",
+			preset:           true,
+			key:              "SubStrKey10",
+			presetValue:      "Test String Ten",
+			command:          []string{"SUBSTR", "SubStrKey10", "50", "100"},
+			expectedResponse: "",
+			expectedError:    nil,
+		},
+		{
+			name:          "Command too short",
+			command:       []string{"SUBSTR", "key", "10"},
+			expectedError: errors.New(constants.WrongArgsResponse),
+		},
+		{
+			name:          "Command too long",
+			command:       []string{"SUBSTR", "key", "10", "15", "20"},
+			expectedError: errors.New(constants.WrongArgsResponse),
+		},
+		{
+			name:          "Start index is not an integer",
+			command:       []string{"SUBSTR", "key", "start", "10"},
+			expectedError: errors.New("start and end indices must be integers"),
+		},
+		{
+			name:          "End index is not an integer",
+			command:       []string{"SUBSTR", "key", "0", "end"},
+			expectedError: errors.New("start and end indices must be integers"),
+		},
+		{
+			name:          "Non-existent key",
+			command:       []string{"SUBSTR", "non-existent-key", "0", "10"},
+			expectedError: errors.New("key non-existent-key does not exist"),
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+
+			ctx := context.WithValue(context.Background(), "test_name", fmt.Sprintf("SUBSTR, %d", i))
+
+			if test.preset {
+				if _, err := mockServer.CreateKeyAndLock(ctx, test.key); err != nil {
+					t.Error(err)
+				}
+				if err := mockServer.SetValue(ctx, test.key, test.presetValue); err != nil {
+					t.Error(err)
+				}
+				mockServer.KeyUnlock(ctx, test.key)
+			}
+
+			handler := getHandler(test.command[0])
+			if handler == nil {
+				t.Errorf("no handler found for command %s", test.command[0])
+				return
+			}
+
+			params := getHandlerFuncParams(ctx, test.command, nil)
+			params.GetConfigParameters = func() map[string]string {
+				if test.legacy {
+					return map[string]string{"protocol-compat": constants.ProtocolCompatEchoVault}
+				}
+				return map[string]string{"protocol-compat": constants.ProtocolCompatRedis7}
+			}
+
+			res, err := handler(params)
+
+			if test.expectedError != nil {
+				if err.Error() != test.expectedError.Error() {
+					t.Errorf("expected error \"%s\", got \"%s\"", test.expectedError.Error(), err.Error())
+				}
+				return
+			}
+			rd := resp.NewReader(bytes.NewBuffer(res))
+			rv, _, err := rd.ReadValue()
+			if err != nil {
+				t.Error(err)
+			}
+			if rv.String() != test.expectedResponse {
+				t.Errorf("expected response \"%s\", got \"%s\"", test.expectedResponse, rv.String())
+			}
+		})
+	}
+}
+
+// presetStrings stores each of the values at its key.
+func presetStrings(ctx context.Context, values map[string]string) error {
+	for key, value := range values {
+		if _, err := mockServer.CreateKeyAndLock(ctx, key); err != nil {
+			return err
+		}
+		if err := mockServer.SetValue(ctx, key, value); err != nil {
+			mockServer.KeyUnlock(ctx, key)
+			return err
+		}
+		mockServer.KeyUnlock(ctx, key)
+	}
+	return nil
+}
+
+func Test_HandleSetBit(t *testing.T) {
+	tests := []struct {
+		name             string
+		presetValues     map[string]string
+		command          []string
+		expectedResponse int
+		expectedValue    string
+		expectedError    error
+	}{
+		{
+			name:             "1. Create the string when the key does not exist",
+			command:          []string{"SETBIT", "SetBitKey1", "7", "1"},
+			expectedResponse: 0,
+			expectedValue:    "\x01",
+			expectedError:    nil,
+		},
+		{
+			name:             "2. Clear a bit of an existing string",
+			presetValues:     map[string]string{"SetBitKey2": "a"},
+			command:          []string{"SETBIT", "SetBitKey2", "1", "0"},
+			expectedResponse: 1,
+			expectedValue:    "!",
+			expectedError:    nil,
+		},
+		{
+			name:             "3. Pad the string with zero bytes up to the offset",
+			presetValues:     map[string]string{"SetBitKey3": "a"},
+			command:          []string{"SETBIT", "SetBitKey3", "23", "1"},
+			expectedResponse: 0,
+			expectedValue:    "a\x00\x01",
+			expectedError:    nil,
+		},
+		{
+			name:          "4. Return error when the offset is negative",
+			command:       []string{"SETBIT", "SetBitKey4", "-1", "1"},
+			expectedError: errors.New("bit offset is not an integer or out of range"),
+		},
+		{
+			name:          "5. Return error when the bit is not 0 or 1",
+			command:       []string{"SETBIT", "SetBitKey5", "1", "2"},
+			expectedError: errors.New("bit is not an integer or out of range"),
+		},
+		{
+			name:          "6. Command too short",
+			command:       []string{"SETBIT", "SetBitKey6", "1"},
+			expectedError: errors.New(constants.WrongArgsResponse),
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), "test_name", fmt.Sprintf("SETBIT, %d", i))
+
+			if err := presetStrings(ctx, test.presetValues); err != nil {
+				t.Error(err)
+				return
+			}
+
+			res, err := getHandler(test.command[0])(getHandlerFuncParams(ctx, test.command, nil))
+			if test.expectedError != nil {
+				if err == nil || err.Error() != test.expectedError.Error() {
+					t.Errorf("expected error \"%s\", got \"%v\"", test.expectedError.Error(), err)
+				}
+				return
+			}
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			rv, _, _ := resp.NewReader(bytes.NewBuffer(res)).ReadValue()
+			if rv.Integer() != test.expectedResponse {
+				t.Errorf("expected response %d, got %d", test.expectedResponse, rv.Integer())
+			}
+
+			key := test.command[1]
+			if _, err = mockServer.KeyRLock(ctx, key); err != nil {
+				t.Error(err)
+				return
+			}
+			defer mockServer.KeyRUnlock(ctx, key)
+			if value := mockServer.GetValue(ctx, key); value != test.expectedValue {
+				t.Errorf("expected value %q, got %q", test.expectedValue, value)
+			}
+		})
+	}
+}
+
+// testBitmapIntegerCommand runs the integer reply test cases of a bitmap command.
+func testBitmapIntegerCommand(t *testing.T, tests []struct {
+	name             string
+	presetValues     map[string]string
+	command          []string
+	expectedResponse int
+	expectedError    error
+}) {
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), "test_name", fmt.Sprintf("%s, %d", test.command[0], i))
+
+			if err := presetStrings(ctx, test.presetValues); err != nil {
+				t.Error(err)
+				return
+			}
+
+			res, err := getHandler(test.command[0])(getHandlerFuncParams(ctx, test.command, nil))
+			if test.expectedError != nil {
+				if err == nil || err.Error() != test.expectedError.Error() {
+					t.Errorf("expected error \"%s\", got \"%v\"", test.expectedError.Error(), err)
+				}
+				return
+			}
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			rv, _, _ := resp.NewReader(bytes.NewBuffer(res)).ReadValue()
+			if rv.Integer() != test.expectedResponse {
+				t.Errorf("expected response %d, got %d", test.expectedResponse, rv.Integer())
+			}
+		})
+	}
+}
+
+func Test_HandleGetBit(t *testing.T) {
+	testBitmapIntegerCommand(t, []struct {
+		name             string
+		presetValues     map[string]string
+		command          []string
+		expectedResponse int
+		expectedError    error
+	}{
+		{
+			name:             "1. Return a set bit",
+			presetValues:     map[string]string{"GetBitKey1": "a"},
+			command:          []string{"GETBIT", "GetBitKey1", "1"},
+			expectedResponse: 1,
+			expectedError:    nil,
+		},
+		{
+			name:             "2. Return a clear bit",
+			presetValues:     map[string]string{"GetBitKey2": "a"},
+			command:          []string{"GETBIT", "GetBitKey2", "0"},
+			expectedResponse: 0,
+			expectedError:    nil,
+		},
+		{
+			name:             "3. Return 0 when the offset is past the end of the string",
+			presetValues:     map[string]string{"GetBitKey3": "a"},
+			command:          []string{"GETBIT", "GetBitKey3", "100"},
+			expectedResponse: 0,
+			expectedError:    nil,
+		},
+		{
+			name:             "4. Return 0 when the key does not exist",
+			command:          []string{"GETBIT", "GetBitKey4", "1"},
+			expectedResponse: 0,
+			expectedError:    nil,
+		},
+		{
+			name:          "5. Return error when the offset is not an integer",
+			command:       []string{"GETBIT", "GetBitKey5", "abc"},
+			expectedError: errors.New("bit offset is not an integer or out of range"),
+		},
+		{
+			name:          "6. Command too short",
+			command:       []string{"GETBIT", "GetBitKey6"},
+			expectedError: errors.New(constants.WrongArgsResponse),
+		},
+	})
+}
+
+func Test_HandleBitCount(t *testing.T) {
+	testBitmapIntegerCommand(t, []struct {
+		name             string
+		presetValues     map[string]string
+		command          []string
+		expectedResponse int
+		expectedError    error
+	}{
+		{
+			name:             "1. Count the set bits of the whole string",
+			presetValues:     map[string]string{"BitCountKey1": "foobar"},
+			command:          []string{"BITCOUNT", "BitCountKey1"},
+			expectedResponse: 26,
+			expectedError:    nil,
+		},
+		{
+			name:             "2. Count the set bits in a byte range",
+			presetValues:     map[string]string{"BitCountKey2": "foobar"},
+			command:          []string{"BITCOUNT", "BitCountKey2", "1", "1"},
+			expectedResponse: 6,
+			expectedError:    nil,
+		},
+		{
+			name:             "3. Count the set bits in a byte range with negative indices",
+			presetValues:     map[string]string{"BitCountKey3": "foobar"},
+			command:          []string{"BITCOUNT", "BitCountKey3", "-2", "-1", "BYTE"},
+			expectedResponse: 7,
+			expectedError:    nil,
+		},
+		{
+			name:             "4. Count the set bits in a bit range",
+			presetValues:     map[string]string{"BitCountKey4": "foobar"},
+			command:          []string{"BITCOUNT", "BitCountKey4", "5", "30", "BIT"},
+			expectedResponse: 17,
+			expectedError:    nil,
+		},
+		{
+			name:             "5. Return 0 when the range is inverted",
+			presetValues:     map[string]string{"BitCountKey5": "foobar"},
+			command:          []string{"BITCOUNT", "BitCountKey5", "3", "1"},
+			expectedResponse: 0,
+			expectedError:    nil,
+		},
+		{
+			name:             "6. Return 0 when the key does not exist",
+			command:          []string{"BITCOUNT", "BitCountKey6"},
+			expectedResponse: 0,
+			expectedError:    nil,
+		},
+		{
+			name:          "7. Return error when the unit is unknown",
+			presetValues:  map[string]string{"BitCountKey7": "foobar"},
+			command:       []string{"BITCOUNT", "BitCountKey7", "0", "1", "WORD"},
+			expectedError: errors.New("syntax error"),
+		},
+		{
+			name:          "8. Return error when the start index is provided without the end index",
+			command:       []string{"BITCOUNT", "BitCountKey8", "0"},
+			expectedError: errors.New(constants.WrongArgsResponse),
+		},
+	})
+}
+
+func Test_HandleBitPos(t *testing.T) {
+	testBitmapIntegerCommand(t, []struct {
+		name             string
+		presetValues     map[string]string
+		command          []string
+		expectedResponse int
+		expectedError    error
+	}{
+		{
+			name:             "1. Return the position of the first clear bit",
+			presetValues:     map[string]string{"BitPosKey1": "\xff\xf0\x00"},
+			command:          []string{"BITPOS", "BitPosKey1", "0"},
+			expectedResponse: 12,
+			expectedError:    nil,
+		},
+		{
+			name:             "2. Return the position of the first set bit after the start byte",
+			presetValues:     map[string]string{"BitPosKey2": "\x00\xff\xf0"},
+			command:          []string{"BITPOS", "BitPosKey2", "1", "2"},
+			expectedResponse: 16,
+			expectedError:    nil,
+		},
+		{
+			name:             "3. Return the position of the first set bit in a bit range",
+			presetValues:     map[string]string{"BitPosKey3": "\x00\xff\xf0"},
+			command:          []string{"BITPOS", "BitPosKey3", "1", "7", "15", "BIT"},
+			expectedResponse: 8,
+			expectedError:    nil,
+		},
+		{
+			name:             "4. Return the bit past the end of the string when all the bits are set",
+			presetValues:     map[string]string{"BitPosKey4": "\xff\xff"},
+			command:          []string{"BITPOS", "BitPosKey4", "0"},
+			expectedResponse: 16,
+			expectedError:    nil,
+		},
+		{
+			name:             "5. Return -1 when all the bits in an explicit range are set",
+			presetValues:     map[string]string{"BitPosKey5": "\xff\xff"},
+			command:          []string{"BITPOS", "BitPosKey5", "0", "0", "-1"},
+			expectedResponse: -1,
+			expectedError:    nil,
+		},
+		{
+			name:             "6. Return -1 when looking for a set bit in a key that does not exist",
+			command:          []string{"BITPOS", "BitPosKey6", "1"},
+			expectedResponse: -1,
+			expectedError:    nil,
+		},
+		{
+			name:          "7. Return error when the bit is not 0 or 1",
+			command:       []string{"BITPOS", "BitPosKey7", "2"},
+			expectedError: errors.New("the bit argument must be 1 or 0"),
+		},
+		{
+			name:          "8. Command too short",
+			command:       []string{"BITPOS", "BitPosKey8"},
+			expectedError: errors.New(constants.WrongArgsResponse),
+		},
+	})
+}
+
+func Test_HandleBitOp(t *testing.T) {
+	tests := []struct {
+		name             string
+		presetValues     map[string]string
+		command          []string
+		expectedResponse int
+		expectedValue    interface{} // Nil if the destination should not exist.
+		expectedError    error
+	}{
+		{
+			name:             "1. AND the strings into the destination",
+			presetValues:     map[string]string{"BitOpKey1": "foobar", "BitOpKey2": "abcdef"},
+			command:          []string{"BITOP", "AND", "BitOpDest1", "BitOpKey1", "BitOpKey2"},
+			expectedResponse: 6,
+			expectedValue:    "`bc`ab",
+			expectedError:    nil,
+		},
+		{
+			name:             "2. OR pads the shorter strings with zero bytes",
+			presetValues:     map[string]string{"BitOpKey3": "a", "BitOpKey4": "\x00\x01"},
+			command:          []string{"BITOP", "OR", "BitOpDest2", "BitOpKey3", "BitOpKey4", "BitOpKey5"},
+			expectedResponse: 2,
+			expectedValue:    "a\x01",
+			expectedError:    nil,
+		},
+		{
+			name:             "3. XOR the strings into the destination",
+			presetValues:     map[string]string{"BitOpKey6": "\x0f", "BitOpKey7": "\xff"},
+			command:          []string{"BITOP", "XOR", "BitOpDest3", "BitOpKey6", "BitOpKey7"},
+			expectedResponse: 1,
+			expectedValue:    "\xf0",
+			expectedError:    nil,
+		},
+		{
+			name:             "4. NOT the string into the destination",
+			presetValues:     map[string]string{"BitOpKey8": "\x0f\x00"},
+			command:          []string{"BITOP", "NOT", "BitOpDest4", "BitOpKey8"},
+			expectedResponse: 2,
+			expectedValue:    "\xf0\xff",
+			expectedError:    nil,
+		},
+		{
+			name:             "5. Delete the destination when the result is empty",
+			presetValues:     map[string]string{"BitOpDest5": "value"},
+			command:          []string{"BITOP", "AND", "BitOpDest5", "BitOpKey9", "BitOpKey10"},
+			expectedResponse: 0,
+			expectedValue:    nil,
+			expectedError:    nil,
+		},
+		{
+			name:          "6. Return error when NOT is called with more than one key",
+			command:       []string{"BITOP", "NOT", "BitOpDest6", "BitOpKey11", "BitOpKey12"},
+			expectedError: errors.New("BITOP NOT must be called with a single source key"),
+		},
+		{
+			name:          "7. Return error when the operation is unknown",
+			command:       []string{"BITOP", "NAND", "BitOpDest7", "BitOpKey13"},
+			expectedError: errors.New("unknown operation NAND"),
+		},
+		{
+			name:          "8. Command too short",
+			command:       []string{"BITOP", "AND", "BitOpDest8"},
+			expectedError: errors.New(constants.WrongArgsResponse),
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), "test_name", fmt.Sprintf("BITOP, %d", i))
+
+			if err := presetStrings(ctx, test.presetValues); err != nil {
+				t.Error(err)
+				return
+			}
+
+			res, err := getHandler(test.command[0])(getHandlerFuncParams(ctx, test.command, nil))
+			if test.expectedError != nil {
+				if err == nil || err.Error() != test.expectedError.Error() {
+					t.Errorf("expected error \"%s\", got \"%v\"", test.expectedError.Error(), err)
+				}
+				return
+			}
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			rv, _, _ := resp.NewReader(bytes.NewBuffer(res)).ReadValue()
+			if rv.Integer() != test.expectedResponse {
+				t.Errorf("expected response %d, got %d", test.expectedResponse, rv.Integer())
+			}
+
+			destination := test.command[2]
+			if test.expectedValue == nil {
+				if mockServer.KeyExists(ctx, destination) {
+					t.Errorf("expected key %s to be deleted", destination)
+				}
+				return
+			}
+			if _, err = mockServer.KeyRLock(ctx, destination); err != nil {
+				t.Error(err)
+				return
+			}
+			defer mockServer.KeyRUnlock(ctx, destination)
+			if value := mockServer.GetValue(ctx, destination); value != test.expectedValue {
+				t.Errorf("expected value %q, got %q", test.expectedValue, value)
 			}
 		})
 	}
@@ -0,0 +1,288 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transaction
+
+import (
+	"fmt"
+	"github.com/echovault/echovault/echovault"
+	"github.com/echovault/echovault/internal/config"
+	"github.com/echovault/echovault/internal/constants"
+	"github.com/tidwall/resp"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+)
+
+var mockServer *echovault.EchoVault
+var bindAddr = "localhost"
+var port uint16 = 7498
+
+func init() {
+	mockServer, _ = echovault.NewEchoVault(
+		echovault.WithConfig(config.Config{
+			BindAddr:       bindAddr,
+			Port:           port,
+			DataDir:        "",
+			EvictionPolicy: constants.NoEviction,
+		}),
+	)
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		wg.Done()
+		mockServer.Start()
+	}()
+	wg.Wait()
+}
+
+type client struct {
+	t    *testing.T
+	conn net.Conn
+	r    *resp.Conn
+}
+
+func newClient(t *testing.T) *client {
+	conn, err := net.Dial("tcp", fmt.Sprintf("%s:%d", bindAddr, port))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+	return &client{t: t, conn: conn, r: resp.NewConn(conn)}
+}
+
+// do sends the command and returns the reply.
+func (c *client) do(args ...string) resp.Value {
+	cmd := make([]resp.Value, len(args))
+	for i, arg := range args {
+		cmd[i] = resp.StringValue(arg)
+	}
+	if err := c.r.WriteArray(cmd); err != nil {
+		c.t.Fatal(err)
+	}
+	v, _, err := c.r.ReadValue()
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	return v
+}
+
+// expect sends the command and checks that the reply is the expected string.
+func (c *client) expect(want string, args ...string) {
+	c.t.Helper()
+	if got := c.do(args...).String(); got != want {
+		c.t.Errorf("%s: expected reply \"%s\", got \"%s\"", strings.Join(args, " "), want, got)
+	}
+}
+
+// expectError sends the command and checks that the reply is an error containing the expected message.
+func (c *client) expectError(want string, args ...string) {
+	c.t.Helper()
+	v := c.do(args...)
+	if v.Type() != resp.Error || !strings.Contains(v.Error().Error(), want) {
+		c.t.Errorf("%s: expected error containing \"%s\", got \"%s\"", strings.Join(args, " "), want, v.String())
+	}
+}
+
+func Test_HandleMULTI(t *testing.T) {
+	t.Run("1. Run the queued commands and return their replies", func(t *testing.T) {
+		c := newClient(t)
+		c.expect("OK", "MULTI")
+		c.expect("QUEUED", "SET", "MultiKey1", "value1")
+		c.expect("QUEUED", "GET", "MultiKey1")
+		c.expect("QUEUED", "LPUSH", "MultiKey1", "element")
+		c.expect("QUEUED", "LPUSH", "MultiKey2", "element")
+
+		res := c.do("EXEC")
+		if res.Type() != resp.Array || len(res.Array()) != 4 {
+			t.Fatalf("expected array of 4 replies, got %s", res.String())
+		}
+		if got := res.Array()[0].String(); got != "OK" {
+			t.Errorf("expected reply \"OK\", got \"%s\"", got)
+		}
+		if got := res.Array()[1].String(); got != "value1" {
+			t.Errorf("expected reply \"value1\", got \"%s\"", got)
+		}
+		// A failing command does not stop the commands after it.
+		if res.Array()[2].Type() != resp.Error {
+			t.Errorf("expected error reply, got \"%s\"", res.Array()[2].String())
+		}
+		if got := res.Array()[3].Integer(); got != 1 {
+			t.Errorf("expected reply 1, got %d", got)
+		}
+	})
+
+	t.Run("2. Discard the queued commands", func(t *testing.T) {
+		c := newClient(t)
+		c.expect("OK", "MULTI")
+		c.expect("QUEUED", "SET", "MultiKey3", "value3")
+		c.expect("OK", "DISCARD")
+		if v := c.do("GET", "MultiKey3"); !v.IsNull() {
+			t.Errorf("expected nil reply, got \"%s\"", v.String())
+		}
+	})
+
+	t.Run("3. Abort the transaction when a command can not be queued", func(t *testing.T) {
+		c := newClient(t)
+		c.expect("OK", "MULTI")
+		c.expect("QUEUED", "SET", "MultiKey4", "value4")
+		c.expectError(constants.WrongArgsResponse, "SET", "MultiKey4")
+		c.expectError("EXECABORT", "EXEC")
+		if v := c.do("GET", "MultiKey4"); !v.IsNull() {
+			t.Errorf("expected nil reply, got \"%s\"", v.String())
+		}
+
+		c.expect("OK", "MULTI")
+		c.expectError("not supported", "UNKNOWNCOMMAND")
+		c.expectError("EXECABORT", "EXEC")
+
+		c.expect("OK", "MULTI")
+		c.expectError("BLPOP is not allowed in a transaction", "BLPOP", "MultiKey5", "0")
+		c.expectError("EXECABORT", "EXEC")
+	})
+
+	t.Run("4. Return errors for transaction commands in the wrong state", func(t *testing.T) {
+		c := newClient(t)
+		c.expectError("EXEC without MULTI", "EXEC")
+		c.expectError("DISCARD without MULTI", "DISCARD")
+		c.expect("OK", "MULTI")
+		c.expectError("MULTI calls can not be nested", "MULTI")
+		c.expectError("WATCH inside MULTI is not allowed", "WATCH", "MultiKey6")
+		// The errors above don't abort the transaction.
+		c.expect("QUEUED", "SET", "MultiKey6", "value6")
+		res := c.do("EXEC")
+		if len(res.Array()) != 1 || res.Array()[0].String() != "OK" {
+			t.Errorf("expected [OK], got %s", res.String())
+		}
+	})
+
+	t.Run("5. Run the transaction with no other command in between", func(t *testing.T) {
+		writer := newClient(t)
+		done := make(chan struct{})
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+					writer.do("SET", "MultiKey7", "writer")
+				}
+			}
+		}()
+
+		c := newClient(t)
+		for i := 0; i < 50; i++ {
+			c.expect("OK", "MULTI")
+			c.expect("QUEUED", "SET", "MultiKey7", "transaction")
+			c.expect("QUEUED", "GET", "MultiKey7")
+			res := c.do("EXEC")
+			if len(res.Array()) != 2 || res.Array()[1].String() != "transaction" {
+				t.Errorf("expected the transaction to read its own write, got %s", res.String())
+				break
+			}
+		}
+		close(done)
+		wg.Wait()
+	})
+}
+
+func Test_HandleWATCH(t *testing.T) {
+	t.Run("1. Abort EXEC when a watched key is modified", func(t *testing.T) {
+		c, other := newClient(t), newClient(t)
+		c.expect("OK", "SET", "WatchKey1", "value1")
+		c.expect("OK", "WATCH", "WatchKey1")
+		other.expect("OK", "SET", "WatchKey1", "other")
+		c.expect("OK", "MULTI")
+		c.expect("QUEUED", "SET", "WatchKey1", "transaction")
+		if v := c.do("EXEC"); !v.IsNull() {
+			t.Errorf("expected nil reply, got %s", v.String())
+		}
+		c.expect("other", "GET", "WatchKey1")
+
+		// EXEC unwatches the keys, so the next transaction is run.
+		other.expect("OK", "SET", "WatchKey1", "other2")
+		c.expect("OK", "MULTI")
+		c.expect("QUEUED", "SET", "WatchKey1", "transaction")
+		if v := c.do("EXEC"); len(v.Array()) != 1 {
+			t.Errorf("expected [OK], got %s", v.String())
+		}
+		c.expect("transaction", "GET", "WatchKey1")
+	})
+
+	t.Run("2. Abort EXEC when a watched key is created or deleted", func(t *testing.T) {
+		c, other := newClient(t), newClient(t)
+		c.expect("OK", "WATCH", "WatchKey2")
+		other.expect("OK", "SET", "WatchKey2", "other")
+		c.expect("OK", "MULTI")
+		c.expect("QUEUED", "GET", "WatchKey2")
+		if v := c.do("EXEC"); !v.IsNull() {
+			t.Errorf("expected nil reply, got %s", v.String())
+		}
+
+		c.expect("OK", "WATCH", "WatchKey2")
+		other.do("DEL", "WatchKey2")
+		c.expect("OK", "MULTI")
+		c.expect("QUEUED", "GET", "WatchKey2")
+		if v := c.do("EXEC"); !v.IsNull() {
+			t.Errorf("expected nil reply, got %s", v.String())
+		}
+	})
+
+	t.Run("3. Run EXEC when the watched keys are unchanged", func(t *testing.T) {
+		c := newClient(t)
+		c.expect("OK", "SET", "WatchKey3", "value3")
+		c.expect("OK", "WATCH", "WatchKey3", "WatchKey4")
+		c.expect("OK", "MULTI")
+		c.expect("QUEUED", "SET", "WatchKey3", "transaction")
+		if v := c.do("EXEC"); len(v.Array()) != 1 {
+			t.Errorf("expected [OK], got %s", v.String())
+		}
+	})
+
+	t.Run("4. UNWATCH forgets the watched keys", func(t *testing.T) {
+		c, other := newClient(t), newClient(t)
+		c.expect("OK", "WATCH", "WatchKey5")
+		other.expect("OK", "SET", "WatchKey5", "other")
+		c.expect("OK", "UNWATCH")
+		c.expect("OK", "MULTI")
+		c.expect("QUEUED", "SET", "WatchKey5", "transaction")
+		if v := c.do("EXEC"); len(v.Array()) != 1 {
+			t.Errorf("expected [OK], got %s", v.String())
+		}
+	})
+
+	t.Run("5. DISCARD forgets the watched keys", func(t *testing.T) {
+		c, other := newClient(t), newClient(t)
+		c.expect("OK", "WATCH", "WatchKey6")
+		other.expect("OK", "SET", "WatchKey6", "other")
+		c.expect("OK", "MULTI")
+		c.expect("OK", "DISCARD")
+		c.expect("OK", "MULTI")
+		c.expect("QUEUED", "SET", "WatchKey6", "transaction")
+		if v := c.do("EXEC"); len(v.Array()) != 1 {
+			t.Errorf("expected [OK], got %s", v.String())
+		}
+	})
+
+	t.Run("6. Command too short", func(t *testing.T) {
+		c := newClient(t)
+		c.expectError(constants.WrongArgsResponse, "WATCH")
+	})
+}
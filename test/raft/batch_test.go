@@ -0,0 +1,145 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raft
+
+import (
+	"fmt"
+	"github.com/echovault/echovault/echovault"
+	"github.com/echovault/echovault/internal/config"
+	"github.com/echovault/echovault/internal/constants"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newLeader returns a single node cluster once it has been elected leader.
+func newLeader(t *testing.T, port uint16, batchSize uint) *echovault.EchoVault {
+	server, err := echovault.NewEchoVault(
+		echovault.WithConfig(config.Config{
+			ServerID:           fmt.Sprintf("raft_batch_node_%d", port),
+			BindAddr:           "127.0.0.1",
+			Port:               port,
+			RaftBindPort:       port + 1,
+			MemberListBindPort: port + 2,
+			InMemory:           true,
+			BootstrapCluster:   true,
+			EvictionPolicy:     constants.NoEviction,
+			RaftBatchSize:      batchSize,
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Writes are rejected until the node has elected itself leader.
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		if _, err = server.Set("RaftBatchReady", "ready", echovault.SetOptions{}); err == nil {
+			return server
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the node to become leader, got %v", err)
+		}
+		<-time.After(50 * time.Millisecond)
+	}
+}
+
+func Test_RaftBatching(t *testing.T) {
+	server := newLeader(t, 7530, 16)
+
+	t.Run("1. Each concurrent proposal receives its own response", func(t *testing.T) {
+		const writers = 200
+		results := make([]int, writers)
+		errs := make([]error, writers)
+		wg := sync.WaitGroup{}
+		for i := 0; i < writers; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				results[i], errs[i] = server.Incr("RaftBatchCounter")
+			}(i)
+		}
+		wg.Wait()
+
+		for i, err := range errs {
+			if err != nil {
+				t.Fatalf("expected write %d to succeed, got %v", i, err)
+			}
+		}
+		// Every increment is applied once, so the responses are exactly the values 1 to n.
+		slices.Sort(results)
+		for i, result := range results {
+			if result != i+1 {
+				t.Fatalf("expected the responses to be 1 to %d, got %v", writers, results)
+			}
+		}
+	})
+
+	t.Run("2. A failed proposal does not fail the rest of its batch", func(t *testing.T) {
+		if _, err := server.Set("RaftBatchString", "not a number", echovault.SetOptions{}); err != nil {
+			t.Fatal(err)
+		}
+
+		const writers = 100
+		errs := make([]error, writers)
+		wg := sync.WaitGroup{}
+		for i := 0; i < writers; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				if i%2 == 0 {
+					_, errs[i] = server.Incr("RaftBatchString")
+					return
+				}
+				_, errs[i] = server.Set(fmt.Sprintf("RaftBatchKey%d", i), fmt.Sprintf("value%d", i), echovault.SetOptions{})
+			}(i)
+		}
+		wg.Wait()
+
+		for i, err := range errs {
+			if i%2 == 0 && err == nil {
+				t.Errorf("expected INCR %d on a string to fail", i)
+			}
+			if i%2 == 1 && err != nil {
+				t.Errorf("expected SET %d to succeed, got %v", i, err)
+			}
+		}
+		for i := 1; i < writers; i += 2 {
+			value, err := server.Get(fmt.Sprintf("RaftBatchKey%d", i))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if value != fmt.Sprintf("value%d", i) {
+				t.Errorf("expected value%d at RaftBatchKey%d, got %s", i, i, value)
+			}
+		}
+	})
+
+	t.Run("3. Writes from a single client are applied in order", func(t *testing.T) {
+		for i := 0; i < 50; i++ {
+			if _, err := server.Set("RaftBatchOrdered", fmt.Sprintf("value%d", i), echovault.SetOptions{}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		value, err := server.Get("RaftBatchOrdered")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if value != "value49" {
+			t.Errorf("expected value49, got %s", value)
+		}
+	})
+}
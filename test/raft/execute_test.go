@@ -0,0 +1,54 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raft
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func Test_ExecuteCommand(t *testing.T) {
+	server := newLeader(t, 7540, 0)
+
+	t.Run("1. Apply a write through the raft log", func(t *testing.T) {
+		reply, err := server.ExecuteCommand(context.Background(), "SET", "ExecuteKey1", "value1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reply.String != "OK" {
+			t.Errorf("expected OK, got %+v", reply)
+		}
+		reply, err = server.ExecuteCommand(context.Background(), "GET", "ExecuteKey1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reply.String != "value1" {
+			t.Errorf("expected value1, got %+v", reply)
+		}
+	})
+
+	t.Run("2. Run the command with the caller's deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		reply, err := server.ExecuteCommand(ctx, "INCR", "ExecuteKey2")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reply.Integer != 1 {
+			t.Errorf("expected 1, got %+v", reply)
+		}
+	})
+}
@@ -0,0 +1,103 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshot
+
+import (
+	"fmt"
+	"github.com/echovault/echovault/internal"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// generateState returns a state with n keys of each of the JSON value types held in a snapshot.
+func generateState(n int) map[string]internal.KeyData {
+	expireAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	state := make(map[string]internal.KeyData, n*4)
+	for i := 0; i < n; i++ {
+		state[fmt.Sprintf("string:%d", i)] = internal.KeyData{Value: fmt.Sprintf("value %d", i), ExpireAt: expireAt}
+		state[fmt.Sprintf("number:%d", i)] = internal.KeyData{Value: float64(i)}
+		state[fmt.Sprintf("hash:%d", i)] = internal.KeyData{Value: map[string]interface{}{"field": fmt.Sprintf("%d", i)}}
+		state[fmt.Sprintf("list:%d", i)] = internal.KeyData{Value: []interface{}{"one", "two", float64(i)}}
+	}
+	return state
+}
+
+func Test_DecodeState(t *testing.T) {
+	t.Run("1. Decode a state with more keys than shards", func(t *testing.T) {
+		state := generateState(1000)
+		b, err := internal.EncodeState(state)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := internal.DecodeState(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(decoded, state) {
+			t.Errorf("expected decoded state to match the encoded state, got %d keys out of %d", len(decoded), len(state))
+		}
+	})
+
+	t.Run("2. Decode an empty state", func(t *testing.T) {
+		for _, b := range []string{"{}", "null"} {
+			decoded, err := internal.DecodeState([]byte(b))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(decoded) != 0 {
+				t.Errorf("expected empty state for %s, got %+v", b, decoded)
+			}
+		}
+	})
+
+	t.Run("3. The last value of a duplicated key wins", func(t *testing.T) {
+		decoded, err := internal.DecodeState([]byte(`{"key":{"Value":"first"},"key":{"Value":"second"}}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if decoded["key"].Value != "second" {
+			t.Errorf("expected value \"second\", got %v", decoded["key"].Value)
+		}
+	})
+
+	t.Run("4. Return an error for an invalid state", func(t *testing.T) {
+		for _, b := range []string{
+			`[]`,
+			`{"key":{"Value":"value"}`,
+			`{"key":{"ExpireAt":"not a time"}}`,
+			`{"key":{"Value":"value"}} {}`,
+			"{\"\\u0000base64:!!!\":{\"Value\":\"value\"}}",
+		} {
+			if _, err := internal.DecodeState([]byte(b)); err == nil {
+				t.Errorf("expected error decoding %s, got nil", b)
+			}
+		}
+	})
+}
+
+func Benchmark_DecodeState(b *testing.B) {
+	encoded, err := internal.EncodeState(generateState(25000))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.SetBytes(int64(len(encoded)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err = internal.DecodeState(encoded); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
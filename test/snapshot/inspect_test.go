@@ -0,0 +1,100 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshot
+
+import (
+	"github.com/echovault/echovault/internal"
+	"github.com/echovault/echovault/internal/snapshot"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_Inspect(t *testing.T) {
+	directory := t.TempDir()
+	now := time.Now()
+
+	engine := snapshot.NewSnapshotEngine(
+		snapshot.WithDirectory(directory),
+		snapshot.WithInterval(0),
+		snapshot.WithGetStateFunc(func() map[string]internal.KeyData {
+			return map[string]internal.KeyData{
+				"string": {Value: "value", ExpireAt: now.Add(time.Hour)},
+				"int":    {Value: 12345},
+				"hash":   {Value: map[string]interface{}{"field1": "value1", "field2": "value2"}},
+				"list":   {Value: []interface{}{"one", "two", "three"}},
+			}
+		}),
+	)
+	if err := engine.TakeSnapshot(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []snapshot.KeyInfo{
+		{Key: "hash", Type: "hash", Size: 37, Length: 2, TTL: -1},
+		{Key: "int", Type: "string", Size: 5, Length: 5, TTL: -1},
+		{Key: "list", Type: "list", Size: 21, Length: 3, TTL: -1},
+		{Key: "string", Type: "string", Size: 7, Length: 5},
+	}
+
+	t.Run("1. Inspect the latest snapshot in a data directory", func(t *testing.T) {
+		dump, err := snapshot.Inspect(directory, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(dump.Keys) != len(want) {
+			t.Fatalf("expected %d keys, got %d", len(want), len(dump.Keys))
+		}
+		// The TTL depends on when the snapshot was taken, so check it separately.
+		if ttl := dump.Keys[3].TTL; ttl <= 0 || ttl > time.Hour.Milliseconds() {
+			t.Errorf("expected TTL within an hour, got %d", ttl)
+		}
+		dump.Keys[3].TTL = 0
+		if !reflect.DeepEqual(dump.Keys, want) {
+			t.Errorf("expected keys %+v, got %+v", want, dump.Keys)
+		}
+	})
+
+	t.Run("2. Inspect a snapshot file and report expired keys", func(t *testing.T) {
+		entries, err := os.ReadDir(path.Join(directory, "snapshots"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var file string
+		for _, entry := range entries {
+			if entry.IsDir() {
+				file = path.Join(directory, "snapshots", entry.Name(), "state.bin")
+			}
+		}
+		dump, err := snapshot.Inspect(file, now.Add(2*time.Hour))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dump.File != file {
+			t.Errorf("expected file %s, got %s", file, dump.File)
+		}
+		if key := dump.Keys[3]; !key.Expired || key.TTL != 0 {
+			t.Errorf("expected key to be expired, got %+v", key)
+		}
+	})
+
+	t.Run("3. Return an error if there's no snapshot", func(t *testing.T) {
+		if _, err := snapshot.Inspect(t.TempDir(), now); err == nil {
+			t.Error("expected error, got nil")
+		}
+	})
+}
@@ -0,0 +1,113 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshot
+
+import (
+	"github.com/echovault/echovault/internal"
+	"github.com/echovault/echovault/internal/snapshot"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_RestoreBinaryKeys(t *testing.T) {
+	directory := t.TempDir()
+
+	// Keys that are not valid UTF-8, contain CR/LF or null bytes, or look like an encoded key.
+	state := map[string]internal.KeyData{
+		"\xff\xfe":        {Value: "invalid utf-8"},
+		"line\r\nbreak":   {Value: "crlf"},
+		"null\x00byte":    {Value: "null"},
+		"\x00base64:AAAA": {Value: "prefix"},
+		"plain key":       {Value: "space"},
+		"unicode é世":      {Value: "unicode"},
+	}
+
+	engine := snapshot.NewSnapshotEngine(
+		snapshot.WithDirectory(directory),
+		snapshot.WithInterval(0),
+		snapshot.WithGetStateFunc(func() map[string]internal.KeyData { return state }),
+	)
+	if err := engine.TakeSnapshot(); err != nil {
+		t.Fatal(err)
+	}
+
+	restored := make(map[string]internal.KeyData)
+	engine = snapshot.NewSnapshotEngine(
+		snapshot.WithDirectory(directory),
+		snapshot.WithInterval(0),
+		snapshot.WithSetKeyDataFunc(func(key string, data internal.KeyData) { restored[key] = data }),
+	)
+	if err := engine.Restore(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(restored, state) {
+		t.Errorf("expected restored state %q, got %q", state, restored)
+	}
+
+	dump, err := snapshot.Inspect(directory, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range dump.Keys {
+		if _, ok := state[key.Key]; !ok {
+			t.Errorf("inspect returned unexpected key %q", key.Key)
+		}
+	}
+}
+
+func Test_RestoreProgress(t *testing.T) {
+	directory := t.TempDir()
+
+	state := map[string]internal.KeyData{
+		"key1": {Value: "value1"},
+		"key2": {Value: "value2"},
+		"key3": {Value: "value3"},
+		"key4": {Value: "value4"},
+	}
+
+	engine := snapshot.NewSnapshotEngine(
+		snapshot.WithDirectory(directory),
+		snapshot.WithInterval(0),
+		snapshot.WithGetStateFunc(func() map[string]internal.KeyData { return state }),
+	)
+	if err := engine.TakeSnapshot(); err != nil {
+		t.Fatal(err)
+	}
+
+	var restored int
+	var progress [][2]int
+	engine = snapshot.NewSnapshotEngine(
+		snapshot.WithDirectory(directory),
+		snapshot.WithInterval(0),
+		snapshot.WithSetKeyDataFunc(func(key string, data internal.KeyData) { restored++ }),
+		snapshot.WithRestoreProgressFunc(func(loaded, total int) {
+			// The progress is reported after the key is restored.
+			if loaded != restored {
+				t.Errorf("expected progress %d to match the %d keys restored", loaded, restored)
+			}
+			progress = append(progress, [2]int{loaded, total})
+		}),
+	)
+	if err := engine.Restore(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := [][2]int{{1, 4}, {2, 4}, {3, 4}, {4, 4}}
+	if !reflect.DeepEqual(progress, expected) {
+		t.Errorf("expected progress %v, got %v", expected, progress)
+	}
+}
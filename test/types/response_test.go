@@ -0,0 +1,144 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"bytes"
+	"errors"
+	"github.com/echovault/echovault/types"
+	"testing"
+)
+
+func Test_ResponseWriter(t *testing.T) {
+	tests := []struct {
+		name  string
+		write func(w *types.ResponseWriter)
+		want  string
+	}{
+		{
+			name:  "1. Write an integer",
+			write: func(w *types.ResponseWriter) { w.Integer(-12) },
+			want:  ":-12\r\n",
+		},
+		{
+			name:  "2. Write a bulk string",
+			write: func(w *types.ResponseWriter) { w.Bulk("hello") },
+			want:  "$5\r\nhello\r\n",
+		},
+		{
+			name:  "3. Write an empty bulk string",
+			write: func(w *types.ResponseWriter) { w.Bulk("") },
+			want:  "$0\r\n\r\n",
+		},
+		{
+			name:  "4. Write a float in its shortest representation",
+			write: func(w *types.ResponseWriter) { w.Float(3.50) },
+			want:  "$3\r\n3.5\r\n",
+		},
+		{
+			name:  "5. Write simple strings and errors",
+			write: func(w *types.ResponseWriter) { w.Simple("OK").Error("ERR failed") },
+			want:  "+OK\r\n-ERR failed\r\n",
+		},
+		{
+			name:  "6. Write nil replies",
+			write: func(w *types.ResponseWriter) { w.Null().NullArray() },
+			want:  "$-1\r\n*-1\r\n",
+		},
+		{
+			name:  "7. Write an empty array",
+			write: func(w *types.ResponseWriter) { w.BulkArray(nil) },
+			want:  "*0\r\n",
+		},
+		{
+			name: "8. Write nested arrays",
+			write: func(w *types.ResponseWriter) {
+				w.Array(2).BulkArray([]string{"a", "b"}).Array(1).Integer(1)
+			},
+			want: "*2\r\n*2\r\n$1\r\na\r\n$1\r\nb\r\n*1\r\n:1\r\n",
+		},
+		{
+			name: "9. Write a map as an array of keys and values",
+			write: func(w *types.ResponseWriter) {
+				w.Map(1).Bulk("field").Bulk("value")
+			},
+			want: "*2\r\n$5\r\nfield\r\n$5\r\nvalue\r\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := types.NewResponseWriter(0)
+			test.write(w)
+			if got := string(w.Bytes()); got != test.want {
+				t.Errorf("expected response %q, got %q", test.want, got)
+			}
+		})
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("connection closed")
+}
+
+func Test_StreamingResponseWriter(t *testing.T) {
+	t.Run("1. Stream the reply once the flush size is reached", func(t *testing.T) {
+		out := new(bytes.Buffer)
+		w := types.NewStreamingResponseWriter(out, 16)
+		// The header and the first element are 15 bytes, so the reply is streamed with the second element.
+		w.Array(3).Bulk("first")
+		if w.Streamed() {
+			t.Fatal("expected reply not to be streamed before the flush size is reached")
+		}
+		w.Bulk("second")
+		if got := out.String(); !w.Streamed() || got != "*3\r\n$5\r\nfirst\r\n$6\r\nsecond\r\n" {
+			t.Errorf("expected streamed reply, got %q", got)
+		}
+		w.Bulk("c")
+		if got := string(w.Bytes()); got != "$1\r\nc\r\n" {
+			t.Errorf("expected the rest of the reply, got %q", got)
+		}
+		if got := out.String() + string(w.Bytes()); got != "*3\r\n$5\r\nfirst\r\n$6\r\nsecond\r\n$1\r\nc\r\n" {
+			t.Errorf("expected full reply, got %q", got)
+		}
+	})
+
+	t.Run("2. Do not stream a reply smaller than the flush size", func(t *testing.T) {
+		out := new(bytes.Buffer)
+		w := types.NewStreamingResponseWriter(out, 1024)
+		w.BulkArray([]string{"a", "b"})
+		if w.Streamed() || out.Len() != 0 {
+			t.Errorf("expected reply not to be streamed, got %q", out.String())
+		}
+		if got := string(w.Bytes()); got != "*2\r\n$1\r\na\r\n$1\r\nb\r\n" {
+			t.Errorf("expected buffered reply, got %q", got)
+		}
+	})
+
+	t.Run("3. Discard the reply once the output fails", func(t *testing.T) {
+		w := types.NewStreamingResponseWriter(failingWriter{}, 8)
+		for i := 0; i < 100; i++ {
+			w.Bulk("member")
+		}
+		if w.Err() == nil {
+			t.Error("expected error, got nil")
+		}
+		if len(w.Bytes()) >= 8 {
+			t.Errorf("expected the reply to be discarded, got %d buffered bytes", len(w.Bytes()))
+		}
+	})
+}
@@ -0,0 +1,152 @@
+// Copyright 2024 Kelvin Clement Mwinuka
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"io"
+	"strconv"
+)
+
+// ResponseWriter builds a RESP2 reply in a single growing buffer.
+// Command handlers use it instead of concatenating RESP strings, so that the CRLF terminators and length
+// prefixes are always correct and large replies are not copied on every append.
+//
+// Aggregate replies are written as a header followed by their elements, e.g. Array(2) followed by two Bulk calls.
+// The methods return the writer so that short replies can be chained, e.g. new(ResponseWriter).Integer(1).Bytes().
+// The zero value is ready to use.
+type ResponseWriter struct {
+	buf       []byte
+	out       io.Writer // When set, the buffered reply is written to out once it reaches flushSize bytes.
+	flushSize int
+	streamed  bool
+	err       error
+}
+
+// NewResponseWriter returns a ResponseWriter with room for size bytes before the buffer has to grow.
+func NewResponseWriter(size int) *ResponseWriter {
+	return &ResponseWriter{buf: make([]byte, 0, size)}
+}
+
+// NewStreamingResponseWriter returns a ResponseWriter that writes the reply to out whenever flushSize bytes are buffered,
+// so that a very large reply is not held in memory in full. Writes to out block while the receiver is not keeping up,
+// which slows down the writer instead of buffering more of the reply. Bytes returns the part of the reply
+// that has not been written to out yet.
+func NewStreamingResponseWriter(out io.Writer, flushSize int) *ResponseWriter {
+	return &ResponseWriter{out: out, flushSize: flushSize}
+}
+
+// Integer writes an integer reply.
+func (w *ResponseWriter) Integer(n int) *ResponseWriter {
+	w.buf = append(w.buf, ':')
+	w.buf = strconv.AppendInt(w.buf, int64(n), 10)
+	w.buf = append(w.buf, '\r', '\n')
+	return w.flush()
+}
+
+// Bulk writes a bulk string reply.
+func (w *ResponseWriter) Bulk(s string) *ResponseWriter {
+	w.buf = append(w.buf, '$')
+	w.buf = strconv.AppendInt(w.buf, int64(len(s)), 10)
+	w.buf = append(w.buf, '\r', '\n')
+	w.buf = append(w.buf, s...)
+	w.buf = append(w.buf, '\r', '\n')
+	return w.flush()
+}
+
+// Float writes a float as a bulk string reply, in its shortest decimal representation.
+func (w *ResponseWriter) Float(f float64) *ResponseWriter {
+	return w.Bulk(strconv.FormatFloat(f, 'f', -1, 64))
+}
+
+// Simple writes a simple string reply. The string must not contain CR or LF.
+func (w *ResponseWriter) Simple(s string) *ResponseWriter {
+	w.buf = append(w.buf, '+')
+	w.buf = append(w.buf, s...)
+	w.buf = append(w.buf, '\r', '\n')
+	return w.flush()
+}
+
+// Error writes an error reply. It's used for errors nested in aggregate replies; handlers report a
+// failed command by returning an error instead.
+func (w *ResponseWriter) Error(s string) *ResponseWriter {
+	w.buf = append(w.buf, '-')
+	w.buf = append(w.buf, s...)
+	w.buf = append(w.buf, '\r', '\n')
+	return w.flush()
+}
+
+// Null writes a nil bulk string reply.
+func (w *ResponseWriter) Null() *ResponseWriter {
+	w.buf = append(w.buf, "$-1\r\n"...)
+	return w.flush()
+}
+
+// NullArray writes a nil array reply.
+func (w *ResponseWriter) NullArray() *ResponseWriter {
+	w.buf = append(w.buf, "*-1\r\n"...)
+	return w.flush()
+}
+
+// Array writes the header of an array reply with n elements. The elements must be written next.
+func (w *ResponseWriter) Array(n int) *ResponseWriter {
+	w.buf = append(w.buf, '*')
+	w.buf = strconv.AppendInt(w.buf, int64(n), 10)
+	w.buf = append(w.buf, '\r', '\n')
+	return w.flush()
+}
+
+// BulkArray writes an array reply of bulk strings.
+func (w *ResponseWriter) BulkArray(elems []string) *ResponseWriter {
+	w.Array(len(elems))
+	for _, e := range elems {
+		w.Bulk(e)
+	}
+	return w
+}
+
+// Map writes the header of a map reply with n key-value pairs. The keys and values must be written next,
+// alternating between a key and its value. RESP2 has no map type, so the map is written as an array of 2n elements.
+func (w *ResponseWriter) Map(n int) *ResponseWriter {
+	return w.Array(2 * n)
+}
+
+// Bytes returns the reply written so far, or the part of it that has not been streamed yet.
+func (w *ResponseWriter) Bytes() []byte {
+	return w.buf
+}
+
+// Streamed returns true if part of the reply has been written to the output of a streaming writer.
+func (w *ResponseWriter) Streamed() bool {
+	return w.streamed
+}
+
+// Err returns the first error returned by the output of a streaming writer.
+// Once the output has failed, the rest of the reply is discarded.
+func (w *ResponseWriter) Err() error {
+	return w.err
+}
+
+// flush writes the buffered reply to the output of a streaming writer once it has reached the flush size.
+func (w *ResponseWriter) flush() *ResponseWriter {
+	if w.out == nil || len(w.buf) < w.flushSize {
+		return w
+	}
+	if w.err == nil {
+		_, w.err = w.out.Write(w.buf)
+		w.streamed = true
+	}
+	w.buf = w.buf[:0]
+	return w
+}
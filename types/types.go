@@ -85,6 +85,15 @@ type CommandHandlerFunc func(params CommandHandlerFuncParams) ([]byte, error)
 //
 // SetValue sets the value at the specified key. Make sure to invoke KeyLock on the key before
 // SetValue to ensure thread safety.
+//
+// GetMetadata returns the metadata attached to the TCP connection that triggered the command.
+// It returns false if the key is not set, or if the command was not triggered by a TCP client.
+//
+// SetMetadata attaches metadata such as a tenant ID or trace ID to the TCP connection that triggered the command.
+// Passing a nil value removes the key. The metadata is removed when the connection is closed.
+//
+// Replay is true when the command is replayed from the AOF log on startup. The handler should skip side effects
+// that already happened when the command was first executed, such as notifications and metrics.
 type CommandHandlerFuncParams struct {
 	Context          context.Context
 	Command          []string
@@ -97,4 +106,7 @@ type CommandHandlerFuncParams struct {
 	KeyRUnlock       func(ctx context.Context, key string)
 	GetValue         func(ctx context.Context, key string) interface{}
 	SetValue         func(ctx context.Context, key string, value interface{}) error
+	GetMetadata      func(ctx context.Context, key string) (interface{}, bool)
+	SetMetadata      func(ctx context.Context, key string, value interface{}) error
+	Replay           bool
 }